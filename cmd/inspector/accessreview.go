@@ -0,0 +1,256 @@
+package main
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"regexp"
+	"strings"
+	"text/tabwriter"
+
+	"github.com/spf13/cobra"
+
+	"github.com/comfortablynumb/pmp-cloud-inspector/pkg/access"
+	"github.com/comfortablynumb/pmp-cloud-inspector/pkg/config"
+	"github.com/comfortablynumb/pmp-cloud-inspector/pkg/identity"
+	"github.com/comfortablynumb/pmp-cloud-inspector/pkg/resource"
+)
+
+var (
+	accessReviewInput          string
+	accessReviewConfig         string
+	accessReviewOutput         string
+	accessReviewType           string
+	accessReviewMappings       []string
+	accessReviewMatchUsernames bool
+	accessReviewOwner          string
+	accessReviewPerOwner       bool
+)
+
+var accessReviewCmd = &cobra.Command{
+	Use:   "access-review",
+	Short: "List who can access what across providers, per owner, for access reviews",
+	Long: `Flatten the access grants of an export (has_access relationships) into the effective access
+of every person, for quarterly access reviews:
+
+  AWS      IAM user, group and role attached managed and inline policies
+  GitHub   team repository permissions, for the members of the team
+  GitLab   group membership levels
+  JFrog    permission target actions on repositories, for users and group members
+  Okta     group application assignments, for the members of the group
+  Auth0    user roles, and the permissions of the roles on APIs
+
+Grants are owned by the person of their user account. People are taken from the export when it
+was inspected with --correlate-identities, and are correlated otherwise (see the identities
+command). Grants of principals that are not user accounts, such as IAM roles, are listed under
+the (unassigned) owner.
+
+The CSV and XLSX outputs have an empty decision column to sign off each grant. The XLSX workbook
+has a summary worksheet and a worksheet per owner.
+
+Examples:
+  # Show the owners and their number of grants
+  pmp-cloud-inspector access-review -i export.json
+
+  # Write an XLSX workbook for sign-off, linking accounts with a mapping file
+  pmp-cloud-inspector access-review -i export.json --mapping people.yaml -t xlsx -o access-review.xlsx
+
+  # Write the grants of one person as CSV
+  pmp-cloud-inspector access-review -i export.json -t csv --owner jane@example.com
+
+  # Write a CSV file per owner into a directory
+  pmp-cloud-inspector access-review -i export.json -t csv --per-owner -o reviews/`,
+	RunE: runAccessReview,
+}
+
+func init() {
+	accessReviewCmd.Flags().StringVarP(&accessReviewInput, "input", "i", "", "Export file (JSON)")
+	accessReviewCmd.Flags().StringVarP(&accessReviewConfig, "config", "c", "", "Configuration file providing identity settings (optional)")
+	accessReviewCmd.Flags().StringVarP(&accessReviewOutput, "output", "o", "", "Output file, or directory with --per-owner (defaults to stdout)")
+	accessReviewCmd.Flags().StringVarP(&accessReviewType, "type", "t", "summary", "Output type: summary, csv, xlsx, json")
+	accessReviewCmd.Flags().StringSliceVar(&accessReviewMappings, "mapping", nil, "Identity mapping file, used along with identity.mappings of the config")
+	accessReviewCmd.Flags().BoolVar(&accessReviewMatchUsernames, "match-usernames", false, "Also link accounts with the same normalized username")
+	accessReviewCmd.Flags().StringVar(&accessReviewOwner, "owner", "", "Only include the grants of the owner with this name or email")
+	accessReviewCmd.Flags().BoolVar(&accessReviewPerOwner, "per-owner", false, "Write a file per owner into the output directory (csv, xlsx)")
+	if err := accessReviewCmd.MarkFlagRequired("input"); err != nil {
+		fmt.Fprintf(os.Stderr, "Warning: failed to mark input flag as required: %v\n", err)
+	}
+}
+
+func runAccessReview(cmd *cobra.Command, args []string) error {
+	if accessReviewPerOwner {
+		if accessReviewType != "csv" && accessReviewType != "xlsx" {
+			return fmt.Errorf("--per-owner requires the csv or xlsx output type")
+		}
+		if accessReviewOutput == "" {
+			return fmt.Errorf("--per-owner requires an output directory (-o)")
+		}
+	}
+
+	collection, err := loadExport(accessReviewInput)
+	if err != nil {
+		return fmt.Errorf("failed to load export: %w", err)
+	}
+
+	people := collection.Filter(func(res *resource.Resource) bool {
+		return res.Type == resource.TypeIdentityPerson
+	})
+	if len(people) == 0 {
+		var settings identity.Settings
+		if accessReviewConfig != "" {
+			cfg, err := config.LoadConfig(accessReviewConfig)
+			if err != nil {
+				return fmt.Errorf("failed to load config: %w", err)
+			}
+			settings = cfg.Identity
+		}
+
+		settings.Mappings = append(settings.Mappings, accessReviewMappings...)
+		if accessReviewMatchUsernames {
+			settings.MatchUsernames = true
+		}
+
+		correlator, err := identity.NewCorrelator(settings)
+		if err != nil {
+			return err
+		}
+		people = correlator.Correlate(collection).People
+	}
+
+	report := access.Review(collection, people)
+	if len(report.Grants) == 0 {
+		fmt.Fprintf(os.Stderr, "Warning: the export has no access grants, run inspect with relationships enabled\n")
+	}
+
+	if accessReviewOwner != "" {
+		owner, ok := findOwner(report, accessReviewOwner)
+		if !ok {
+			return fmt.Errorf("no grants found for owner '%s'", accessReviewOwner)
+		}
+		report = report.ForOwner(owner.ID)
+	}
+
+	if accessReviewPerOwner {
+		return writeAccessReviewPerOwner(report, accessReviewOutput, accessReviewType)
+	}
+
+	writer := os.Stdout
+	if accessReviewOutput != "" {
+		// #nosec G304 - accessReviewOutput is provided by user as CLI argument, this is expected behavior
+		writer, err = os.Create(accessReviewOutput)
+		if err != nil {
+			return fmt.Errorf("failed to create output file: %w", err)
+		}
+		defer func() {
+			if closeErr := writer.Close(); closeErr != nil {
+				fmt.Fprintf(os.Stderr, "Warning: failed to close output file: %v\n", closeErr)
+			}
+		}()
+	}
+
+	switch accessReviewType {
+	case "csv":
+		return report.WriteCSV(writer)
+	case "xlsx":
+		return report.WriteXLSX(writer)
+	case "json":
+		encoder := json.NewEncoder(writer)
+		encoder.SetIndent("", "  ")
+		if err := encoder.Encode(report); err != nil {
+			return fmt.Errorf("failed to encode JSON: %w", err)
+		}
+		return nil
+	case "summary":
+		return writeAccessReviewSummary(report, writer)
+	}
+
+	return fmt.Errorf("unsupported output type '%s' (supported: summary, csv, xlsx, json)", accessReviewType)
+}
+
+// findOwner finds an owner of the report by ID, name or email, ignoring case
+func findOwner(report *access.Report, query string) (access.Owner, bool) {
+	for _, owner := range report.Owners {
+		if owner.ID == query || strings.EqualFold(owner.Name, query) || strings.EqualFold(owner.Email, query) {
+			return owner, true
+		}
+	}
+	return access.Owner{}, false
+}
+
+// unsafeFileChars matches the characters replaced in the file names of owners
+var unsafeFileChars = regexp.MustCompile(`[^A-Za-z0-9@._-]+`)
+
+// writeAccessReviewPerOwner writes the grants of each owner to a file of the output directory
+func writeAccessReviewPerOwner(report *access.Report, dir, outputType string) error {
+	if err := os.MkdirAll(dir, 0o750); err != nil {
+		return fmt.Errorf("failed to create output directory: %w", err)
+	}
+
+	used := make(map[string]bool)
+	for _, owner := range report.Owners {
+		base := strings.Trim(unsafeFileChars.ReplaceAllString(owner.Name, "_"), "_.")
+		if base == "" {
+			base = "owner"
+		}
+		name := base
+		for n := 2; used[strings.ToLower(name)]; n++ {
+			name = fmt.Sprintf("%s-%d", base, n)
+		}
+		used[strings.ToLower(name)] = true
+
+		path := filepath.Join(dir, name+"."+outputType)
+		if err := writeOwnerReview(report.ForOwner(owner.ID), path, outputType); err != nil {
+			return err
+		}
+		fmt.Fprintf(os.Stderr, "Wrote %d grants of %s to %s\n", owner.Grants, owner.Name, path)
+	}
+
+	return nil
+}
+
+// writeOwnerReview writes the report of an owner to a file
+func writeOwnerReview(report *access.Report, path, outputType string) (err error) {
+	// #nosec G304 - path is built from the output directory provided by user as CLI argument
+	file, err := os.Create(path)
+	if err != nil {
+		return fmt.Errorf("failed to create output file: %w", err)
+	}
+	defer func() {
+		if closeErr := file.Close(); closeErr != nil && err == nil {
+			err = fmt.Errorf("failed to close output file: %w", closeErr)
+		}
+	}()
+
+	if outputType == "xlsx" {
+		return report.WriteXLSX(file)
+	}
+	return report.WriteCSV(file)
+}
+
+// writeAccessReviewSummary writes a human-readable access review
+func writeAccessReviewSummary(report *access.Report, writer io.Writer) error {
+	fmt.Fprintln(writer, "=== Access Review ===")
+	fmt.Fprintln(writer)
+
+	fmt.Fprintln(writer, "Summary:")
+	fmt.Fprintf(writer, "  Owners:                %d\n", len(report.Owners))
+	fmt.Fprintf(writer, "  Principals:            %d\n", report.Principals)
+	fmt.Fprintf(writer, "  Grants:                %d\n", len(report.Grants))
+	fmt.Fprintf(writer, "  Unassigned grants:     %d\n", report.Unassigned)
+	fmt.Fprintln(writer)
+
+	if len(report.Owners) == 0 {
+		return nil
+	}
+
+	table := tabwriter.NewWriter(writer, 0, 0, 2, ' ', 0)
+	fmt.Fprintln(table, "OWNER\tEMAIL\tGRANTS\tPROVIDERS\tACCOUNTS")
+	for _, owner := range report.Owners {
+		fmt.Fprintf(table, "%s\t%s\t%d\t%s\t%s\n",
+			owner.Name, owner.Email, owner.Grants, strings.Join(owner.Providers, ", "), strings.Join(owner.Accounts, ", "))
+	}
+
+	return table.Flush()
+}
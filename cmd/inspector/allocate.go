@@ -0,0 +1,143 @@
+package main
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"os"
+	"strings"
+	"text/tabwriter"
+
+	"github.com/spf13/cobra"
+
+	"github.com/comfortablynumb/pmp-cloud-inspector/pkg/config"
+	"github.com/comfortablynumb/pmp-cloud-inspector/pkg/cost"
+)
+
+var (
+	allocateInput     string
+	allocateTags      []string
+	allocateConfig    string
+	allocateOutput    string
+	allocateType      string
+	allocateResources bool
+)
+
+var allocateCmd = &cobra.Command{
+	Use:   "allocate",
+	Short: "Allocate estimated costs to owners by tag",
+	Long: `Allocate the estimated cost of every resource of an export to exactly one bucket, using
+a list of tag keys in priority order (e.g., CostCenter, then Team, then Owner).
+
+Resources missing all of the keys inherit them from related resources (a subnet from its VPC,
+a Lambda function from its execution role). Resources still without a key are reported as
+unallocated spend. The export must contain cost estimates (inspect --estimate-costs).
+
+Tag keys are taken from --tags, or from cost.allocation_tags of the configuration file.
+
+Examples:
+  # Show the allocation by cost center, team or owner
+  pmp-cloud-inspector allocate -i export.json --tags CostCenter,Team,Owner
+
+  # Write a CSV showback report using the tag keys of the configuration file
+  pmp-cloud-inspector allocate -i export.json -c config.yaml -t csv -o showback.csv
+
+  # Write the allocation of every resource as CSV
+  pmp-cloud-inspector allocate -i export.json --tags Team -t csv --resources -o resources.csv`,
+	RunE: runAllocate,
+}
+
+func init() {
+	allocateCmd.Flags().StringVarP(&allocateInput, "input", "i", "", "Export file with cost estimates (JSON)")
+	allocateCmd.Flags().StringSliceVar(&allocateTags, "tags", nil, "Allocation tag keys in priority order")
+	allocateCmd.Flags().StringVarP(&allocateConfig, "config", "c", "", "Configuration file providing cost.allocation_tags (optional)")
+	allocateCmd.Flags().StringVarP(&allocateOutput, "output", "o", "", "Output file (defaults to stdout)")
+	allocateCmd.Flags().StringVarP(&allocateType, "type", "t", "summary", "Output type: summary, csv, json")
+	allocateCmd.Flags().BoolVar(&allocateResources, "resources", false, "Write one CSV row per resource instead of per bucket")
+	if err := allocateCmd.MarkFlagRequired("input"); err != nil {
+		fmt.Fprintf(os.Stderr, "Warning: failed to mark input flag as required: %v\n", err)
+	}
+}
+
+func runAllocate(cmd *cobra.Command, args []string) error {
+	tagKeys := allocateTags
+	if len(tagKeys) == 0 && allocateConfig != "" {
+		cfg, err := config.LoadConfig(allocateConfig)
+		if err != nil {
+			return fmt.Errorf("failed to load config: %w", err)
+		}
+		tagKeys = cfg.Cost.AllocationTags
+	}
+	if len(tagKeys) == 0 {
+		return fmt.Errorf("no allocation tag keys given (use --tags or cost.allocation_tags in the config)")
+	}
+
+	collection, err := loadExport(allocateInput)
+	if err != nil {
+		return fmt.Errorf("failed to load export: %w", err)
+	}
+
+	report := cost.Allocate(collection, tagKeys)
+	if len(report.Resources) == 0 {
+		fmt.Fprintf(os.Stderr, "Warning: the export has no cost estimates, run inspect with --estimate-costs\n")
+	}
+
+	writer := os.Stdout
+	if allocateOutput != "" {
+		// #nosec G304 - allocateOutput is provided by user as CLI argument, this is expected behavior
+		writer, err = os.Create(allocateOutput)
+		if err != nil {
+			return fmt.Errorf("failed to create output file: %w", err)
+		}
+		defer func() {
+			if closeErr := writer.Close(); closeErr != nil {
+				fmt.Fprintf(os.Stderr, "Warning: failed to close output file: %v\n", closeErr)
+			}
+		}()
+	}
+
+	switch allocateType {
+	case "csv":
+		if allocateResources {
+			return report.WriteResourcesCSV(writer)
+		}
+		return report.WriteCSV(writer)
+	case "json":
+		encoder := json.NewEncoder(writer)
+		encoder.SetIndent("", "  ")
+		if err := encoder.Encode(report); err != nil {
+			return fmt.Errorf("failed to encode JSON: %w", err)
+		}
+		return nil
+	case "summary":
+		return writeAllocationSummary(report, writer)
+	}
+
+	return fmt.Errorf("unsupported output type '%s' (supported: summary, csv, json)", allocateType)
+}
+
+// writeAllocationSummary writes a human-readable allocation report
+func writeAllocationSummary(report *cost.AllocationReport, writer io.Writer) error {
+	fmt.Fprintln(writer, "=== Cost Allocation Report ===")
+	fmt.Fprintf(writer, "Tag keys: %s\n", strings.Join(report.TagKeys, ", "))
+	fmt.Fprintln(writer)
+
+	fmt.Fprintln(writer, "Summary:")
+	fmt.Fprintf(writer, "  Total:       %.2f %s\n", report.Total, report.Currency)
+	fmt.Fprintf(writer, "  Allocated:   %.2f %s\n", report.Allocated, report.Currency)
+	fmt.Fprintf(writer, "  Unallocated: %.2f %s\n", report.Unallocated, report.Currency)
+	fmt.Fprintln(writer)
+
+	if len(report.Buckets) == 0 {
+		return nil
+	}
+
+	table := tabwriter.NewWriter(writer, 0, 0, 2, ' ', 0)
+	fmt.Fprintln(table, "TAG\tVALUE\tCOST\tSHARE\tRESOURCES\tINHERITED")
+	for _, bucket := range report.Buckets {
+		fmt.Fprintf(table, "%s\t%s\t%.2f\t%.1f%%\t%d\t%d\n",
+			bucket.TagKey, bucket.Value, bucket.Cost, bucket.Share*100, bucket.Resources, bucket.Inherited)
+	}
+
+	return table.Flush()
+}
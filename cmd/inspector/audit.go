@@ -0,0 +1,181 @@
+package main
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"os"
+	"strings"
+	"text/tabwriter"
+
+	"github.com/spf13/cobra"
+
+	"github.com/comfortablynumb/pmp-cloud-inspector/pkg/config"
+	"github.com/comfortablynumb/pmp-cloud-inspector/pkg/policy"
+)
+
+var (
+	auditInput       string
+	auditConfig      string
+	auditOutput      string
+	auditType        string
+	auditRules       []string
+	auditNoBuiltin   bool
+	auditDisable     []string
+	auditMinSeverity string
+	auditFailOn      string
+)
+
+var auditCmd = &cobra.Command{
+	Use:   "audit",
+	Short: "Evaluate compliance rules against an export",
+	Long: `Evaluate security and hygiene rules against an export and report the resources that
+fail them, with the rule, severity and remediation of each finding.
+
+Rules are written in YAML: a selector choosing the resources a rule applies to, and checks
+written in the filter expression language over the resource, the items of its list
+properties and its neighbors in the relationship graph. A built-in rule pack covers the
+resource types collected by every provider; rule files add to it, and replace built-in rules
+with the same ID.
+
+Examples:
+  # Audit an export with the built-in rules
+  pmp-cloud-inspector audit -i export.json
+
+  # Add custom rules and only report high and critical findings
+  pmp-cloud-inspector audit -i export.json --rules ./policies --min-severity high
+
+  # Write a SARIF log for code scanning and fail on critical findings
+  pmp-cloud-inspector audit -i export.json -t sarif -o audit.sarif --fail-on critical`,
+	RunE: runAudit,
+}
+
+func init() {
+	auditCmd.Flags().StringVarP(&auditInput, "input", "i", "", "Export file (JSON)")
+	auditCmd.Flags().StringVarP(&auditConfig, "config", "c", "", "Configuration file providing policy settings (optional)")
+	auditCmd.Flags().StringVarP(&auditOutput, "output", "o", "", "Output file (defaults to stdout)")
+	auditCmd.Flags().StringVarP(&auditType, "type", "t", "summary", "Output type: summary, json, sarif")
+	auditCmd.Flags().StringSliceVar(&auditRules, "rules", nil, "Rule file or directory, evaluated along with policy.rules of the config")
+	auditCmd.Flags().BoolVar(&auditNoBuiltin, "no-builtin", false, "Do not evaluate the built-in rules")
+	auditCmd.Flags().StringSliceVar(&auditDisable, "disable", nil, "IDs of rules not to evaluate")
+	auditCmd.Flags().StringVar(&auditMinSeverity, "min-severity", "", "Only evaluate rules of this severity or higher: info, low, medium, high, critical")
+	auditCmd.Flags().StringVar(&auditFailOn, "fail-on", "", "Exit with an error if a finding of this severity or higher is found (overrides policy.fail_on of the config)")
+	if err := auditCmd.MarkFlagRequired("input"); err != nil {
+		fmt.Fprintf(os.Stderr, "Warning: failed to mark input flag as required: %v\n", err)
+	}
+}
+
+func runAudit(cmd *cobra.Command, args []string) error {
+	var settings policy.Settings
+	if auditConfig != "" {
+		cfg, err := config.LoadConfig(auditConfig)
+		if err != nil {
+			return fmt.Errorf("failed to load config: %w", err)
+		}
+		settings = cfg.Policy
+	}
+
+	settings.Rules = append(settings.Rules, auditRules...)
+	settings.Disabled = append(settings.Disabled, auditDisable...)
+	if auditNoBuiltin {
+		settings.DisableBuiltin = true
+	}
+	if auditMinSeverity != "" {
+		settings.MinSeverity = auditMinSeverity
+	}
+	if auditFailOn != "" {
+		settings.FailOn = auditFailOn
+	}
+
+	engine, err := policy.NewEngine(settings)
+	if err != nil {
+		return err
+	}
+
+	collection, err := loadExport(auditInput)
+	if err != nil {
+		return fmt.Errorf("failed to load export: %w", err)
+	}
+
+	report := engine.Evaluate(collection)
+
+	writer := os.Stdout
+	if auditOutput != "" {
+		// #nosec G304 - auditOutput is provided by user as CLI argument, this is expected behavior
+		writer, err = os.Create(auditOutput)
+		if err != nil {
+			return fmt.Errorf("failed to create output file: %w", err)
+		}
+		defer func() {
+			if closeErr := writer.Close(); closeErr != nil {
+				fmt.Fprintf(os.Stderr, "Warning: failed to close output file: %v\n", closeErr)
+			}
+		}()
+	}
+
+	switch auditType {
+	case "json":
+		encoder := json.NewEncoder(writer)
+		encoder.SetIndent("", "  ")
+		if err := encoder.Encode(report); err != nil {
+			return fmt.Errorf("failed to encode JSON: %w", err)
+		}
+	case "sarif":
+		err = report.WriteSARIF(writer)
+	case "summary":
+		err = writeAuditSummary(report, writer)
+	default:
+		return fmt.Errorf("unsupported output type '%s' (supported: summary, json, sarif)", auditType)
+	}
+	if err != nil {
+		return err
+	}
+
+	if settings.FailOn != "" {
+		if failing := report.FindingsAtLeast(settings.FailOn); len(failing) > 0 {
+			// The report was written, the error is the outcome rather than a usage problem
+			cmd.SilenceUsage = true
+			return fmt.Errorf("%d findings of %s severity or higher", len(failing), strings.ToLower(settings.FailOn))
+		}
+	}
+
+	return nil
+}
+
+// writeAuditSummary writes a human-readable audit report
+func writeAuditSummary(report *policy.Report, writer io.Writer) error {
+	passed := 0
+	for _, rule := range report.Rules {
+		if rule.Findings == 0 {
+			passed++
+		}
+	}
+
+	fmt.Fprintln(writer, "=== Policy Audit ===")
+	fmt.Fprintln(writer)
+
+	fmt.Fprintln(writer, "Summary:")
+	fmt.Fprintf(writer, "  Resources:  %d\n", report.Resources)
+	fmt.Fprintf(writer, "  Rules:      %d (%d passed)\n", len(report.Rules), passed)
+	fmt.Fprintf(writer, "  Findings:   %d\n", len(report.Findings))
+	for i := len(policy.Severities) - 1; i >= 0; i-- {
+		severity := policy.Severities[i]
+		if count := report.BySeverity[severity]; count > 0 {
+			fmt.Fprintf(writer, "  %-11s %d\n", severity+":", count)
+		}
+	}
+	fmt.Fprintln(writer)
+
+	if len(report.Findings) == 0 {
+		return nil
+	}
+
+	table := tabwriter.NewWriter(writer, 0, 0, 2, ' ', 0)
+	fmt.Fprintln(table, "SEVERITY\tRULE\tRESOURCE\tTYPE\tREGION\tMESSAGE")
+	for _, finding := range report.Findings {
+		fmt.Fprintf(table, "%s\t%s\t%s\t%s\t%s\t%s\n",
+			finding.Severity, finding.RuleID, finding.Name, finding.ResourceType, finding.Region, finding.Message)
+	}
+
+	return table.Flush()
+}
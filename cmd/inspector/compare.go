@@ -3,19 +3,34 @@ package main
 import (
 	"encoding/json"
 	"fmt"
+	"io"
 	"os"
 	"reflect"
+	"sort"
+	"strings"
+	"text/tabwriter"
 	"time"
 
 	"github.com/spf13/cobra"
 
+	"github.com/comfortablynumb/pmp-cloud-inspector/pkg/config"
+	"github.com/comfortablynumb/pmp-cloud-inspector/pkg/cost"
+	"github.com/comfortablynumb/pmp-cloud-inspector/pkg/filter"
 	"github.com/comfortablynumb/pmp-cloud-inspector/pkg/resource"
 )
 
 var (
-	baseFile    string
-	compareFile string
-	outputType  string
+	baseFile             string
+	compareFile          string
+	outputType           string
+	compareWhere         string
+	compareCost          bool
+	compareCostThreshold float64
+	compareTop           int
+	compareTagKeys       []string
+	compareConfig        string
+	compareCurrency      string
+	compareExchangeRates string
 )
 
 var compareCmd = &cobra.Command{
@@ -29,14 +44,31 @@ Examples:
   pmp-cloud-inspector compare -b export1.json -c export2.json
 
   # Compare with detailed output
-  pmp-cloud-inspector compare -b export1.json -c export2.json -t detailed`,
+  pmp-cloud-inspector compare -b export1.json -c export2.json -t detailed
+
+  # Report the cost change, flagging changes of 20% or more
+  pmp-cloud-inspector compare -b export1.json -c export2.json --cost --cost-threshold 20
+
+  # Cost change as Markdown (e.g., for a pull request comment)
+  pmp-cloud-inspector compare -b export1.json -c export2.json --cost -t markdown
+
+  # Cost change of exports estimated in different currencies, converted to EUR
+  pmp-cloud-inspector compare -b export1.json -c export2.json --cost --currency EUR --exchange-rates rates.yaml`,
 	RunE: runCompare,
 }
 
 func init() {
 	compareCmd.Flags().StringVarP(&baseFile, "base", "b", "", "Base export file (older snapshot)")
 	compareCmd.Flags().StringVarP(&compareFile, "compare", "c", "", "Compare export file (newer snapshot)")
-	compareCmd.Flags().StringVarP(&outputType, "type", "t", "summary", "Output type: summary, detailed, json")
+	compareCmd.Flags().StringVarP(&outputType, "type", "t", "summary", "Output type: summary, detailed, json (with --cost: summary, json, markdown)")
+	compareCmd.Flags().StringVar(&compareWhere, "where", "", "Only compare resources matching this filter expression")
+	compareCmd.Flags().BoolVar(&compareCost, "cost", false, "Report the cost change between the exports instead of the resource drift")
+	compareCmd.Flags().Float64Var(&compareCostThreshold, "cost-threshold", 0, "Flag cost changes of at least this percentage (defaults to cost.delta_threshold of the config)")
+	compareCmd.Flags().IntVar(&compareTop, "top", cost.DefaultDeltaTop, "Number of resources reported as cost drivers (-1 = all)")
+	compareCmd.Flags().StringSliceVar(&compareTagKeys, "tag-keys", nil, "Tag keys reported in the cost change by tag (default: all tags)")
+	compareCmd.Flags().StringVar(&compareConfig, "config", "", "Configuration file providing cost.delta_threshold and the cost currency settings (optional)")
+	compareCmd.Flags().StringVar(&compareCurrency, "currency", "", "Currency both exports are converted to before the cost change is computed (overrides cost.currency of the config)")
+	compareCmd.Flags().StringVar(&compareExchangeRates, "exchange-rates", "", "Exchange rates file (JSON or YAML) used with --currency (overrides cost.exchange_rates of the config)")
 	if err := compareCmd.MarkFlagRequired("base"); err != nil {
 		fmt.Fprintf(os.Stderr, "Warning: failed to mark base flag as required: %v\n", err)
 	}
@@ -92,6 +124,20 @@ func runCompare(cmd *cobra.Command, args []string) error {
 		return fmt.Errorf("failed to load compare export: %w", err)
 	}
 
+	// Restrict both exports to matching resources
+	if compareWhere != "" {
+		f, parseErr := filter.ParseExpression(compareWhere)
+		if parseErr != nil {
+			return fmt.Errorf("invalid where expression: %w", parseErr)
+		}
+		baseCollection = filter.ApplyFilters(baseCollection, f)
+		compareCollection = filter.ApplyFilters(compareCollection, f)
+	}
+
+	if compareCost {
+		return runCompareCost(baseCollection, compareCollection)
+	}
+
 	// Generate drift report
 	report := generateDriftReport(baseCollection, compareCollection)
 
@@ -319,3 +365,231 @@ func formatValue(v interface{}) string {
 		return fmt.Sprintf("%v", v)
 	}
 }
+
+func runCompareCost(base, compare *resource.Collection) error {
+	var costConfig config.CostConfig
+	if compareConfig != "" {
+		cfg, err := config.LoadConfig(compareConfig)
+		if err != nil {
+			return fmt.Errorf("failed to load config: %w", err)
+		}
+		costConfig = cfg.Cost
+	}
+
+	threshold := compareCostThreshold
+	if threshold == 0 {
+		threshold = costConfig.DeltaThreshold
+	}
+
+	// Both exports are converted to the same currency, if one is given
+	target, rates, err := resolveCostCurrency(compareCurrency, compareExchangeRates, costConfig)
+	if err != nil {
+		return err
+	}
+	if rates != nil {
+		fmt.Fprintf(os.Stderr, "Converting costs to %s using exchange rates %s\n", target, rates.Version)
+		for _, collection := range []*resource.Collection{base, compare} {
+			if err := cost.ConvertCollection(collection, target, rates); err != nil {
+				return fmt.Errorf("failed to convert costs: %w", err)
+			}
+		}
+	}
+
+	report, err := cost.Delta(base, compare, cost.DeltaOptions{
+		ThresholdPercent: threshold,
+		Top:              compareTop,
+		TagKeys:          compareTagKeys,
+	})
+	if err != nil {
+		return fmt.Errorf("%w (use --currency with --exchange-rates)", err)
+	}
+
+	switch outputType {
+	case "json":
+		encoder := json.NewEncoder(os.Stdout)
+		encoder.SetIndent("", "  ")
+		if err := encoder.Encode(report); err != nil {
+			return fmt.Errorf("failed to encode JSON: %w", err)
+		}
+		return nil
+	case "markdown":
+		return outputCostMarkdown(report, os.Stdout)
+	default:
+		return outputCostSummary(report, os.Stdout)
+	}
+}
+
+// costDimensions lists the cost change dimensions in output order
+func costDimensions(report *cost.DeltaReport) []struct {
+	title   string
+	entries []cost.DeltaEntry
+} {
+	return []struct {
+		title   string
+		entries []cost.DeltaEntry
+	}{
+		{"Provider", report.ByProvider},
+		{"Account", report.ByAccount},
+		{"Region", report.ByRegion},
+		{"Type", report.ByType},
+		{"Tag", report.ByTag},
+	}
+}
+
+// formatDelta formats a cost change with its sign
+func formatDelta(delta float64) string {
+	return fmt.Sprintf("%+.2f", delta)
+}
+
+// formatPercent formats the percentage of a cost change, "new" for a change from zero
+func formatPercent(base, percent float64) string {
+	if base == 0 {
+		return "new"
+	}
+	return fmt.Sprintf("%+.1f%%", percent)
+}
+
+// flagMark marks a flagged change
+func flagMark(flagged bool) string {
+	if flagged {
+		return "!"
+	}
+	return ""
+}
+
+func outputCostSummary(report *cost.DeltaReport, writer io.Writer) error {
+	fmt.Fprintln(writer, "=== Cloud Cost Change Report ===")
+	fmt.Fprintf(writer, "Base total:    %.2f %s\n", report.BaseTotal, report.Currency)
+	fmt.Fprintf(writer, "Compare total: %.2f %s\n", report.CompareTotal, report.Currency)
+	fmt.Fprintf(writer, "Delta:         %s %s (%s) %s\n", formatDelta(report.Delta), report.Currency,
+		formatPercent(report.BaseTotal, report.Percent), flagMark(report.Flagged))
+	if report.ThresholdPercent > 0 {
+		fmt.Fprintf(writer, "Threshold:     %.1f%% (changes at or above it are marked with !)\n", report.ThresholdPercent)
+	}
+	fmt.Fprintln(writer)
+
+	fmt.Fprintln(writer, "Summary:")
+	fmt.Fprintf(writer, "  Added:   %d resources (%s)\n", report.Added.Count, formatDelta(report.Added.Delta))
+	fmt.Fprintf(writer, "  Removed: %d resources (%s)\n", report.Removed.Count, formatDelta(report.Removed.Delta))
+	fmt.Fprintf(writer, "  Resized: %d resources (%s)\n", report.Resized.Count, formatDelta(report.Resized.Delta))
+	fmt.Fprintf(writer, "  Changed: %d resources (%s)\n", report.Changed.Count, formatDelta(report.Changed.Delta))
+	fmt.Fprintln(writer)
+
+	for _, dimension := range costDimensions(report) {
+		if len(dimension.entries) == 0 {
+			continue
+		}
+
+		fmt.Fprintf(writer, "By %s:\n", dimension.title)
+		table := tabwriter.NewWriter(writer, 0, 0, 2, ' ', 0)
+		fmt.Fprintln(table, "  KEY\tBASE\tCOMPARE\tDELTA\tCHANGE\t")
+		for _, entry := range dimension.entries {
+			fmt.Fprintf(table, "  %s\t%.2f\t%.2f\t%s\t%s\t%s\n", entry.Key, entry.Base, entry.Compare,
+				formatDelta(entry.Delta), formatPercent(entry.Base, entry.Percent), flagMark(entry.Flagged))
+		}
+		if err := table.Flush(); err != nil {
+			return err
+		}
+		fmt.Fprintln(writer)
+	}
+
+	if len(report.Drivers) > 0 {
+		fmt.Fprintf(writer, "Top Cost Drivers (%d):\n", len(report.Drivers))
+		table := tabwriter.NewWriter(writer, 0, 0, 2, ' ', 0)
+		fmt.Fprintln(table, "  CHANGE\tRESOURCE\tTYPE\tBASE\tCOMPARE\tDELTA\tDETAILS\t")
+		for _, driver := range report.Drivers {
+			fmt.Fprintf(table, "  %s\t%s\t%s\t%.2f\t%.2f\t%s\t%s\t%s\n", driver.Change, driver.Name, driver.ResourceType,
+				driver.Base, driver.Compare, formatDelta(driver.Delta), formatResized(driver), flagMark(driver.Flagged))
+		}
+		if err := table.Flush(); err != nil {
+			return err
+		}
+		fmt.Fprintln(writer)
+	}
+
+	return nil
+}
+
+func outputCostMarkdown(report *cost.DeltaReport, writer io.Writer) error {
+	fmt.Fprintln(writer, "## Cloud Cost Change Report")
+	fmt.Fprintln(writer)
+	fmt.Fprintln(writer, "| | Monthly cost |")
+	fmt.Fprintln(writer, "|---|---:|")
+	fmt.Fprintf(writer, "| Base | %.2f %s |\n", report.BaseTotal, report.Currency)
+	fmt.Fprintf(writer, "| Compare | %.2f %s |\n", report.CompareTotal, report.Currency)
+	fmt.Fprintf(writer, "| **Delta** | **%s %s (%s)**%s |\n", formatDelta(report.Delta), report.Currency,
+		formatPercent(report.BaseTotal, report.Percent), markdownFlag(report.Flagged))
+	fmt.Fprintln(writer)
+
+	if report.ThresholdPercent > 0 {
+		fmt.Fprintf(writer, "Changes of %.1f%% or more are marked with :warning:.\n\n", report.ThresholdPercent)
+	}
+
+	fmt.Fprintln(writer, "| Change | Resources | Delta |")
+	fmt.Fprintln(writer, "|---|---:|---:|")
+	fmt.Fprintf(writer, "| Added | %d | %s |\n", report.Added.Count, formatDelta(report.Added.Delta))
+	fmt.Fprintf(writer, "| Removed | %d | %s |\n", report.Removed.Count, formatDelta(report.Removed.Delta))
+	fmt.Fprintf(writer, "| Resized | %d | %s |\n", report.Resized.Count, formatDelta(report.Resized.Delta))
+	fmt.Fprintf(writer, "| Changed | %d | %s |\n", report.Changed.Count, formatDelta(report.Changed.Delta))
+	fmt.Fprintln(writer)
+
+	for _, dimension := range costDimensions(report) {
+		if len(dimension.entries) == 0 {
+			continue
+		}
+
+		fmt.Fprintf(writer, "### By %s\n\n", dimension.title)
+		fmt.Fprintf(writer, "| %s | Base | Compare | Delta | Change |\n", dimension.title)
+		fmt.Fprintln(writer, "|---|---:|---:|---:|---:|")
+		for _, entry := range dimension.entries {
+			fmt.Fprintf(writer, "| %s | %.2f | %.2f | %s | %s%s |\n", markdownEscape(entry.Key), entry.Base, entry.Compare,
+				formatDelta(entry.Delta), formatPercent(entry.Base, entry.Percent), markdownFlag(entry.Flagged))
+		}
+		fmt.Fprintln(writer)
+	}
+
+	if len(report.Drivers) > 0 {
+		fmt.Fprintln(writer, "### Top Cost Drivers")
+		fmt.Fprintln(writer)
+		fmt.Fprintln(writer, "| Change | Resource | Type | Base | Compare | Delta | Details |")
+		fmt.Fprintln(writer, "|---|---|---|---:|---:|---:|---|")
+		for _, driver := range report.Drivers {
+			fmt.Fprintf(writer, "| %s | %s | %s | %.2f | %.2f | %s%s | %s |\n", driver.Change, markdownEscape(driver.Name),
+				driver.ResourceType, driver.Base, driver.Compare, formatDelta(driver.Delta), markdownFlag(driver.Flagged),
+				markdownEscape(formatResized(driver)))
+		}
+		fmt.Fprintln(writer)
+	}
+
+	return nil
+}
+
+// formatResized describes the size changes of a resized resource, e.g. instance_type: t3.small -> t3.large
+func formatResized(driver cost.ResourceCostChange) string {
+	names := make([]string, 0, len(driver.Resized))
+	for name := range driver.Resized {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+
+	parts := make([]string, 0, len(names))
+	for _, name := range names {
+		values := driver.Resized[name]
+		parts = append(parts, fmt.Sprintf("%s: %s -> %s", name, formatValue(values[0]), formatValue(values[1])))
+	}
+
+	return strings.Join(parts, ", ")
+}
+
+// markdownFlag marks a flagged change in Markdown
+func markdownFlag(flagged bool) string {
+	if flagged {
+		return " :warning:"
+	}
+	return ""
+}
+
+// markdownEscape escapes the characters that would break a Markdown table cell
+func markdownEscape(value string) string {
+	return strings.ReplaceAll(value, "|", "\\|")
+}
@@ -0,0 +1,200 @@
+package main
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"os"
+	"strings"
+	"text/tabwriter"
+
+	"github.com/spf13/cobra"
+
+	"github.com/comfortablynumb/pmp-cloud-inspector/pkg/exposure"
+)
+
+var (
+	exposureInput       string
+	exposureOutput      string
+	exposureType        string
+	exposureMinSeverity string
+	exposureFailOn      string
+)
+
+var exposureCmd = &cobra.Command{
+	Use:   "exposure",
+	Short: "Analyze the internet exposure of security groups and the resources using them",
+	Long: `Find the security groups with ingress rules open to the internet (0.0.0.0/0 or ::/0), the
+resources attached to them, and whether those resources are actually reachable from the
+internet: instances with a public IP, internet-facing load balancers and EKS clusters with a
+public API endpoint.
+
+Severities:
+  critical  reachable from the internet on every port
+  high      reachable from the internet on a sensitive port (SSH, RDP, database ports)
+  medium    reachable from the internet on other ports
+  low       attached to an open security group, but not reachable from the internet
+
+The report also lists the security groups allowing traffic from other security groups, flagging
+the rules whose source group is attached to resources reachable from the internet.
+
+Examples:
+  # Show the exposure of an export
+  pmp-cloud-inspector exposure -i export.json
+
+  # Only show resources reachable on sensitive ports, and fail if there is any
+  pmp-cloud-inspector exposure -i export.json --min-severity high --fail-on high
+
+  # Write the full report as JSON
+  pmp-cloud-inspector exposure -i export.json -t json -o exposure.json`,
+	RunE: runExposure,
+}
+
+func init() {
+	exposureCmd.Flags().StringVarP(&exposureInput, "input", "i", "", "Export file (JSON)")
+	exposureCmd.Flags().StringVarP(&exposureOutput, "output", "o", "", "Output file (defaults to stdout)")
+	exposureCmd.Flags().StringVarP(&exposureType, "type", "t", "summary", "Output type: summary, json")
+	exposureCmd.Flags().StringVar(&exposureMinSeverity, "min-severity", "", "Only list resources of this severity or higher: low, medium, high, critical")
+	exposureCmd.Flags().StringVar(&exposureFailOn, "fail-on", "", "Exit with an error if a resource of this severity or higher is found")
+	if err := exposureCmd.MarkFlagRequired("input"); err != nil {
+		fmt.Fprintf(os.Stderr, "Warning: failed to mark input flag as required: %v\n", err)
+	}
+}
+
+func runExposure(cmd *cobra.Command, args []string) error {
+	for _, severity := range []string{exposureMinSeverity, exposureFailOn} {
+		if severity != "" && exposure.SeverityRank(severity) == 0 {
+			return fmt.Errorf("invalid severity '%s' (supported: %s)", severity, strings.Join(exposure.Severities, ", "))
+		}
+	}
+
+	collection, err := loadExport(exposureInput)
+	if err != nil {
+		return fmt.Errorf("failed to load export: %w", err)
+	}
+
+	report := exposure.Analyze(collection)
+
+	failing := 0
+	listed := report.Resources[:0:0]
+	for _, res := range report.Resources {
+		rank := exposure.SeverityRank(res.Severity)
+		if exposureFailOn != "" && rank >= exposure.SeverityRank(exposureFailOn) {
+			failing++
+		}
+		if rank >= exposure.SeverityRank(exposureMinSeverity) {
+			listed = append(listed, res)
+		}
+	}
+	report.Resources = listed
+
+	writer := os.Stdout
+	if exposureOutput != "" {
+		// #nosec G304 - exposureOutput is provided by user as CLI argument, this is expected behavior
+		writer, err = os.Create(exposureOutput)
+		if err != nil {
+			return fmt.Errorf("failed to create output file: %w", err)
+		}
+		defer func() {
+			if closeErr := writer.Close(); closeErr != nil {
+				fmt.Fprintf(os.Stderr, "Warning: failed to close output file: %v\n", closeErr)
+			}
+		}()
+	}
+
+	switch exposureType {
+	case "json":
+		encoder := json.NewEncoder(writer)
+		encoder.SetIndent("", "  ")
+		if err := encoder.Encode(report); err != nil {
+			return fmt.Errorf("failed to encode JSON: %w", err)
+		}
+	case "summary":
+		if err := writeExposureSummary(report, writer); err != nil {
+			return err
+		}
+	default:
+		return fmt.Errorf("unsupported output type '%s' (supported: summary, json)", exposureType)
+	}
+
+	if failing > 0 {
+		// The report was written, the error is the outcome rather than a usage problem
+		cmd.SilenceUsage = true
+		return fmt.Errorf("%d resources of %s exposure or higher", failing, strings.ToLower(exposureFailOn))
+	}
+
+	return nil
+}
+
+// writeExposureSummary writes a human-readable exposure report
+func writeExposureSummary(report *exposure.Report, writer io.Writer) error {
+	fmt.Fprintln(writer, "=== Internet Exposure ===")
+	fmt.Fprintln(writer)
+
+	fmt.Fprintln(writer, "Summary:")
+	fmt.Fprintf(writer, "  Open security groups:  %d (%d exposing sensitive ports)\n", report.OpenGroups, report.SensitiveGroups)
+	fmt.Fprintf(writer, "  Exposed resources:     %d\n", report.ExposedResources)
+	for i := len(exposure.Severities) - 1; i >= 0; i-- {
+		severity := exposure.Severities[i]
+		if count := report.BySeverity[severity]; count > 0 {
+			fmt.Fprintf(writer, "  %-22s %d\n", severity+":", count)
+		}
+	}
+	fmt.Fprintln(writer)
+
+	table := tabwriter.NewWriter(writer, 0, 0, 2, ' ', 0)
+
+	if len(report.Resources) > 0 {
+		fmt.Fprintln(writer, "Resources:")
+		fmt.Fprintln(table, "SEVERITY\tRESOURCE\tTYPE\tREGION\tOPEN PORTS\tREASON")
+		for _, res := range report.Resources {
+			ports := strings.Join(res.OpenPorts, ", ")
+			if len(res.SensitivePorts) > 0 {
+				ports += " (" + strings.Join(res.SensitivePorts, ", ") + ")"
+			}
+			fmt.Fprintf(table, "%s\t%s\t%s\t%s\t%s\t%s\n", res.Severity, res.Name, res.Type, res.Region, ports, res.Reason)
+		}
+		if err := table.Flush(); err != nil {
+			return err
+		}
+		fmt.Fprintln(writer)
+	}
+
+	if len(report.Groups) > 0 {
+		fmt.Fprintln(writer, "Open security groups:")
+		fmt.Fprintln(table, "GROUP\tNAME\tREGION\tOPEN RULES\tATTACHED\tPUBLIC")
+		for _, group := range report.Groups {
+			rules := make([]string, 0, len(group.OpenRules))
+			for _, rule := range group.OpenRules {
+				rules = append(rules, rule.Ports+" from "+rule.Source)
+			}
+			fmt.Fprintf(table, "%s\t%s\t%s\t%s\t%d\t%d\n",
+				group.ID, group.Name, group.Region, strings.Join(rules, ", "), len(group.Attached), len(group.Public))
+		}
+		if err := table.Flush(); err != nil {
+			return err
+		}
+		fmt.Fprintln(writer)
+	}
+
+	if len(report.References) > 0 {
+		fmt.Fprintln(writer, "Security group references:")
+		fmt.Fprintln(table, "GROUP\tALLOWS\tPORTS\tSOURCE EXPOSED")
+		for _, ref := range report.References {
+			source := ref.Source
+			if ref.SourceName != "" {
+				source = fmt.Sprintf("%s (%s)", ref.Source, ref.SourceName)
+			}
+			exposed := "no"
+			if ref.SourceExposed {
+				exposed = "yes"
+			}
+			fmt.Fprintf(table, "%s (%s)\t%s\t%s\t%s\n", ref.Group, ref.GroupName, source, ref.Ports, exposed)
+		}
+		if err := table.Flush(); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
@@ -0,0 +1,189 @@
+package main
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"os"
+	"strings"
+	"text/tabwriter"
+
+	"github.com/spf13/cobra"
+
+	"github.com/comfortablynumb/pmp-cloud-inspector/pkg/config"
+	"github.com/comfortablynumb/pmp-cloud-inspector/pkg/identity"
+	"github.com/comfortablynumb/pmp-cloud-inspector/pkg/resource"
+)
+
+var (
+	identitiesInput          string
+	identitiesConfig         string
+	identitiesOutput         string
+	identitiesType           string
+	identitiesMappings       []string
+	identitiesMatchUsernames bool
+)
+
+var identitiesCmd = &cobra.Command{
+	Use:   "identities",
+	Short: "Correlate user accounts across providers and report orphans",
+	Long: `Link the user accounts of AWS IAM, GitHub, GitLab, JFrog, Okta and Auth0 that belong to the
+same person, by email, by username (with --match-usernames) and by mapping files, and report
+the accounts that need review:
+
+  orphans       active AWS, GitHub, GitLab and JFrog accounts without an Okta counterpart
+  deactivated   people deactivated or suspended in Okta with active accounts elsewhere
+  multi-admin   people with administrator privileges in several providers
+
+Orphans are only reported for exports with Okta users.
+
+Examples:
+  # Report the accounts of an export that need review
+  pmp-cloud-inspector identities -i export.json
+
+  # Link accounts with a mapping file and by username
+  pmp-cloud-inspector identities -i export.json --mapping people.yaml --match-usernames
+
+  # Write the people and the report as JSON
+  pmp-cloud-inspector identities -i export.json -c config.yaml -t json -o identities.json`,
+	RunE: runIdentities,
+}
+
+func init() {
+	identitiesCmd.Flags().StringVarP(&identitiesInput, "input", "i", "", "Export file (JSON)")
+	identitiesCmd.Flags().StringVarP(&identitiesConfig, "config", "c", "", "Configuration file providing identity settings (optional)")
+	identitiesCmd.Flags().StringVarP(&identitiesOutput, "output", "o", "", "Output file (defaults to stdout)")
+	identitiesCmd.Flags().StringVarP(&identitiesType, "type", "t", "summary", "Output type: summary, json")
+	identitiesCmd.Flags().StringSliceVar(&identitiesMappings, "mapping", nil, "Identity mapping file, used along with identity.mappings of the config")
+	identitiesCmd.Flags().BoolVar(&identitiesMatchUsernames, "match-usernames", false, "Also link accounts with the same normalized username")
+	if err := identitiesCmd.MarkFlagRequired("input"); err != nil {
+		fmt.Fprintf(os.Stderr, "Warning: failed to mark input flag as required: %v\n", err)
+	}
+}
+
+// identitiesOutputJSON is the JSON output of the identities command
+type identitiesOutputJSON struct {
+	People []*resource.Resource `json:"people"`
+	Report *identity.Report     `json:"report"`
+}
+
+func runIdentities(cmd *cobra.Command, args []string) error {
+	var settings identity.Settings
+	if identitiesConfig != "" {
+		cfg, err := config.LoadConfig(identitiesConfig)
+		if err != nil {
+			return fmt.Errorf("failed to load config: %w", err)
+		}
+		settings = cfg.Identity
+	}
+
+	settings.Mappings = append(settings.Mappings, identitiesMappings...)
+	if identitiesMatchUsernames {
+		settings.MatchUsernames = true
+	}
+
+	correlator, err := identity.NewCorrelator(settings)
+	if err != nil {
+		return err
+	}
+
+	collection, err := loadExport(identitiesInput)
+	if err != nil {
+		return fmt.Errorf("failed to load export: %w", err)
+	}
+
+	result := correlator.Correlate(collection)
+
+	writer := os.Stdout
+	if identitiesOutput != "" {
+		// #nosec G304 - identitiesOutput is provided by user as CLI argument, this is expected behavior
+		writer, err = os.Create(identitiesOutput)
+		if err != nil {
+			return fmt.Errorf("failed to create output file: %w", err)
+		}
+		defer func() {
+			if closeErr := writer.Close(); closeErr != nil {
+				fmt.Fprintf(os.Stderr, "Warning: failed to close output file: %v\n", closeErr)
+			}
+		}()
+	}
+
+	switch identitiesType {
+	case "json":
+		encoder := json.NewEncoder(writer)
+		encoder.SetIndent("", "  ")
+		if err := encoder.Encode(identitiesOutputJSON{People: result.People, Report: result.Report}); err != nil {
+			return fmt.Errorf("failed to encode JSON: %w", err)
+		}
+		return nil
+	case "summary":
+		return writeIdentitiesSummary(result.Report, writer)
+	}
+
+	return fmt.Errorf("unsupported output type '%s' (supported: summary, json)", identitiesType)
+}
+
+// writeIdentitiesSummary writes a human-readable identity report
+func writeIdentitiesSummary(report *identity.Report, writer io.Writer) error {
+	fmt.Fprintln(writer, "=== Identity Correlation ===")
+	fmt.Fprintln(writer)
+
+	fmt.Fprintln(writer, "Summary:")
+	fmt.Fprintf(writer, "  Accounts:              %d (%d linked)\n", report.Accounts, report.Linked)
+	fmt.Fprintf(writer, "  People:                %d\n", report.People)
+	fmt.Fprintf(writer, "  Orphan accounts:       %d\n", len(report.Orphans))
+	fmt.Fprintf(writer, "  Deactivated in Okta:   %d\n", len(report.Deactivated))
+	fmt.Fprintf(writer, "  Multi-system admins:   %d\n", len(report.MultiAdmins))
+	if report.OktaUsers == 0 {
+		fmt.Fprintln(writer, "  (no Okta users in the export, orphans are not reported)")
+	}
+	fmt.Fprintln(writer)
+
+	table := tabwriter.NewWriter(writer, 0, 0, 2, ' ', 0)
+
+	if len(report.Orphans) > 0 {
+		fmt.Fprintln(writer, "Orphan accounts (no Okta counterpart):")
+		fmt.Fprintln(table, "PROVIDER\tACCOUNT\tNAME\tADMIN")
+		for _, acc := range report.Orphans {
+			fmt.Fprintf(table, "%s\t%s\t%s\t%t\n", acc.Provider, acc.Account, acc.Name, acc.Admin)
+		}
+		if err := table.Flush(); err != nil {
+			return err
+		}
+		fmt.Fprintln(writer)
+	}
+
+	if len(report.Deactivated) > 0 {
+		fmt.Fprintln(writer, "Deactivated in Okta with active accounts:")
+		fmt.Fprintln(table, "PERSON\tOKTA STATUS\tACTIVE ACCOUNTS")
+		for _, person := range report.Deactivated {
+			fmt.Fprintf(table, "%s\t%s\t%s\n", person.Name, person.OktaStatus, describeAccounts(person.Accounts))
+		}
+		if err := table.Flush(); err != nil {
+			return err
+		}
+		fmt.Fprintln(writer)
+	}
+
+	if len(report.MultiAdmins) > 0 {
+		fmt.Fprintln(writer, "Admins in multiple systems:")
+		fmt.Fprintln(table, "PERSON\tPROVIDERS\tADMIN ACCOUNTS")
+		for _, person := range report.MultiAdmins {
+			fmt.Fprintf(table, "%s\t%s\t%s\n", person.Name, strings.Join(person.Providers, ", "), describeAccounts(person.Accounts))
+		}
+		if err := table.Flush(); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+// describeAccounts lists accounts as provider:name
+func describeAccounts(accounts []identity.Account) string {
+	names := make([]string, 0, len(accounts))
+	for _, acc := range accounts {
+		names = append(names, acc.Provider+":"+acc.Name)
+	}
+	return strings.Join(names, ", ")
+}
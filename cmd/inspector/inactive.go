@@ -0,0 +1,188 @@
+package main
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"os"
+	"text/tabwriter"
+	"time"
+
+	"github.com/spf13/cobra"
+
+	"github.com/comfortablynumb/pmp-cloud-inspector/pkg/config"
+	"github.com/comfortablynumb/pmp-cloud-inspector/pkg/identity"
+)
+
+var (
+	inactiveInput        string
+	inactiveConfig       string
+	inactiveOutput       string
+	inactiveType         string
+	inactiveDays         int
+	inactiveProviderDays map[string]int
+)
+
+var inactiveCmd = &cobra.Command{
+	Use:   "inactive",
+	Short: "Report user accounts and access keys without recent activity",
+	Long: `Report the user accounts that can still sign in and the active AWS access keys without activity
+for more days than the threshold of their provider, for deprovisioning. The last activity of
+an account is the latest of the signals of its provider:
+
+  AWS      console password and access key last use
+  GitHub   latest public event, with the member_activity option of the provider
+  GitLab   last activity and sign-in (requires an administrator token)
+  JFrog    last login
+  Okta     last login
+  Auth0    last login
+
+Accounts that were never used are measured from their creation. Inactivity is measured at the
+time of the export. Thresholds default to 90 days and are set by identity.inactivity of the
+config, or by flags.
+
+Examples:
+  # Report the accounts and keys inactive for more than 90 days
+  pmp-cloud-inspector inactive -i export.json
+
+  # Use a longer threshold for GitHub
+  pmp-cloud-inspector inactive -i export.json --days 60 --provider-days github=180
+
+  # Write a CSV for deprovisioning tickets
+  pmp-cloud-inspector inactive -i export.json -c config.yaml -t csv -o inactive.csv`,
+	RunE: runInactive,
+}
+
+func init() {
+	inactiveCmd.Flags().StringVarP(&inactiveInput, "input", "i", "", "Export file (JSON)")
+	inactiveCmd.Flags().StringVarP(&inactiveConfig, "config", "c", "", "Configuration file providing identity settings (optional)")
+	inactiveCmd.Flags().StringVarP(&inactiveOutput, "output", "o", "", "Output file (defaults to stdout)")
+	inactiveCmd.Flags().StringVarP(&inactiveType, "type", "t", "summary", "Output type: summary, csv, json")
+	inactiveCmd.Flags().IntVar(&inactiveDays, "days", 0, "Days without activity after which accounts and keys are inactive (default 90)")
+	inactiveCmd.Flags().StringToIntVar(&inactiveProviderDays, "provider-days", nil, "Days per provider, e.g., github=180,okta=30")
+	if err := inactiveCmd.MarkFlagRequired("input"); err != nil {
+		fmt.Fprintf(os.Stderr, "Warning: failed to mark input flag as required: %v\n", err)
+	}
+}
+
+func runInactive(cmd *cobra.Command, args []string) error {
+	var settings identity.InactivitySettings
+	if inactiveConfig != "" {
+		cfg, err := config.LoadConfig(inactiveConfig)
+		if err != nil {
+			return fmt.Errorf("failed to load config: %w", err)
+		}
+		settings = cfg.Identity.Inactivity
+	}
+
+	if cmd.Flags().Changed("days") {
+		settings.Days = inactiveDays
+	}
+	if len(inactiveProviderDays) > 0 {
+		providers := make(map[string]int, len(settings.Providers)+len(inactiveProviderDays))
+		for provider, days := range settings.Providers {
+			providers[provider] = days
+		}
+		for provider, days := range inactiveProviderDays {
+			providers[provider] = days
+		}
+		settings.Providers = providers
+	}
+	if err := settings.Validate(); err != nil {
+		return err
+	}
+
+	collection, err := loadExport(inactiveInput)
+	if err != nil {
+		return fmt.Errorf("failed to load export: %w", err)
+	}
+
+	asOf := collection.Metadata.Timestamp
+	if asOf.IsZero() {
+		asOf = time.Now()
+	}
+
+	report := identity.DetectInactive(collection, settings, asOf)
+
+	writer := os.Stdout
+	if inactiveOutput != "" {
+		// #nosec G304 - inactiveOutput is provided by user as CLI argument, this is expected behavior
+		writer, err = os.Create(inactiveOutput)
+		if err != nil {
+			return fmt.Errorf("failed to create output file: %w", err)
+		}
+		defer func() {
+			if closeErr := writer.Close(); closeErr != nil {
+				fmt.Fprintf(os.Stderr, "Warning: failed to close output file: %v\n", closeErr)
+			}
+		}()
+	}
+
+	switch inactiveType {
+	case "csv":
+		return report.WriteCSV(writer)
+	case "json":
+		encoder := json.NewEncoder(writer)
+		encoder.SetIndent("", "  ")
+		if err := encoder.Encode(report); err != nil {
+			return fmt.Errorf("failed to encode JSON: %w", err)
+		}
+		return nil
+	case "summary":
+		return writeInactiveSummary(report, writer)
+	}
+
+	return fmt.Errorf("unsupported output type '%s' (supported: summary, csv, json)", inactiveType)
+}
+
+// writeInactiveSummary writes a human-readable inactivity report
+func writeInactiveSummary(report *identity.InactivityReport, writer io.Writer) error {
+	fmt.Fprintln(writer, "=== Inactive Identities ===")
+	fmt.Fprintln(writer)
+
+	fmt.Fprintln(writer, "Summary:")
+	fmt.Fprintf(writer, "  As of:                 %s\n", report.AsOf.Format(time.RFC3339))
+	fmt.Fprintf(writer, "  Accounts checked:      %d\n", report.Accounts)
+	fmt.Fprintf(writer, "  Inactive accounts:     %d\n", len(report.InactiveAccounts))
+	fmt.Fprintf(writer, "  Unknown activity:      %d\n", report.Unknown)
+	fmt.Fprintf(writer, "  Active access keys:    %d\n", report.Keys)
+	fmt.Fprintf(writer, "  Inactive access keys:  %d\n", len(report.InactiveKeys))
+	fmt.Fprintln(writer)
+
+	if len(report.InactiveAccounts) > 0 {
+		fmt.Fprintln(writer, "Inactive accounts:")
+		table := tabwriter.NewWriter(writer, 0, 0, 2, ' ', 0)
+		fmt.Fprintln(table, "PROVIDER\tACCOUNT\tNAME\tPERSON\tLAST ACTIVITY\tDAYS\tREASON")
+		for _, a := range report.InactiveAccounts {
+			fmt.Fprintf(table, "%s\t%s\t%s\t%s\t%s\t%d\t%s\n",
+				a.Provider, a.Account, a.Name, a.Person, formatDate(a.LastActivity), a.DaysInactive, a.Reason)
+		}
+		if err := table.Flush(); err != nil {
+			return err
+		}
+		fmt.Fprintln(writer)
+	}
+
+	if len(report.InactiveKeys) > 0 {
+		fmt.Fprintln(writer, "Inactive access keys:")
+		table := tabwriter.NewWriter(writer, 0, 0, 2, ' ', 0)
+		fmt.Fprintln(table, "ACCOUNT\tUSER\tACCESS KEY\tLAST USED\tDAYS\tREASON")
+		for _, k := range report.InactiveKeys {
+			fmt.Fprintf(table, "%s\t%s\t%s\t%s\t%d\t%s\n",
+				k.Account, k.User, k.KeyID, formatDate(k.LastUsed), k.DaysInactive, k.Reason)
+		}
+		if err := table.Flush(); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+// formatDate formats an optional time as a date, or "never"
+func formatDate(t *time.Time) string {
+	if t == nil {
+		return "never"
+	}
+	return t.Format("2006-01-02")
+}
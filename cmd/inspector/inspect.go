@@ -4,6 +4,8 @@ import (
 	"context"
 	"fmt"
 	"os"
+	"strings"
+	"time"
 
 	"github.com/spf13/cobra"
 
@@ -11,18 +13,24 @@ import (
 	"github.com/comfortablynumb/pmp-cloud-inspector/pkg/cost"
 	"github.com/comfortablynumb/pmp-cloud-inspector/pkg/exporter"
 	"github.com/comfortablynumb/pmp-cloud-inspector/pkg/filter"
+	"github.com/comfortablynumb/pmp-cloud-inspector/pkg/iampolicy"
+	"github.com/comfortablynumb/pmp-cloud-inspector/pkg/identity"
 	"github.com/comfortablynumb/pmp-cloud-inspector/pkg/provider"
 	"github.com/comfortablynumb/pmp-cloud-inspector/pkg/resource"
 )
 
 var (
-	configFile    string
-	outputFile    string
-	format        string
-	pretty        bool
-	includeRaw    bool
-	concurrency   int
-	estimateCosts bool
+	configFile          string
+	outputFile          string
+	format              string
+	pretty              bool
+	includeRaw          bool
+	concurrency         int
+	estimateCosts       bool
+	pricingCatalogFiles []string
+	currency            string
+	exchangeRatesFile   string
+	failOnAlert         string
 
 	// Filter flags
 	filterTags       []string
@@ -33,6 +41,28 @@ var (
 	filterCost       string
 	filterTypes      []string
 	filterProviders  []string
+	whereExpr        string
+
+	// Relationship filter flags
+	filterRelated      []string
+	filterNeighborhood []string
+	filterIncoming     []string
+	filterNoIncoming   []string
+	includeRelated     bool
+
+	// Named filter sets from the config
+	filterSets []string
+
+	// Ordering flags
+	sortBy string
+	topN   int
+
+	// Terraform flags
+	terraformStates []string
+	terraformReport string
+
+	// Identity flags
+	correlateIdentities bool
 )
 
 var inspectCmd = &cobra.Command{
@@ -54,6 +84,10 @@ func init() {
 	inspectCmd.Flags().BoolVar(&includeRaw, "include-raw", false, "Include raw cloud provider data")
 	inspectCmd.Flags().IntVar(&concurrency, "concurrent", 4, "Number of concurrent goroutines for parallel resource collection")
 	inspectCmd.Flags().BoolVar(&estimateCosts, "estimate-costs", false, "Estimate monthly costs for resources")
+	inspectCmd.Flags().StringSliceVar(&pricingCatalogFiles, "pricing-catalog", nil, "Pricing catalog file or directory used to estimate costs (overrides the bundled and refreshed catalogs)")
+	inspectCmd.Flags().StringVar(&currency, "currency", "", "Currency costs are reported in, e.g. EUR (overrides cost.currency of the config)")
+	inspectCmd.Flags().StringVar(&exchangeRatesFile, "exchange-rates", "", "Exchange rates file (JSON or YAML) used with --currency (overrides cost.exchange_rates of the config)")
+	inspectCmd.Flags().StringVar(&failOnAlert, "fail-on-alert", "", "Exit with an error after exporting if a budget alert of this severity or higher is raised: warning, critical (overrides cost.fail_on of the config)")
 
 	// Filter flags
 	inspectCmd.Flags().StringSliceVar(&filterTags, "filter-tag", nil, "Filter by tags (e.g., Environment=prod, Name~test, Owner)")
@@ -64,6 +98,22 @@ func init() {
 	inspectCmd.Flags().StringVar(&filterCost, "filter-cost", "", "Filter by cost (e.g., 100..500, >100, <500)")
 	inspectCmd.Flags().StringSliceVar(&filterTypes, "filter-type", nil, "Filter by resource types (e.g., aws:ec2:instance)")
 	inspectCmd.Flags().StringSliceVar(&filterProviders, "filter-provider", nil, "Filter by providers (e.g., aws, azure, gcp)")
+	inspectCmd.Flags().StringArrayVar(&filterRelated, "filter-related", nil, "Filter by relationship to a resource (e.g., id=vpc-123,type=belongs_to,direction=out)")
+	inspectCmd.Flags().StringArrayVar(&filterNeighborhood, "filter-neighborhood", nil, "Filter by distance from seed resources (e.g., id=arn:aws:iam::123:role/app,depth=2)")
+	inspectCmd.Flags().StringArrayVar(&filterIncoming, "filter-incoming", nil, "Filter by incoming relationship (e.g., type=belongs_to,from=aws:ec2:instance)")
+	inspectCmd.Flags().StringArrayVar(&filterNoIncoming, "filter-no-incoming", nil, "Filter by absence of incoming relationship (e.g., type=attached_to,from=aws:ec2:instance)")
+	inspectCmd.Flags().BoolVar(&includeRelated, "include-related", false, "Include direct relationship targets of filtered resources in the output")
+	inspectCmd.Flags().StringSliceVar(&filterSets, "filter-set", nil, "Apply named filter sets from the config (e.g., prod-compute)")
+	inspectCmd.Flags().StringVar(&whereExpr, "where", "", `Filter by boolean expression (e.g., provider == "aws" && (tags.Environment == "prod" || name =~ /^prod-/))`)
+
+	// Ordering flags
+	inspectCmd.Flags().StringVar(&sortBy, "sort-by", "", "Sort resources: cost (most expensive first), cost.<component>, name, type, provider, created_at")
+	inspectCmd.Flags().IntVar(&topN, "top", 0, "Keep only the N most expensive resources (by cost, or by --sort-by cost.<component>)")
+
+	// Terraform flags
+	inspectCmd.Flags().StringSliceVar(&terraformStates, "terraform-state", nil, "Terraform state file or directory of state files to reconcile against")
+	inspectCmd.Flags().StringVar(&terraformReport, "terraform-report", "", "Write the Terraform reconciliation report (JSON) to this file")
+	inspectCmd.Flags().BoolVar(&correlateIdentities, "correlate-identities", false, "Link the user accounts of every provider into identity:person resources (see identity in the config)")
 }
 
 // contextKey is a type for context keys to avoid collisions
@@ -89,6 +139,34 @@ func runInspect(cmd *cobra.Command, args []string) error {
 
 	fmt.Fprintf(os.Stderr, "Loaded configuration from %s\n", configFile)
 
+	// Build filters before collecting so that invalid filters fail fast
+	filters, err := buildFilters(cfg)
+	if err != nil {
+		return fmt.Errorf("failed to build filters: %w", err)
+	}
+
+	var sorter *filter.Sorter
+	if sortBy != "" {
+		if sorter, err = filter.ParseSortKey(sortBy); err != nil {
+			return fmt.Errorf("invalid sort key: %w", err)
+		}
+	}
+
+	if topN > 0 && !estimateCosts {
+		fmt.Fprintf(os.Stderr, "Warning: --top selects resources by estimated cost, consider enabling --estimate-costs\n")
+	}
+
+	failOn := cfg.Cost.FailOn
+	if failOnAlert != "" {
+		if cost.SeverityRank(failOnAlert) == 0 {
+			return fmt.Errorf("invalid --fail-on-alert '%s' (supported: warning, critical)", failOnAlert)
+		}
+		failOn = failOnAlert
+	}
+	if len(cfg.Cost.Budgets) > 0 && !estimateCosts {
+		fmt.Fprintf(os.Stderr, "Warning: budgets are only checked with --estimate-costs\n")
+	}
+
 	// Collect resources from all configured providers
 	allResources := resource.NewCollection()
 
@@ -141,162 +219,338 @@ func runInspect(cmd *cobra.Command, args []string) error {
 
 	fmt.Fprintf(os.Stderr, "Total resources collected: %d\n", len(allResources.Resources))
 
+	// Role trusts can cross accounts, they are linked once every provider is merged
+	if cfg.Resources.Relationships {
+		if trusts := iampolicy.LinkTrusts(allResources); trusts > 0 {
+			fmt.Fprintf(os.Stderr, "Linked %d IAM role trusts\n", trusts)
+		}
+	}
+
 	// Estimate costs if enabled
 	if estimateCosts {
 		fmt.Fprintf(os.Stderr, "Estimating costs...\n")
-		if costErr := estimateResourceCosts(allResources); costErr != nil {
+		if costErr := estimateResourceCosts(allResources, append(cfg.Cost.Catalogs, pricingCatalogFiles...), cfg.Cost.Assumptions); costErr != nil {
 			fmt.Fprintf(os.Stderr, "Warning: failed to estimate costs: %v\n", costErr)
-		} else if allResources.Metadata.TotalCost != nil {
-			fmt.Fprintf(os.Stderr, "Estimated total monthly cost: $%.2f %s\n",
+		} else {
+			// A currency was explicitly requested, budgets and exports must not use mixed totals
+			if convertErr := convertResourceCosts(allResources, cfg.Cost); convertErr != nil {
+				return fmt.Errorf("failed to convert costs: %w", convertErr)
+			}
+		}
+		if allResources.Metadata.TotalCost != nil {
+			fmt.Fprintf(os.Stderr, "Estimated total monthly cost: %.2f %s\n",
 				allResources.Metadata.TotalCost.Total,
 				allResources.Metadata.TotalCost.Currency)
 		}
+
+		// Check budgets against the estimated costs of the whole inventory
+		if len(cfg.Cost.Budgets) > 0 {
+			alerts, budgetErr := cost.CheckBudgets(allResources, cfg.Cost.Budgets)
+			if budgetErr != nil {
+				return fmt.Errorf("failed to check budgets: %w", budgetErr)
+			}
+			allResources.Metadata.Alerts = alerts
+
+			fmt.Fprintf(os.Stderr, "Checked %d budgets: %d alerts\n", len(cfg.Cost.Budgets), len(alerts))
+			for _, alert := range alerts {
+				fmt.Fprintf(os.Stderr, "  %s: %s\n", strings.ToUpper(alert.Severity), alert.Message)
+			}
+		}
 	}
 
-	// Apply filters if any
-	filters, err := buildFilters()
-	if err != nil {
-		return fmt.Errorf("failed to build filters: %w", err)
+	// Reconcile against Terraform states if any
+	if len(terraformStates) > 0 {
+		fmt.Fprintf(os.Stderr, "Reconciling with Terraform state...\n")
+		report, tfErr := reconcileTerraformStates(allResources, terraformStates, configScope(cfg))
+		if tfErr != nil {
+			return tfErr
+		}
+
+		fmt.Fprintf(os.Stderr, "Terraform: %d managed, %d unmanaged, %d ghost resources\n",
+			report.Summary.Managed, report.Summary.Unmanaged, report.Summary.Ghosts)
+
+		if terraformReport != "" {
+			if writeErr := writeTerraformReport(report, terraformReport); writeErr != nil {
+				return writeErr
+			}
+		}
+	}
+
+	// Normalize the sign-in and usage signals of user accounts into last_activity
+	if count := identity.NormalizeActivity(allResources); count > 0 {
+		fmt.Fprintf(os.Stderr, "Recorded the last activity of %d user accounts\n", count)
 	}
 
+	// Correlate the user accounts of every provider into people
+	if correlateIdentities || cfg.Identity.Correlate {
+		fmt.Fprintf(os.Stderr, "Correlating identities...\n")
+		correlator, idErr := identity.NewCorrelator(cfg.Identity)
+		if idErr != nil {
+			return fmt.Errorf("failed to correlate identities: %w", idErr)
+		}
+
+		result := correlator.Correlate(allResources)
+		for _, person := range result.People {
+			allResources.Add(person)
+		}
+
+		fmt.Fprintf(os.Stderr, "Identities: %d people, %d orphan accounts, %d deactivated people with active accounts, %d multi-system admins\n",
+			result.Report.People, len(result.Report.Orphans), len(result.Report.Deactivated), len(result.Report.MultiAdmins))
+	}
+
+	// Apply filters if any
+	unfiltered := allResources
 	if len(filters) > 0 {
 		fmt.Fprintf(os.Stderr, "Applying filters...\n")
 		allResources = filter.ApplyFilters(allResources, filters...)
 		fmt.Fprintf(os.Stderr, "Filtered to %d resources\n", len(allResources.Resources))
 	}
 
-	// Determine output format
-	outputFormat := format
-	if outputFormat == "" {
-		outputFormat = cfg.Export.Format
+	// Keep only the most expensive resources if requested
+	if topN > 0 {
+		component := ""
+		if sorter != nil && sorter.Key == filter.SortByCost {
+			component = sorter.Component
+		}
+		allResources = filter.TopByCost(allResources, topN, component)
+		fmt.Fprintf(os.Stderr, "Selected the %d most expensive resources\n", len(allResources.Resources))
 	}
-	if outputFormat == "" {
-		outputFormat = "json" // default
+
+	if sorter != nil {
+		allResources = sorter.Sort(allResources)
 	}
 
-	// Get exporter
-	exp, err := exporter.Get(outputFormat)
-	if err != nil {
-		return fmt.Errorf("failed to get exporter: %w", err)
+	if includeRelated && allResources != unfiltered {
+		allResources = filter.IncludeRelated(allResources, unfiltered)
 	}
 
-	// Determine output writer
-	var writer *os.File
-	if outputFile != "" {
-		// #nosec G304 - outputFile is provided by user as CLI argument, this is expected behavior
-		writer, err = os.Create(outputFile)
-		if err != nil {
-			return fmt.Errorf("failed to create output file: %w", err)
-		}
-		defer func() {
-			if closeErr := writer.Close(); closeErr != nil {
-				fmt.Fprintf(os.Stderr, "Warning: failed to close output file: %v\n", closeErr)
-			}
-		}()
-		fmt.Fprintf(os.Stderr, "Writing output to %s in %s format...\n", outputFile, outputFormat)
-	} else {
-		writer = os.Stdout
-		fmt.Fprintf(os.Stderr, "Writing output to stdout in %s format...\n", outputFormat)
+	// Build export targets
+	targets, err := buildExportTargets(cmd, cfg)
+	if err != nil {
+		return err
 	}
 
 	// Export
-	exportOptions := exporter.ExportOptions{
-		Pretty:     pretty,
-		IncludeRaw: includeRaw,
+	written, err := exporter.WriteTargets(allResources, targets, time.Now())
+	if err != nil {
+		return fmt.Errorf("failed to export resources: %w", err)
 	}
 
-	if err := exp.Export(allResources, writer, exportOptions); err != nil {
-		return fmt.Errorf("failed to export resources: %w", err)
+	for _, w := range written {
+		switch {
+		case w.Empty:
+			fmt.Fprintf(os.Stderr, "Warning: 0 resources for %s, no files written (files of previous runs were left in place)\n", w.Path)
+		case w.Path != "-":
+			fmt.Fprintf(os.Stderr, "Wrote %d resources to %s in %s format\n", w.Resources, w.Path, w.Format)
+		}
 	}
 
 	fmt.Fprintf(os.Stderr, "Export completed successfully!\n")
 
+	// Fail after exporting, so that CI runs still publish the export with its alerts
+	if failOn != "" {
+		if failing := cost.AlertsAtLeast(allResources.Metadata.Alerts, failOn); len(failing) > 0 {
+			return fmt.Errorf("%d budget alerts of %s severity or higher", len(failing), strings.ToLower(failOn))
+		}
+	}
+
 	return nil
 }
 
-// buildFilters constructs filters from command-line flags
-func buildFilters() ([]filter.Filter, error) {
-	var filters []filter.Filter
+// buildExportTargets determines where to export the collection. The --output and --format
+// flags select a single target and take precedence over the targets in the config.
+func buildExportTargets(cmd *cobra.Command, cfg *config.Config) ([]exporter.Target, error) {
+	configTargets := cfg.Export.ResolvedTargets()
 
-	// Tag filters
-	for _, tagExpr := range filterTags {
-		f, err := filter.ParseTagFilter(tagExpr)
-		if err != nil {
-			return nil, fmt.Errorf("invalid tag filter '%s': %w", tagExpr, err)
+	if outputFile != "" || format != "" || len(configTargets) == 0 {
+		outputFormat := format
+		if outputFormat == "" {
+			outputFormat = cfg.Export.Format
+		}
+		if outputFormat == "" {
+			outputFormat = "json" // default
 		}
-		filters = append(filters, f)
-	}
 
-	// Regex filters
-	for _, regexExpr := range filterRegex {
-		f, err := filter.ParseRegexFilter(regexExpr)
-		if err != nil {
-			return nil, fmt.Errorf("invalid regex filter '%s': %w", regexExpr, err)
+		path := outputFile
+		if path == "" && format == "" {
+			path = cfg.Export.OutputFile
 		}
-		filters = append(filters, f)
-	}
 
-	// Date range filters
-	for _, dateExpr := range filterDateRange {
-		f, err := filter.ParseDateRangeFilter(dateExpr)
-		if err != nil {
-			return nil, fmt.Errorf("invalid date filter '%s': %w", dateExpr, err)
+		if path == "" {
+			fmt.Fprintf(os.Stderr, "Writing output to stdout in %s format...\n", outputFormat)
+		} else {
+			fmt.Fprintf(os.Stderr, "Writing output to %s in %s format...\n", path, outputFormat)
 		}
-		filters = append(filters, f)
+
+		return []exporter.Target{{
+			Format: outputFormat,
+			Path:   path,
+			Options: exporter.ExportOptions{
+				Pretty:     pretty,
+				IncludeRaw: includeRaw,
+			},
+		}}, nil
 	}
 
-	// State filter
-	if filterStates != "" {
-		f, err := filter.ParseStateFilter(filterStates)
-		if err != nil {
-			return nil, fmt.Errorf("invalid state filter '%s': %w", filterStates, err)
+	// Config options, overridden by explicitly set flags, overridden by target options
+	defaults := exporter.ExportOptions{
+		Pretty:     cfg.Export.Pretty,
+		IncludeRaw: cfg.Export.IncludeRaw,
+	}
+	if cmd.Flags().Changed("pretty") {
+		defaults.Pretty = pretty
+	}
+	if cmd.Flags().Changed("include-raw") {
+		defaults.IncludeRaw = includeRaw
+	}
+
+	targets := make([]exporter.Target, 0, len(configTargets))
+	for i, targetCfg := range configTargets {
+		target := exporter.Target{
+			Format:  targetCfg.Format,
+			Path:    targetCfg.Output,
+			Options: defaults,
 		}
-		filters = append(filters, f)
+		if targetCfg.Pretty != nil {
+			target.Options.Pretty = *targetCfg.Pretty
+		}
+		if targetCfg.IncludeRaw != nil {
+			target.Options.IncludeRaw = *targetCfg.IncludeRaw
+		}
+
+		if targetCfg.FilterSet != "" {
+			setFilters, err := cfg.BuildFilterSet(targetCfg.FilterSet)
+			if err != nil {
+				return nil, fmt.Errorf("export target %d: %w", i+1, err)
+			}
+			target.Filters = append(target.Filters, setFilters...)
+		}
+
+		if targetCfg.Where != "" {
+			f, err := filter.ParseExpression(targetCfg.Where)
+			if err != nil {
+				return nil, fmt.Errorf("export target %d: invalid where expression: %w", i+1, err)
+			}
+			target.Filters = append(target.Filters, f)
+		}
+
+		if err := target.Validate(); err != nil {
+			return nil, fmt.Errorf("invalid export target %d: %w", i+1, err)
+		}
+
+		targets = append(targets, target)
 	}
 
-	// Property filters
-	for _, propExpr := range filterProperties {
-		f, err := filter.ParsePropertyFilter(propExpr)
+	fmt.Fprintf(os.Stderr, "Writing output to %d export targets...\n", len(targets))
+
+	return targets, nil
+}
+
+// buildFilters constructs filters from the configured where expression, the selected
+// filter sets and command-line flags. All of them are ANDed.
+func buildFilters(cfg *config.Config) ([]filter.Filter, error) {
+	var filters []filter.Filter
+
+	if cfg.Resources.Where != "" {
+		f, err := filter.ParseExpression(cfg.Resources.Where)
 		if err != nil {
-			return nil, fmt.Errorf("invalid property filter '%s': %w", propExpr, err)
+			return nil, fmt.Errorf("invalid where expression: %w", err)
 		}
 		filters = append(filters, f)
 	}
 
-	// Cost filter
-	if filterCost != "" {
-		f, err := filter.ParseCostFilter(filterCost)
+	for _, name := range filterSets {
+		setFilters, err := cfg.BuildFilterSet(name)
 		if err != nil {
-			return nil, fmt.Errorf("invalid cost filter '%s': %w", filterCost, err)
+			return nil, err
 		}
-		filters = append(filters, f)
+		filters = append(filters, setFilters...)
 	}
 
-	// Type filter
-	if len(filterTypes) > 0 {
-		f := filter.ParseTypeFilter(filterTypes)
-		filters = append(filters, f)
+	flagSet := &config.FilterSet{
+		Where:        whereExpr,
+		Tags:         filterTags,
+		Regex:        filterRegex,
+		Dates:        filterDateRange,
+		Properties:   filterProperties,
+		Cost:         filterCost,
+		Types:        filterTypes,
+		Providers:    filterProviders,
+		Related:      filterRelated,
+		Neighborhood: filterNeighborhood,
+		Incoming:     filterIncoming,
+		NoIncoming:   filterNoIncoming,
+	}
+	if filterStates != "" {
+		flagSet.States = []string{filterStates}
 	}
 
-	// Provider filter
-	if len(filterProviders) > 0 {
-		f := filter.ParseProviderFilter(filterProviders)
-		filters = append(filters, f)
+	flagFilters, err := flagSet.Build()
+	if err != nil {
+		return nil, err
 	}
 
-	return filters, nil
+	return append(filters, flagFilters...), nil
 }
 
-// estimateResourceCosts estimates costs for all resources in the collection
-func estimateResourceCosts(collection *resource.Collection) error {
-	// Create cost estimator registry
-	registry := cost.NewEstimatorRegistry()
+// convertResourceCosts converts the cost estimates of the collection to the currency given with
+// --currency or configured under cost.currency, if any
+func convertResourceCosts(collection *resource.Collection, costConfig config.CostConfig) error {
+	target, rates, err := resolveCostCurrency(currency, exchangeRatesFile, costConfig)
+	if err != nil || rates == nil {
+		return err
+	}
+
+	fmt.Fprintf(os.Stderr, "  Converting costs to %s using exchange rates %s\n", target, rates.Version)
 
-	// Register estimators for each provider
-	registry.Register("aws", cost.NewAWSEstimator())
-	registry.Register("azure", cost.NewAzureEstimator())
-	registry.Register("gcp", cost.NewGCPEstimator())
+	return cost.ConvertCollection(collection, target, rates)
+}
+
+// resolveCostCurrency returns the currency costs are converted to, given as a flag or configured
+// under cost.currency, and the exchange rates to convert them with. The rates are nil if there
+// is nothing to convert.
+func resolveCostCurrency(currencyFlag, ratesFlag string, costConfig config.CostConfig) (string, *cost.ExchangeRates, error) {
+	target := currencyFlag
+	if target == "" {
+		target = costConfig.Currency
+	}
+	if target == "" {
+		return "", nil, nil
+	}
+	target = strings.ToUpper(target)
+
+	ratesFile := ratesFlag
+	if ratesFile == "" {
+		ratesFile = costConfig.ExchangeRates
+	}
+	rates, err := cost.ResolveExchangeRates(ratesFile, costConfig.Rates)
+	if err != nil {
+		return "", nil, err
+	}
+	if rates == nil {
+		if target == cost.DefaultCurrency {
+			// Estimates are priced in the default currency, there is nothing to convert
+			return target, nil, nil
+		}
+		return "", nil, fmt.Errorf("no exchange rates to convert costs to %s (use --exchange-rates, cost.exchange_rates or cost.rates)", target)
+	}
+
+	return target, rates, nil
+}
+
+// estimateResourceCosts estimates costs for all resources in the collection using the
+// bundled, refreshed and given pricing catalogs and the usage assumptions
+func estimateResourceCosts(collection *resource.Collection, catalogPaths []string, usage cost.UsageAssumptions) error {
+	catalogs, err := cost.LoadCatalogs(catalogPaths)
+	if err != nil {
+		return err
+	}
+
+	for _, catalog := range catalogs.List() {
+		fmt.Fprintf(os.Stderr, "  Using %s pricing catalog %s (%d prices)\n", catalog.Provider, catalog.Version, len(catalog.Prices))
+	}
 
-	// Estimate costs for all resources
+	// Estimate costs for all resources with the estimators of each provider
+	registry := cost.NewCatalogEstimatorRegistry(catalogs, usage)
 	return registry.EstimateCollection(collection)
 }
@@ -26,6 +26,16 @@ func init() {
 	rootCmd.AddCommand(inspectCmd)
 	rootCmd.AddCommand(compareCmd)
 	rootCmd.AddCommand(uiCmd)
+	rootCmd.AddCommand(reconcileCmd)
+	rootCmd.AddCommand(pricingCmd)
+	rootCmd.AddCommand(allocateCmd)
+	rootCmd.AddCommand(recommendCmd)
+	rootCmd.AddCommand(auditCmd)
+	rootCmd.AddCommand(exposureCmd)
+	rootCmd.AddCommand(identitiesCmd)
+	rootCmd.AddCommand(accessReviewCmd)
+	rootCmd.AddCommand(inactiveCmd)
+	rootCmd.AddCommand(whoCanCmd)
 }
 
 func main() {
@@ -0,0 +1,221 @@
+package main
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+	"text/tabwriter"
+
+	"github.com/spf13/cobra"
+
+	"github.com/comfortablynumb/pmp-cloud-inspector/pkg/cost"
+)
+
+var (
+	pricingProviders    []string
+	pricingRegions      []string
+	pricingOutputDir    string
+	pricingGCPAPIKey    string
+	pricingImportFiles  []string
+	pricingShowCatalogs []string
+)
+
+var pricingCmd = &cobra.Command{
+	Use:   "pricing",
+	Short: "Manage the pricing catalogs used for cost estimation",
+	Long: `Manage the pricing catalogs used by --estimate-costs.
+
+A pricing snapshot is bundled with the binary. Refreshed or imported catalogs are stored in
+the pricing cache directory and take precedence over the bundled snapshot; catalogs passed
+with --pricing-catalog or configured under cost.catalogs take precedence over both.`,
+}
+
+var pricingRefreshCmd = &cobra.Command{
+	Use:   "refresh",
+	Short: "Download current prices from the provider price list APIs",
+	Long: `Download current on-demand prices from the AWS Price List bulk API, the Azure retail
+prices API and the GCP Cloud Billing Catalog API, and store them as versioned catalogs.
+
+Only the regions of the bundled snapshot are downloaded unless --region is given. Regions
+are given per provider as <provider>=<region>; a region without a provider is only accepted
+when a single provider is refreshed. Providers without a --region keep the regions of the
+bundled snapshot.
+
+The GCP API requires an API key (--gcp-api-key or GCP_PRICING_API_KEY). Without a key, GCP
+is skipped with a warning unless it was requested with --provider.
+
+Examples:
+  # Refresh AWS and Azure prices for one region each
+  pmp-cloud-inspector pricing refresh --provider aws,azure --region aws=us-east-1,azure=eastus
+
+  # Refresh AWS prices for two regions
+  pmp-cloud-inspector pricing refresh --provider aws --region us-east-1,eu-west-1
+
+  # Refresh GCP prices
+  GCP_PRICING_API_KEY=... pmp-cloud-inspector pricing refresh --provider gcp`,
+	RunE: runPricingRefresh,
+}
+
+var pricingImportCmd = &cobra.Command{
+	Use:   "import",
+	Short: "Convert downloaded price lists into catalogs",
+	Long: `Convert price list files downloaded manually into catalogs stored in the pricing cache
+directory. Supported files are AWS Price List bulk offer files (JSON or CSV), Azure retail
+prices API responses (JSON) and GCP Cloud Billing Catalog SKU lists (JSON).
+Files of the same provider are merged into a single catalog.
+
+Examples:
+  pmp-cloud-inspector pricing import --file AmazonEC2-us-east-1.csv --file azure-prices.json`,
+	RunE: runPricingImport,
+}
+
+var pricingShowCmd = &cobra.Command{
+	Use:   "show",
+	Short: "Show the pricing catalogs used for cost estimation",
+	RunE:  runPricingShow,
+}
+
+func init() {
+	pricingRefreshCmd.Flags().StringSliceVar(&pricingProviders, "provider", []string{"aws", "azure", "gcp"}, "Providers to refresh: aws, azure, gcp")
+	pricingRefreshCmd.Flags().StringSliceVar(&pricingRegions, "region", nil, "Regions to download as <provider>=<region> (defaults to the regions of the bundled snapshot)")
+	pricingRefreshCmd.Flags().StringVar(&pricingOutputDir, "output-dir", cost.DefaultCatalogDir(), "Directory where catalogs are stored")
+	pricingRefreshCmd.Flags().StringVar(&pricingGCPAPIKey, "gcp-api-key", "", "API key for the GCP Cloud Billing Catalog API (defaults to GCP_PRICING_API_KEY)")
+
+	pricingImportCmd.Flags().StringSliceVar(&pricingImportFiles, "file", nil, "Price list file to import (repeatable)")
+	pricingImportCmd.Flags().StringVar(&pricingOutputDir, "output-dir", cost.DefaultCatalogDir(), "Directory where catalogs are stored")
+	if err := pricingImportCmd.MarkFlagRequired("file"); err != nil {
+		fmt.Fprintf(os.Stderr, "Warning: failed to mark file flag as required: %v\n", err)
+	}
+
+	pricingShowCmd.Flags().StringSliceVar(&pricingShowCatalogs, "pricing-catalog", nil, "Additional pricing catalog file or directory")
+
+	pricingCmd.AddCommand(pricingRefreshCmd)
+	pricingCmd.AddCommand(pricingImportCmd)
+	pricingCmd.AddCommand(pricingShowCmd)
+}
+
+func runPricingRefresh(cmd *cobra.Command, args []string) error {
+	apiKey := pricingGCPAPIKey
+	if apiKey == "" {
+		apiKey = os.Getenv("GCP_PRICING_API_KEY")
+	}
+
+	regions, err := regionsByProvider(pricingProviders, pricingRegions)
+	if err != nil {
+		return err
+	}
+
+	for _, provider := range pricingProviders {
+		// GCP is part of the default providers, but can't be refreshed without an API key
+		if provider == "gcp" && apiKey == "" && !cmd.Flags().Changed("provider") {
+			fmt.Fprintf(os.Stderr, "Warning: skipping gcp prices, no API key (--gcp-api-key or GCP_PRICING_API_KEY)\n")
+			continue
+		}
+
+		fmt.Fprintf(os.Stderr, "Refreshing %s prices...\n", provider)
+
+		catalog, err := cost.RefreshCatalog(context.Background(), provider, cost.RefreshOptions{
+			Regions:   regions[provider],
+			GCPAPIKey: apiKey,
+		})
+		if err != nil {
+			return fmt.Errorf("failed to refresh %s prices: %w", provider, err)
+		}
+
+		if err := saveCatalog(catalog); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+// regionsByProvider assigns the --region values to the providers refreshed. Values are given as
+// <provider>=<region>; a region without a provider is only accepted when refreshing a single provider.
+func regionsByProvider(providers, values []string) (map[string][]string, error) {
+	refreshed := make(map[string]bool, len(providers))
+	for _, provider := range providers {
+		refreshed[provider] = true
+	}
+
+	regions := make(map[string][]string)
+	for _, value := range values {
+		provider, region, found := strings.Cut(strings.TrimSpace(value), "=")
+		if !found {
+			if len(providers) != 1 {
+				return nil, fmt.Errorf("region %q must be given as <provider>=<region> when refreshing several providers", value)
+			}
+			provider, region = providers[0], provider
+		}
+		provider, region = strings.TrimSpace(provider), strings.TrimSpace(region)
+
+		if !refreshed[provider] {
+			return nil, fmt.Errorf("region %q is given for provider %s, which is not refreshed", value, provider)
+		}
+		if region == "" {
+			return nil, fmt.Errorf("invalid region %q", value)
+		}
+		regions[provider] = append(regions[provider], region)
+	}
+
+	return regions, nil
+}
+
+func runPricingImport(cmd *cobra.Command, args []string) error {
+	catalogs := cost.NewCatalogSet()
+
+	for _, file := range pricingImportFiles {
+		catalog, err := cost.LoadCatalogFile(file)
+		if err != nil {
+			return err
+		}
+		fmt.Fprintf(os.Stderr, "Imported %d %s prices from %s\n", len(catalog.Prices), catalog.Provider, file)
+
+		if existing := catalogs.Get(catalog.Provider); existing != nil {
+			existing.Merge(catalog)
+			continue
+		}
+		catalogs.Set(catalog)
+	}
+
+	for _, catalog := range catalogs.List() {
+		if err := saveCatalog(catalog); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+// saveCatalog stores a catalog as <provider>.json in the output directory
+func saveCatalog(catalog *cost.Catalog) error {
+	path := filepath.Join(pricingOutputDir, catalog.Provider+".json")
+	if err := cost.SaveCatalog(catalog, path); err != nil {
+		return err
+	}
+
+	fmt.Fprintf(os.Stderr, "Saved %s catalog %s (%d prices) to %s\n", catalog.Provider, catalog.Version, len(catalog.Prices), path)
+	return nil
+}
+
+func runPricingShow(cmd *cobra.Command, args []string) error {
+	catalogs, err := cost.LoadCatalogs(pricingShowCatalogs)
+	if err != nil {
+		return err
+	}
+
+	// Catalogs are listed in lookup order: a provider's first catalog takes precedence
+	writer := tabwriter.NewWriter(os.Stdout, 0, 0, 2, ' ', 0)
+	fmt.Fprintln(writer, "PROVIDER\tVERSION\tCURRENCY\tPRICES\tGENERATED\tSOURCE")
+	for _, layered := range catalogs.List() {
+		for catalog := layered; catalog != nil; catalog = catalog.Base() {
+			fmt.Fprintf(writer, "%s\t%s\t%s\t%d\t%s\t%s\n",
+				catalog.Provider, catalog.Version, catalog.Currency, len(catalog.Prices),
+				catalog.GeneratedAt.Format("2006-01-02"), catalog.Source)
+		}
+	}
+
+	return writer.Flush()
+}
@@ -0,0 +1,163 @@
+package main
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"os"
+	"text/tabwriter"
+
+	"github.com/spf13/cobra"
+
+	"github.com/comfortablynumb/pmp-cloud-inspector/pkg/config"
+	"github.com/comfortablynumb/pmp-cloud-inspector/pkg/cost"
+)
+
+var (
+	recommendInput    string
+	recommendConfig   string
+	recommendOutput   string
+	recommendType     string
+	recommendRules    []string
+	recommendCatalogs []string
+)
+
+var recommendCmd = &cobra.Command{
+	Use:   "recommend",
+	Short: "Recommend savings for idle and wasteful resources",
+	Long: `Find idle or wasteful resources in an export, with the reason and the estimated monthly
+saving of acting on each of them.
+
+Rules:
+  stopped-instance       stopped EC2 instances still paying for their EBS volumes
+  idle-load-balancer     load balancers without registered targets
+  previous-generation    instance and cache node types with a current generation equivalent (t2, m4, ...)
+  oversized-function     Lambda functions with very large memory settings
+  unused-secret          secrets not accessed for cost.recommendations.secret_unused_days (default 90)
+  archived-project       archived GitLab projects still storing data
+  unused-security-group  security groups not attached to any collected resource
+
+Savings are taken from the cost estimates of the export (inspect --estimate-costs), or estimated
+from the pricing catalogs for resources without one. Thresholds are set in cost.recommendations
+of the configuration file.
+
+Examples:
+  # Show the recommendations for an export
+  pmp-cloud-inspector recommend -i export.json
+
+  # Only report idle load balancers and unused secrets
+  pmp-cloud-inspector recommend -i export.json --rules idle-load-balancer,unused-secret
+
+  # Write the recommendations as CSV using the thresholds of the configuration file
+  pmp-cloud-inspector recommend -i export.json -c config.yaml -t csv -o savings.csv`,
+	RunE: runRecommend,
+}
+
+func init() {
+	recommendCmd.Flags().StringVarP(&recommendInput, "input", "i", "", "Export file (JSON)")
+	recommendCmd.Flags().StringVarP(&recommendConfig, "config", "c", "", "Configuration file providing cost.recommendations (optional)")
+	recommendCmd.Flags().StringVarP(&recommendOutput, "output", "o", "", "Output file (defaults to stdout)")
+	recommendCmd.Flags().StringVarP(&recommendType, "type", "t", "summary", "Output type: summary, csv, json")
+	recommendCmd.Flags().StringSliceVar(&recommendRules, "rules", nil, "Rules to run (overrides cost.recommendations.rules of the config)")
+	recommendCmd.Flags().StringSliceVar(&recommendCatalogs, "pricing-catalog", nil, "Pricing catalog file or directory used to price savings")
+	if err := recommendCmd.MarkFlagRequired("input"); err != nil {
+		fmt.Fprintf(os.Stderr, "Warning: failed to mark input flag as required: %v\n", err)
+	}
+}
+
+func runRecommend(cmd *cobra.Command, args []string) error {
+	var costConfig config.CostConfig
+	if recommendConfig != "" {
+		cfg, err := config.LoadConfig(recommendConfig)
+		if err != nil {
+			return fmt.Errorf("failed to load config: %w", err)
+		}
+		costConfig = cfg.Cost
+	}
+
+	settings := costConfig.Recommendations
+	if len(recommendRules) > 0 {
+		settings.Rules = recommendRules
+	}
+
+	catalogs, err := cost.LoadCatalogs(append(costConfig.Catalogs, recommendCatalogs...))
+	if err != nil {
+		return fmt.Errorf("failed to load pricing catalogs: %w", err)
+	}
+
+	rates, err := cost.ResolveExchangeRates(costConfig.ExchangeRates, costConfig.Rates)
+	if err != nil {
+		return err
+	}
+
+	collection, err := loadExport(recommendInput)
+	if err != nil {
+		return fmt.Errorf("failed to load export: %w", err)
+	}
+
+	recommender := cost.NewRecommender(catalogs, costConfig.Assumptions, settings)
+	recommender.SetExchangeRates(rates)
+
+	report, err := recommender.Recommend(collection)
+	if err != nil {
+		return err
+	}
+
+	writer := os.Stdout
+	if recommendOutput != "" {
+		// #nosec G304 - recommendOutput is provided by user as CLI argument, this is expected behavior
+		writer, err = os.Create(recommendOutput)
+		if err != nil {
+			return fmt.Errorf("failed to create output file: %w", err)
+		}
+		defer func() {
+			if closeErr := writer.Close(); closeErr != nil {
+				fmt.Fprintf(os.Stderr, "Warning: failed to close output file: %v\n", closeErr)
+			}
+		}()
+	}
+
+	switch recommendType {
+	case "csv":
+		return report.WriteCSV(writer)
+	case "json":
+		encoder := json.NewEncoder(writer)
+		encoder.SetIndent("", "  ")
+		if err := encoder.Encode(report); err != nil {
+			return fmt.Errorf("failed to encode JSON: %w", err)
+		}
+		return nil
+	case "summary":
+		return writeRecommendationSummary(report, writer)
+	}
+
+	return fmt.Errorf("unsupported output type '%s' (supported: summary, csv, json)", recommendType)
+}
+
+// writeRecommendationSummary writes a human-readable recommendation report
+func writeRecommendationSummary(report *cost.RecommendationReport, writer io.Writer) error {
+	fmt.Fprintln(writer, "=== Savings Recommendations ===")
+	fmt.Fprintln(writer)
+
+	fmt.Fprintln(writer, "Summary:")
+	fmt.Fprintf(writer, "  Recommendations:  %d\n", len(report.Recommendations))
+	fmt.Fprintf(writer, "  Monthly saving:   %.2f %s\n", report.TotalSaving, report.Currency)
+	for _, total := range report.ByRule {
+		fmt.Fprintf(writer, "  %-22s %d (%.2f %s)\n", total.Rule+":", total.Count, total.MonthlySaving, report.Currency)
+	}
+	fmt.Fprintln(writer)
+
+	if len(report.Recommendations) == 0 {
+		return nil
+	}
+
+	table := tabwriter.NewWriter(writer, 0, 0, 2, ' ', 0)
+	fmt.Fprintln(table, "RULE\tRESOURCE\tTYPE\tREGION\tSAVING\tREASON")
+	for _, recommendation := range report.Recommendations {
+		fmt.Fprintf(table, "%s\t%s\t%s\t%s\t%.2f\t%s\n",
+			recommendation.Rule, recommendation.Name, recommendation.ResourceType, recommendation.Region,
+			recommendation.MonthlySaving, recommendation.Reason)
+	}
+
+	return table.Flush()
+}
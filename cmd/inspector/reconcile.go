@@ -0,0 +1,177 @@
+package main
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"os"
+
+	"github.com/spf13/cobra"
+
+	"github.com/comfortablynumb/pmp-cloud-inspector/pkg/config"
+	"github.com/comfortablynumb/pmp-cloud-inspector/pkg/resource"
+	"github.com/comfortablynumb/pmp-cloud-inspector/pkg/terraform"
+)
+
+var (
+	reconcileInput  string
+	reconcileStates []string
+	reconcileOutput string
+	reconcileType   string
+)
+
+var reconcileCmd = &cobra.Command{
+	Use:   "reconcile",
+	Short: "Reconcile an export against Terraform state files",
+	Long: `Match the resources of an export against one or more Terraform state files (v4 JSON)
+to find resources not managed by Terraform and state entries that no longer exist in the cloud.
+
+Examples:
+  # Reconcile an export against a single state file
+  pmp-cloud-inspector reconcile -i export.json -s terraform.tfstate
+
+  # Reconcile against every *.tfstate in a directory and write a JSON report
+  pmp-cloud-inspector reconcile -i export.json -s ./states -t json -o report.json`,
+	RunE: runReconcile,
+}
+
+func init() {
+	reconcileCmd.Flags().StringVarP(&reconcileInput, "input", "i", "", "Export file to reconcile (JSON)")
+	reconcileCmd.Flags().StringSliceVarP(&reconcileStates, "state", "s", nil, "Terraform state file or directory of state files (repeatable)")
+	reconcileCmd.Flags().StringVarP(&reconcileOutput, "output", "o", "", "Output file (defaults to stdout)")
+	reconcileCmd.Flags().StringVarP(&reconcileType, "type", "t", "summary", "Output type: summary, json")
+	if err := reconcileCmd.MarkFlagRequired("input"); err != nil {
+		fmt.Fprintf(os.Stderr, "Warning: failed to mark input flag as required: %v\n", err)
+	}
+	if err := reconcileCmd.MarkFlagRequired("state"); err != nil {
+		fmt.Fprintf(os.Stderr, "Warning: failed to mark state flag as required: %v\n", err)
+	}
+}
+
+func runReconcile(cmd *cobra.Command, args []string) error {
+	collection, err := loadExport(reconcileInput)
+	if err != nil {
+		return fmt.Errorf("failed to load export: %w", err)
+	}
+
+	// The configuration of the export is not known: ghosts are limited to what it contains
+	report, err := reconcileTerraformStates(collection, reconcileStates, terraform.CollectionScope(collection))
+	if err != nil {
+		return err
+	}
+
+	writer := os.Stdout
+	if reconcileOutput != "" {
+		// #nosec G304 - reconcileOutput is provided by user as CLI argument, this is expected behavior
+		writer, err = os.Create(reconcileOutput)
+		if err != nil {
+			return fmt.Errorf("failed to create output file: %w", err)
+		}
+		defer func() {
+			if closeErr := writer.Close(); closeErr != nil {
+				fmt.Fprintf(os.Stderr, "Warning: failed to close output file: %v\n", closeErr)
+			}
+		}()
+	}
+
+	if reconcileType == "json" {
+		return writeReconcileJSON(report, writer)
+	}
+
+	return writeReconcileSummary(report, writer)
+}
+
+// reconcileTerraformStates loads the given state files and reconciles the collection against them,
+// reporting ghosts within the scope of what was collected
+func reconcileTerraformStates(collection *resource.Collection, paths []string, scope terraform.Scope) (*terraform.Report, error) {
+	states, err := terraform.LoadStates(paths)
+	if err != nil {
+		return nil, fmt.Errorf("failed to load Terraform states: %w", err)
+	}
+
+	fmt.Fprintf(os.Stderr, "Loaded %d Terraform state files\n", len(states))
+
+	return terraform.Reconcile(collection, states, scope), nil
+}
+
+// configScope returns the resource types and regions an inspection configuration collects
+func configScope(cfg *config.Config) terraform.Scope {
+	var scope terraform.Scope
+	if !cfg.Resources.IncludeAll {
+		for _, t := range cfg.Resources.Types {
+			scope.Types = append(scope.Types, resource.ResourceType(t))
+		}
+	}
+
+	// Regions are only limited if every provider is limited to some regions
+	for _, providerCfg := range cfg.Providers {
+		if len(providerCfg.Regions) == 0 {
+			scope.Regions = nil
+			break
+		}
+		scope.Regions = append(scope.Regions, providerCfg.Regions...)
+	}
+
+	return scope
+}
+
+// writeTerraformReport writes a reconciliation report as JSON to the given file
+func writeTerraformReport(report *terraform.Report, path string) error {
+	// #nosec G304 - path is provided by user as CLI argument, this is expected behavior
+	file, err := os.Create(path)
+	if err != nil {
+		return fmt.Errorf("failed to create Terraform report file: %w", err)
+	}
+	defer func() {
+		if closeErr := file.Close(); closeErr != nil {
+			fmt.Fprintf(os.Stderr, "Warning: failed to close Terraform report file: %v\n", closeErr)
+		}
+	}()
+
+	fmt.Fprintf(os.Stderr, "Writing Terraform reconciliation report to %s...\n", path)
+
+	return writeReconcileJSON(report, file)
+}
+
+// writeReconcileJSON writes a reconciliation report as JSON
+func writeReconcileJSON(report *terraform.Report, writer io.Writer) error {
+	encoder := json.NewEncoder(writer)
+	encoder.SetIndent("", "  ")
+	if err := encoder.Encode(report); err != nil {
+		return fmt.Errorf("failed to encode JSON: %w", err)
+	}
+	return nil
+}
+
+// writeReconcileSummary writes a human-readable reconciliation report
+func writeReconcileSummary(report *terraform.Report, writer io.Writer) error {
+	fmt.Fprintln(writer, "=== Terraform Reconciliation Report ===")
+	fmt.Fprintf(writer, "State files: %d\n", len(report.States))
+	fmt.Fprintln(writer)
+
+	fmt.Fprintln(writer, "Summary:")
+	fmt.Fprintf(writer, "  Resources:         %d\n", report.Summary.TotalResources)
+	fmt.Fprintf(writer, "  Managed:           %d\n", report.Summary.Managed)
+	fmt.Fprintf(writer, "  Unmanaged:         %d\n", report.Summary.Unmanaged)
+	fmt.Fprintf(writer, "  Ghosts:            %d\n", report.Summary.Ghosts)
+	fmt.Fprintf(writer, "  Unsupported types: %d\n", report.Summary.UnsupportedTypes)
+	fmt.Fprintln(writer)
+
+	if len(report.Unmanaged) > 0 {
+		fmt.Fprintf(writer, "Unmanaged Resources (%d):\n", len(report.Unmanaged))
+		for _, res := range report.Unmanaged {
+			fmt.Fprintf(writer, "  ? [%s] %s (%s)\n", res.Type, res.Name, res.ID)
+		}
+		fmt.Fprintln(writer)
+	}
+
+	if len(report.Ghosts) > 0 {
+		fmt.Fprintf(writer, "Ghost Resources (%d):\n", len(report.Ghosts))
+		for _, ghost := range report.Ghosts {
+			fmt.Fprintf(writer, "  ! %s (%s) in %s\n", ghost.Address, ghost.ID, ghost.StateFile)
+		}
+		fmt.Fprintln(writer)
+	}
+
+	return nil
+}
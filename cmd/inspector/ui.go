@@ -5,11 +5,16 @@ import (
 
 	"github.com/spf13/cobra"
 
+	"github.com/comfortablynumb/pmp-cloud-inspector/pkg/config"
+	"github.com/comfortablynumb/pmp-cloud-inspector/pkg/cost"
 	"github.com/comfortablynumb/pmp-cloud-inspector/pkg/ui"
 )
 
 var (
-	port int
+	port            int
+	uiConfig        string
+	uiCurrency      string
+	uiExchangeRates string
 )
 
 var uiCmd = &cobra.Command{
@@ -18,18 +23,56 @@ var uiCmd = &cobra.Command{
 	Long: `Start a web server that provides a user interface for viewing cloud resources.
 
 The UI allows you to upload exported JSON or YAML files and view the resources
-in a beautiful, interactive interface built with Tailwind CSS and jQuery.`,
+in a beautiful, interactive interface built with Tailwind CSS and jQuery.
+
+When a configuration file is given, its named filter sets are offered as presets.
+Costs can be converted to another currency with exchange rates from --exchange-rates or
+from the cost section of the configuration file.`,
 	RunE: runUI,
 }
 
 func init() {
 	uiCmd.Flags().IntVarP(&port, "port", "p", 8080, "Port to listen on")
+	uiCmd.Flags().StringVarP(&uiConfig, "config", "c", "", "Configuration file providing filter set presets (optional)")
+	uiCmd.Flags().StringVar(&uiCurrency, "currency", "", "Default currency costs are shown in, e.g. EUR (overrides cost.currency of the config)")
+	uiCmd.Flags().StringVar(&uiExchangeRates, "exchange-rates", "", "Exchange rates file (JSON or YAML) used to convert costs (overrides cost.exchange_rates of the config)")
 }
 
 func runUI(cmd *cobra.Command, args []string) error {
+	var cfg *config.Config
+	if uiConfig != "" {
+		var err error
+		cfg, err = config.LoadConfig(uiConfig)
+		if err != nil {
+			return fmt.Errorf("failed to load config: %w", err)
+		}
+		fmt.Printf("Loaded %d filter sets from %s\n", len(cfg.Filters), uiConfig)
+	}
+
 	fmt.Printf("Starting PMP Cloud Inspector UI on port %d...\n", port)
 	fmt.Printf("Open your browser at http://localhost:%d\n", port)
 
-	server := ui.NewServer(port)
+	// Exchange rates used to convert costs
+	ratesFile, currency := uiExchangeRates, uiCurrency
+	var staticRates map[string]float64
+	if cfg != nil {
+		if ratesFile == "" {
+			ratesFile = cfg.Cost.ExchangeRates
+		}
+		if currency == "" {
+			currency = cfg.Cost.Currency
+		}
+		staticRates = cfg.Cost.Rates
+	}
+	rates, err := cost.ResolveExchangeRates(ratesFile, staticRates)
+	if err != nil {
+		return err
+	}
+	if rates != nil {
+		fmt.Printf("Loaded exchange rates %s (%d currencies)\n", rates.Version, len(rates.Rates))
+	}
+
+	server := ui.NewServer(port, cfg)
+	server.SetCurrency(currency, rates)
 	return server.Start()
 }
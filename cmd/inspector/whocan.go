@@ -0,0 +1,195 @@
+package main
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"os"
+	"strings"
+	"text/tabwriter"
+
+	"github.com/spf13/cobra"
+
+	"github.com/comfortablynumb/pmp-cloud-inspector/pkg/exporter"
+	"github.com/comfortablynumb/pmp-cloud-inspector/pkg/iampolicy"
+	"github.com/comfortablynumb/pmp-cloud-inspector/pkg/resource"
+)
+
+var (
+	whoCanInput    string
+	whoCanOutput   string
+	whoCanType     string
+	whoCanAction   string
+	whoCanResource string
+	whoCanAttach   string
+)
+
+var whoCanCmd = &cobra.Command{
+	Use:   "who-can",
+	Short: "List the IAM principals that can perform an action on a resource",
+	Long: `Evaluate the identity-based policies of the IAM users and roles of an export to list the
+principals that can perform an action on a resource: their attached managed policies, their
+inline policies and the policies of the groups of users. Explicit denies win over allows, as in
+AWS.
+
+Principals allowed only by statements with conditions or policy variables are reported as
+conditional. Permissions boundaries, service control policies, session policies and
+resource-based policies are not evaluated.
+
+The resource is an ARN, or the ID or name of a resource of the export. With --attach, the
+export is written back with a has_access relationship from every principal to the resource,
+with the action as permission, for the graph and the access-review command.
+
+Examples:
+  # Who can read the objects of a bucket
+  pmp-cloud-inspector who-can -i export.json -a s3:GetObject -r 'arn:aws:s3:::my-bucket/*'
+
+  # Who can invoke a function, as JSON
+  pmp-cloud-inspector who-can -i export.json -a lambda:InvokeFunction -r my-function -t json
+
+  # Attach the principals that can delete a table to the export
+  pmp-cloud-inspector who-can -i export.json -a dynamodb:DeleteTable -r orders --attach export-access.json`,
+	RunE: runWhoCan,
+}
+
+func init() {
+	whoCanCmd.Flags().StringVarP(&whoCanInput, "input", "i", "", "Export file (JSON)")
+	whoCanCmd.Flags().StringVarP(&whoCanOutput, "output", "o", "", "Output file (defaults to stdout)")
+	whoCanCmd.Flags().StringVarP(&whoCanType, "type", "t", "summary", "Output type: summary, json")
+	whoCanCmd.Flags().StringVarP(&whoCanAction, "action", "a", "", "Action, e.g., s3:GetObject")
+	whoCanCmd.Flags().StringVarP(&whoCanResource, "resource", "r", "", "Resource ARN, ID or name")
+	whoCanCmd.Flags().StringVar(&whoCanAttach, "attach", "", "Write the export with has_access relationships to the resource to this file")
+	for _, name := range []string{"input", "action", "resource"} {
+		if err := whoCanCmd.MarkFlagRequired(name); err != nil {
+			fmt.Fprintf(os.Stderr, "Warning: failed to mark %s flag as required: %v\n", name, err)
+		}
+	}
+}
+
+// whoCanOutputJSON is the JSON output of the who-can command
+type whoCanOutputJSON struct {
+	Action     string             `json:"action"`
+	Resource   string             `json:"resource"`
+	Principals []iampolicy.Result `json:"principals"`
+	Missing    []string           `json:"missing_policies,omitempty"` // attached managed policies without document
+}
+
+func runWhoCan(cmd *cobra.Command, args []string) error {
+	collection, err := loadExport(whoCanInput)
+	if err != nil {
+		return fmt.Errorf("failed to load export: %w", err)
+	}
+
+	target := findResource(collection, whoCanResource)
+	arn := whoCanResource
+	if target != nil {
+		arn = iampolicy.ResourceARN(target)
+	} else if whoCanAttach != "" {
+		return fmt.Errorf("resource '%s' not found in the export, --attach requires a collected resource", whoCanResource)
+	}
+
+	evaluator := iampolicy.NewEvaluator(collection)
+	if len(evaluator.Missing) > 0 {
+		fmt.Fprintf(os.Stderr, "Warning: %d attached managed policies were not collected, include aws:iam:policy in the inspected types\n",
+			len(evaluator.Missing))
+	}
+
+	results := evaluator.WhoCan(whoCanAction, arn)
+
+	if whoCanAttach != "" {
+		added := iampolicy.Attach(results, target, whoCanAction)
+		if err := writeExport(collection, whoCanAttach); err != nil {
+			return err
+		}
+		fmt.Fprintf(os.Stderr, "Attached %d has_access relationships to %s in %s\n", added, target.Name, whoCanAttach)
+	}
+
+	writer := os.Stdout
+	if whoCanOutput != "" {
+		// #nosec G304 - whoCanOutput is provided by user as CLI argument, this is expected behavior
+		writer, err = os.Create(whoCanOutput)
+		if err != nil {
+			return fmt.Errorf("failed to create output file: %w", err)
+		}
+		defer func() {
+			if closeErr := writer.Close(); closeErr != nil {
+				fmt.Fprintf(os.Stderr, "Warning: failed to close output file: %v\n", closeErr)
+			}
+		}()
+	}
+
+	switch whoCanType {
+	case "json":
+		output := whoCanOutputJSON{
+			Action:     whoCanAction,
+			Resource:   arn,
+			Principals: results,
+			Missing:    evaluator.Missing,
+		}
+		if output.Principals == nil {
+			output.Principals = []iampolicy.Result{}
+		}
+		encoder := json.NewEncoder(writer)
+		encoder.SetIndent("", "  ")
+		if err := encoder.Encode(output); err != nil {
+			return fmt.Errorf("failed to encode JSON: %w", err)
+		}
+		return nil
+	case "summary":
+		return writeWhoCanSummary(results, arn, writer)
+	}
+
+	return fmt.Errorf("unsupported output type '%s' (supported: summary, json)", whoCanType)
+}
+
+// findResource finds a resource of a collection by ARN or ID, or else by name
+func findResource(collection *resource.Collection, query string) *resource.Resource {
+	for _, res := range collection.Resources {
+		if res.ARN == query || res.ID == query || iampolicy.ResourceARN(res) == query {
+			return res
+		}
+	}
+	for _, res := range collection.Resources {
+		if res.Name == query {
+			return res
+		}
+	}
+	return nil
+}
+
+// writeExport writes a collection as a JSON export
+func writeExport(collection *resource.Collection, path string) error {
+	jsonExporter, err := exporter.Get("json")
+	if err != nil {
+		return err
+	}
+
+	return exporter.WriteFileAtomic(path, func(w io.Writer) error {
+		return jsonExporter.Export(collection, w, exporter.ExportOptions{Pretty: true})
+	})
+}
+
+// writeWhoCanSummary writes a human-readable list of the principals that can perform an action
+func writeWhoCanSummary(results []iampolicy.Result, arn string, writer io.Writer) error {
+	fmt.Fprintln(writer, "=== Who Can ===")
+	fmt.Fprintln(writer)
+
+	fmt.Fprintln(writer, "Summary:")
+	fmt.Fprintf(writer, "  Action:                %s\n", whoCanAction)
+	fmt.Fprintf(writer, "  Resource:              %s\n", arn)
+	fmt.Fprintf(writer, "  Principals:            %d\n", len(results))
+	fmt.Fprintln(writer)
+
+	if len(results) == 0 {
+		return nil
+	}
+
+	table := tabwriter.NewWriter(writer, 0, 0, 2, ' ', 0)
+	fmt.Fprintln(table, "PRINCIPAL\tTYPE\tACCOUNT\tDECISION\tPOLICIES")
+	for _, r := range results {
+		fmt.Fprintf(table, "%s\t%s\t%s\t%s\t%s\n",
+			r.Principal, r.PrincipalType, r.Account, r.Decision, strings.Join(r.Policies, ", "))
+	}
+
+	return table.Flush()
+}
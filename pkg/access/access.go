@@ -0,0 +1,267 @@
+package access
+
+import (
+	"cmp"
+	"slices"
+	"strings"
+
+	"github.com/comfortablynumb/pmp-cloud-inspector/pkg/identity"
+	"github.com/comfortablynumb/pmp-cloud-inspector/pkg/resource"
+)
+
+// UnassignedOwner is the owner of the grants of principals that are not user accounts nor
+// groups of user accounts, e.g., AWS IAM roles
+const UnassignedOwner = "(unassigned)"
+
+// groupTypes lists the types of principals whose grants apply to their members: teams and
+// groups, whose members are linked by contains or belongs_to relationships, and Auth0 roles,
+// granted to users by has_access relationships. GitLab groups are not principals: the
+// membership in a GitLab group is the grant itself.
+var groupTypes = map[resource.ResourceType]bool{
+	resource.TypeAWSIAMGroup: true,
+	resource.TypeGitHubTeam:  true,
+	resource.TypeJFrogGroup:  true,
+	resource.TypeOktaGroup:   true,
+	resource.TypeAuth0Role:   true,
+}
+
+// Grant is an effective access of a principal to a resource
+type Grant struct {
+	Owner         string                `json:"owner"` // person, or user account when not linked to a person
+	OwnerEmail    string                `json:"owner_email,omitempty"`
+	Provider      string                `json:"provider"`
+	Account       string                `json:"account,omitempty"` // AWS account or GitHub organization
+	Principal     string                `json:"principal"`         // account holding the access
+	PrincipalType resource.ResourceType `json:"principal_type"`
+	Via           string                `json:"via,omitempty"` // group, team or role the access is inherited from
+	ViaType       resource.ResourceType `json:"via_type,omitempty"`
+	Resource      string                `json:"resource"`
+	ResourceID    string                `json:"resource_id"`
+	ResourceType  resource.ResourceType `json:"resource_type"`
+	Permission    string                `json:"permission,omitempty"`
+	Actions       []string              `json:"actions,omitempty"`
+
+	ownerID     string
+	principalID string
+}
+
+// Owner is a person, user account or the unassigned owner with the number of grants to review
+type Owner struct {
+	ID        string   `json:"id"` // person or user account ID
+	Name      string   `json:"name"`
+	Email     string   `json:"email,omitempty"`
+	Accounts  []string `json:"accounts"` // accounts holding the grants, as provider:name
+	Providers []string `json:"providers"`
+	Grants    int      `json:"grants"`
+}
+
+// Report is the access review of an inventory: the effective access of every owner
+type Report struct {
+	Owners     []Owner `json:"owners"`
+	Grants     []Grant `json:"grants"`
+	Principals int     `json:"principals"` // principals holding at least one grant
+	Unassigned int     `json:"unassigned"` // grants of the unassigned owner
+}
+
+// key identifies a resource by type and ID, as IDs are only unique within a resource type
+type key struct {
+	Type resource.ResourceType
+	ID   string
+}
+
+// owner is the owner of the grants of a user account
+type owner struct {
+	id    string
+	name  string
+	email string
+}
+
+// Review flattens the has_access relationships of a collection into the effective access of
+// every owner. User accounts are owned by the person referencing them among people (see
+// identity correlation), or by themselves. Groups, teams and roles pass their grants to their
+// members.
+func Review(collection *resource.Collection, people []*resource.Resource) *Report {
+	index := make(map[key]*resource.Resource, len(collection.Resources))
+	for _, res := range collection.Resources {
+		index[key{res.Type, res.ID}] = res
+	}
+
+	ownerOf := make(map[key]owner)
+	for _, person := range people {
+		emails := person.StringsProperty("emails")
+		for _, rel := range person.Relationships {
+			if rel.Type != resource.RelationReferences {
+				continue
+			}
+			o := owner{id: person.ID, name: person.Name}
+			if len(emails) > 0 {
+				o.email = emails[0]
+			}
+			ownerOf[key{rel.TargetType, rel.TargetID}] = o
+		}
+	}
+
+	// Members of groups, by user account
+	memberships := make(map[key][]*resource.Resource)
+	addMember := func(user key, group *resource.Resource) {
+		if !slices.Contains(memberships[user], group) {
+			memberships[user] = append(memberships[user], group)
+		}
+	}
+	for _, res := range collection.Resources {
+		for _, rel := range res.Relationships {
+			switch {
+			case groupTypes[res.Type] && rel.Type == resource.RelationContains && identity.IsUserType(rel.TargetType):
+				addMember(key{rel.TargetType, rel.TargetID}, res)
+			case identity.IsUserType(res.Type) && groupTypes[rel.TargetType] &&
+				(rel.Type == resource.RelationBelongsTo || rel.Type == resource.RelationHasAccess):
+				if group := index[key{rel.TargetType, rel.TargetID}]; group != nil {
+					addMember(key{res.Type, res.ID}, group)
+				}
+			}
+		}
+	}
+
+	report := &Report{}
+	grant := func(o owner, principal, via *resource.Resource, rel resource.Relationship) {
+		g := Grant{
+			Owner:         o.name,
+			OwnerEmail:    o.email,
+			Provider:      principal.Provider,
+			Account:       principal.Account,
+			Principal:     principal.Name,
+			PrincipalType: principal.Type,
+			Resource:      rel.TargetID,
+			ResourceID:    rel.TargetID,
+			ResourceType:  rel.TargetType,
+			Actions:       resource.ToStrings(rel.Properties[resource.PropActions]),
+			ownerID:       o.id,
+			principalID:   string(principal.Type) + ":" + principal.ID,
+		}
+		if via != nil {
+			g.Via = via.Name
+			g.ViaType = via.Type
+		}
+		if target := index[key{rel.TargetType, rel.TargetID}]; target != nil {
+			g.Resource = target.Name
+			if target.Account != "" {
+				g.Account = target.Account
+			}
+		}
+		if permission, ok := rel.Properties[resource.PropPermission].(string); ok {
+			g.Permission = permission
+		}
+		report.Grants = append(report.Grants, g)
+	}
+
+	for _, res := range collection.Resources {
+		switch {
+		case identity.IsUserType(res.Type):
+			o, ok := ownerOf[key{res.Type, res.ID}]
+			if !ok {
+				o = owner{id: string(res.Type) + ":" + res.ID, name: res.Name}
+				if email, ok := res.StringProperty("email"); ok {
+					o.email = email
+				}
+			}
+			for _, rel := range res.Relationships {
+				if rel.Type == resource.RelationHasAccess {
+					grant(o, res, nil, rel)
+				}
+			}
+			for _, group := range memberships[key{res.Type, res.ID}] {
+				for _, rel := range group.Relationships {
+					if rel.Type == resource.RelationHasAccess {
+						grant(o, res, group, rel)
+					}
+				}
+			}
+		case !groupTypes[res.Type]:
+			// Grants of groups are listed for their members, those of other principals are
+			// left for the reviewer to assign
+			for _, rel := range res.Relationships {
+				if rel.Type == resource.RelationHasAccess {
+					grant(owner{id: UnassignedOwner, name: UnassignedOwner}, res, nil, rel)
+					report.Unassigned++
+				}
+			}
+		}
+	}
+
+	slices.SortStableFunc(report.Grants, func(a, b Grant) int {
+		// The unassigned owner goes last
+		if unassigned := a.ownerID == UnassignedOwner; unassigned != (b.ownerID == UnassignedOwner) {
+			if unassigned {
+				return 1
+			}
+			return -1
+		}
+		return cmp.Or(
+			cmp.Compare(strings.ToLower(a.Owner), strings.ToLower(b.Owner)),
+			cmp.Compare(a.ownerID, b.ownerID),
+			cmp.Compare(a.Provider, b.Provider),
+			cmp.Compare(a.Principal, b.Principal),
+			cmp.Compare(a.Resource, b.Resource),
+			cmp.Compare(a.Via, b.Via),
+		)
+	})
+
+	report.Principals = countPrincipals(report.Grants)
+	report.Owners = owners(report.Grants)
+	return report
+}
+
+// ForOwner returns the report of the grants of an owner
+func (r *Report) ForOwner(id string) *Report {
+	owned := &Report{}
+	for _, g := range r.Grants {
+		if g.ownerID != id {
+			continue
+		}
+		owned.Grants = append(owned.Grants, g)
+		if id == UnassignedOwner {
+			owned.Unassigned++
+		}
+	}
+	owned.Principals = countPrincipals(owned.Grants)
+	owned.Owners = owners(owned.Grants)
+	return owned
+}
+
+// countPrincipals counts the principals holding grants
+func countPrincipals(grants []Grant) int {
+	principals := make(map[string]bool)
+	for _, g := range grants {
+		principals[g.principalID] = true
+	}
+	return len(principals)
+}
+
+// owners lists the owners of sorted grants, in the same order
+func owners(grants []Grant) []Owner {
+	var list []Owner
+	positions := make(map[string]int)
+	for _, g := range grants {
+		i, ok := positions[g.ownerID]
+		if !ok {
+			i = len(list)
+			positions[g.ownerID] = i
+			list = append(list, Owner{ID: g.ownerID, Name: g.Owner, Email: g.OwnerEmail})
+		}
+
+		o := &list[i]
+		o.Grants++
+		if account := g.Provider + ":" + g.Principal; !slices.Contains(o.Accounts, account) {
+			o.Accounts = append(o.Accounts, account)
+		}
+		if !slices.Contains(o.Providers, g.Provider) {
+			o.Providers = append(o.Providers, g.Provider)
+		}
+	}
+
+	for i := range list {
+		slices.Sort(list[i].Accounts)
+		slices.Sort(list[i].Providers)
+	}
+	return list
+}
@@ -0,0 +1,70 @@
+package access
+
+import (
+	"encoding/csv"
+	"fmt"
+	"io"
+	"strconv"
+	"strings"
+)
+
+// grantColumns are the columns of grant rows. The decision column is left empty for the
+// reviewer to sign off each grant (e.g., keep or revoke).
+var grantColumns = []string{
+	"owner", "owner_email", "provider", "account", "principal", "principal_type", "via",
+	"resource", "resource_type", "permission", "actions", "decision",
+}
+
+// row returns the values of a grant in the order of grantColumns
+func (g Grant) row() []string {
+	via := g.Via
+	if via != "" {
+		via = fmt.Sprintf("%s (%s)", g.Via, g.ViaType)
+	}
+	return []string{
+		g.Owner, g.OwnerEmail, g.Provider, g.Account, g.Principal, string(g.PrincipalType), via,
+		g.Resource, string(g.ResourceType), g.Permission, strings.Join(g.Actions, ", "), "",
+	}
+}
+
+// WriteCSV writes the grants as CSV, one row per grant
+func (r *Report) WriteCSV(writer io.Writer) error {
+	w := csv.NewWriter(writer)
+
+	if err := w.Write(grantColumns); err != nil {
+		return fmt.Errorf("failed to write CSV: %w", err)
+	}
+	for _, g := range r.Grants {
+		if err := w.Write(g.row()); err != nil {
+			return fmt.Errorf("failed to write CSV: %w", err)
+		}
+	}
+
+	w.Flush()
+	return w.Error()
+}
+
+// WriteXLSX writes the grants as an XLSX workbook with a summary worksheet listing the owners,
+// followed by a worksheet per owner for sign-off
+func (r *Report) WriteXLSX(writer io.Writer) error {
+	sheets := []sheet{{
+		Name: "Summary",
+		Rows: [][]string{{"owner", "email", "grants", "providers", "accounts"}},
+	}}
+
+	for _, o := range r.Owners {
+		sheets[0].Rows = append(sheets[0].Rows, []string{
+			o.Name, o.Email, strconv.Itoa(o.Grants), strings.Join(o.Providers, ", "), strings.Join(o.Accounts, ", "),
+		})
+
+		owned := sheet{Name: o.Name, Rows: [][]string{grantColumns}}
+		for _, g := range r.Grants {
+			if g.ownerID == o.ID {
+				owned.Rows = append(owned.Rows, g.row())
+			}
+		}
+		sheets = append(sheets, owned)
+	}
+
+	return writeXLSX(writer, sheets)
+}
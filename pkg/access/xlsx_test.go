@@ -0,0 +1,138 @@
+package access
+
+import (
+	"archive/zip"
+	"bytes"
+	"encoding/xml"
+	"fmt"
+	"io"
+	"strings"
+	"testing"
+)
+
+func TestWriteXLSX(t *testing.T) {
+	long := strings.Repeat("platform-engineering-", 3)
+	sheets := []sheet{
+		{Name: "Summary", Rows: [][]string{{"Owner", "Grants"}, {"Summary", "1"}}},
+		{Name: "Summary", Rows: [][]string{{"Principal", "Role"}, {"alice <admin> & co", "roles/owner"}}},
+		{Name: "SUMMARY", Rows: [][]string{{"Principal"}}},
+		{Name: long, Rows: [][]string{{"Principal"}, {"bob"}}},
+		{Name: long, Rows: [][]string{{"Principal"}, {"carol"}}},
+		{Name: "team/a: [ops]?", Rows: [][]string{{"Principal"}}},
+		{Name: "''", Rows: [][]string{{"Principal"}}},
+	}
+	wantNames := []string{
+		"Summary",
+		"Summary (2)",
+		"SUMMARY (3)",
+		long[:31],
+		long[:27] + " (2)",
+		"team_a_ (ops)_",
+		"Sheet",
+	}
+
+	var buf bytes.Buffer
+	if err := writeXLSX(&buf, sheets); err != nil {
+		t.Fatalf("writeXLSX failed: %v", err)
+	}
+
+	archive, err := zip.NewReader(bytes.NewReader(buf.Bytes()), int64(buf.Len()))
+	if err != nil {
+		t.Fatalf("failed to open the workbook: %v", err)
+	}
+	parts := make(map[string][]byte)
+	for _, file := range archive.File {
+		reader, err := file.Open()
+		if err != nil {
+			t.Fatal(err)
+		}
+		data, err := io.ReadAll(reader)
+		_ = reader.Close()
+		if err != nil {
+			t.Fatal(err)
+		}
+		parts[file.Name] = data
+	}
+
+	// Every part is well-formed XML
+	for name, data := range parts {
+		decoder := xml.NewDecoder(bytes.NewReader(data))
+		for {
+			if _, err := decoder.Token(); err == io.EOF {
+				break
+			} else if err != nil {
+				t.Fatalf("%s is not well-formed: %v", name, err)
+			}
+		}
+	}
+
+	var workbook struct {
+		Sheets []struct {
+			Name string `xml:"name,attr"`
+		} `xml:"sheets>sheet"`
+	}
+	if err := xml.Unmarshal(parts["xl/workbook.xml"], &workbook); err != nil {
+		t.Fatalf("failed to parse the workbook: %v", err)
+	}
+	if len(workbook.Sheets) != len(wantNames) {
+		t.Fatalf("got %d sheets, want %d", len(workbook.Sheets), len(wantNames))
+	}
+	for i, want := range wantNames {
+		if got := workbook.Sheets[i].Name; got != want {
+			t.Errorf("sheet %d: got name %q, want %q", i, got, want)
+		}
+		if len([]rune(workbook.Sheets[i].Name)) > maxSheetName {
+			t.Errorf("sheet %d: name %q is longer than %d characters", i, workbook.Sheets[i].Name, maxSheetName)
+		}
+	}
+
+	for i, s := range sheets {
+		name := fmt.Sprintf("xl/worksheets/sheet%d.xml", i+1)
+		var worksheet struct {
+			Rows []struct {
+				Cells []struct {
+					Ref   string `xml:"r,attr"`
+					Value string `xml:"is>t"`
+				} `xml:"c"`
+			} `xml:"sheetData>row"`
+		}
+		if err := xml.Unmarshal(parts[name], &worksheet); err != nil {
+			t.Fatalf("failed to parse %s: %v", name, err)
+		}
+
+		if len(worksheet.Rows) != len(s.Rows) {
+			t.Fatalf("%s: got %d rows, want %d", name, len(worksheet.Rows), len(s.Rows))
+		}
+		for r, row := range s.Rows {
+			if len(worksheet.Rows[r].Cells) != len(row) {
+				t.Fatalf("%s row %d: got %d cells, want %d", name, r+1, len(worksheet.Rows[r].Cells), len(row))
+			}
+			for c, want := range row {
+				cell := worksheet.Rows[r].Cells[c]
+				if wantRef := fmt.Sprintf("%s%d", columnName(c), r+1); cell.Ref != wantRef || cell.Value != want {
+					t.Errorf("%s: got cell %s = %q, want %s = %q", name, cell.Ref, cell.Value, wantRef, want)
+				}
+			}
+		}
+	}
+}
+
+func TestColumnName(t *testing.T) {
+	tests := []struct {
+		index int
+		want  string
+	}{
+		{0, "A"},
+		{25, "Z"},
+		{26, "AA"},
+		{27, "AB"},
+		{701, "ZZ"},
+		{702, "AAA"},
+	}
+
+	for _, tt := range tests {
+		if got := columnName(tt.index); got != tt.want {
+			t.Errorf("columnName(%d) = %q, want %q", tt.index, got, tt.want)
+		}
+	}
+}
@@ -3,15 +3,27 @@ package config
 import (
 	"fmt"
 	"os"
+	"path/filepath"
+	"strings"
 
 	"gopkg.in/yaml.v3"
+
+	"github.com/comfortablynumb/pmp-cloud-inspector/pkg/cost"
+	"github.com/comfortablynumb/pmp-cloud-inspector/pkg/exporter"
+	"github.com/comfortablynumb/pmp-cloud-inspector/pkg/filter"
+	"github.com/comfortablynumb/pmp-cloud-inspector/pkg/identity"
+	"github.com/comfortablynumb/pmp-cloud-inspector/pkg/policy"
 )
 
 // Config represents the main configuration structure
 type Config struct {
-	Providers []ProviderConfig `yaml:"providers"`
-	Resources ResourceConfig   `yaml:"resources"`
-	Export    ExportConfig     `yaml:"export"`
+	Providers []ProviderConfig      `yaml:"providers"`
+	Resources ResourceConfig        `yaml:"resources"`
+	Export    ExportConfig          `yaml:"export"`
+	Cost      CostConfig            `yaml:"cost"`
+	Filters   map[string]*FilterSet `yaml:"filters"`  // named, reusable filter sets
+	Policy    policy.Settings       `yaml:"policy"`   // compliance rules evaluated by audit
+	Identity  identity.Settings     `yaml:"identity"` // correlation of user accounts into people
 }
 
 // ProviderConfig defines cloud provider configuration
@@ -28,15 +40,63 @@ type ResourceConfig struct {
 	Types         []string `yaml:"types"`         // specific resource types (empty = all)
 	IncludeAll    bool     `yaml:"include_all"`   // include all resource types
 	Relationships bool     `yaml:"relationships"` // track relationships between resources
+	Where         string   `yaml:"where"`         // filter expression applied to collected resources
 }
 
 // ExportConfig defines export settings
 type ExportConfig struct {
-	Format     string   `yaml:"format"`      // json, yaml, dot, etc.
-	OutputFile string   `yaml:"output_file"` // output file path
-	Pretty     bool     `yaml:"pretty"`      // pretty print output
-	IncludeRaw bool     `yaml:"include_raw"` // include raw cloud provider data
-	Formats    []string `yaml:"formats"`     // multiple output formats
+	Format     string         `yaml:"format"`      // json, yaml, dot, etc.
+	OutputFile string         `yaml:"output_file"` // output file path
+	Pretty     bool           `yaml:"pretty"`      // pretty print output
+	IncludeRaw bool           `yaml:"include_raw"` // include raw cloud provider data
+	Formats    []string       `yaml:"formats"`     // multiple output formats
+	Targets    []ExportTarget `yaml:"targets"`     // export destinations written from a single collection pass
+}
+
+// CostConfig defines cost estimation settings
+type CostConfig struct {
+	Catalogs       []string              `yaml:"catalogs"`        // pricing catalog files or directories, loaded after the bundled and refreshed catalogs
+	Assumptions    cost.UsageAssumptions `yaml:"assumptions"`     // monthly usage assumed for usage-priced components (zero = default)
+	AllocationTags []string              `yaml:"allocation_tags"` // tag keys used to allocate costs, in priority order
+	DeltaThreshold float64               `yaml:"delta_threshold"` // percentage above which cost changes are flagged by compare --cost
+	Currency       string                `yaml:"currency"`        // currency costs are reported in (default: the catalog currency, USD)
+	ExchangeRates  string                `yaml:"exchange_rates"`  // exchange rates file (JSON or YAML)
+	Rates          map[string]float64    `yaml:"rates"`           // static exchange rates, applied over the file
+
+	Recommendations cost.RecommendationSettings `yaml:"recommendations"` // savings recommendation rules and thresholds
+
+	Budgets []cost.Budget `yaml:"budgets"` // monthly budgets checked by inspect --estimate-costs
+	FailOn  string        `yaml:"fail_on"` // alert severity (warning or critical) that makes inspect exit with an error
+}
+
+// ExportTarget defines a single export destination
+type ExportTarget struct {
+	Format     string `yaml:"format"`      // json, yaml, dot, etc.
+	Output     string `yaml:"output"`      // output path template (e.g., inventory-{date}-{provider}.json), empty = stdout
+	Pretty     *bool  `yaml:"pretty"`      // defaults to export.pretty
+	IncludeRaw *bool  `yaml:"include_raw"` // defaults to export.include_raw
+	FilterSet  string `yaml:"filter_set"`  // optional named filter set applied to this target only
+	Where      string `yaml:"where"`       // optional filter expression applied to this target only
+}
+
+// ResolvedTargets returns the configured export targets. When no targets are configured,
+// one target is derived for each entry of formats, writing to output_file with the
+// extension replaced by the format (or inventory-{date}.{format} if output_file is empty).
+func (e *ExportConfig) ResolvedTargets() []ExportTarget {
+	if len(e.Targets) > 0 {
+		return e.Targets
+	}
+
+	targets := make([]ExportTarget, 0, len(e.Formats))
+	for _, format := range e.Formats {
+		output := "inventory-{date}.{format}"
+		if e.OutputFile != "" {
+			output = strings.TrimSuffix(e.OutputFile, filepath.Ext(e.OutputFile)) + ".{format}"
+		}
+		targets = append(targets, ExportTarget{Format: format, Output: output})
+	}
+
+	return targets
 }
 
 // LoadConfig loads configuration from a YAML file
@@ -61,6 +121,11 @@ func LoadConfig(path string) (*Config, error) {
 		config.Export.Format = "json"
 	}
 
+	// Filter sets are validated on load so that every command using the config fails early
+	if err := config.validateFilterSets(); err != nil {
+		return nil, err
+	}
+
 	return &config, nil
 }
 
@@ -76,5 +141,44 @@ func (c *Config) Validate() error {
 		}
 	}
 
+	for i, target := range c.Export.ResolvedTargets() {
+		if _, err := exporter.Get(target.Format); err != nil {
+			return fmt.Errorf("invalid export target %d: %w", i+1, err)
+		}
+		if target.FilterSet != "" {
+			if _, ok := c.Filters[target.FilterSet]; !ok {
+				return fmt.Errorf("invalid export target %d: unknown filter set '%s'", i+1, target.FilterSet)
+			}
+		}
+		if target.Where != "" {
+			if _, err := filter.ParseExpression(target.Where); err != nil {
+				return fmt.Errorf("invalid export target %d where expression: %w", i+1, err)
+			}
+		}
+	}
+
+	if c.Resources.Where != "" {
+		if _, err := filter.ParseExpression(c.Resources.Where); err != nil {
+			return fmt.Errorf("invalid resources.where expression: %w", err)
+		}
+	}
+
+	for i, budget := range c.Cost.Budgets {
+		if err := budget.Validate(); err != nil {
+			return fmt.Errorf("invalid cost budget %d: %w", i+1, err)
+		}
+	}
+	if c.Cost.FailOn != "" && cost.SeverityRank(c.Cost.FailOn) == 0 {
+		return fmt.Errorf("invalid cost.fail_on '%s' (supported: warning, critical)", c.Cost.FailOn)
+	}
+
+	if err := c.Policy.Validate(); err != nil {
+		return fmt.Errorf("invalid policy settings: %w", err)
+	}
+
+	if err := c.Identity.Validate(); err != nil {
+		return fmt.Errorf("invalid identity settings: %w", err)
+	}
+
 	return nil
 }
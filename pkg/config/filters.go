@@ -0,0 +1,204 @@
+package config
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+
+	"github.com/comfortablynumb/pmp-cloud-inspector/pkg/filter"
+)
+
+// FilterSet is a named, reusable set of filters. All filters of a set are ANDed,
+// including the filters of the sets it includes.
+type FilterSet struct {
+	Description  string   `yaml:"description"`  // shown in the UI presets
+	Include      []string `yaml:"include"`      // other filter sets to combine with this one
+	Where        string   `yaml:"where"`        // filter expression
+	Tags         []string `yaml:"tags"`         // e.g., Environment=prod, Name~test, Owner
+	Regex        []string `yaml:"regex"`        // e.g., name:/prod-.*/
+	Dates        []string `yaml:"dates"`        // e.g., created:>2024-01-01
+	States       []string `yaml:"states"`       // e.g., running, active
+	Properties   []string `yaml:"properties"`   // e.g., instance_type=t3.micro
+	Cost         string   `yaml:"cost"`         // e.g., 100..500, >100
+	Types        []string `yaml:"types"`        // e.g., aws:ec2:instance
+	Providers    []string `yaml:"providers"`    // e.g., aws, gcp
+	Related      []string `yaml:"related"`      // e.g., id=vpc-123,type=belongs_to,direction=out
+	Neighborhood []string `yaml:"neighborhood"` // e.g., id=vpc-123,depth=2
+	Incoming     []string `yaml:"incoming"`     // e.g., type=belongs_to,from=aws:ec2:instance
+	NoIncoming   []string `yaml:"no_incoming"`  // e.g., type=belongs_to,from=aws:ec2:instance
+}
+
+// Build constructs the filters of the set itself, without resolving included sets
+func (s *FilterSet) Build() ([]filter.Filter, error) {
+	var filters []filter.Filter
+
+	if s.Where != "" {
+		f, err := filter.ParseExpression(s.Where)
+		if err != nil {
+			return nil, fmt.Errorf("invalid where expression: %w", err)
+		}
+		filters = append(filters, f)
+	}
+
+	for _, tagExpr := range s.Tags {
+		f, err := filter.ParseTagFilter(tagExpr)
+		if err != nil {
+			return nil, fmt.Errorf("invalid tag filter '%s': %w", tagExpr, err)
+		}
+		filters = append(filters, f)
+	}
+
+	for _, regexExpr := range s.Regex {
+		f, err := filter.ParseRegexFilter(regexExpr)
+		if err != nil {
+			return nil, fmt.Errorf("invalid regex filter '%s': %w", regexExpr, err)
+		}
+		filters = append(filters, f)
+	}
+
+	for _, dateExpr := range s.Dates {
+		f, err := filter.ParseDateRangeFilter(dateExpr)
+		if err != nil {
+			return nil, fmt.Errorf("invalid date filter '%s': %w", dateExpr, err)
+		}
+		filters = append(filters, f)
+	}
+
+	if len(s.States) > 0 {
+		states := strings.Join(s.States, ",")
+		f, err := filter.ParseStateFilter(states)
+		if err != nil {
+			return nil, fmt.Errorf("invalid state filter '%s': %w", states, err)
+		}
+		filters = append(filters, f)
+	}
+
+	for _, propExpr := range s.Properties {
+		f, err := filter.ParsePropertyFilter(propExpr)
+		if err != nil {
+			return nil, fmt.Errorf("invalid property filter '%s': %w", propExpr, err)
+		}
+		filters = append(filters, f)
+	}
+
+	if s.Cost != "" {
+		f, err := filter.ParseCostFilter(s.Cost)
+		if err != nil {
+			return nil, fmt.Errorf("invalid cost filter '%s': %w", s.Cost, err)
+		}
+		filters = append(filters, f)
+	}
+
+	if len(s.Types) > 0 {
+		filters = append(filters, filter.ParseTypeFilter(s.Types))
+	}
+
+	if len(s.Providers) > 0 {
+		filters = append(filters, filter.ParseProviderFilter(s.Providers))
+	}
+
+	for _, relatedExpr := range s.Related {
+		f, err := filter.ParseRelatedFilter(relatedExpr)
+		if err != nil {
+			return nil, fmt.Errorf("invalid related filter '%s': %w", relatedExpr, err)
+		}
+		filters = append(filters, f)
+	}
+
+	for _, neighborhoodExpr := range s.Neighborhood {
+		f, err := filter.ParseNeighborhoodFilter(neighborhoodExpr)
+		if err != nil {
+			return nil, fmt.Errorf("invalid neighborhood filter '%s': %w", neighborhoodExpr, err)
+		}
+		filters = append(filters, f)
+	}
+
+	for _, incomingExpr := range s.Incoming {
+		f, err := filter.ParseIncomingFilter(incomingExpr)
+		if err != nil {
+			return nil, fmt.Errorf("invalid incoming filter '%s': %w", incomingExpr, err)
+		}
+		filters = append(filters, f)
+	}
+
+	for _, incomingExpr := range s.NoIncoming {
+		f, err := filter.ParseIncomingFilter(incomingExpr)
+		if err != nil {
+			return nil, fmt.Errorf("invalid no-incoming filter '%s': %w", incomingExpr, err)
+		}
+		filters = append(filters, &filter.NotFilter{Filter: f})
+	}
+
+	return filters, nil
+}
+
+// FilterSetNames returns the names of the configured filter sets, sorted
+func (c *Config) FilterSetNames() []string {
+	names := make([]string, 0, len(c.Filters))
+	for name := range c.Filters {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	return names
+}
+
+// BuildFilterSet constructs the filters of a named filter set, including the sets it includes.
+// A new set of filters is returned on every call.
+func (c *Config) BuildFilterSet(name string) ([]filter.Filter, error) {
+	return c.buildFilterSet(name, nil)
+}
+
+func (c *Config) buildFilterSet(name string, path []string) ([]filter.Filter, error) {
+	for _, visited := range path {
+		if visited == name {
+			return nil, fmt.Errorf("filter set include cycle: %s -> %s", strings.Join(path, " -> "), name)
+		}
+	}
+
+	set, ok := c.Filters[name]
+	if !ok {
+		if len(path) > 0 {
+			return nil, fmt.Errorf("filter set '%s' includes unknown filter set '%s'", path[len(path)-1], name)
+		}
+		available := c.FilterSetNames()
+		if len(available) == 0 {
+			return nil, fmt.Errorf("unknown filter set '%s': no filter sets are configured", name)
+		}
+		return nil, fmt.Errorf("unknown filter set '%s', available filter sets: %s", name, strings.Join(available, ", "))
+	}
+
+	if set == nil {
+		return nil, fmt.Errorf("filter set '%s' has no filters", name)
+	}
+
+	path = append(path, name)
+
+	var filters []filter.Filter
+	for _, include := range set.Include {
+		included, err := c.buildFilterSet(include, path)
+		if err != nil {
+			return nil, err
+		}
+		filters = append(filters, included...)
+	}
+
+	own, err := set.Build()
+	if err != nil {
+		return nil, fmt.Errorf("filter set '%s': %w", name, err)
+	}
+
+	return append(filters, own...), nil
+}
+
+// validateFilterSets checks that every configured filter set can be built
+func (c *Config) validateFilterSets() error {
+	for _, name := range c.FilterSetNames() {
+		if strings.TrimSpace(name) == "" {
+			return fmt.Errorf("filter set name cannot be empty")
+		}
+		if _, err := c.BuildFilterSet(name); err != nil {
+			return fmt.Errorf("invalid filters: %w", err)
+		}
+	}
+	return nil
+}
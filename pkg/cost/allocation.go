@@ -0,0 +1,276 @@
+package cost
+
+import (
+	"encoding/csv"
+	"fmt"
+	"io"
+	"sort"
+	"strconv"
+	"strings"
+
+	"github.com/comfortablynumb/pmp-cloud-inspector/pkg/resource"
+)
+
+// UnallocatedValue is the bucket value of resources that could not be allocated
+const UnallocatedValue = "(unallocated)"
+
+// allocationInheritDepth is the maximum number of relationship hops followed to inherit tags
+const allocationInheritDepth = 3
+
+// allocationInheritTypes are the relationships followed to inherit allocation tags, from a
+// resource to the resource it lives in or runs as (e.g., subnet -> VPC, Lambda -> role)
+var allocationInheritTypes = []resource.RelationType{
+	resource.RelationBelongsTo,
+	resource.RelationAssumes,
+	resource.RelationAttachedTo,
+	resource.RelationDependsOn,
+}
+
+// AllocationReport assigns the cost of every priced resource to exactly one bucket, so that
+// bucket costs add up to the total (unlike CostSummary.ByTag, which counts a resource once per tag)
+type AllocationReport struct {
+	TagKeys     []string               `json:"tag_keys"`
+	Currency    string                 `json:"currency"`
+	Total       float64                `json:"total"`
+	Allocated   float64                `json:"allocated"`
+	Unallocated float64                `json:"unallocated"`
+	Buckets     []AllocationBucket     `json:"buckets"`
+	Resources   []AllocationAssignment `json:"resources"`
+}
+
+// AllocationBucket is the cost allocated to a tag value. The unallocated bucket has an empty
+// tag key and UnallocatedValue as value.
+type AllocationBucket struct {
+	TagKey    string  `json:"tag_key"`
+	Value     string  `json:"value"`
+	Cost      float64 `json:"cost"`
+	Share     float64 `json:"share"`     // fraction of the total cost
+	Resources int     `json:"resources"` // number of resources in the bucket
+	Inherited int     `json:"inherited"` // resources allocated through a related resource's tags
+}
+
+// AllocationAssignment records the bucket a resource was allocated to
+type AllocationAssignment struct {
+	ResourceID    string                `json:"resource_id"`
+	ResourceType  resource.ResourceType `json:"resource_type"`
+	Name          string                `json:"name"`
+	Cost          float64               `json:"cost"`
+	TagKey        string                `json:"tag_key,omitempty"`
+	Value         string                `json:"value"`
+	InheritedFrom string                `json:"inherited_from,omitempty"` // ID of the resource the tag was taken from
+}
+
+// Allocate builds an allocation report for the priced resources of a collection.
+//
+// Each resource is allocated to the value of the first tag key it has, in priority order.
+// Resources without any of the keys inherit them from the nearest related resource that has
+// one (following belongs_to, assumes, attached_to and depends_on relationships). Resources
+// still without a key are reported as unallocated. Tag keys are matched case-insensitively.
+func Allocate(collection *resource.Collection, tagKeys []string) *AllocationReport {
+	report := &AllocationReport{
+		TagKeys:   tagKeys,
+		Buckets:   make([]AllocationBucket, 0),
+		Resources: make([]AllocationAssignment, 0),
+	}
+	if collection.Metadata.TotalCost != nil {
+		report.Currency = collection.Metadata.TotalCost.Currency
+	}
+
+	graph := resource.NewGraph(collection)
+	buckets := make(map[[2]string]*AllocationBucket)
+	unallocated := &AllocationBucket{Value: UnallocatedValue}
+
+	for _, res := range collection.Resources {
+		if res.Cost == nil || res.Cost.MonthlyEstimate <= 0 {
+			continue
+		}
+		if report.Currency == "" {
+			report.Currency = res.Cost.Currency
+		}
+
+		cost := res.Cost.MonthlyEstimate
+		assignment := AllocationAssignment{
+			ResourceID:   res.ID,
+			ResourceType: res.Type,
+			Name:         res.Name,
+			Cost:         cost,
+			Value:        UnallocatedValue,
+		}
+
+		key, value, ok := allocationTag(res, tagKeys)
+		if !ok {
+			var source *resource.Resource
+			if source, key, value, ok = inheritAllocationTag(graph, res, tagKeys); ok {
+				assignment.InheritedFrom = source.ID
+			}
+		}
+
+		report.Total += cost
+		if !ok {
+			unallocated.Cost += cost
+			unallocated.Resources++
+			report.Unallocated += cost
+			report.Resources = append(report.Resources, assignment)
+			continue
+		}
+
+		assignment.TagKey = key
+		assignment.Value = value
+		report.Resources = append(report.Resources, assignment)
+		report.Allocated += cost
+
+		bucket := buckets[[2]string{key, value}]
+		if bucket == nil {
+			bucket = &AllocationBucket{TagKey: key, Value: value}
+			buckets[[2]string{key, value}] = bucket
+		}
+		bucket.Cost += cost
+		bucket.Resources++
+		if assignment.InheritedFrom != "" {
+			bucket.Inherited++
+		}
+	}
+
+	for _, bucket := range buckets {
+		report.Buckets = append(report.Buckets, *bucket)
+	}
+	sort.Slice(report.Buckets, func(i, j int) bool {
+		a, b := report.Buckets[i], report.Buckets[j]
+		if a.Cost != b.Cost {
+			return a.Cost > b.Cost
+		}
+		if a.TagKey != b.TagKey {
+			return a.TagKey < b.TagKey
+		}
+		return a.Value < b.Value
+	})
+
+	// Unallocated spend is always reported last
+	if unallocated.Resources > 0 {
+		report.Buckets = append(report.Buckets, *unallocated)
+	}
+
+	if report.Total > 0 {
+		for i := range report.Buckets {
+			report.Buckets[i].Share = report.Buckets[i].Cost / report.Total
+		}
+	}
+
+	sort.SliceStable(report.Resources, func(i, j int) bool {
+		return report.Resources[i].Cost > report.Resources[j].Cost
+	})
+
+	return report
+}
+
+// allocationTag returns the first allocation tag key, in priority order, set on a resource
+func allocationTag(res *resource.Resource, tagKeys []string) (string, string, bool) {
+	_, key, value, ok := allocationTagRank(res, tagKeys)
+	return key, value, ok
+}
+
+// allocationTagRank returns the priority (index in tagKeys) of the first allocation tag key
+// set on a resource, along with the key and value
+func allocationTagRank(res *resource.Resource, tagKeys []string) (int, string, string, bool) {
+	if len(res.Tags) == 0 {
+		return 0, "", "", false
+	}
+
+	for rank, key := range tagKeys {
+		if value := strings.TrimSpace(res.Tags[key]); value != "" {
+			return rank, key, value, true
+		}
+		for tagKey, value := range res.Tags {
+			if strings.EqualFold(tagKey, key) && strings.TrimSpace(value) != "" {
+				return rank, key, strings.TrimSpace(value), true
+			}
+		}
+	}
+
+	return 0, "", "", false
+}
+
+// inheritAllocationTag finds the nearest related resource with an allocation tag. Among the
+// resources at the same distance, the one with the highest-priority key wins (then the lowest ID).
+func inheritAllocationTag(graph *resource.Graph, res *resource.Resource, tagKeys []string) (*resource.Resource, string, string, bool) {
+	distances := graph.Neighborhood([]string{res.ID}, allocationInheritDepth, resource.DirectionOutgoing, allocationInheritTypes...)
+
+	var (
+		best               *resource.Resource
+		bestKey, bestValue string
+		bestDistance       int
+		bestRank           int
+	)
+	for id, distance := range distances {
+		if distance == 0 {
+			continue
+		}
+		related := graph.Get(id)
+		if related == nil {
+			continue
+		}
+
+		rank, key, value, ok := allocationTagRank(related, tagKeys)
+		if !ok {
+			continue
+		}
+
+		if best == nil || distance < bestDistance ||
+			(distance == bestDistance && (rank < bestRank || (rank == bestRank && related.ID < best.ID))) {
+			best, bestKey, bestValue, bestDistance, bestRank = related, key, value, distance, rank
+		}
+	}
+
+	return best, bestKey, bestValue, best != nil
+}
+
+// WriteCSV writes the allocation buckets as CSV, one row per bucket
+func (r *AllocationReport) WriteCSV(writer io.Writer) error {
+	w := csv.NewWriter(writer)
+
+	if err := w.Write([]string{"tag_key", "value", "cost", "currency", "share", "resources", "inherited"}); err != nil {
+		return fmt.Errorf("failed to write CSV: %w", err)
+	}
+	for _, bucket := range r.Buckets {
+		if err := w.Write([]string{
+			bucket.TagKey,
+			bucket.Value,
+			strconv.FormatFloat(bucket.Cost, 'f', 2, 64),
+			r.Currency,
+			strconv.FormatFloat(bucket.Share, 'f', 4, 64),
+			strconv.Itoa(bucket.Resources),
+			strconv.Itoa(bucket.Inherited),
+		}); err != nil {
+			return fmt.Errorf("failed to write CSV: %w", err)
+		}
+	}
+
+	w.Flush()
+	return w.Error()
+}
+
+// WriteResourcesCSV writes the allocation of every resource as CSV, one row per resource
+func (r *AllocationReport) WriteResourcesCSV(writer io.Writer) error {
+	w := csv.NewWriter(writer)
+
+	if err := w.Write([]string{"resource_id", "resource_type", "name", "cost", "currency", "tag_key", "value", "inherited_from"}); err != nil {
+		return fmt.Errorf("failed to write CSV: %w", err)
+	}
+	for _, assignment := range r.Resources {
+		if err := w.Write([]string{
+			assignment.ResourceID,
+			string(assignment.ResourceType),
+			assignment.Name,
+			strconv.FormatFloat(assignment.Cost, 'f', 2, 64),
+			r.Currency,
+			assignment.TagKey,
+			assignment.Value,
+			assignment.InheritedFrom,
+		}); err != nil {
+			return fmt.Errorf("failed to write CSV: %w", err)
+		}
+	}
+
+	w.Flush()
+	return w.Error()
+}
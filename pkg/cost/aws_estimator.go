@@ -6,28 +6,54 @@ import (
 	"github.com/comfortablynumb/pmp-cloud-inspector/pkg/resource"
 )
 
+const bytesPerGB = 1024 * 1024 * 1024
+
 // AWSEstimator provides cost estimation for AWS resources
 type AWSEstimator struct {
-	// Simplified pricing data (USD per month)
-	// In a production system, this would integrate with AWS Pricing API
+	// Simplified pricing data (USD per month), used when the catalog has no price
 	pricing map[resource.ResourceType]float64
+
+	// Pricing catalog keyed by region, instance type and OS
+	catalog *Catalog
+
+	// Usage assumed for usage-priced components
+	usage UsageAssumptions
 }
 
-// NewAWSEstimator creates a new AWS cost estimator
+// NewAWSEstimator creates a new AWS cost estimator using the bundled pricing catalog
 func NewAWSEstimator() *AWSEstimator {
+	return NewAWSEstimatorWithCatalog(DefaultCatalogs().Get("aws"))
+}
+
+// NewAWSEstimatorWithCatalog creates a new AWS cost estimator using a pricing catalog
+func NewAWSEstimatorWithCatalog(catalog *Catalog) *AWSEstimator {
 	return &AWSEstimator{
+		catalog: catalog,
+		usage:   DefaultUsageAssumptions(),
 		pricing: map[resource.ResourceType]float64{
 			// EC2 - Average t3.medium instance (730 hours/month)
 			resource.TypeAWSEC2Instance: 30.37,
 
 			// RDS - Average db.t3.medium instance
-			// resource.TypeAWSRDS: 54.75,
+			resource.TypeAWSRDSInstance: 54.75,
+
+			// RDS cluster - Aurora storage (100GB)
+			resource.TypeAWSRDSCluster: 10.00,
+
+			// EBS volume - Average gp3 volume (100GB)
+			resource.TypeAWSEBSVolume: 8.00,
+
+			// EBS snapshot - Average snapshot (100GB)
+			resource.TypeAWSEBSSnapshot: 5.00,
+
+			// EFS - Average file system (100GB standard storage)
+			resource.TypeAWSEFS: 30.00,
 
 			// Lambda - Average 128MB, 1M requests, 100ms duration
 			resource.TypeAWSLambda: 0.20,
 
-			// S3 - Not per-resource, usage-based
-			// resource.TypeAWSS3Bucket: 0.023, // per GB/month
+			// S3 - Average bucket (100GB standard storage)
+			resource.TypeAWSS3Bucket: 2.30,
 
 			// DynamoDB - Average on-demand table
 			resource.TypeAWSDynamoDBTable: 25.00,
@@ -41,6 +67,12 @@ func NewAWSEstimator() *AWSEstimator {
 			// NLB - Network Load Balancer
 			resource.TypeAWSNLB: 22.26,
 
+			// Classic Load Balancer
+			resource.TypeAWSELB: 18.25,
+
+			// NAT Gateway - Hourly charge only
+			resource.TypeAWSNATGateway: 32.85,
+
 			// CloudFront - Average distribution
 			resource.TypeAWSCloudFront: 50.00,
 
@@ -68,6 +100,11 @@ func NewAWSEstimator() *AWSEstimator {
 	}
 }
 
+// SetUsageAssumptions sets the usage assumed for usage-priced components
+func (e *AWSEstimator) SetUsageAssumptions(usage UsageAssumptions) {
+	e.usage = usage.WithDefaults()
+}
+
 // EstimateCost estimates the cost for an AWS resource
 func (e *AWSEstimator) EstimateCost(res *resource.Resource) (*resource.ResourceCost, error) {
 	// Get base price from pricing map
@@ -77,50 +114,93 @@ func (e *AWSEstimator) EstimateCost(res *resource.Resource) (*resource.ResourceC
 		return nil, nil
 	}
 
-	cost := &resource.ResourceCost{
-		MonthlyEstimate: basePrice,
-		Currency:        "USD",
-		Breakdown:       make(map[string]float64),
+	// Attached volumes are priced with the storage of their instance
+	if res.Type == resource.TypeAWSEBSVolume {
+		if state, _ := res.StringProperty(resource.PropState); state == "in-use" {
+			return nil, nil
+		}
 	}
 
-	// Adjust pricing based on instance type or other properties
+	builder := newCostBuilder(e.catalog)
+
+	// Price each component from the resource properties
 	switch res.Type {
 	case resource.TypeAWSEC2Instance:
-		cost = e.estimateEC2Cost(res, basePrice)
+		e.estimateEC2Cost(builder, res, basePrice)
 	case resource.TypeAWSElastiCache:
-		cost = e.estimateElastiCacheCost(res, basePrice)
+		e.estimateElastiCacheCost(builder, res, basePrice)
 	case resource.TypeAWSMemoryDB:
-		cost = e.estimateMemoryDBCost(res, basePrice)
+		e.estimateMemoryDBCost(builder, res, basePrice)
+	case resource.TypeAWSLambda:
+		e.estimateLambdaCost(builder, res)
+	case resource.TypeAWSDynamoDBTable:
+		e.estimateDynamoDBCost(builder, res)
+	case resource.TypeAWSEKSCluster:
+		builder.addCatalog("control_plane", PriceKey{Service: "eks", Region: res.Region}, 1)
+	case resource.TypeAWSALB, resource.TypeAWSNLB, resource.TypeAWSELB:
+		e.estimateLoadBalancerCost(builder, res)
+	case resource.TypeAWSNATGateway:
+		if builder.addCatalog("hours", PriceKey{Service: "nat-gateway", Region: res.Region, Usage: "hours"}, 1) {
+			builder.addCatalog("data_processed", PriceKey{Service: "nat-gateway", Region: res.Region, Usage: "data-processed"}, e.usage.NATDataProcessedGB)
+		}
+	case resource.TypeAWSECR:
+		builder.addCatalog("storage", PriceKey{Service: "ecr", Region: res.Region}, e.storageGB(res, e.usage.RegistryStorageGB))
+	case resource.TypeAWSRDSInstance:
+		e.estimateRDSInstanceCost(builder, res, basePrice)
+	case resource.TypeAWSRDSCluster:
+		e.estimateRDSClusterCost(builder, res)
+	case resource.TypeAWSEBSVolume:
+		e.estimateEBSVolumeCost(builder, res)
+	case resource.TypeAWSEBSSnapshot:
+		e.estimateEBSSnapshotCost(builder, res)
+	case resource.TypeAWSEFS:
+		e.estimateEFSCost(builder, res)
+	case resource.TypeAWSS3Bucket:
+		storageClass, ok := res.StringProperty(resource.PropStorageClass)
+		if !ok || storageClass == "" {
+			storageClass = "STANDARD"
+		}
+		builder.addCatalog("storage", PriceKey{Service: "s3", Region: res.Region, StorageClass: storageClass}, e.storageGB(res, e.usage.BucketStorageGB))
 	}
 
-	return cost, nil
-}
-
-// estimateEC2Cost provides more accurate EC2 cost based on instance type
-func (e *AWSEstimator) estimateEC2Cost(res *resource.Resource, basePrice float64) *resource.ResourceCost {
-	cost := &resource.ResourceCost{
-		MonthlyEstimate: basePrice,
-		Currency:        "USD",
-		Breakdown:       make(map[string]float64),
+	// Fall back to the flat price when no component could be priced
+	if builder.empty() {
+		builder.add("base", basePrice)
 	}
 
-	// Check if instance is stopped (no compute cost)
-	if state, ok := res.Properties["state"].(string); ok {
-		if state == "stopped" || state == "terminated" {
-			cost.MonthlyEstimate = 0
-			cost.Breakdown["compute"] = 0
-			return cost
+	return builder.build(), nil
+}
+
+// estimateEC2Cost prices the compute of running instances (by instance type, region and OS)
+// and the storage of attached EBS volumes, which is charged even when the instance is stopped
+func (e *AWSEstimator) estimateEC2Cost(builder *costBuilder, res *resource.Resource, basePrice float64) {
+	// Stopped and terminated instances have no compute cost
+	state, _ := res.StringProperty(resource.PropState)
+	if state == "stopped" || state == "terminated" {
+		builder.add("compute", 0)
+	} else if instanceType, ok := res.StringProperty(resource.PropInstanceType); ok {
+		platform, _ := res.StringProperty(resource.PropPlatform)
+		key := PriceKey{Service: "ec2", Region: res.Region, Size: instanceType, OS: normalizeOS(platform)}
+		if !builder.addCatalog("compute", key, 1) {
+			builder.add("compute", basePrice*e.getInstanceTypeMultiplier(instanceType))
 		}
 	}
 
-	// Adjust based on instance type if available
-	if instanceType, ok := res.Properties["instance_type"].(string); ok {
-		multiplier := e.getInstanceTypeMultiplier(instanceType)
-		cost.MonthlyEstimate = basePrice * multiplier
-		cost.Breakdown["compute"] = cost.MonthlyEstimate
+	if state == "terminated" {
+		return
 	}
 
-	return cost
+	for _, volume := range res.MapsProperty(resource.PropVolumes) {
+		sizeGB, ok := resource.ToFloat(volume[resource.PropVolumeSizeGB])
+		if !ok {
+			continue
+		}
+		volumeType, _ := volume[resource.PropVolumeType].(string)
+		if volumeType == "" {
+			volumeType = "gp2"
+		}
+		builder.addCatalog("storage", PriceKey{Service: "ebs", Region: res.Region, StorageClass: volumeType}, sizeGB)
+	}
 }
 
 // getInstanceTypeMultiplier returns a cost multiplier based on instance type
@@ -144,40 +224,204 @@ func (e *AWSEstimator) getInstanceTypeMultiplier(instanceType string) float64 {
 	}
 }
 
-// estimateElastiCacheCost provides ElastiCache cost based on node type
-func (e *AWSEstimator) estimateElastiCacheCost(res *resource.Resource, basePrice float64) *resource.ResourceCost {
-	cost := &resource.ResourceCost{
-		MonthlyEstimate: basePrice,
-		Currency:        "USD",
-		Breakdown: map[string]float64{
-			"cache_nodes": basePrice,
-		},
+// estimateElastiCacheCost prices ElastiCache clusters by node type and number of nodes
+func (e *AWSEstimator) estimateElastiCacheCost(builder *costBuilder, res *resource.Resource, basePrice float64) {
+	numNodes, ok := res.FloatProperty(resource.PropNumCacheNodes)
+	if !ok || numNodes <= 0 {
+		numNodes = 1
+	}
+
+	nodeType, _ := res.StringProperty(resource.PropNodeType)
+	if !builder.addCatalog("cache_nodes", PriceKey{Service: "elasticache", Region: res.Region, Size: nodeType}, numNodes) {
+		builder.add("cache_nodes", basePrice*numNodes)
 	}
+}
 
-	// Adjust based on number of cache nodes
-	if numNodes, ok := res.Properties["num_cache_nodes"].(float64); ok {
-		cost.MonthlyEstimate = basePrice * numNodes
-		cost.Breakdown["cache_nodes"] = cost.MonthlyEstimate
+// estimateMemoryDBCost prices MemoryDB clusters by node type and number of nodes. Without
+// shard details only the primary node of each shard is counted.
+func (e *AWSEstimator) estimateMemoryDBCost(builder *costBuilder, res *resource.Resource, basePrice float64) {
+	numNodes, ok := res.FloatProperty(resource.PropNumNodes)
+	if !ok || numNodes <= 0 {
+		numNodes, ok = res.FloatProperty(resource.PropNumShards)
+		if !ok || numNodes <= 0 {
+			numNodes = 1
+		}
 	}
 
-	return cost
+	nodeType, _ := res.StringProperty(resource.PropNodeType)
+	if !builder.addCatalog("memory_nodes", PriceKey{Service: "memorydb", Region: res.Region, Size: nodeType}, numNodes) {
+		builder.add("memory_nodes", basePrice*numNodes)
+	}
 }
 
-// estimateMemoryDBCost provides MemoryDB cost based on node type
-func (e *AWSEstimator) estimateMemoryDBCost(res *resource.Resource, basePrice float64) *resource.ResourceCost {
-	cost := &resource.ResourceCost{
-		MonthlyEstimate: basePrice,
-		Currency:        "USD",
-		Breakdown: map[string]float64{
-			"memory_nodes": basePrice,
-		},
+// estimateLambdaCost prices Lambda functions from the assumed invocations, each running for
+// a fraction of the timeout with the configured memory
+func (e *AWSEstimator) estimateLambdaCost(builder *costBuilder, res *resource.Resource) {
+	memoryMB, ok := res.FloatProperty(resource.PropMemorySize)
+	if !ok || memoryMB <= 0 {
+		memoryMB = 128 // Lambda default
+	}
+	timeout, ok := res.FloatProperty(resource.PropTimeout)
+	if !ok || timeout <= 0 {
+		timeout = 3 // Lambda default
+	}
+
+	invocations := e.usage.LambdaInvocations
+	gbSeconds := invocations * timeout * e.usage.LambdaDurationRatio * memoryMB / 1024
+
+	builder.addCatalog("requests", PriceKey{Service: "lambda", Region: res.Region, Usage: "requests"}, invocations)
+	builder.addCatalog("duration", PriceKey{Service: "lambda", Region: res.Region, Usage: "duration"}, gbSeconds)
+}
+
+// estimateDynamoDBCost prices DynamoDB tables by billing mode: provisioned capacity units, or
+// the assumed request units for on-demand tables, plus storage
+func (e *AWSEstimator) estimateDynamoDBCost(builder *costBuilder, res *resource.Resource) {
+	billingMode, _ := res.StringProperty(resource.PropBillingMode)
+
+	if billingMode == "PAY_PER_REQUEST" {
+		builder.addCatalog("read_requests", PriceKey{Service: "dynamodb", Region: res.Region, Usage: "read-requests"}, e.usage.DynamoDBReadRequests)
+		builder.addCatalog("write_requests", PriceKey{Service: "dynamodb", Region: res.Region, Usage: "write-requests"}, e.usage.DynamoDBWriteRequests)
+	} else {
+		readUnits, _ := res.FloatProperty(resource.PropReadCapacityUnits)
+		writeUnits, _ := res.FloatProperty(resource.PropWriteCapacityUnits)
+		builder.addCatalog("read_capacity", PriceKey{Service: "dynamodb", Region: res.Region, Usage: "read-capacity"}, readUnits)
+		builder.addCatalog("write_capacity", PriceKey{Service: "dynamodb", Region: res.Region, Usage: "write-capacity"}, writeUnits)
+	}
+
+	if sizeBytes, ok := res.FloatProperty(resource.PropTableSizeBytes); ok {
+		builder.addCatalog("storage", PriceKey{Service: "dynamodb", Region: res.Region, Usage: "storage"}, sizeBytes/bytesPerGB)
+	}
+}
+
+// estimateLoadBalancerCost prices load balancers by the hour plus the assumed capacity units
+func (e *AWSEstimator) estimateLoadBalancerCost(builder *costBuilder, res *resource.Resource) {
+	service := "alb"
+	switch res.Type {
+	case resource.TypeAWSNLB:
+		service = "nlb"
+	case resource.TypeAWSELB:
+		service = "elb"
+	}
+
+	if builder.addCatalog("hours", PriceKey{Service: service, Region: res.Region, Usage: "hours"}, 1) && service != "elb" {
+		builder.addCatalog("lcu", PriceKey{Service: service, Region: res.Region, Usage: "lcu"}, e.usage.LoadBalancerLCUs)
 	}
+}
 
-	// Adjust based on number of shards
-	if numShards, ok := res.Properties["number_of_shards"].(float64); ok {
-		cost.MonthlyEstimate = basePrice * numShards
-		cost.Breakdown["memory_nodes"] = cost.MonthlyEstimate
+// estimateRDSInstanceCost prices RDS instances by instance class and engine, and their
+// provisioned storage by type. Multi-AZ instances pay for their standby too. The storage of
+// the instances of a cluster is priced with the cluster.
+func (e *AWSEstimator) estimateRDSInstanceCost(builder *costBuilder, res *resource.Resource, basePrice float64) {
+	replicas := 1.0
+	if multiAZ, _ := res.BoolProperty(resource.PropMultiAZ); multiAZ {
+		replicas = 2
 	}
 
-	return cost
+	// Stopped instances have no compute cost, but still pay for their storage
+	state, _ := res.StringProperty(resource.PropStatus)
+	if state == "stopped" {
+		builder.add("compute", 0)
+	} else if instanceClass, ok := res.StringProperty(resource.PropInstanceClass); ok {
+		engine, _ := res.StringProperty(resource.PropEngine)
+		key := PriceKey{Service: "rds", Region: res.Region, Size: instanceClass, OS: normalizeDBEngine(engine)}
+		if !builder.addCatalog("compute", key, replicas) {
+			builder.add("compute", basePrice*replicas)
+		}
+	}
+
+	if clusterID, _ := res.StringProperty(resource.PropClusterID); clusterID != "" {
+		return
+	}
+
+	sizeGB, ok := res.FloatProperty(resource.PropAllocatedStorage)
+	if !ok || sizeGB <= 0 {
+		return
+	}
+	storageType, _ := res.StringProperty(resource.PropStorageType)
+	if storageType == "" {
+		storageType = "gp2"
+	}
+	builder.addCatalog("storage", PriceKey{Service: "rds-storage", Region: res.Region, StorageClass: storageType}, sizeGB*replicas)
+}
+
+// estimateRDSClusterCost prices the storage of RDS clusters: the assumed size of Aurora
+// clusters, whose storage grows with the data, or the provisioned storage of Multi-AZ DB
+// clusters. Their instances are priced separately.
+func (e *AWSEstimator) estimateRDSClusterCost(builder *costBuilder, res *resource.Resource) {
+	engine, _ := res.StringProperty(resource.PropEngine)
+	if strings.HasPrefix(engine, "aurora") {
+		builder.addCatalog("storage", PriceKey{Service: "rds-storage", Region: res.Region, StorageClass: "aurora"}, e.usage.DatabaseStorageGB)
+		return
+	}
+
+	sizeGB, ok := res.FloatProperty(resource.PropAllocatedStorage)
+	if !ok || sizeGB <= 0 {
+		return
+	}
+	storageType, _ := res.StringProperty(resource.PropStorageType)
+	if storageType == "" {
+		storageType = "gp3"
+	}
+	builder.addCatalog("storage", PriceKey{Service: "rds-storage", Region: res.Region, StorageClass: storageType}, sizeGB)
+}
+
+// estimateEBSVolumeCost prices unattached EBS volumes by type and size
+func (e *AWSEstimator) estimateEBSVolumeCost(builder *costBuilder, res *resource.Resource) {
+	sizeGB, ok := res.FloatProperty(resource.PropVolumeSizeGB)
+	if !ok {
+		return
+	}
+	volumeType, _ := res.StringProperty(resource.PropVolumeType)
+	if volumeType == "" {
+		volumeType = "gp2"
+	}
+	builder.addCatalog("storage", PriceKey{Service: "ebs", Region: res.Region, StorageClass: volumeType}, sizeGB)
+}
+
+// estimateEBSSnapshotCost prices EBS snapshots by storage tier, at their full size when known
+// or else the size of their volume. Snapshots are incremental, so this is an upper bound.
+func (e *AWSEstimator) estimateEBSSnapshotCost(builder *costBuilder, res *resource.Resource) {
+	sizeGB, ok := res.FloatProperty(resource.PropVolumeSizeGB)
+	if sizeBytes, known := res.FloatProperty(resource.PropSizeBytes); known {
+		sizeGB, ok = sizeBytes/bytesPerGB, true
+	}
+	if !ok {
+		return
+	}
+	tier, _ := res.StringProperty(resource.PropStorageClass)
+	if tier == "" {
+		tier = "standard"
+	}
+	builder.addCatalog("storage", PriceKey{Service: "ebs-snapshot", Region: res.Region, StorageClass: tier}, sizeGB)
+}
+
+// estimateEFSCost prices EFS file systems by the bytes stored in each storage class
+func (e *AWSEstimator) estimateEFSCost(builder *costBuilder, res *resource.Resource) {
+	sizeBytes, ok := res.FloatProperty(resource.PropSizeBytes)
+	if !ok {
+		return
+	}
+	iaBytes, _ := res.FloatProperty(resource.PropSizeIABytes)
+	archiveBytes, _ := res.FloatProperty(resource.PropSizeArchiveBytes)
+
+	standardBytes := sizeBytes - iaBytes - archiveBytes
+	if standardBytes < 0 {
+		standardBytes = 0
+	}
+
+	builder.addCatalog("storage", PriceKey{Service: "efs", Region: res.Region, StorageClass: "standard"}, standardBytes/bytesPerGB)
+	if iaBytes > 0 {
+		builder.addCatalog("storage_ia", PriceKey{Service: "efs", Region: res.Region, StorageClass: "infrequent_access"}, iaBytes/bytesPerGB)
+	}
+	if archiveBytes > 0 {
+		builder.addCatalog("storage_archive", PriceKey{Service: "efs", Region: res.Region, StorageClass: "archive"}, archiveBytes/bytesPerGB)
+	}
+}
+
+// storageGB returns the stored GB of a resource, or the assumed size if it is unknown
+func (e *AWSEstimator) storageGB(res *resource.Resource, assumed float64) float64 {
+	if sizeBytes, ok := res.FloatProperty(resource.PropSizeBytes); ok {
+		return sizeBytes / bytesPerGB
+	}
+	return assumed
 }
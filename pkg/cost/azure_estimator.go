@@ -8,14 +8,26 @@ import (
 
 // AzureEstimator provides cost estimation for Azure resources
 type AzureEstimator struct {
-	// Simplified pricing data (USD per month)
-	// In a production system, this would integrate with Azure Pricing API
+	// Simplified pricing data (USD per month), used when the catalog has no price
 	pricing map[resource.ResourceType]float64
+
+	// Pricing catalog keyed by region, VM size and OS
+	catalog *Catalog
+
+	// Usage assumed for usage-priced components
+	usage UsageAssumptions
 }
 
-// NewAzureEstimator creates a new Azure cost estimator
+// NewAzureEstimator creates a new Azure cost estimator using the bundled pricing catalog
 func NewAzureEstimator() *AzureEstimator {
+	return NewAzureEstimatorWithCatalog(DefaultCatalogs().Get("azure"))
+}
+
+// NewAzureEstimatorWithCatalog creates a new Azure cost estimator using a pricing catalog
+func NewAzureEstimatorWithCatalog(catalog *Catalog) *AzureEstimator {
 	return &AzureEstimator{
+		catalog: catalog,
+		usage:   DefaultUsageAssumptions(),
 		pricing: map[resource.ResourceType]float64{
 			// Virtual Machines - Average Standard_D2s_v3
 			resource.TypeAzureVM: 70.08,
@@ -38,6 +50,11 @@ func NewAzureEstimator() *AzureEstimator {
 	}
 }
 
+// SetUsageAssumptions sets the usage assumed for usage-priced components
+func (e *AzureEstimator) SetUsageAssumptions(usage UsageAssumptions) {
+	e.usage = usage.WithDefaults()
+}
+
 // EstimateCost estimates the cost for an Azure resource
 func (e *AzureEstimator) EstimateCost(res *resource.Resource) (*resource.ResourceCost, error) {
 	// Get base price from pricing map
@@ -47,50 +64,47 @@ func (e *AzureEstimator) EstimateCost(res *resource.Resource) (*resource.Resourc
 		return nil, nil
 	}
 
-	cost := &resource.ResourceCost{
-		MonthlyEstimate: basePrice,
-		Currency:        "USD",
-		Breakdown:       make(map[string]float64),
-	}
+	builder := newCostBuilder(e.catalog)
 
-	// Adjust pricing based on SKU or other properties
+	// Price each component from the SKU or other properties
 	switch res.Type {
 	case resource.TypeAzureVM:
-		cost = e.estimateVMCost(res, basePrice)
+		e.estimateVMCost(builder, res, basePrice)
 	case resource.TypeAzureStorageAccount:
-		cost = e.estimateStorageCost(res, basePrice)
+		sku, _ := res.StringProperty(resource.PropSKU)
+		builder.addCatalog("storage", PriceKey{Service: "storage", Region: res.Region, StorageClass: sku}, e.usage.BucketStorageGB)
 	case resource.TypeAzureAppService:
-		cost = e.estimateAppServiceCost(res, basePrice)
+		builder.add("app_service_plan", basePrice)
 	}
 
-	return cost, nil
-}
-
-// estimateVMCost provides more accurate VM cost based on size
-func (e *AzureEstimator) estimateVMCost(res *resource.Resource, basePrice float64) *resource.ResourceCost {
-	cost := &resource.ResourceCost{
-		MonthlyEstimate: basePrice,
-		Currency:        "USD",
-		Breakdown:       make(map[string]float64),
+	// Fall back to the flat price when no component could be priced
+	if builder.empty() {
+		builder.add("base", basePrice)
 	}
 
+	return builder.build(), nil
+}
+
+// estimateVMCost prices VMs by size, region and OS; deallocated VMs have no compute cost
+func (e *AzureEstimator) estimateVMCost(builder *costBuilder, res *resource.Resource, basePrice float64) {
 	// Check if VM is deallocated or stopped
-	if state, ok := res.Properties["provisioning_state"].(string); ok {
+	if state, ok := res.StringProperty("provisioning_state"); ok {
 		if state == "Deallocated" || state == "Stopped" {
-			cost.MonthlyEstimate = 0
-			cost.Breakdown["compute"] = 0
-			return cost
+			builder.add("compute", 0)
+			return
 		}
 	}
 
-	// Adjust based on VM size
-	if vmSize, ok := res.Properties["vm_size"].(string); ok {
-		multiplier := e.getVMSizeMultiplier(vmSize)
-		cost.MonthlyEstimate = basePrice * multiplier
-		cost.Breakdown["compute"] = cost.MonthlyEstimate
+	vmSize, ok := res.StringProperty(resource.PropVMSize)
+	if !ok {
+		return
 	}
 
-	return cost
+	osType, _ := res.StringProperty(resource.PropOSType)
+	key := PriceKey{Service: "vm", Region: res.Region, Size: vmSize, OS: normalizeOS(osType)}
+	if !builder.addCatalog("compute", key, 1) {
+		builder.add("compute", basePrice*e.getVMSizeMultiplier(vmSize))
+	}
 }
 
 // getVMSizeMultiplier returns a cost multiplier based on VM size
@@ -117,28 +131,3 @@ func (e *AzureEstimator) getVMSizeMultiplier(vmSize string) float64 {
 		return 1.0
 	}
 }
-
-// estimateStorageCost provides storage account cost
-func (e *AzureEstimator) estimateStorageCost(_ *resource.Resource, basePrice float64) *resource.ResourceCost {
-	return &resource.ResourceCost{
-		MonthlyEstimate: basePrice,
-		Currency:        "USD",
-		Breakdown: map[string]float64{
-			"storage": basePrice,
-		},
-	}
-}
-
-// estimateAppServiceCost provides App Service cost based on plan
-func (e *AzureEstimator) estimateAppServiceCost(_ *resource.Resource, basePrice float64) *resource.ResourceCost {
-	cost := &resource.ResourceCost{
-		MonthlyEstimate: basePrice,
-		Currency:        "USD",
-		Breakdown: map[string]float64{
-			"app_service_plan": basePrice,
-		},
-	}
-
-	// Could be enhanced with actual plan tier from properties
-	return cost
-}
@@ -0,0 +1,231 @@
+package cost
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+
+	"github.com/comfortablynumb/pmp-cloud-inspector/pkg/resource"
+)
+
+// Budget scopes
+const (
+	BudgetScopeTotal    = "total"    // every resource
+	BudgetScopeProvider = "provider" // resources of a provider
+	BudgetScopeAccount  = "account"  // resources of an account
+	BudgetScopeTag      = "tag"      // resources with a tag value
+)
+
+// Alert severities, in increasing order
+const (
+	SeverityWarning  = "warning"
+	SeverityCritical = "critical"
+)
+
+// Default budget thresholds, as percentages of the budget amount
+const (
+	DefaultWarningPercent  = 80
+	DefaultCriticalPercent = 100
+)
+
+// Budget is a monthly cost limit for a scope of resources. Budgets of the provider and account
+// scopes apply to every provider or account unless Match names one; budgets of the tag scope
+// match a key=value pair, or every value of a tag key.
+type Budget struct {
+	Name     string  `yaml:"name"`
+	Scope    string  `yaml:"scope"`    // total (default), provider, account or tag
+	Match    string  `yaml:"match"`    // provider, account ID, key=value or tag key (empty = every provider or account)
+	Amount   float64 `yaml:"amount"`   // monthly budget, in the currency costs are reported in
+	Currency string  `yaml:"currency"` // currency of the amount, checked against the estimates (optional)
+	Warning  float64 `yaml:"warning"`  // percentage of the amount raising a warning (default 80)
+	Critical float64 `yaml:"critical"` // percentage of the amount raising a critical alert (default 100)
+}
+
+// Validate checks the budget definition
+func (b Budget) Validate() error {
+	switch b.Scope {
+	case "", BudgetScopeTotal, BudgetScopeProvider, BudgetScopeAccount:
+	case BudgetScopeTag:
+		if strings.TrimSpace(b.Match) == "" {
+			return fmt.Errorf("budget %s: a tag budget needs a tag key or key=value pair to match", b.label())
+		}
+	default:
+		return fmt.Errorf("budget %s: unsupported scope '%s' (supported: total, provider, account, tag)", b.label(), b.Scope)
+	}
+
+	if b.Amount <= 0 {
+		return fmt.Errorf("budget %s: amount must be positive", b.label())
+	}
+
+	warning, critical := b.thresholds()
+	if warning <= 0 || critical <= 0 {
+		return fmt.Errorf("budget %s: thresholds must be positive", b.label())
+	}
+	if warning > critical {
+		return fmt.Errorf("budget %s: the warning threshold (%g%%) is above the critical threshold (%g%%)", b.label(), warning, critical)
+	}
+
+	return nil
+}
+
+// label returns the name of the budget, or a description of its scope if it has none
+func (b Budget) label() string {
+	if b.Name != "" {
+		return b.Name
+	}
+	scope := b.Scope
+	if scope == "" {
+		scope = BudgetScopeTotal
+	}
+	if b.Match != "" {
+		return scope + ":" + b.Match
+	}
+	return scope
+}
+
+// thresholds returns the warning and critical thresholds, applying the defaults
+func (b Budget) thresholds() (float64, float64) {
+	warning, critical := b.Warning, b.Critical
+	if warning == 0 {
+		warning = DefaultWarningPercent
+	}
+	if critical == 0 {
+		critical = DefaultCriticalPercent
+	}
+	return warning, critical
+}
+
+// spend returns the monthly cost of each key of the budget's scope
+func (b Budget) spend(collection *resource.Collection) map[string]float64 {
+	tagKey, tagValue, hasValue := strings.Cut(b.Match, "=")
+	tagKey, tagValue = strings.TrimSpace(tagKey), strings.TrimSpace(tagValue)
+
+	spend := make(map[string]float64)
+	if b.Scope == "" || b.Scope == BudgetScopeTotal {
+		spend[""] = 0
+	}
+
+	for _, res := range collection.Resources {
+		cost := resourceCost(res)
+
+		var key string
+		switch b.Scope {
+		case BudgetScopeProvider:
+			key = res.Provider
+		case BudgetScopeAccount:
+			key = res.Account
+		case BudgetScopeTag:
+			value, ok := res.Tags[tagKey]
+			if !ok || (hasValue && value != tagValue) {
+				continue
+			}
+			key = tagKey + "=" + value
+		}
+
+		if b.Match != "" && b.Scope != BudgetScopeTag && key != b.Match {
+			continue
+		}
+		if key == "" && b.Scope != "" && b.Scope != BudgetScopeTotal {
+			continue
+		}
+		spend[key] += cost
+	}
+
+	return spend
+}
+
+// CheckBudgets compares the estimated monthly costs of a collection with the budgets and returns
+// an alert for every scope whose spend crossed the warning or critical threshold, most severe
+// and largest overspend first
+func CheckBudgets(collection *resource.Collection, budgets []Budget) ([]resource.CostAlert, error) {
+	currency := collectionCurrency(collection)
+	if currency == "" {
+		currency = DefaultCurrency
+	}
+
+	alerts := make([]resource.CostAlert, 0)
+	for _, budget := range budgets {
+		if err := budget.Validate(); err != nil {
+			return nil, err
+		}
+		if budget.Currency != "" && !strings.EqualFold(budget.Currency, currency) {
+			return nil, fmt.Errorf("budget %s is in %s but costs are estimated in %s", budget.label(), strings.ToUpper(budget.Currency), currency)
+		}
+
+		warning, critical := budget.thresholds()
+		for key, spend := range budget.spend(collection) {
+			percent := spend / budget.Amount * 100
+
+			severity, threshold := "", 0.0
+			switch {
+			case percent >= critical:
+				severity, threshold = SeverityCritical, critical
+			case percent >= warning:
+				severity, threshold = SeverityWarning, warning
+			default:
+				continue
+			}
+
+			scope := budget.Scope
+			if scope == "" {
+				scope = BudgetScopeTotal
+			}
+			subject := "total estimated cost"
+			if key != "" {
+				subject = fmt.Sprintf("estimated cost of %s %s", scope, key)
+			}
+
+			alerts = append(alerts, resource.CostAlert{
+				Budget:    budget.label(),
+				Scope:     scope,
+				Key:       key,
+				Severity:  severity,
+				Amount:    budget.Amount,
+				Spend:     spend,
+				Percent:   percent,
+				Threshold: threshold,
+				Currency:  currency,
+				Message: fmt.Sprintf("%s is %.2f %s, %.0f%% of budget %q (%.2f %s)",
+					subject, spend, currency, percent, budget.label(), budget.Amount, currency),
+			})
+		}
+	}
+
+	sort.Slice(alerts, func(i, j int) bool {
+		a, b := alerts[i], alerts[j]
+		if a.Severity != b.Severity {
+			return SeverityRank(a.Severity) > SeverityRank(b.Severity)
+		}
+		if a.Percent != b.Percent {
+			return a.Percent > b.Percent
+		}
+		if a.Budget != b.Budget {
+			return a.Budget < b.Budget
+		}
+		return a.Key < b.Key
+	})
+
+	return alerts, nil
+}
+
+// SeverityRank orders alert severities: 0 for unknown severities, then warning, then critical
+func SeverityRank(severity string) int {
+	switch strings.ToLower(severity) {
+	case SeverityWarning:
+		return 1
+	case SeverityCritical:
+		return 2
+	}
+	return 0
+}
+
+// AlertsAtLeast returns the alerts of at least the given severity
+func AlertsAtLeast(alerts []resource.CostAlert, severity string) []resource.CostAlert {
+	var matching []resource.CostAlert
+	for _, alert := range alerts {
+		if SeverityRank(alert.Severity) >= SeverityRank(severity) {
+			matching = append(matching, alert)
+		}
+	}
+	return matching
+}
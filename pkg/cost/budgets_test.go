@@ -0,0 +1,198 @@
+package cost
+
+import (
+	"testing"
+
+	"github.com/comfortablynumb/pmp-cloud-inspector/pkg/resource"
+)
+
+// budgetCollection returns 120 USD of resources across two providers, two accounts and a few tags
+func budgetCollection() *resource.Collection {
+	collection := resource.NewCollection()
+	for _, res := range []*resource.Resource{
+		{ID: "web", Provider: "aws", Account: "111", Tags: map[string]string{"env": "prod", "team": "web"}, Cost: &resource.ResourceCost{MonthlyEstimate: 60, Currency: "USD"}},
+		{ID: "dev", Provider: "aws", Account: "222", Tags: map[string]string{"env": "dev"}, Cost: &resource.ResourceCost{MonthlyEstimate: 30, Currency: "USD"}},
+		{ID: "bucket", Provider: "gcp", Tags: map[string]string{"env": "prod"}, Cost: &resource.ResourceCost{MonthlyEstimate: 20, Currency: "USD"}},
+		{ID: "volume", Provider: "aws", Account: "111", Cost: &resource.ResourceCost{MonthlyEstimate: 10, Currency: "USD"}},
+		{ID: "vpc", Provider: "aws", Account: "111"},
+	} {
+		res.Type = resource.TypeAWSEC2Instance
+		collection.Add(res)
+	}
+	collection.RecomputeMetadata()
+	return collection
+}
+
+func TestCheckBudgets(t *testing.T) {
+	type wantAlert struct {
+		budget    string
+		key       string
+		severity  string
+		threshold float64
+	}
+
+	tests := []struct {
+		name    string
+		budgets []Budget
+		want    []wantAlert
+		wantErr bool
+	}{
+		{
+			name:    "total over budget",
+			budgets: []Budget{{Amount: 100}},
+			want:    []wantAlert{{"total", "", SeverityCritical, 100}},
+		},
+		{
+			name:    "total above the default warning threshold",
+			budgets: []Budget{{Name: "monthly", Scope: BudgetScopeTotal, Amount: 140}},
+			want:    []wantAlert{{"monthly", "", SeverityWarning, 80}},
+		},
+		{
+			name:    "total within budget",
+			budgets: []Budget{{Amount: 200}},
+		},
+		{
+			name:    "custom thresholds",
+			budgets: []Budget{{Amount: 200, Warning: 50, Critical: 150}},
+			want:    []wantAlert{{"total", "", SeverityWarning, 50}},
+		},
+		{
+			name:    "every provider",
+			budgets: []Budget{{Scope: BudgetScopeProvider, Amount: 50}},
+			want:    []wantAlert{{"provider", "aws", SeverityCritical, 100}},
+		},
+		{
+			name:    "matching provider at the warning threshold",
+			budgets: []Budget{{Scope: BudgetScopeProvider, Match: "gcp", Amount: 25}},
+			want:    []wantAlert{{"provider:gcp", "gcp", SeverityWarning, 80}},
+		},
+		{
+			name:    "every account skips resources without one",
+			budgets: []Budget{{Scope: BudgetScopeAccount, Amount: 20}},
+			want: []wantAlert{
+				{"account", "111", SeverityCritical, 100},
+				{"account", "222", SeverityCritical, 100},
+			},
+		},
+		{
+			name:    "matching account",
+			budgets: []Budget{{Scope: BudgetScopeAccount, Match: "222", Amount: 20}},
+			want:    []wantAlert{{"account:222", "222", SeverityCritical, 100}},
+		},
+		{
+			name:    "every value of a tag key",
+			budgets: []Budget{{Scope: BudgetScopeTag, Match: "env", Amount: 35}},
+			want: []wantAlert{
+				{"tag:env", "env=prod", SeverityCritical, 100},
+				{"tag:env", "env=dev", SeverityWarning, 80},
+			},
+		},
+		{
+			name:    "tag key=value",
+			budgets: []Budget{{Scope: BudgetScopeTag, Match: "env=dev", Amount: 35}},
+			want:    []wantAlert{{"tag:env=dev", "env=dev", SeverityWarning, 80}},
+		},
+		{
+			name:    "tag key=value with spaces",
+			budgets: []Budget{{Scope: BudgetScopeTag, Match: "team = web", Amount: 50}},
+			want:    []wantAlert{{"tag:team = web", "team=web", SeverityCritical, 100}},
+		},
+		{
+			name:    "missing tag",
+			budgets: []Budget{{Scope: BudgetScopeTag, Match: "owner", Amount: 1}},
+		},
+		{
+			name: "most severe and largest overspend first",
+			budgets: []Budget{
+				{Name: "b-warning", Amount: 150},
+				{Name: "b-critical", Amount: 100},
+				{Name: "a-critical", Amount: 100},
+				{Name: "double", Amount: 60},
+			},
+			want: []wantAlert{
+				{"double", "", SeverityCritical, 100},
+				{"a-critical", "", SeverityCritical, 100},
+				{"b-critical", "", SeverityCritical, 100},
+				{"b-warning", "", SeverityWarning, 80},
+			},
+		},
+		{
+			name:    "matching currency",
+			budgets: []Budget{{Amount: 100, Currency: "usd"}},
+			want:    []wantAlert{{"total", "", SeverityCritical, 100}},
+		},
+		{
+			name:    "currency mismatch",
+			budgets: []Budget{{Amount: 100, Currency: "EUR"}},
+			wantErr: true,
+		},
+		{
+			name:    "unsupported scope",
+			budgets: []Budget{{Scope: "region", Amount: 100}},
+			wantErr: true,
+		},
+		{
+			name:    "tag budget without a key",
+			budgets: []Budget{{Scope: BudgetScopeTag, Match: " ", Amount: 100}},
+			wantErr: true,
+		},
+		{
+			name:    "no amount",
+			budgets: []Budget{{Scope: BudgetScopeProvider}},
+			wantErr: true,
+		},
+		{
+			name:    "warning above critical",
+			budgets: []Budget{{Amount: 100, Warning: 120}},
+			wantErr: true,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			alerts, err := CheckBudgets(budgetCollection(), tt.budgets)
+			if tt.wantErr {
+				if err == nil {
+					t.Fatalf("CheckBudgets = %+v, want an error", alerts)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("CheckBudgets failed: %v", err)
+			}
+
+			if len(alerts) != len(tt.want) {
+				t.Fatalf("got %d alerts %+v, want %d", len(alerts), alerts, len(tt.want))
+			}
+			for i, want := range tt.want {
+				got := alerts[i]
+				if got.Budget != want.budget || got.Key != want.key || got.Severity != want.severity || got.Threshold != want.threshold {
+					t.Errorf("alert %d: got %s %q %s at %g%%, want %s %q %s at %g%%", i, got.Budget, got.Key, got.Severity, got.Threshold, want.budget, want.key, want.severity, want.threshold)
+				}
+				if got.Currency != "USD" || got.Percent != got.Spend/got.Amount*100 {
+					t.Errorf("alert %d: got %g %s, %g%% of %g", i, got.Spend, got.Currency, got.Percent, got.Amount)
+				}
+			}
+		})
+	}
+}
+
+func TestAlertsAtLeast(t *testing.T) {
+	alerts := []resource.CostAlert{{Severity: SeverityCritical}, {Severity: SeverityWarning}, {Severity: "info"}}
+
+	tests := []struct {
+		severity string
+		want     int
+	}{
+		{SeverityCritical, 1},
+		{"CRITICAL", 1},
+		{SeverityWarning, 2},
+		{"", 3},
+	}
+
+	for _, tt := range tests {
+		if got := AlertsAtLeast(alerts, tt.severity); len(got) != tt.want {
+			t.Errorf("AlertsAtLeast(%q) returned %d alerts, want %d", tt.severity, len(got), tt.want)
+		}
+	}
+}
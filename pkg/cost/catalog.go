@@ -0,0 +1,457 @@
+package cost
+
+import (
+	"embed"
+	"encoding/json"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"time"
+)
+
+//go:embed catalogs/*.json
+var bundledCatalogsFS embed.FS
+
+// HoursPerMonth is the number of hours used to turn hourly prices into monthly estimates
+const HoursPerMonth = 730
+
+// Price units used in catalogs
+const (
+	UnitHour     = "hour"     // per hour (per vCPU-hour or GB-hour for GCP core/ram prices)
+	UnitMonth    = "month"    // per month
+	UnitGBMonth  = "gb-month" // per GB stored per month
+	UnitGB       = "gb"       // per GB transferred or processed
+	UnitGBSecond = "gb-second"
+	UnitRequest  = "request"
+)
+
+// Operating systems used in catalog keys
+const (
+	OSLinux   = "linux"
+	OSWindows = "windows"
+)
+
+// Catalog is a versioned set of prices for a provider, normalized from the provider's
+// own price list format (see LoadCatalogFile)
+type Catalog struct {
+	Provider    string    `json:"provider"`
+	Version     string    `json:"version"`
+	Currency    string    `json:"currency"`
+	Source      string    `json:"source,omitempty"`
+	GeneratedAt time.Time `json:"generated_at"`
+	Prices      []Price   `json:"prices"`
+
+	index map[PriceKey]int // position of each price in Prices
+	base  *Catalog         // older catalog consulted for keys this catalog has no price for
+}
+
+// Price is a single catalog entry
+type Price struct {
+	PriceKey
+	Unit  string  `json:"unit"`
+	Price float64 `json:"price"`
+}
+
+// PriceKey identifies a price. Empty fields are wildcards when looking up prices:
+// a price without a region applies to every region.
+type PriceKey struct {
+	Service      string `json:"service"`                 // e.g., ec2, ebs, vm, compute, storage
+	Region       string `json:"region,omitempty"`        // e.g., us-east-1, eastus, us-central1
+	Size         string `json:"size,omitempty"`          // instance type, VM size, machine type or family
+	OS           string `json:"os,omitempty"`            // operating system or engine, e.g., linux, windows, redis
+	StorageClass string `json:"storage_class,omitempty"` // e.g., gp3, STANDARD, Standard_LRS
+	Usage        string `json:"usage,omitempty"`         // usage dimension, e.g., hours, requests, duration
+}
+
+// Add adds a price to the catalog, replacing an existing price with the same key
+func (c *Catalog) Add(price Price) {
+	if c.index == nil {
+		c.buildIndex()
+	}
+	if i, ok := c.index[price.PriceKey]; ok {
+		c.Prices[i] = price
+		return
+	}
+	c.index[price.PriceKey] = len(c.Prices)
+	c.Prices = append(c.Prices, price)
+}
+
+// buildIndex indexes the prices of the catalog for lookups, keeping the last price of duplicate keys
+func (c *Catalog) buildIndex() {
+	c.index = make(map[PriceKey]int, len(c.Prices))
+	prices := c.Prices[:0]
+	for _, price := range c.Prices {
+		if i, ok := c.index[price.PriceKey]; ok {
+			prices[i] = price
+			continue
+		}
+		c.index[price.PriceKey] = len(prices)
+		prices = append(prices, price)
+	}
+	c.Prices = prices
+}
+
+// Base returns the catalog consulted for prices missing from this catalog, or nil
+func (c *Catalog) Base() *Catalog {
+	return c.base
+}
+
+// Lookup returns the price for a key and its unit. If there is no price for the key's
+// region, a region-independent price is used.
+func (c *Catalog) Lookup(key PriceKey) (float64, string, bool) {
+	price, _, ok := c.find(key)
+	return price.Price, price.Unit, ok
+}
+
+// find returns the price for a key and the catalog that priced it, consulting the
+// base catalogs if this catalog has no price for the key
+func (c *Catalog) find(key PriceKey) (Price, *Catalog, bool) {
+	for catalog := c; catalog != nil; catalog = catalog.base {
+		if catalog.index == nil {
+			catalog.buildIndex()
+		}
+
+		if i, ok := catalog.index[key]; ok {
+			return catalog.Prices[i], catalog, true
+		}
+
+		if key.Region != "" {
+			global := key
+			global.Region = ""
+			if i, ok := catalog.index[global]; ok {
+				return catalog.Prices[i], catalog, true
+			}
+		}
+	}
+
+	return Price{}, nil, false
+}
+
+// Monthly returns the monthly price for a key, converting hourly prices
+func (c *Catalog) Monthly(key PriceKey) (float64, bool) {
+	monthly, _, ok := c.monthly(key)
+	return monthly, ok
+}
+
+// monthly returns the monthly price for a key and the catalog that priced it
+func (c *Catalog) monthly(key PriceKey) (float64, *Catalog, bool) {
+	price, catalog, ok := c.find(key)
+	if !ok {
+		return 0, nil, false
+	}
+	if price.Unit == UnitHour {
+		return price.Price * HoursPerMonth, catalog, true
+	}
+	return price.Price, catalog, true
+}
+
+// Merge adds the prices of another catalog of the same provider. Prices of the other
+// catalog take precedence.
+func (c *Catalog) Merge(other *Catalog) {
+	for _, price := range other.Prices {
+		c.Add(price)
+	}
+	if other.Version != "" && !strings.Contains(c.Version, other.Version) {
+		if c.Version == "" {
+			c.Version = other.Version
+		} else {
+			c.Version += "+" + other.Version
+		}
+	}
+}
+
+// CatalogSet holds one pricing catalog per provider
+type CatalogSet struct {
+	catalogs map[string]*Catalog
+}
+
+// NewCatalogSet creates an empty catalog set
+func NewCatalogSet() *CatalogSet {
+	return &CatalogSet{catalogs: make(map[string]*Catalog)}
+}
+
+// Get returns the catalog of a provider, or nil
+func (s *CatalogSet) Get(provider string) *Catalog {
+	if s == nil {
+		return nil
+	}
+	return s.catalogs[provider]
+}
+
+// Set sets the catalog of a provider, replacing any existing one
+func (s *CatalogSet) Set(catalog *Catalog) {
+	s.catalogs[catalog.Provider] = catalog
+}
+
+// Layer sets the catalog of a provider, keeping any existing one as its base so that
+// prices missing from the new catalog are looked up in the existing one
+func (s *CatalogSet) Layer(catalog *Catalog) {
+	catalog.base = s.catalogs[catalog.Provider]
+	s.catalogs[catalog.Provider] = catalog
+}
+
+// List returns the catalogs of the set sorted by provider
+func (s *CatalogSet) List() []*Catalog {
+	catalogs := make([]*Catalog, 0, len(s.catalogs))
+	for _, catalog := range s.catalogs {
+		catalogs = append(catalogs, catalog)
+	}
+	sort.Slice(catalogs, func(i, j int) bool {
+		return catalogs[i].Provider < catalogs[j].Provider
+	})
+	return catalogs
+}
+
+// DefaultCatalogs returns the pricing snapshot bundled with the binary. The catalogs are embedded
+// at build time, so an invalid bundled catalog is a build defect and panics.
+func DefaultCatalogs() *CatalogSet {
+	set, err := loadBundledCatalogs()
+	if err != nil {
+		panic(err)
+	}
+	return set
+}
+
+// loadBundledCatalogs loads and indexes the catalogs embedded in the binary
+func loadBundledCatalogs() (*CatalogSet, error) {
+	set := NewCatalogSet()
+
+	entries, err := bundledCatalogsFS.ReadDir("catalogs")
+	if err != nil {
+		return nil, fmt.Errorf("failed to read the bundled pricing catalogs: %w", err)
+	}
+
+	for _, entry := range entries {
+		data, err := bundledCatalogsFS.ReadFile("catalogs/" + entry.Name())
+		if err != nil {
+			return nil, fmt.Errorf("failed to read the bundled pricing catalog %s: %w", entry.Name(), err)
+		}
+		var catalog Catalog
+		if err := json.Unmarshal(data, &catalog); err != nil {
+			return nil, fmt.Errorf("failed to parse the bundled pricing catalog %s: %w", entry.Name(), err)
+		}
+		if catalog.Provider == "" {
+			return nil, fmt.Errorf("bundled pricing catalog %s has no provider", entry.Name())
+		}
+		catalog.buildIndex()
+		set.Set(&catalog)
+	}
+
+	return set, nil
+}
+
+// DefaultCatalogDir returns the directory where refreshed catalogs are stored by default
+func DefaultCatalogDir() string {
+	dir, err := os.UserCacheDir()
+	if err != nil {
+		return filepath.Join(".pmp-cloud-inspector", "pricing")
+	}
+	return filepath.Join(dir, "pmp-cloud-inspector", "pricing")
+}
+
+// LoadCatalogs loads the bundled snapshot, then the refreshed catalogs in the default catalog
+// directory, then the given files or directories. Catalogs loaded later take precedence;
+// prices they don't have are still looked up in the catalogs loaded before them.
+// Catalogs of the same provider given explicitly are merged.
+func LoadCatalogs(paths []string) (*CatalogSet, error) {
+	set := DefaultCatalogs()
+
+	if info, err := os.Stat(DefaultCatalogDir()); err == nil && info.IsDir() {
+		refreshed, err := loadCatalogPaths([]string{DefaultCatalogDir()})
+		if err != nil {
+			return nil, fmt.Errorf("failed to load refreshed catalogs: %w", err)
+		}
+		for _, catalog := range refreshed.List() {
+			set.Layer(catalog)
+		}
+	}
+
+	explicit, err := loadCatalogPaths(paths)
+	if err != nil {
+		return nil, err
+	}
+	for _, catalog := range explicit.List() {
+		set.Layer(catalog)
+	}
+
+	return set, nil
+}
+
+// loadCatalogPaths loads catalogs from files and directories (*.json and *.csv),
+// merging catalogs of the same provider
+func loadCatalogPaths(paths []string) (*CatalogSet, error) {
+	set := NewCatalogSet()
+
+	var files []string
+	for _, path := range paths {
+		info, err := os.Stat(path)
+		if err != nil {
+			return nil, fmt.Errorf("failed to access catalog path %s: %w", path, err)
+		}
+
+		if !info.IsDir() {
+			files = append(files, path)
+			continue
+		}
+
+		entries, err := os.ReadDir(path)
+		if err != nil {
+			return nil, fmt.Errorf("failed to read catalog directory %s: %w", path, err)
+		}
+		for _, entry := range entries {
+			ext := strings.ToLower(filepath.Ext(entry.Name()))
+			if !entry.IsDir() && (ext == ".json" || ext == ".csv") {
+				files = append(files, filepath.Join(path, entry.Name()))
+			}
+		}
+	}
+
+	sort.Strings(files)
+
+	for _, file := range files {
+		catalog, err := LoadCatalogFile(file)
+		if err != nil {
+			return nil, err
+		}
+		if existing := set.Get(catalog.Provider); existing != nil {
+			existing.Merge(catalog)
+			continue
+		}
+		set.Set(catalog)
+	}
+
+	return set, nil
+}
+
+// LoadCatalogFile loads a pricing catalog from a file. Supported formats are the normalized
+// catalog JSON written by SaveCatalog, AWS Price List bulk offer files (JSON or CSV),
+// Azure retail prices API responses (JSON) and GCP Cloud Billing Catalog SKU lists (JSON).
+func LoadCatalogFile(path string) (*Catalog, error) {
+	// #nosec G304 - path is provided by user as CLI argument, this is expected behavior
+	file, err := os.Open(path)
+	if err != nil {
+		return nil, fmt.Errorf("failed to open catalog %s: %w", path, err)
+	}
+	defer func() {
+		//nolint:errcheck // Read-only file, nothing to do on close error
+		file.Close()
+	}()
+
+	info, err := file.Stat()
+	if err != nil {
+		return nil, fmt.Errorf("failed to stat catalog %s: %w", path, err)
+	}
+	fileDate := info.ModTime().UTC().Format("2006-01-02")
+
+	var catalog *Catalog
+	if strings.EqualFold(filepath.Ext(path), ".csv") {
+		catalog, err = parseAWSOfferCSV(file)
+	} else {
+		catalog, err = parseCatalogJSON(file, fileDate)
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to parse catalog %s: %w", path, err)
+	}
+
+	if catalog.Version == "" {
+		catalog.Version = fmt.Sprintf("%s-%s", catalog.Provider, fileDate)
+	}
+	if catalog.Source == "" {
+		catalog.Source = path
+	}
+	catalog.buildIndex()
+
+	return catalog, nil
+}
+
+// rawCatalog is used to detect the format of a JSON catalog
+type rawCatalog struct {
+	// Normalized catalog
+	Provider string  `json:"provider"`
+	Prices   []Price `json:"prices"`
+
+	// AWS offer file
+	Products json.RawMessage `json:"products"`
+
+	// Azure retail prices
+	Items json.RawMessage `json:"Items"`
+
+	// GCP SKU list
+	SKUs json.RawMessage `json:"skus"`
+}
+
+// parseCatalogJSON detects the format of a JSON catalog and normalizes it
+func parseCatalogJSON(file io.Reader, fileDate string) (*Catalog, error) {
+	data, err := io.ReadAll(file)
+	if err != nil {
+		return nil, err
+	}
+
+	var raw rawCatalog
+	if err := json.Unmarshal(data, &raw); err != nil {
+		return nil, fmt.Errorf("invalid JSON: %w", err)
+	}
+
+	switch {
+	case raw.Provider != "":
+		var catalog Catalog
+		if err := json.Unmarshal(data, &catalog); err != nil {
+			return nil, err
+		}
+		return &catalog, nil
+	case raw.Products != nil:
+		return parseAWSOfferJSON(data)
+	case raw.Items != nil:
+		return parseAzureRetailPrices(data, "azure-retail-"+fileDate)
+	case raw.SKUs != nil:
+		return parseGCPSKUs(data, "gcp-skus-"+fileDate)
+	}
+
+	return nil, fmt.Errorf("unrecognized catalog format (expected a normalized catalog, AWS offer file, Azure retail prices or GCP SKU list)")
+}
+
+// SaveCatalog writes a catalog in the normalized JSON format
+func SaveCatalog(catalog *Catalog, path string) error {
+	sort.SliceStable(catalog.Prices, func(i, j int) bool {
+		a, b := catalog.Prices[i].PriceKey, catalog.Prices[j].PriceKey
+		if a.Service != b.Service {
+			return a.Service < b.Service
+		}
+		if a.Region != b.Region {
+			return a.Region < b.Region
+		}
+		if a.Size != b.Size {
+			return a.Size < b.Size
+		}
+		if a.OS != b.OS {
+			return a.OS < b.OS
+		}
+		if a.StorageClass != b.StorageClass {
+			return a.StorageClass < b.StorageClass
+		}
+		return a.Usage < b.Usage
+	})
+	catalog.buildIndex()
+
+	data, err := json.MarshalIndent(catalog, "", "  ")
+	if err != nil {
+		return fmt.Errorf("failed to encode catalog: %w", err)
+	}
+
+	if err := os.MkdirAll(filepath.Dir(path), 0o750); err != nil {
+		return fmt.Errorf("failed to create catalog directory: %w", err)
+	}
+
+	tmp := path + ".tmp"
+	if err := os.WriteFile(tmp, data, 0o600); err != nil {
+		return fmt.Errorf("failed to write catalog: %w", err)
+	}
+	if err := os.Rename(tmp, path); err != nil {
+		return fmt.Errorf("failed to write catalog: %w", err)
+	}
+
+	return nil
+}
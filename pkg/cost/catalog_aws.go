@@ -0,0 +1,457 @@
+package cost
+
+import (
+	"encoding/csv"
+	"encoding/json"
+	"fmt"
+	"io"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// awsOffer is the subset of an AWS Price List bulk offer file (index.json) used for catalogs
+type awsOffer struct {
+	OfferCode       string                `json:"offerCode"`
+	Version         string                `json:"version"`
+	PublicationDate string                `json:"publicationDate"`
+	Products        map[string]awsProduct `json:"products"`
+	Terms           struct {
+		OnDemand map[string]map[string]awsTerm `json:"OnDemand"`
+	} `json:"terms"`
+}
+
+type awsProduct struct {
+	SKU           string            `json:"sku"`
+	ProductFamily string            `json:"productFamily"`
+	Attributes    map[string]string `json:"attributes"`
+}
+
+type awsTerm struct {
+	PriceDimensions map[string]awsPriceDimension `json:"priceDimensions"`
+}
+
+type awsPriceDimension struct {
+	Unit         string            `json:"unit"`
+	BeginRange   string            `json:"beginRange"`
+	PricePerUnit map[string]string `json:"pricePerUnit"`
+}
+
+// awsS3VolumeTypes maps S3 price list volume types to S3 storage classes
+var awsS3VolumeTypes = map[string]string{
+	"Standard":                              "STANDARD",
+	"Standard - Infrequent Access":          "STANDARD_IA",
+	"One Zone - Infrequent Access":          "ONEZONE_IA",
+	"Amazon Glacier":                        "GLACIER",
+	"Glacier Flexible Retrieval":            "GLACIER",
+	"Glacier Instant Retrieval":             "GLACIER_IR",
+	"Glacier Deep Archive":                  "DEEP_ARCHIVE",
+	"Intelligent-Tiering Frequent Access":   "INTELLIGENT_TIERING",
+	"IntelligentTieringFrequentAccess":      "INTELLIGENT_TIERING",
+	"Reduced Redundancy":                    "REDUCED_REDUNDANCY",
+	"Intelligent-Tiering Infrequent Access": "INTELLIGENT_TIERING_IA",
+}
+
+// awsEFSStorageClasses maps EFS price list storage classes to the storage classes of regional
+// file systems
+var awsEFSStorageClasses = map[string]string{
+	"General Purpose":   "standard",
+	"Infrequent Access": "infrequent_access",
+	"Archive":           "archive",
+}
+
+// parseAWSOfferJSON normalizes an AWS Price List bulk offer file
+func parseAWSOfferJSON(data []byte) (*Catalog, error) {
+	var offer awsOffer
+	if err := json.Unmarshal(data, &offer); err != nil {
+		return nil, fmt.Errorf("invalid AWS offer file: %w", err)
+	}
+
+	catalog := newAWSCatalog(offer.PublicationDate)
+
+	for sku, product := range offer.Products {
+		for _, term := range offer.Terms.OnDemand[sku] {
+			var selected *awsTier
+			for _, dimension := range term.PriceDimensions {
+				price, err := strconv.ParseFloat(dimension.PricePerUnit["USD"], 64)
+				if err != nil {
+					continue
+				}
+				tier := newAWSTier(dimension.BeginRange, dimension.Unit, price)
+				if tier.better(selected) {
+					selected = &tier
+				}
+			}
+			if selected != nil {
+				addAWSPrice(catalog, product.ProductFamily, product.Attributes, selected.unit, selected.price)
+			}
+		}
+	}
+
+	return catalog, nil
+}
+
+// awsCSVColumns maps AWS Price List CSV columns to offer file attribute names
+var awsCSVColumns = map[string]string{
+	"serviceCode":       "servicecode",
+	"Instance Type":     "instanceType",
+	"Operating System":  "operatingSystem",
+	"Tenancy":           "tenancy",
+	"Pre Installed S/W": "preInstalledSw",
+	"CapacityStatus":    "capacitystatus",
+	"License Model":     "licenseModel",
+	"Region Code":       "regionCode",
+	"Volume API Name":   "volumeApiName",
+	"Volume Type":       "volumeType",
+	"Storage Class":     "storageClass",
+	"usageType":         "usagetype",
+	"Database Engine":   "databaseEngine",
+	"Deployment Option": "deploymentOption",
+	"Group":             "group",
+}
+
+// parseAWSOfferCSV normalizes an AWS Price List bulk offer file in CSV format. The file is
+// streamed so that large offer files (e.g., EC2) do not need to fit in memory.
+func parseAWSOfferCSV(reader io.Reader) (*Catalog, error) {
+	r := csv.NewReader(reader)
+	r.FieldsPerRecord = -1
+
+	publicationDate := ""
+	var header map[string]int
+
+	for {
+		record, err := r.Read()
+		if err == io.EOF {
+			break
+		}
+		if err != nil {
+			return nil, fmt.Errorf("invalid AWS offer CSV: %w", err)
+		}
+
+		// Metadata lines precede the header, e.g. "Publication Date","2024-06-01T00:00:00Z"
+		if header == nil {
+			if len(record) >= 2 && record[0] == "Publication Date" {
+				publicationDate = record[1]
+			}
+			if len(record) > 0 && record[0] == "SKU" {
+				header = make(map[string]int, len(record))
+				for i, column := range record {
+					header[column] = i
+				}
+				if _, ok := header["PricePerUnit"]; !ok {
+					return nil, fmt.Errorf("invalid AWS offer CSV: missing PricePerUnit column")
+				}
+				break
+			}
+			continue
+		}
+	}
+
+	if header == nil {
+		return nil, fmt.Errorf("invalid AWS offer CSV: header row not found")
+	}
+
+	catalog := newAWSCatalog(publicationDate)
+
+	// Tiers of a product are on separate rows, so the selected tier of each product is only
+	// known at the end of the file
+	type csvProduct struct {
+		family     string
+		attributes map[string]string
+		tier       awsTier
+	}
+	products := make(map[string]*csvProduct)
+
+	column := func(record []string, name string) string {
+		if i, ok := header[name]; ok && i < len(record) {
+			return record[i]
+		}
+		return ""
+	}
+
+	for {
+		record, err := r.Read()
+		if err == io.EOF {
+			break
+		}
+		if err != nil {
+			return nil, fmt.Errorf("invalid AWS offer CSV: %w", err)
+		}
+
+		if column(record, "TermType") != "OnDemand" || column(record, "Currency") != "USD" {
+			continue
+		}
+
+		price, err := strconv.ParseFloat(column(record, "PricePerUnit"), 64)
+		if err != nil {
+			continue
+		}
+
+		tier := newAWSTier(column(record, "StartingRange"), column(record, "Unit"), price)
+		sku := column(record, "SKU")
+		if existing, ok := products[sku]; ok {
+			if tier.better(&existing.tier) {
+				existing.tier = tier
+			}
+			continue
+		}
+
+		attributes := make(map[string]string, len(awsCSVColumns))
+		for csvColumn, attribute := range awsCSVColumns {
+			attributes[attribute] = column(record, csvColumn)
+		}
+		products[sku] = &csvProduct{family: column(record, "Product Family"), attributes: attributes, tier: tier}
+	}
+
+	for _, product := range products {
+		if product.tier.price > 0 {
+			addAWSPrice(catalog, product.family, product.attributes, product.tier.unit, product.tier.price)
+		}
+	}
+
+	return catalog, nil
+}
+
+// awsTier is a price tier of an AWS price list product
+type awsTier struct {
+	begin float64
+	unit  string
+	price float64
+}
+
+// newAWSTier creates a price tier; an empty begin range is the first tier
+func newAWSTier(beginRange, unit string, price float64) awsTier {
+	begin, err := strconv.ParseFloat(beginRange, 64)
+	if err != nil {
+		begin = 0
+	}
+	return awsTier{begin: begin, unit: unit, price: price}
+}
+
+// better reports whether the tier should be used instead of the selected one. The first
+// paid tier is used: free tiers don't reflect the price of additional usage.
+func (t awsTier) better(selected *awsTier) bool {
+	if t.price <= 0 {
+		return false
+	}
+	return selected == nil || selected.price <= 0 || t.begin < selected.begin
+}
+
+// newAWSCatalog creates an empty AWS catalog versioned by the offer publication date
+func newAWSCatalog(publicationDate string) *Catalog {
+	catalog := &Catalog{
+		Provider:    "aws",
+		Currency:    "USD",
+		Source:      "AWS Price List bulk API",
+		GeneratedAt: time.Now().UTC(),
+	}
+	if t, err := time.Parse(time.RFC3339, publicationDate); err == nil {
+		catalog.Version = "aws-" + t.UTC().Format("2006-01-02")
+	}
+	return catalog
+}
+
+// addAWSPrice converts an AWS price list product into a catalog price, if it is one we use
+func addAWSPrice(catalog *Catalog, family string, attributes map[string]string, unit string, price float64) {
+	region := attributes["regionCode"]
+	usageType := attributes["usagetype"]
+
+	key := PriceKey{Region: region}
+
+	switch attributes["servicecode"] {
+	case "AmazonEC2":
+		switch family {
+		case "Compute Instance":
+			if attributes["tenancy"] != "Shared" || attributes["preInstalledSw"] != "NA" ||
+				(attributes["capacitystatus"] != "" && attributes["capacitystatus"] != "Used") ||
+				attributes["licenseModel"] == "Bring your own license" {
+				return
+			}
+			key.Service = "ec2"
+			key.Size = attributes["instanceType"]
+			key.OS = normalizeOS(attributes["operatingSystem"])
+		case "Storage":
+			key.Service = "ebs"
+			key.StorageClass = attributes["volumeApiName"]
+		case "Storage Snapshot":
+			key.Service = "ebs-snapshot"
+			key.StorageClass = awsUsage(usageType, map[string]string{"EBS:SnapshotUsage": "standard", "EBS:SnapshotArchiveStorage": "archive"})
+			if key.StorageClass == "none" {
+				return
+			}
+		case "NAT Gateway":
+			key.Service = "nat-gateway"
+			key.Usage = awsUsage(usageType, map[string]string{"NatGateway-Hours": "hours", "NatGateway-Bytes": "data-processed"})
+		default:
+			return
+		}
+	case "AmazonElastiCache":
+		if family != "Cache Instance" {
+			return
+		}
+		key.Service = "elasticache"
+		key.Size = attributes["instanceType"]
+	case "AmazonMemoryDB":
+		if attributes["instanceType"] == "" {
+			return
+		}
+		key.Service = "memorydb"
+		key.Size = attributes["instanceType"]
+	case "AmazonRDS":
+		// Multi-AZ deployments are priced as twice the Single-AZ price
+		switch {
+		case family == "Database Instance" && attributes["deploymentOption"] == "Single-AZ":
+			key.Service = "rds"
+			key.Size = attributes["instanceType"]
+			key.OS = normalizeDBEngine(attributes["databaseEngine"])
+		case family == "Database Storage" && strings.HasSuffix(usageType, "Aurora:StorageUsage"):
+			key.Service = "rds-storage"
+			key.StorageClass = "aurora"
+		case family == "Database Storage" && attributes["deploymentOption"] == "Single-AZ":
+			key.Service = "rds-storage"
+			key.StorageClass = awsUsage(usageType, map[string]string{
+				"RDS:GP2-Storage":       "gp2",
+				"RDS:GP3-Storage":       "gp3",
+				"RDS:PIOPS-Storage":     "io1",
+				"RDS:PIOPS-Storage-IO2": "io2",
+				"RDS:StorageUsage":      "standard",
+			})
+			if key.StorageClass == "none" {
+				return
+			}
+		default:
+			return
+		}
+	case "AmazonEFS":
+		storageClass, ok := awsEFSStorageClasses[attributes["storageClass"]]
+		if family != "Storage" || !ok || !strings.HasSuffix(usageType, "TimedStorage-ByteHrs") {
+			return
+		}
+		key.Service = "efs"
+		key.StorageClass = storageClass
+	case "AmazonEKS":
+		if !strings.Contains(usageType, "AmazonEKS-Hours:perCluster") {
+			return
+		}
+		key.Service = "eks"
+	case "AWSLambda":
+		if strings.Contains(usageType, "ARM") || strings.Contains(usageType, "Edge") {
+			return
+		}
+		key.Service = "lambda"
+		switch attributes["group"] {
+		case "AWS-Lambda-Requests":
+			key.Usage = "requests"
+		case "AWS-Lambda-Duration":
+			key.Usage = "duration"
+		default:
+			return
+		}
+	case "AWSELB":
+		switch family {
+		case "Load Balancer-Application":
+			key.Service = "alb"
+		case "Load Balancer-Network":
+			key.Service = "nlb"
+		case "Load Balancer":
+			key.Service = "elb"
+		default:
+			return
+		}
+		key.Usage = awsUsage(usageType, map[string]string{"LoadBalancerUsage": "hours", "LCUUsage": "lcu", "DataProcessing-Bytes": "data-processed"})
+	case "AmazonS3":
+		storageClass, ok := awsS3VolumeTypes[attributes["volumeType"]]
+		if family != "Storage" || !ok {
+			return
+		}
+		key.Service = "s3"
+		key.StorageClass = storageClass
+	case "AmazonDynamoDB":
+		key.Service = "dynamodb"
+		key.Usage = awsUsage(usageType, map[string]string{
+			"ReadCapacityUnit-Hrs":  "read-capacity",
+			"WriteCapacityUnit-Hrs": "write-capacity",
+			"ReadRequestUnits":      "read-requests",
+			"WriteRequestUnits":     "write-requests",
+			"TimedStorage-ByteHrs":  "storage",
+		})
+	case "AmazonECR":
+		if !strings.Contains(usageType, "TimedStorage-ByteHrs") {
+			return
+		}
+		key.Service = "ecr"
+	default:
+		return
+	}
+
+	// Usage types we don't price (e.g., data transfer)
+	if key.Usage == "none" {
+		return
+	}
+
+	catalog.Add(Price{PriceKey: key, Unit: normalizeAWSUnit(unit), Price: price})
+}
+
+// awsUsage maps a usage type to a usage dimension by suffix; "none" if it matches none of them
+func awsUsage(usageType string, suffixes map[string]string) string {
+	for suffix, usage := range suffixes {
+		if strings.HasSuffix(usageType, suffix) {
+			return usage
+		}
+	}
+	return "none"
+}
+
+// normalizeAWSUnit converts AWS price list units to catalog units
+func normalizeAWSUnit(unit string) string {
+	lower := strings.ToLower(unit)
+	switch {
+	case lower == "hrs" || lower == "hours" || strings.HasSuffix(lower, "-hrs"):
+		return UnitHour // includes LCU-Hrs and ReadCapacityUnit-Hrs
+	case lower == "gb-mo" || lower == "gb-month":
+		return UnitGBMonth
+	case lower == "gb":
+		return UnitGB
+	case lower == "lambda-gb-second" || lower == "gb-seconds" || lower == "gb-second":
+		return UnitGBSecond
+	case lower == "requests" || lower == "request" || strings.HasSuffix(lower, "requestunits"):
+		return UnitRequest
+	}
+	return lower
+}
+
+// normalizeDBEngine converts a database engine, as named by RDS or by the price list, to a
+// catalog engine: mysql, postgresql, mariadb, aurora-mysql, aurora-postgresql, oracle or
+// sqlserver
+func normalizeDBEngine(engine string) string {
+	lower := strings.ToLower(engine)
+	switch {
+	case strings.HasPrefix(lower, "aurora") && strings.Contains(lower, "postgres"):
+		return "aurora-postgresql"
+	case strings.HasPrefix(lower, "aurora"):
+		return "aurora-mysql" // "aurora" is Aurora MySQL 1
+	case strings.Contains(lower, "postgres"):
+		return "postgresql"
+	case strings.HasPrefix(lower, "oracle"):
+		return "oracle"
+	case strings.HasPrefix(lower, "sqlserver") || strings.HasPrefix(lower, "sql server"):
+		return "sqlserver"
+	}
+	return lower
+}
+
+// normalizeOS converts an operating system name to a catalog OS
+func normalizeOS(os string) string {
+	lower := strings.ToLower(os)
+	switch {
+	case lower == "" || strings.HasPrefix(lower, "linux"):
+		return OSLinux
+	case strings.HasPrefix(lower, "windows"):
+		return OSWindows
+	case strings.Contains(lower, "red hat") || lower == "rhel":
+		return "rhel"
+	case strings.Contains(lower, "suse"):
+		return "suse"
+	}
+	return lower
+}
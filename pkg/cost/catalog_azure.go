@@ -0,0 +1,108 @@
+package cost
+
+import (
+	"encoding/json"
+	"fmt"
+	"strings"
+	"time"
+)
+
+// azureRetailPrices is a page of the Azure retail prices API (https://prices.azure.com/api/retail/prices)
+type azureRetailPrices struct {
+	BillingCurrency string            `json:"BillingCurrency"`
+	Items           []azureRetailItem `json:"Items"`
+	NextPageLink    string            `json:"NextPageLink"`
+}
+
+type azureRetailItem struct {
+	CurrencyCode      string  `json:"currencyCode"`
+	RetailPrice       float64 `json:"retailPrice"`
+	ArmRegionName     string  `json:"armRegionName"`
+	ArmSkuName        string  `json:"armSkuName"`
+	ProductName       string  `json:"productName"`
+	SkuName           string  `json:"skuName"`
+	ServiceName       string  `json:"serviceName"`
+	MeterName         string  `json:"meterName"`
+	UnitOfMeasure     string  `json:"unitOfMeasure"`
+	Type              string  `json:"type"`
+	TierMinimumUnits  float64 `json:"tierMinimumUnits"`
+	EffectiveStartDay string  `json:"effectiveStartDate"`
+}
+
+// azureStorageRedundancy maps blob storage retail SKU names to storage account SKUs
+var azureStorageRedundancy = map[string]string{
+	"Hot LRS":     "Standard_LRS",
+	"Hot GRS":     "Standard_GRS",
+	"Hot RA-GRS":  "Standard_RAGRS",
+	"Hot ZRS":     "Standard_ZRS",
+	"Hot GZRS":    "Standard_GZRS",
+	"Premium LRS": "Premium_LRS",
+	"Premium ZRS": "Premium_ZRS",
+}
+
+// parseAzureRetailPrices normalizes a page of the Azure retail prices API
+func parseAzureRetailPrices(data []byte, version string) (*Catalog, error) {
+	var page azureRetailPrices
+	if err := json.Unmarshal(data, &page); err != nil {
+		return nil, fmt.Errorf("invalid Azure retail prices: %w", err)
+	}
+
+	catalog := newAzureCatalog(version)
+	addAzureItems(catalog, page.Items)
+
+	return catalog, nil
+}
+
+// newAzureCatalog creates an empty Azure catalog
+func newAzureCatalog(version string) *Catalog {
+	return &Catalog{
+		Provider:    "azure",
+		Version:     version,
+		Currency:    "USD",
+		Source:      "Azure retail prices API",
+		GeneratedAt: time.Now().UTC(),
+	}
+}
+
+// addAzureItems converts Azure retail price items into catalog prices
+func addAzureItems(catalog *Catalog, items []azureRetailItem) {
+	for _, item := range items {
+		if item.Type != "Consumption" || item.TierMinimumUnits != 0 {
+			continue
+		}
+		if item.CurrencyCode != "" && item.CurrencyCode != catalog.Currency {
+			continue
+		}
+
+		key := PriceKey{Region: item.ArmRegionName}
+		var unit string
+
+		switch item.ServiceName {
+		case "Virtual Machines":
+			if strings.Contains(item.SkuName, "Spot") || strings.Contains(item.SkuName, "Low Priority") ||
+				item.UnitOfMeasure != "1 Hour" || item.ArmSkuName == "" {
+				continue
+			}
+			key.Service = "vm"
+			key.Size = item.ArmSkuName
+			key.OS = OSLinux
+			if strings.Contains(item.ProductName, "Windows") {
+				key.OS = OSWindows
+			}
+			unit = UnitHour
+		case "Storage":
+			storageClass, ok := azureStorageRedundancy[item.SkuName]
+			if !ok || !strings.Contains(item.ProductName, "Blob") || !strings.HasSuffix(item.MeterName, "Data Stored") ||
+				item.UnitOfMeasure != "1 GB/Month" {
+				continue
+			}
+			key.Service = "storage"
+			key.StorageClass = storageClass
+			unit = UnitGBMonth
+		default:
+			continue
+		}
+
+		catalog.Add(Price{PriceKey: key, Unit: unit, Price: item.RetailPrice})
+	}
+}
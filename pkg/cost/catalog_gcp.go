@@ -0,0 +1,236 @@
+package cost
+
+import (
+	"encoding/json"
+	"fmt"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// gcpSKUList is a page of the Cloud Billing Catalog API (services.skus.list)
+type gcpSKUList struct {
+	SKUs          []gcpSKU `json:"skus"`
+	NextPageToken string   `json:"nextPageToken"`
+}
+
+type gcpSKU struct {
+	Description string `json:"description"`
+	Category    struct {
+		ServiceDisplayName string `json:"serviceDisplayName"`
+		ResourceFamily     string `json:"resourceFamily"`
+		ResourceGroup      string `json:"resourceGroup"`
+		UsageType          string `json:"usageType"`
+	} `json:"category"`
+	ServiceRegions []string `json:"serviceRegions"`
+	PricingInfo    []struct {
+		PricingExpression struct {
+			UsageUnit   string `json:"usageUnit"`
+			TieredRates []struct {
+				StartUsageAmount float64 `json:"startUsageAmount"`
+				UnitPrice        struct {
+					CurrencyCode string `json:"currencyCode"`
+					Units        string `json:"units"`
+					Nanos        int64  `json:"nanos"`
+				} `json:"unitPrice"`
+			} `json:"tieredRates"`
+		} `json:"pricingExpression"`
+	} `json:"pricingInfo"`
+}
+
+// gcpFamilies maps the first word of Compute Engine SKU descriptions to machine families
+var gcpFamilies = map[string]string{
+	"N1":      "n1",
+	"N2":      "n2",
+	"N2D":     "n2d",
+	"E2":      "e2",
+	"C2":      "c2",
+	"C2D":     "c2d",
+	"C3":      "c3",
+	"T2D":     "t2d",
+	"Compute": "c2", // "Compute optimized Core running in ..."
+	"Memory":  "m1", // "Memory-optimized Instance Core running in ..."
+}
+
+// gcpSharedCoreSKUs maps shared-core SKU description prefixes to machine types
+var gcpSharedCoreSKUs = map[string]string{
+	"Micro Instance with burstable CPU": "f1-micro",
+	"Small Instance with 1 VCPU":        "g1-small",
+}
+
+// gcpStorageClasses maps Cloud Storage resource groups to storage classes
+var gcpStorageClasses = map[string]string{
+	"RegionalStorage":      "STANDARD",
+	"MultiRegionalStorage": "STANDARD",
+	"NearlineStorage":      "NEARLINE",
+	"ColdlineStorage":      "COLDLINE",
+	"ArchiveStorage":       "ARCHIVE",
+}
+
+// parseGCPSKUs normalizes a page of the Cloud Billing Catalog API
+func parseGCPSKUs(data []byte, version string) (*Catalog, error) {
+	var list gcpSKUList
+	if err := json.Unmarshal(data, &list); err != nil {
+		return nil, fmt.Errorf("invalid GCP SKU list: %w", err)
+	}
+
+	catalog := newGCPCatalog(version)
+	addGCPSKUs(catalog, list.SKUs)
+
+	return catalog, nil
+}
+
+// newGCPCatalog creates an empty GCP catalog
+func newGCPCatalog(version string) *Catalog {
+	return &Catalog{
+		Provider:    "gcp",
+		Version:     version,
+		Currency:    "USD",
+		Source:      "GCP Cloud Billing Catalog API",
+		GeneratedAt: time.Now().UTC(),
+	}
+}
+
+// addGCPSKUs converts GCP SKUs into catalog prices. Compute Engine core and RAM prices are
+// stored per machine family (size) with the "core" and "ram" usages.
+func addGCPSKUs(catalog *Catalog, skus []gcpSKU) {
+	for _, sku := range skus {
+		if sku.Category.UsageType != "OnDemand" || len(sku.PricingInfo) == 0 {
+			continue
+		}
+
+		rates := sku.PricingInfo[0].PricingExpression.TieredRates
+		if len(rates) == 0 {
+			continue
+		}
+		// The last tier is the standard rate (earlier tiers are usually free usage)
+		rate := rates[len(rates)-1].UnitPrice
+		if rate.CurrencyCode != "" && rate.CurrencyCode != catalog.Currency {
+			continue
+		}
+		units, err := strconv.ParseFloat(rate.Units, 64)
+		if err != nil && rate.Units != "" {
+			continue
+		}
+		price := units + float64(rate.Nanos)/1e9
+
+		var key PriceKey
+		var unit string
+
+		switch {
+		case sku.Category.ServiceDisplayName == "Compute Engine" && sku.Category.ResourceFamily == "Compute":
+			key, unit = gcpComputeKey(sku)
+		case sku.Category.ServiceDisplayName == "Cloud Storage" && sku.Category.ResourceFamily == "Storage":
+			storageClass, ok := gcpStorageClasses[sku.Category.ResourceGroup]
+			if !ok || strings.Contains(sku.Description, "Dual-region") {
+				continue
+			}
+			key = PriceKey{Service: "storage", StorageClass: storageClass}
+			unit = UnitGBMonth
+		}
+
+		if key.Service == "" {
+			continue
+		}
+
+		for _, region := range sku.ServiceRegions {
+			regional := key
+			regional.Region = region
+			catalog.Add(Price{PriceKey: regional, Unit: unit, Price: price})
+		}
+	}
+}
+
+// gcpComputeKey returns the catalog key of a Compute Engine SKU, or an empty key if it is not used
+func gcpComputeKey(sku gcpSKU) (PriceKey, string) {
+	description := sku.Description
+
+	for _, skip := range []string{"Custom", "Preemptible", "Spot", "Sole Tenancy", "Commitment", "Extended"} {
+		if strings.Contains(description, skip) {
+			return PriceKey{}, ""
+		}
+	}
+
+	for prefix, machineType := range gcpSharedCoreSKUs {
+		if strings.HasPrefix(description, prefix) {
+			return PriceKey{Service: "compute", Size: machineType}, UnitHour
+		}
+	}
+
+	first, _, _ := strings.Cut(description, " ")
+	family, ok := gcpFamilies[first]
+	if !ok {
+		return PriceKey{}, ""
+	}
+
+	switch {
+	case strings.Contains(description, "Core"):
+		return PriceKey{Service: "compute", Size: family, Usage: "core"}, UnitHour
+	case strings.Contains(description, "Ram"):
+		return PriceKey{Service: "compute", Size: family, Usage: "ram"}, UnitHour
+	}
+
+	return PriceKey{}, ""
+}
+
+// gcpMemoryPerCPU is the memory (GB) per vCPU of predefined machine types by family and class
+var gcpMemoryPerCPU = map[string]map[string]float64{
+	"n1":  {"standard": 3.75, "highmem": 6.5, "highcpu": 0.9},
+	"n2":  {"standard": 4, "highmem": 8, "highcpu": 1},
+	"n2d": {"standard": 4, "highmem": 8, "highcpu": 1},
+	"e2":  {"standard": 4, "highmem": 8, "highcpu": 1},
+	"c2":  {"standard": 4},
+	"c2d": {"standard": 4, "highmem": 8, "highcpu": 2},
+	"c3":  {"standard": 4, "highmem": 8, "highcpu": 2},
+	"t2d": {"standard": 4},
+}
+
+// gcpSharedCore lists shared-core machine types, which are priced per instance
+var gcpSharedCore = map[string]bool{
+	"f1-micro": true, "g1-small": true, "e2-micro": true, "e2-small": true, "e2-medium": true,
+}
+
+// gcpMachineSpec returns the family, vCPUs and memory (GB) of a machine type
+// (e.g., n1-standard-4, e2-highmem-8, custom-4-16384, n2-custom-2-8192)
+func gcpMachineSpec(machineType string) (family string, vcpus, memoryGB float64, ok bool) {
+	parts := strings.Split(machineType, "-")
+
+	// Custom machine types: [family-]custom-<cpus>-<memory MB>[-ext]
+	for i, part := range parts {
+		if part != "custom" || i+2 >= len(parts) {
+			continue
+		}
+		family = "n1"
+		if i > 0 {
+			family = parts[0]
+		}
+		cpus, err := strconv.ParseFloat(parts[i+1], 64)
+		if err != nil {
+			return "", 0, 0, false
+		}
+		memoryMB, err := strconv.ParseFloat(parts[i+2], 64)
+		if err != nil {
+			return "", 0, 0, false
+		}
+		return family, cpus, memoryMB / 1024, true
+	}
+
+	if len(parts) != 3 {
+		return "", 0, 0, false
+	}
+
+	ratios, ok := gcpMemoryPerCPU[parts[0]]
+	if !ok {
+		return "", 0, 0, false
+	}
+	ratio, ok := ratios[parts[1]]
+	if !ok {
+		return "", 0, 0, false
+	}
+	cpus, err := strconv.ParseFloat(parts[2], 64)
+	if err != nil {
+		return "", 0, 0, false
+	}
+
+	return parts[0], cpus, cpus * ratio, true
+}
@@ -0,0 +1,59 @@
+package cost
+
+import (
+	"encoding/json"
+	"strings"
+	"testing"
+)
+
+func TestBundledCatalogsLoad(t *testing.T) {
+	set, err := loadBundledCatalogs()
+	if err != nil {
+		t.Fatalf("loadBundledCatalogs failed: %v", err)
+	}
+
+	entries, err := bundledCatalogsFS.ReadDir("catalogs")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(entries) == 0 {
+		t.Fatal("no bundled catalogs")
+	}
+
+	for _, entry := range entries {
+		t.Run(entry.Name(), func(t *testing.T) {
+			data, err := bundledCatalogsFS.ReadFile("catalogs/" + entry.Name())
+			if err != nil {
+				t.Fatal(err)
+			}
+			var raw Catalog
+			if err := json.Unmarshal(data, &raw); err != nil {
+				t.Fatalf("failed to parse %s: %v", entry.Name(), err)
+			}
+
+			provider := strings.TrimSuffix(entry.Name(), ".json")
+			catalog := set.Get(provider)
+			if catalog == nil {
+				t.Fatalf("no %s catalog in the bundled catalogs", provider)
+			}
+			if catalog.Version == "" || catalog.Currency == "" || len(catalog.Prices) == 0 {
+				t.Fatalf("incomplete %s catalog: version %q, currency %q, %d prices", provider, catalog.Version, catalog.Currency, len(catalog.Prices))
+			}
+			if len(catalog.index) != len(catalog.Prices) {
+				t.Errorf("%s catalog indexes %d of %d prices", provider, len(catalog.index), len(catalog.Prices))
+			}
+			if len(catalog.Prices) != len(raw.Prices) {
+				t.Errorf("%s catalog has %d duplicate prices", provider, len(raw.Prices)-len(catalog.Prices))
+			}
+
+			for _, price := range catalog.Prices {
+				if price.Price < 0 || price.Unit == "" {
+					t.Errorf("invalid %s price %+v", provider, price)
+				}
+				if got, unit, ok := catalog.Lookup(price.PriceKey); !ok || got != price.Price || unit != price.Unit {
+					t.Errorf("Lookup(%+v) = %g %s (%v), want %g %s", price.PriceKey, got, unit, ok, price.Price, price.Unit)
+				}
+			}
+		})
+	}
+}
@@ -0,0 +1,271 @@
+package cost
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+
+	"gopkg.in/yaml.v3"
+
+	"github.com/comfortablynumb/pmp-cloud-inspector/pkg/resource"
+)
+
+// DefaultCurrency is the currency of the pricing catalogs and flat estimates
+const DefaultCurrency = "USD"
+
+// ExchangeRates holds exchange rates relative to a base currency: one unit of the base
+// currency is worth Rates[code] units of the currency code
+type ExchangeRates struct {
+	Base    string             `json:"base" yaml:"base"`       // base currency (default USD)
+	Version string             `json:"version" yaml:"version"` // identifies the rates in converted costs, e.g. ecb-2024-06-28
+	Date    string             `json:"date,omitempty" yaml:"date,omitempty"`
+	Source  string             `json:"source,omitempty" yaml:"source,omitempty"`
+	Rates   map[string]float64 `json:"rates" yaml:"rates"`
+}
+
+// NewExchangeRates creates exchange rates relative to a base currency
+func NewExchangeRates(base, version string, rates map[string]float64) *ExchangeRates {
+	r := &ExchangeRates{
+		Base:    base,
+		Version: version,
+		Rates:   make(map[string]float64, len(rates)),
+	}
+	r.Merge(rates)
+	r.normalize()
+	return r
+}
+
+// LoadExchangeRates loads exchange rates from a JSON or YAML file
+func LoadExchangeRates(path string) (*ExchangeRates, error) {
+	// #nosec G304 - path is provided by user as CLI argument, this is expected behavior
+	content, err := os.ReadFile(path)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read exchange rates %s: %w", path, err)
+	}
+
+	var rates ExchangeRates
+	switch strings.ToLower(filepath.Ext(path)) {
+	case ".yaml", ".yml":
+		err = yaml.Unmarshal(content, &rates)
+	default:
+		err = json.Unmarshal(content, &rates)
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to parse exchange rates %s: %w", path, err)
+	}
+
+	if rates.Version == "" {
+		rates.Version = strings.TrimSuffix(filepath.Base(path), filepath.Ext(path))
+		if rates.Date != "" {
+			rates.Version += "-" + rates.Date
+		}
+	}
+	if rates.Source == "" {
+		rates.Source = path
+	}
+	rates.normalize()
+
+	for code, rate := range rates.Rates {
+		if rate <= 0 {
+			return nil, fmt.Errorf("invalid exchange rate %g for %s in %s", rate, code, path)
+		}
+	}
+
+	return &rates, nil
+}
+
+// ResolveExchangeRates loads the exchange rates of a file and applies static rates (relative to
+// the base currency of the file, or USD without a file) over them. It returns nil if neither
+// a file nor static rates are given.
+func ResolveExchangeRates(path string, static map[string]float64) (*ExchangeRates, error) {
+	if path == "" {
+		if len(static) == 0 {
+			return nil, nil
+		}
+		return NewExchangeRates(DefaultCurrency, "static", static), nil
+	}
+
+	rates, err := LoadExchangeRates(path)
+	if err != nil {
+		return nil, err
+	}
+	if len(static) > 0 {
+		rates.Merge(static)
+		rates.Version += "+static"
+	}
+
+	return rates, nil
+}
+
+// normalize upper-cases currency codes and sets the base currency rate
+func (r *ExchangeRates) normalize() {
+	r.Base = strings.ToUpper(strings.TrimSpace(r.Base))
+	if r.Base == "" {
+		r.Base = DefaultCurrency
+	}
+
+	rates := make(map[string]float64, len(r.Rates)+1)
+	for code, rate := range r.Rates {
+		rates[strings.ToUpper(strings.TrimSpace(code))] = rate
+	}
+	rates[r.Base] = 1
+	r.Rates = rates
+}
+
+// Merge adds rates relative to the same base currency, replacing existing ones. The rate of
+// the base currency is always 1.
+func (r *ExchangeRates) Merge(rates map[string]float64) {
+	for code, rate := range rates {
+		if code = strings.ToUpper(strings.TrimSpace(code)); code != r.Base {
+			r.Rates[code] = rate
+		}
+	}
+}
+
+// Currencies returns the currency codes with a rate, sorted
+func (r *ExchangeRates) Currencies() []string {
+	codes := make([]string, 0, len(r.Rates))
+	for code := range r.Rates {
+		codes = append(codes, code)
+	}
+	sort.Strings(codes)
+	return codes
+}
+
+// Rate returns the number of units of the currency to that one unit of the currency from is
+// worth, converting through the base currency
+func (r *ExchangeRates) Rate(from, to string) (float64, error) {
+	from, to = strings.ToUpper(from), strings.ToUpper(to)
+	if from == to {
+		return 1, nil
+	}
+
+	fromRate, ok := r.Rates[from]
+	if !ok || fromRate <= 0 {
+		return 0, fmt.Errorf("no exchange rate for %s", from)
+	}
+	toRate, ok := r.Rates[to]
+	if !ok || toRate <= 0 {
+		return 0, fmt.Errorf("no exchange rate for %s", to)
+	}
+
+	return toRate / fromRate, nil
+}
+
+// ConvertCost converts a cost estimate and its breakdown to a currency. The currency and amount
+// originally estimated are preserved along with the rate used, and conversions are always made
+// from the original amount, so converting twice doesn't compound rounding.
+func ConvertCost(cost *resource.ResourceCost, currency string, rates *ExchangeRates) error {
+	currency = strings.ToUpper(currency)
+
+	current := cost.Currency
+	if current == "" {
+		current = DefaultCurrency
+	}
+	if current == currency {
+		return nil
+	}
+
+	originalCurrency, originalEstimate := current, cost.MonthlyEstimate
+	if cost.OriginalCurrency != "" {
+		originalCurrency, originalEstimate = cost.OriginalCurrency, cost.OriginalEstimate
+	}
+
+	rate, err := rates.Rate(originalCurrency, currency)
+	if err != nil {
+		return err
+	}
+	step, err := rates.Rate(current, currency)
+	if err != nil {
+		return err
+	}
+
+	for component, value := range cost.Breakdown {
+		cost.Breakdown[component] = value * step
+	}
+	cost.MonthlyEstimate = originalEstimate * rate
+	cost.Currency = currency
+
+	if originalCurrency == currency {
+		// Converted back to the original currency
+		cost.OriginalCurrency = ""
+		cost.OriginalEstimate = 0
+		cost.ExchangeRate = 0
+		cost.ExchangeRateVersion = ""
+		return nil
+	}
+
+	cost.OriginalCurrency = originalCurrency
+	cost.OriginalEstimate = originalEstimate
+	cost.ExchangeRate = rate
+	cost.ExchangeRateVersion = rates.Version
+
+	return nil
+}
+
+// ConvertCollection converts the cost estimates of every resource of a collection to a currency
+// and recomputes the cost summary. The conversion is all-or-nothing: every currency is checked to
+// have a rate first, and the collection is left unchanged if any resource can't be converted, so
+// that the summary never adds amounts in different currencies.
+func ConvertCollection(collection *resource.Collection, currency string, rates *ExchangeRates) error {
+	currency = strings.ToUpper(currency)
+	if _, ok := rates.Rates[currency]; !ok {
+		return fmt.Errorf("no exchange rate for %s in exchange rates %s", currency, rates.Version)
+	}
+
+	var failed []string
+	for _, res := range collection.Resources {
+		if res.Cost == nil {
+			continue
+		}
+		if err := checkConvertible(res.Cost, currency, rates); err != nil {
+			failed = append(failed, fmt.Sprintf("%s (%v)", res.ID, err))
+		}
+	}
+	if len(failed) > 0 {
+		count := len(failed)
+		if len(failed) > 5 {
+			failed = append(failed[:5], "...")
+		}
+		return fmt.Errorf("failed to convert the cost of %d resources to %s: %s", count, currency, strings.Join(failed, ", "))
+	}
+
+	for _, res := range collection.Resources {
+		if res.Cost == nil {
+			continue
+		}
+		if err := ConvertCost(res.Cost, currency, rates); err != nil {
+			// Unreachable: every rate was checked above
+			return fmt.Errorf("failed to convert the cost of %s to %s: %w", res.ID, currency, err)
+		}
+	}
+
+	collection.RecomputeMetadata()
+
+	return nil
+}
+
+// checkConvertible returns an error if a cost estimate can't be converted to a currency, because
+// its current or original currency has no rate
+func checkConvertible(cost *resource.ResourceCost, currency string, rates *ExchangeRates) error {
+	current := cost.Currency
+	if current == "" {
+		current = DefaultCurrency
+	}
+	if current == currency {
+		return nil
+	}
+
+	if _, err := rates.Rate(current, currency); err != nil {
+		return err
+	}
+	if cost.OriginalCurrency != "" {
+		if _, err := rates.Rate(cost.OriginalCurrency, currency); err != nil {
+			return err
+		}
+	}
+	return nil
+}
@@ -0,0 +1,157 @@
+package cost
+
+import (
+	"math"
+	"testing"
+
+	"github.com/comfortablynumb/pmp-cloud-inspector/pkg/resource"
+)
+
+// testRates are exchange rates relative to USD
+func testRates() *ExchangeRates {
+	return NewExchangeRates("USD", "test-2024-06-28", map[string]float64{"EUR": 0.9, "GBP": 0.8})
+}
+
+// usdCost returns a cost estimate in USD with a compute and a storage component
+func usdCost(compute, storage float64) *resource.ResourceCost {
+	return &resource.ResourceCost{
+		MonthlyEstimate: compute + storage,
+		Currency:        "USD",
+		Breakdown:       map[string]float64{"compute": compute, "storage": storage},
+	}
+}
+
+func approx(a, b float64) bool {
+	return math.Abs(a-b) < 1e-9
+}
+
+func TestConvertCollection(t *testing.T) {
+	type wantCost struct {
+		estimate         float64
+		currency         string
+		compute          float64
+		originalCurrency string
+		originalEstimate float64
+		rate             float64
+	}
+
+	tests := []struct {
+		name      string
+		costs     []*resource.ResourceCost
+		convertTo []string // currencies converted to in turn, the last one may fail
+		wantErr   bool
+		want      []wantCost
+		wantTotal float64
+	}{
+		{
+			name:      "convert from the default currency",
+			costs:     []*resource.ResourceCost{usdCost(80, 20), {MonthlyEstimate: 10}},
+			convertTo: []string{"eur"},
+			want: []wantCost{
+				{estimate: 90, currency: "EUR", compute: 72, originalCurrency: "USD", originalEstimate: 100, rate: 0.9},
+				{estimate: 9, currency: "EUR", originalCurrency: "USD", originalEstimate: 10, rate: 0.9},
+			},
+			wantTotal: 99,
+		},
+		{
+			name:      "already converted collection",
+			costs:     []*resource.ResourceCost{usdCost(80, 20)},
+			convertTo: []string{"EUR", "GBP"},
+			want: []wantCost{
+				{estimate: 80, currency: "GBP", compute: 64, originalCurrency: "USD", originalEstimate: 100, rate: 0.8},
+			},
+			wantTotal: 80,
+		},
+		{
+			name:      "converted back to the original currency",
+			costs:     []*resource.ResourceCost{usdCost(80, 20)},
+			convertTo: []string{"EUR", "USD"},
+			want: []wantCost{
+				{estimate: 100, currency: "USD", compute: 80},
+			},
+			wantTotal: 100,
+		},
+		{
+			name: "mixed currency input",
+			costs: []*resource.ResourceCost{
+				usdCost(80, 20),
+				{MonthlyEstimate: 45, Currency: "EUR", Breakdown: map[string]float64{"compute": 45}},
+			},
+			convertTo: []string{"EUR"},
+			want: []wantCost{
+				{estimate: 90, currency: "EUR", compute: 72, originalCurrency: "USD", originalEstimate: 100, rate: 0.9},
+				{estimate: 45, currency: "EUR", compute: 45},
+			},
+			wantTotal: 135,
+		},
+		{
+			name:      "missing target rate",
+			costs:     []*resource.ResourceCost{usdCost(80, 20)},
+			convertTo: []string{"JPY"},
+			wantErr:   true,
+			want:      []wantCost{{estimate: 100, currency: "USD", compute: 80}},
+		},
+		{
+			name: "missing source rate leaves the collection unchanged",
+			costs: []*resource.ResourceCost{
+				usdCost(80, 20),
+				{MonthlyEstimate: 50, Currency: "CHF", Breakdown: map[string]float64{"compute": 50}},
+			},
+			convertTo: []string{"EUR"},
+			wantErr:   true,
+			want: []wantCost{
+				{estimate: 100, currency: "USD", compute: 80},
+				{estimate: 50, currency: "CHF", compute: 50},
+			},
+		},
+		{
+			name:      "missing rate of the original currency",
+			costs:     []*resource.ResourceCost{{MonthlyEstimate: 90, Currency: "EUR", OriginalCurrency: "CHF", OriginalEstimate: 100, ExchangeRate: 0.9, ExchangeRateVersion: "old"}},
+			convertTo: []string{"GBP"},
+			wantErr:   true,
+			want:      []wantCost{{estimate: 90, currency: "EUR", originalCurrency: "CHF", originalEstimate: 100, rate: 0.9}},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			collection := resource.NewCollection()
+			resources := make([]*resource.Resource, len(tt.costs))
+			for i, c := range tt.costs {
+				resources[i] = &resource.Resource{ID: string(rune('a' + i)), Type: resource.TypeAWSEC2Instance, Provider: "aws", Cost: c}
+				collection.Add(resources[i])
+			}
+			collection.RecomputeMetadata()
+
+			var err error
+			for _, currency := range tt.convertTo {
+				if err = ConvertCollection(collection, currency, testRates()); err != nil {
+					break
+				}
+			}
+			if tt.wantErr != (err != nil) {
+				t.Fatalf("ConvertCollection error = %v, want error %v", err, tt.wantErr)
+			}
+
+			for i, want := range tt.want {
+				got := resources[i].Cost
+				if !approx(got.MonthlyEstimate, want.estimate) || got.Currency != want.currency || !approx(got.Breakdown["compute"], want.compute) {
+					t.Errorf("cost %d: got %g %s (compute %g), want %g %s (compute %g)", i, got.MonthlyEstimate, got.Currency, got.Breakdown["compute"], want.estimate, want.currency, want.compute)
+				}
+				if got.OriginalCurrency != want.originalCurrency || !approx(got.OriginalEstimate, want.originalEstimate) || !approx(got.ExchangeRate, want.rate) {
+					t.Errorf("cost %d: got original %g %s at %g, want %g %s at %g", i, got.OriginalEstimate, got.OriginalCurrency, got.ExchangeRate, want.originalEstimate, want.originalCurrency, want.rate)
+				}
+				if (got.OriginalCurrency != "") != (got.ExchangeRateVersion != "") {
+					t.Errorf("cost %d: got exchange rate version %q with original currency %q", i, got.ExchangeRateVersion, got.OriginalCurrency)
+				}
+			}
+
+			if !tt.wantErr {
+				summary := collection.Metadata.TotalCost
+				if summary == nil || !approx(summary.Total, tt.wantTotal) || summary.Currency != tt.want[0].currency {
+					t.Errorf("got total cost %+v, want %g %s", summary, tt.wantTotal, tt.want[0].currency)
+				}
+			}
+		})
+	}
+}
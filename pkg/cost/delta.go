@@ -0,0 +1,390 @@
+package cost
+
+import (
+	"fmt"
+	"math"
+	"reflect"
+	"slices"
+	"sort"
+	"strings"
+
+	"github.com/comfortablynumb/pmp-cloud-inspector/pkg/resource"
+)
+
+// Kinds of resource cost changes
+const (
+	ChangeAdded   = "added"   // resource only in the newer snapshot
+	ChangeRemoved = "removed" // resource only in the older snapshot
+	ChangeResized = "resized" // size property changed (instance type, VM size, node type, ...)
+	ChangeChanged = "changed" // cost changed for another reason (state, usage, prices, ...)
+)
+
+// DefaultDeltaTop is the default number of resources reported as cost drivers
+const DefaultDeltaTop = 10
+
+// sizeProperties are the properties whose change means a resource was resized
+var sizeProperties = []string{
+	resource.PropInstanceType, resource.PropVMSize, resource.PropMachineType, resource.PropNodeType,
+	resource.PropMemorySize, resource.PropNumNodes, resource.PropNumShards, resource.PropNumCacheNodes,
+	resource.PropReadCapacityUnits, resource.PropWriteCapacityUnits, resource.PropBillingMode,
+}
+
+// DeltaOptions configures a cost delta report
+type DeltaOptions struct {
+	ThresholdPercent float64  // flag changes whose absolute percentage is at least this (0 = flag nothing)
+	Top              int      // number of cost drivers to report (0 = DefaultDeltaTop, negative = all)
+	TagKeys          []string // tag keys reported in ByTag (empty = all tags)
+}
+
+// DeltaReport is the cost change between two snapshots of the same inventory
+type DeltaReport struct {
+	Currency         string  `json:"currency"`
+	BaseTotal        float64 `json:"base_total"`
+	CompareTotal     float64 `json:"compare_total"`
+	Delta            float64 `json:"delta"`
+	Percent          float64 `json:"percent"` // relative to the base total, 0 if the base total is 0
+	Flagged          bool    `json:"flagged"` // the total change is above the threshold
+	ThresholdPercent float64 `json:"threshold_percent,omitempty"`
+
+	ByProvider []DeltaEntry `json:"by_provider"`
+	ByAccount  []DeltaEntry `json:"by_account"`
+	ByRegion   []DeltaEntry `json:"by_region"`
+	ByType     []DeltaEntry `json:"by_type"`
+	ByTag      []DeltaEntry `json:"by_tag"` // keyed by key=value, like CostSummary.ByTag
+
+	Drivers []ResourceCostChange `json:"drivers"` // resources with the largest cost changes
+
+	Added   ChangeTotal `json:"added"`
+	Removed ChangeTotal `json:"removed"`
+	Resized ChangeTotal `json:"resized"`
+	Changed ChangeTotal `json:"changed"`
+}
+
+// DeltaEntry is the cost change of a group of resources (a provider, region, ...)
+type DeltaEntry struct {
+	Key     string  `json:"key"`
+	Base    float64 `json:"base"`
+	Compare float64 `json:"compare"`
+	Delta   float64 `json:"delta"`
+	Percent float64 `json:"percent"` // relative to the base cost, 0 if the base cost is 0
+	Flagged bool    `json:"flagged"`
+}
+
+// ResourceCostChange is the cost change of a single resource
+type ResourceCostChange struct {
+	ResourceID   string                    `json:"resource_id"`
+	ResourceType resource.ResourceType     `json:"resource_type"`
+	Name         string                    `json:"name"`
+	Provider     string                    `json:"provider"`
+	Region       string                    `json:"region,omitempty"`
+	Change       string                    `json:"change"` // added, removed, resized or changed
+	Base         float64                   `json:"base"`
+	Compare      float64                   `json:"compare"`
+	Delta        float64                   `json:"delta"`
+	Percent      float64                   `json:"percent"`
+	Flagged      bool                      `json:"flagged"`
+	Resized      map[string][2]interface{} `json:"resized,omitempty"` // size property -> [old, new]
+}
+
+// ChangeTotal sums the resource cost changes of a kind
+type ChangeTotal struct {
+	Count int     `json:"count"`
+	Delta float64 `json:"delta"`
+}
+
+// Delta computes the cost change between two snapshots. Resources are matched by ID; resources
+// without a cost estimate count as zero. Amounts in different currencies can't be subtracted:
+// it returns an error unless every estimate of both snapshots is in the same currency (see
+// ConvertCollection).
+func Delta(base, compare *resource.Collection, opts DeltaOptions) (*DeltaReport, error) {
+	currency, err := deltaCurrency(base, compare)
+	if err != nil {
+		return nil, err
+	}
+
+	report := &DeltaReport{
+		Currency:         currency,
+		ThresholdPercent: opts.ThresholdPercent,
+		Drivers:          make([]ResourceCostChange, 0),
+	}
+
+	dimensions := []struct {
+		target *[]DeltaEntry
+		keys   func(*resource.Resource) []string
+	}{
+		{&report.ByProvider, func(r *resource.Resource) []string { return []string{r.Provider} }},
+		{&report.ByAccount, func(r *resource.Resource) []string { return nonEmpty(r.Account) }},
+		{&report.ByRegion, func(r *resource.Resource) []string { return nonEmpty(r.Region) }},
+		{&report.ByType, func(r *resource.Resource) []string { return []string{string(r.Type)} }},
+		{&report.ByTag, func(r *resource.Resource) []string { return tagPairs(r, opts.TagKeys) }},
+	}
+	for _, dimension := range dimensions {
+		*dimension.target = deltaEntries(base, compare, dimension.keys, opts.ThresholdPercent)
+	}
+
+	report.BaseTotal = collectionCost(base)
+	report.CompareTotal = collectionCost(compare)
+	report.Delta = report.CompareTotal - report.BaseTotal
+	report.Percent, report.Flagged = percentChange(report.BaseTotal, report.CompareTotal, opts.ThresholdPercent)
+
+	// Resource changes
+	baseIndex := make(map[string]*resource.Resource, len(base.Resources))
+	for _, res := range base.Resources {
+		baseIndex[res.ID] = res
+	}
+	seen := make(map[string]bool, len(compare.Resources))
+
+	var changes []ResourceCostChange
+	for _, res := range compare.Resources {
+		seen[res.ID] = true
+		if change, ok := resourceCostChange(baseIndex[res.ID], res, opts.ThresholdPercent); ok {
+			changes = append(changes, change)
+		}
+	}
+	for _, res := range base.Resources {
+		if seen[res.ID] {
+			continue
+		}
+		if change, ok := resourceCostChange(res, nil, opts.ThresholdPercent); ok {
+			changes = append(changes, change)
+		}
+	}
+
+	for _, change := range changes {
+		var total *ChangeTotal
+		switch change.Change {
+		case ChangeAdded:
+			total = &report.Added
+		case ChangeRemoved:
+			total = &report.Removed
+		case ChangeResized:
+			total = &report.Resized
+		default:
+			total = &report.Changed
+		}
+		total.Count++
+		total.Delta += change.Delta
+	}
+
+	// Largest changes first, increases before decreases of the same size
+	sort.Slice(changes, func(i, j int) bool {
+		a, b := math.Abs(changes[i].Delta), math.Abs(changes[j].Delta)
+		if a != b {
+			return a > b
+		}
+		if changes[i].Delta != changes[j].Delta {
+			return changes[i].Delta > changes[j].Delta
+		}
+		return changes[i].ResourceID < changes[j].ResourceID
+	})
+
+	top := opts.Top
+	if top == 0 {
+		top = DefaultDeltaTop
+	}
+	if top > 0 && len(changes) > top {
+		changes = changes[:top]
+	}
+	report.Drivers = append(report.Drivers, changes...)
+
+	return report, nil
+}
+
+// resourceCostChange returns the cost change of a resource between two snapshots, where either
+// side may be nil. Resources whose cost did not change are skipped.
+func resourceCostChange(base, compare *resource.Resource, threshold float64) (ResourceCostChange, bool) {
+	baseCost, compareCost := resourceCost(base), resourceCost(compare)
+	if baseCost == compareCost {
+		return ResourceCostChange{}, false
+	}
+
+	res := compare
+	if res == nil {
+		res = base
+	}
+
+	change := ResourceCostChange{
+		ResourceID:   res.ID,
+		ResourceType: res.Type,
+		Name:         res.Name,
+		Provider:     res.Provider,
+		Region:       res.Region,
+		Base:         baseCost,
+		Compare:      compareCost,
+		Delta:        compareCost - baseCost,
+	}
+	change.Percent, change.Flagged = percentChange(baseCost, compareCost, threshold)
+
+	switch {
+	case base == nil:
+		change.Change = ChangeAdded
+	case compare == nil:
+		change.Change = ChangeRemoved
+	default:
+		change.Change = ChangeChanged
+		for _, name := range sizeProperties {
+			oldValue, newValue := base.Properties[name], compare.Properties[name]
+			if oldValue == nil && newValue == nil {
+				continue
+			}
+			if oldNumber, ok := resource.ToFloat(oldValue); ok {
+				if newNumber, ok := resource.ToFloat(newValue); ok && oldNumber == newNumber {
+					continue
+				}
+			}
+			if !reflect.DeepEqual(oldValue, newValue) {
+				if change.Resized == nil {
+					change.Resized = make(map[string][2]interface{})
+				}
+				change.Resized[name] = [2]interface{}{oldValue, newValue}
+				change.Change = ChangeResized
+			}
+		}
+	}
+
+	return change, true
+}
+
+// deltaEntries aggregates the cost of both snapshots by the keys of a dimension
+func deltaEntries(base, compare *resource.Collection, keys func(*resource.Resource) []string, threshold float64) []DeltaEntry {
+	entries := make(map[string]*DeltaEntry)
+	add := func(collection *resource.Collection, isBase bool) {
+		for _, res := range collection.Resources {
+			cost := resourceCost(res)
+			if cost == 0 {
+				continue
+			}
+			for _, key := range keys(res) {
+				entry := entries[key]
+				if entry == nil {
+					entry = &DeltaEntry{Key: key}
+					entries[key] = entry
+				}
+				if isBase {
+					entry.Base += cost
+				} else {
+					entry.Compare += cost
+				}
+			}
+		}
+	}
+	add(base, true)
+	add(compare, false)
+
+	result := make([]DeltaEntry, 0, len(entries))
+	for _, entry := range entries {
+		entry.Delta = entry.Compare - entry.Base
+		entry.Percent, entry.Flagged = percentChange(entry.Base, entry.Compare, threshold)
+		result = append(result, *entry)
+	}
+
+	// Largest changes first
+	sort.Slice(result, func(i, j int) bool {
+		a, b := math.Abs(result[i].Delta), math.Abs(result[j].Delta)
+		if a != b {
+			return a > b
+		}
+		return result[i].Key < result[j].Key
+	})
+
+	return result
+}
+
+// percentChange returns the change from base to compare as a percentage of base, and whether
+// it reaches the threshold. A change from zero has no percentage and is always flagged.
+func percentChange(base, compare, threshold float64) (float64, bool) {
+	if base == 0 {
+		return 0, threshold > 0 && compare != 0
+	}
+
+	percent := (compare - base) / base * 100
+	return percent, threshold > 0 && math.Abs(percent) >= threshold
+}
+
+// resourceCost returns the monthly cost estimate of a resource, 0 if it has none
+func resourceCost(res *resource.Resource) float64 {
+	if res == nil || res.Cost == nil {
+		return 0
+	}
+	return res.Cost.MonthlyEstimate
+}
+
+// collectionCost returns the sum of the monthly cost estimates of a collection
+func collectionCost(collection *resource.Collection) float64 {
+	total := 0.0
+	for _, res := range collection.Resources {
+		total += resourceCost(res)
+	}
+	return total
+}
+
+// deltaCurrency returns the currency of the cost estimates of two snapshots, or an error if they
+// are in different currencies
+func deltaCurrency(base, compare *resource.Collection) (string, error) {
+	var currencies []string
+	for _, collection := range []*resource.Collection{base, compare} {
+		for _, res := range collection.Resources {
+			if res.Cost == nil {
+				continue
+			}
+			currency := res.Cost.Currency
+			if currency == "" {
+				currency = DefaultCurrency
+			}
+			if !slices.Contains(currencies, currency) {
+				currencies = append(currencies, currency)
+			}
+		}
+	}
+
+	switch len(currencies) {
+	case 0:
+		if currency := collectionCurrency(compare); currency != "" {
+			return currency, nil
+		}
+		return collectionCurrency(base), nil
+	case 1:
+		return currencies[0], nil
+	}
+	sort.Strings(currencies)
+	return "", fmt.Errorf("the cost estimates are in different currencies (%s), convert them to a single currency first", strings.Join(currencies, ", "))
+}
+
+// collectionCurrency returns the currency of the cost estimates of a collection
+func collectionCurrency(collection *resource.Collection) string {
+	if collection.Metadata.TotalCost != nil && collection.Metadata.TotalCost.Currency != "" {
+		return collection.Metadata.TotalCost.Currency
+	}
+	for _, res := range collection.Resources {
+		if res.Cost != nil && res.Cost.Currency != "" {
+			return res.Cost.Currency
+		}
+	}
+	return ""
+}
+
+// tagPairs returns the key=value pairs of the tags of a resource, restricted to the given keys
+func tagPairs(res *resource.Resource, tagKeys []string) []string {
+	var pairs []string
+	if len(tagKeys) == 0 {
+		for key, value := range res.Tags {
+			pairs = append(pairs, key+"="+value)
+		}
+		return pairs
+	}
+
+	for _, key := range tagKeys {
+		if value, ok := res.Tags[key]; ok {
+			pairs = append(pairs, key+"="+value)
+		}
+	}
+	return pairs
+}
+
+// nonEmpty returns a single-key list, or no keys if the key is empty
+func nonEmpty(key string) []string {
+	if key == "" {
+		return nil
+	}
+	return []string{key}
+}
@@ -0,0 +1,73 @@
+package cost
+
+import (
+	"testing"
+
+	"github.com/comfortablynumb/pmp-cloud-inspector/pkg/resource"
+)
+
+// costCollection returns a collection of resources with the given monthly estimates and currencies
+func costCollection(costs map[string]*resource.ResourceCost) *resource.Collection {
+	collection := resource.NewCollection()
+	for id, c := range costs {
+		collection.Add(&resource.Resource{ID: id, Type: resource.TypeAWSEC2Instance, Provider: "aws", Cost: c})
+	}
+	collection.RecomputeMetadata()
+	return collection
+}
+
+func TestDeltaCurrencies(t *testing.T) {
+	tests := []struct {
+		name         string
+		base         map[string]*resource.ResourceCost
+		compare      map[string]*resource.ResourceCost
+		wantErr      bool
+		wantCurrency string
+		wantDelta    float64
+	}{
+		{
+			name:         "same currency",
+			base:         map[string]*resource.ResourceCost{"a": {MonthlyEstimate: 100, Currency: "EUR"}},
+			compare:      map[string]*resource.ResourceCost{"a": {MonthlyEstimate: 150, Currency: "EUR"}},
+			wantCurrency: "EUR",
+			wantDelta:    50,
+		},
+		{
+			name:         "default currency",
+			base:         map[string]*resource.ResourceCost{"a": {MonthlyEstimate: 100}},
+			compare:      map[string]*resource.ResourceCost{"a": {MonthlyEstimate: 80, Currency: "USD"}},
+			wantCurrency: "USD",
+			wantDelta:    -20,
+		},
+		{
+			name:    "different currencies",
+			base:    map[string]*resource.ResourceCost{"a": {MonthlyEstimate: 100, Currency: "USD"}},
+			compare: map[string]*resource.ResourceCost{"a": {MonthlyEstimate: 100, Currency: "EUR"}},
+			wantErr: true,
+		},
+		{
+			name:    "mixed currencies in one snapshot",
+			base:    map[string]*resource.ResourceCost{"a": {MonthlyEstimate: 100, Currency: "EUR"}},
+			compare: map[string]*resource.ResourceCost{"a": {MonthlyEstimate: 100, Currency: "EUR"}, "b": {MonthlyEstimate: 10, Currency: "GBP"}},
+			wantErr: true,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			report, err := Delta(costCollection(tt.base), costCollection(tt.compare), DeltaOptions{})
+			if tt.wantErr {
+				if err == nil {
+					t.Fatalf("Delta = %+v, want an error", report)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("Delta failed: %v", err)
+			}
+			if report.Currency != tt.wantCurrency || report.Delta != tt.wantDelta {
+				t.Errorf("got delta %g %s, want %g %s", report.Delta, report.Currency, tt.wantDelta, tt.wantCurrency)
+			}
+		})
+	}
+}
@@ -23,6 +23,24 @@ func NewEstimatorRegistry() *EstimatorRegistry {
 	}
 }
 
+// NewCatalogEstimatorRegistry creates an estimator registry with the AWS, Azure and GCP
+// estimators, pricing from a catalog set and the usage assumptions
+func NewCatalogEstimatorRegistry(catalogs *CatalogSet, usage UsageAssumptions) *EstimatorRegistry {
+	awsEstimator := NewAWSEstimatorWithCatalog(catalogs.Get("aws"))
+	awsEstimator.SetUsageAssumptions(usage)
+	azureEstimator := NewAzureEstimatorWithCatalog(catalogs.Get("azure"))
+	azureEstimator.SetUsageAssumptions(usage)
+	gcpEstimator := NewGCPEstimatorWithCatalog(catalogs.Get("gcp"))
+	gcpEstimator.SetUsageAssumptions(usage)
+
+	registry := NewEstimatorRegistry()
+	registry.Register("aws", awsEstimator)
+	registry.Register("azure", azureEstimator)
+	registry.Register("gcp", gcpEstimator)
+
+	return registry
+}
+
 // Register registers an estimator for a provider
 func (r *EstimatorRegistry) Register(provider string, estimator Estimator) {
 	r.estimators[provider] = estimator
@@ -56,5 +74,58 @@ func (r *EstimatorRegistry) EstimateCollection(collection *resource.Collection)
 		}
 	}
 
+	// Aggregate the new costs into the collection metadata
+	collection.RecomputeMetadata()
+
 	return nil
 }
+
+// costBuilder accumulates the components of a resource cost
+type costBuilder struct {
+	catalog *Catalog
+	cost    *resource.ResourceCost
+}
+
+// newCostBuilder creates a cost builder pricing components from a catalog
+func newCostBuilder(catalog *Catalog) *costBuilder {
+	return &costBuilder{
+		catalog: catalog,
+		cost: &resource.ResourceCost{
+			Currency:  "USD",
+			Breakdown: make(map[string]float64),
+		},
+	}
+}
+
+// addCatalog adds a component priced from the catalog as quantity times the monthly price
+// (hourly prices are converted). It returns false if the catalog has no price for the key.
+func (b *costBuilder) addCatalog(component string, key PriceKey, quantity float64) bool {
+	monthly, pricedBy, ok := b.catalog.monthly(key)
+	if !ok {
+		return false
+	}
+
+	b.add(component, monthly*quantity)
+	b.cost.Currency = pricedBy.Currency
+	if b.cost.CatalogVersion == "" {
+		b.cost.CatalogVersion = pricedBy.Version
+	}
+
+	return true
+}
+
+// add adds a component with a monthly cost
+func (b *costBuilder) add(component string, monthly float64) {
+	b.cost.Breakdown[component] += monthly
+	b.cost.MonthlyEstimate += monthly
+}
+
+// empty reports whether no component was added
+func (b *costBuilder) empty() bool {
+	return len(b.cost.Breakdown) == 0
+}
+
+// build returns the resource cost
+func (b *costBuilder) build() *resource.ResourceCost {
+	return b.cost
+}
@@ -8,14 +8,26 @@ import (
 
 // GCPEstimator provides cost estimation for GCP resources
 type GCPEstimator struct {
-	// Simplified pricing data (USD per month)
-	// In a production system, this would integrate with GCP Cloud Billing Catalog API
+	// Simplified pricing data (USD per month), used when the catalog has no price
 	pricing map[resource.ResourceType]float64
+
+	// Pricing catalog with per-region core and RAM prices by machine family
+	catalog *Catalog
+
+	// Usage assumed for usage-priced components
+	usage UsageAssumptions
 }
 
-// NewGCPEstimator creates a new GCP cost estimator
+// NewGCPEstimator creates a new GCP cost estimator using the bundled pricing catalog
 func NewGCPEstimator() *GCPEstimator {
+	return NewGCPEstimatorWithCatalog(DefaultCatalogs().Get("gcp"))
+}
+
+// NewGCPEstimatorWithCatalog creates a new GCP cost estimator using a pricing catalog
+func NewGCPEstimatorWithCatalog(catalog *Catalog) *GCPEstimator {
 	return &GCPEstimator{
+		catalog: catalog,
+		usage:   DefaultUsageAssumptions(),
 		pricing: map[resource.ResourceType]float64{
 			// Compute Instance - Average n1-standard-1 (730 hours/month)
 			resource.TypeGCPComputeInstance: 24.27,
@@ -35,6 +47,11 @@ func NewGCPEstimator() *GCPEstimator {
 	}
 }
 
+// SetUsageAssumptions sets the usage assumed for usage-priced components
+func (e *GCPEstimator) SetUsageAssumptions(usage UsageAssumptions) {
+	e.usage = usage.WithDefaults()
+}
+
 // EstimateCost estimates the cost for a GCP resource
 func (e *GCPEstimator) EstimateCost(res *resource.Resource) (*resource.ResourceCost, error) {
 	// Get base price from pricing map
@@ -44,48 +61,69 @@ func (e *GCPEstimator) EstimateCost(res *resource.Resource) (*resource.ResourceC
 		return nil, nil
 	}
 
-	cost := &resource.ResourceCost{
-		MonthlyEstimate: basePrice,
-		Currency:        "USD",
-		Breakdown:       make(map[string]float64),
-	}
+	builder := newCostBuilder(e.catalog)
 
-	// Adjust pricing based on machine type or other properties
+	// Price each component from the machine type or other properties
 	switch res.Type {
 	case resource.TypeGCPComputeInstance:
-		cost = e.estimateComputeCost(res, basePrice)
+		e.estimateComputeCost(builder, res, basePrice)
 	case resource.TypeGCPStorageBucket:
-		cost = e.estimateStorageCost(res, basePrice)
+		storageClass, _ := res.StringProperty(resource.PropStorageClass)
+		if storageClass == "" || storageClass == "MULTI_REGIONAL" || storageClass == "REGIONAL" {
+			storageClass = "STANDARD"
+		}
+		// Bucket locations are upper case regions or multi-regions (e.g., US-CENTRAL1, EU)
+		key := PriceKey{Service: "storage", Region: strings.ToLower(res.Region), StorageClass: storageClass}
+		builder.addCatalog("storage", key, e.usage.BucketStorageGB)
 	}
 
-	return cost, nil
-}
-
-// estimateComputeCost provides more accurate Compute Engine cost based on machine type
-func (e *GCPEstimator) estimateComputeCost(res *resource.Resource, basePrice float64) *resource.ResourceCost {
-	cost := &resource.ResourceCost{
-		MonthlyEstimate: basePrice,
-		Currency:        "USD",
-		Breakdown:       make(map[string]float64),
+	// Fall back to the flat price when no component could be priced
+	if builder.empty() {
+		builder.add("base", basePrice)
 	}
 
+	return builder.build(), nil
+}
+
+// estimateComputeCost prices Compute Engine instances from the catalog: shared-core machine
+// types have a per-instance price, other machine types are priced per vCPU and GB of RAM
+func (e *GCPEstimator) estimateComputeCost(builder *costBuilder, res *resource.Resource, basePrice float64) {
 	// Check if instance is stopped
-	if status, ok := res.Properties["status"].(string); ok {
+	if status, ok := res.StringProperty(resource.PropStatus); ok {
 		if status == "TERMINATED" || status == "STOPPED" {
-			cost.MonthlyEstimate = 0
-			cost.Breakdown["compute"] = 0
-			return cost
+			builder.add("compute", 0)
+			return
 		}
 	}
 
-	// Adjust based on machine type
-	if machineType, ok := res.Properties["machine_type"].(string); ok {
-		multiplier := e.getMachineTypeMultiplier(machineType)
-		cost.MonthlyEstimate = basePrice * multiplier
-		cost.Breakdown["compute"] = cost.MonthlyEstimate
+	machineType, ok := res.StringProperty(resource.PropMachineType)
+	if !ok {
+		return
+	}
+
+	// Machine types may be full URLs (.../zones/us-central1-a/machineTypes/n1-standard-1)
+	machineType = machineType[strings.LastIndex(machineType, "/")+1:]
+
+	// Instances are located in zones; prices are per region
+	region := res.Region
+	if i := strings.LastIndex(region, "-"); i > 0 && strings.Count(region, "-") == 2 {
+		region = region[:i]
+	}
+
+	if gcpSharedCore[machineType] {
+		if builder.addCatalog("compute", PriceKey{Service: "compute", Region: region, Size: machineType}, 1) {
+			return
+		}
+	} else if family, vcpus, memoryGB, ok := gcpMachineSpec(machineType); ok {
+		coreKey := PriceKey{Service: "compute", Region: region, Size: family, Usage: "core"}
+		ramKey := PriceKey{Service: "compute", Region: region, Size: family, Usage: "ram"}
+		if _, _, hasRAM := e.catalog.Lookup(ramKey); hasRAM && builder.addCatalog("cpu", coreKey, vcpus) {
+			builder.addCatalog("memory", ramKey, memoryGB)
+			return
+		}
 	}
 
-	return cost
+	builder.add("compute", basePrice*e.getMachineTypeMultiplier(machineType))
 }
 
 // getMachineTypeMultiplier returns a cost multiplier based on machine type
@@ -114,14 +152,3 @@ func (e *GCPEstimator) getMachineTypeMultiplier(machineType string) float64 {
 		return 1.0
 	}
 }
-
-// estimateStorageCost provides storage bucket cost
-func (e *GCPEstimator) estimateStorageCost(_ *resource.Resource, basePrice float64) *resource.ResourceCost {
-	return &resource.ResourceCost{
-		MonthlyEstimate: basePrice,
-		Currency:        "USD",
-		Breakdown: map[string]float64{
-			"storage": basePrice,
-		},
-	}
-}
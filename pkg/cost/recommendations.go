@@ -0,0 +1,559 @@
+package cost
+
+import (
+	"encoding/csv"
+	"fmt"
+	"io"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/comfortablynumb/pmp-cloud-inspector/pkg/resource"
+)
+
+// Recommendation rules
+const (
+	RuleStoppedInstance     = "stopped-instance"      // stopped EC2 instances still paying for volumes
+	RuleUnusedSecurityGroup = "unused-security-group" // security groups not attached to anything
+	RuleIdleLoadBalancer    = "idle-load-balancer"    // load balancers without registered targets
+	RulePreviousGeneration  = "previous-generation"   // instance and node types with a cheaper current generation
+	RuleOversizedFunction   = "oversized-function"    // functions with very large memory settings
+	RuleUnusedSecret        = "unused-secret"         // secrets not accessed for a long time
+	RuleArchivedProject     = "archived-project"      // archived repositories still holding storage
+)
+
+// Recommendation categories
+const (
+	CategoryIdle        = "idle"        // the resource is paid for but not used
+	CategoryRightsizing = "rightsizing" // the resource is larger than it likely needs to be
+	CategoryModernize   = "modernize"   // a newer generation is cheaper for the same size
+	CategoryCleanup     = "cleanup"     // the resource is unused, with little or no cost
+)
+
+// RecommendationRules lists every recommendation rule, in report order
+var RecommendationRules = []string{
+	RuleStoppedInstance, RuleIdleLoadBalancer, RulePreviousGeneration, RuleOversizedFunction,
+	RuleUnusedSecret, RuleArchivedProject, RuleUnusedSecurityGroup,
+}
+
+// previousGenerations maps previous-generation instance families to the current generation
+// family of the same sizes
+var previousGenerations = map[string]string{
+	"t1": "t3", "t2": "t3",
+	"m1": "m5", "m3": "m5", "m4": "m5",
+	"c1": "c5", "c3": "c5", "c4": "c5",
+	"r3": "r5", "r4": "r5",
+	"i2":       "i3",
+	"cache.t1": "cache.t3", "cache.t2": "cache.t3",
+	"cache.m1": "cache.m5", "cache.m3": "cache.m5", "cache.m4": "cache.m5",
+	"cache.r3": "cache.r5", "cache.r4": "cache.r5",
+}
+
+// RecommendationSettings are the thresholds of the recommendation rules. Zero values are
+// replaced by the defaults.
+type RecommendationSettings struct {
+	Rules                    []string `yaml:"rules"`                        // rules to run (empty = all)
+	SecretUnusedDays         float64  `yaml:"secret_unused_days"`           // days without access after which a secret is unused
+	FunctionMemoryMB         float64  `yaml:"function_memory_mb"`           // memory from which a function is flagged as oversized
+	FunctionTargetMemoryMB   float64  `yaml:"function_target_memory_mb"`    // memory suggested for oversized functions
+	ArchivedProjectMinGB     float64  `yaml:"archived_project_min_gb"`      // storage from which archived projects are reported
+	ProjectStoragePricePerGB float64  `yaml:"project_storage_price_per_gb"` // USD per GB-month of repository storage
+}
+
+// DefaultRecommendationSettings returns the default recommendation settings
+func DefaultRecommendationSettings() RecommendationSettings {
+	return RecommendationSettings{
+		SecretUnusedDays:         90,
+		FunctionMemoryMB:         3008,
+		FunctionTargetMemoryMB:   1024,
+		ArchivedProjectMinGB:     1,
+		ProjectStoragePricePerGB: 0.50, // GitLab.com storage add-on, 60 USD per 10 GB per year
+	}
+}
+
+// WithDefaults returns the settings with zero values replaced by the defaults
+func (s RecommendationSettings) WithDefaults() RecommendationSettings {
+	defaults := DefaultRecommendationSettings()
+
+	for _, field := range []struct {
+		value        *float64
+		defaultValue float64
+	}{
+		{&s.SecretUnusedDays, defaults.SecretUnusedDays},
+		{&s.FunctionMemoryMB, defaults.FunctionMemoryMB},
+		{&s.FunctionTargetMemoryMB, defaults.FunctionTargetMemoryMB},
+		{&s.ArchivedProjectMinGB, defaults.ArchivedProjectMinGB},
+		{&s.ProjectStoragePricePerGB, defaults.ProjectStoragePricePerGB},
+	} {
+		if *field.value == 0 {
+			*field.value = field.defaultValue
+		}
+	}
+
+	return s
+}
+
+// Recommendation is a resource flagged as idle or wasteful, with the estimated monthly saving
+// of acting on it
+type Recommendation struct {
+	Rule          string                `json:"rule"`
+	Category      string                `json:"category"`
+	ResourceID    string                `json:"resource_id"`
+	ResourceType  resource.ResourceType `json:"resource_type"`
+	Name          string                `json:"name"`
+	Provider      string                `json:"provider"`
+	Account       string                `json:"account,omitempty"`
+	Region        string                `json:"region,omitempty"`
+	Reason        string                `json:"reason"`
+	Action        string                `json:"action"`
+	MonthlySaving float64               `json:"monthly_saving"` // 0 if the saving is negligible or unknown
+}
+
+// RecommendationTotal sums the recommendations of a rule
+type RecommendationTotal struct {
+	Rule          string  `json:"rule"`
+	Count         int     `json:"count"`
+	MonthlySaving float64 `json:"monthly_saving"`
+}
+
+// RecommendationReport lists the recommendations for a collection, largest savings first
+type RecommendationReport struct {
+	Currency        string                `json:"currency"`
+	TotalSaving     float64               `json:"total_saving"`
+	ByRule          []RecommendationTotal `json:"by_rule"`
+	Recommendations []Recommendation      `json:"recommendations"`
+}
+
+// Recommender finds idle and wasteful resources. Savings are taken from the cost estimates of
+// the resources, or estimated from the pricing catalogs for resources without one.
+type Recommender struct {
+	catalogs   *CatalogSet
+	estimators *EstimatorRegistry
+	settings   RecommendationSettings
+	rates      *ExchangeRates
+	now        time.Time
+}
+
+// NewRecommender creates a recommender pricing savings from a catalog set
+func NewRecommender(catalogs *CatalogSet, usage UsageAssumptions, settings RecommendationSettings) *Recommender {
+	return &Recommender{
+		catalogs:   catalogs,
+		estimators: NewCatalogEstimatorRegistry(catalogs, usage),
+		settings:   settings.WithDefaults(),
+		now:        time.Now(),
+	}
+}
+
+// SetExchangeRates sets the exchange rates used to convert savings priced in USD to the
+// currency of the collection
+func (r *Recommender) SetExchangeRates(rates *ExchangeRates) {
+	r.rates = rates
+}
+
+// recommendationContext holds the state of a recommendation run
+type recommendationContext struct {
+	*Recommender
+	graph    *resource.Graph
+	currency string
+	usdRate  float64 // units of the report currency per USD
+}
+
+// Recommend runs the enabled rules over a collection
+func (r *Recommender) Recommend(collection *resource.Collection) (*RecommendationReport, error) {
+	ctx := &recommendationContext{
+		Recommender: r,
+		graph:       resource.NewGraph(collection),
+		currency:    collectionCurrency(collection),
+		usdRate:     1,
+	}
+	if ctx.currency == "" {
+		ctx.currency = DefaultCurrency
+	}
+	if ctx.currency != DefaultCurrency {
+		rate, err := usdRate(collection, ctx.currency, r.rates)
+		if err != nil {
+			return nil, err
+		}
+		ctx.usdRate = rate
+	}
+
+	enabled := make(map[string]bool)
+	for _, rule := range r.settings.Rules {
+		enabled[rule] = true
+	}
+	for rule := range enabled {
+		if !isRecommendationRule(rule) {
+			return nil, fmt.Errorf("unknown recommendation rule '%s' (supported: %s)", rule, strings.Join(RecommendationRules, ", "))
+		}
+	}
+	runs := func(rule string) bool {
+		return len(enabled) == 0 || enabled[rule]
+	}
+
+	// Security groups are only known to be unused if the inventory records their attachments
+	checkSecurityGroups := runs(RuleUnusedSecurityGroup) && hasSecurityGroupAttachments(collection)
+
+	report := &RecommendationReport{
+		Currency:        ctx.currency,
+		ByRule:          make([]RecommendationTotal, 0),
+		Recommendations: make([]Recommendation, 0),
+	}
+
+	for _, res := range collection.Resources {
+		var found []*Recommendation
+
+		switch res.Type {
+		case resource.TypeAWSEC2Instance:
+			if runs(RuleStoppedInstance) {
+				found = append(found, ctx.stoppedInstance(res))
+			}
+			if runs(RulePreviousGeneration) {
+				found = append(found, ctx.previousGeneration(res, "ec2", resource.PropInstanceType, "compute"))
+			}
+		case resource.TypeAWSElastiCache:
+			if runs(RulePreviousGeneration) {
+				found = append(found, ctx.previousGeneration(res, "elasticache", resource.PropNodeType, "cache_nodes"))
+			}
+		case resource.TypeAWSELB, resource.TypeAWSALB, resource.TypeAWSNLB:
+			if runs(RuleIdleLoadBalancer) {
+				found = append(found, ctx.idleLoadBalancer(res))
+			}
+		case resource.TypeAWSLambda:
+			if runs(RuleOversizedFunction) {
+				found = append(found, ctx.oversizedFunction(res))
+			}
+		case resource.TypeAWSSecret:
+			if runs(RuleUnusedSecret) {
+				found = append(found, ctx.unusedSecret(res))
+			}
+		case resource.TypeAWSSecurityGroup:
+			if checkSecurityGroups {
+				found = append(found, ctx.unusedSecurityGroup(res))
+			}
+		case resource.TypeGitLabProject:
+			if runs(RuleArchivedProject) {
+				found = append(found, ctx.archivedProject(res))
+			}
+		}
+
+		for _, recommendation := range found {
+			if recommendation != nil {
+				report.Recommendations = append(report.Recommendations, *recommendation)
+			}
+		}
+	}
+
+	// Largest savings first, then by rule order and resource
+	ruleOrder := make(map[string]int, len(RecommendationRules))
+	for i, rule := range RecommendationRules {
+		ruleOrder[rule] = i
+	}
+	sort.Slice(report.Recommendations, func(i, j int) bool {
+		a, b := report.Recommendations[i], report.Recommendations[j]
+		if a.MonthlySaving != b.MonthlySaving {
+			return a.MonthlySaving > b.MonthlySaving
+		}
+		if a.Rule != b.Rule {
+			return ruleOrder[a.Rule] < ruleOrder[b.Rule]
+		}
+		return a.ResourceID < b.ResourceID
+	})
+
+	totals := make(map[string]*RecommendationTotal)
+	for _, recommendation := range report.Recommendations {
+		total := totals[recommendation.Rule]
+		if total == nil {
+			total = &RecommendationTotal{Rule: recommendation.Rule}
+			totals[recommendation.Rule] = total
+		}
+		total.Count++
+		total.MonthlySaving += recommendation.MonthlySaving
+		report.TotalSaving += recommendation.MonthlySaving
+	}
+	for _, rule := range RecommendationRules {
+		if total := totals[rule]; total != nil {
+			report.ByRule = append(report.ByRule, *total)
+		}
+	}
+
+	return report, nil
+}
+
+// stoppedInstance flags stopped instances, which are still charged for their EBS volumes
+func (c *recommendationContext) stoppedInstance(res *resource.Resource) *Recommendation {
+	if state, _ := res.StringProperty(resource.PropState); state != "stopped" {
+		return nil
+	}
+	volumes := res.MapsProperty(resource.PropVolumes)
+	if len(volumes) == 0 {
+		return nil
+	}
+
+	sizeGB := 0.0
+	for _, volume := range volumes {
+		if size, ok := resource.ToFloat(volume[resource.PropVolumeSizeGB]); ok {
+			sizeGB += size
+		}
+	}
+
+	return c.recommend(res, RuleStoppedInstance, CategoryIdle,
+		fmt.Sprintf("instance is stopped but still pays for %.0f GB of attached volumes", sizeGB),
+		"snapshot the volumes and terminate the instance if it is no longer needed",
+		c.component(res, "storage"))
+}
+
+// previousGeneration flags instance or node types of a previous generation family, pricing
+// the saving from the catalog price of the same size in the current generation
+func (c *recommendationContext) previousGeneration(res *resource.Resource, service, sizeProperty, component string) *Recommendation {
+	if state, _ := res.StringProperty(resource.PropState); state == "stopped" || state == "terminated" {
+		return nil
+	}
+	size, ok := res.StringProperty(sizeProperty)
+	if !ok {
+		return nil
+	}
+	dot := strings.LastIndex(size, ".")
+	if dot < 0 {
+		return nil
+	}
+	current, ok := previousGenerations[size[:dot]]
+	if !ok {
+		return nil
+	}
+	target := current + size[dot:]
+
+	key := PriceKey{Service: service, Region: res.Region, Size: size}
+	if service == "ec2" {
+		platform, _ := res.StringProperty(resource.PropPlatform)
+		key.OS = normalizeOS(platform)
+	}
+	targetKey := key
+	targetKey.Size = target
+
+	catalog := c.catalogs.Get(res.Provider)
+	price, priced := catalog.Monthly(key)
+	targetPrice, targetPriced := catalog.Monthly(targetKey)
+
+	reason := fmt.Sprintf("%s is a previous generation type, %s is the current generation equivalent", size, target)
+	saving := 0.0
+	switch {
+	case !priced || !targetPriced:
+		reason += " (no catalog price to compare, refresh the pricing catalogs)"
+	case targetPrice >= price:
+		return nil
+	default:
+		saving = c.component(res, component) * (1 - targetPrice/price)
+		reason += fmt.Sprintf(" and costs %.0f%% less", (1-targetPrice/price)*100)
+	}
+
+	return c.recommend(res, RulePreviousGeneration, CategoryModernize, reason,
+		fmt.Sprintf("change the type to %s", target), saving)
+}
+
+// idleLoadBalancer flags load balancers without registered targets
+func (c *recommendationContext) idleLoadBalancer(res *resource.Resource) *Recommendation {
+	targets, ok := res.IntProperty(resource.PropTargetCount)
+	if !ok || targets > 0 {
+		return nil
+	}
+
+	return c.recommend(res, RuleIdleLoadBalancer, CategoryIdle,
+		"load balancer has no registered targets",
+		"delete the load balancer if it is no longer needed",
+		c.total(res))
+}
+
+// oversizedFunction flags functions with very large memory settings. The saving assumes the
+// same duration with less memory, so it is an upper bound for CPU-bound functions.
+func (c *recommendationContext) oversizedFunction(res *resource.Resource) *Recommendation {
+	memoryMB, ok := res.FloatProperty(resource.PropMemorySize)
+	if !ok || memoryMB < c.settings.FunctionMemoryMB || memoryMB <= c.settings.FunctionTargetMemoryMB {
+		return nil
+	}
+
+	target := c.settings.FunctionTargetMemoryMB
+	return c.recommend(res, RuleOversizedFunction, CategoryRightsizing,
+		fmt.Sprintf("function has %.0f MB of memory, the duration cost scales with it", memoryMB),
+		fmt.Sprintf("measure the memory used and reduce it, e.g. to %.0f MB", target),
+		c.component(res, "duration")*(1-target/memoryMB))
+}
+
+// unusedSecret flags secrets not accessed for the configured number of days, or never
+// accessed since they were created that long ago
+func (c *recommendationContext) unusedSecret(res *resource.Resource) *Recommendation {
+	threshold := c.now.Add(-time.Duration(c.settings.SecretUnusedDays * float64(24*time.Hour)))
+
+	var reason string
+	if accessed, ok := res.TimeProperty(resource.PropLastAccessedDate); ok {
+		if accessed.After(threshold) {
+			return nil
+		}
+		reason = fmt.Sprintf("secret was last accessed %d days ago", int(c.now.Sub(accessed).Hours()/24))
+	} else {
+		if res.CreatedAt == nil || res.CreatedAt.After(threshold) {
+			return nil
+		}
+		reason = fmt.Sprintf("secret was never accessed since it was created %d days ago", int(c.now.Sub(*res.CreatedAt).Hours()/24))
+	}
+
+	return c.recommend(res, RuleUnusedSecret, CategoryIdle, reason,
+		"delete the secret if no application uses it", c.total(res))
+}
+
+// unusedSecurityGroup flags security groups that no resource of the inventory is attached to
+// or references. Default groups can't be deleted and are skipped.
+func (c *recommendationContext) unusedSecurityGroup(res *resource.Resource) *Recommendation {
+	if name, _ := res.StringProperty(resource.PropGroupName); name == "default" {
+		return nil
+	}
+	for _, edge := range c.graph.GetIncoming(res.ID) {
+		if edge.Type != resource.RelationContains {
+			return nil
+		}
+	}
+
+	return c.recommend(res, RuleUnusedSecurityGroup, CategoryCleanup,
+		"security group is not attached to any collected resource",
+		"check it isn't used by resources outside the inventory and delete it", 0)
+}
+
+// archivedProject flags archived repositories that still hold storage
+func (c *recommendationContext) archivedProject(res *resource.Resource) *Recommendation {
+	if archived, _ := res.BoolProperty(resource.PropArchived); !archived {
+		return nil
+	}
+	sizeBytes, ok := res.FloatProperty(resource.PropSizeBytes)
+	if !ok {
+		return nil
+	}
+	sizeGB := sizeBytes / bytesPerGB
+	if sizeGB < c.settings.ArchivedProjectMinGB {
+		return nil
+	}
+
+	return c.recommend(res, RuleArchivedProject, CategoryCleanup,
+		fmt.Sprintf("project is archived but stores %.1f GB", sizeGB),
+		"export the project to cold storage and delete it, or prune its artifacts and packages",
+		sizeGB*c.settings.ProjectStoragePricePerGB*c.usdRate)
+}
+
+// recommend creates a recommendation for a resource
+func (c *recommendationContext) recommend(res *resource.Resource, rule, category, reason, action string, saving float64) *Recommendation {
+	return &Recommendation{
+		Rule:          rule,
+		Category:      category,
+		ResourceID:    res.ID,
+		ResourceType:  res.Type,
+		Name:          res.Name,
+		Provider:      res.Provider,
+		Account:       res.Account,
+		Region:        res.Region,
+		Reason:        reason,
+		Action:        action,
+		MonthlySaving: saving,
+	}
+}
+
+// cost returns the cost estimate of a resource in the report currency, estimating it from the
+// catalogs if the resource has none
+func (c *recommendationContext) cost(res *resource.Resource) *resource.ResourceCost {
+	if res.Cost != nil {
+		return res.Cost
+	}
+
+	estimate, err := c.estimators.EstimateCost(res)
+	if err != nil || estimate == nil {
+		return nil
+	}
+	estimate.MonthlyEstimate *= c.usdRate
+	for component, value := range estimate.Breakdown {
+		estimate.Breakdown[component] = value * c.usdRate
+	}
+	return estimate
+}
+
+// total returns the monthly cost of a resource in the report currency
+func (c *recommendationContext) total(res *resource.Resource) float64 {
+	if cost := c.cost(res); cost != nil {
+		return cost.MonthlyEstimate
+	}
+	return 0
+}
+
+// component returns the monthly cost of a component of a resource in the report currency
+func (c *recommendationContext) component(res *resource.Resource, name string) float64 {
+	if cost := c.cost(res); cost != nil {
+		return cost.Breakdown[name]
+	}
+	return 0
+}
+
+// usdRate returns the units of a currency per USD, from the exchange rates or, without them,
+// from the rates recorded in converted cost estimates
+func usdRate(collection *resource.Collection, currency string, rates *ExchangeRates) (float64, error) {
+	if rates != nil {
+		return rates.Rate(DefaultCurrency, currency)
+	}
+
+	for _, res := range collection.Resources {
+		if res.Cost != nil && res.Cost.Currency == currency && res.Cost.OriginalCurrency == DefaultCurrency && res.Cost.ExchangeRate > 0 {
+			return res.Cost.ExchangeRate, nil
+		}
+	}
+
+	return 0, fmt.Errorf("no exchange rate to convert savings from %s to %s (use exchange rates)", DefaultCurrency, currency)
+}
+
+// hasSecurityGroupAttachments reports whether any resource of a collection is attached to a
+// security group (exports of older versions don't record attachments)
+func hasSecurityGroupAttachments(collection *resource.Collection) bool {
+	for _, res := range collection.Resources {
+		for _, rel := range res.Relationships {
+			if rel.Type == resource.RelationAttachedTo && rel.TargetType == resource.TypeAWSSecurityGroup {
+				return true
+			}
+		}
+	}
+	return false
+}
+
+// isRecommendationRule reports whether a rule name is known
+func isRecommendationRule(rule string) bool {
+	for _, known := range RecommendationRules {
+		if known == rule {
+			return true
+		}
+	}
+	return false
+}
+
+// WriteCSV writes the recommendations as CSV, one row per recommendation
+func (r *RecommendationReport) WriteCSV(writer io.Writer) error {
+	w := csv.NewWriter(writer)
+
+	if err := w.Write([]string{"rule", "category", "resource_id", "resource_type", "name", "provider", "account", "region", "monthly_saving", "currency", "reason", "action"}); err != nil {
+		return fmt.Errorf("failed to write CSV: %w", err)
+	}
+	for _, recommendation := range r.Recommendations {
+		if err := w.Write([]string{
+			recommendation.Rule,
+			recommendation.Category,
+			recommendation.ResourceID,
+			string(recommendation.ResourceType),
+			recommendation.Name,
+			recommendation.Provider,
+			recommendation.Account,
+			recommendation.Region,
+			strconv.FormatFloat(recommendation.MonthlySaving, 'f', 2, 64),
+			r.Currency,
+			recommendation.Reason,
+			recommendation.Action,
+		}); err != nil {
+			return fmt.Errorf("failed to write CSV: %w", err)
+		}
+	}
+
+	w.Flush()
+	return w.Error()
+}
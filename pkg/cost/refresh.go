@@ -0,0 +1,227 @@
+package cost
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"sort"
+	"time"
+)
+
+// Price list endpoints used to refresh catalogs
+const (
+	awsPriceListURL    = "https://pricing.us-east-1.amazonaws.com/offers/v1.0/aws/%s/current/%s/index.csv"
+	azureRetailURL     = "https://prices.azure.com/api/retail/prices"
+	gcpBillingSKUsURL  = "https://cloudbilling.googleapis.com/v1/services/%s/skus"
+	gcpComputeService  = "6F81-5844-456A"
+	gcpStorageService  = "95FF-2EF5-5EA1"
+	refreshHTTPTimeout = 10 * time.Minute
+)
+
+// awsOfferCodes lists the AWS price list offers used by the AWS estimator
+var awsOfferCodes = []string{
+	"AmazonEC2", "AmazonElastiCache", "AmazonMemoryDB", "AmazonRDS", "AmazonEKS",
+	"AWSLambda", "AWSELB", "AmazonS3", "AmazonECR",
+}
+
+// RefreshOptions configures the download of pricing catalogs
+type RefreshOptions struct {
+	Regions   []string     // regions to download (empty = the regions of the bundled catalog)
+	GCPAPIKey string       // API key for the GCP Cloud Billing Catalog API
+	Client    *http.Client // HTTP client (default: client with a 10 minute timeout)
+}
+
+// RefreshCatalog downloads the current prices of a provider (aws, azure or gcp) from its
+// public price list API and normalizes them into a catalog
+func RefreshCatalog(ctx context.Context, provider string, opts RefreshOptions) (*Catalog, error) {
+	if opts.Client == nil {
+		opts.Client = &http.Client{Timeout: refreshHTTPTimeout}
+	}
+	if len(opts.Regions) == 0 {
+		opts.Regions = catalogRegions(DefaultCatalogs().Get(provider))
+	}
+
+	switch provider {
+	case "aws":
+		return refreshAWS(ctx, opts)
+	case "azure":
+		return refreshAzure(ctx, opts)
+	case "gcp":
+		return refreshGCP(ctx, opts)
+	}
+
+	return nil, fmt.Errorf("unsupported pricing provider '%s' (supported: aws, azure, gcp)", provider)
+}
+
+// catalogRegions returns the regions priced by a catalog
+func catalogRegions(catalog *Catalog) []string {
+	if catalog == nil {
+		return nil
+	}
+
+	seen := make(map[string]bool)
+	var regions []string
+	for _, price := range catalog.Prices {
+		if price.Region != "" && !seen[price.Region] {
+			seen[price.Region] = true
+			regions = append(regions, price.Region)
+		}
+	}
+	sort.Strings(regions)
+
+	return regions
+}
+
+// refreshAWS streams the per-region CSV offer files of the AWS Price List bulk API
+func refreshAWS(ctx context.Context, opts RefreshOptions) (*Catalog, error) {
+	catalog := newAWSCatalog("")
+
+	for _, region := range opts.Regions {
+		for _, code := range awsOfferCodes {
+			body, err := httpGet(ctx, opts.Client, fmt.Sprintf(awsPriceListURL, code, region))
+			if err != nil {
+				return nil, fmt.Errorf("failed to download %s prices for %s: %w", code, region, err)
+			}
+
+			offer, err := parseAWSOfferCSV(body)
+			//nolint:errcheck // Response body, nothing to do on close error
+			body.Close()
+			if err != nil {
+				return nil, fmt.Errorf("failed to parse %s prices for %s: %w", code, region, err)
+			}
+
+			if catalog.Version == "" {
+				catalog.Version = offer.Version
+			}
+			for _, price := range offer.Prices {
+				catalog.Add(price)
+			}
+		}
+	}
+
+	if catalog.Version == "" {
+		catalog.Version = "aws-" + time.Now().UTC().Format("2006-01-02")
+	}
+
+	return catalog, nil
+}
+
+// refreshAzure pages through the Azure retail prices API for virtual machines and storage
+func refreshAzure(ctx context.Context, opts RefreshOptions) (*Catalog, error) {
+	catalog := newAzureCatalog("azure-retail-" + time.Now().UTC().Format("2006-01-02"))
+
+	for _, region := range opts.Regions {
+		for _, service := range []string{"Virtual Machines", "Storage"} {
+			query := url.Values{}
+			query.Set("$filter", fmt.Sprintf("serviceName eq '%s' and armRegionName eq '%s' and priceType eq 'Consumption'", service, region))
+			next := azureRetailURL + "?" + query.Encode()
+
+			for next != "" {
+				var page azureRetailPrices
+				if err := httpGetJSON(ctx, opts.Client, next, &page); err != nil {
+					return nil, fmt.Errorf("failed to download Azure %s prices for %s: %w", service, region, err)
+				}
+				addAzureItems(catalog, page.Items)
+				next = page.NextPageLink
+			}
+		}
+	}
+
+	return catalog, nil
+}
+
+// refreshGCP pages through the Cloud Billing Catalog API for Compute Engine and Cloud Storage
+func refreshGCP(ctx context.Context, opts RefreshOptions) (*Catalog, error) {
+	if opts.GCPAPIKey == "" {
+		return nil, fmt.Errorf("an API key is required to download GCP prices")
+	}
+
+	catalog := newGCPCatalog("gcp-skus-" + time.Now().UTC().Format("2006-01-02"))
+
+	regions := make(map[string]bool, len(opts.Regions))
+	for _, region := range opts.Regions {
+		regions[region] = true
+	}
+
+	for _, service := range []string{gcpComputeService, gcpStorageService} {
+		pageToken := ""
+		for {
+			query := url.Values{}
+			query.Set("key", opts.GCPAPIKey)
+			query.Set("pageSize", "5000")
+			if pageToken != "" {
+				query.Set("pageToken", pageToken)
+			}
+
+			var page gcpSKUList
+			if err := httpGetJSON(ctx, opts.Client, fmt.Sprintf(gcpBillingSKUsURL, service)+"?"+query.Encode(), &page); err != nil {
+				return nil, fmt.Errorf("failed to download GCP prices for service %s: %w", service, err)
+			}
+
+			// Keep only the requested regions
+			if len(regions) > 0 {
+				for i := range page.SKUs {
+					var kept []string
+					for _, region := range page.SKUs[i].ServiceRegions {
+						if regions[region] {
+							kept = append(kept, region)
+						}
+					}
+					page.SKUs[i].ServiceRegions = kept
+				}
+			}
+			addGCPSKUs(catalog, page.SKUs)
+
+			if page.NextPageToken == "" {
+				break
+			}
+			pageToken = page.NextPageToken
+		}
+	}
+
+	return catalog, nil
+}
+
+// httpGet performs a GET request and returns the response body of a successful response
+func httpGet(ctx context.Context, client *http.Client, rawURL string) (io.ReadCloser, error) {
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, rawURL, nil)
+	if err != nil {
+		return nil, err
+	}
+
+	resp, err := client.Do(req)
+	if err != nil {
+		// Drop the URL from the error, it may contain an API key
+		var urlErr *url.Error
+		if errors.As(err, &urlErr) {
+			return nil, urlErr.Err
+		}
+		return nil, err
+	}
+
+	if resp.StatusCode != http.StatusOK {
+		//nolint:errcheck // Error response, nothing to do on close error
+		resp.Body.Close()
+		return nil, fmt.Errorf("unexpected status %s", resp.Status)
+	}
+
+	return resp.Body, nil
+}
+
+// httpGetJSON performs a GET request and decodes the JSON response into v
+func httpGetJSON(ctx context.Context, client *http.Client, rawURL string, v interface{}) error {
+	body, err := httpGet(ctx, client, rawURL)
+	if err != nil {
+		return err
+	}
+	defer func() {
+		//nolint:errcheck // Response body, nothing to do on close error
+		body.Close()
+	}()
+
+	return json.NewDecoder(body).Decode(v)
+}
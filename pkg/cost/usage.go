@@ -0,0 +1,56 @@
+package cost
+
+// UsageAssumptions are the monthly usage figures assumed for usage-priced components, since
+// inventories don't include usage metrics. Zero values are replaced by the defaults.
+type UsageAssumptions struct {
+	LambdaInvocations     float64 `yaml:"lambda_invocations"`      // invocations per function
+	LambdaDurationRatio   float64 `yaml:"lambda_duration_ratio"`   // average duration as a fraction of the timeout
+	DynamoDBReadRequests  float64 `yaml:"dynamodb_read_requests"`  // read request units per on-demand table
+	DynamoDBWriteRequests float64 `yaml:"dynamodb_write_requests"` // write request units per on-demand table
+	LoadBalancerLCUs      float64 `yaml:"load_balancer_lcus"`      // average LCUs consumed per load balancer
+	NATDataProcessedGB    float64 `yaml:"nat_data_processed_gb"`   // GB processed per NAT gateway
+	RegistryStorageGB     float64 `yaml:"registry_storage_gb"`     // GB stored per container repository
+	BucketStorageGB       float64 `yaml:"bucket_storage_gb"`       // GB stored per bucket or storage account of unknown size
+	DatabaseStorageGB     float64 `yaml:"database_storage_gb"`     // GB stored per Aurora cluster
+}
+
+// DefaultUsageAssumptions returns the default usage assumptions
+func DefaultUsageAssumptions() UsageAssumptions {
+	return UsageAssumptions{
+		LambdaInvocations:     1_000_000,
+		LambdaDurationRatio:   1, // every invocation runs until the timeout (upper bound)
+		DynamoDBReadRequests:  10_000_000,
+		DynamoDBWriteRequests: 1_000_000,
+		LoadBalancerLCUs:      1,
+		NATDataProcessedGB:    100,
+		RegistryStorageGB:     10,
+		BucketStorageGB:       100,
+		DatabaseStorageGB:     100,
+	}
+}
+
+// WithDefaults returns the assumptions with zero values replaced by the defaults
+func (u UsageAssumptions) WithDefaults() UsageAssumptions {
+	defaults := DefaultUsageAssumptions()
+
+	for _, field := range []struct {
+		value        *float64
+		defaultValue float64
+	}{
+		{&u.LambdaInvocations, defaults.LambdaInvocations},
+		{&u.LambdaDurationRatio, defaults.LambdaDurationRatio},
+		{&u.DynamoDBReadRequests, defaults.DynamoDBReadRequests},
+		{&u.DynamoDBWriteRequests, defaults.DynamoDBWriteRequests},
+		{&u.LoadBalancerLCUs, defaults.LoadBalancerLCUs},
+		{&u.NATDataProcessedGB, defaults.NATDataProcessedGB},
+		{&u.RegistryStorageGB, defaults.RegistryStorageGB},
+		{&u.BucketStorageGB, defaults.BucketStorageGB},
+		{&u.DatabaseStorageGB, defaults.DatabaseStorageGB},
+	} {
+		if *field.value == 0 {
+			*field.value = field.defaultValue
+		}
+	}
+
+	return u
+}
@@ -75,9 +75,17 @@ func (e *DOTExporter) writeEdges(writer io.Writer, res *resource.Resource) error
 	for _, rel := range res.Relationships {
 		targetID := e.sanitizeID(rel.TargetID)
 		label := string(rel.Type)
+		if destinations := resource.ToStrings(rel.Properties["destinations"]); len(destinations) > 0 {
+			label += "\\n" + strings.Join(destinations, "\\n")
+		}
+
+		attributes := fmt.Sprintf("label=\"%s\"", label)
+		if style, ok := edgeStyles[rel.Type]; ok {
+			attributes += ", " + style
+		}
 
-		if _, err := fmt.Fprintf(writer, "  %s -> %s [label=\"%s\"];\n",
-			nodeID, targetID, label); err != nil {
+		if _, err := fmt.Fprintf(writer, "  %s -> %s [%s];\n",
+			nodeID, targetID, attributes); err != nil {
 			return err
 		}
 	}
@@ -85,6 +93,13 @@ func (e *DOTExporter) writeEdges(writer io.Writer, res *resource.Resource) error
 	return nil
 }
 
+// edgeStyles are the DOT attributes added to the edges of a relation type. Routes are drawn
+// dashed and do not constrain the ranking, so that the layout follows the network hierarchy
+// (VPC, subnets, resources) rather than the routing between gateways.
+var edgeStyles = map[resource.RelationType]string{
+	resource.RelationRoutesTo: "style=dashed, color=\"#1E90FF\", fontcolor=\"#1E90FF\", constraint=false",
+}
+
 // sanitizeID makes an ID safe for DOT format
 func (e *DOTExporter) sanitizeID(id string) string {
 	// Replace characters that are not valid in DOT identifiers
@@ -111,11 +126,23 @@ func (e *DOTExporter) getColorForType(resourceType resource.ResourceType) string
 	colors := map[resource.ResourceType]string{
 		resource.TypeAWSIAMUser:       "#FFE4B5",
 		resource.TypeAWSIAMRole:       "#FFD700",
+		resource.TypeAWSIAMGroup:      "#F0E68C",
+		resource.TypeAWSIAMPolicy:     "#FFFACD",
 		resource.TypeAWSAccount:       "#87CEEB",
 		resource.TypeAWSVPC:           "#98FB98",
 		resource.TypeAWSSubnet:        "#90EE90",
 		resource.TypeAWSSecurityGroup: "#FFA07A",
 		resource.TypeAWSECR:           "#DDA0DD",
+
+		resource.TypeAWSRouteTable:               "#B0E0E6",
+		resource.TypeAWSInternetGateway:          "#1E90FF",
+		resource.TypeAWSNATGateway:               "#87CEFA",
+		resource.TypeAWSTransitGateway:           "#6495ED",
+		resource.TypeAWSTransitGatewayAttachment: "#ADD8E6",
+		resource.TypeAWSVPCPeeringConnection:     "#AFEEEE",
+		resource.TypeAWSVPCEndpoint:              "#E0FFFF",
+		resource.TypeAWSElasticIP:                "#F5DEB3",
+		resource.TypeAWSNetworkInterface:         "#D3D3D3",
 	}
 
 	if color, ok := colors[resourceType]; ok {
@@ -0,0 +1,223 @@
+package exporter
+
+import (
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/comfortablynumb/pmp-cloud-inspector/pkg/filter"
+	"github.com/comfortablynumb/pmp-cloud-inspector/pkg/resource"
+)
+
+// Placeholders supported in target paths. {provider}, {account} and {region} split the
+// collection into one file per distinct value.
+const (
+	PlaceholderDate      = "{date}"      // 2006-01-02
+	PlaceholderTime      = "{time}"      // 150405
+	PlaceholderTimestamp = "{timestamp}" // Unix seconds
+	PlaceholderFormat    = "{format}"
+	PlaceholderProvider  = "{provider}"
+	PlaceholderAccount   = "{account}"
+	PlaceholderRegion    = "{region}"
+)
+
+// splitPlaceholders are the placeholders that split a collection into several files
+var splitPlaceholders = []string{PlaceholderProvider, PlaceholderAccount, PlaceholderRegion}
+
+// Target is a single export destination
+type Target struct {
+	Format  string
+	Path    string // Path template; empty or "-" writes to stdout
+	Options ExportOptions
+	Filters []filter.Filter // Optional filters applied to the collection for this target only
+}
+
+// IsStdout reports whether the target writes to stdout
+func (t *Target) IsStdout() bool {
+	return t.Path == "" || t.Path == "-"
+}
+
+// Validate checks that the target format exists and the path template is usable
+func (t *Target) Validate() error {
+	if _, err := Get(t.Format); err != nil {
+		return err
+	}
+
+	if t.IsStdout() {
+		for _, placeholder := range splitPlaceholders {
+			if strings.Contains(t.Path, placeholder) {
+				return fmt.Errorf("placeholder %s requires an output path", placeholder)
+			}
+		}
+	}
+
+	return nil
+}
+
+// Written describes a file written by WriteTargets
+type Written struct {
+	Path      string
+	Format    string
+	Resources int
+	Empty     bool // A split path with no resources: no file was written and Path is the template
+}
+
+// WriteTargets exports the collection to every target. Files are written atomically:
+// each one is written to a temporary file in the same directory and renamed into place.
+func WriteTargets(collection *resource.Collection, targets []Target, now time.Time) ([]Written, error) {
+	var written []Written
+
+	for i := range targets {
+		target := &targets[i]
+
+		if err := target.Validate(); err != nil {
+			return written, fmt.Errorf("invalid export target %d: %w", i+1, err)
+		}
+
+		exp, err := Get(target.Format)
+		if err != nil {
+			return written, err
+		}
+
+		source := collection
+		if len(target.Filters) > 0 {
+			source = filter.ApplyFilters(collection, target.Filters...)
+		}
+
+		if target.IsStdout() {
+			if err := exp.Export(source, os.Stdout, target.Options); err != nil {
+				return written, fmt.Errorf("failed to export %s to stdout: %w", target.Format, err)
+			}
+			written = append(written, Written{Path: "-", Format: target.Format, Resources: len(source.Resources)})
+			continue
+		}
+
+		path := expandPath(target.Path, target.Format, now)
+		parts := splitByPath(source, path)
+		if len(parts) == 0 {
+			// Files of previous runs are left in place, callers must report it
+			written = append(written, Written{Path: path, Format: target.Format, Empty: true})
+			continue
+		}
+
+		for path, part := range parts {
+			err := WriteFileAtomic(path, func(w io.Writer) error {
+				return exp.Export(part, w, target.Options)
+			})
+			if err != nil {
+				return written, fmt.Errorf("failed to export %s to %s: %w", target.Format, path, err)
+			}
+			written = append(written, Written{Path: path, Format: target.Format, Resources: len(part.Resources)})
+		}
+	}
+
+	sort.SliceStable(written, func(i, j int) bool {
+		return written[i].Path < written[j].Path
+	})
+
+	return written, nil
+}
+
+// expandPath replaces the time and format placeholders of a path template
+func expandPath(path, format string, now time.Time) string {
+	return strings.NewReplacer(
+		PlaceholderDate, now.Format("2006-01-02"),
+		PlaceholderTime, now.Format("150405"),
+		PlaceholderTimestamp, fmt.Sprintf("%d", now.Unix()),
+		PlaceholderFormat, format,
+	).Replace(path)
+}
+
+// splitByPath groups the resources of a collection by the path obtained by replacing the
+// {provider}, {account} and {region} placeholders. Paths without those placeholders
+// receive the whole collection; paths with them get no group for an empty collection.
+func splitByPath(collection *resource.Collection, path string) map[string]*resource.Collection {
+	split := false
+	for _, placeholder := range splitPlaceholders {
+		if strings.Contains(path, placeholder) {
+			split = true
+			break
+		}
+	}
+
+	if !split {
+		return map[string]*resource.Collection{path: collection}
+	}
+
+	parts := make(map[string]*resource.Collection)
+	for _, res := range collection.Resources {
+		resPath := strings.NewReplacer(
+			PlaceholderProvider, pathSegment(res.Provider),
+			PlaceholderAccount, pathSegment(res.Account),
+			PlaceholderRegion, pathSegment(res.Region),
+		).Replace(path)
+
+		part, ok := parts[resPath]
+		if !ok {
+			part = resource.NewCollection()
+			part.InheritMetadata(collection)
+			parts[resPath] = part
+		}
+		part.Add(res)
+	}
+
+	return parts
+}
+
+// pathSegment makes a value safe to use in a file name
+func pathSegment(value string) string {
+	if value == "" {
+		return "global"
+	}
+	return strings.NewReplacer("/", "_", "\\", "_", ":", "_", " ", "_").Replace(value)
+}
+
+// WriteFileAtomic writes a file by writing to a temporary file in the same directory and
+// renaming it into place, so that readers never see a partially written file.
+func WriteFileAtomic(path string, write func(w io.Writer) error) (err error) {
+	dir := filepath.Dir(path)
+	if err := os.MkdirAll(dir, 0o750); err != nil {
+		return fmt.Errorf("failed to create directory: %w", err)
+	}
+
+	tmp, err := os.CreateTemp(dir, "."+filepath.Base(path)+".tmp-*")
+	if err != nil {
+		return fmt.Errorf("failed to create temporary file: %w", err)
+	}
+
+	defer func() {
+		if err != nil {
+			//nolint:errcheck // Best effort cleanup, the original error is more relevant
+			tmp.Close()
+			//nolint:errcheck // Best effort cleanup, the original error is more relevant
+			os.Remove(tmp.Name())
+		}
+	}()
+
+	if err = write(tmp); err != nil {
+		return err
+	}
+
+	if err = tmp.Sync(); err != nil {
+		return fmt.Errorf("failed to sync temporary file: %w", err)
+	}
+
+	// #nosec G302 - exports are meant to be readable by other tools and users
+	if err = tmp.Chmod(0o644); err != nil {
+		return fmt.Errorf("failed to set file permissions: %w", err)
+	}
+
+	if err = tmp.Close(); err != nil {
+		return fmt.Errorf("failed to close temporary file: %w", err)
+	}
+
+	if err = os.Rename(tmp.Name(), path); err != nil {
+		return fmt.Errorf("failed to rename temporary file: %w", err)
+	}
+
+	return nil
+}
@@ -0,0 +1,452 @@
+package exposure
+
+import (
+	"fmt"
+	"slices"
+	"sort"
+	"strings"
+
+	"github.com/comfortablynumb/pmp-cloud-inspector/pkg/resource"
+)
+
+// Internet sources of security group rules
+const (
+	SourceIPv4 = "0.0.0.0/0"
+	SourceIPv6 = "::/0"
+)
+
+// Exposure severities, in increasing order
+const (
+	SeverityLow      = "low"      // attached to an open security group, but not reachable from the internet
+	SeverityMedium   = "medium"   // reachable from the internet on non-sensitive ports
+	SeverityHigh     = "high"     // reachable from the internet on a sensitive port
+	SeverityCritical = "critical" // reachable from the internet on every port
+)
+
+// Severities lists the exposure severities, in increasing order
+var Severities = []string{SeverityLow, SeverityMedium, SeverityHigh, SeverityCritical}
+
+// SensitivePort is the TCP port of an administration or data service that should not be
+// reachable from the internet
+type SensitivePort struct {
+	Port    int    `json:"port"`
+	Service string `json:"service"`
+}
+
+// SensitivePorts lists the ports reported as sensitive
+var SensitivePorts = []SensitivePort{
+	{22, "SSH"},
+	{3389, "RDP"},
+	{3306, "MySQL"},
+	{5432, "PostgreSQL"},
+	{1433, "SQL Server"},
+	{1521, "Oracle"},
+	{5439, "Redshift"},
+	{6379, "Redis"},
+	{11211, "Memcached"},
+	{27017, "MongoDB"},
+	{9200, "Elasticsearch"},
+	{9042, "Cassandra"},
+}
+
+// OpenRule is an ingress rule of a security group allowing traffic from the internet
+type OpenRule struct {
+	Ports          string   `json:"ports"`                     // e.g. tcp/22, tcp/8000-8080, all
+	Source         string   `json:"source"`                    // 0.0.0.0/0 or ::/0
+	AllTraffic     bool     `json:"all_traffic"`               // the rule allows every protocol and port
+	SensitivePorts []string `json:"sensitive_ports,omitempty"` // sensitive ports in the range, e.g. 22/SSH
+}
+
+// Group is a security group with ingress rules open to the internet
+type Group struct {
+	ID        string     `json:"id"`
+	Name      string     `json:"name"`
+	Account   string     `json:"account,omitempty"`
+	Region    string     `json:"region,omitempty"`
+	OpenRules []OpenRule `json:"open_rules"`
+	Sensitive bool       `json:"sensitive"` // a rule opens a sensitive port or all traffic
+	Attached  []string   `json:"attached"`  // IDs of the collected resources using the group
+	Public    []string   `json:"public"`    // IDs of the attached resources reachable from the internet
+}
+
+// Resource is a resource attached to security groups open to the internet, or reachable through
+// a public endpoint
+type Resource struct {
+	ID             string                `json:"id"`
+	Type           resource.ResourceType `json:"type"`
+	Name           string                `json:"name"`
+	Account        string                `json:"account,omitempty"`
+	Region         string                `json:"region,omitempty"`
+	Public         bool                  `json:"public"` // reachable from the internet
+	Reason         string                `json:"reason"` // why the resource is or is not reachable
+	Groups         []string              `json:"groups,omitempty"`
+	OpenPorts      []string              `json:"open_ports"` // e.g. tcp/22, all
+	SensitivePorts []string              `json:"sensitive_ports,omitempty"`
+	Severity       string                `json:"severity"`
+}
+
+// Reference is an ingress rule of a security group allowing traffic from another security group.
+// Resources of the source group that are reachable from the internet can reach the resources
+// of the group.
+type Reference struct {
+	Group         string `json:"group"`
+	GroupName     string `json:"group_name"`
+	Source        string `json:"source"`
+	SourceName    string `json:"source_name,omitempty"` // empty when the source group was not collected
+	Ports         string `json:"ports"`
+	SourceExposed bool   `json:"source_exposed"` // the source group is attached to resources reachable from the internet
+}
+
+// Report is the internet exposure of the resources of a collection
+type Report struct {
+	OpenGroups       int            `json:"open_groups"`       // security groups with rules open to the internet
+	SensitiveGroups  int            `json:"sensitive_groups"`  // open security groups exposing sensitive ports or all traffic
+	ExposedResources int            `json:"exposed_resources"` // resources reachable from the internet
+	BySeverity       map[string]int `json:"by_severity"`       // number of resources of each severity
+	Groups           []Group        `json:"groups"`
+	Resources        []Resource     `json:"resources"`  // most severe first
+	References       []Reference    `json:"references"` // security group to security group ingress rules
+}
+
+// SeverityRank orders exposure severities: 0 for unknown severities, then low to critical
+func SeverityRank(severity string) int {
+	for i, s := range Severities {
+		if strings.EqualFold(s, severity) {
+			return i + 1
+		}
+	}
+	return 0
+}
+
+// Analyze computes which security groups allow traffic from the internet, which resources use
+// them and are reachable from the internet, and which security groups allow traffic from
+// other security groups
+func Analyze(collection *resource.Collection) *Report {
+	report := &Report{
+		BySeverity: make(map[string]int),
+		Groups:     make([]Group, 0),
+		Resources:  make([]Resource, 0),
+		References: make([]Reference, 0),
+	}
+
+	groups := make(map[string]*Group)
+	names := make(map[string]string) // names of the collected security groups
+	for _, res := range collection.Resources {
+		if res.Type != resource.TypeAWSSecurityGroup {
+			continue
+		}
+		names[res.ID] = res.Name
+		if rules := openRules(res); len(rules) > 0 {
+			group := &Group{
+				ID:        res.ID,
+				Name:      res.Name,
+				Account:   res.Account,
+				Region:    res.Region,
+				OpenRules: rules,
+				Attached:  make([]string, 0),
+				Public:    make([]string, 0),
+			}
+			for _, rule := range rules {
+				if rule.AllTraffic || len(rule.SensitivePorts) > 0 {
+					group.Sensitive = true
+				}
+			}
+			groups[res.ID] = group
+		}
+	}
+
+	for _, res := range collection.Resources {
+		var attached []*Group
+		for _, rel := range res.Relationships {
+			if rel.Type != resource.RelationAttachedTo || rel.TargetType != resource.TypeAWSSecurityGroup {
+				continue
+			}
+			if group, ok := groups[rel.TargetID]; ok {
+				attached = append(attached, group)
+			}
+		}
+
+		reach := reachabilityOf(res)
+		if len(attached) == 0 && reach.endpoint == "" {
+			continue
+		}
+
+		exposed := analyzeResource(res, attached, reach)
+		for _, group := range attached {
+			group.Attached = append(group.Attached, res.ID)
+			if exposed.Public && reach.public {
+				group.Public = append(group.Public, res.ID)
+			}
+		}
+
+		if exposed.Public {
+			report.ExposedResources++
+		}
+		report.BySeverity[exposed.Severity]++
+		report.Resources = append(report.Resources, exposed)
+	}
+
+	for _, res := range collection.Resources {
+		if res.Type != resource.TypeAWSSecurityGroup {
+			continue
+		}
+		for _, rel := range res.Relationships {
+			if rel.Type != resource.RelationReferences || rel.TargetType != resource.TypeAWSSecurityGroup {
+				continue
+			}
+			if direction, _ := rel.Properties["direction"].(string); direction != "ingress" {
+				continue
+			}
+
+			reference := Reference{
+				Group:      res.ID,
+				GroupName:  res.Name,
+				Source:     rel.TargetID,
+				SourceName: names[rel.TargetID],
+				Ports:      portRange(rel.Properties),
+			}
+			if group, ok := groups[rel.TargetID]; ok && len(group.Public) > 0 {
+				reference.SourceExposed = true
+			}
+			report.References = append(report.References, reference)
+		}
+	}
+
+	for _, group := range groups {
+		report.OpenGroups++
+		if group.Sensitive {
+			report.SensitiveGroups++
+		}
+		report.Groups = append(report.Groups, *group)
+	}
+
+	sort.Slice(report.Groups, func(i, j int) bool {
+		a, b := report.Groups[i], report.Groups[j]
+		if len(a.Public) != len(b.Public) {
+			return len(a.Public) > len(b.Public)
+		}
+		if a.Sensitive != b.Sensitive {
+			return a.Sensitive
+		}
+		return a.ID < b.ID
+	})
+	sort.Slice(report.Resources, func(i, j int) bool {
+		a, b := report.Resources[i], report.Resources[j]
+		if a.Severity != b.Severity {
+			return SeverityRank(a.Severity) > SeverityRank(b.Severity)
+		}
+		if a.Type != b.Type {
+			return a.Type < b.Type
+		}
+		return a.ID < b.ID
+	})
+	sort.Slice(report.References, func(i, j int) bool {
+		a, b := report.References[i], report.References[j]
+		if a.SourceExposed != b.SourceExposed {
+			return a.SourceExposed
+		}
+		if a.Group != b.Group {
+			return a.Group < b.Group
+		}
+		return a.Source < b.Source
+	})
+
+	return report
+}
+
+// reachability describes how a resource can be reached from the internet
+type reachability struct {
+	public   bool   // the ports opened by its security groups are reachable from the internet
+	reason   string // why the resource is or is not reachable
+	endpoint string // port of a public endpoint not filtered by its security groups, e.g. tcp/443
+}
+
+// reachabilityOf determines whether a resource can be reached from the internet: instances
+// with a public IP, internet-facing load balancers, publicly accessible RDS instances and EKS
+// clusters with a public API endpoint
+func reachabilityOf(res *resource.Resource) reachability {
+	switch res.Type {
+	case resource.TypeAWSEC2Instance:
+		publicIP, _ := res.StringProperty("public_ip")
+		state, _ := res.StringProperty(resource.PropState)
+		switch {
+		case publicIP == "":
+			return reachability{reason: "no public IP"}
+		case state != "" && state != "running":
+			return reachability{reason: fmt.Sprintf("instance is %s", state)}
+		}
+		return reachability{public: true, reason: fmt.Sprintf("public IP %s", publicIP)}
+
+	case resource.TypeAWSALB, resource.TypeAWSNLB, resource.TypeAWSELB:
+		if scheme, _ := res.StringProperty("scheme"); scheme == "internet-facing" {
+			return reachability{public: true, reason: "internet-facing load balancer"}
+		}
+		return reachability{reason: "internal load balancer"}
+
+	case resource.TypeAWSEKSCluster:
+		// The public endpoint of the API server is filtered by its public access CIDRs, the
+		// security groups only apply to the control plane interfaces in the VPC
+		if public, _ := res.BoolProperty("endpoint_public_access"); !public {
+			return reachability{reason: "private API endpoint"}
+		}
+		cidrs := res.StringsProperty("public_access_cidrs")
+		if len(cidrs) == 0 || slices.Contains(cidrs, SourceIPv4) {
+			return reachability{reason: "public API endpoint open to " + SourceIPv4, endpoint: "tcp/443"}
+		}
+		return reachability{reason: "public API endpoint restricted to " + strings.Join(cidrs, ", ")}
+
+	case resource.TypeAWSRDSInstance:
+		if public, _ := res.BoolProperty("publicly_accessible"); public {
+			return reachability{public: true, reason: "publicly accessible database"}
+		}
+		return reachability{reason: "database not publicly accessible"}
+
+	case resource.TypeAWSLambda:
+		return reachability{reason: "functions do not accept inbound connections"}
+	}
+
+	return reachability{reason: "no public endpoint"}
+}
+
+// analyzeResource computes the exposure of a resource attached to open security groups
+func analyzeResource(res *resource.Resource, groups []*Group, reach reachability) Resource {
+	exposed := Resource{
+		ID:        res.ID,
+		Type:      res.Type,
+		Name:      res.Name,
+		Account:   res.Account,
+		Region:    res.Region,
+		Reason:    reach.reason,
+		OpenPorts: make([]string, 0),
+	}
+
+	allTraffic := false
+	seenPorts := make(map[string]bool)
+	seenSensitive := make(map[string]bool)
+	for _, group := range groups {
+		exposed.Groups = append(exposed.Groups, group.ID)
+		for _, rule := range group.OpenRules {
+			allTraffic = allTraffic || rule.AllTraffic
+			if !seenPorts[rule.Ports] {
+				seenPorts[rule.Ports] = true
+				exposed.OpenPorts = append(exposed.OpenPorts, rule.Ports)
+			}
+			for _, port := range rule.SensitivePorts {
+				if !seenSensitive[port] {
+					seenSensitive[port] = true
+					exposed.SensitivePorts = append(exposed.SensitivePorts, port)
+				}
+			}
+		}
+	}
+	if reach.endpoint != "" && !seenPorts[reach.endpoint] {
+		exposed.OpenPorts = append(exposed.OpenPorts, reach.endpoint)
+	}
+
+	switch {
+	case reach.public && allTraffic:
+		exposed.Public, exposed.Severity = true, SeverityCritical
+	case reach.public && len(exposed.SensitivePorts) > 0:
+		exposed.Public, exposed.Severity = true, SeverityHigh
+	case reach.public && len(exposed.OpenPorts) > 0, reach.endpoint != "":
+		exposed.Public, exposed.Severity = true, SeverityMedium
+	default:
+		exposed.Severity = SeverityLow
+	}
+	if !reach.public {
+		// Ports of the security groups are not reachable, only the public endpoint is
+		exposed.SensitivePorts = nil
+	}
+
+	return exposed
+}
+
+// openRules returns the ingress rules of a security group allowing traffic from the internet
+func openRules(sg *resource.Resource) []OpenRule {
+	var rules []OpenRule
+	for _, rule := range sg.MapsProperty("ingress_rules") {
+		for _, source := range internetSources(rule) {
+			protocol := protocolName(rule["ip_protocol"])
+			open := OpenRule{
+				Ports:      portRange(rule),
+				Source:     source,
+				AllTraffic: protocol == "all",
+			}
+			// Rules allowing all traffic expose every port, sensitive ports are only listed
+			// for TCP rules
+			if protocol == "tcp" {
+				from, to := portBounds(rule)
+				for _, port := range SensitivePorts {
+					if port.Port >= from && port.Port <= to {
+						open.SensitivePorts = append(open.SensitivePorts, fmt.Sprintf("%d/%s", port.Port, port.Service))
+					}
+				}
+			}
+			rules = append(rules, open)
+		}
+	}
+	return rules
+}
+
+// internetSources returns the internet ranges (0.0.0.0/0, ::/0) allowed by a rule
+func internetSources(rule map[string]interface{}) []string {
+	var sources []string
+	if slices.Contains(resource.ToStrings(rule["cidr_blocks"]), SourceIPv4) {
+		sources = append(sources, SourceIPv4)
+	}
+	if slices.Contains(resource.ToStrings(rule["ipv6_cidr_blocks"]), SourceIPv6) {
+		sources = append(sources, SourceIPv6)
+	}
+	return sources
+}
+
+// portRange describes the protocol and ports of a rule, e.g. tcp/22, udp/1000-2000 or all
+func portRange(rule map[string]interface{}) string {
+	protocol := protocolName(rule["ip_protocol"])
+	if protocol == "all" {
+		return protocol
+	}
+
+	from, fromOK := resource.ToFloat(rule["from_port"])
+	to, toOK := resource.ToFloat(rule["to_port"])
+	switch {
+	case !fromOK || !toOK || from < 0:
+		return protocol
+	case protocol == "tcp" || protocol == "udp":
+		if from == 0 && to == 65535 {
+			return protocol + "/all"
+		}
+	}
+	if from == to {
+		return fmt.Sprintf("%s/%d", protocol, int(from))
+	}
+	return fmt.Sprintf("%s/%d-%d", protocol, int(from), int(to))
+}
+
+// portBounds returns the port range of a rule, every port when it has none
+func portBounds(rule map[string]interface{}) (int, int) {
+	from, fromOK := resource.ToFloat(rule["from_port"])
+	to, toOK := resource.ToFloat(rule["to_port"])
+	if !fromOK || !toOK || from < 0 {
+		return 0, 65535
+	}
+	return int(from), int(to)
+}
+
+// protocolName returns the name of an IP protocol given by name or number
+func protocolName(value interface{}) string {
+	protocol := strings.ToLower(fmt.Sprint(value))
+	switch protocol {
+	case "-1", "all", "<nil>", "":
+		return "all"
+	case "6":
+		return "tcp"
+	case "17":
+		return "udp"
+	case "1":
+		return "icmp"
+	case "58":
+		return "icmpv6"
+	}
+	return protocol
+}
@@ -0,0 +1,135 @@
+package exposure
+
+import (
+	"reflect"
+	"testing"
+
+	"github.com/comfortablynumb/pmp-cloud-inspector/pkg/resource"
+)
+
+func TestPortRange(t *testing.T) {
+	tests := []struct {
+		name string
+		rule map[string]interface{}
+		want string
+	}{
+		{"all traffic", map[string]interface{}{"ip_protocol": "-1"}, "all"},
+		{"no protocol", map[string]interface{}{}, "all"},
+		{"single port", map[string]interface{}{"ip_protocol": "tcp", "from_port": int32(22), "to_port": int32(22)}, "tcp/22"},
+		{"port range", map[string]interface{}{"ip_protocol": "udp", "from_port": int32(1000), "to_port": int32(2000)}, "udp/1000-2000"},
+		{"every tcp port", map[string]interface{}{"ip_protocol": "tcp", "from_port": int32(0), "to_port": int32(65535)}, "tcp/all"},
+		{"protocol number decoded from JSON", map[string]interface{}{"ip_protocol": "6", "from_port": float64(443), "to_port": float64(443)}, "tcp/443"},
+		{"icmp without ports", map[string]interface{}{"ip_protocol": "icmp", "from_port": int32(-1), "to_port": int32(-1)}, "icmp"},
+		{"missing ports", map[string]interface{}{"ip_protocol": "tcp"}, "tcp"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := portRange(tt.rule); got != tt.want {
+				t.Errorf("portRange(%v) = %q, want %q", tt.rule, got, tt.want)
+			}
+		})
+	}
+}
+
+func TestOpenRules(t *testing.T) {
+	tests := []struct {
+		name  string
+		rules interface{}
+		want  []OpenRule
+	}{
+		{
+			name: "ssh from the internet",
+			rules: []map[string]interface{}{
+				{"ip_protocol": "tcp", "from_port": int32(22), "to_port": int32(22), "cidr_blocks": []string{"10.0.0.0/8", SourceIPv4}},
+			},
+			want: []OpenRule{{Ports: "tcp/22", Source: SourceIPv4, SensitivePorts: []string{"22/SSH"}}},
+		},
+		{
+			name: "all traffic over IPv4 and IPv6",
+			rules: []map[string]interface{}{
+				{"ip_protocol": "-1", "cidr_blocks": []string{SourceIPv4}, "ipv6_cidr_blocks": []string{SourceIPv6}},
+			},
+			want: []OpenRule{
+				{Ports: "all", Source: SourceIPv4, AllTraffic: true},
+				{Ports: "all", Source: SourceIPv6, AllTraffic: true},
+			},
+		},
+		{
+			name: "port range decoded from JSON",
+			rules: []interface{}{
+				map[string]interface{}{"ip_protocol": "tcp", "from_port": float64(3000), "to_port": float64(3400), "ipv6_cidr_blocks": []interface{}{SourceIPv6}},
+			},
+			want: []OpenRule{{Ports: "tcp/3000-3400", Source: SourceIPv6, SensitivePorts: []string{"3389/RDP", "3306/MySQL"}}},
+		},
+		{
+			name: "udp ports are not sensitive",
+			rules: []map[string]interface{}{
+				{"ip_protocol": "udp", "from_port": int32(0), "to_port": int32(65535), "cidr_blocks": []string{SourceIPv4}},
+			},
+			want: []OpenRule{{Ports: "udp/all", Source: SourceIPv4}},
+		},
+		{
+			name: "private ranges only",
+			rules: []map[string]interface{}{
+				{"ip_protocol": "-1", "cidr_blocks": []string{"10.0.0.0/8"}, "ipv6_cidr_blocks": []string{"fd00::/8"}},
+			},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			sg := &resource.Resource{ID: "sg-1", Type: resource.TypeAWSSecurityGroup, Properties: map[string]interface{}{"ingress_rules": tt.rules}}
+			if got := openRules(sg); !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("openRules = %+v, want %+v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestAnalyzeResourceSeverity(t *testing.T) {
+	web := &Group{ID: "sg-web", OpenRules: []OpenRule{{Ports: "tcp/443", Source: SourceIPv4}}}
+	ssh := &Group{ID: "sg-ssh", OpenRules: []OpenRule{{Ports: "tcp/22", Source: SourceIPv4, SensitivePorts: []string{"22/SSH"}}}}
+	all := &Group{ID: "sg-all", OpenRules: []OpenRule{{Ports: "all", Source: SourceIPv4, AllTraffic: true}}}
+
+	public := reachability{public: true, reason: "public IP 203.0.113.10"}
+	private := reachability{reason: "no public IP"}
+	endpoint := reachability{reason: "public API endpoint open to " + SourceIPv4, endpoint: "tcp/443"}
+
+	tests := []struct {
+		name          string
+		groups        []*Group
+		reach         reachability
+		wantSeverity  string
+		wantPublic    bool
+		wantPorts     []string
+		wantSensitive []string
+	}{
+		{"public with all traffic", []*Group{web, all}, public, SeverityCritical, true, []string{"tcp/443", "all"}, nil},
+		{"public with a sensitive port", []*Group{web, ssh}, public, SeverityHigh, true, []string{"tcp/443", "tcp/22"}, []string{"22/SSH"}},
+		{"public with other ports", []*Group{web}, public, SeverityMedium, true, []string{"tcp/443"}, nil},
+		{"public endpoint", []*Group{ssh}, endpoint, SeverityMedium, true, []string{"tcp/22", "tcp/443"}, nil},
+		{"public endpoint without open groups", nil, endpoint, SeverityMedium, true, []string{"tcp/443"}, nil},
+		{"not reachable with all traffic", []*Group{all, ssh}, private, SeverityLow, false, []string{"all", "tcp/22"}, nil},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			res := &resource.Resource{ID: "i-1", Type: resource.TypeAWSEC2Instance}
+			got := analyzeResource(res, tt.groups, tt.reach)
+
+			if got.Severity != tt.wantSeverity || got.Public != tt.wantPublic {
+				t.Errorf("got severity %s (public %v), want %s (public %v)", got.Severity, got.Public, tt.wantSeverity, tt.wantPublic)
+			}
+			if !reflect.DeepEqual(got.OpenPorts, tt.wantPorts) {
+				t.Errorf("got open ports %v, want %v", got.OpenPorts, tt.wantPorts)
+			}
+			if !reflect.DeepEqual(got.SensitivePorts, tt.wantSensitive) {
+				t.Errorf("got sensitive ports %v, want %v", got.SensitivePorts, tt.wantSensitive)
+			}
+			if got.Reason != tt.reach.reason || len(got.Groups) != len(tt.groups) {
+				t.Errorf("got reason %q and groups %v", got.Reason, got.Groups)
+			}
+		})
+	}
+}
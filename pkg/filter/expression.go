@@ -0,0 +1,444 @@
+package filter
+
+import (
+	"fmt"
+	"reflect"
+	"regexp"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/comfortablynumb/pmp-cloud-inspector/pkg/resource"
+)
+
+// Additional operators supported by filter expressions
+const (
+	OpMatches    CompareOperator = "=~"
+	OpNotMatches CompareOperator = "!~"
+	OpIn         CompareOperator = "in"
+	OpNotIn      CompareOperator = "not in"
+	OpTruthy     CompareOperator = "truthy"
+)
+
+// expressionFields lists the root fields that can be referenced in filter expressions
+var expressionFields = []string{
+	"id", "type", "name", "provider", "account", "region", "arn",
+	"created_at", "updated_at", "cost", "tags", "properties",
+}
+
+// FieldPath is a reference to a resource field in a filter expression (e.g., tags.Environment)
+type FieldPath struct {
+	Parts []string
+}
+
+// validate checks that the path references a known resource field
+func (f FieldPath) validate() error {
+	root := f.Parts[0]
+
+	switch root {
+	case "id", "type", "name", "provider", "account", "region", "arn", "created_at", "updated_at":
+		if len(f.Parts) > 1 {
+			return fmt.Errorf("field '%s' has no sub-fields", root)
+		}
+	case "tags":
+		if len(f.Parts) != 2 {
+			return fmt.Errorf("expected a tag key, e.g. tags.Environment or tags[\"aws:createdBy\"]")
+		}
+	case "properties":
+		if len(f.Parts) < 2 {
+			return fmt.Errorf("expected a property name, e.g. properties.instance_type")
+		}
+	case "cost":
+		if len(f.Parts) == 1 {
+			return nil
+		}
+		switch f.Parts[1] {
+		case "monthly_estimate", "currency":
+			if len(f.Parts) > 2 {
+				return fmt.Errorf("field 'cost.%s' has no sub-fields", f.Parts[1])
+			}
+		case "breakdown":
+			if len(f.Parts) != 3 {
+				return fmt.Errorf("expected a breakdown component, e.g. cost.breakdown.compute")
+			}
+		default:
+			return fmt.Errorf("unknown cost field '%s', expected monthly_estimate, currency or breakdown", f.Parts[1])
+		}
+	default:
+		return fmt.Errorf("unknown field '%s', expected one of: %s", root, strings.Join(expressionFields, ", "))
+	}
+
+	return nil
+}
+
+// Resolve returns the value of the field for a resource, and whether it is set
+func (f FieldPath) Resolve(res *resource.Resource) (interface{}, bool) {
+	switch f.Parts[0] {
+	case "id":
+		return res.ID, true
+	case "type":
+		return string(res.Type), true
+	case "name":
+		return res.Name, true
+	case "provider":
+		return res.Provider, true
+	case "account":
+		return res.Account, res.Account != ""
+	case "region":
+		return res.Region, res.Region != ""
+	case "arn":
+		return res.ARN, res.ARN != ""
+	case "created_at":
+		return res.CreatedAt, res.CreatedAt != nil
+	case "updated_at":
+		return res.UpdatedAt, res.UpdatedAt != nil
+	case "tags":
+		value, ok := res.Tags[f.Parts[1]]
+		return value, ok
+	case "cost":
+		if res.Cost == nil {
+			return nil, false
+		}
+		if len(f.Parts) == 1 || f.Parts[1] == "monthly_estimate" {
+			return res.Cost.MonthlyEstimate, true
+		}
+		if f.Parts[1] == "currency" {
+			return res.Cost.Currency, true
+		}
+		value, ok := res.Cost.Breakdown[f.Parts[2]]
+		return value, ok
+	case "properties":
+		return resolvePath(res.Properties, f.Parts[1:])
+	}
+
+	return nil, false
+}
+
+// resolvePath resolves a path in nested property maps. A path through a list collects the
+// value of the rest of the path for every item of the list, flattening nested lists (e.g.,
+// properties.ingress_rules.cidr_blocks lists the CIDR blocks of every ingress rule).
+func resolvePath(current interface{}, parts []string) (interface{}, bool) {
+	for i, part := range parts {
+		switch m := current.(type) {
+		case map[string]interface{}:
+			value, ok := m[part]
+			if !ok {
+				return nil, false
+			}
+			current = value
+		case map[string]string:
+			value, ok := m[part]
+			if !ok {
+				return nil, false
+			}
+			current = value
+		default:
+			if !isList(current) {
+				return nil, false
+			}
+
+			list := reflect.ValueOf(current)
+			values := make([]interface{}, 0, list.Len())
+			for j := 0; j < list.Len(); j++ {
+				value, ok := resolvePath(list.Index(j).Interface(), parts[i:])
+				if !ok {
+					continue
+				}
+				if !isList(value) {
+					values = append(values, value)
+					continue
+				}
+				nested := reflect.ValueOf(value)
+				for k := 0; k < nested.Len(); k++ {
+					values = append(values, nested.Index(k).Interface())
+				}
+			}
+			return values, len(values) > 0
+		}
+	}
+
+	return current, current != nil
+}
+
+// isList reports whether a value is a slice or an array
+func isList(v interface{}) bool {
+	kind := reflect.ValueOf(v).Kind()
+	return kind == reflect.Slice || kind == reflect.Array
+}
+
+func (f FieldPath) String() string {
+	var sb strings.Builder
+	for i, part := range f.Parts {
+		if i > 0 && strings.ContainsAny(part, ":/ .") {
+			sb.WriteString(fmt.Sprintf("[%q]", part))
+			continue
+		}
+		if i > 0 {
+			sb.WriteString(".")
+		}
+		sb.WriteString(part)
+	}
+	return sb.String()
+}
+
+// ExpressionFilter compares a resource field against a value in a filter expression. A field that
+// is not set fails every comparison, the negative ones (!=, !~ and not in) included, except
+// comparisons with null: use !has(field) to match resources without the field.
+type ExpressionFilter struct {
+	Field    FieldPath
+	Operator CompareOperator
+	Value    interface{} // string, float64, bool, nil, *regexp.Regexp or []interface{} for OpIn and OpNotIn
+}
+
+func (f *ExpressionFilter) Apply(res *resource.Resource) bool {
+	actual, ok := f.Field.Resolve(res)
+
+	switch f.Operator {
+	case OpTruthy:
+		return ok && isTruthy(actual)
+	case OpEquals:
+		if !ok || f.Value == nil {
+			return !ok && f.Value == nil
+		}
+		return valuesEqual(actual, f.Value)
+	case OpNotEquals:
+		if !ok || f.Value == nil {
+			// A missing field is neither different from a value nor from null
+			return ok
+		}
+		return !valuesEqual(actual, f.Value)
+	case OpMatches:
+		re, isRegex := f.Value.(*regexp.Regexp)
+		return ok && isRegex && re.MatchString(stringify(actual))
+	case OpNotMatches:
+		re, isRegex := f.Value.(*regexp.Regexp)
+		return ok && isRegex && !re.MatchString(stringify(actual))
+	case OpIn, OpNotIn:
+		values, _ := f.Value.([]interface{})
+		if !ok {
+			return false
+		}
+		for _, value := range values {
+			if value != nil && valuesEqual(actual, value) {
+				return f.Operator == OpIn
+			}
+		}
+		return f.Operator == OpNotIn
+	case OpContains:
+		return ok && containsValue(actual, f.Value)
+	case OpLessThan, OpLessThanOrEqual, OpGreaterThan, OpGreaterThanOrEqual:
+		if !ok {
+			return false
+		}
+		cmp, comparable := compareOrdered(actual, f.Value)
+		if !comparable {
+			return false
+		}
+		switch f.Operator {
+		case OpLessThan:
+			return cmp < 0
+		case OpLessThanOrEqual:
+			return cmp <= 0
+		case OpGreaterThan:
+			return cmp > 0
+		default:
+			return cmp >= 0
+		}
+	}
+
+	return false
+}
+
+func (f *ExpressionFilter) Description() string {
+	if f.Operator == OpTruthy {
+		return f.Field.String()
+	}
+
+	// OpEquals is shared with the flag filters as "=", expressions spell it "=="
+	operator := string(f.Operator)
+	if f.Operator == OpEquals {
+		operator = "=="
+	}
+	return fmt.Sprintf("%s %s %s", f.Field, operator, formatExpressionValue(f.Value))
+}
+
+// ExistsFilter matches resources where a field is set (has(field) in filter expressions)
+type ExistsFilter struct {
+	Field FieldPath
+}
+
+func (f *ExistsFilter) Apply(res *resource.Resource) bool {
+	_, ok := f.Field.Resolve(res)
+	return ok
+}
+
+func (f *ExistsFilter) Description() string {
+	return fmt.Sprintf("has(%s)", f.Field)
+}
+
+// NotFilter negates another filter
+type NotFilter struct {
+	Filter Filter
+}
+
+func (f *NotFilter) Apply(res *resource.Resource) bool {
+	return !f.Filter.Apply(res)
+}
+
+func (f *NotFilter) Description() string {
+	return fmt.Sprintf("!(%s)", f.Filter.Description())
+}
+
+// isTruthy reports whether a value is considered true in a bare field check
+func isTruthy(v interface{}) bool {
+	switch val := v.(type) {
+	case nil:
+		return false
+	case bool:
+		return val
+	case string:
+		b, err := strconv.ParseBool(val)
+		if err == nil {
+			return b
+		}
+		return val != ""
+	}
+
+	if n, err := toFloat64(v); err == nil {
+		return n != 0
+	}
+
+	return true
+}
+
+// valuesEqual compares an actual field value with an expression literal using the literal's type
+func valuesEqual(actual, expected interface{}) bool {
+	switch e := expected.(type) {
+	case float64:
+		if a, err := toFloat64(actual); err == nil {
+			return a == e
+		}
+	case bool:
+		switch a := actual.(type) {
+		case bool:
+			return a == e
+		case string:
+			b, err := strconv.ParseBool(a)
+			return err == nil && b == e
+		}
+		return false
+	case string:
+		if t, isTime := asTime(actual); isTime {
+			if et, err := parseDate(e); err == nil {
+				return t.Equal(et)
+			}
+		}
+	}
+
+	return stringify(actual) == stringify(expected)
+}
+
+// containsValue reports whether a list contains an element equal to the expected value, a map
+// has it as a key, or a string has it as a substring
+func containsValue(actual, expected interface{}) bool {
+	if expected == nil {
+		return false
+	}
+
+	value := reflect.ValueOf(actual)
+	switch {
+	case isList(actual):
+		for i := 0; i < value.Len(); i++ {
+			if valuesEqual(value.Index(i).Interface(), expected) {
+				return true
+			}
+		}
+		return false
+	case value.Kind() == reflect.Map:
+		key := stringify(expected)
+		for _, k := range value.MapKeys() {
+			if stringify(k.Interface()) == key {
+				return true
+			}
+		}
+		return false
+	}
+
+	return strings.Contains(stringify(actual), stringify(expected))
+}
+
+// compareOrdered compares an actual field value with an expression literal.
+// It returns false if the values cannot be ordered.
+func compareOrdered(actual, expected interface{}) (int, bool) {
+	switch e := expected.(type) {
+	case float64:
+		a, err := toFloat64(actual)
+		if err != nil {
+			return 0, false
+		}
+		switch {
+		case a < e:
+			return -1, true
+		case a > e:
+			return 1, true
+		}
+		return 0, true
+	case string:
+		if t, isTime := asTime(actual); isTime {
+			et, err := parseDate(e)
+			if err != nil {
+				return 0, false
+			}
+			return t.Compare(et), true
+		}
+		if a, isString := actual.(string); isString {
+			return strings.Compare(a, e), true
+		}
+	}
+
+	return 0, false
+}
+
+// asTime returns the value as a time if it is one
+func asTime(v interface{}) (time.Time, bool) {
+	switch t := v.(type) {
+	case *time.Time:
+		if t == nil {
+			return time.Time{}, false
+		}
+		return *t, true
+	case time.Time:
+		return t, true
+	}
+	return time.Time{}, false
+}
+
+// stringify converts a field value to its string representation
+func stringify(v interface{}) string {
+	if t, isTime := asTime(v); isTime {
+		return t.Format(time.RFC3339)
+	}
+	if f, isFloat := v.(float64); isFloat {
+		return strconv.FormatFloat(f, 'f', -1, 64)
+	}
+	return fmt.Sprintf("%v", v)
+}
+
+// formatExpressionValue formats an expression literal for descriptions
+func formatExpressionValue(v interface{}) string {
+	switch val := v.(type) {
+	case nil:
+		return "null"
+	case string:
+		return strconv.Quote(val)
+	case *regexp.Regexp:
+		return "/" + val.String() + "/"
+	case []interface{}:
+		parts := make([]string, len(val))
+		for i, item := range val {
+			parts[i] = formatExpressionValue(item)
+		}
+		return "[" + strings.Join(parts, ", ") + "]"
+	}
+	return stringify(v)
+}
@@ -0,0 +1,686 @@
+package filter
+
+import (
+	"fmt"
+	"regexp"
+	"strconv"
+	"strings"
+	"unicode"
+
+	"github.com/comfortablynumb/pmp-cloud-inspector/pkg/resource"
+)
+
+// ParseError is returned when a filter expression cannot be parsed
+type ParseError struct {
+	Expr    string // The full expression being parsed
+	Pos     int    // 1-based position of the offending token
+	Message string
+}
+
+func (e *ParseError) Error() string {
+	return fmt.Sprintf("parse error at position %d: %s\n  %s\n  %s^", e.Pos, e.Message, e.Expr, strings.Repeat(" ", e.Pos-1))
+}
+
+// tokenKind identifies the kind of a lexical token
+type tokenKind int
+
+const (
+	tokEOF tokenKind = iota
+	tokIdent
+	tokString
+	tokNumber
+	tokRegex
+	tokAnd      // &&
+	tokOr       // ||
+	tokNot      // !
+	tokEq       // ==
+	tokNeq      // !=
+	tokMatch    // =~
+	tokNotMatch // !~
+	tokLt       // <
+	tokLte      // <=
+	tokGt       // >
+	tokGte      // >=
+	tokLParen   // (
+	tokRParen   // )
+	tokLBracket // [
+	tokRBracket // ]
+	tokComma    // ,
+	tokDot      // .
+)
+
+// token is a lexical token of a filter expression
+type token struct {
+	kind  tokenKind
+	text  string // Raw text (unquoted value for strings and regexes)
+	pos   int    // 1-based position in the expression
+	value interface{}
+}
+
+func (t token) String() string {
+	switch t.kind {
+	case tokEOF:
+		return "end of expression"
+	case tokString:
+		return strconv.Quote(t.text)
+	case tokRegex:
+		return "/" + t.text + "/"
+	default:
+		return "'" + t.text + "'"
+	}
+}
+
+// lexer splits a filter expression into tokens
+type lexer struct {
+	expr []rune
+	pos  int
+}
+
+func (l *lexer) errorf(pos int, format string, args ...interface{}) error {
+	return &ParseError{Expr: string(l.expr), Pos: pos + 1, Message: fmt.Sprintf(format, args...)}
+}
+
+func (l *lexer) tokens() ([]token, error) {
+	var tokens []token
+
+	for {
+		tok, err := l.next()
+		if err != nil {
+			return nil, err
+		}
+		tokens = append(tokens, tok)
+		if tok.kind == tokEOF {
+			return tokens, nil
+		}
+	}
+}
+
+func (l *lexer) peek(offset int) rune {
+	if l.pos+offset >= len(l.expr) {
+		return 0
+	}
+	return l.expr[l.pos+offset]
+}
+
+func (l *lexer) next() (token, error) {
+	for l.pos < len(l.expr) && unicode.IsSpace(l.expr[l.pos]) {
+		l.pos++
+	}
+
+	start := l.pos
+	if l.pos >= len(l.expr) {
+		return token{kind: tokEOF, pos: start + 1}, nil
+	}
+
+	ch := l.expr[l.pos]
+	twoChar := string([]rune{ch, l.peek(1)})
+
+	operators := map[string]tokenKind{
+		"&&": tokAnd, "||": tokOr, "==": tokEq, "!=": tokNeq,
+		"=~": tokMatch, "!~": tokNotMatch, "<=": tokLte, ">=": tokGte,
+	}
+	if kind, ok := operators[twoChar]; ok {
+		l.pos += 2
+		return token{kind: kind, text: twoChar, pos: start + 1}, nil
+	}
+
+	single := map[rune]tokenKind{
+		'!': tokNot, '<': tokLt, '>': tokGt, '(': tokLParen, ')': tokRParen,
+		'[': tokLBracket, ']': tokRBracket, ',': tokComma, '.': tokDot,
+	}
+	if kind, ok := single[ch]; ok {
+		l.pos++
+		return token{kind: kind, text: string(ch), pos: start + 1}, nil
+	}
+
+	switch {
+	case ch == '"' || ch == '\'':
+		return l.lexString(ch)
+	case ch == '/':
+		return l.lexRegex()
+	case unicode.IsDigit(ch) || (ch == '-' && unicode.IsDigit(l.peek(1))):
+		return l.lexNumber()
+	case unicode.IsLetter(ch) || ch == '_':
+		for l.pos < len(l.expr) && isIdentRune(l.expr[l.pos]) {
+			l.pos++
+		}
+		return token{kind: tokIdent, text: string(l.expr[start:l.pos]), pos: start + 1}, nil
+	case ch == '=':
+		return token{}, l.errorf(start, "unexpected '=', use '==' for equality")
+	case ch == '&' || ch == '|':
+		return token{}, l.errorf(start, "unexpected '%c', use '%c%c'", ch, ch, ch)
+	}
+
+	return token{}, l.errorf(start, "unexpected character '%c'", ch)
+}
+
+func isIdentRune(ch rune) bool {
+	return unicode.IsLetter(ch) || unicode.IsDigit(ch) || ch == '_' || ch == '-'
+}
+
+func (l *lexer) lexString(quote rune) (token, error) {
+	start := l.pos
+	l.pos++
+
+	var sb strings.Builder
+	for l.pos < len(l.expr) {
+		ch := l.expr[l.pos]
+		switch {
+		case ch == '\\' && l.pos+1 < len(l.expr):
+			l.pos++
+			switch esc := l.expr[l.pos]; esc {
+			case 'n':
+				sb.WriteRune('\n')
+			case 't':
+				sb.WriteRune('\t')
+			default:
+				sb.WriteRune(esc)
+			}
+		case ch == quote:
+			l.pos++
+			text := sb.String()
+			return token{kind: tokString, text: text, pos: start + 1, value: text}, nil
+		default:
+			sb.WriteRune(ch)
+		}
+		l.pos++
+	}
+
+	return token{}, l.errorf(start, "unterminated string")
+}
+
+func (l *lexer) lexRegex() (token, error) {
+	start := l.pos
+	l.pos++
+
+	var sb strings.Builder
+	for l.pos < len(l.expr) {
+		ch := l.expr[l.pos]
+		switch {
+		case ch == '\\' && l.peek(1) == '/':
+			sb.WriteRune('/')
+			l.pos++
+		case ch == '/':
+			l.pos++
+			pattern := sb.String()
+			re, err := regexp.Compile(pattern)
+			if err != nil {
+				return token{}, l.errorf(start, "invalid regular expression: %v", err)
+			}
+			return token{kind: tokRegex, text: pattern, pos: start + 1, value: re}, nil
+		default:
+			sb.WriteRune(ch)
+		}
+		l.pos++
+	}
+
+	return token{}, l.errorf(start, "unterminated regular expression")
+}
+
+func (l *lexer) lexNumber() (token, error) {
+	start := l.pos
+	l.pos++
+	for l.pos < len(l.expr) && (unicode.IsDigit(l.expr[l.pos]) || l.expr[l.pos] == '.') {
+		l.pos++
+	}
+
+	text := string(l.expr[start:l.pos])
+	value, err := strconv.ParseFloat(text, 64)
+	if err != nil {
+		return token{}, l.errorf(start, "invalid number '%s'", text)
+	}
+
+	return token{kind: tokNumber, text: text, pos: start + 1, value: value}, nil
+}
+
+// parser is a recursive descent parser for filter expressions
+type parser struct {
+	expr   string
+	tokens []token
+	pos    int
+}
+
+// ParseExpression parses a boolean filter expression into a Filter
+// Examples:
+//   - provider == "aws" && type == "aws:ec2:instance"
+//   - tags.Environment == "prod" || name =~ /^prod-/
+//   - !has(tags.Owner) && properties.instance_type in ["m5.large", "m5.xlarge"]
+//   - properties.security_groups contains "sg-123"
+//   - cost > 100 && created_at >= "2024-01-01"
+//   - type == "aws:ec2:subnet" && !has_incoming("belongs_to", "aws:ec2:instance")
+//
+// Operator precedence from highest to lowest is: !, comparisons, &&, ||.
+func ParseExpression(expr string) (Filter, error) {
+	if strings.TrimSpace(expr) == "" {
+		return nil, fmt.Errorf("empty filter expression")
+	}
+
+	lex := &lexer{expr: []rune(expr)}
+	tokens, err := lex.tokens()
+	if err != nil {
+		return nil, err
+	}
+
+	p := &parser{expr: expr, tokens: tokens}
+	f, err := p.parseOr()
+	if err != nil {
+		return nil, err
+	}
+
+	if tok := p.current(); tok.kind != tokEOF {
+		return nil, p.errorf(tok, "unexpected %s, expected '&&', '||' or end of expression", tok)
+	}
+
+	return f, nil
+}
+
+func (p *parser) current() token {
+	return p.tokens[p.pos]
+}
+
+func (p *parser) advance() token {
+	tok := p.tokens[p.pos]
+	if tok.kind != tokEOF {
+		p.pos++
+	}
+	return tok
+}
+
+func (p *parser) expect(kind tokenKind, what string) (token, error) {
+	tok := p.current()
+	if tok.kind != kind {
+		return tok, p.errorf(tok, "expected %s but found %s", what, tok)
+	}
+	return p.advance(), nil
+}
+
+func (p *parser) errorf(tok token, format string, args ...interface{}) error {
+	return &ParseError{Expr: p.expr, Pos: tok.pos, Message: fmt.Sprintf(format, args...)}
+}
+
+// parseOr parses: and ('||' and)*
+func (p *parser) parseOr() (Filter, error) {
+	left, err := p.parseAnd()
+	if err != nil {
+		return nil, err
+	}
+
+	filters := []Filter{left}
+	for p.current().kind == tokOr {
+		p.advance()
+		right, err := p.parseAnd()
+		if err != nil {
+			return nil, err
+		}
+		filters = append(filters, right)
+	}
+
+	if len(filters) == 1 {
+		return left, nil
+	}
+
+	return &CompositeFilter{Filters: filters, Logic: LogicOR}, nil
+}
+
+// parseAnd parses: unary ('&&' unary)*
+func (p *parser) parseAnd() (Filter, error) {
+	left, err := p.parseUnary()
+	if err != nil {
+		return nil, err
+	}
+
+	filters := []Filter{left}
+	for p.current().kind == tokAnd {
+		p.advance()
+		right, err := p.parseUnary()
+		if err != nil {
+			return nil, err
+		}
+		filters = append(filters, right)
+	}
+
+	if len(filters) == 1 {
+		return left, nil
+	}
+
+	return &CompositeFilter{Filters: filters, Logic: LogicAND}, nil
+}
+
+// parseUnary parses: '!' unary | primary
+func (p *parser) parseUnary() (Filter, error) {
+	if p.current().kind == tokNot {
+		p.advance()
+		inner, err := p.parseUnary()
+		if err != nil {
+			return nil, err
+		}
+		return &NotFilter{Filter: inner}, nil
+	}
+
+	return p.parsePrimary()
+}
+
+// parsePrimary parses: '(' or ')' | function call | comparison
+func (p *parser) parsePrimary() (Filter, error) {
+	tok := p.current()
+
+	switch tok.kind {
+	case tokLParen:
+		p.advance()
+		inner, err := p.parseOr()
+		if err != nil {
+			return nil, err
+		}
+		if _, err := p.expect(tokRParen, "')'"); err != nil {
+			return nil, err
+		}
+		return inner, nil
+	case tokIdent:
+		if p.tokens[p.pos+1].kind == tokLParen {
+			return p.parseFunction()
+		}
+		return p.parseComparison()
+	}
+
+	return nil, p.errorf(tok, "expected a field, function or '(' but found %s", tok)
+}
+
+// parseFunction parses: ident '(' args ')'
+func (p *parser) parseFunction() (Filter, error) {
+	name := p.advance()
+	p.advance() // (
+
+	switch name.text {
+	case "has":
+		field, err := p.parseField()
+		if err != nil {
+			return nil, err
+		}
+		if _, err := p.expect(tokRParen, "')'"); err != nil {
+			return nil, err
+		}
+		return &ExistsFilter{Field: field}, nil
+	case "related_to", "near", "has_incoming":
+		return p.parseGraphFunction(name)
+	}
+
+	return nil, p.errorf(name, "unknown function '%s', supported functions: has, related_to, near, has_incoming", name.text)
+}
+
+// parseGraphFunction parses the arguments of a relationship function:
+//   - related_to("vpc-123"[, "belongs_to"[, "out"]])
+//   - near("vpc-123", 2[, "any"])
+//   - has_incoming(["belongs_to"[, "aws:ec2:instance"]])
+func (p *parser) parseGraphFunction(name token) (Filter, error) {
+	var args []token
+	for p.current().kind != tokRParen {
+		if len(args) > 0 {
+			if _, err := p.expect(tokComma, "',' or ')'"); err != nil {
+				return nil, err
+			}
+		}
+		arg, err := p.parseValue()
+		if err != nil {
+			return nil, err
+		}
+		args = append(args, arg)
+	}
+	p.advance() // )
+
+	stringArg := func(i int) (string, error) {
+		if i >= len(args) {
+			return "", nil
+		}
+		if args[i].kind != tokString {
+			return "", p.errorf(args[i], "%s: argument %d must be a string", name.text, i+1)
+		}
+		return args[i].text, nil
+	}
+
+	directionArg := func(i int) (resource.Direction, error) {
+		value, err := stringArg(i)
+		if err != nil {
+			return "", err
+		}
+		direction, err := parseDirection(value, resource.DirectionBoth)
+		if err != nil {
+			return "", p.errorf(args[i], "%s: %v", name.text, err)
+		}
+		return direction, nil
+	}
+
+	switch name.text {
+	case "related_to":
+		if len(args) < 1 || len(args) > 3 {
+			return nil, p.errorf(name, "related_to expects 1 to 3 arguments: id[, relation type[, direction]]")
+		}
+		id, err := stringArg(0)
+		if err != nil {
+			return nil, err
+		}
+		relType, err := stringArg(1)
+		if err != nil {
+			return nil, err
+		}
+		direction, err := directionArg(2)
+		if err != nil {
+			return nil, err
+		}
+		return &RelatedFilter{TargetID: id, RelationType: resource.RelationType(relType), Direction: direction}, nil
+	case "near":
+		if len(args) < 2 || len(args) > 3 {
+			return nil, p.errorf(name, "near expects 2 or 3 arguments: id, depth[, direction]")
+		}
+		id, err := stringArg(0)
+		if err != nil {
+			return nil, err
+		}
+		depth, isNumber := args[1].value.(float64)
+		if args[1].kind != tokNumber || !isNumber || depth < 0 || depth != float64(int(depth)) {
+			return nil, p.errorf(args[1], "near: depth must be a non-negative integer")
+		}
+		direction, err := directionArg(2)
+		if err != nil {
+			return nil, err
+		}
+		return &NeighborhoodFilter{Seeds: []string{id}, Depth: int(depth), Direction: direction}, nil
+	}
+
+	if len(args) > 2 {
+		return nil, p.errorf(name, "has_incoming expects at most 2 arguments: [relation type[, source type]]")
+	}
+	relType, err := stringArg(0)
+	if err != nil {
+		return nil, err
+	}
+	sourceType, err := stringArg(1)
+	if err != nil {
+		return nil, err
+	}
+	return &IncomingFilter{RelationType: resource.RelationType(relType), SourceType: resource.ResourceType(sourceType)}, nil
+}
+
+// parseComparison parses: field [op value | ['not'] 'in' list | 'contains' value]
+func (p *parser) parseComparison() (Filter, error) {
+	field, err := p.parseField()
+	if err != nil {
+		return nil, err
+	}
+
+	opTok := p.current()
+
+	operators := map[tokenKind]CompareOperator{
+		tokEq: OpEquals, tokNeq: OpNotEquals, tokLt: OpLessThan, tokLte: OpLessThanOrEqual,
+		tokGt: OpGreaterThan, tokGte: OpGreaterThanOrEqual, tokMatch: OpMatches, tokNotMatch: OpNotMatches,
+	}
+
+	if op, ok := operators[opTok.kind]; ok {
+		p.advance()
+
+		value, err := p.parseValue()
+		if err != nil {
+			return nil, err
+		}
+
+		if op == OpMatches || op == OpNotMatches {
+			value, err = p.toRegex(value)
+			if err != nil {
+				return nil, err
+			}
+		} else if value.kind == tokRegex {
+			return nil, p.errorf(value, "regular expressions can only be used with '=~' and '!~'")
+		}
+
+		return &ExpressionFilter{Field: field, Operator: op, Value: value.value}, nil
+	}
+
+	if opTok.kind == tokIdent && opTok.text == "not" {
+		p.advance()
+		if tok := p.current(); tok.kind != tokIdent || tok.text != "in" {
+			return nil, p.errorf(tok, "expected 'in' after 'not' but found %s", tok)
+		}
+		p.advance()
+		values, err := p.parseList()
+		if err != nil {
+			return nil, err
+		}
+		return &ExpressionFilter{Field: field, Operator: OpNotIn, Value: values}, nil
+	}
+
+	if opTok.kind == tokIdent && opTok.text == "in" {
+		p.advance()
+		values, err := p.parseList()
+		if err != nil {
+			return nil, err
+		}
+		return &ExpressionFilter{Field: field, Operator: OpIn, Value: values}, nil
+	}
+
+	if opTok.kind == tokIdent && opTok.text == "contains" {
+		p.advance()
+		value, err := p.parseValue()
+		if err != nil {
+			return nil, err
+		}
+		if value.kind == tokRegex {
+			return nil, p.errorf(value, "regular expressions can only be used with '=~' and '!~'")
+		}
+		return &ExpressionFilter{Field: field, Operator: OpContains, Value: value.value}, nil
+	}
+
+	// A bare field is a truthiness check (e.g., properties.enabled)
+	return &ExpressionFilter{Field: field, Operator: OpTruthy}, nil
+}
+
+func (p *parser) toRegex(value token) (token, error) {
+	switch value.kind {
+	case tokRegex:
+		return value, nil
+	case tokString:
+		re, err := regexp.Compile(value.text)
+		if err != nil {
+			return value, p.errorf(value, "invalid regular expression: %v", err)
+		}
+		value.value = re
+		return value, nil
+	}
+	return value, p.errorf(value, "expected a regular expression or string but found %s", value)
+}
+
+// parseField parses: ident ('.' ident | '[' string ']')*
+func (p *parser) parseField() (FieldPath, error) {
+	first, err := p.expect(tokIdent, "a field name")
+	if err != nil {
+		return FieldPath{}, err
+	}
+
+	field := FieldPath{Parts: []string{first.text}}
+
+	for {
+		switch p.current().kind {
+		case tokDot:
+			p.advance()
+			part, err := p.expect(tokIdent, "a field name after '.'")
+			if err != nil {
+				return FieldPath{}, err
+			}
+			field.Parts = append(field.Parts, part.text)
+			continue
+		case tokLBracket:
+			p.advance()
+			part, err := p.expect(tokString, "a quoted key")
+			if err != nil {
+				return FieldPath{}, err
+			}
+			if _, err := p.expect(tokRBracket, "']'"); err != nil {
+				return FieldPath{}, err
+			}
+			field.Parts = append(field.Parts, part.text)
+			continue
+		}
+		break
+	}
+
+	if err := field.validate(); err != nil {
+		return FieldPath{}, p.errorf(first, "%v", err)
+	}
+
+	return field, nil
+}
+
+// parseValue parses a literal value
+func (p *parser) parseValue() (token, error) {
+	tok := p.current()
+
+	switch tok.kind {
+	case tokString, tokNumber, tokRegex:
+		return p.advance(), nil
+	case tokIdent:
+		switch tok.text {
+		case "true", "false":
+			tok.value = tok.text == "true"
+			p.advance()
+			return tok, nil
+		case "null":
+			tok.value = nil
+			p.advance()
+			return tok, nil
+		}
+		return tok, p.errorf(tok, "expected a value but found %s (quote strings with \"...\")", tok)
+	}
+
+	return tok, p.errorf(tok, "expected a value but found %s", tok)
+}
+
+// parseList parses: '[' value (',' value)* ']'
+func (p *parser) parseList() ([]interface{}, error) {
+	if _, err := p.expect(tokLBracket, "'['"); err != nil {
+		return nil, err
+	}
+
+	var values []interface{}
+	if p.current().kind == tokRBracket {
+		p.advance()
+		return values, nil
+	}
+
+	for {
+		value, err := p.parseValue()
+		if err != nil {
+			return nil, err
+		}
+		if value.kind == tokRegex {
+			return nil, p.errorf(value, "regular expressions are not allowed in lists")
+		}
+		values = append(values, value.value)
+
+		tok := p.advance()
+		switch tok.kind {
+		case tokComma:
+			continue
+		case tokRBracket:
+			return values, nil
+		}
+		return nil, p.errorf(tok, "expected ',' or ']' but found %s", tok)
+	}
+}
@@ -0,0 +1,217 @@
+package filter
+
+import (
+	"errors"
+	"testing"
+
+	"github.com/comfortablynumb/pmp-cloud-inspector/pkg/resource"
+)
+
+func testResources() map[string]*resource.Resource {
+	return map[string]*resource.Resource{
+		"prod": {
+			ID:       "i-prod",
+			Type:     resource.TypeAWSEC2Instance,
+			Name:     "prod-web",
+			Provider: "aws",
+			Region:   "us-east-1",
+			Tags:     map[string]string{"Env": "prod", "aws:createdBy": "terraform"},
+			Properties: map[string]interface{}{
+				"instance_type":   "m5.large",
+				"enabled":         true,
+				"security_groups": []string{"sg-1", "sg-2"},
+				"vcpus":           int16(2),
+				"disk_gb":         "100",
+			},
+		},
+		"dev": {
+			ID:       "i-dev",
+			Type:     resource.TypeAWSEC2Instance,
+			Name:     "dev-web",
+			Provider: "aws",
+			Region:   "eu-west-1",
+			Tags:     map[string]string{"Env": "dev", "Owner": "team-a"},
+			Properties: map[string]interface{}{
+				"instance_type": "t3.micro",
+				"enabled":       false,
+				"vcpus":         uint(1),
+			},
+		},
+		"untagged": {
+			ID:       "bucket",
+			Type:     resource.TypeAWSS3Bucket,
+			Name:     "logs",
+			Provider: "aws",
+		},
+	}
+}
+
+// matching returns the names of the test resources matched by a filter, in a fixed order
+func matching(f Filter, resources map[string]*resource.Resource) []string {
+	var names []string
+	for _, name := range []string{"prod", "dev", "untagged"} {
+		if f.Apply(resources[name]) {
+			names = append(names, name)
+		}
+	}
+	return names
+}
+
+func equalNames(a, b []string) bool {
+	if len(a) != len(b) {
+		return false
+	}
+	for i := range a {
+		if a[i] != b[i] {
+			return false
+		}
+	}
+	return true
+}
+
+func TestParseExpressionMatches(t *testing.T) {
+	resources := testResources()
+
+	tests := []struct {
+		name string
+		expr string
+		want []string
+	}{
+		// Precedence: ! binds tighter than &&, which binds tighter than ||
+		{"and binds tighter than or", `name == "logs" || provider == "aws" && tags.Env == "dev"`, []string{"dev", "untagged"}},
+		{"not binds tighter than and", `provider == "aws" && !has(tags.Owner) || tags.Env == "dev"`, []string{"prod", "dev", "untagged"}},
+		{"or and not", `tags.Env == "prod" || region == "eu-west-1" && !has(tags.Owner)`, []string{"prod"}},
+		{"parentheses override precedence", `(tags.Env == "prod" || region == "eu-west-1") && !has(tags.Owner)`, []string{"prod"}},
+		{"double negation", `!!has(tags.Env)`, []string{"prod", "dev"}},
+
+		// in lists
+		{"in list", `properties.instance_type in ["m5.large", "m5.xlarge"]`, []string{"prod"}},
+		{"in list with numbers and strings", `tags.Env in ["dev", 1]`, []string{"dev"}},
+		{"not in list", `properties.instance_type not in ["m5.large"]`, []string{"dev"}},
+		{"empty in list", `name in []`, nil},
+
+		// Regular expressions
+		{"regex", `name =~ /^prod-/`, []string{"prod"}},
+		{"negated regex", `name !~ /^prod-/`, []string{"dev", "untagged"}},
+		{"regex with escaped slash", `tags["aws:createdBy"] =~ /^terra\/?form$/`, []string{"prod"}},
+
+		// Other operators and fields
+		{"contains list element", `properties.security_groups contains "sg-2"`, []string{"prod"}},
+		{"bare field is truthy", `properties.enabled`, []string{"prod"}},
+		{"numeric property of any integer type", `properties.vcpus >= 2`, []string{"prod"}},
+		{"numeric property equals", `properties.vcpus == 1`, []string{"dev"}},
+		{"numeric string property", `properties.disk_gb > 50`, []string{"prod"}},
+		{"quoted tag key", `tags["aws:createdBy"] == 'terraform'`, []string{"prod"}},
+
+		// Missing fields fail every comparison, the negative ones included
+		{"missing field not equal", `tags.Env != "prod"`, []string{"dev"}},
+		{"missing field not matches", `tags.Env !~ /prod/`, []string{"dev"}},
+		{"missing field not in", `tags.Env not in ["prod"]`, []string{"dev"}},
+		{"missing field equals null", `tags.Env == null`, []string{"untagged"}},
+		{"missing field not equals null", `tags.Env != null`, []string{"prod", "dev"}},
+		{"missing field ordered", `properties.cpu > 1`, nil},
+		{"missing field included with has", `!has(tags.Env) || tags.Env != "prod"`, []string{"dev", "untagged"}},
+		{"negated comparison includes missing field", `!(tags.Env == "prod")`, []string{"dev", "untagged"}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			f, err := ParseExpression(tt.expr)
+			if err != nil {
+				t.Fatalf("ParseExpression(%q) failed: %v", tt.expr, err)
+			}
+			if got := matching(f, resources); !equalNames(got, tt.want) {
+				t.Errorf("ParseExpression(%q) matched %v, want %v", tt.expr, got, tt.want)
+			}
+		})
+	}
+}
+
+func TestParseExpressionStructure(t *testing.T) {
+	f, err := ParseExpression(`provider == "aws" || type == "aws:s3:bucket" && !has(tags.Owner)`)
+	if err != nil {
+		t.Fatalf("ParseExpression failed: %v", err)
+	}
+
+	or, ok := f.(*CompositeFilter)
+	if !ok || or.Logic != LogicOR || len(or.Filters) != 2 {
+		t.Fatalf("expected an OR of 2 filters, got %#v", f)
+	}
+	and, ok := or.Filters[1].(*CompositeFilter)
+	if !ok || and.Logic != LogicAND || len(and.Filters) != 2 {
+		t.Fatalf("expected the right side to be an AND of 2 filters, got %#v", or.Filters[1])
+	}
+	if _, ok := and.Filters[1].(*NotFilter); !ok {
+		t.Fatalf("expected the negation to apply to has() only, got %#v", and.Filters[1])
+	}
+}
+
+func TestParseExpressionErrors(t *testing.T) {
+	tests := []struct {
+		name    string
+		expr    string
+		wantPos int
+	}{
+		{"single equals", `provider = "aws"`, 10},
+		{"single ampersand", `provider == "aws" & region == "x"`, 19},
+		{"unterminated string", `name == "web`, 9},
+		{"unterminated regex", `name =~ /web`, 9},
+		{"invalid regex", `name =~ /(/`, 9},
+		{"regex with equality", `name == /web/`, 9},
+		{"invalid regex string", `name =~ "("`, 9},
+		{"missing value", `name ==`, 8},
+		{"missing closing paren", `(name == "web"`, 15},
+		{"unknown field", `owner == "x"`, 1},
+		{"trailing token", `name == "web" region`, 15},
+		{"regex in list", `name in [/web/]`, 10},
+		{"not without in", `name not "web"`, 10},
+		{"unexpected character", `name == "web" # comment`, 15},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			_, err := ParseExpression(tt.expr)
+			if err == nil {
+				t.Fatalf("ParseExpression(%q) succeeded, want an error", tt.expr)
+			}
+			var parseErr *ParseError
+			if !errors.As(err, &parseErr) {
+				t.Fatalf("ParseExpression(%q) returned %T, want *ParseError: %v", tt.expr, err, err)
+			}
+			if parseErr.Pos != tt.wantPos {
+				t.Errorf("ParseExpression(%q) error at position %d, want %d: %v", tt.expr, parseErr.Pos, tt.wantPos, err)
+			}
+		})
+	}
+}
+
+func TestExpressionDescriptionRoundTrip(t *testing.T) {
+	resources := testResources()
+
+	exprs := []string{
+		`tags.Env == "prod"`,
+		`tags.Env != null`,
+		`name =~ /^prod-/`,
+		`properties.instance_type not in ["m5.large", "t3.micro"]`,
+		`tags["aws:createdBy"] == "terraform"`,
+		`!has(tags.Owner)`,
+		`properties.enabled`,
+	}
+
+	for _, expr := range exprs {
+		t.Run(expr, func(t *testing.T) {
+			f, err := ParseExpression(expr)
+			if err != nil {
+				t.Fatalf("ParseExpression(%q) failed: %v", expr, err)
+			}
+			description := f.Description()
+			reparsed, err := ParseExpression(description)
+			if err != nil {
+				t.Fatalf("description %q of %q can't be parsed: %v", description, expr, err)
+			}
+			if got, want := matching(reparsed, resources), matching(f, resources); !equalNames(got, want) {
+				t.Errorf("description %q matched %v, want %v", description, got, want)
+			}
+		})
+	}
+}
@@ -340,49 +340,38 @@ func (f *PropertyFilter) compareNumeric(actual, expected interface{}) bool {
 	return false
 }
 
+// toFloat64 converts a numeric value with resource.ToFloat, or parses a numeric string given
+// on the command line or stored as a string property
 func toFloat64(v interface{}) (float64, error) {
-	switch val := v.(type) {
-	case float64:
-		return val, nil
-	case float32:
-		return float64(val), nil
-	case int:
-		return float64(val), nil
-	case int64:
-		return float64(val), nil
-	case string:
+	if val, ok := v.(string); ok {
 		return strconv.ParseFloat(val, 64)
-	default:
-		return 0, fmt.Errorf("cannot convert %T to float64", v)
 	}
+	if val, ok := resource.ToFloat(v); ok {
+		return val, nil
+	}
+	return 0, fmt.Errorf("cannot convert %T to float64", v)
 }
 
 func (f *PropertyFilter) Description() string {
 	return fmt.Sprintf("%s %s %v", f.Path, f.Operator, f.Value)
 }
 
-// CostFilter filters resources by cost (when cost data is available)
+// CostFilter filters resources by their estimated monthly cost (Resource.Cost).
+// Resources without cost data are excluded.
 type CostFilter struct {
-	MinCost *float64
-	MaxCost *float64
+	MinCost   *float64
+	MaxCost   *float64
+	Component string // Breakdown component to compare (e.g., compute); empty compares the monthly estimate
+	Currency  string // Only match costs in this currency; empty matches any currency
 }
 
 func (f *CostFilter) Apply(res *resource.Resource) bool {
-	// Try to get cost from properties
-	costValue, ok := res.Properties["cost"]
-	if !ok {
-		costValue, ok = res.Properties["monthly_cost"]
-	}
+	cost, ok := f.cost(res)
 	if !ok {
 		// No cost data, exclude by default
 		return false
 	}
 
-	cost, err := toFloat64(costValue)
-	if err != nil {
-		return false
-	}
-
 	if f.MinCost != nil && cost < *f.MinCost {
 		return false
 	}
@@ -394,13 +383,48 @@ func (f *CostFilter) Apply(res *resource.Resource) bool {
 	return true
 }
 
+// cost returns the cost of the resource being compared, and whether it is known
+func (f *CostFilter) cost(res *resource.Resource) (float64, bool) {
+	if res.Cost == nil {
+		// Fall back to cost properties set by providers or previous tools
+		costValue, ok := res.Properties["cost"]
+		if !ok {
+			costValue, ok = res.Properties["monthly_cost"]
+		}
+		if !ok || f.Component != "" || f.Currency != "" {
+			return 0, false
+		}
+		cost, err := toFloat64(costValue)
+		return cost, err == nil
+	}
+
+	if f.Currency != "" && !strings.EqualFold(res.Cost.Currency, f.Currency) {
+		return 0, false
+	}
+
+	if f.Component != "" {
+		cost, ok := res.Cost.Breakdown[f.Component]
+		return cost, ok
+	}
+
+	return res.Cost.MonthlyEstimate, true
+}
+
 func (f *CostFilter) Description() string {
+	field := "cost"
+	if f.Component != "" {
+		field = "cost." + f.Component
+	}
+
 	var parts []string
 	if f.MinCost != nil {
-		parts = append(parts, fmt.Sprintf("cost >= %.2f", *f.MinCost))
+		parts = append(parts, fmt.Sprintf("%s >= %.2f", field, *f.MinCost))
 	}
 	if f.MaxCost != nil {
-		parts = append(parts, fmt.Sprintf("cost <= %.2f", *f.MaxCost))
+		parts = append(parts, fmt.Sprintf("%s <= %.2f", field, *f.MaxCost))
+	}
+	if f.Currency != "" {
+		parts = append(parts, fmt.Sprintf("currency = %s", f.Currency))
 	}
 	if len(parts) == 0 {
 		return "cost filter (any)"
@@ -473,8 +497,14 @@ func ApplyFilters(collection *resource.Collection, filters ...Filter) *resource.
 		Logic:   LogicAND,
 	}
 
+	// Relationship filters need the graph of the whole collection, not just the matches
+	if needsGraph(composite) {
+		bindGraph(composite, resource.NewGraph(collection))
+	}
+
+	// Metadata is recomputed from the matching resources as they are added
 	filtered := resource.NewCollection()
-	filtered.Metadata = collection.Metadata
+	filtered.InheritMetadata(collection)
 
 	for _, res := range collection.Resources {
 		if composite.Apply(res) {
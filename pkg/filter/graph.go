@@ -0,0 +1,348 @@
+package filter
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+
+	"github.com/comfortablynumb/pmp-cloud-inspector/pkg/resource"
+)
+
+// GraphFilter is implemented by filters that need the relationship graph of the
+// collection being filtered. ApplyFilters binds the graph before applying them.
+type GraphFilter interface {
+	Filter
+	BindGraph(graph *resource.Graph)
+}
+
+// RelatedFilter matches resources that have a relationship with a target resource
+// (e.g., all resources that belong_to vpc-123)
+type RelatedFilter struct {
+	TargetID     string
+	RelationType resource.RelationType // Empty matches any relationship type
+	Direction    resource.Direction
+	matches      map[string]int
+}
+
+func (f *RelatedFilter) BindGraph(graph *resource.Graph) {
+	var types []resource.RelationType
+	if f.RelationType != "" {
+		types = append(types, f.RelationType)
+	}
+
+	// Resources related to the target are reached by traversing from it in the opposite direction
+	f.matches = graph.Neighborhood([]string{f.TargetID}, 1, oppositeDirection(f.Direction), types...)
+	delete(f.matches, f.TargetID)
+}
+
+func (f *RelatedFilter) Apply(res *resource.Resource) bool {
+	_, ok := f.matches[res.ID]
+	return ok
+}
+
+func (f *RelatedFilter) Description() string {
+	relType := string(f.RelationType)
+	if relType == "" {
+		relType = "*"
+	}
+	return fmt.Sprintf("related_to(%q, %q, %q)", f.TargetID, relType, f.Direction)
+}
+
+// NeighborhoodFilter matches resources within a number of hops of a seed set
+type NeighborhoodFilter struct {
+	Seeds         []string
+	Depth         int
+	Direction     resource.Direction
+	RelationTypes []resource.RelationType // Empty follows any relationship type
+	matches       map[string]int
+}
+
+func (f *NeighborhoodFilter) BindGraph(graph *resource.Graph) {
+	f.matches = graph.Neighborhood(f.Seeds, f.Depth, f.Direction, f.RelationTypes...)
+}
+
+func (f *NeighborhoodFilter) Apply(res *resource.Resource) bool {
+	_, ok := f.matches[res.ID]
+	return ok
+}
+
+func (f *NeighborhoodFilter) Description() string {
+	return fmt.Sprintf("within %d hops (%s) of [%s]", f.Depth, f.Direction, strings.Join(f.Seeds, ", "))
+}
+
+// IncomingFilter matches resources that have at least one incoming relationship
+// of the given type, optionally from a given source resource type
+// (e.g., subnets that an aws:ec2:instance belongs_to)
+type IncomingFilter struct {
+	RelationType resource.RelationType // Empty matches any relationship type
+	SourceType   resource.ResourceType // Empty matches any source type
+	graph        *resource.Graph
+}
+
+func (f *IncomingFilter) BindGraph(graph *resource.Graph) {
+	f.graph = graph
+}
+
+func (f *IncomingFilter) Apply(res *resource.Resource) bool {
+	if f.graph == nil {
+		return false
+	}
+
+	for _, edge := range f.graph.GetIncoming(res.ID) {
+		if f.RelationType != "" && edge.Type != f.RelationType {
+			continue
+		}
+		if f.SourceType != "" && edge.SourceType != f.SourceType {
+			continue
+		}
+		return true
+	}
+
+	return false
+}
+
+func (f *IncomingFilter) Description() string {
+	relType := string(f.RelationType)
+	if relType == "" {
+		relType = "*"
+	}
+	sourceType := string(f.SourceType)
+	if sourceType == "" {
+		sourceType = "*"
+	}
+	return fmt.Sprintf("has_incoming(%q, %q)", relType, sourceType)
+}
+
+// oppositeDirection returns the reverse traversal direction
+func oppositeDirection(d resource.Direction) resource.Direction {
+	switch d {
+	case resource.DirectionOutgoing:
+		return resource.DirectionIncoming
+	case resource.DirectionIncoming:
+		return resource.DirectionOutgoing
+	}
+	return resource.DirectionBoth
+}
+
+// needsGraph reports whether a filter (or any nested filter) requires the relationship graph
+func needsGraph(f Filter) bool {
+	switch filter := f.(type) {
+	case GraphFilter:
+		return true
+	case *CompositeFilter:
+		for _, child := range filter.Filters {
+			if needsGraph(child) {
+				return true
+			}
+		}
+	case *NotFilter:
+		return needsGraph(filter.Filter)
+	}
+	return false
+}
+
+// bindGraph binds the graph to a filter and all of its nested filters
+func bindGraph(f Filter, graph *resource.Graph) {
+	switch filter := f.(type) {
+	case GraphFilter:
+		filter.BindGraph(graph)
+	case *CompositeFilter:
+		for _, child := range filter.Filters {
+			bindGraph(child, graph)
+		}
+	case *NotFilter:
+		bindGraph(filter.Filter, graph)
+	}
+}
+
+// BindGraph binds a graph to the relationship filters nested in a filter, for filters that
+// are applied to resources directly rather than through ApplyFilters
+func BindGraph(f Filter, graph *resource.Graph) {
+	if needsGraph(f) {
+		bindGraph(f, graph)
+	}
+}
+
+// IncludeRelated adds to the filtered collection every resource of the source collection
+// that is a direct target of a relationship of a filtered resource, so that the relationships
+// of the filtered resources don't dangle. The added resources are copies whose relationships
+// are pruned to the resources of the result, so the exported subgraph is self-consistent;
+// the resources of the source collection are not modified.
+func IncludeRelated(filtered, source *resource.Collection) *resource.Collection {
+	graph := resource.NewGraph(source)
+
+	included := make(map[string]bool, len(filtered.Resources))
+	for _, res := range filtered.Resources {
+		included[res.ID] = true
+	}
+
+	result := resource.NewCollection()
+	result.InheritMetadata(filtered)
+
+	var neighbors []*resource.Resource
+	for _, res := range filtered.Resources {
+		result.Add(res)
+		for _, rel := range res.Relationships {
+			if included[rel.TargetID] {
+				continue
+			}
+			if target := graph.Get(rel.TargetID); target != nil {
+				included[rel.TargetID] = true
+				neighbors = append(neighbors, target)
+			}
+		}
+	}
+
+	for _, res := range neighbors {
+		result.Add(pruneRelationships(res, included))
+	}
+
+	return result
+}
+
+// pruneRelationships returns a resource without its relationships to resources that are not
+// included. The resource is copied if any relationship is removed.
+func pruneRelationships(res *resource.Resource, included map[string]bool) *resource.Resource {
+	kept := make([]resource.Relationship, 0, len(res.Relationships))
+	for _, rel := range res.Relationships {
+		if included[rel.TargetID] {
+			kept = append(kept, rel)
+		}
+	}
+	if len(kept) == len(res.Relationships) {
+		return res
+	}
+
+	pruned := *res
+	pruned.Relationships = kept
+	return &pruned
+}
+
+// parseKeyValues parses a comma-separated list of key=value pairs. Repeated keys are accumulated.
+func parseKeyValues(expr string, allowed ...string) (map[string][]string, error) {
+	allowedSet := make(map[string]bool)
+	for _, key := range allowed {
+		allowedSet[key] = true
+	}
+
+	values := make(map[string][]string)
+	for _, part := range strings.Split(expr, ",") {
+		part = strings.TrimSpace(part)
+		if part == "" {
+			continue
+		}
+		key, value, ok := strings.Cut(part, "=")
+		if !ok {
+			return nil, fmt.Errorf("expected key=value but found '%s'", part)
+		}
+		if !allowedSet[key] {
+			return nil, fmt.Errorf("unknown key '%s', expected one of: %s", key, strings.Join(allowed, ", "))
+		}
+		values[key] = append(values[key], value)
+	}
+
+	return values, nil
+}
+
+// parseDirection parses a traversal direction
+func parseDirection(s string, defaultDirection resource.Direction) (resource.Direction, error) {
+	switch resource.Direction(s) {
+	case "":
+		return defaultDirection, nil
+	case resource.DirectionOutgoing, resource.DirectionIncoming, resource.DirectionBoth:
+		return resource.Direction(s), nil
+	}
+	return "", fmt.Errorf("invalid direction '%s', expected out, in or any", s)
+}
+
+// lastValue returns the last value for a key, or an empty string
+func lastValue(values map[string][]string, key string) string {
+	if v := values[key]; len(v) > 0 {
+		return v[len(v)-1]
+	}
+	return ""
+}
+
+// ParseRelatedFilter parses related-to filter expressions
+// Examples:
+//   - "id=vpc-123" -> resources with any relationship to vpc-123
+//   - "id=vpc-123,type=belongs_to,direction=out" -> resources that belong to vpc-123
+//   - "id=sg-123,type=attached_to,direction=in" -> resources sg-123 is attached to
+func ParseRelatedFilter(expr string) (*RelatedFilter, error) {
+	values, err := parseKeyValues(expr, "id", "type", "direction")
+	if err != nil {
+		return nil, err
+	}
+
+	id := lastValue(values, "id")
+	if id == "" {
+		return nil, fmt.Errorf("missing target id (id=...)")
+	}
+
+	direction, err := parseDirection(lastValue(values, "direction"), resource.DirectionBoth)
+	if err != nil {
+		return nil, err
+	}
+
+	return &RelatedFilter{
+		TargetID:     id,
+		RelationType: resource.RelationType(lastValue(values, "type")),
+		Direction:    direction,
+	}, nil
+}
+
+// ParseNeighborhoodFilter parses neighborhood filter expressions
+// Examples:
+//   - "id=arn:aws:iam::123:role/app,depth=2" -> resources within 2 hops of the role
+//   - "id=vpc-1,id=vpc-2,depth=1,direction=in,type=belongs_to" -> resources belonging to either VPC
+func ParseNeighborhoodFilter(expr string) (*NeighborhoodFilter, error) {
+	values, err := parseKeyValues(expr, "id", "depth", "type", "direction")
+	if err != nil {
+		return nil, err
+	}
+
+	if len(values["id"]) == 0 {
+		return nil, fmt.Errorf("missing seed id (id=...)")
+	}
+
+	depth := 1
+	if depthStr := lastValue(values, "depth"); depthStr != "" {
+		depth, err = strconv.Atoi(depthStr)
+		if err != nil || depth < 0 {
+			return nil, fmt.Errorf("invalid depth '%s'", depthStr)
+		}
+	}
+
+	direction, err := parseDirection(lastValue(values, "direction"), resource.DirectionBoth)
+	if err != nil {
+		return nil, err
+	}
+
+	var types []resource.RelationType
+	for _, t := range values["type"] {
+		types = append(types, resource.RelationType(t))
+	}
+
+	return &NeighborhoodFilter{
+		Seeds:         values["id"],
+		Depth:         depth,
+		Direction:     direction,
+		RelationTypes: types,
+	}, nil
+}
+
+// ParseIncomingFilter parses incoming relationship filter expressions
+// Examples:
+//   - "type=belongs_to,from=aws:ec2:instance" -> resources an EC2 instance belongs to
+//   - "from=aws:lambda:function" -> resources referenced by any Lambda function
+func ParseIncomingFilter(expr string) (*IncomingFilter, error) {
+	values, err := parseKeyValues(expr, "type", "from")
+	if err != nil {
+		return nil, err
+	}
+
+	return &IncomingFilter{
+		RelationType: resource.RelationType(lastValue(values, "type")),
+		SourceType:   resource.ResourceType(lastValue(values, "from")),
+	}, nil
+}
@@ -0,0 +1,92 @@
+package filter
+
+import (
+	"sort"
+	"testing"
+
+	"github.com/comfortablynumb/pmp-cloud-inspector/pkg/resource"
+)
+
+// networkCollection returns a VPC with a subnet and a security group attached to the two
+// instances of the subnet
+func networkCollection() *resource.Collection {
+	rel := func(relType resource.RelationType, id string, targetType resource.ResourceType) resource.Relationship {
+		return resource.Relationship{Type: relType, TargetID: id, TargetType: targetType}
+	}
+
+	collection := resource.NewCollection()
+	for _, res := range []*resource.Resource{
+		{ID: "vpc-1", Type: resource.TypeAWSVPC, Relationships: []resource.Relationship{
+			rel(resource.RelationContains, "subnet-1", resource.TypeAWSSubnet),
+		}},
+		{ID: "subnet-1", Type: resource.TypeAWSSubnet, Relationships: []resource.Relationship{
+			rel(resource.RelationBelongsTo, "vpc-1", resource.TypeAWSVPC),
+		}},
+		{ID: "sg-1", Type: resource.TypeAWSSecurityGroup, Relationships: []resource.Relationship{
+			rel(resource.RelationBelongsTo, "vpc-1", resource.TypeAWSVPC),
+			rel(resource.RelationAttachedTo, "i-web", resource.TypeAWSEC2Instance),
+			rel(resource.RelationAttachedTo, "i-db", resource.TypeAWSEC2Instance),
+		}},
+		{ID: "i-web", Type: resource.TypeAWSEC2Instance, Relationships: []resource.Relationship{
+			rel(resource.RelationBelongsTo, "subnet-1", resource.TypeAWSSubnet),
+			rel(resource.RelationReferences, "sg-1", resource.TypeAWSSecurityGroup),
+		}},
+		{ID: "i-db", Type: resource.TypeAWSEC2Instance, Relationships: []resource.Relationship{
+			rel(resource.RelationBelongsTo, "subnet-1", resource.TypeAWSSubnet),
+		}},
+	} {
+		collection.Add(res)
+	}
+	return collection
+}
+
+func TestIncludeRelated(t *testing.T) {
+	tests := []struct {
+		name    string
+		ids     []string
+		wantIDs []string
+	}{
+		{"instance", []string{"i-web"}, []string{"i-web", "sg-1", "subnet-1"}},
+		{"subnet", []string{"subnet-1"}, []string{"subnet-1", "vpc-1"}},
+		{"security group", []string{"sg-1"}, []string{"i-db", "i-web", "sg-1", "vpc-1"}},
+		{"instances", []string{"i-web", "i-db"}, []string{"i-db", "i-web", "sg-1", "subnet-1"}},
+		{"nothing", nil, nil},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			source := networkCollection()
+			filtered := resource.NewCollection()
+			for _, id := range tt.ids {
+				filtered.Add(source.Get(id))
+			}
+
+			result := IncludeRelated(filtered, source)
+
+			var ids []string
+			for _, res := range result.Resources {
+				ids = append(ids, res.ID)
+			}
+			sort.Strings(ids)
+			if !equalNames(ids, tt.wantIDs) {
+				t.Errorf("got resources %v, want %v", ids, tt.wantIDs)
+			}
+
+			// The exported subgraph is self-consistent
+			for _, res := range result.Resources {
+				for _, rel := range res.Relationships {
+					if result.Get(rel.TargetID) == nil {
+						t.Errorf("%s has a relationship to %s, which is not in the result", res.ID, rel.TargetID)
+					}
+				}
+			}
+
+			// The source collection is left untouched
+			for id, want := range map[string]int{"vpc-1": 1, "subnet-1": 1, "sg-1": 3, "i-web": 2, "i-db": 1} {
+				if got := len(source.Get(id).Relationships); got != want {
+					t.Errorf("%s of the source has %d relationships, want %d", id, got, want)
+				}
+			}
+		})
+	}
+}
@@ -269,14 +269,40 @@ func parseValue(s string) interface{} {
 	return s
 }
 
-// ParseCostFilter parses cost filter expressions
+// currencyCodePattern matches an ISO 4217 currency code suffix of a cost filter expression
+var currencyCodePattern = regexp.MustCompile(`^[A-Za-z]{3}$`)
+
+// ParseCostFilter parses cost filter expressions. The cost compared is the monthly estimate,
+// or a breakdown component when prefixed with "component:". A trailing currency code restricts
+// matches to costs in that currency.
 // Examples:
 //   - "100..500" -> between 100 and 500
 //   - ">100" -> greater than 100
 //   - "<500" -> less than 500
+//   - "storage:>20" -> storage component greater than 20
+//   - ">100 EUR" -> greater than 100, in EUR only
 func ParseCostFilter(expr string) (*CostFilter, error) {
 	filter := &CostFilter{}
 
+	expr = strings.TrimSpace(expr)
+
+	// Handle currency suffix: >100 USD. Only a currency code is stripped, so that spaces
+	// within the expression (e.g., "> 100") are allowed.
+	if idx := strings.LastIndex(expr, " "); idx != -1 && currencyCodePattern.MatchString(expr[idx+1:]) {
+		filter.Currency = strings.ToUpper(expr[idx+1:])
+		expr = strings.TrimSpace(expr[:idx])
+	}
+
+	// Handle component prefix: compute:>100
+	if component, rest, ok := strings.Cut(expr, ":"); ok {
+		component = strings.TrimSpace(component)
+		if component == "" {
+			return nil, fmt.Errorf("empty cost component in: %s", expr)
+		}
+		filter.Component = component
+		expr = strings.TrimSpace(rest)
+	}
+
 	// Handle range: 100..500
 	if strings.Contains(expr, "..") {
 		parts := strings.Split(expr, "..")
@@ -284,13 +310,13 @@ func ParseCostFilter(expr string) (*CostFilter, error) {
 			return nil, fmt.Errorf("invalid cost range: %s", expr)
 		}
 
-		min, err := strconv.ParseFloat(parts[0], 64)
+		min, err := strconv.ParseFloat(strings.TrimSpace(parts[0]), 64)
 		if err != nil {
 			return nil, fmt.Errorf("invalid min cost: %w", err)
 		}
 		filter.MinCost = &min
 
-		max, err := strconv.ParseFloat(parts[1], 64)
+		max, err := strconv.ParseFloat(strings.TrimSpace(parts[1]), 64)
 		if err != nil {
 			return nil, fmt.Errorf("invalid max cost: %w", err)
 		}
@@ -299,9 +325,9 @@ func ParseCostFilter(expr string) (*CostFilter, error) {
 		return filter, nil
 	}
 
-	// Handle comparisons
+	// Handle comparisons (bounds are inclusive)
 	if strings.HasPrefix(expr, ">") {
-		costStr := strings.TrimPrefix(expr, ">")
+		costStr := strings.TrimSpace(strings.TrimPrefix(strings.TrimPrefix(expr, ">"), "="))
 		min, err := strconv.ParseFloat(costStr, 64)
 		if err != nil {
 			return nil, fmt.Errorf("invalid cost: %w", err)
@@ -311,7 +337,7 @@ func ParseCostFilter(expr string) (*CostFilter, error) {
 	}
 
 	if strings.HasPrefix(expr, "<") {
-		costStr := strings.TrimPrefix(expr, "<")
+		costStr := strings.TrimSpace(strings.TrimPrefix(strings.TrimPrefix(expr, "<"), "="))
 		max, err := strconv.ParseFloat(costStr, 64)
 		if err != nil {
 			return nil, fmt.Errorf("invalid cost: %w", err)
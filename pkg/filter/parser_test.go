@@ -0,0 +1,65 @@
+package filter
+
+import "testing"
+
+func TestParseCostFilter(t *testing.T) {
+	floatPtr := func(f float64) *float64 { return &f }
+
+	tests := []struct {
+		expr    string
+		want    CostFilter
+		wantErr bool
+	}{
+		{expr: ">100", want: CostFilter{MinCost: floatPtr(100)}},
+		{expr: "> 100", want: CostFilter{MinCost: floatPtr(100)}},
+		{expr: ">= 100", want: CostFilter{MinCost: floatPtr(100)}},
+		{expr: "<500", want: CostFilter{MaxCost: floatPtr(500)}},
+		{expr: "100..500", want: CostFilter{MinCost: floatPtr(100), MaxCost: floatPtr(500)}},
+		{expr: "100 .. 500", want: CostFilter{MinCost: floatPtr(100), MaxCost: floatPtr(500)}},
+		{expr: "storage:>20", want: CostFilter{MinCost: floatPtr(20), Component: "storage"}},
+		{expr: "compute: >100", want: CostFilter{MinCost: floatPtr(100), Component: "compute"}},
+		{expr: ">100 EUR", want: CostFilter{MinCost: floatPtr(100), Currency: "EUR"}},
+		{expr: "> 100 eur", want: CostFilter{MinCost: floatPtr(100), Currency: "EUR"}},
+		{expr: "compute: 10..20 GBP", want: CostFilter{MinCost: floatPtr(10), MaxCost: floatPtr(20), Component: "compute", Currency: "GBP"}},
+		{expr: ">100 EURO", wantErr: true},
+		{expr: ">", wantErr: true},
+		{expr: ":>100", wantErr: true},
+		{expr: "100", wantErr: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.expr, func(t *testing.T) {
+			got, err := ParseCostFilter(tt.expr)
+			if tt.wantErr {
+				if err == nil {
+					t.Fatalf("ParseCostFilter(%q) = %+v, want an error", tt.expr, got)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("ParseCostFilter(%q) failed: %v", tt.expr, err)
+			}
+
+			if !equalBound(got.MinCost, tt.want.MinCost) || !equalBound(got.MaxCost, tt.want.MaxCost) {
+				t.Errorf("ParseCostFilter(%q) bounds = [%v, %v], want [%v, %v]", tt.expr, bound(got.MinCost), bound(got.MaxCost), bound(tt.want.MinCost), bound(tt.want.MaxCost))
+			}
+			if got.Component != tt.want.Component || got.Currency != tt.want.Currency {
+				t.Errorf("ParseCostFilter(%q) component %q currency %q, want %q and %q", tt.expr, got.Component, got.Currency, tt.want.Component, tt.want.Currency)
+			}
+		})
+	}
+}
+
+func equalBound(a, b *float64) bool {
+	if a == nil || b == nil {
+		return a == b
+	}
+	return *a == *b
+}
+
+func bound(f *float64) interface{} {
+	if f == nil {
+		return "none"
+	}
+	return *f
+}
@@ -0,0 +1,125 @@
+package filter
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+
+	"github.com/comfortablynumb/pmp-cloud-inspector/pkg/resource"
+)
+
+// SortKey identifies how resources are ordered
+type SortKey string
+
+const (
+	SortByCost      SortKey = "cost" // Most expensive first
+	SortByName      SortKey = "name"
+	SortByType      SortKey = "type"
+	SortByProvider  SortKey = "provider"
+	SortByCreatedAt SortKey = "created_at" // Oldest first
+)
+
+// sortKeys lists the supported sort keys for error messages
+var sortKeys = []string{"cost", "cost.<component>", "name", "type", "provider", "created_at"}
+
+// Sorter orders resources by a sort key
+type Sorter struct {
+	Key       SortKey
+	Component string // Breakdown component when sorting by cost; empty sorts by monthly estimate
+}
+
+// ParseSortKey parses a sort key
+// Examples:
+//   - "cost" -> most expensive first
+//   - "cost.compute" -> highest compute cost first
+//   - "name" -> alphabetical
+func ParseSortKey(expr string) (*Sorter, error) {
+	key, component, _ := strings.Cut(expr, ".")
+
+	switch SortKey(key) {
+	case SortByCost:
+		return &Sorter{Key: SortByCost, Component: component}, nil
+	case SortByName, SortByType, SortByProvider, SortByCreatedAt:
+		if component != "" {
+			return nil, fmt.Errorf("sort key '%s' has no sub-fields", key)
+		}
+		return &Sorter{Key: SortKey(key)}, nil
+	}
+
+	return nil, fmt.Errorf("unknown sort key '%s', expected one of: %s", expr, strings.Join(sortKeys, ", "))
+}
+
+// Sort returns a new collection with the resources of the collection ordered by the sort key.
+// The sort is stable; resources without a value for the key are placed last.
+func (s *Sorter) Sort(collection *resource.Collection) *resource.Collection {
+	resources := make([]*resource.Resource, len(collection.Resources))
+	copy(resources, collection.Resources)
+
+	sort.SliceStable(resources, func(i, j int) bool {
+		return s.less(resources[i], resources[j])
+	})
+
+	sorted := resource.NewCollection()
+	sorted.InheritMetadata(collection)
+	for _, res := range resources {
+		sorted.Add(res)
+	}
+
+	return sorted
+}
+
+func (s *Sorter) less(a, b *resource.Resource) bool {
+	switch s.Key {
+	case SortByCost:
+		costA, okA := resourceCost(a, s.Component)
+		costB, okB := resourceCost(b, s.Component)
+		if okA != okB {
+			return okA
+		}
+		return costA > costB
+	case SortByName:
+		return a.Name < b.Name
+	case SortByType:
+		return a.Type < b.Type
+	case SortByProvider:
+		return a.Provider < b.Provider
+	case SortByCreatedAt:
+		if (a.CreatedAt == nil) != (b.CreatedAt == nil) {
+			return a.CreatedAt != nil
+		}
+		return a.CreatedAt != nil && a.CreatedAt.Before(*b.CreatedAt)
+	}
+	return false
+}
+
+// resourceCost returns the monthly estimate or a breakdown component of a resource's cost
+func resourceCost(res *resource.Resource, component string) (float64, bool) {
+	if res.Cost == nil {
+		return 0, false
+	}
+	if component != "" {
+		cost, ok := res.Cost.Breakdown[component]
+		return cost, ok
+	}
+	return res.Cost.MonthlyEstimate, true
+}
+
+// TopByCost returns a new collection with the n most expensive resources, most expensive first.
+// Resources without cost data are never selected.
+func TopByCost(collection *resource.Collection, n int, component string) *resource.Collection {
+	sorted := (&Sorter{Key: SortByCost, Component: component}).Sort(collection)
+
+	top := resource.NewCollection()
+	top.InheritMetadata(collection)
+	for _, res := range sorted.Resources {
+		if len(top.Resources) >= n {
+			break
+		}
+		if _, ok := resourceCost(res, component); !ok {
+			break
+		}
+		top.Add(res)
+	}
+
+	return top
+}
@@ -0,0 +1,260 @@
+package iampolicy
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/url"
+	"strings"
+)
+
+// Statement effects
+const (
+	EffectAllow = "Allow"
+	EffectDeny  = "Deny"
+)
+
+// Principal types of trust policies
+const (
+	PrincipalAWS       = "AWS"
+	PrincipalService   = "Service"
+	PrincipalFederated = "Federated"
+)
+
+// Document is an IAM policy document
+type Document struct {
+	Version   string     `json:"Version,omitempty"`
+	Statement Statements `json:"Statement"`
+}
+
+// Statement is a statement of a policy document
+type Statement struct {
+	Sid          string                 `json:"Sid,omitempty"`
+	Effect       string                 `json:"Effect"`
+	Principal    Principals             `json:"Principal,omitempty"`
+	NotPrincipal Principals             `json:"NotPrincipal,omitempty"`
+	Action       Values                 `json:"Action,omitempty"`
+	NotAction    Values                 `json:"NotAction,omitempty"`
+	Resource     Values                 `json:"Resource,omitempty"`
+	NotResource  Values                 `json:"NotResource,omitempty"`
+	Condition    map[string]interface{} `json:"Condition,omitempty"`
+}
+
+// Statements are the statements of a policy document, written as a single statement or a list
+type Statements []Statement
+
+// UnmarshalJSON accepts a single statement or a list of statements
+func (s *Statements) UnmarshalJSON(data []byte) error {
+	if len(data) > 0 && data[0] == '{' {
+		var statement Statement
+		if err := json.Unmarshal(data, &statement); err != nil {
+			return err
+		}
+		*s = Statements{statement}
+		return nil
+	}
+
+	var statements []Statement
+	if err := json.Unmarshal(data, &statements); err != nil {
+		return err
+	}
+	*s = statements
+	return nil
+}
+
+// Values are the actions, resources or principals of a statement, written as a single string or
+// a list
+type Values []string
+
+// UnmarshalJSON accepts a single string or a list of strings
+func (v *Values) UnmarshalJSON(data []byte) error {
+	if len(data) > 0 && data[0] == '"' {
+		var value string
+		if err := json.Unmarshal(data, &value); err != nil {
+			return err
+		}
+		*v = Values{value}
+		return nil
+	}
+
+	var values []string
+	if err := json.Unmarshal(data, &values); err != nil {
+		return err
+	}
+	*v = values
+	return nil
+}
+
+// Principals are the principals of a statement by type (AWS, Service, Federated,
+// CanonicalUser). The "*" principal is stored as an AWS principal.
+type Principals map[string]Values
+
+// UnmarshalJSON accepts "*" or principals by type
+func (p *Principals) UnmarshalJSON(data []byte) error {
+	if len(data) > 0 && data[0] == '"' {
+		var value string
+		if err := json.Unmarshal(data, &value); err != nil {
+			return err
+		}
+		*p = Principals{PrincipalAWS: Values{value}}
+		return nil
+	}
+
+	var principals map[string]Values
+	if err := json.Unmarshal(data, &principals); err != nil {
+		return err
+	}
+	*p = principals
+	return nil
+}
+
+// Parse parses a policy document. The documents returned by the IAM API are URL-encoded.
+func Parse(document string) (*Document, error) {
+	document = strings.TrimSpace(document)
+	if !strings.HasPrefix(document, "{") {
+		decoded, err := url.QueryUnescape(document)
+		if err != nil {
+			return nil, fmt.Errorf("failed to decode policy document: %w", err)
+		}
+		document = decoded
+	}
+
+	var doc Document
+	if err := json.Unmarshal([]byte(document), &doc); err != nil {
+		return nil, fmt.Errorf("failed to parse policy document: %w", err)
+	}
+	return &doc, nil
+}
+
+// FromProperty returns the document stored in a resource property, as collected or decoded from
+// an export
+func FromProperty(value interface{}) (*Document, error) {
+	switch v := value.(type) {
+	case nil:
+		return nil, fmt.Errorf("no policy document")
+	case *Document:
+		return v, nil
+	case string:
+		return Parse(v)
+	}
+
+	data, err := json.Marshal(value)
+	if err != nil {
+		return nil, fmt.Errorf("failed to encode policy document: %w", err)
+	}
+	var doc Document
+	if err := json.Unmarshal(data, &doc); err != nil {
+		return nil, fmt.Errorf("failed to parse policy document: %w", err)
+	}
+	return &doc, nil
+}
+
+// ToProperty parses a URL-encoded policy document into a value that can be stored in resource
+// properties and exported as a JSON object
+func ToProperty(document string) (map[string]interface{}, error) {
+	doc, err := Parse(document)
+	if err != nil {
+		return nil, err
+	}
+
+	data, err := json.Marshal(doc)
+	if err != nil {
+		return nil, fmt.Errorf("failed to encode policy document: %w", err)
+	}
+	var value map[string]interface{}
+	if err := json.Unmarshal(data, &value); err != nil {
+		return nil, fmt.Errorf("failed to encode policy document: %w", err)
+	}
+	return value, nil
+}
+
+// matchesAction reports whether a statement applies to an action
+func (s Statement) matchesAction(action string) bool {
+	if len(s.NotAction) > 0 {
+		return !matchAny(s.NotAction, action, true)
+	}
+	return matchAny(s.Action, action, true)
+}
+
+// matchesResource reports whether a statement applies to a resource ARN. Statements without
+// resources, such as those of trust policies, apply to every resource.
+func (s Statement) matchesResource(arn string) bool {
+	if len(s.NotResource) > 0 {
+		return !matchAny(s.NotResource, arn, false)
+	}
+	if len(s.Resource) == 0 {
+		return true
+	}
+	return matchAny(s.Resource, arn, false)
+}
+
+// usesVariables reports whether the resources of a statement use policy variables, such as
+// ${aws:username}, that are matched as wildcards
+func (s Statement) usesVariables() bool {
+	for _, values := range []Values{s.Resource, s.NotResource} {
+		for _, value := range values {
+			if strings.Contains(value, "${") {
+				return true
+			}
+		}
+	}
+	return false
+}
+
+// matchAny reports whether a value matches one of the patterns
+func matchAny(patterns []string, value string, ignoreCase bool) bool {
+	for _, pattern := range patterns {
+		if ignoreCase {
+			if Match(strings.ToLower(pattern), strings.ToLower(value)) {
+				return true
+			}
+		} else if Match(pattern, value) {
+			return true
+		}
+	}
+	return false
+}
+
+// Match reports whether a value matches a pattern of IAM policies, where * matches any
+// sequence of characters and ? any single character. Policy variables such as ${aws:username}
+// match any sequence of characters.
+func Match(pattern, value string) bool {
+	pattern = replaceVariables(pattern)
+
+	p, v := 0, 0
+	star, next := -1, 0
+	for v < len(value) {
+		switch {
+		case p < len(pattern) && (pattern[p] == '?' || pattern[p] == value[v]):
+			p++
+			v++
+		case p < len(pattern) && pattern[p] == '*':
+			star, next = p, v
+			p++
+		case star >= 0:
+			p = star + 1
+			next++
+			v = next
+		default:
+			return false
+		}
+	}
+	for p < len(pattern) && pattern[p] == '*' {
+		p++
+	}
+	return p == len(pattern)
+}
+
+// replaceVariables replaces the policy variables of a pattern by *
+func replaceVariables(pattern string) string {
+	for {
+		start := strings.Index(pattern, "${")
+		if start < 0 {
+			return pattern
+		}
+		end := strings.Index(pattern[start:], "}")
+		if end < 0 {
+			return pattern
+		}
+		pattern = pattern[:start] + "*" + pattern[start+end+1:]
+	}
+}
@@ -0,0 +1,326 @@
+package iampolicy
+
+import (
+	"fmt"
+	"slices"
+	"sort"
+	"strings"
+
+	"github.com/comfortablynumb/pmp-cloud-inspector/pkg/resource"
+)
+
+// Decisions of principals allowed to perform an action
+const (
+	DecisionAllowed     = "allowed"     // allowed by a statement without conditions, and not denied
+	DecisionConditional = "conditional" // allowed depending on conditions or policy variables
+)
+
+// AdministratorAccess is the ARN of the AWS managed policy granting every action on every
+// resource
+const AdministratorAccess = "arn:aws:iam::aws:policy/AdministratorAccess"
+
+// Result is a principal allowed to perform an action on a resource by its identity-based
+// policies
+type Result struct {
+	PrincipalID   string                `json:"principal_id"`
+	PrincipalType resource.ResourceType `json:"principal_type"`
+	Principal     string                `json:"principal"`
+	Account       string                `json:"account,omitempty"`
+	Decision      string                `json:"decision"`
+	Policies      []string              `json:"policies"` // policies allowing the action, e.g., ReadOnly (via group Developers)
+
+	res *resource.Resource
+}
+
+// Evaluator evaluates the identity-based policies of the IAM users and roles of a collection:
+// their attached managed policies, their inline policies and those of the groups of users.
+// Permissions boundaries, service control policies, session policies and resource-based
+// policies are not evaluated.
+type Evaluator struct {
+	principals []*principal
+
+	// Missing lists the managed policies attached to principals whose document was not collected
+	Missing []string
+}
+
+// principal is an IAM user or role with its policies
+type principal struct {
+	res      *resource.Resource
+	policies []policy
+}
+
+// policy is a policy of a principal
+type policy struct {
+	name     string
+	arn      string // managed policies only
+	via      string // group the policy is attached to
+	document *Document
+}
+
+// label returns the name of a policy, with the group it is inherited from
+func (p policy) label() string {
+	if p.via != "" {
+		return fmt.Sprintf("%s (via group %s)", p.name, p.via)
+	}
+	return p.name
+}
+
+// NewEvaluator indexes the policies of the IAM users and roles of a collection
+func NewEvaluator(collection *resource.Collection) *Evaluator {
+	e := &Evaluator{}
+
+	// The document of AdministratorAccess is known, even when the policy was not collected
+	documents := map[string]*Document{
+		AdministratorAccess: {Version: "2012-10-17", Statement: Statements{{Effect: EffectAllow, Action: Values{"*"}, Resource: Values{"*"}}}},
+	}
+	for _, res := range collection.Resources {
+		if res.Type != resource.TypeAWSIAMPolicy {
+			continue
+		}
+		if doc, err := FromProperty(res.Properties[resource.PropPolicyDocument]); err == nil {
+			documents[res.ID] = doc
+		}
+	}
+
+	missing := make(map[string]bool)
+	policiesOf := func(res *resource.Resource, via string) []policy {
+		var policies []policy
+		for _, rel := range res.Relationships {
+			if rel.Type != resource.RelationHasAccess || rel.TargetType != resource.TypeAWSIAMPolicy {
+				continue
+			}
+			if policyType, _ := rel.Properties["policy_type"].(string); policyType == "inline" {
+				continue
+			}
+			name, _ := rel.Properties[resource.PropPermission].(string)
+			if name == "" {
+				name = rel.TargetID
+			}
+			p := policy{name: name, arn: rel.TargetID, via: via, document: documents[rel.TargetID]}
+			if p.document == nil {
+				missing[rel.TargetID] = true
+			}
+			policies = append(policies, p)
+		}
+		for _, inline := range res.MapsProperty(resource.PropInlinePolicies) {
+			name, _ := inline["name"].(string)
+			doc, err := FromProperty(inline["document"])
+			if err != nil {
+				continue
+			}
+			policies = append(policies, policy{name: name, via: via, document: doc})
+		}
+		return policies
+	}
+
+	// Policies of groups, by member
+	inherited := make(map[string][]policy)
+	for _, res := range collection.Resources {
+		if res.Type != resource.TypeAWSIAMGroup {
+			continue
+		}
+		policies := policiesOf(res, res.Name)
+		for _, rel := range res.Relationships {
+			if rel.Type == resource.RelationContains && rel.TargetType == resource.TypeAWSIAMUser {
+				inherited[rel.TargetID] = append(inherited[rel.TargetID], policies...)
+			}
+		}
+	}
+
+	for _, res := range collection.Resources {
+		switch res.Type {
+		case resource.TypeAWSIAMUser:
+			policies := append(policiesOf(res, ""), inherited[res.ID]...)
+			e.principals = append(e.principals, &principal{res: res, policies: policies})
+		case resource.TypeAWSIAMRole:
+			e.principals = append(e.principals, &principal{res: res, policies: policiesOf(res, "")})
+		}
+	}
+
+	for arn := range missing {
+		e.Missing = append(e.Missing, arn)
+	}
+	sort.Strings(e.Missing)
+
+	return e
+}
+
+// WhoCan returns the principals allowed to perform an action on a resource ARN, sorted by type
+// and name. Principals denied by a statement without conditions are left out.
+func (e *Evaluator) WhoCan(action, arn string) []Result {
+	var results []Result
+	for _, p := range e.principals {
+		decision, policies := p.evaluate(action, arn)
+		if decision == "" {
+			continue
+		}
+		results = append(results, Result{
+			PrincipalID:   p.res.ID,
+			PrincipalType: p.res.Type,
+			Principal:     p.res.Name,
+			Account:       p.res.Account,
+			Decision:      decision,
+			Policies:      policies,
+			res:           p.res,
+		})
+	}
+
+	sort.SliceStable(results, func(i, j int) bool {
+		if results[i].PrincipalType != results[j].PrincipalType {
+			return results[i].PrincipalType > results[j].PrincipalType // users first
+		}
+		return results[i].Principal < results[j].Principal
+	})
+	return results
+}
+
+// evaluate returns the decision of the policies of a principal for an action on a resource,
+// with the policies allowing it, or an empty decision if the action is not allowed
+func (p *principal) evaluate(action, arn string) (string, []string) {
+	var allowing, conditional []string
+	denied, conditionallyDenied := false, false
+
+	for _, pol := range p.policies {
+		if pol.document == nil {
+			continue
+		}
+		for _, statement := range pol.document.Statement {
+			if !statement.matchesAction(action) || !statement.matchesResource(arn) {
+				continue
+			}
+			hasConditions := len(statement.Condition) > 0 || statement.usesVariables()
+
+			switch statement.Effect {
+			case EffectDeny:
+				if hasConditions {
+					conditionallyDenied = true
+				} else {
+					denied = true
+				}
+			case EffectAllow:
+				if hasConditions {
+					conditional = appendUnique(conditional, pol.label())
+				} else {
+					allowing = appendUnique(allowing, pol.label())
+				}
+			}
+		}
+	}
+
+	switch {
+	case denied:
+		return "", nil
+	case len(allowing) > 0 && !conditionallyDenied:
+		return DecisionAllowed, allowing
+	case len(allowing) > 0 || len(conditional) > 0:
+		return DecisionConditional, append(allowing, conditional...)
+	}
+	return "", nil
+}
+
+// IsAdmin reports whether the policies of an IAM user or role allow every action on every
+// resource without conditions, e.g., the AdministratorAccess managed policy
+func (e *Evaluator) IsAdmin(res *resource.Resource) bool {
+	for _, p := range e.principals {
+		if p.res == res {
+			return p.isAdmin()
+		}
+	}
+	return false
+}
+
+// isAdmin reports whether a policy of a principal allows every action on every resource
+func (p *principal) isAdmin() bool {
+	for _, pol := range p.policies {
+		if pol.arn == AdministratorAccess {
+			return true
+		}
+		if pol.document == nil {
+			continue
+		}
+		for _, statement := range pol.document.Statement {
+			if statement.Effect == EffectAllow && len(statement.Condition) == 0 &&
+				len(statement.NotAction) == 0 && len(statement.NotResource) == 0 &&
+				(slices.Contains(statement.Action, "*") || slices.Contains(statement.Action, "*:*")) &&
+				slices.Contains(statement.Resource, "*") {
+				return true
+			}
+		}
+	}
+	return false
+}
+
+// MarkAdmins sets the admin property of the IAM users and roles of a collection allowed every
+// action on every resource, and returns their number
+func MarkAdmins(collection *resource.Collection) int {
+	e := NewEvaluator(collection)
+
+	count := 0
+	for _, p := range e.principals {
+		if p.isAdmin() {
+			if p.res.Properties == nil {
+				p.res.Properties = make(map[string]interface{})
+			}
+			p.res.Properties[resource.PropAdmin] = true
+			count++
+		}
+	}
+	return count
+}
+
+// Attach adds has_access relationships from the principals of results to the resource they
+// can perform an action on, with the action as permission, and returns the number of
+// relationships added. Principals already granted the action on the resource are skipped.
+func Attach(results []Result, target *resource.Resource, action string) int {
+	count := 0
+	for _, r := range results {
+		if r.res == nil || hasGrant(r.res, target, action) {
+			continue
+		}
+		r.res.Relationships = append(r.res.Relationships, resource.Relationship{
+			Type:       resource.RelationHasAccess,
+			TargetID:   target.ID,
+			TargetType: target.Type,
+			Properties: map[string]interface{}{
+				resource.PropPermission: action,
+				resource.PropActions:    []string{action},
+				"decision":              r.Decision,
+				"policies":              r.Policies,
+				"evaluated":             true,
+			},
+		})
+		count++
+	}
+	return count
+}
+
+// hasGrant reports whether a principal already has access to a resource with a permission
+func hasGrant(principal, target *resource.Resource, permission string) bool {
+	for _, rel := range principal.Relationships {
+		if rel.Type == resource.RelationHasAccess && rel.TargetID == target.ID && rel.TargetType == target.Type &&
+			rel.Properties[resource.PropPermission] == permission {
+			return true
+		}
+	}
+	return false
+}
+
+// appendUnique appends a value to a list unless it is already in it
+func appendUnique(values []string, value string) []string {
+	if slices.Contains(values, value) {
+		return values
+	}
+	return append(values, value)
+}
+
+// ResourceARN returns the ARN a resource is evaluated as: its ARN, or the ARN of an S3 bucket
+// given by name
+func ResourceARN(res *resource.Resource) string {
+	if res.ARN != "" {
+		return res.ARN
+	}
+	if res.Type == resource.TypeAWSS3Bucket && !strings.HasPrefix(res.ID, "arn:") {
+		return "arn:aws:s3:::" + res.ID
+	}
+	return res.ID
+}
@@ -0,0 +1,169 @@
+package iampolicy
+
+import (
+	"testing"
+
+	"github.com/comfortablynumb/pmp-cloud-inspector/pkg/resource"
+)
+
+// roleWithPolicy returns an IAM role with an inline policy document
+func roleWithPolicy(t *testing.T, name, document string) *resource.Resource {
+	t.Helper()
+	doc, err := Parse(document)
+	if err != nil {
+		t.Fatalf("failed to parse the policy of %s: %v", name, err)
+	}
+	return &resource.Resource{
+		ID:       "arn:aws:iam::111111111111:role/" + name,
+		Type:     resource.TypeAWSIAMRole,
+		Name:     name,
+		Provider: "aws",
+		Account:  "111111111111",
+		Properties: map[string]interface{}{
+			resource.PropInlinePolicies: []map[string]interface{}{{"name": name + "-policy", "document": doc}},
+		},
+	}
+}
+
+func TestWhoCan(t *testing.T) {
+	const bucket = "arn:aws:s3:::reports/2024/q1.csv"
+
+	tests := []struct {
+		name         string
+		document     string
+		action       string
+		arn          string
+		wantDecision string // empty if the principal is not allowed
+	}{
+		{
+			name:         "allow",
+			document:     `{"Statement": {"Effect": "Allow", "Action": "s3:GetObject", "Resource": "arn:aws:s3:::reports/*"}}`,
+			action:       "s3:GetObject",
+			arn:          bucket,
+			wantDecision: DecisionAllowed,
+		},
+		{
+			name: "explicit deny overrides allow",
+			document: `{"Statement": [
+				{"Effect": "Allow", "Action": "s3:*", "Resource": "*"},
+				{"Effect": "Deny", "Action": "s3:GetObject", "Resource": "arn:aws:s3:::reports/*"}
+			]}`,
+			action: "s3:GetObject",
+			arn:    bucket,
+		},
+		{
+			name: "deny of other resources",
+			document: `{"Statement": [
+				{"Effect": "Allow", "Action": "s3:*", "Resource": "*"},
+				{"Effect": "Deny", "Action": "s3:GetObject", "Resource": "arn:aws:s3:::secrets/*"}
+			]}`,
+			action:       "s3:GetObject",
+			arn:          bucket,
+			wantDecision: DecisionAllowed,
+		},
+		{
+			name: "conditional deny makes allow conditional",
+			document: `{"Statement": [
+				{"Effect": "Allow", "Action": "s3:GetObject", "Resource": "*"},
+				{"Effect": "Deny", "Action": "s3:*", "Resource": "*", "Condition": {"Bool": {"aws:SecureTransport": "false"}}}
+			]}`,
+			action:       "s3:GetObject",
+			arn:          bucket,
+			wantDecision: DecisionConditional,
+		},
+		{
+			name:         "get wildcard matches get action",
+			document:     `{"Statement": {"Effect": "Allow", "Action": "s3:Get*", "Resource": "*"}}`,
+			action:       "s3:GetObject",
+			arn:          bucket,
+			wantDecision: DecisionAllowed,
+		},
+		{
+			name:         "get wildcard ignores action case",
+			document:     `{"Statement": {"Effect": "Allow", "Action": "S3:get*", "Resource": "*"}}`,
+			action:       "s3:GetObjectVersion",
+			arn:          bucket,
+			wantDecision: DecisionAllowed,
+		},
+		{
+			name:     "get wildcard does not match put action",
+			document: `{"Statement": {"Effect": "Allow", "Action": "s3:Get*", "Resource": "*"}}`,
+			action:   "s3:PutObject",
+			arn:      bucket,
+		},
+		{
+			name:     "get wildcard of another service",
+			document: `{"Statement": {"Effect": "Allow", "Action": "s3:Get*", "Resource": "*"}}`,
+			action:   "dynamodb:GetItem",
+			arn:      "arn:aws:dynamodb:us-east-1:111111111111:table/orders",
+		},
+		{
+			name:     "resource wildcard is case sensitive",
+			document: `{"Statement": {"Effect": "Allow", "Action": "s3:GetObject", "Resource": "arn:aws:s3:::Reports/*"}}`,
+			action:   "s3:GetObject",
+			arn:      bucket,
+		},
+		{
+			name:         "not action",
+			document:     `{"Statement": {"Effect": "Allow", "NotAction": "s3:Delete*", "Resource": "*"}}`,
+			action:       "s3:GetObject",
+			arn:          bucket,
+			wantDecision: DecisionAllowed,
+		},
+		{
+			name:         "policy variable",
+			document:     `{"Statement": {"Effect": "Allow", "Action": "s3:GetObject", "Resource": "arn:aws:s3:::reports/${aws:username}/*"}}`,
+			action:       "s3:GetObject",
+			arn:          bucket,
+			wantDecision: DecisionConditional,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			collection := resource.NewCollection()
+			collection.Add(roleWithPolicy(t, "reader", tt.document))
+
+			results := NewEvaluator(collection).WhoCan(tt.action, tt.arn)
+			if tt.wantDecision == "" {
+				if len(results) != 0 {
+					t.Errorf("WhoCan(%s, %s) = %+v, want no principals", tt.action, tt.arn, results)
+				}
+				return
+			}
+			if len(results) != 1 {
+				t.Fatalf("WhoCan(%s, %s) returned %d principals, want 1", tt.action, tt.arn, len(results))
+			}
+			if results[0].Decision != tt.wantDecision {
+				t.Errorf("WhoCan(%s, %s) decision = %s, want %s", tt.action, tt.arn, results[0].Decision, tt.wantDecision)
+			}
+			if len(results[0].Policies) != 1 || results[0].Policies[0] != "reader-policy" {
+				t.Errorf("WhoCan(%s, %s) policies = %v, want [reader-policy]", tt.action, tt.arn, results[0].Policies)
+			}
+		})
+	}
+}
+
+func TestMatch(t *testing.T) {
+	tests := []struct {
+		pattern string
+		value   string
+		want    bool
+	}{
+		{"s3:Get*", "s3:GetObject", true},
+		{"s3:Get*", "s3:Get", true},
+		{"s3:Get*", "s3:PutObject", false},
+		{"s3:Get*", "s3:ListBucket", false},
+		{"s3:*Object", "s3:GetObject", true},
+		{"s3:*Object", "s3:GetObjectAcl", false},
+		{"s3:Get?bject", "s3:GetObject", true},
+		{"*", "iam:PassRole", true},
+		{"arn:aws:s3:::reports/${aws:username}/*", "arn:aws:s3:::reports/alice/a.csv", true},
+	}
+
+	for _, tt := range tests {
+		if got := Match(tt.pattern, tt.value); got != tt.want {
+			t.Errorf("Match(%q, %q) = %v, want %v", tt.pattern, tt.value, got, tt.want)
+		}
+	}
+}
@@ -0,0 +1,170 @@
+package iampolicy
+
+import (
+	"strings"
+
+	"github.com/comfortablynumb/pmp-cloud-inspector/pkg/resource"
+)
+
+// TrustedPrincipal is a principal granted access by a resource-based policy: allowed to assume
+// a role by its trust policy, or to use a KMS key by its key policy
+type TrustedPrincipal struct {
+	Type         string `json:"type"`      // AWS, Service or Federated
+	Principal    string `json:"principal"` // ARN, account ID, service or identity provider
+	Account      string `json:"account,omitempty"`
+	CrossAccount bool   `json:"cross_account"` // AWS principal of another account
+	Conditional  bool   `json:"conditional"`   // the statement has conditions, e.g., sts:ExternalId
+}
+
+// TrustedPrincipals returns the principals allowed to assume a role of an account by its trust
+// policy: the principals of the Allow statements with an sts:AssumeRole* action
+func TrustedPrincipals(doc *Document, account string) []TrustedPrincipal {
+	return allowedPrincipals(doc, account, func(statement Statement) bool {
+		return statement.matchesAction("sts:AssumeRole") || statement.matchesAction("sts:AssumeRoleWithWebIdentity") ||
+			statement.matchesAction("sts:AssumeRoleWithSAML")
+	})
+}
+
+// AllowedPrincipals returns the principals granted any action on a resource of an account by
+// its resource-based policy, such as a KMS key policy: the principals of all the Allow
+// statements
+func AllowedPrincipals(doc *Document, account string) []TrustedPrincipal {
+	return allowedPrincipals(doc, account, func(Statement) bool { return true })
+}
+
+// allowedPrincipals returns the principals of the Allow statements of a policy matching a filter,
+// without duplicates
+func allowedPrincipals(doc *Document, account string, filter func(Statement) bool) []TrustedPrincipal {
+	var allowed []TrustedPrincipal
+	seen := make(map[string]bool)
+
+	for _, statement := range doc.Statement {
+		if statement.Effect != EffectAllow || !filter(statement) {
+			continue
+		}
+
+		for _, principalType := range []string{PrincipalAWS, PrincipalService, PrincipalFederated} {
+			for _, principal := range statement.Principal[principalType] {
+				key := principalType + ":" + principal
+				if seen[key] {
+					continue
+				}
+				seen[key] = true
+
+				t := TrustedPrincipal{
+					Type:        principalType,
+					Principal:   principal,
+					Conditional: len(statement.Condition) > 0,
+				}
+				if principalType == PrincipalAWS {
+					t.Account = PrincipalAccount(principal)
+					t.CrossAccount = t.Account != "" && t.Account != account
+				}
+				allowed = append(allowed, t)
+			}
+		}
+	}
+
+	return allowed
+}
+
+// PrincipalAccount returns the account of an AWS principal: an account ID, or the account of an
+// ARN such as arn:aws:iam::123456789012:root
+func PrincipalAccount(principal string) string {
+	if isAccountID(principal) {
+		return principal
+	}
+	parts := strings.SplitN(principal, ":", 6)
+	if len(parts) == 6 && parts[0] == "arn" && isAccountID(parts[4]) {
+		return parts[4]
+	}
+	return ""
+}
+
+// isAccountID reports whether a value is a 12 digit AWS account ID
+func isAccountID(value string) bool {
+	if len(value) != 12 {
+		return false
+	}
+	for _, c := range value {
+		if c < '0' || c > '9' {
+			return false
+		}
+	}
+	return true
+}
+
+// LinkTrusts adds assumes relationships from the IAM users, roles and accounts of a collection
+// to the roles whose trust policy allows them, and returns the number of relationships added.
+// Trusts of services and identity providers, and of principals that were not collected, are
+// only listed in the trusted_principals property of roles.
+func LinkTrusts(collection *resource.Collection) int {
+	type key struct {
+		Type resource.ResourceType
+		ID   string
+	}
+	principals := make(map[string]*resource.Resource)
+	accounts := make(map[string]*resource.Resource)
+	for _, res := range collection.Resources {
+		switch res.Type {
+		case resource.TypeAWSIAMUser, resource.TypeAWSIAMRole:
+			principals[res.ID] = res
+		case resource.TypeAWSAccount:
+			accounts[res.ID] = res
+		}
+	}
+
+	count := 0
+	linked := make(map[key]map[string]bool)
+	for _, role := range collection.Resources {
+		if role.Type != resource.TypeAWSIAMRole {
+			continue
+		}
+		for _, trusted := range role.MapsProperty(resource.PropTrustedPrincipals) {
+			if principalType, _ := trusted["type"].(string); principalType != PrincipalAWS {
+				continue
+			}
+			name, _ := trusted["principal"].(string)
+
+			source := principals[name]
+			if source == nil {
+				if account := PrincipalAccount(name); account != "" && (account == name || strings.HasSuffix(name, ":root")) {
+					source = accounts[account]
+				}
+			}
+			if source == nil || source == role {
+				continue
+			}
+
+			// Skip the relationships already discovered
+			sourceKey := key{source.Type, source.ID}
+			if linked[sourceKey] == nil {
+				linked[sourceKey] = make(map[string]bool)
+				for _, rel := range source.Relationships {
+					if rel.Type == resource.RelationAssumes && rel.TargetType == resource.TypeAWSIAMRole {
+						linked[sourceKey][rel.TargetID] = true
+					}
+				}
+			}
+			if linked[sourceKey][role.ID] {
+				continue
+			}
+			linked[sourceKey][role.ID] = true
+
+			crossAccount, _ := trusted["cross_account"].(bool)
+			conditional, _ := trusted["conditional"].(bool)
+			source.Relationships = append(source.Relationships, resource.Relationship{
+				Type:       resource.RelationAssumes,
+				TargetID:   role.ID,
+				TargetType: resource.TypeAWSIAMRole,
+				Properties: map[string]interface{}{
+					"cross_account": crossAccount,
+					"conditional":   conditional,
+				},
+			})
+			count++
+		}
+	}
+
+	return count
+}
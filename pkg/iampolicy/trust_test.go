@@ -0,0 +1,175 @@
+package iampolicy
+
+import (
+	"testing"
+
+	"github.com/comfortablynumb/pmp-cloud-inspector/pkg/resource"
+)
+
+const (
+	localAccount    = "111111111111"
+	externalAccount = "222222222222"
+)
+
+// trustingRole returns an IAM role of the local account with the trusted principals of a trust
+// policy, stored as the AWS collector does
+func trustingRole(t *testing.T, name, trustPolicy string) *resource.Resource {
+	t.Helper()
+	doc, err := Parse(trustPolicy)
+	if err != nil {
+		t.Fatalf("failed to parse the trust policy of %s: %v", name, err)
+	}
+
+	var trusted []map[string]interface{}
+	for _, p := range TrustedPrincipals(doc, localAccount) {
+		trusted = append(trusted, map[string]interface{}{
+			"type":          p.Type,
+			"principal":     p.Principal,
+			"account":       p.Account,
+			"cross_account": p.CrossAccount,
+			"conditional":   p.Conditional,
+		})
+	}
+
+	return &resource.Resource{
+		ID:         "arn:aws:iam::" + localAccount + ":role/" + name,
+		Type:       resource.TypeAWSIAMRole,
+		Name:       name,
+		Provider:   "aws",
+		Account:    localAccount,
+		Properties: map[string]interface{}{resource.PropTrustedPrincipals: trusted},
+	}
+}
+
+func TestTrustedPrincipals(t *testing.T) {
+	doc, err := Parse(`{"Statement": [
+		{"Effect": "Allow", "Principal": {"AWS": ["arn:aws:iam::222222222222:root", "arn:aws:iam::111111111111:user/alice"]}, "Action": "sts:AssumeRole"},
+		{"Effect": "Allow", "Principal": {"AWS": "333333333333"}, "Action": "sts:AssumeRole", "Condition": {"StringEquals": {"sts:ExternalId": "x"}}},
+		{"Effect": "Allow", "Principal": {"Service": "ec2.amazonaws.com"}, "Action": "sts:AssumeRole"},
+		{"Effect": "Allow", "Principal": {"AWS": "arn:aws:iam::444444444444:root"}, "Action": "sts:TagSession"},
+		{"Effect": "Deny", "Principal": {"AWS": "arn:aws:iam::555555555555:root"}, "Action": "sts:AssumeRole"}
+	]}`)
+	if err != nil {
+		t.Fatalf("Parse failed: %v", err)
+	}
+
+	want := []TrustedPrincipal{
+		{Type: PrincipalAWS, Principal: "arn:aws:iam::222222222222:root", Account: externalAccount, CrossAccount: true},
+		{Type: PrincipalAWS, Principal: "arn:aws:iam::111111111111:user/alice", Account: localAccount},
+		{Type: PrincipalAWS, Principal: "333333333333", Account: "333333333333", CrossAccount: true, Conditional: true},
+		{Type: PrincipalService, Principal: "ec2.amazonaws.com"},
+	}
+	got := TrustedPrincipals(doc, localAccount)
+	if len(got) != len(want) {
+		t.Fatalf("got trusted principals %+v, want %+v", got, want)
+	}
+	for i := range want {
+		if got[i] != want[i] {
+			t.Errorf("trusted principal %d: got %+v, want %+v", i, got[i], want[i])
+		}
+	}
+}
+
+func TestLinkTrusts(t *testing.T) {
+	external := &resource.Resource{ID: externalAccount, Type: resource.TypeAWSAccount, Provider: "aws", Account: externalAccount}
+	alice := &resource.Resource{ID: "arn:aws:iam::" + localAccount + ":user/alice", Type: resource.TypeAWSIAMUser, Name: "alice", Provider: "aws", Account: localAccount}
+	deployer := &resource.Resource{ID: "arn:aws:iam::" + externalAccount + ":role/deployer", Type: resource.TypeAWSIAMRole, Name: "deployer", Provider: "aws", Account: externalAccount}
+
+	tests := []struct {
+		name        string
+		trustPolicy string
+		source      *resource.Resource // nil if no relationship is expected
+		wantCross   bool
+		wantCond    bool
+	}{
+		{
+			name:        "cross-account root",
+			trustPolicy: `{"Statement": {"Effect": "Allow", "Principal": {"AWS": "arn:aws:iam::222222222222:root"}, "Action": "sts:AssumeRole"}}`,
+			source:      external,
+			wantCross:   true,
+		},
+		{
+			name:        "cross-account ID with external ID",
+			trustPolicy: `{"Statement": {"Effect": "Allow", "Principal": {"AWS": "222222222222"}, "Action": "sts:AssumeRole", "Condition": {"StringEquals": {"sts:ExternalId": "x"}}}}`,
+			source:      external,
+			wantCross:   true,
+			wantCond:    true,
+		},
+		{
+			name:        "cross-account role",
+			trustPolicy: `{"Statement": {"Effect": "Allow", "Principal": {"AWS": "arn:aws:iam::222222222222:role/deployer"}, "Action": "sts:AssumeRole"}}`,
+			source:      deployer,
+			wantCross:   true,
+		},
+		{
+			name:        "same-account user",
+			trustPolicy: `{"Statement": {"Effect": "Allow", "Principal": {"AWS": "arn:aws:iam::111111111111:user/alice"}, "Action": "sts:AssumeRole"}}`,
+			source:      alice,
+		},
+		{
+			name:        "principal of an uncollected account",
+			trustPolicy: `{"Statement": {"Effect": "Allow", "Principal": {"AWS": "arn:aws:iam::999999999999:root"}, "Action": "sts:AssumeRole"}}`,
+		},
+		{
+			name:        "user of an account is not the account",
+			trustPolicy: `{"Statement": {"Effect": "Allow", "Principal": {"AWS": "arn:aws:iam::222222222222:user/bob"}, "Action": "sts:AssumeRole"}}`,
+		},
+		{
+			name:        "service",
+			trustPolicy: `{"Statement": {"Effect": "Allow", "Principal": {"Service": "lambda.amazonaws.com"}, "Action": "sts:AssumeRole"}}`,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			// Fresh copies, so relationships of other cases don't leak
+			sources := []*resource.Resource{
+				{ID: external.ID, Type: external.Type, Provider: external.Provider, Account: external.Account},
+				{ID: alice.ID, Type: alice.Type, Name: alice.Name, Provider: alice.Provider, Account: alice.Account},
+				{ID: deployer.ID, Type: deployer.Type, Name: deployer.Name, Provider: deployer.Provider, Account: deployer.Account},
+			}
+			role := trustingRole(t, "target", tt.trustPolicy)
+
+			collection := resource.NewCollection()
+			for _, res := range append(sources, role) {
+				collection.Add(res)
+			}
+
+			count := LinkTrusts(collection)
+
+			var linked *resource.Resource
+			var rel resource.Relationship
+			for _, res := range sources {
+				for _, r := range res.Relationships {
+					if r.Type == resource.RelationAssumes && r.TargetID == role.ID {
+						linked, rel = res, r
+					}
+				}
+			}
+
+			if tt.source == nil {
+				if count != 0 || linked != nil {
+					t.Errorf("LinkTrusts added %d relationships, want none (from %v)", count, linked)
+				}
+				return
+			}
+			if count != 1 || linked == nil {
+				t.Fatalf("LinkTrusts added %d relationships, want 1", count)
+			}
+			if linked.ID != tt.source.ID {
+				t.Errorf("got relationship from %s, want %s", linked.ID, tt.source.ID)
+			}
+			if cross, _ := rel.Properties["cross_account"].(bool); cross != tt.wantCross {
+				t.Errorf("got cross_account %v, want %v", cross, tt.wantCross)
+			}
+			if conditional, _ := rel.Properties["conditional"].(bool); conditional != tt.wantCond {
+				t.Errorf("got conditional %v, want %v", conditional, tt.wantCond)
+			}
+
+			// Linking again doesn't duplicate the relationship
+			if again := LinkTrusts(collection); again != 0 {
+				t.Errorf("second LinkTrusts added %d relationships, want 0", again)
+			}
+		})
+	}
+}
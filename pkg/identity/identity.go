@@ -0,0 +1,640 @@
+package identity
+
+import (
+	"fmt"
+	"reflect"
+	"regexp"
+	"slices"
+	"sort"
+	"strings"
+
+	"github.com/comfortablynumb/pmp-cloud-inspector/pkg/resource"
+)
+
+// ProviderIdentity is the provider of the synthetic person resources
+const ProviderIdentity = "identity"
+
+// How accounts were linked to a person
+const (
+	MatchedByEmail    = "email"
+	MatchedByUsername = "username"
+	MatchedByMapping  = "mapping"
+)
+
+// minUsernameLength is the length below which usernames are not used to link accounts, as
+// short usernames are too likely to belong to different people
+const minUsernameLength = 3
+
+// userTypes lists the resource types of user accounts
+var userTypes = map[resource.ResourceType]bool{
+	resource.TypeAWSIAMUser: true,
+	resource.TypeGitHubUser: true,
+	resource.TypeGitLabUser: true,
+	resource.TypeJFrogUser:  true,
+	resource.TypeOktaUser:   true,
+	resource.TypeAuth0User:  true,
+}
+
+// IsUserType reports whether a resource type is a user account, correlated into people
+func IsUserType(resourceType resource.ResourceType) bool {
+	return userTypes[resourceType]
+}
+
+// inactiveOktaStatuses are the statuses of Okta users that can no longer sign in
+var inactiveOktaStatuses = map[string]bool{
+	"DEPROVISIONED": true,
+	"SUSPENDED":     true,
+}
+
+// workforceProviders are the providers whose accounts are expected to belong to people of the
+// Okta directory. Auth0 users are usually customers and are not checked.
+var workforceProviders = map[string]bool{
+	"aws":    true,
+	"github": true,
+	"gitlab": true,
+	"jfrog":  true,
+}
+
+// Account is the user account of a provider
+type Account struct {
+	ID        string                `json:"id"`
+	Type      resource.ResourceType `json:"type"`
+	Name      string                `json:"name"`
+	Provider  string                `json:"provider"`
+	Account   string                `json:"account,omitempty"` // AWS account or GitHub organization
+	Status    string                `json:"status,omitempty"`
+	Active    bool                  `json:"active"`
+	Admin     bool                  `json:"admin"`
+	MatchedBy []string              `json:"matched_by,omitempty"` // how the account was linked to the other accounts of its person
+}
+
+// Deactivated is a person deactivated in Okta with active accounts in other providers
+type Deactivated struct {
+	Person     string    `json:"person"`
+	Name       string    `json:"name"`
+	OktaStatus string    `json:"okta_status"`
+	Accounts   []Account `json:"accounts"`
+}
+
+// MultiAdmin is a person with administrator privileges in several providers
+type MultiAdmin struct {
+	Person    string    `json:"person"`
+	Name      string    `json:"name"`
+	Providers []string  `json:"providers"`
+	Accounts  []Account `json:"accounts"`
+}
+
+// Report lists the accounts that need review after correlation
+type Report struct {
+	Accounts    int           `json:"accounts"`     // user accounts of every provider
+	People      int           `json:"people"`       // identity:person resources
+	Linked      int           `json:"linked"`       // accounts linked to at least one other account
+	OktaUsers   int           `json:"okta_users"`   // orphans are only reported when Okta users were collected
+	Orphans     []Account     `json:"orphans"`      // active AWS, GitHub, GitLab and JFrog accounts without an Okta counterpart
+	Deactivated []Deactivated `json:"deactivated"`  // people deactivated in Okta with active accounts elsewhere
+	MultiAdmins []MultiAdmin  `json:"multi_admins"` // people with administrator privileges in several providers
+}
+
+// Result is the outcome of a correlation: the person resources and the review report
+type Result struct {
+	People []*resource.Resource
+	Report *Report
+}
+
+// Correlator links the user accounts of every provider that belong to the same person
+type Correlator struct {
+	settings Settings
+	patterns map[string]*regexp.Regexp
+	mappings []Mapping
+}
+
+// NewCorrelator creates a correlator, loading the mapping files of the settings
+func NewCorrelator(settings Settings) (*Correlator, error) {
+	patterns, err := settings.compilePatterns()
+	if err != nil {
+		return nil, err
+	}
+
+	mappings, err := LoadMappings(settings.Mappings)
+	if err != nil {
+		return nil, err
+	}
+
+	return &Correlator{settings: settings, patterns: patterns, mappings: mappings}, nil
+}
+
+// account is a user account with the keys used to link it
+type account struct {
+	Account
+	res       *resource.Resource
+	emails    []string
+	usernames []string
+	matchedBy map[string]bool
+}
+
+// Correlate links the user accounts of a collection by email, username and mappings, and
+// returns a person resource for every group of linked accounts that has more than one account,
+// an Okta account or a mapping. Person resources already in the collection are ignored.
+func (c *Correlator) Correlate(collection *resource.Collection) *Result {
+	var accounts []*account
+	for _, res := range collection.Resources {
+		if userTypes[res.Type] {
+			accounts = append(accounts, c.newAccount(res))
+		}
+	}
+
+	// Nodes of the union-find are the accounts, followed by the mappings
+	sets := newDisjointSets(len(accounts) + len(c.mappings))
+
+	keys := make(map[string]int)
+	link := func(key string, node int, kind string) {
+		first, ok := keys[key]
+		if !ok {
+			keys[key] = node
+			return
+		}
+		sets.union(first, node)
+		for _, n := range []int{first, node} {
+			if n < len(accounts) {
+				accounts[n].matchedBy[kind] = true
+			}
+		}
+	}
+
+	for i, acc := range accounts {
+		for _, email := range acc.emails {
+			link("email:"+email, i, MatchedByEmail)
+		}
+		if c.settings.MatchUsernames {
+			for _, username := range acc.usernames {
+				link("username:"+username, i, MatchedByUsername)
+			}
+		}
+	}
+
+	for m, mapping := range c.mappings {
+		node := len(accounts) + m
+		if email := normalizeEmail(mapping.Email); email != "" {
+			link("email:"+email, node, MatchedByMapping)
+		}
+		for provider, logins := range mapping.Accounts {
+			for _, login := range logins {
+				for i, acc := range accounts {
+					if acc.Provider == provider && acc.identifiedBy(login) {
+						sets.union(node, i)
+						acc.matchedBy[MatchedByMapping] = true
+					}
+				}
+			}
+		}
+	}
+
+	groups := make(map[int]*group)
+	var roots []int
+	for node := 0; node < len(accounts)+len(c.mappings); node++ {
+		root := sets.find(node)
+		g, ok := groups[root]
+		if !ok {
+			g = &group{}
+			groups[root] = g
+			roots = append(roots, root)
+		}
+		if node < len(accounts) {
+			g.accounts = append(g.accounts, accounts[node])
+		} else {
+			g.mappings = append(g.mappings, c.mappings[node-len(accounts)])
+		}
+	}
+
+	result := &Result{
+		People: make([]*resource.Resource, 0),
+		Report: &Report{
+			Accounts:    len(accounts),
+			Orphans:     make([]Account, 0),
+			Deactivated: make([]Deactivated, 0),
+			MultiAdmins: make([]MultiAdmin, 0),
+		},
+	}
+	report := result.Report
+
+	for _, acc := range accounts {
+		if acc.Provider == "okta" {
+			report.OktaUsers++
+		}
+	}
+
+	ids := make(map[string]bool)
+	for _, root := range roots {
+		g := groups[root]
+		if len(g.accounts) == 0 {
+			continue // mapping without collected accounts
+		}
+		if len(g.accounts) > 1 {
+			report.Linked += len(g.accounts)
+		}
+
+		okta := g.oktaAccounts()
+		if report.OktaUsers > 0 && len(okta) == 0 {
+			for _, acc := range g.accounts {
+				if workforceProviders[acc.Provider] && acc.Active {
+					report.Orphans = append(report.Orphans, acc.public())
+				}
+			}
+		}
+
+		if len(g.accounts) < 2 && len(okta) == 0 && len(g.mappings) == 0 {
+			continue
+		}
+
+		person := g.person(ids)
+		result.People = append(result.People, person)
+
+		if len(okta) > 0 && !okta[0].Active {
+			var active []Account
+			for _, acc := range g.accounts {
+				if workforceProviders[acc.Provider] && acc.Active {
+					active = append(active, acc.public())
+				}
+			}
+			if len(active) > 0 {
+				report.Deactivated = append(report.Deactivated, Deactivated{
+					Person:     person.ID,
+					Name:       person.Name,
+					OktaStatus: okta[0].Status,
+					Accounts:   active,
+				})
+			}
+		}
+
+		if providers := g.adminProviders(); len(providers) > 1 {
+			var admins []Account
+			for _, acc := range g.accounts {
+				if acc.Admin {
+					admins = append(admins, acc.public())
+				}
+			}
+			report.MultiAdmins = append(report.MultiAdmins, MultiAdmin{
+				Person:    person.ID,
+				Name:      person.Name,
+				Providers: providers,
+				Accounts:  admins,
+			})
+		}
+	}
+	report.People = len(result.People)
+
+	sort.Slice(report.Orphans, func(i, j int) bool {
+		a, b := report.Orphans[i], report.Orphans[j]
+		if a.Provider != b.Provider {
+			return a.Provider < b.Provider
+		}
+		return a.Name < b.Name
+	})
+	sort.Slice(report.Deactivated, func(i, j int) bool {
+		return report.Deactivated[i].Person < report.Deactivated[j].Person
+	})
+	sort.Slice(report.MultiAdmins, func(i, j int) bool {
+		a, b := report.MultiAdmins[i], report.MultiAdmins[j]
+		if len(a.Providers) != len(b.Providers) {
+			return len(a.Providers) > len(b.Providers)
+		}
+		return a.Person < b.Person
+	})
+
+	return result
+}
+
+// newAccount extracts the identity of a user account
+func (c *Correlator) newAccount(res *resource.Resource) *account {
+	acc := &account{
+		Account: Account{
+			ID:       res.ID,
+			Type:     res.Type,
+			Name:     res.Name,
+			Provider: res.Provider,
+			Account:  res.Account,
+			Active:   true,
+		},
+		res:       res,
+		matchedBy: make(map[string]bool),
+	}
+
+	var emails, usernames []string
+	email, _ := res.StringProperty("email")
+	acc.Status, acc.Active = AccountStatus(res)
+
+	switch res.Type {
+	case resource.TypeOktaUser:
+		login := profileString(res, "login")
+		emails = []string{email, profileString(res, "email"), login}
+		usernames = []string{login}
+
+	case resource.TypeAuth0User:
+		username, _ := res.StringProperty("username")
+		emails = []string{email}
+		usernames = []string{username}
+
+	case resource.TypeGitHubUser:
+		emails = []string{email}
+		usernames = []string{res.Name}
+		role, _ := res.StringProperty("role")
+		siteAdmin, _ := res.BoolProperty("site_admin")
+		acc.Admin = role == "admin" || siteAdmin
+
+	case resource.TypeGitLabUser:
+		username, _ := res.StringProperty("username")
+		emails = []string{email}
+		usernames = []string{username}
+		acc.Admin, _ = res.BoolProperty("is_admin")
+
+	case resource.TypeJFrogUser:
+		emails = []string{email}
+		usernames = []string{res.Name}
+		acc.Admin, _ = res.BoolProperty("admin")
+
+	case resource.TypeAWSIAMUser:
+		emails = []string{res.Tags["email"], res.Tags["Email"], res.Name}
+		usernames = []string{res.Name}
+		acc.Admin, _ = res.BoolProperty(resource.PropAdmin)
+	}
+
+	for _, e := range emails {
+		if e = normalizeEmail(e); e != "" && !slices.Contains(acc.emails, e) {
+			acc.emails = append(acc.emails, e)
+		}
+	}
+	for _, u := range usernames {
+		if u = c.normalizeUsername(res.Provider, u); u != "" && !slices.Contains(acc.usernames, u) {
+			acc.usernames = append(acc.usernames, u)
+		}
+	}
+
+	return acc
+}
+
+// AccountStatus returns the status of a user account and whether it can still sign in: Okta
+// users that are not deprovisioned or suspended, Auth0 users that are not blocked and active
+// GitLab users
+func AccountStatus(res *resource.Resource) (string, bool) {
+	switch res.Type {
+	case resource.TypeOktaUser:
+		status, _ := res.StringProperty(resource.PropStatus)
+		return status, !inactiveOktaStatuses[strings.ToUpper(status)]
+	case resource.TypeAuth0User:
+		if blocked, _ := res.BoolProperty("blocked"); blocked {
+			return "blocked", false
+		}
+	case resource.TypeGitLabUser:
+		state, _ := res.StringProperty(resource.PropState)
+		return state, state == "" || state == "active"
+	}
+	return "", true
+}
+
+// identifiedBy reports whether an account name, login, email or ID of a mapping designates the
+// account
+func (a *account) identifiedBy(login string) bool {
+	login = strings.TrimSpace(login)
+	if strings.EqualFold(login, a.Name) || login == a.ID {
+		return true
+	}
+	if slices.Contains(a.emails, normalizeEmail(login)) {
+		return true
+	}
+	if username, ok := a.res.StringProperty("username"); ok && strings.EqualFold(login, username) {
+		return true
+	}
+	return strings.EqualFold(login, profileString(a.res, "login"))
+}
+
+// public returns the account with how it was linked to its person
+func (a *account) public() Account {
+	acc := a.Account
+	acc.MatchedBy = sortedKeys(a.matchedBy)
+	return acc
+}
+
+// normalizeEmail returns an email in lower case, or an empty string if the value is not an email
+func normalizeEmail(value string) string {
+	value = strings.ToLower(strings.TrimSpace(value))
+	if at := strings.Index(value, "@"); at <= 0 || at == len(value)-1 {
+		return ""
+	}
+	return value
+}
+
+// normalizeUsername applies the username pattern of the provider, then keeps the lower case
+// letters and digits of the username (jane.doe, Jane-Doe and jane_doe are the same username).
+// Emails are reduced to their local part.
+func (c *Correlator) normalizeUsername(provider, username string) string {
+	if re, ok := c.patterns[provider]; ok {
+		if match := re.FindStringSubmatch(username); match != nil {
+			username = match[1]
+		}
+	}
+	if at := strings.Index(username, "@"); at >= 0 {
+		username = username[:at]
+	}
+
+	var normalized strings.Builder
+	for _, r := range strings.ToLower(username) {
+		if (r >= 'a' && r <= 'z') || (r >= '0' && r <= '9') {
+			normalized.WriteRune(r)
+		}
+	}
+	if normalized.Len() < minUsernameLength {
+		return ""
+	}
+	return normalized.String()
+}
+
+// profileString returns a string field of the profile of an Okta user
+func profileString(res *resource.Resource, field string) string {
+	profile := reflect.ValueOf(res.Properties["profile"])
+	if profile.Kind() != reflect.Map || profile.Type().Key().Kind() != reflect.String {
+		return ""
+	}
+	value := profile.MapIndex(reflect.ValueOf(field).Convert(profile.Type().Key()))
+	if !value.IsValid() {
+		return ""
+	}
+	s, _ := value.Interface().(string)
+	return s
+}
+
+// group is a set of linked accounts, with the mappings that linked them
+type group struct {
+	accounts []*account
+	mappings []Mapping
+}
+
+// oktaAccounts returns the Okta accounts of the group, active accounts first
+func (g *group) oktaAccounts() []*account {
+	var okta []*account
+	for _, acc := range g.accounts {
+		if acc.Provider == "okta" {
+			okta = append(okta, acc)
+		}
+	}
+	sort.SliceStable(okta, func(i, j int) bool { return okta[i].Active && !okta[j].Active })
+	return okta
+}
+
+// adminProviders returns the providers in which the group has administrator privileges
+func (g *group) adminProviders() []string {
+	providers := make(map[string]bool)
+	for _, acc := range g.accounts {
+		if acc.Admin {
+			providers[acc.Provider] = true
+		}
+	}
+	return sortedKeys(providers)
+}
+
+// person builds the person resource of the group, with a references relationship to each of
+// its accounts
+func (g *group) person(ids map[string]bool) *resource.Resource {
+	okta := g.oktaAccounts()
+
+	emails := make(map[string]bool)
+	providers := make(map[string]bool)
+	for _, acc := range g.accounts {
+		providers[acc.Provider] = true
+		for _, email := range acc.emails {
+			emails[email] = true
+		}
+	}
+
+	// The primary email identifies the person: the mapping email, then the Okta login
+	primary := ""
+	for _, mapping := range g.mappings {
+		if email := normalizeEmail(mapping.Email); email != "" && primary == "" {
+			primary = email
+			emails[email] = true
+		}
+	}
+	if primary == "" && len(okta) > 0 && len(okta[0].emails) > 0 {
+		primary = okta[0].emails[0]
+	}
+	if primary == "" && len(emails) > 0 {
+		primary = sortedKeys(emails)[0]
+	}
+	if primary == "" {
+		primary = g.accounts[0].Provider + ":" + g.accounts[0].Name
+	}
+
+	id := "person:" + primary
+	for n := 2; ids[id]; n++ {
+		id = fmt.Sprintf("person:%s#%d", primary, n)
+	}
+	ids[id] = true
+
+	properties := map[string]interface{}{
+		"emails":          sortedKeys(emails),
+		"providers":       sortedKeys(providers),
+		"account_count":   len(g.accounts),
+		"admin_providers": g.adminProviders(),
+	}
+	if len(okta) > 0 {
+		properties["okta_status"] = okta[0].Status
+		properties["active"] = okta[0].Active
+	}
+
+	person := &resource.Resource{
+		ID:         id,
+		Type:       resource.TypeIdentityPerson,
+		Name:       g.displayName(primary),
+		Provider:   ProviderIdentity,
+		Properties: properties,
+	}
+
+	for _, acc := range g.accounts {
+		rel := resource.Relationship{
+			Type:       resource.RelationReferences,
+			TargetID:   acc.ID,
+			TargetType: acc.Type,
+			Properties: map[string]interface{}{
+				"provider": acc.Provider,
+			},
+		}
+		if matchedBy := sortedKeys(acc.matchedBy); len(matchedBy) > 0 {
+			rel.Properties["matched_by"] = strings.Join(matchedBy, ",")
+		}
+		person.Relationships = append(person.Relationships, rel)
+	}
+
+	return person
+}
+
+// displayName returns the name of the person: the mapping name, then the Okta, GitHub or GitLab
+// name, then the primary email
+func (g *group) displayName(primary string) string {
+	for _, mapping := range g.mappings {
+		if mapping.Name != "" {
+			return mapping.Name
+		}
+	}
+	for _, acc := range g.oktaAccounts() {
+		first, _ := acc.res.StringProperty("firstName")
+		last, _ := acc.res.StringProperty("lastName")
+		if name := strings.TrimSpace(first + " " + last); name != "" {
+			return name
+		}
+	}
+	for _, acc := range g.accounts {
+		switch acc.Type {
+		case resource.TypeGitHubUser:
+			if name, _ := acc.res.StringProperty("name"); name != "" {
+				return name
+			}
+		case resource.TypeGitLabUser:
+			if acc.Name != "" {
+				return acc.Name
+			}
+		}
+	}
+	return primary
+}
+
+// disjointSets is a union-find structure over account and mapping nodes
+type disjointSets struct {
+	parent []int
+}
+
+func newDisjointSets(size int) *disjointSets {
+	parent := make([]int, size)
+	for i := range parent {
+		parent[i] = i
+	}
+	return &disjointSets{parent: parent}
+}
+
+func (d *disjointSets) find(node int) int {
+	for d.parent[node] != node {
+		d.parent[node] = d.parent[d.parent[node]]
+		node = d.parent[node]
+	}
+	return node
+}
+
+func (d *disjointSets) union(a, b int) {
+	rootA, rootB := d.find(a), d.find(b)
+	if rootA == rootB {
+		return
+	}
+	// Keep the smallest node as the root so that groups are reported in collection order
+	if rootB < rootA {
+		rootA, rootB = rootB, rootA
+	}
+	d.parent[rootB] = rootA
+}
+
+// sortedKeys returns the keys of a set, sorted
+func sortedKeys(set map[string]bool) []string {
+	keys := make([]string, 0, len(set))
+	for key := range set {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+	return keys
+}
@@ -0,0 +1,356 @@
+package identity
+
+import (
+	"encoding/csv"
+	"fmt"
+	"io"
+	"sort"
+	"strconv"
+	"time"
+
+	"github.com/comfortablynumb/pmp-cloud-inspector/pkg/resource"
+)
+
+// DefaultInactiveDays is the number of days without activity after which accounts and access
+// keys are inactive, unless configured otherwise
+const DefaultInactiveDays = 90
+
+// activitySignals lists the properties of user accounts recording a sign-in or a use, by type.
+// IAM users are also active when one of their access keys is used.
+var activitySignals = map[resource.ResourceType][]string{
+	resource.TypeAWSIAMUser: {"password_last_used"},
+	resource.TypeGitHubUser: {"last_event_at"},
+	resource.TypeGitLabUser: {"last_activity_on", "last_sign_in_at", "current_sign_in_at"},
+	resource.TypeJFrogUser:  {"last_logged_in"},
+	resource.TypeOktaUser:   {"lastLogin"},
+	resource.TypeAuth0User:  {"last_login"},
+}
+
+// InactivitySettings configures the thresholds of inactive accounts and access keys
+type InactivitySettings struct {
+	Days      int            `yaml:"days"`      // days without activity after which accounts and access keys are inactive (default 90)
+	Providers map[string]int `yaml:"providers"` // days per provider, e.g., github: 180
+}
+
+// Validate checks that the thresholds are not negative
+func (s InactivitySettings) Validate() error {
+	if s.Days < 0 {
+		return fmt.Errorf("invalid inactivity days %d: must not be negative", s.Days)
+	}
+	for provider, days := range s.Providers {
+		if days < 0 {
+			return fmt.Errorf("invalid inactivity days %d for %s: must not be negative", days, provider)
+		}
+	}
+	return nil
+}
+
+// Threshold returns the days without activity after which the accounts of a provider are
+// inactive
+func (s InactivitySettings) Threshold(provider string) int {
+	if days := s.Providers[provider]; days > 0 {
+		return days
+	}
+	if s.Days > 0 {
+		return s.Days
+	}
+	return DefaultInactiveDays
+}
+
+// InactiveAccount is a user account without activity beyond the threshold of its provider
+type InactiveAccount struct {
+	ID           string                `json:"id"`
+	Type         resource.ResourceType `json:"type"`
+	Name         string                `json:"name"`
+	Provider     string                `json:"provider"`
+	Account      string                `json:"account,omitempty"` // AWS account or GitHub organization
+	Email        string                `json:"email,omitempty"`
+	Person       string                `json:"person,omitempty"`        // name of the person of the account, when correlated
+	LastActivity *time.Time            `json:"last_activity,omitempty"` // nil if the account was never used
+	CreatedAt    *time.Time            `json:"created_at,omitempty"`
+	DaysInactive int                   `json:"days_inactive"` // since the last activity, or the creation of unused accounts
+	Threshold    int                   `json:"threshold"`
+	Reason       string                `json:"reason"`
+}
+
+// InactiveKey is an active IAM access key unused beyond the threshold of AWS
+type InactiveKey struct {
+	KeyID        string     `json:"access_key_id"`
+	User         string     `json:"user"`
+	UserID       string     `json:"user_id"`
+	Account      string     `json:"account,omitempty"`
+	Person       string     `json:"person,omitempty"`    // name of the person of the user, when correlated
+	LastUsed     *time.Time `json:"last_used,omitempty"` // nil if the key was never used
+	CreatedAt    *time.Time `json:"created_at,omitempty"`
+	DaysInactive int        `json:"days_inactive"`
+	Threshold    int        `json:"threshold"`
+	Reason       string     `json:"reason"`
+}
+
+// InactivityReport lists the accounts and access keys to deprovision
+type InactivityReport struct {
+	AsOf             time.Time         `json:"as_of"`    // time inactivity is measured at
+	Accounts         int               `json:"accounts"` // accounts that can still sign in, the ones checked
+	Keys             int               `json:"keys"`     // active IAM access keys
+	Unknown          int               `json:"unknown"`  // accounts without activity signals nor creation date
+	InactiveAccounts []InactiveAccount `json:"inactive_accounts"`
+	InactiveKeys     []InactiveKey     `json:"inactive_keys"`
+}
+
+// LastActivity returns the latest activity of a user account among the signals of its provider,
+// or its last_activity property when already normalized
+func LastActivity(res *resource.Resource) (time.Time, bool) {
+	var latest time.Time
+	consider := func(t time.Time, ok bool) {
+		if ok && !t.IsZero() && t.After(latest) {
+			latest = t
+		}
+	}
+
+	consider(res.TimeProperty(resource.PropLastActivity))
+	for _, name := range activitySignals[res.Type] {
+		consider(res.TimeProperty(name))
+	}
+	for _, key := range res.MapsProperty(resource.PropAccessKeys) {
+		consider(mapTime(key, "last_used_date"))
+	}
+
+	return latest, !latest.IsZero()
+}
+
+// NormalizeActivity sets the last_activity property (RFC 3339) of the user accounts with
+// activity signals, and returns the number of accounts updated
+func NormalizeActivity(collection *resource.Collection) int {
+	count := 0
+	for _, res := range collection.Resources {
+		if !userTypes[res.Type] {
+			continue
+		}
+		if last, ok := LastActivity(res); ok {
+			if res.Properties == nil {
+				res.Properties = make(map[string]interface{})
+			}
+			res.Properties[resource.PropLastActivity] = last.UTC().Format(time.RFC3339)
+			count++
+		}
+	}
+	return count
+}
+
+// DetectInactive reports the user accounts that can still sign in and the active IAM access keys
+// without activity for more days than the threshold of their provider, as of a time. Accounts
+// that were never used are measured from their creation.
+func DetectInactive(collection *resource.Collection, settings InactivitySettings, asOf time.Time) *InactivityReport {
+	report := &InactivityReport{
+		AsOf:             asOf,
+		InactiveAccounts: []InactiveAccount{},
+		InactiveKeys:     []InactiveKey{},
+	}
+
+	// People of the accounts, when correlated
+	people := make(map[string]string)
+	for _, res := range collection.Resources {
+		if res.Type != resource.TypeIdentityPerson {
+			continue
+		}
+		for _, rel := range res.Relationships {
+			if rel.Type == resource.RelationReferences {
+				people[string(rel.TargetType)+":"+rel.TargetID] = res.Name
+			}
+		}
+	}
+
+	for _, res := range collection.Resources {
+		if !userTypes[res.Type] {
+			continue
+		}
+		if _, active := AccountStatus(res); !active {
+			continue
+		}
+		report.Accounts++
+
+		threshold := settings.Threshold(res.Provider)
+		last, used := LastActivity(res)
+		created, hasCreated := createdAt(res)
+
+		var since time.Time
+		switch {
+		case used:
+			since = last
+		case hasCreated:
+			since = created
+		default:
+			report.Unknown++
+			continue
+		}
+
+		days := daysBetween(since, asOf)
+		if days <= threshold {
+			continue
+		}
+
+		account := InactiveAccount{
+			ID:           res.ID,
+			Type:         res.Type,
+			Name:         res.Name,
+			Provider:     res.Provider,
+			Account:      res.Account,
+			Person:       people[string(res.Type)+":"+res.ID],
+			DaysInactive: days,
+			Threshold:    threshold,
+		}
+		account.Email, _ = res.StringProperty("email")
+		if used {
+			account.LastActivity = &last
+			account.Reason = fmt.Sprintf("no activity for %d days", days)
+		} else {
+			account.Reason = fmt.Sprintf("never active, created %d days ago", days)
+		}
+		if hasCreated {
+			account.CreatedAt = &created
+		}
+		report.InactiveAccounts = append(report.InactiveAccounts, account)
+	}
+
+	for _, res := range collection.Resources {
+		if res.Type != resource.TypeAWSIAMUser {
+			continue
+		}
+		threshold := settings.Threshold(res.Provider)
+		for _, key := range res.MapsProperty(resource.PropAccessKeys) {
+			if status, _ := key["status"].(string); status != "Active" {
+				continue
+			}
+			report.Keys++
+
+			lastUsed, used := mapTime(key, "last_used_date")
+			created, hasCreated := mapTime(key, "create_date")
+
+			var since time.Time
+			switch {
+			case used:
+				since = lastUsed
+			case hasCreated:
+				since = created
+			default:
+				continue
+			}
+
+			days := daysBetween(since, asOf)
+			if days <= threshold {
+				continue
+			}
+
+			inactive := InactiveKey{
+				User:         res.Name,
+				UserID:       res.ID,
+				Account:      res.Account,
+				Person:       people[string(res.Type)+":"+res.ID],
+				DaysInactive: days,
+				Threshold:    threshold,
+			}
+			inactive.KeyID, _ = key["access_key_id"].(string)
+			if used {
+				inactive.LastUsed = &lastUsed
+				inactive.Reason = fmt.Sprintf("not used for %d days", days)
+			} else {
+				inactive.Reason = fmt.Sprintf("never used, created %d days ago", days)
+			}
+			if hasCreated {
+				inactive.CreatedAt = &created
+			}
+			report.InactiveKeys = append(report.InactiveKeys, inactive)
+		}
+	}
+
+	sort.SliceStable(report.InactiveAccounts, func(i, j int) bool {
+		a, b := report.InactiveAccounts[i], report.InactiveAccounts[j]
+		if a.DaysInactive != b.DaysInactive {
+			return a.DaysInactive > b.DaysInactive
+		}
+		if a.Provider != b.Provider {
+			return a.Provider < b.Provider
+		}
+		return a.Name < b.Name
+	})
+	sort.SliceStable(report.InactiveKeys, func(i, j int) bool {
+		a, b := report.InactiveKeys[i], report.InactiveKeys[j]
+		if a.DaysInactive != b.DaysInactive {
+			return a.DaysInactive > b.DaysInactive
+		}
+		return a.KeyID < b.KeyID
+	})
+
+	return report
+}
+
+// inactivityColumns are the columns of the CSV of inactive accounts and access keys, one row per
+// account or key to deprovision
+var inactivityColumns = []string{
+	"kind", "provider", "account", "id", "name", "email", "person", "last_activity", "created_at",
+	"days_inactive", "threshold", "reason",
+}
+
+// WriteCSV writes the inactive accounts and access keys as CSV, e.g., to file deprovisioning
+// tickets
+func (r *InactivityReport) WriteCSV(writer io.Writer) error {
+	w := csv.NewWriter(writer)
+
+	rows := [][]string{inactivityColumns}
+	for _, a := range r.InactiveAccounts {
+		rows = append(rows, []string{
+			"account", a.Provider, a.Account, a.ID, a.Name, a.Email, a.Person, formatTime(a.LastActivity),
+			formatTime(a.CreatedAt), strconv.Itoa(a.DaysInactive), strconv.Itoa(a.Threshold), a.Reason,
+		})
+	}
+	for _, k := range r.InactiveKeys {
+		rows = append(rows, []string{
+			"access_key", "aws", k.Account, k.KeyID, k.User, "", k.Person, formatTime(k.LastUsed),
+			formatTime(k.CreatedAt), strconv.Itoa(k.DaysInactive), strconv.Itoa(k.Threshold), k.Reason,
+		})
+	}
+
+	if err := w.WriteAll(rows); err != nil {
+		return fmt.Errorf("failed to write CSV: %w", err)
+	}
+	return nil
+}
+
+// formatTime formats an optional time as RFC 3339
+func formatTime(t *time.Time) string {
+	if t == nil {
+		return ""
+	}
+	return t.UTC().Format(time.RFC3339)
+}
+
+// createdAt returns the creation time of a user account
+func createdAt(res *resource.Resource) (time.Time, bool) {
+	if res.CreatedAt != nil && !res.CreatedAt.IsZero() {
+		return *res.CreatedAt, true
+	}
+	for _, name := range []string{"create_date", "created", "created_at"} {
+		if t, ok := res.TimeProperty(name); ok && !t.IsZero() {
+			return t, true
+		}
+	}
+	return time.Time{}, false
+}
+
+// mapTime returns an RFC 3339 time of an object property
+func mapTime(values map[string]interface{}, name string) (time.Time, bool) {
+	value, ok := values[name].(string)
+	if !ok {
+		return time.Time{}, false
+	}
+	t, err := time.Parse(time.RFC3339, value)
+	return t, err == nil && !t.IsZero()
+}
+
+// daysBetween returns the number of whole days between two times
+func daysBetween(from, to time.Time) int {
+	if !to.After(from) {
+		return 0
+	}
+	return int(to.Sub(from).Hours() / 24)
+}
@@ -0,0 +1,105 @@
+package identity
+
+import (
+	"fmt"
+	"os"
+	"regexp"
+	"strings"
+
+	"gopkg.in/yaml.v3"
+)
+
+// Settings configures the correlation of the user accounts of every provider into people
+type Settings struct {
+	Correlate        bool              `yaml:"correlate"`         // add identity:person resources to inspect exports
+	Mappings         []string          `yaml:"mappings"`          // mapping files linking the accounts of people
+	MatchUsernames   bool              `yaml:"match_usernames"`   // also link accounts with the same normalized username
+	UsernamePatterns map[string]string `yaml:"username_patterns"` // per provider regex whose first group is the username, e.g. github: '^(.+)-acme$'
+
+	Inactivity InactivitySettings `yaml:"inactivity"` // thresholds of inactive accounts and access keys
+}
+
+// Validate checks the username patterns and the inactivity thresholds of the settings
+func (s Settings) Validate() error {
+	if _, err := s.compilePatterns(); err != nil {
+		return err
+	}
+	return s.Inactivity.Validate()
+}
+
+// compilePatterns compiles the username patterns of each provider
+func (s Settings) compilePatterns() (map[string]*regexp.Regexp, error) {
+	patterns := make(map[string]*regexp.Regexp, len(s.UsernamePatterns))
+	for provider, pattern := range s.UsernamePatterns {
+		re, err := regexp.Compile(pattern)
+		if err != nil {
+			return nil, fmt.Errorf("invalid username pattern for %s: %w", provider, err)
+		}
+		if re.NumSubexp() < 1 {
+			return nil, fmt.Errorf("invalid username pattern for %s: the pattern needs a group capturing the username", provider)
+		}
+		patterns[provider] = re
+	}
+	return patterns, nil
+}
+
+// MappingFile is the format of mapping files: the accounts of people that cannot be linked by
+// email or username
+type MappingFile struct {
+	People []Mapping `yaml:"people"`
+}
+
+// Mapping links the accounts of a person
+type Mapping struct {
+	Name     string            `yaml:"name"`     // display name of the person (optional)
+	Email    string            `yaml:"email"`    // email of the person, also linking the accounts with this email (optional)
+	Accounts map[string]Logins `yaml:"accounts"` // provider => account names, logins, emails or IDs, e.g. github: janedoe
+}
+
+// Logins is a list of account identifiers, written as a single value or a list
+type Logins []string
+
+// UnmarshalYAML accepts a single identifier or a list of identifiers
+func (l *Logins) UnmarshalYAML(node *yaml.Node) error {
+	if node.Kind == yaml.ScalarNode {
+		*l = Logins{node.Value}
+		return nil
+	}
+
+	var logins []string
+	if err := node.Decode(&logins); err != nil {
+		return err
+	}
+	*l = logins
+	return nil
+}
+
+// LoadMappings loads the people of mapping files
+func LoadMappings(paths []string) ([]Mapping, error) {
+	var mappings []Mapping
+	for _, path := range paths {
+		// #nosec G304 - mapping files are provided by user as CLI arguments or config, this is expected behavior
+		content, err := os.ReadFile(path)
+		if err != nil {
+			return nil, fmt.Errorf("failed to read identity mappings %s: %w", path, err)
+		}
+
+		var file MappingFile
+		if err := yaml.Unmarshal(content, &file); err != nil {
+			return nil, fmt.Errorf("failed to parse identity mappings %s: %w", path, err)
+		}
+
+		for i, mapping := range file.People {
+			if len(mapping.Accounts) == 0 {
+				return nil, fmt.Errorf("invalid identity mappings %s: person %d has no accounts", path, i+1)
+			}
+			for provider, logins := range mapping.Accounts {
+				if strings.TrimSpace(provider) == "" || len(logins) == 0 {
+					return nil, fmt.Errorf("invalid identity mappings %s: person %d has an empty account", path, i+1)
+				}
+			}
+		}
+		mappings = append(mappings, file.People...)
+	}
+	return mappings, nil
+}
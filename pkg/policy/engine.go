@@ -0,0 +1,411 @@
+package policy
+
+import (
+	"fmt"
+	"reflect"
+	"sort"
+	"strings"
+	"sync"
+
+	"github.com/comfortablynumb/pmp-cloud-inspector/pkg/filter"
+	"github.com/comfortablynumb/pmp-cloud-inspector/pkg/resource"
+)
+
+// Settings configures the rules evaluated by the policy engine
+type Settings struct {
+	Rules          []string `yaml:"rules"`           // rule files or directories, evaluated along with the built-in rules
+	DisableBuiltin bool     `yaml:"disable_builtin"` // only evaluate the rules of the rule files
+	Disabled       []string `yaml:"disabled"`        // IDs of rules that are not evaluated
+	MinSeverity    string   `yaml:"min_severity"`    // rules below this severity are not evaluated (default: all)
+	FailOn         string   `yaml:"fail_on"`         // finding severity that makes audit exit with an error
+}
+
+// Validate checks the severities of the settings
+func (s Settings) Validate() error {
+	if s.MinSeverity != "" && SeverityRank(s.MinSeverity) == 0 {
+		return fmt.Errorf("invalid min_severity '%s' (supported: %s)", s.MinSeverity, strings.Join(Severities, ", "))
+	}
+	if s.FailOn != "" && SeverityRank(s.FailOn) == 0 {
+		return fmt.Errorf("invalid fail_on '%s' (supported: %s)", s.FailOn, strings.Join(Severities, ", "))
+	}
+	return nil
+}
+
+// Finding is a resource failing a rule
+type Finding struct {
+	RuleID       string                `json:"rule_id"`
+	Title        string                `json:"title"`
+	Severity     string                `json:"severity"`
+	Category     string                `json:"category,omitempty"`
+	ResourceID   string                `json:"resource_id"`
+	ResourceType resource.ResourceType `json:"resource_type"`
+	Name         string                `json:"name"`
+	Provider     string                `json:"provider"`
+	Account      string                `json:"account,omitempty"`
+	Region       string                `json:"region,omitempty"`
+	Message      string                `json:"message"` // the checks the resource failed
+	Remediation  string                `json:"remediation,omitempty"`
+}
+
+// RuleResult is the outcome of a rule over a collection
+type RuleResult struct {
+	ID          string   `json:"id"`
+	Title       string   `json:"title"`
+	Description string   `json:"description,omitempty"`
+	Severity    string   `json:"severity"`
+	Category    string   `json:"category,omitempty"`
+	Remediation string   `json:"remediation,omitempty"`
+	References  []string `json:"references,omitempty"`
+	Source      string   `json:"source"`
+	Evaluated   int      `json:"evaluated"` // resources selected by the rule
+	Findings    int      `json:"findings"`
+}
+
+// Report lists the findings of an audit, most severe first
+type Report struct {
+	Resources  int            `json:"resources"`   // resources in the audited collection
+	BySeverity map[string]int `json:"by_severity"` // number of findings of each severity
+	Rules      []RuleResult   `json:"rules"`
+	Findings   []Finding      `json:"findings"`
+}
+
+// Engine evaluates compliance rules over collections
+type Engine struct {
+	rules []*Rule
+	mu    sync.Mutex // rule filters hold the graph of the collection being evaluated
+}
+
+// NewEngine creates an engine evaluating the built-in rules and the rules of the configured files.
+// Rules of the files replace built-in rules with the same ID.
+func NewEngine(settings Settings) (*Engine, error) {
+	if err := settings.Validate(); err != nil {
+		return nil, err
+	}
+
+	var rules []*Rule
+	if !settings.DisableBuiltin {
+		builtin, err := BuiltinRules()
+		if err != nil {
+			return nil, err
+		}
+		rules = append(rules, builtin...)
+	}
+
+	custom, err := LoadRules(settings.Rules)
+	if err != nil {
+		return nil, err
+	}
+
+	index := make(map[string]int)
+	merged := make([]*Rule, 0, len(rules)+len(custom))
+	for _, rule := range append(rules, custom...) {
+		if i, ok := index[rule.ID]; ok {
+			if !strings.HasPrefix(merged[i].Source, "builtin:") || strings.HasPrefix(rule.Source, "builtin:") {
+				return nil, fmt.Errorf("duplicate rule %s in %s and %s", rule.ID, merged[i].Source, rule.Source)
+			}
+			merged[i] = rule
+			continue
+		}
+		index[rule.ID] = len(merged)
+		merged = append(merged, rule)
+	}
+
+	disabled := make(map[string]bool)
+	for _, id := range settings.Disabled {
+		if _, ok := index[id]; !ok {
+			return nil, fmt.Errorf("unknown rule '%s' in disabled rules", id)
+		}
+		disabled[id] = true
+	}
+
+	engine := &Engine{}
+	for _, rule := range merged {
+		if disabled[rule.ID] || SeverityRank(rule.Severity) < SeverityRank(settings.MinSeverity) {
+			continue
+		}
+		engine.rules = append(engine.rules, rule)
+	}
+
+	return engine, nil
+}
+
+// Rules returns the rules evaluated by the engine
+func (e *Engine) Rules() []*Rule {
+	return e.rules
+}
+
+// Evaluate runs every rule over a collection
+func (e *Engine) Evaluate(collection *resource.Collection) *Report {
+	e.mu.Lock()
+	defer e.mu.Unlock()
+
+	graph := resource.NewGraph(collection)
+
+	report := &Report{
+		Resources:  len(collection.Resources),
+		BySeverity: make(map[string]int),
+		Rules:      make([]RuleResult, 0, len(e.rules)),
+		Findings:   make([]Finding, 0),
+	}
+
+	for _, rule := range e.rules {
+		rule.bindGraph(graph)
+
+		result := RuleResult{
+			ID:          rule.ID,
+			Title:       rule.Title,
+			Description: rule.Description,
+			Severity:    rule.Severity,
+			Category:    rule.Category,
+			Remediation: rule.Remediation,
+			References:  rule.References,
+			Source:      rule.Source,
+		}
+
+		for _, res := range collection.Resources {
+			if !rule.selector.Apply(res) {
+				continue
+			}
+			result.Evaluated++
+
+			failures := rule.check(res, graph)
+			if len(failures) == 0 {
+				continue
+			}
+
+			result.Findings++
+			report.BySeverity[rule.Severity]++
+			report.Findings = append(report.Findings, Finding{
+				RuleID:       rule.ID,
+				Title:        rule.Title,
+				Severity:     rule.Severity,
+				Category:     rule.Category,
+				ResourceID:   res.ID,
+				ResourceType: res.Type,
+				Name:         res.Name,
+				Provider:     res.Provider,
+				Account:      res.Account,
+				Region:       res.Region,
+				Message:      strings.Join(failures, "; "),
+				Remediation:  rule.Remediation,
+			})
+		}
+
+		report.Rules = append(report.Rules, result)
+	}
+
+	sort.SliceStable(report.Findings, func(i, j int) bool {
+		a, b := report.Findings[i], report.Findings[j]
+		if a.Severity != b.Severity {
+			return SeverityRank(a.Severity) > SeverityRank(b.Severity)
+		}
+		if a.RuleID != b.RuleID {
+			return a.RuleID < b.RuleID
+		}
+		return a.ResourceID < b.ResourceID
+	})
+
+	return report
+}
+
+// FindingsAtLeast returns the findings of at least the given severity
+func (r *Report) FindingsAtLeast(severity string) []Finding {
+	var matching []Finding
+	for _, finding := range r.Findings {
+		if SeverityRank(finding.Severity) >= SeverityRank(severity) {
+			matching = append(matching, finding)
+		}
+	}
+	return matching
+}
+
+// bindGraph binds the graph of the evaluated collection to the expressions of the rule
+func (r *Rule) bindGraph(graph *resource.Graph) {
+	filter.BindGraph(r.selector, graph)
+	if r.require != nil {
+		filter.BindGraph(r.require, graph)
+	}
+	for _, check := range r.Items {
+		filter.BindGraph(check.where, graph)
+	}
+	for _, check := range r.Related {
+		if check.where != nil {
+			filter.BindGraph(check.where, graph)
+		}
+	}
+}
+
+// check runs the checks of the rule on a resource and describes the ones it fails
+func (r *Rule) check(res *resource.Resource, graph *resource.Graph) []string {
+	var failures []string
+
+	if r.require != nil && !r.require.Apply(res) {
+		message := r.Message
+		if message == "" {
+			message = fmt.Sprintf("does not satisfy %s", r.Require)
+		}
+		failures = append(failures, message)
+	}
+
+	for _, check := range r.Items {
+		items := listItems(res, check.Property)
+
+		matches := 0
+		for _, item := range items {
+			if check.where.Apply(item) {
+				matches++
+			}
+		}
+
+		if !quantifierHolds(check.Match, matches, len(items)) {
+			failures = append(failures, describeFailure(check.Description, check.Match, matches, len(items),
+				check.Property+" items", check.Where))
+		}
+	}
+
+	for _, check := range r.Related {
+		neighbors := relatedResources(res, graph, check)
+
+		matches := 0
+		for _, neighbor := range neighbors {
+			if check.where == nil || check.where.Apply(neighbor) {
+				matches++
+			}
+		}
+
+		if !quantifierHolds(check.Match, matches, len(neighbors)) {
+			what := "related resources"
+			if len(check.Types) > 0 {
+				what = "related " + strings.Join(check.Types, "/")
+			}
+			failures = append(failures, describeFailure(check.Description, check.Match, matches, len(neighbors), what, check.Where))
+		}
+	}
+
+	return failures
+}
+
+// quantifierHolds reports whether the number of matching items satisfies a quantifier. Empty
+// lists fail any checks and pass all and none checks.
+func quantifierHolds(match string, matches, total int) bool {
+	switch match {
+	case MatchAny:
+		return matches > 0
+	case MatchAll:
+		return matches == total
+	}
+	return matches == 0
+}
+
+// describeFailure describes a failed item or related check
+func describeFailure(description, match string, matches, total int, what, where string) string {
+	counts := fmt.Sprintf("%d of %d %s", matches, total, what)
+	if match == MatchAll {
+		counts = fmt.Sprintf("%d of %d %s do not match", total-matches, total, what)
+	}
+
+	if description != "" {
+		if match == MatchAny {
+			return description
+		}
+		return fmt.Sprintf("%s (%s)", description, counts)
+	}
+
+	switch match {
+	case MatchAny:
+		if total == 0 {
+			return fmt.Sprintf("no %s", what)
+		}
+		return fmt.Sprintf("none of %d %s match %s", total, what, where)
+	case MatchAll:
+		return fmt.Sprintf("%s %s", counts, where)
+	}
+	return fmt.Sprintf("%s match %s", counts, where)
+}
+
+// listItems returns the items of a list property as resources whose properties are the fields
+// of the item, so that filter expressions can be evaluated on them
+func listItems(res *resource.Resource, property string) []*resource.Resource {
+	path := filter.FieldPath{Parts: append([]string{"properties"}, strings.Split(property, ".")...)}
+	value, ok := path.Resolve(res)
+	if !ok {
+		return nil
+	}
+
+	list := reflect.ValueOf(value)
+	if list.Kind() != reflect.Slice && list.Kind() != reflect.Array {
+		return nil
+	}
+
+	items := make([]*resource.Resource, 0, list.Len())
+	for i := 0; i < list.Len(); i++ {
+		var properties map[string]interface{}
+		switch fields := list.Index(i).Interface().(type) {
+		case map[string]interface{}:
+			properties = fields
+		case map[string]string:
+			properties = make(map[string]interface{}, len(fields))
+			for key, field := range fields {
+				properties[key] = field
+			}
+		default:
+			properties = map[string]interface{}{"value": fields}
+		}
+
+		item := *res
+		item.Properties = properties
+		items = append(items, &item)
+	}
+
+	return items
+}
+
+// relatedResources returns the collected resources related to a resource by a related check
+func relatedResources(res *resource.Resource, graph *resource.Graph, check RelatedCheck) []*resource.Resource {
+	direction := resource.Direction(check.Direction)
+
+	seen := make(map[string]bool)
+	var neighbors []*resource.Resource
+	add := func(id string) {
+		if seen[id] {
+			return
+		}
+		seen[id] = true
+
+		neighbor := graph.Get(id)
+		if neighbor == nil {
+			return
+		}
+		if len(check.Types) > 0 && !containsString(check.Types, string(neighbor.Type)) {
+			return
+		}
+		neighbors = append(neighbors, neighbor)
+	}
+
+	if direction != resource.DirectionIncoming {
+		for _, rel := range graph.GetRelationships(res.ID) {
+			if check.Relation == "" || string(rel.Type) == check.Relation {
+				add(rel.TargetID)
+			}
+		}
+	}
+	if direction != resource.DirectionOutgoing {
+		for _, edge := range graph.GetIncoming(res.ID) {
+			if check.Relation == "" || string(edge.Type) == check.Relation {
+				add(edge.SourceID)
+			}
+		}
+	}
+
+	return neighbors
+}
+
+// containsString reports whether a list contains a string
+func containsString(list []string, s string) bool {
+	for _, item := range list {
+		if item == s {
+			return true
+		}
+	}
+	return false
+}
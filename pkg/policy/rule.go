@@ -0,0 +1,299 @@
+package policy
+
+import (
+	"embed"
+	"fmt"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+
+	"gopkg.in/yaml.v3"
+
+	"github.com/comfortablynumb/pmp-cloud-inspector/pkg/filter"
+	"github.com/comfortablynumb/pmp-cloud-inspector/pkg/resource"
+)
+
+//go:embed rules/*.yaml
+var builtinRulesFS embed.FS
+
+// Finding severities, in increasing order
+const (
+	SeverityInfo     = "info"
+	SeverityLow      = "low"
+	SeverityMedium   = "medium"
+	SeverityHigh     = "high"
+	SeverityCritical = "critical"
+)
+
+// Severities lists the finding severities, in increasing order
+var Severities = []string{SeverityInfo, SeverityLow, SeverityMedium, SeverityHigh, SeverityCritical}
+
+// Quantifiers of item and related checks
+const (
+	MatchAny  = "any"  // at least one item or neighbor matches
+	MatchAll  = "all"  // every item or neighbor matches
+	MatchNone = "none" // no item or neighbor matches
+)
+
+// RuleFile is the format of policy files: a list of rules
+type RuleFile struct {
+	Rules []*Rule `yaml:"rules"`
+}
+
+// Rule is a compliance rule. It selects the resources it applies to, and raises a finding for
+// every selected resource that fails one of its checks.
+type Rule struct {
+	ID          string         `yaml:"id" json:"id"`
+	Title       string         `yaml:"title" json:"title"`
+	Description string         `yaml:"description" json:"description,omitempty"`
+	Severity    string         `yaml:"severity" json:"severity"`           // info, low, medium, high or critical
+	Category    string         `yaml:"category" json:"category,omitempty"` // e.g., network, encryption, identity, hygiene
+	Select      Selector       `yaml:"select" json:"select"`               // resources the rule applies to
+	Require     string         `yaml:"require" json:"require,omitempty"`   // expression every selected resource must satisfy
+	Message     string         `yaml:"message" json:"message,omitempty"`   // reported when the require expression fails
+	Items       []ItemCheck    `yaml:"items" json:"items,omitempty"`       // checks over the items of list properties
+	Related     []RelatedCheck `yaml:"related" json:"related,omitempty"`   // checks over the graph neighbors
+	Remediation string         `yaml:"remediation" json:"remediation,omitempty"`
+	References  []string       `yaml:"references" json:"references,omitempty"` // documentation URLs
+
+	Source string `yaml:"-" json:"source"` // file the rule was loaded from, or builtin:<file>
+
+	selector filter.Filter
+	require  filter.Filter
+}
+
+// Selector selects resources by type, provider, tags, properties and expression. All of its
+// filters are ANDed; an empty selector selects every resource.
+type Selector struct {
+	Types      []string `yaml:"types" json:"types,omitempty"`           // e.g., aws:ec2:security-group
+	Providers  []string `yaml:"providers" json:"providers,omitempty"`   // e.g., aws, github
+	Tags       []string `yaml:"tags" json:"tags,omitempty"`             // e.g., Environment=prod, Owner
+	Properties []string `yaml:"properties" json:"properties,omitempty"` // e.g., state=running
+	Where      string   `yaml:"where" json:"where,omitempty"`           // filter expression
+}
+
+// ItemCheck checks the items of a list property (e.g., the ingress rules of a security group).
+// The fields of each item are available in the expression as properties.<field>, and scalar
+// items as properties.value.
+type ItemCheck struct {
+	Property    string `yaml:"property" json:"property"`                 // list property, e.g. ingress_rules
+	Where       string `yaml:"where" json:"where"`                       // expression evaluated for each item
+	Match       string `yaml:"match" json:"match"`                       // any, all or none of the items must match
+	Description string `yaml:"description" json:"description,omitempty"` // reported when the check fails
+	where       filter.Filter
+}
+
+// RelatedCheck checks the resources related to a resource in the relationship graph (e.g., the
+// security groups attached to an instance, or the instances attached to a security group)
+type RelatedCheck struct {
+	Relation    string   `yaml:"relation" json:"relation,omitempty"`       // relation type (empty = any)
+	Direction   string   `yaml:"direction" json:"direction,omitempty"`     // out (default): relationships of the resource, in: relationships to it, any: both
+	Types       []string `yaml:"types" json:"types,omitempty"`             // neighbor resource types (empty = any)
+	Where       string   `yaml:"where" json:"where,omitempty"`             // expression evaluated for each neighbor (empty = every neighbor matches)
+	Match       string   `yaml:"match" json:"match"`                       // any, all or none of the neighbors must match
+	Description string   `yaml:"description" json:"description,omitempty"` // reported when the check fails
+	where       filter.Filter
+}
+
+// compile validates the rule and parses its expressions
+func (r *Rule) compile() error {
+	if r.ID == "" {
+		return fmt.Errorf("rule without id")
+	}
+	if r.Title == "" {
+		return fmt.Errorf("rule %s: missing title", r.ID)
+	}
+	r.Severity = strings.ToLower(r.Severity)
+	if SeverityRank(r.Severity) == 0 {
+		return fmt.Errorf("rule %s: invalid severity '%s' (supported: %s)", r.ID, r.Severity, strings.Join(Severities, ", "))
+	}
+	if r.Require == "" && len(r.Items) == 0 && len(r.Related) == 0 {
+		return fmt.Errorf("rule %s: a rule needs at least one of require, items or related", r.ID)
+	}
+
+	selector, err := r.Select.build()
+	if err != nil {
+		return fmt.Errorf("rule %s: invalid selector: %w", r.ID, err)
+	}
+	r.selector = selector
+
+	if r.Require != "" {
+		if r.require, err = filter.ParseExpression(r.Require); err != nil {
+			return fmt.Errorf("rule %s: invalid require expression: %w", r.ID, err)
+		}
+	}
+
+	for i := range r.Items {
+		check := &r.Items[i]
+		if check.Property == "" {
+			return fmt.Errorf("rule %s: item check %d has no property", r.ID, i+1)
+		}
+		if check.Match, err = parseMatch(check.Match); err != nil {
+			return fmt.Errorf("rule %s: item check %d: %w", r.ID, i+1, err)
+		}
+		if check.Where == "" {
+			return fmt.Errorf("rule %s: item check %d has no where expression", r.ID, i+1)
+		}
+		if check.where, err = filter.ParseExpression(check.Where); err != nil {
+			return fmt.Errorf("rule %s: item check %d: invalid where expression: %w", r.ID, i+1, err)
+		}
+	}
+
+	for i := range r.Related {
+		check := &r.Related[i]
+		if check.Match, err = parseMatch(check.Match); err != nil {
+			return fmt.Errorf("rule %s: related check %d: %w", r.ID, i+1, err)
+		}
+		switch resource.Direction(check.Direction) {
+		case "":
+			check.Direction = string(resource.DirectionOutgoing)
+		case resource.DirectionOutgoing, resource.DirectionIncoming, resource.DirectionBoth:
+		default:
+			return fmt.Errorf("rule %s: related check %d: invalid direction '%s', expected out, in or any", r.ID, i+1, check.Direction)
+		}
+		if check.Where != "" {
+			if check.where, err = filter.ParseExpression(check.Where); err != nil {
+				return fmt.Errorf("rule %s: related check %d: invalid where expression: %w", r.ID, i+1, err)
+			}
+		}
+	}
+
+	return nil
+}
+
+// build constructs the filter of the selector
+func (s Selector) build() (filter.Filter, error) {
+	var filters []filter.Filter
+
+	if len(s.Types) > 0 {
+		filters = append(filters, filter.ParseTypeFilter(s.Types))
+	}
+	if len(s.Providers) > 0 {
+		filters = append(filters, filter.ParseProviderFilter(s.Providers))
+	}
+	for _, tagExpr := range s.Tags {
+		f, err := filter.ParseTagFilter(tagExpr)
+		if err != nil {
+			return nil, fmt.Errorf("invalid tag filter '%s': %w", tagExpr, err)
+		}
+		filters = append(filters, f)
+	}
+	for _, propExpr := range s.Properties {
+		f, err := filter.ParsePropertyFilter(propExpr)
+		if err != nil {
+			return nil, fmt.Errorf("invalid property filter '%s': %w", propExpr, err)
+		}
+		filters = append(filters, f)
+	}
+	if s.Where != "" {
+		f, err := filter.ParseExpression(s.Where)
+		if err != nil {
+			return nil, fmt.Errorf("invalid where expression: %w", err)
+		}
+		filters = append(filters, f)
+	}
+
+	return &filter.CompositeFilter{Filters: filters, Logic: filter.LogicAND}, nil
+}
+
+// parseMatch validates a check quantifier
+func parseMatch(match string) (string, error) {
+	switch match = strings.ToLower(match); match {
+	case MatchAny, MatchAll, MatchNone:
+		return match, nil
+	case "":
+		return "", fmt.Errorf("missing match (any, all or none)")
+	}
+	return "", fmt.Errorf("invalid match '%s', expected any, all or none", match)
+}
+
+// SeverityRank orders finding severities: 0 for unknown severities, then info to critical
+func SeverityRank(severity string) int {
+	for i, s := range Severities {
+		if strings.EqualFold(s, severity) {
+			return i + 1
+		}
+	}
+	return 0
+}
+
+// BuiltinRules returns the rules of the built-in rule pack
+func BuiltinRules() ([]*Rule, error) {
+	entries, err := fs.ReadDir(builtinRulesFS, "rules")
+	if err != nil {
+		return nil, fmt.Errorf("failed to read built-in rules: %w", err)
+	}
+
+	var rules []*Rule
+	for _, entry := range entries {
+		content, err := builtinRulesFS.ReadFile("rules/" + entry.Name())
+		if err != nil {
+			return nil, fmt.Errorf("failed to read built-in rules %s: %w", entry.Name(), err)
+		}
+		fileRules, err := parseRules(content, "builtin:"+entry.Name())
+		if err != nil {
+			return nil, err
+		}
+		rules = append(rules, fileRules...)
+	}
+
+	return rules, nil
+}
+
+// LoadRules loads the rules of YAML files, or of every *.yaml and *.yml file of directories
+func LoadRules(paths []string) ([]*Rule, error) {
+	var rules []*Rule
+	for _, path := range paths {
+		info, err := os.Stat(path)
+		if err != nil {
+			return nil, fmt.Errorf("failed to read rules %s: %w", path, err)
+		}
+
+		files := []string{path}
+		if info.IsDir() {
+			files = nil
+			for _, pattern := range []string{"*.yaml", "*.yml"} {
+				matches, err := filepath.Glob(filepath.Join(path, pattern))
+				if err != nil {
+					return nil, fmt.Errorf("failed to list rules in %s: %w", path, err)
+				}
+				files = append(files, matches...)
+			}
+			sort.Strings(files)
+		}
+
+		for _, file := range files {
+			// #nosec G304 - rule files are provided by user as CLI arguments or config, this is expected behavior
+			content, err := os.ReadFile(file)
+			if err != nil {
+				return nil, fmt.Errorf("failed to read rules %s: %w", file, err)
+			}
+			fileRules, err := parseRules(content, file)
+			if err != nil {
+				return nil, err
+			}
+			rules = append(rules, fileRules...)
+		}
+	}
+
+	return rules, nil
+}
+
+// parseRules parses and compiles the rules of a policy file
+func parseRules(content []byte, source string) ([]*Rule, error) {
+	var file RuleFile
+	if err := yaml.Unmarshal(content, &file); err != nil {
+		return nil, fmt.Errorf("failed to parse rules %s: %w", source, err)
+	}
+
+	for _, rule := range file.Rules {
+		rule.Source = source
+		if err := rule.compile(); err != nil {
+			return nil, fmt.Errorf("invalid rule in %s: %w", source, err)
+		}
+	}
+
+	return file.Rules, nil
+}
@@ -0,0 +1,138 @@
+package policy
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/comfortablynumb/pmp-cloud-inspector/pkg/resource"
+)
+
+func TestBuiltinRulesCompile(t *testing.T) {
+	rules, err := BuiltinRules()
+	if err != nil {
+		t.Fatalf("BuiltinRules failed: %v", err)
+	}
+	if len(rules) == 0 {
+		t.Fatal("no built-in rules")
+	}
+
+	seen := make(map[string]string)
+	for _, rule := range rules {
+		if source, ok := seen[rule.ID]; ok {
+			t.Errorf("duplicate rule %s in %s and %s", rule.ID, source, rule.Source)
+		}
+		seen[rule.ID] = rule.Source
+
+		if !strings.HasPrefix(rule.Source, "builtin:") {
+			t.Errorf("rule %s has source %q", rule.ID, rule.Source)
+		}
+		if rule.selector == nil {
+			t.Errorf("rule %s has no compiled selector", rule.ID)
+		}
+	}
+
+	// The engine accepts the built-in rule pack as is
+	if _, err := NewEngine(Settings{}); err != nil {
+		t.Fatalf("NewEngine failed: %v", err)
+	}
+}
+
+func TestSecurityGroupRules(t *testing.T) {
+	ingress := func(protocol string, from, to int32, cidrs ...string) map[string]interface{} {
+		rule := map[string]interface{}{"ip_protocol": protocol}
+		if protocol != "-1" {
+			rule["from_port"], rule["to_port"] = from, to
+		}
+		var ipv4, ipv6 []string
+		for _, cidr := range cidrs {
+			if strings.Contains(cidr, ":") {
+				ipv6 = append(ipv6, cidr)
+			} else {
+				ipv4 = append(ipv4, cidr)
+			}
+		}
+		if len(ipv4) > 0 {
+			rule["cidr_blocks"] = ipv4
+		}
+		if len(ipv6) > 0 {
+			rule["ipv6_cidr_blocks"] = ipv6
+		}
+		return rule
+	}
+
+	tests := []struct {
+		name    string
+		rules   []map[string]interface{}
+		wantSSH bool
+		wantAll bool
+	}{
+		{
+			name:    "ssh from the internet",
+			rules:   []map[string]interface{}{ingress("tcp", 22, 22, "0.0.0.0/0")},
+			wantSSH: true,
+		},
+		{
+			name:    "port range including ssh from the internet over IPv6",
+			rules:   []map[string]interface{}{ingress("tcp", 0, 65535, "::/0")},
+			wantSSH: true,
+		},
+		{
+			name:  "ssh from a private range",
+			rules: []map[string]interface{}{ingress("tcp", 22, 22, "10.0.0.0/8")},
+		},
+		{
+			name:  "https from the internet",
+			rules: []map[string]interface{}{ingress("tcp", 443, 443, "0.0.0.0/0", "::/0")},
+		},
+		{
+			name:    "all traffic from the internet",
+			rules:   []map[string]interface{}{ingress("tcp", 443, 443, "0.0.0.0/0"), ingress("-1", 0, 0, "10.0.0.0/8", "::/0")},
+			wantAll: true,
+		},
+		{
+			name:  "all traffic from a private range",
+			rules: []map[string]interface{}{ingress("-1", 0, 0, "10.0.0.0/16")},
+		},
+		{
+			name: "no ingress rules",
+		},
+	}
+
+	engine, err := NewEngine(Settings{})
+	if err != nil {
+		t.Fatalf("NewEngine failed: %v", err)
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			properties := map[string]interface{}{}
+			if tt.rules != nil {
+				properties["ingress_rules"] = tt.rules
+			}
+			collection := resource.NewCollection()
+			collection.Add(&resource.Resource{ID: "sg-1", Type: resource.TypeAWSSecurityGroup, Provider: "aws", Properties: properties})
+			// Other resource types are not selected by the security group rules
+			collection.Add(&resource.Resource{ID: "subnet-1", Type: resource.TypeAWSSubnet, Provider: "aws", Properties: properties})
+
+			report := engine.Evaluate(collection)
+
+			found := make(map[string]bool)
+			for _, finding := range report.Findings {
+				if finding.RuleID != "aws-sg-open-ssh" && finding.RuleID != "aws-sg-open-all-traffic" {
+					continue
+				}
+				if finding.ResourceID != "sg-1" {
+					t.Errorf("rule %s raised a finding on %s", finding.RuleID, finding.ResourceID)
+				}
+				found[finding.RuleID] = true
+			}
+
+			if found["aws-sg-open-ssh"] != tt.wantSSH {
+				t.Errorf("got open SSH finding %v, want %v", found["aws-sg-open-ssh"], tt.wantSSH)
+			}
+			if found["aws-sg-open-all-traffic"] != tt.wantAll {
+				t.Errorf("got open all traffic finding %v, want %v", found["aws-sg-open-all-traffic"], tt.wantAll)
+			}
+		})
+	}
+}
@@ -0,0 +1,171 @@
+package policy
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+)
+
+// SARIF identifies the tool in SARIF logs
+const (
+	sarifSchema   = "https://json.schemastore.org/sarif-2.1.0.json"
+	sarifVersion  = "2.1.0"
+	sarifToolName = "pmp-cloud-inspector"
+	sarifToolURI  = "https://github.com/comfortablynumb/pmp-cloud-inspector"
+)
+
+// sarifLevels maps finding severities to SARIF result levels
+var sarifLevels = map[string]string{
+	SeverityCritical: "error",
+	SeverityHigh:     "error",
+	SeverityMedium:   "warning",
+	SeverityLow:      "note",
+	SeverityInfo:     "note",
+}
+
+// securitySeverities maps finding severities to the security-severity scores used by code
+// scanning tools to rank results
+var securitySeverities = map[string]string{
+	SeverityCritical: "9.5",
+	SeverityHigh:     "8.0",
+	SeverityMedium:   "5.5",
+	SeverityLow:      "3.0",
+	SeverityInfo:     "0.0",
+}
+
+type sarifLog struct {
+	Schema  string     `json:"$schema"`
+	Version string     `json:"version"`
+	Runs    []sarifRun `json:"runs"`
+}
+
+type sarifRun struct {
+	Tool    sarifTool     `json:"tool"`
+	Results []sarifResult `json:"results"`
+}
+
+type sarifTool struct {
+	Driver sarifDriver `json:"driver"`
+}
+
+type sarifDriver struct {
+	Name           string      `json:"name"`
+	InformationURI string      `json:"informationUri"`
+	Rules          []sarifRule `json:"rules"`
+}
+
+type sarifRule struct {
+	ID                   string                 `json:"id"`
+	ShortDescription     sarifMessage           `json:"shortDescription"`
+	FullDescription      *sarifMessage          `json:"fullDescription,omitempty"`
+	Help                 *sarifMessage          `json:"help,omitempty"`
+	HelpURI              string                 `json:"helpUri,omitempty"`
+	DefaultConfiguration sarifConfiguration     `json:"defaultConfiguration"`
+	Properties           map[string]interface{} `json:"properties"`
+}
+
+type sarifConfiguration struct {
+	Level string `json:"level"`
+}
+
+type sarifMessage struct {
+	Text string `json:"text"`
+}
+
+type sarifResult struct {
+	RuleID              string                 `json:"ruleId"`
+	RuleIndex           int                    `json:"ruleIndex"`
+	Level               string                 `json:"level"`
+	Message             sarifMessage           `json:"message"`
+	Locations           []sarifLocation        `json:"locations"`
+	PartialFingerprints map[string]string      `json:"partialFingerprints"`
+	Properties          map[string]interface{} `json:"properties"`
+}
+
+type sarifLocation struct {
+	LogicalLocations []sarifLogicalLocation `json:"logicalLocations"`
+}
+
+type sarifLogicalLocation struct {
+	Name               string `json:"name"`
+	FullyQualifiedName string `json:"fullyQualifiedName"`
+	Kind               string `json:"kind"`
+}
+
+// WriteSARIF writes the report as a SARIF 2.1.0 log. Resources are reported as logical
+// locations, identified by their resource ID.
+func (r *Report) WriteSARIF(writer io.Writer) error {
+	run := sarifRun{
+		Tool: sarifTool{Driver: sarifDriver{
+			Name:           sarifToolName,
+			InformationURI: sarifToolURI,
+			Rules:          make([]sarifRule, 0, len(r.Rules)),
+		}},
+		Results: make([]sarifResult, 0, len(r.Findings)),
+	}
+
+	ruleIndex := make(map[string]int, len(r.Rules))
+	for i, rule := range r.Rules {
+		ruleIndex[rule.ID] = i
+
+		properties := map[string]interface{}{
+			"severity":          rule.Severity,
+			"security-severity": securitySeverities[rule.Severity],
+		}
+		if rule.Category != "" {
+			properties["tags"] = []string{rule.Category}
+		}
+
+		sarif := sarifRule{
+			ID:                   rule.ID,
+			ShortDescription:     sarifMessage{Text: rule.Title},
+			DefaultConfiguration: sarifConfiguration{Level: sarifLevels[rule.Severity]},
+			Properties:           properties,
+		}
+		if rule.Description != "" {
+			sarif.FullDescription = &sarifMessage{Text: rule.Description}
+		}
+		if rule.Remediation != "" {
+			sarif.Help = &sarifMessage{Text: rule.Remediation}
+		}
+		if len(rule.References) > 0 {
+			sarif.HelpURI = rule.References[0]
+		}
+		run.Tool.Driver.Rules = append(run.Tool.Driver.Rules, sarif)
+	}
+
+	for _, finding := range r.Findings {
+		properties := map[string]interface{}{
+			"resource_type": finding.ResourceType,
+			"provider":      finding.Provider,
+		}
+		if finding.Account != "" {
+			properties["account"] = finding.Account
+		}
+		if finding.Region != "" {
+			properties["region"] = finding.Region
+		}
+
+		run.Results = append(run.Results, sarifResult{
+			RuleID:    finding.RuleID,
+			RuleIndex: ruleIndex[finding.RuleID],
+			Level:     sarifLevels[finding.Severity],
+			Message:   sarifMessage{Text: fmt.Sprintf("%s: %s", finding.Name, finding.Message)},
+			Locations: []sarifLocation{{LogicalLocations: []sarifLogicalLocation{{
+				Name:               finding.Name,
+				FullyQualifiedName: finding.ResourceID,
+				Kind:               "resource",
+			}}}},
+			PartialFingerprints: map[string]string{"resourceId/v1": finding.ResourceID},
+			Properties:          properties,
+		})
+	}
+
+	encoder := json.NewEncoder(writer)
+	encoder.SetIndent("", "  ")
+	if err := encoder.Encode(sarifLog{Schema: sarifSchema, Version: sarifVersion, Runs: []sarifRun{run}}); err != nil {
+		return fmt.Errorf("failed to encode SARIF: %w", err)
+	}
+
+	return nil
+}
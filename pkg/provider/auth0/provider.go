@@ -139,8 +139,18 @@ func (p *Provider) CollectResources(ctx context.Context, types []resource.Resour
 
 // DiscoverRelationships analyzes resources and establishes relationships between them
 func (p *Provider) DiscoverRelationships(ctx context.Context, collection *resource.Collection) error {
+	// Resource servers are referenced by their identifier in role permissions
+	resourceServers := make(map[string]string)
+	for _, res := range collection.Resources {
+		if res.Type == resource.TypeAuth0ResourceServer {
+			if identifier, ok := res.Properties["identifier"].(string); ok {
+				resourceServers[identifier] = res.ID
+			}
+		}
+	}
+
 	// Build relationships based on Auth0 resource structure
-	// Example: Users -> Roles, Clients -> Resource Servers, etc.
+	// Example: Users -> Roles, Roles -> Resource Servers, etc.
 	for _, res := range collection.Resources {
 		switch res.Type {
 		case resource.TypeAuth0User:
@@ -151,15 +161,73 @@ func (p *Provider) DiscoverRelationships(ctx context.Context, collection *resour
 					for _, role := range roleList.Roles {
 						if role.ID != nil {
 							res.Relationships = append(res.Relationships, resource.Relationship{
-								Type:       "has_role",
+								Type:       resource.RelationHasAccess,
 								TargetID:   *role.ID,
 								TargetType: resource.TypeAuth0Role,
+								Properties: map[string]interface{}{
+									resource.PropPermission: role.GetName(),
+								},
 							})
 						}
 					}
 				}
 			}
+		case resource.TypeAuth0Role:
+			// Roles grant permissions on resource servers
+			if err := p.discoverRolePermissions(ctx, res, resourceServers); err != nil {
+				fmt.Fprintf(os.Stderr, "    Warning: failed to discover permissions of role %s: %v\n", res.Name, err)
+			}
+		}
+	}
+
+	return nil
+}
+
+// discoverRolePermissions adds has_access relationships from a role to the resource servers it
+// grants permissions on, with the granted permissions as actions
+func (p *Provider) discoverRolePermissions(ctx context.Context, role *resource.Resource, resourceServers map[string]string) error {
+	var (
+		identifiers []string
+		actions     = make(map[string][]string)
+	)
+
+	for page := 0; ; page++ {
+		permissions, err := p.client.Role.Permissions(ctx, role.ID, management.Page(page), management.PerPage(100))
+		if err != nil {
+			return err
+		}
+		if permissions == nil {
+			break
+		}
+
+		for _, permission := range permissions.Permissions {
+			identifier := permission.GetResourceServerIdentifier()
+			if _, ok := actions[identifier]; !ok {
+				identifiers = append(identifiers, identifier)
+			}
+			actions[identifier] = append(actions[identifier], permission.GetName())
+		}
+
+		if !permissions.HasNext() {
+			break
+		}
+	}
+
+	for _, identifier := range identifiers {
+		// Fall back to the identifier for resource servers that were not collected
+		targetID, ok := resourceServers[identifier]
+		if !ok {
+			targetID = identifier
 		}
+		role.Relationships = append(role.Relationships, resource.Relationship{
+			Type:       resource.RelationHasAccess,
+			TargetID:   targetID,
+			TargetType: resource.TypeAuth0ResourceServer,
+			Properties: map[string]interface{}{
+				resource.PropActions: actions[identifier],
+				"identifier":         identifier,
+			},
+		})
 	}
 
 	return nil
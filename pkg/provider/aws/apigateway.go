@@ -4,6 +4,8 @@ import (
 	"context"
 	"fmt"
 	"os"
+	"slices"
+	"strings"
 
 	"github.com/aws/aws-sdk-go-v2/aws"
 	"github.com/aws/aws-sdk-go-v2/service/apigateway"
@@ -16,21 +18,82 @@ import (
 
 // collectAPIGatewayAPIs collects both REST APIs (v1) and HTTP APIs (v2) in a region
 func (p *Provider) collectAPIGatewayAPIs(ctx context.Context, collection *resource.Collection, region string, cfg aws.Config) error {
+	// Custom domain names relate the APIs to the DNS records aliased to them; they are optional
+	domains, err := listAPIDomainNames(ctx, apigatewayv2.NewFromConfig(cfg))
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "    Warning: failed to list API Gateway domain names in %s: %v\n", region, err)
+	}
+
 	// Collect REST APIs (v1)
-	if err := p.collectRESTAPIs(ctx, collection, region, cfg); err != nil {
+	if err := p.collectRESTAPIs(ctx, collection, region, cfg, domains); err != nil {
 		return err
 	}
 
 	// Collect HTTP APIs (v2)
-	if err := p.collectHTTPAPIs(ctx, collection, region, cfg); err != nil {
+	if err := p.collectHTTPAPIs(ctx, collection, region, cfg, domains); err != nil {
 		return err
 	}
 
 	return nil
 }
 
+// listAPIDomainNames returns the custom domain names mapped to the REST and HTTP APIs of a
+// region, by API ID, with the API Gateway domain name their DNS records point to
+func listAPIDomainNames(ctx context.Context, client *apigatewayv2.Client) (map[string][]map[string]interface{}, error) {
+	domains := make(map[string][]map[string]interface{})
+
+	input := &apigatewayv2.GetDomainNamesInput{}
+	for {
+		output, err := client.GetDomainNames(ctx, input)
+		if err != nil {
+			return domains, err
+		}
+
+		for _, domain := range output.Items {
+			var target map[string]interface{}
+			if len(domain.DomainNameConfigurations) > 0 {
+				config := domain.DomainNameConfigurations[0]
+				target = map[string]interface{}{
+					"api_gateway_domain_name": safeString(config.ApiGatewayDomainName),
+					"endpoint_type":           string(config.EndpointType),
+				}
+				if config.CertificateArn != nil {
+					target["certificate_arn"] = *config.CertificateArn
+				}
+			}
+
+			mappings, err := client.GetApiMappings(ctx, &apigatewayv2.GetApiMappingsInput{DomainName: domain.DomainName})
+			if err != nil {
+				fmt.Fprintf(os.Stderr, "    Warning: failed to get API mappings of domain %s: %v\n", safeString(domain.DomainName), err)
+				continue
+			}
+			for _, mapping := range mappings.Items {
+				entry := map[string]interface{}{
+					"domain_name": safeString(domain.DomainName),
+					"stage":       safeString(mapping.Stage),
+				}
+				if mapping.ApiMappingKey != nil {
+					entry["base_path"] = *mapping.ApiMappingKey
+				}
+				for key, value := range target {
+					entry[key] = value
+				}
+				apiID := safeString(mapping.ApiId)
+				domains[apiID] = append(domains[apiID], entry)
+			}
+		}
+
+		if output.NextToken == nil || *output.NextToken == "" {
+			break
+		}
+		input.NextToken = output.NextToken
+	}
+
+	return domains, nil
+}
+
 // collectRESTAPIs collects REST APIs (API Gateway v1)
-func (p *Provider) collectRESTAPIs(ctx context.Context, collection *resource.Collection, region string, cfg aws.Config) error {
+func (p *Provider) collectRESTAPIs(ctx context.Context, collection *resource.Collection, region string, cfg aws.Config, domains map[string][]map[string]interface{}) error {
 	fmt.Fprintf(os.Stderr, "  Collecting API Gateway REST APIs in %s...\n", region)
 	client := apigateway.NewFromConfig(cfg)
 
@@ -45,6 +108,14 @@ func (p *Provider) collectRESTAPIs(ctx context.Context, collection *resource.Col
 
 		for _, api := range output.Items {
 			res := p.convertRESTAPIToResource(&api, region)
+			if len(domains[res.ID]) > 0 {
+				res.Properties["custom_domains"] = domains[res.ID]
+			}
+			integrations, err := restAPIIntegrations(ctx, client, res.ID)
+			if err != nil {
+				fmt.Fprintf(os.Stderr, "    Warning: failed to get integrations of REST API %s: %v\n", res.ID, err)
+			}
+			addAPIIntegrations(res, integrations)
 			collection.Add(res)
 			count++
 			fmt.Fprintf(os.Stderr, "    Found REST API: %s (%s)\n", safeString(api.Name), safeString(api.Id))
@@ -56,7 +127,7 @@ func (p *Provider) collectRESTAPIs(ctx context.Context, collection *resource.Col
 }
 
 // collectHTTPAPIs collects HTTP APIs (API Gateway v2)
-func (p *Provider) collectHTTPAPIs(ctx context.Context, collection *resource.Collection, region string, cfg aws.Config) error {
+func (p *Provider) collectHTTPAPIs(ctx context.Context, collection *resource.Collection, region string, cfg aws.Config, domains map[string][]map[string]interface{}) error {
 	fmt.Fprintf(os.Stderr, "  Collecting API Gateway HTTP APIs in %s...\n", region)
 	client := apigatewayv2.NewFromConfig(cfg)
 
@@ -75,6 +146,14 @@ func (p *Provider) collectHTTPAPIs(ctx context.Context, collection *resource.Col
 
 		for _, api := range output.Items {
 			res := p.convertHTTPAPIToResource(&api, region)
+			if len(domains[res.ID]) > 0 {
+				res.Properties["custom_domains"] = domains[res.ID]
+			}
+			integrations, err := httpAPIIntegrations(ctx, client, res.ID)
+			if err != nil {
+				fmt.Fprintf(os.Stderr, "    Warning: failed to get integrations of HTTP API %s: %v\n", res.ID, err)
+			}
+			addAPIIntegrations(res, integrations)
 			collection.Add(res)
 			count++
 			fmt.Fprintf(os.Stderr, "    Found HTTP API: %s (%s)\n", safeString(api.Name), safeString(api.ApiId))
@@ -90,6 +169,133 @@ func (p *Provider) collectHTTPAPIs(ctx context.Context, collection *resource.Col
 	return nil
 }
 
+// restAPIIntegrations returns the integrations of the methods of a REST API
+func restAPIIntegrations(ctx context.Context, client *apigateway.Client, apiID string) ([]map[string]interface{}, error) {
+	var integrations []map[string]interface{}
+
+	paginator := apigateway.NewGetResourcesPaginator(client, &apigateway.GetResourcesInput{
+		RestApiId: aws.String(apiID),
+		Embed:     []string{"methods"},
+	})
+	for paginator.HasMorePages() {
+		output, err := paginator.NextPage(ctx)
+		if err != nil {
+			return integrations, err
+		}
+
+		for _, apiResource := range output.Items {
+			httpMethods := make([]string, 0, len(apiResource.ResourceMethods))
+			for httpMethod := range apiResource.ResourceMethods {
+				httpMethods = append(httpMethods, httpMethod)
+			}
+			slices.Sort(httpMethods)
+
+			for _, httpMethod := range httpMethods {
+				integration := apiResource.ResourceMethods[httpMethod].MethodIntegration
+				if integration == nil {
+					continue
+				}
+				integrations = append(integrations, map[string]interface{}{
+					"path":            safeString(apiResource.Path),
+					"method":          httpMethod,
+					"type":            string(integration.Type),
+					"uri":             safeString(integration.Uri),
+					"connection_type": string(integration.ConnectionType),
+				})
+			}
+		}
+	}
+
+	return integrations, nil
+}
+
+// httpAPIIntegrations returns the integrations of an HTTP or WebSocket API
+func httpAPIIntegrations(ctx context.Context, client *apigatewayv2.Client, apiID string) ([]map[string]interface{}, error) {
+	var integrations []map[string]interface{}
+
+	input := &apigatewayv2.GetIntegrationsInput{ApiId: aws.String(apiID)}
+	for {
+		output, err := client.GetIntegrations(ctx, input)
+		if err != nil {
+			return integrations, err
+		}
+
+		for _, integration := range output.Items {
+			integrations = append(integrations, map[string]interface{}{
+				"integration_id":  safeString(integration.IntegrationId),
+				"type":            string(integration.IntegrationType),
+				"uri":             safeString(integration.IntegrationUri),
+				"connection_type": string(integration.ConnectionType),
+			})
+		}
+
+		if output.NextToken == nil || *output.NextToken == "" {
+			break
+		}
+		input.NextToken = output.NextToken
+	}
+
+	return integrations, nil
+}
+
+// addAPIIntegrations adds the integrations of an API to its resource, with a triggers
+// relationship to every Lambda function it invokes and a routes_to relationship to every load
+// balancer it reaches through a VPC link
+func addAPIIntegrations(res *resource.Resource, integrations []map[string]interface{}) {
+	if len(integrations) == 0 {
+		return
+	}
+	res.Properties["integrations"] = integrations
+
+	var targets []string
+	for _, integration := range integrations {
+		uri, _ := integration["uri"].(string)
+		targetARN := integrationTargetARN(uri)
+		if targetARN == "" || slices.Contains(targets, targetARN) {
+			continue
+		}
+		targets = append(targets, targetARN)
+
+		switch targetType := arnResourceType(targetARN); targetType {
+		case resource.TypeAWSLambda:
+			res.Relationships = append(res.Relationships, resource.Relationship{
+				Type:       resource.RelationTriggers,
+				TargetID:   targetARN,
+				TargetType: targetType,
+			})
+		case resource.TypeAWSALB, resource.TypeAWSNLB:
+			res.Relationships = append(res.Relationships, resource.Relationship{
+				Type:       resource.RelationRoutesTo,
+				TargetID:   targetARN,
+				TargetType: targetType,
+			})
+		}
+	}
+}
+
+// integrationTargetARN returns the ARN of the Lambda function or load balancer an integration URI
+// refers to, or an empty string for other integrations. REST APIs invoke functions through an
+// invocation URI wrapping the function ARN, and HTTP APIs reach load balancers through the ARN of
+// one of their listeners.
+func integrationTargetARN(uri string) string {
+	// arn:aws:apigateway:region:lambda:path/2015-03-31/functions/<function ARN>/invocations
+	if _, function, found := strings.Cut(uri, ":lambda:path/2015-03-31/functions/"); found {
+		uri = strings.TrimSuffix(function, "/invocations")
+	}
+
+	// arn:aws:elasticloadbalancing:region:account:listener/app/name/id/listener-id
+	if prefix, listener, found := strings.Cut(uri, ":listener/"); found && strings.Contains(prefix, ":elasticloadbalancing:") {
+		if i := strings.LastIndex(listener, "/"); i > 0 {
+			uri = prefix + ":loadbalancer/" + listener[:i]
+		}
+	}
+
+	if !strings.HasPrefix(uri, "arn:") {
+		return ""
+	}
+	return unqualifiedARN(uri)
+}
+
 // convertRESTAPIToResource converts a REST API to a Resource
 func (p *Provider) convertRESTAPIToResource(api *apigwTypes.RestApi, region string) *resource.Resource {
 	var account string
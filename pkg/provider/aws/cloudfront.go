@@ -68,6 +68,9 @@ func (p *Provider) convertCloudFrontDistributionToResource(dist *cloudfrontTypes
 	if dist.WebACLId != nil {
 		properties["web_acl_id"] = *dist.WebACLId
 	}
+	if dist.Origins != nil && len(dist.Origins.Items) > 0 {
+		properties["origins"] = cloudFrontOrigins(dist.Origins.Items)
+	}
 
 	res := &resource.Resource{
 		ID:         safeString(dist.Id),
@@ -88,6 +91,30 @@ func (p *Provider) convertCloudFrontDistributionToResource(dist *cloudfrontTypes
 	return res
 }
 
+// cloudFrontOrigins returns the origins of a distribution. The resources serving them are
+// resolved by discoverCloudFrontOrigins, from their domain names.
+func cloudFrontOrigins(origins []cloudfrontTypes.Origin) []map[string]interface{} {
+	items := make([]map[string]interface{}, 0, len(origins))
+	for _, origin := range origins {
+		item := map[string]interface{}{
+			"id":          safeString(origin.Id),
+			"domain_name": safeString(origin.DomainName),
+		}
+		if origin.OriginPath != nil && *origin.OriginPath != "" {
+			item["origin_path"] = *origin.OriginPath
+		}
+		switch {
+		case origin.S3OriginConfig != nil:
+			item["origin_type"] = "s3"
+		case origin.CustomOriginConfig != nil:
+			item["origin_type"] = "custom"
+			item["protocol_policy"] = string(origin.CustomOriginConfig.OriginProtocolPolicy)
+		}
+		items = append(items, item)
+	}
+	return items
+}
+
 // safeBool safely dereferences a bool pointer
 func safeBool(b *bool) bool {
 	if b == nil {
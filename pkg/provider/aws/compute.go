@@ -4,6 +4,7 @@ import (
 	"context"
 	"fmt"
 	"os"
+	"slices"
 
 	"github.com/aws/aws-sdk-go-v2/aws"
 	"github.com/aws/aws-sdk-go-v2/service/ec2"
@@ -21,6 +22,12 @@ func (p *Provider) collectEC2Instances(ctx context.Context, collection *resource
 	fmt.Fprintf(os.Stderr, "  Collecting EC2 instances in %s...\n", region)
 	client := ec2.NewFromConfig(cfg)
 
+	// Attached volumes are used for storage cost estimates; they are optional
+	volumes, err := describeAttachedVolumes(ctx, client)
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "    Warning: failed to describe volumes in %s: %v\n", region, err)
+	}
+
 	paginator := ec2.NewDescribeInstancesPaginator(client, &ec2.DescribeInstancesInput{})
 
 	count := 0
@@ -33,6 +40,9 @@ func (p *Provider) collectEC2Instances(ctx context.Context, collection *resource
 		for _, reservation := range output.Reservations {
 			for _, instance := range reservation.Instances {
 				res := p.convertEC2InstanceToResource(&instance, region)
+				if instanceVolumes := volumes[res.ID]; len(instanceVolumes) > 0 {
+					res.Properties[resource.PropVolumes] = instanceVolumes
+				}
 				collection.Add(res)
 				count++
 				fmt.Fprintf(os.Stderr, "    Found EC2 instance: %s (%s)\n", safeString(instance.InstanceId), instance.State.Name)
@@ -44,6 +54,39 @@ func (p *Provider) collectEC2Instances(ctx context.Context, collection *resource
 	return nil
 }
 
+// describeAttachedVolumes returns the EBS volumes attached to each instance, by instance ID
+func describeAttachedVolumes(ctx context.Context, client *ec2.Client) (map[string][]map[string]interface{}, error) {
+	volumes := make(map[string][]map[string]interface{})
+
+	paginator := ec2.NewDescribeVolumesPaginator(client, &ec2.DescribeVolumesInput{
+		Filters: []ec2Types.Filter{
+			{Name: aws.String("attachment.status"), Values: []string{"attached"}},
+		},
+	})
+
+	for paginator.HasMorePages() {
+		output, err := paginator.NextPage(ctx)
+		if err != nil {
+			return volumes, err
+		}
+
+		for _, volume := range output.Volumes {
+			for _, attachment := range volume.Attachments {
+				if attachment.InstanceId == nil {
+					continue
+				}
+				volumes[*attachment.InstanceId] = append(volumes[*attachment.InstanceId], map[string]interface{}{
+					resource.PropVolumeID:     safeString(volume.VolumeId),
+					resource.PropVolumeType:   string(volume.VolumeType),
+					resource.PropVolumeSizeGB: safeInt32(volume.Size),
+				})
+			}
+		}
+	}
+
+	return volumes, nil
+}
+
 // collectEKSClusters collects all EKS clusters in a region
 func (p *Provider) collectEKSClusters(ctx context.Context, collection *resource.Collection, region string, cfg aws.Config) error {
 	fmt.Fprintf(os.Stderr, "  Collecting EKS clusters in %s...\n", region)
@@ -84,6 +127,13 @@ func (p *Provider) collectLambdaFunctions(ctx context.Context, collection *resou
 	fmt.Fprintf(os.Stderr, "  Collecting Lambda functions in %s...\n", region)
 	client := lambda.NewFromConfig(cfg)
 
+	// Event source mappings relate the functions to the queues and streams invoking them; they
+	// are optional
+	eventSources, err := listEventSourceMappings(ctx, client)
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "    Warning: failed to list event source mappings in %s: %v\n", region, err)
+	}
+
 	paginator := lambda.NewListFunctionsPaginator(client, &lambda.ListFunctionsInput{})
 
 	count := 0
@@ -95,6 +145,12 @@ func (p *Provider) collectLambdaFunctions(ctx context.Context, collection *resou
 
 		for _, function := range output.Functions {
 			res := p.convertLambdaFunctionToResource(&function, region)
+			if sources := eventSources[safeString(function.FunctionArn)]; len(sources) > 0 {
+				res.Properties[resource.PropEventSources] = sources
+			}
+			if function.PackageType == lambdaTypes.PackageTypeImage {
+				p.describeLambdaImage(ctx, client, res)
+			}
 			collection.Add(res)
 			count++
 			fmt.Fprintf(os.Stderr, "    Found Lambda function: %s\n", safeString(function.FunctionName))
@@ -105,6 +161,36 @@ func (p *Provider) collectLambdaFunctions(ctx context.Context, collection *resou
 	return nil
 }
 
+// describeLambdaImage adds the container image of a function packaged as an image to its
+// resource, with a depends_on relationship to its ECR repository. The image is not listed with
+// the functions, and is optional: failures are reported as warnings.
+func (p *Provider) describeLambdaImage(ctx context.Context, client *lambda.Client, res *resource.Resource) {
+	output, err := client.GetFunction(ctx, &lambda.GetFunctionInput{FunctionName: aws.String(res.ID)})
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "    Warning: failed to get Lambda function %s: %v\n", res.ID, err)
+		return
+	}
+	if output.Code == nil || output.Code.ImageUri == nil {
+		return
+	}
+
+	image := *output.Code.ImageUri
+	res.Properties["image_uri"] = image
+	if output.Code.ResolvedImageUri != nil {
+		res.Properties["resolved_image_uri"] = *output.Code.ResolvedImageUri
+	}
+	if repositoryARN := ecrRepositoryARN(image); repositoryARN != "" {
+		res.Relationships = append(res.Relationships, resource.Relationship{
+			Type:       resource.RelationDependsOn,
+			TargetID:   repositoryARN,
+			TargetType: resource.TypeAWSECR,
+			Properties: map[string]interface{}{
+				"image": image,
+			},
+		})
+	}
+}
+
 // convertEC2InstanceToResource converts an EC2 instance to a Resource
 func (p *Provider) convertEC2InstanceToResource(instance *ec2Types.Instance, region string) *resource.Resource {
 	var account string
@@ -113,10 +199,13 @@ func (p *Provider) convertEC2InstanceToResource(instance *ec2Types.Instance, reg
 	}
 
 	properties := map[string]interface{}{
-		"instance_type": string(instance.InstanceType),
-		"state":         string(instance.State.Name),
+		resource.PropInstanceType: string(instance.InstanceType),
+		resource.PropState:        string(instance.State.Name),
 	}
 
+	if instance.PlatformDetails != nil {
+		properties[resource.PropPlatform] = *instance.PlatformDetails
+	}
 	if instance.PrivateIpAddress != nil {
 		properties["private_ip"] = *instance.PrivateIpAddress
 	}
@@ -132,6 +221,19 @@ func (p *Provider) convertEC2InstanceToResource(instance *ec2Types.Instance, reg
 	if instance.Placement != nil && instance.Placement.AvailabilityZone != nil {
 		properties["availability_zone"] = *instance.Placement.AvailabilityZone
 	}
+	if instance.IamInstanceProfile != nil && instance.IamInstanceProfile.Arn != nil {
+		properties["iam_instance_profile_arn"] = *instance.IamInstanceProfile.Arn
+	}
+
+	securityGroupIDs := make([]string, 0, len(instance.SecurityGroups))
+	for _, group := range instance.SecurityGroups {
+		if group.GroupId != nil {
+			securityGroupIDs = append(securityGroupIDs, *group.GroupId)
+		}
+	}
+	if len(securityGroupIDs) > 0 {
+		properties[resource.PropSecurityGroups] = securityGroupIDs
+	}
 
 	var tags map[string]string
 	var name string
@@ -183,6 +285,8 @@ func (p *Provider) convertEC2InstanceToResource(instance *ec2Types.Instance, reg
 		})
 	}
 
+	res.Relationships = append(res.Relationships, securityGroupRelationships(securityGroupIDs)...)
+
 	return res
 }
 
@@ -204,6 +308,9 @@ func (p *Provider) convertEKSClusterToResource(cluster *eksTypes.Cluster, region
 	if cluster.PlatformVersion != nil {
 		properties["platform_version"] = *cluster.PlatformVersion
 	}
+	if cluster.RoleArn != nil {
+		properties["role_arn"] = *cluster.RoleArn
+	}
 	if cluster.ResourcesVpcConfig != nil {
 		if cluster.ResourcesVpcConfig.VpcId != nil {
 			properties["vpc_id"] = *cluster.ResourcesVpcConfig.VpcId
@@ -211,6 +318,16 @@ func (p *Provider) convertEKSClusterToResource(cluster *eksTypes.Cluster, region
 		if len(cluster.ResourcesVpcConfig.SubnetIds) > 0 {
 			properties["subnet_ids"] = cluster.ResourcesVpcConfig.SubnetIds
 		}
+		properties["endpoint_public_access"] = cluster.ResourcesVpcConfig.EndpointPublicAccess
+		properties["endpoint_private_access"] = cluster.ResourcesVpcConfig.EndpointPrivateAccess
+		if len(cluster.ResourcesVpcConfig.PublicAccessCidrs) > 0 {
+			properties["public_access_cidrs"] = cluster.ResourcesVpcConfig.PublicAccessCidrs
+		}
+	}
+
+	securityGroupIDs := eksSecurityGroups(cluster.ResourcesVpcConfig)
+	if len(securityGroupIDs) > 0 {
+		properties[resource.PropSecurityGroups] = securityGroupIDs
 	}
 
 	var createdAt *resource.Resource
@@ -236,18 +353,39 @@ func (p *Provider) convertEKSClusterToResource(cluster *eksTypes.Cluster, region
 		res.CreatedAt = createdAt.CreatedAt
 	}
 
-	// Add VPC relationship
-	if cluster.ResourcesVpcConfig != nil && cluster.ResourcesVpcConfig.VpcId != nil {
+	// Add VPC and subnet relationships
+	if cluster.ResourcesVpcConfig != nil {
+		res.Relationships = append(res.Relationships, networkRelationships(safeString(cluster.ResourcesVpcConfig.VpcId), cluster.ResourcesVpcConfig.SubnetIds)...)
+	}
+	res.Relationships = append(res.Relationships, securityGroupRelationships(securityGroupIDs)...)
+
+	// Add cluster role relationship
+	if cluster.RoleArn != nil {
 		res.Relationships = append(res.Relationships, resource.Relationship{
-			Type:       resource.RelationBelongsTo,
-			TargetID:   *cluster.ResourcesVpcConfig.VpcId,
-			TargetType: resource.TypeAWSVPC,
+			Type:       resource.RelationAssumes,
+			TargetID:   *cluster.RoleArn,
+			TargetType: resource.TypeAWSIAMRole,
 		})
 	}
 
 	return res
 }
 
+// eksSecurityGroups returns the IDs of the security groups of an EKS cluster: the additional
+// groups of its control plane and the cluster security group created by EKS
+func eksSecurityGroups(vpcConfig *eksTypes.VpcConfigResponse) []string {
+	if vpcConfig == nil {
+		return nil
+	}
+
+	groupIDs := make([]string, 0, len(vpcConfig.SecurityGroupIds)+1)
+	groupIDs = append(groupIDs, vpcConfig.SecurityGroupIds...)
+	if vpcConfig.ClusterSecurityGroupId != nil && !slices.Contains(groupIDs, *vpcConfig.ClusterSecurityGroupId) {
+		groupIDs = append(groupIDs, *vpcConfig.ClusterSecurityGroupId)
+	}
+	return groupIDs
+}
+
 // convertLambdaFunctionToResource converts a Lambda function to a Resource
 func (p *Provider) convertLambdaFunctionToResource(function *lambdaTypes.FunctionConfiguration, region string) *resource.Resource {
 	var account string
@@ -264,10 +402,10 @@ func (p *Provider) convertLambdaFunctionToResource(function *lambdaTypes.Functio
 		properties["code_size"] = function.CodeSize
 	}
 	if function.MemorySize != nil {
-		properties["memory_size"] = *function.MemorySize
+		properties[resource.PropMemorySize] = *function.MemorySize
 	}
 	if function.Timeout != nil {
-		properties["timeout"] = *function.Timeout
+		properties[resource.PropTimeout] = *function.Timeout
 	}
 	if function.LastModified != nil {
 		properties["last_modified"] = *function.LastModified
@@ -275,6 +413,15 @@ func (p *Provider) convertLambdaFunctionToResource(function *lambdaTypes.Functio
 	if function.VpcConfig != nil && function.VpcConfig.VpcId != nil {
 		properties["vpc_id"] = *function.VpcConfig.VpcId
 	}
+	if function.VpcConfig != nil && len(function.VpcConfig.SecurityGroupIds) > 0 {
+		properties[resource.PropSecurityGroups] = function.VpcConfig.SecurityGroupIds
+	}
+	if function.VpcConfig != nil && len(function.VpcConfig.SubnetIds) > 0 {
+		properties["subnet_ids"] = function.VpcConfig.SubnetIds
+	}
+	if function.PackageType != "" {
+		properties["package_type"] = string(function.PackageType)
+	}
 
 	res := &resource.Resource{
 		ID:         safeString(function.FunctionName),
@@ -288,12 +435,18 @@ func (p *Provider) convertLambdaFunctionToResource(function *lambdaTypes.Functio
 		RawData:    function,
 	}
 
-	// Add VPC relationship if Lambda is in VPC
-	if function.VpcConfig != nil && function.VpcConfig.VpcId != nil {
+	// Add VPC, subnet and security group relationships if Lambda is in VPC
+	if function.VpcConfig != nil {
+		res.Relationships = append(res.Relationships, networkRelationships(safeString(function.VpcConfig.VpcId), function.VpcConfig.SubnetIds)...)
+		res.Relationships = append(res.Relationships, securityGroupRelationships(function.VpcConfig.SecurityGroupIds)...)
+	}
+
+	// Add execution role relationship
+	if function.Role != nil {
 		res.Relationships = append(res.Relationships, resource.Relationship{
-			Type:       resource.RelationBelongsTo,
-			TargetID:   *function.VpcConfig.VpcId,
-			TargetType: resource.TypeAWSVPC,
+			Type:       resource.RelationAssumes,
+			TargetID:   *function.Role,
+			TargetType: resource.TypeAWSIAMRole,
 		})
 	}
 
@@ -0,0 +1,729 @@
+package aws
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"regexp"
+	"slices"
+
+	"github.com/aws/aws-sdk-go-v2/aws"
+	"github.com/aws/aws-sdk-go-v2/service/ecs"
+	ecsTypes "github.com/aws/aws-sdk-go-v2/service/ecs/types"
+	"github.com/aws/aws-sdk-go-v2/service/eks"
+	eksTypes "github.com/aws/aws-sdk-go-v2/service/eks/types"
+	"github.com/aws/aws-sdk-go-v2/service/elasticloadbalancingv2"
+
+	"github.com/comfortablynumb/pmp-cloud-inspector/pkg/resource"
+)
+
+// ECS describe calls accept a limited number of clusters and services
+const (
+	ecsDescribeClustersBatch = 100
+	ecsDescribeServicesBatch = 10
+	targetGroupsBatch        = 20
+)
+
+// ecrImagePattern matches the images of ECR repositories, e.g.,
+// 123456789012.dkr.ecr.us-east-1.amazonaws.com/app:latest
+var ecrImagePattern = regexp.MustCompile(`^(\d{12})\.dkr\.ecr(?:-fips)?\.([a-z0-9-]+)\.amazonaws\.com(?:\.cn)?/([^:@]+)`)
+
+// collectECSResources collects ECS clusters, their services and the task definitions they use,
+// keeping the requested types. Task definitions are the latest revision of every active family
+// and the revisions used by services.
+func (p *Provider) collectECSResources(ctx context.Context, collection *resource.Collection, region string, cfg aws.Config, typeSet map[resource.ResourceType]bool) error {
+	fmt.Fprintf(os.Stderr, "  Collecting ECS clusters, services and task definitions in %s...\n", region)
+	client := ecs.NewFromConfig(cfg)
+
+	var clusterARNs []string
+	clusterPaginator := ecs.NewListClustersPaginator(client, &ecs.ListClustersInput{})
+	for clusterPaginator.HasMorePages() {
+		output, err := clusterPaginator.NextPage(ctx)
+		if err != nil {
+			return fmt.Errorf("failed to list ECS clusters: %w", err)
+		}
+		clusterARNs = append(clusterARNs, output.ClusterArns...)
+	}
+
+	clusterCount, serviceCount := 0, 0
+	var taskDefinitionARNs []string
+	targetGroups := newTargetGroupResolver(elasticloadbalancingv2.NewFromConfig(cfg), typeSet[resource.TypeAWSTargetGroup])
+	for batch := range slices.Chunk(clusterARNs, ecsDescribeClustersBatch) {
+		output, err := client.DescribeClusters(ctx, &ecs.DescribeClustersInput{
+			Clusters: batch,
+			Include:  []ecsTypes.ClusterField{ecsTypes.ClusterFieldTags, ecsTypes.ClusterFieldSettings},
+		})
+		if err != nil {
+			return fmt.Errorf("failed to describe ECS clusters: %w", err)
+		}
+
+		for _, cluster := range output.Clusters {
+			if typeSet[resource.TypeAWSECSCluster] {
+				collection.Add(p.convertECSClusterToResource(&cluster, region))
+				clusterCount++
+				fmt.Fprintf(os.Stderr, "    Found ECS cluster: %s (%d services)\n", safeString(cluster.ClusterName), cluster.ActiveServicesCount)
+			}
+
+			if !typeSet[resource.TypeAWSECSService] && !typeSet[resource.TypeAWSECSTaskDefinition] {
+				continue
+			}
+
+			services, err := describeECSServices(ctx, client, safeString(cluster.ClusterArn))
+			if err != nil {
+				fmt.Fprintf(os.Stderr, "    Warning: failed to describe services of ECS cluster %s: %v\n", safeString(cluster.ClusterName), err)
+				continue
+			}
+
+			for _, service := range services {
+				if service.TaskDefinition != nil && !slices.Contains(taskDefinitionARNs, *service.TaskDefinition) {
+					taskDefinitionARNs = append(taskDefinitionARNs, *service.TaskDefinition)
+				}
+				if !typeSet[resource.TypeAWSECSService] {
+					continue
+				}
+
+				res := p.convertECSServiceToResource(&service, region)
+				// Load balancers are found through the target groups of the service; they are optional
+				res.Relationships = append(res.Relationships, targetGroups.relationships(ctx, service.LoadBalancers)...)
+				collection.Add(res)
+				serviceCount++
+				fmt.Fprintf(os.Stderr, "    Found ECS service: %s (%d/%d tasks)\n", safeString(service.ServiceName), service.RunningCount, service.DesiredCount)
+			}
+		}
+	}
+
+	taskDefinitionCount := 0
+	if typeSet[resource.TypeAWSECSTaskDefinition] {
+		families := ecs.NewListTaskDefinitionFamiliesPaginator(client, &ecs.ListTaskDefinitionFamiliesInput{
+			Status: ecsTypes.TaskDefinitionFamilyStatusActive,
+		})
+		var latest []string
+		for families.HasMorePages() {
+			output, err := families.NextPage(ctx)
+			if err != nil {
+				return fmt.Errorf("failed to list ECS task definition families: %w", err)
+			}
+			latest = append(latest, output.Families...)
+		}
+
+		seen := make(map[string]bool)
+		for _, taskDefinition := range append(latest, taskDefinitionARNs...) {
+			output, err := client.DescribeTaskDefinition(ctx, &ecs.DescribeTaskDefinitionInput{
+				TaskDefinition: aws.String(taskDefinition),
+				Include:        []ecsTypes.TaskDefinitionField{ecsTypes.TaskDefinitionFieldTags},
+			})
+			if err != nil {
+				fmt.Fprintf(os.Stderr, "    Warning: failed to describe ECS task definition %s: %v\n", taskDefinition, err)
+				continue
+			}
+
+			// A family and the revision used by a service can be the same task definition
+			arn := safeString(output.TaskDefinition.TaskDefinitionArn)
+			if seen[arn] {
+				continue
+			}
+			seen[arn] = true
+
+			collection.Add(p.convertECSTaskDefinitionToResource(output.TaskDefinition, output.Tags, region))
+			taskDefinitionCount++
+			fmt.Fprintf(os.Stderr, "    Found ECS task definition: %s:%d\n", safeString(output.TaskDefinition.Family), output.TaskDefinition.Revision)
+		}
+	}
+
+	fmt.Fprintf(os.Stderr, "  Collected %d ECS clusters, %d services and %d task definitions in %s\n", clusterCount, serviceCount, taskDefinitionCount, region)
+	return nil
+}
+
+// describeECSServices returns the services of an ECS cluster
+func describeECSServices(ctx context.Context, client *ecs.Client, clusterARN string) ([]ecsTypes.Service, error) {
+	var serviceARNs []string
+	paginator := ecs.NewListServicesPaginator(client, &ecs.ListServicesInput{Cluster: aws.String(clusterARN)})
+	for paginator.HasMorePages() {
+		output, err := paginator.NextPage(ctx)
+		if err != nil {
+			return nil, err
+		}
+		serviceARNs = append(serviceARNs, output.ServiceArns...)
+	}
+
+	var services []ecsTypes.Service
+	for batch := range slices.Chunk(serviceARNs, ecsDescribeServicesBatch) {
+		output, err := client.DescribeServices(ctx, &ecs.DescribeServicesInput{
+			Cluster:  aws.String(clusterARN),
+			Services: batch,
+			Include:  []ecsTypes.ServiceField{ecsTypes.ServiceFieldTags},
+		})
+		if err != nil {
+			return nil, err
+		}
+		services = append(services, output.Services...)
+	}
+
+	return services, nil
+}
+
+// targetGroupResolver finds the load balancers of target groups, caching them for the services
+// of a region
+type targetGroupResolver struct {
+	client        *elasticloadbalancingv2.Client
+	collected     bool                // target groups are collected, services are linked to them
+	loadBalancers map[string][]string // target group ARN -> load balancer ARNs
+}
+
+// newTargetGroupResolver creates a target group resolver. When target groups are collected,
+// services are linked to their target groups, which are linked to their load balancers.
+func newTargetGroupResolver(client *elasticloadbalancingv2.Client, collected bool) *targetGroupResolver {
+	return &targetGroupResolver{
+		client:        client,
+		collected:     collected,
+		loadBalancers: make(map[string][]string),
+	}
+}
+
+// relationships returns the attached_to relationships of an ECS service to its target groups,
+// with the container as properties, so the path service -> target group -> load balancer is
+// part of the graph. When target groups are not collected, the service is attached to the load
+// balancers forwarding traffic to its target groups instead, with the target group as a
+// property. Services registered with classic load balancers reference them by name.
+func (r *targetGroupResolver) relationships(ctx context.Context, loadBalancers []ecsTypes.LoadBalancer) []resource.Relationship {
+	var missing []string
+	for _, lb := range loadBalancers {
+		if lb.TargetGroupArn == nil || r.collected {
+			continue
+		}
+		if _, ok := r.loadBalancers[*lb.TargetGroupArn]; !ok && !slices.Contains(missing, *lb.TargetGroupArn) {
+			missing = append(missing, *lb.TargetGroupArn)
+		}
+	}
+
+	for batch := range slices.Chunk(missing, targetGroupsBatch) {
+		output, err := r.client.DescribeTargetGroups(ctx, &elasticloadbalancingv2.DescribeTargetGroupsInput{
+			TargetGroupArns: batch,
+		})
+		if err != nil {
+			fmt.Fprintf(os.Stderr, "    Warning: failed to describe target groups: %v\n", err)
+			break
+		}
+		for _, group := range output.TargetGroups {
+			r.loadBalancers[safeString(group.TargetGroupArn)] = group.LoadBalancerArns
+		}
+	}
+
+	var relationships []resource.Relationship
+	for _, lb := range loadBalancers {
+		properties := map[string]interface{}{
+			"container_name": safeString(lb.ContainerName),
+			"container_port": safeInt32(lb.ContainerPort),
+		}
+
+		if lb.TargetGroupArn == nil {
+			if lb.LoadBalancerName != nil {
+				relationships = append(relationships, resource.Relationship{
+					Type:       resource.RelationAttachedTo,
+					TargetID:   *lb.LoadBalancerName,
+					TargetType: resource.TypeAWSELB,
+					Properties: properties,
+				})
+			}
+			continue
+		}
+
+		if r.collected {
+			relationships = append(relationships, resource.Relationship{
+				Type:       resource.RelationAttachedTo,
+				TargetID:   *lb.TargetGroupArn,
+				TargetType: resource.TypeAWSTargetGroup,
+				Properties: properties,
+			})
+			continue
+		}
+
+		properties["target_group_arn"] = *lb.TargetGroupArn
+		for _, lbARN := range r.loadBalancers[*lb.TargetGroupArn] {
+			targetType := arnResourceType(lbARN)
+			if targetType == "" {
+				continue
+			}
+			relationships = append(relationships, resource.Relationship{
+				Type:       resource.RelationAttachedTo,
+				TargetID:   lbARN,
+				TargetType: targetType,
+				Properties: properties,
+			})
+		}
+	}
+
+	return relationships
+}
+
+// convertECSClusterToResource converts an ECS cluster to a Resource
+func (p *Provider) convertECSClusterToResource(cluster *ecsTypes.Cluster, region string) *resource.Resource {
+	var account string
+	if len(p.accounts) > 0 {
+		account = p.accounts[0]
+	}
+
+	properties := map[string]interface{}{
+		resource.PropStatus:                    safeString(cluster.Status),
+		"running_tasks_count":                  cluster.RunningTasksCount,
+		"pending_tasks_count":                  cluster.PendingTasksCount,
+		"active_services_count":                cluster.ActiveServicesCount,
+		"registered_container_instances_count": cluster.RegisteredContainerInstancesCount,
+	}
+
+	if len(cluster.CapacityProviders) > 0 {
+		properties["capacity_providers"] = cluster.CapacityProviders
+	}
+	for _, setting := range cluster.Settings {
+		if setting.Name == ecsTypes.ClusterSettingNameContainerInsights {
+			properties["container_insights"] = safeString(setting.Value)
+		}
+	}
+
+	return &resource.Resource{
+		ID:         safeString(cluster.ClusterArn),
+		Type:       resource.TypeAWSECSCluster,
+		Name:       safeString(cluster.ClusterName),
+		Provider:   "aws",
+		Account:    account,
+		Region:     region,
+		ARN:        safeString(cluster.ClusterArn),
+		Tags:       ecsTags(cluster.Tags),
+		Properties: properties,
+		RawData:    cluster,
+	}
+}
+
+// convertECSServiceToResource converts an ECS service to a Resource
+func (p *Provider) convertECSServiceToResource(service *ecsTypes.Service, region string) *resource.Resource {
+	var account string
+	if len(p.accounts) > 0 {
+		account = p.accounts[0]
+	}
+
+	properties := map[string]interface{}{
+		resource.PropStatus:      safeString(service.Status),
+		"cluster_arn":            safeString(service.ClusterArn),
+		"task_definition":        safeString(service.TaskDefinition),
+		"desired_count":          service.DesiredCount,
+		"running_count":          service.RunningCount,
+		"pending_count":          service.PendingCount,
+		"launch_type":            string(service.LaunchType),
+		"scheduling_strategy":    string(service.SchedulingStrategy),
+		"enable_execute_command": service.EnableExecuteCommand,
+	}
+
+	if service.PlatformVersion != nil {
+		properties["platform_version"] = *service.PlatformVersion
+	}
+	if service.RoleArn != nil {
+		properties["role_arn"] = *service.RoleArn
+	}
+	if len(service.CapacityProviderStrategy) > 0 {
+		providers := make([]string, 0, len(service.CapacityProviderStrategy))
+		for _, item := range service.CapacityProviderStrategy {
+			providers = append(providers, safeString(item.CapacityProvider))
+		}
+		properties["capacity_providers"] = providers
+	}
+
+	var subnetIDs, groupIDs []string
+	if service.NetworkConfiguration != nil && service.NetworkConfiguration.AwsvpcConfiguration != nil {
+		vpcConfig := service.NetworkConfiguration.AwsvpcConfiguration
+		subnetIDs = vpcConfig.Subnets
+		groupIDs = vpcConfig.SecurityGroups
+		properties["subnet_ids"] = subnetIDs
+		properties["assign_public_ip"] = vpcConfig.AssignPublicIp == ecsTypes.AssignPublicIpEnabled
+		if len(groupIDs) > 0 {
+			properties[resource.PropSecurityGroups] = groupIDs
+		}
+	}
+
+	if len(service.LoadBalancers) > 0 {
+		loadBalancers := make([]map[string]interface{}, 0, len(service.LoadBalancers))
+		for _, lb := range service.LoadBalancers {
+			loadBalancers = append(loadBalancers, map[string]interface{}{
+				"target_group_arn":   safeString(lb.TargetGroupArn),
+				"load_balancer_name": safeString(lb.LoadBalancerName),
+				"container_name":     safeString(lb.ContainerName),
+				"container_port":     safeInt32(lb.ContainerPort),
+			})
+		}
+		properties["load_balancers"] = loadBalancers
+	}
+
+	res := &resource.Resource{
+		ID:         safeString(service.ServiceArn),
+		Type:       resource.TypeAWSECSService,
+		Name:       safeString(service.ServiceName),
+		Provider:   "aws",
+		Account:    account,
+		Region:     region,
+		ARN:        safeString(service.ServiceArn),
+		Tags:       ecsTags(service.Tags),
+		Properties: properties,
+		RawData:    service,
+		CreatedAt:  service.CreatedAt,
+	}
+
+	if service.ClusterArn != nil {
+		res.Relationships = append(res.Relationships, resource.Relationship{
+			Type:       resource.RelationBelongsTo,
+			TargetID:   *service.ClusterArn,
+			TargetType: resource.TypeAWSECSCluster,
+		})
+	}
+	if service.TaskDefinition != nil {
+		res.Relationships = append(res.Relationships, resource.Relationship{
+			Type:       resource.RelationDependsOn,
+			TargetID:   *service.TaskDefinition,
+			TargetType: resource.TypeAWSECSTaskDefinition,
+		})
+	}
+	res.Relationships = append(res.Relationships, networkRelationships("", subnetIDs)...)
+	res.Relationships = append(res.Relationships, securityGroupRelationships(groupIDs)...)
+
+	return res
+}
+
+// convertECSTaskDefinitionToResource converts an ECS task definition to a Resource. Container
+// images hosted in ECR are related to their repository, and the task and execution roles are
+// assumed by the tasks.
+func (p *Provider) convertECSTaskDefinitionToResource(taskDefinition *ecsTypes.TaskDefinition, tags []ecsTypes.Tag, region string) *resource.Resource {
+	var account string
+	if len(p.accounts) > 0 {
+		account = p.accounts[0]
+	}
+
+	properties := map[string]interface{}{
+		resource.PropStatus: string(taskDefinition.Status),
+		"family":            safeString(taskDefinition.Family),
+		"revision":          taskDefinition.Revision,
+		"network_mode":      string(taskDefinition.NetworkMode),
+		"cpu":               safeString(taskDefinition.Cpu),
+		"memory":            safeString(taskDefinition.Memory),
+	}
+
+	if len(taskDefinition.RequiresCompatibilities) > 0 {
+		compatibilities := make([]string, 0, len(taskDefinition.RequiresCompatibilities))
+		for _, compatibility := range taskDefinition.RequiresCompatibilities {
+			compatibilities = append(compatibilities, string(compatibility))
+		}
+		properties["requires_compatibilities"] = compatibilities
+	}
+	if taskDefinition.TaskRoleArn != nil {
+		properties["task_role_arn"] = *taskDefinition.TaskRoleArn
+	}
+	if taskDefinition.ExecutionRoleArn != nil {
+		properties["execution_role_arn"] = *taskDefinition.ExecutionRoleArn
+	}
+
+	var relationships []resource.Relationship
+	containers := make([]map[string]interface{}, 0, len(taskDefinition.ContainerDefinitions))
+	images := make([]string, 0, len(taskDefinition.ContainerDefinitions))
+	for _, container := range taskDefinition.ContainerDefinitions {
+		image := safeString(container.Image)
+		containers = append(containers, map[string]interface{}{
+			"name":       safeString(container.Name),
+			"image":      image,
+			"cpu":        container.Cpu,
+			"memory":     safeInt32(container.Memory),
+			"essential":  safeBool(container.Essential),
+			"privileged": safeBool(container.Privileged),
+		})
+		if !slices.Contains(images, image) {
+			images = append(images, image)
+		}
+
+		if repositoryARN := ecrRepositoryARN(image); repositoryARN != "" {
+			relationships = append(relationships, resource.Relationship{
+				Type:       resource.RelationDependsOn,
+				TargetID:   repositoryARN,
+				TargetType: resource.TypeAWSECR,
+				Properties: map[string]interface{}{
+					"container": safeString(container.Name),
+					"image":     image,
+				},
+			})
+		}
+	}
+	properties["containers"] = containers
+	properties["images"] = images
+
+	roles := []struct {
+		arn  *string
+		kind string
+	}{
+		{taskDefinition.TaskRoleArn, "task"},
+		{taskDefinition.ExecutionRoleArn, "execution"},
+	}
+	for _, role := range roles {
+		if role.arn == nil {
+			continue
+		}
+		relationships = append(relationships, resource.Relationship{
+			Type:       resource.RelationAssumes,
+			TargetID:   *role.arn,
+			TargetType: resource.TypeAWSIAMRole,
+			Properties: map[string]interface{}{
+				"role": role.kind,
+			},
+		})
+	}
+
+	return &resource.Resource{
+		ID:            safeString(taskDefinition.TaskDefinitionArn),
+		Type:          resource.TypeAWSECSTaskDefinition,
+		Name:          fmt.Sprintf("%s:%d", safeString(taskDefinition.Family), taskDefinition.Revision),
+		Provider:      "aws",
+		Account:       account,
+		Region:        region,
+		ARN:           safeString(taskDefinition.TaskDefinitionArn),
+		Tags:          ecsTags(tags),
+		Properties:    properties,
+		RawData:       taskDefinition,
+		Relationships: relationships,
+		CreatedAt:     taskDefinition.RegisteredAt,
+	}
+}
+
+// ecrRepositoryARN returns the ARN of the ECR repository hosting an image, or an empty string
+// for images hosted elsewhere
+func ecrRepositoryARN(image string) string {
+	match := ecrImagePattern.FindStringSubmatch(image)
+	if match == nil {
+		return ""
+	}
+	return fmt.Sprintf("arn:aws:ecr:%s:%s:repository/%s", match[2], match[1], match[3])
+}
+
+// ecsTags converts ECS tags to a map
+func ecsTags(ecsTags []ecsTypes.Tag) map[string]string {
+	if len(ecsTags) == 0 {
+		return nil
+	}
+	tags := make(map[string]string, len(ecsTags))
+	for _, tag := range ecsTags {
+		if tag.Key != nil && tag.Value != nil {
+			tags[*tag.Key] = *tag.Value
+		}
+	}
+	return tags
+}
+
+// collectEKSNodeGroups collects the managed node groups and Fargate profiles of all EKS clusters
+// in a region, keeping the requested types
+func (p *Provider) collectEKSNodeGroups(ctx context.Context, collection *resource.Collection, region string, cfg aws.Config, typeSet map[resource.ResourceType]bool) error {
+	fmt.Fprintf(os.Stderr, "  Collecting EKS node groups and Fargate profiles in %s...\n", region)
+	client := eks.NewFromConfig(cfg)
+
+	var clusterNames []string
+	paginator := eks.NewListClustersPaginator(client, &eks.ListClustersInput{})
+	for paginator.HasMorePages() {
+		output, err := paginator.NextPage(ctx)
+		if err != nil {
+			return fmt.Errorf("failed to list EKS clusters: %w", err)
+		}
+		clusterNames = append(clusterNames, output.Clusters...)
+	}
+
+	nodeGroupCount, profileCount := 0, 0
+	for _, clusterName := range clusterNames {
+		if typeSet[resource.TypeAWSEKSNodeGroup] {
+			nodeGroups := eks.NewListNodegroupsPaginator(client, &eks.ListNodegroupsInput{ClusterName: aws.String(clusterName)})
+			for nodeGroups.HasMorePages() {
+				output, err := nodeGroups.NextPage(ctx)
+				if err != nil {
+					fmt.Fprintf(os.Stderr, "    Warning: failed to list node groups of EKS cluster %s: %v\n", clusterName, err)
+					break
+				}
+
+				for _, nodeGroupName := range output.Nodegroups {
+					describeOutput, err := client.DescribeNodegroup(ctx, &eks.DescribeNodegroupInput{
+						ClusterName:   aws.String(clusterName),
+						NodegroupName: aws.String(nodeGroupName),
+					})
+					if err != nil {
+						fmt.Fprintf(os.Stderr, "    Warning: failed to describe EKS node group %s: %v\n", nodeGroupName, err)
+						continue
+					}
+
+					collection.Add(p.convertEKSNodeGroupToResource(describeOutput.Nodegroup, region))
+					nodeGroupCount++
+					fmt.Fprintf(os.Stderr, "    Found EKS node group: %s/%s\n", clusterName, nodeGroupName)
+				}
+			}
+		}
+
+		if typeSet[resource.TypeAWSEKSFargateProfile] {
+			profiles := eks.NewListFargateProfilesPaginator(client, &eks.ListFargateProfilesInput{ClusterName: aws.String(clusterName)})
+			for profiles.HasMorePages() {
+				output, err := profiles.NextPage(ctx)
+				if err != nil {
+					fmt.Fprintf(os.Stderr, "    Warning: failed to list Fargate profiles of EKS cluster %s: %v\n", clusterName, err)
+					break
+				}
+
+				for _, profileName := range output.FargateProfileNames {
+					describeOutput, err := client.DescribeFargateProfile(ctx, &eks.DescribeFargateProfileInput{
+						ClusterName:        aws.String(clusterName),
+						FargateProfileName: aws.String(profileName),
+					})
+					if err != nil {
+						fmt.Fprintf(os.Stderr, "    Warning: failed to describe EKS Fargate profile %s: %v\n", profileName, err)
+						continue
+					}
+
+					collection.Add(p.convertEKSFargateProfileToResource(describeOutput.FargateProfile, region))
+					profileCount++
+					fmt.Fprintf(os.Stderr, "    Found EKS Fargate profile: %s/%s\n", clusterName, profileName)
+				}
+			}
+		}
+	}
+
+	fmt.Fprintf(os.Stderr, "  Collected %d EKS node groups and %d Fargate profiles in %s\n", nodeGroupCount, profileCount, region)
+	return nil
+}
+
+// convertEKSNodeGroupToResource converts an EKS managed node group to a Resource
+func (p *Provider) convertEKSNodeGroupToResource(nodeGroup *eksTypes.Nodegroup, region string) *resource.Resource {
+	var account string
+	if len(p.accounts) > 0 {
+		account = p.accounts[0]
+	}
+
+	clusterName := safeString(nodeGroup.ClusterName)
+	properties := map[string]interface{}{
+		resource.PropStatus: string(nodeGroup.Status),
+		"cluster_name":      clusterName,
+		"version":           safeString(nodeGroup.Version),
+		"release_version":   safeString(nodeGroup.ReleaseVersion),
+		"capacity_type":     string(nodeGroup.CapacityType),
+		"ami_type":          string(nodeGroup.AmiType),
+		"subnet_ids":        nodeGroup.Subnets,
+	}
+
+	if len(nodeGroup.InstanceTypes) > 0 {
+		properties["instance_types"] = nodeGroup.InstanceTypes
+	}
+	if nodeGroup.DiskSize != nil {
+		properties["disk_size_gb"] = *nodeGroup.DiskSize
+	}
+	if scaling := nodeGroup.ScalingConfig; scaling != nil {
+		properties["min_size"] = safeInt32(scaling.MinSize)
+		properties["max_size"] = safeInt32(scaling.MaxSize)
+		properties["desired_size"] = safeInt32(scaling.DesiredSize)
+	}
+	if nodeGroup.NodeRole != nil {
+		properties["node_role_arn"] = *nodeGroup.NodeRole
+	}
+	if template := nodeGroup.LaunchTemplate; template != nil {
+		properties["launch_template"] = map[string]interface{}{
+			"id":      safeString(template.Id),
+			"name":    safeString(template.Name),
+			"version": safeString(template.Version),
+		}
+	}
+	if nodeGroup.Resources != nil {
+		groups := make([]string, 0, len(nodeGroup.Resources.AutoScalingGroups))
+		for _, group := range nodeGroup.Resources.AutoScalingGroups {
+			groups = append(groups, safeString(group.Name))
+		}
+		if len(groups) > 0 {
+			properties["autoscaling_groups"] = groups
+		}
+		if nodeGroup.Resources.RemoteAccessSecurityGroup != nil {
+			properties[resource.PropSecurityGroups] = []string{*nodeGroup.Resources.RemoteAccessSecurityGroup}
+		}
+	}
+
+	res := &resource.Resource{
+		ID:         safeString(nodeGroup.NodegroupArn),
+		Type:       resource.TypeAWSEKSNodeGroup,
+		Name:       safeString(nodeGroup.NodegroupName),
+		Provider:   "aws",
+		Account:    account,
+		Region:     region,
+		ARN:        safeString(nodeGroup.NodegroupArn),
+		Tags:       nodeGroup.Tags,
+		Properties: properties,
+		RawData:    nodeGroup,
+		CreatedAt:  nodeGroup.CreatedAt,
+	}
+
+	res.Relationships = eksMemberRelationships(clusterName, nodeGroup.Subnets, nodeGroup.NodeRole)
+	if nodeGroup.Resources != nil && nodeGroup.Resources.RemoteAccessSecurityGroup != nil {
+		res.Relationships = append(res.Relationships, securityGroupRelationships([]string{*nodeGroup.Resources.RemoteAccessSecurityGroup})...)
+	}
+
+	return res
+}
+
+// convertEKSFargateProfileToResource converts an EKS Fargate profile to a Resource
+func (p *Provider) convertEKSFargateProfileToResource(profile *eksTypes.FargateProfile, region string) *resource.Resource {
+	var account string
+	if len(p.accounts) > 0 {
+		account = p.accounts[0]
+	}
+
+	clusterName := safeString(profile.ClusterName)
+	properties := map[string]interface{}{
+		resource.PropStatus: string(profile.Status),
+		"cluster_name":      clusterName,
+		"subnet_ids":        profile.Subnets,
+	}
+
+	if profile.PodExecutionRoleArn != nil {
+		properties["pod_execution_role_arn"] = *profile.PodExecutionRoleArn
+	}
+
+	selectors := make([]map[string]interface{}, 0, len(profile.Selectors))
+	for _, selector := range profile.Selectors {
+		selectors = append(selectors, map[string]interface{}{
+			"namespace": safeString(selector.Namespace),
+			"labels":    selector.Labels,
+		})
+	}
+	properties["selectors"] = selectors
+
+	res := &resource.Resource{
+		ID:         safeString(profile.FargateProfileArn),
+		Type:       resource.TypeAWSEKSFargateProfile,
+		Name:       safeString(profile.FargateProfileName),
+		Provider:   "aws",
+		Account:    account,
+		Region:     region,
+		ARN:        safeString(profile.FargateProfileArn),
+		Tags:       profile.Tags,
+		Properties: properties,
+		RawData:    profile,
+		CreatedAt:  profile.CreatedAt,
+	}
+
+	res.Relationships = eksMemberRelationships(clusterName, profile.Subnets, profile.PodExecutionRoleArn)
+
+	return res
+}
+
+// eksMemberRelationships returns the relationships of an EKS node group or Fargate profile to
+// its cluster, the subnets its nodes or pods run in, and the role they assume
+func eksMemberRelationships(clusterName string, subnetIDs []string, roleARN *string) []resource.Relationship {
+	var relationships []resource.Relationship
+	if clusterName != "" {
+		relationships = append(relationships, resource.Relationship{
+			Type:       resource.RelationBelongsTo,
+			TargetID:   clusterName,
+			TargetType: resource.TypeAWSEKSCluster,
+		})
+	}
+	relationships = append(relationships, networkRelationships("", subnetIDs)...)
+	if roleARN != nil {
+		relationships = append(relationships, resource.Relationship{
+			Type:       resource.RelationAssumes,
+			TargetID:   *roleARN,
+			TargetType: resource.TypeAWSIAMRole,
+		})
+	}
+	return relationships
+}
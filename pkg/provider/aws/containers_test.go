@@ -0,0 +1,66 @@
+package aws
+
+import (
+	"context"
+	"testing"
+
+	"github.com/aws/aws-sdk-go-v2/aws"
+	ecsTypes "github.com/aws/aws-sdk-go-v2/service/ecs/types"
+
+	"github.com/comfortablynumb/pmp-cloud-inspector/pkg/resource"
+)
+
+func TestServiceLoadBalancerRelationships(t *testing.T) {
+	const (
+		targetGroupARN  = "arn:aws:elasticloadbalancing:us-east-1:111111111111:targetgroup/api/0123456789abcdef"
+		loadBalancerARN = "arn:aws:elasticloadbalancing:us-east-1:111111111111:loadbalancer/app/public/0123456789abcdef"
+	)
+	loadBalancers := []ecsTypes.LoadBalancer{
+		{TargetGroupArn: aws.String(targetGroupARN), ContainerName: aws.String("api"), ContainerPort: aws.Int32(8080)},
+		{LoadBalancerName: aws.String("legacy"), ContainerName: aws.String("api"), ContainerPort: aws.Int32(8080)},
+	}
+
+	tests := []struct {
+		name      string
+		collected bool
+		want      []resource.Relationship
+	}{
+		{
+			name:      "target groups collected",
+			collected: true,
+			want: []resource.Relationship{
+				{Type: resource.RelationAttachedTo, TargetID: targetGroupARN, TargetType: resource.TypeAWSTargetGroup},
+				{Type: resource.RelationAttachedTo, TargetID: "legacy", TargetType: resource.TypeAWSELB},
+			},
+		},
+		{
+			name:      "target groups not collected",
+			collected: false,
+			want: []resource.Relationship{
+				{Type: resource.RelationAttachedTo, TargetID: loadBalancerARN, TargetType: resource.TypeAWSALB},
+				{Type: resource.RelationAttachedTo, TargetID: "legacy", TargetType: resource.TypeAWSELB},
+			},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			// The load balancers of the target group are cached, so no API call is made
+			resolver := newTargetGroupResolver(nil, tt.collected)
+			resolver.loadBalancers[targetGroupARN] = []string{loadBalancerARN}
+
+			got := resolver.relationships(context.Background(), loadBalancers)
+			if len(got) != len(tt.want) {
+				t.Fatalf("got %d relationships %+v, want %d", len(got), got, len(tt.want))
+			}
+			for i, want := range tt.want {
+				if got[i].Type != want.Type || got[i].TargetID != want.TargetID || got[i].TargetType != want.TargetType {
+					t.Errorf("relationship %d: got %s %s (%s), want %s %s (%s)", i, got[i].Type, got[i].TargetID, got[i].TargetType, want.Type, want.TargetID, want.TargetType)
+				}
+				if got[i].Properties["container_name"] != "api" {
+					t.Errorf("relationship %d: got container_name %v, want api", i, got[i].Properties["container_name"])
+				}
+			}
+		})
+	}
+}
@@ -10,6 +10,8 @@ import (
 	elasticacheTypes "github.com/aws/aws-sdk-go-v2/service/elasticache/types"
 	"github.com/aws/aws-sdk-go-v2/service/memorydb"
 	memorydbTypes "github.com/aws/aws-sdk-go-v2/service/memorydb/types"
+	"github.com/aws/aws-sdk-go-v2/service/rds"
+	rdsTypes "github.com/aws/aws-sdk-go-v2/service/rds/types"
 
 	"github.com/comfortablynumb/pmp-cloud-inspector/pkg/resource"
 )
@@ -19,7 +21,15 @@ func (p *Provider) collectMemoryDBClusters(ctx context.Context, collection *reso
 	fmt.Fprintf(os.Stderr, "  Collecting MemoryDB clusters in %s...\n", region)
 	client := memorydb.NewFromConfig(cfg)
 
-	paginator := memorydb.NewDescribeClustersPaginator(client, &memorydb.DescribeClustersInput{})
+	// Clusters only reference their subnet group by name; its VPC and subnets are optional
+	subnetGroups, err := describeMemoryDBSubnetGroups(ctx, client)
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "    Warning: failed to describe MemoryDB subnet groups in %s: %v\n", region, err)
+	}
+
+	paginator := memorydb.NewDescribeClustersPaginator(client, &memorydb.DescribeClustersInput{
+		ShowShardDetails: aws.Bool(true),
+	})
 
 	count := 0
 	for paginator.HasMorePages() {
@@ -29,7 +39,11 @@ func (p *Provider) collectMemoryDBClusters(ctx context.Context, collection *reso
 		}
 
 		for _, cluster := range output.Clusters {
-			res := p.convertMemoryDBClusterToResource(&cluster, region)
+			var subnetGroup *memorydbTypes.SubnetGroup
+			if group, ok := subnetGroups[safeString(cluster.SubnetGroupName)]; ok {
+				subnetGroup = &group
+			}
+			res := p.convertMemoryDBClusterToResource(&cluster, subnetGroup, region)
 			collection.Add(res)
 			count++
 			fmt.Fprintf(os.Stderr, "    Found MemoryDB cluster: %s (%s)\n", safeString(cluster.Name), safeString(cluster.Status))
@@ -45,6 +59,12 @@ func (p *Provider) collectElastiCacheClusters(ctx context.Context, collection *r
 	fmt.Fprintf(os.Stderr, "  Collecting ElastiCache clusters in %s...\n", region)
 	client := elasticache.NewFromConfig(cfg)
 
+	// Clusters only reference their subnet group by name; its VPC and subnets are optional
+	subnetGroups, err := describeCacheSubnetGroups(ctx, client)
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "    Warning: failed to describe ElastiCache subnet groups in %s: %v\n", region, err)
+	}
+
 	paginator := elasticache.NewDescribeCacheClustersPaginator(client, &elasticache.DescribeCacheClustersInput{})
 
 	count := 0
@@ -55,7 +75,11 @@ func (p *Provider) collectElastiCacheClusters(ctx context.Context, collection *r
 		}
 
 		for _, cluster := range output.CacheClusters {
-			res := p.convertElastiCacheClusterToResource(&cluster, region)
+			var subnetGroup *elasticacheTypes.CacheSubnetGroup
+			if group, ok := subnetGroups[safeString(cluster.CacheSubnetGroupName)]; ok {
+				subnetGroup = &group
+			}
+			res := p.convertElastiCacheClusterToResource(&cluster, subnetGroup, region)
 			collection.Add(res)
 			count++
 			fmt.Fprintf(os.Stderr, "    Found ElastiCache cluster: %s (%s)\n", safeString(cluster.CacheClusterId), safeString(cluster.CacheClusterStatus))
@@ -66,19 +90,161 @@ func (p *Provider) collectElastiCacheClusters(ctx context.Context, collection *r
 	return nil
 }
 
-// convertMemoryDBClusterToResource converts a MemoryDB cluster to a Resource
-func (p *Provider) convertMemoryDBClusterToResource(cluster *memorydbTypes.Cluster, region string) *resource.Resource {
+// collectRDSInstances collects all RDS database instances in a region, including the instances
+// of Aurora clusters
+func (p *Provider) collectRDSInstances(ctx context.Context, collection *resource.Collection, region string, cfg aws.Config) error {
+	fmt.Fprintf(os.Stderr, "  Collecting RDS instances in %s...\n", region)
+	client := rds.NewFromConfig(cfg)
+
+	paginator := rds.NewDescribeDBInstancesPaginator(client, &rds.DescribeDBInstancesInput{})
+
+	count := 0
+	for paginator.HasMorePages() {
+		output, err := paginator.NextPage(ctx)
+		if err != nil {
+			return fmt.Errorf("failed to describe RDS instances: %w", err)
+		}
+
+		for _, instance := range output.DBInstances {
+			res := p.convertRDSInstanceToResource(&instance, region)
+			collection.Add(res)
+			count++
+			fmt.Fprintf(os.Stderr, "    Found RDS instance: %s (%s)\n", safeString(instance.DBInstanceIdentifier), safeString(instance.DBInstanceStatus))
+		}
+	}
+
+	fmt.Fprintf(os.Stderr, "  Collected %d RDS instances in %s\n", count, region)
+	return nil
+}
+
+// collectRDSClusters collects all RDS clusters in a region: Aurora clusters and Multi-AZ DB
+// clusters
+func (p *Provider) collectRDSClusters(ctx context.Context, collection *resource.Collection, region string, cfg aws.Config) error {
+	fmt.Fprintf(os.Stderr, "  Collecting RDS clusters in %s...\n", region)
+	client := rds.NewFromConfig(cfg)
+
+	// Clusters only reference their subnet group by name; its VPC and subnets are optional
+	subnetGroups, err := describeDBSubnetGroups(ctx, client)
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "    Warning: failed to describe DB subnet groups in %s: %v\n", region, err)
+	}
+
+	paginator := rds.NewDescribeDBClustersPaginator(client, &rds.DescribeDBClustersInput{})
+
+	count := 0
+	for paginator.HasMorePages() {
+		output, err := paginator.NextPage(ctx)
+		if err != nil {
+			return fmt.Errorf("failed to describe RDS clusters: %w", err)
+		}
+
+		for _, cluster := range output.DBClusters {
+			var subnetGroup *rdsTypes.DBSubnetGroup
+			if group, ok := subnetGroups[safeString(cluster.DBSubnetGroup)]; ok {
+				subnetGroup = &group
+			}
+			res := p.convertRDSClusterToResource(&cluster, subnetGroup, region)
+			collection.Add(res)
+			count++
+			fmt.Fprintf(os.Stderr, "    Found RDS cluster: %s (%s)\n", safeString(cluster.DBClusterIdentifier), safeString(cluster.Status))
+		}
+	}
+
+	fmt.Fprintf(os.Stderr, "  Collected %d RDS clusters in %s\n", count, region)
+	return nil
+}
+
+// describeDBSubnetGroups returns the DB subnet groups of a region, by name
+func describeDBSubnetGroups(ctx context.Context, client *rds.Client) (map[string]rdsTypes.DBSubnetGroup, error) {
+	groups := make(map[string]rdsTypes.DBSubnetGroup)
+
+	paginator := rds.NewDescribeDBSubnetGroupsPaginator(client, &rds.DescribeDBSubnetGroupsInput{})
+	for paginator.HasMorePages() {
+		output, err := paginator.NextPage(ctx)
+		if err != nil {
+			return groups, err
+		}
+
+		for _, group := range output.DBSubnetGroups {
+			if group.DBSubnetGroupName != nil {
+				groups[*group.DBSubnetGroupName] = group
+			}
+		}
+	}
+
+	return groups, nil
+}
+
+// describeMemoryDBSubnetGroups returns the MemoryDB subnet groups of a region, by name
+func describeMemoryDBSubnetGroups(ctx context.Context, client *memorydb.Client) (map[string]memorydbTypes.SubnetGroup, error) {
+	groups := make(map[string]memorydbTypes.SubnetGroup)
+
+	input := &memorydb.DescribeSubnetGroupsInput{}
+	for {
+		output, err := client.DescribeSubnetGroups(ctx, input)
+		if err != nil {
+			return groups, err
+		}
+
+		for _, group := range output.SubnetGroups {
+			if group.Name != nil {
+				groups[*group.Name] = group
+			}
+		}
+
+		if output.NextToken == nil || *output.NextToken == "" {
+			break
+		}
+		input.NextToken = output.NextToken
+	}
+
+	return groups, nil
+}
+
+// describeCacheSubnetGroups returns the ElastiCache subnet groups of a region, by name
+func describeCacheSubnetGroups(ctx context.Context, client *elasticache.Client) (map[string]elasticacheTypes.CacheSubnetGroup, error) {
+	groups := make(map[string]elasticacheTypes.CacheSubnetGroup)
+
+	paginator := elasticache.NewDescribeCacheSubnetGroupsPaginator(client, &elasticache.DescribeCacheSubnetGroupsInput{})
+	for paginator.HasMorePages() {
+		output, err := paginator.NextPage(ctx)
+		if err != nil {
+			return groups, err
+		}
+
+		for _, group := range output.CacheSubnetGroups {
+			if group.CacheSubnetGroupName != nil {
+				groups[*group.CacheSubnetGroupName] = group
+			}
+		}
+	}
+
+	return groups, nil
+}
+
+// convertMemoryDBClusterToResource converts a MemoryDB cluster to a Resource. The subnet group is
+// nil when it could not be described.
+func (p *Provider) convertMemoryDBClusterToResource(cluster *memorydbTypes.Cluster, subnetGroup *memorydbTypes.SubnetGroup, region string) *resource.Resource {
 	var account string
 	if len(p.accounts) > 0 {
 		account = p.accounts[0]
 	}
 
 	properties := map[string]interface{}{
-		"status":      safeString(cluster.Status),
-		"node_type":   safeString(cluster.NodeType),
-		"engine":      safeString(cluster.Engine),
-		"num_shards":  safeInt32(cluster.NumberOfShards),
-		"tls_enabled": safeBool(cluster.TLSEnabled),
+		"status":               safeString(cluster.Status),
+		resource.PropNodeType:  safeString(cluster.NodeType),
+		"engine":               safeString(cluster.Engine),
+		resource.PropNumShards: safeInt32(cluster.NumberOfShards),
+		"tls_enabled":          safeBool(cluster.TLSEnabled),
+	}
+
+	// Total nodes across shards (primaries and replicas), available with shard details
+	var numNodes int32
+	for _, shard := range cluster.Shards {
+		numNodes += safeInt32(shard.NumberOfNodes)
+	}
+	if numNodes > 0 {
+		properties[resource.PropNumNodes] = numNodes
 	}
 
 	if cluster.Description != nil {
@@ -90,6 +256,23 @@ func (p *Provider) convertMemoryDBClusterToResource(cluster *memorydbTypes.Clust
 	if cluster.SubnetGroupName != nil {
 		properties["subnet_group_name"] = *cluster.SubnetGroupName
 	}
+
+	var vpcID string
+	var subnetIDs []string
+	if subnetGroup != nil {
+		vpcID = safeString(subnetGroup.VpcId)
+		for _, subnet := range subnetGroup.Subnets {
+			if subnet.Identifier != nil {
+				subnetIDs = append(subnetIDs, *subnet.Identifier)
+			}
+		}
+	}
+	if vpcID != "" {
+		properties["vpc_id"] = vpcID
+	}
+	if len(subnetIDs) > 0 {
+		properties["subnet_ids"] = subnetIDs
+	}
 	if cluster.ParameterGroupName != nil {
 		properties["parameter_group_name"] = *cluster.ParameterGroupName
 	}
@@ -100,6 +283,16 @@ func (p *Provider) convertMemoryDBClusterToResource(cluster *memorydbTypes.Clust
 		}
 	}
 
+	securityGroupIDs := make([]string, 0, len(cluster.SecurityGroups))
+	for _, group := range cluster.SecurityGroups {
+		if group.SecurityGroupId != nil {
+			securityGroupIDs = append(securityGroupIDs, *group.SecurityGroupId)
+		}
+	}
+	if len(securityGroupIDs) > 0 {
+		properties[resource.PropSecurityGroups] = securityGroupIDs
+	}
+
 	res := &resource.Resource{
 		ID:         safeString(cluster.Name),
 		Type:       resource.TypeAWSMemoryDB,
@@ -112,22 +305,26 @@ func (p *Provider) convertMemoryDBClusterToResource(cluster *memorydbTypes.Clust
 		RawData:    cluster,
 	}
 
+	res.Relationships = append(res.Relationships, networkRelationships(vpcID, subnetIDs)...)
+	res.Relationships = append(res.Relationships, securityGroupRelationships(securityGroupIDs)...)
+
 	return res
 }
 
-// convertElastiCacheClusterToResource converts an ElastiCache cluster to a Resource
-func (p *Provider) convertElastiCacheClusterToResource(cluster *elasticacheTypes.CacheCluster, region string) *resource.Resource {
+// convertElastiCacheClusterToResource converts an ElastiCache cluster to a Resource. The subnet
+// group is nil when it could not be described.
+func (p *Provider) convertElastiCacheClusterToResource(cluster *elasticacheTypes.CacheCluster, subnetGroup *elasticacheTypes.CacheSubnetGroup, region string) *resource.Resource {
 	var account string
 	if len(p.accounts) > 0 {
 		account = p.accounts[0]
 	}
 
 	properties := map[string]interface{}{
-		"status":          safeString(cluster.CacheClusterStatus),
-		"node_type":       safeString(cluster.CacheNodeType),
-		"engine":          safeString(cluster.Engine),
-		"num_cache_nodes": safeInt32(cluster.NumCacheNodes),
-		"preferred_az":    safeString(cluster.PreferredAvailabilityZone),
+		"status":                   safeString(cluster.CacheClusterStatus),
+		resource.PropNodeType:      safeString(cluster.CacheNodeType),
+		"engine":                   safeString(cluster.Engine),
+		resource.PropNumCacheNodes: safeInt32(cluster.NumCacheNodes),
+		"preferred_az":             safeString(cluster.PreferredAvailabilityZone),
 	}
 
 	if cluster.EngineVersion != nil {
@@ -136,6 +333,23 @@ func (p *Provider) convertElastiCacheClusterToResource(cluster *elasticacheTypes
 	if cluster.CacheSubnetGroupName != nil {
 		properties["subnet_group_name"] = *cluster.CacheSubnetGroupName
 	}
+
+	var vpcID string
+	var subnetIDs []string
+	if subnetGroup != nil {
+		vpcID = safeString(subnetGroup.VpcId)
+		for _, subnet := range subnetGroup.Subnets {
+			if subnet.SubnetIdentifier != nil {
+				subnetIDs = append(subnetIDs, *subnet.SubnetIdentifier)
+			}
+		}
+	}
+	if vpcID != "" {
+		properties["vpc_id"] = vpcID
+	}
+	if len(subnetIDs) > 0 {
+		properties["subnet_ids"] = subnetIDs
+	}
 	if cluster.CacheParameterGroup != nil && cluster.CacheParameterGroup.CacheParameterGroupName != nil {
 		properties["parameter_group_name"] = *cluster.CacheParameterGroup.CacheParameterGroupName
 	}
@@ -146,6 +360,16 @@ func (p *Provider) convertElastiCacheClusterToResource(cluster *elasticacheTypes
 		}
 	}
 
+	securityGroupIDs := make([]string, 0, len(cluster.SecurityGroups))
+	for _, group := range cluster.SecurityGroups {
+		if group.SecurityGroupId != nil {
+			securityGroupIDs = append(securityGroupIDs, *group.SecurityGroupId)
+		}
+	}
+	if len(securityGroupIDs) > 0 {
+		properties[resource.PropSecurityGroups] = securityGroupIDs
+	}
+
 	arn := safeString(cluster.ARN)
 	if arn == "" {
 		// Construct ARN if not provided
@@ -168,9 +392,254 @@ func (p *Provider) convertElastiCacheClusterToResource(cluster *elasticacheTypes
 		res.CreatedAt = cluster.CacheClusterCreateTime
 	}
 
+	res.Relationships = append(res.Relationships, networkRelationships(vpcID, subnetIDs)...)
+	res.Relationships = append(res.Relationships, securityGroupRelationships(securityGroupIDs)...)
+
+	return res
+}
+
+// convertRDSInstanceToResource converts an RDS database instance to a Resource
+func (p *Provider) convertRDSInstanceToResource(instance *rdsTypes.DBInstance, region string) *resource.Resource {
+	var account string
+	if len(p.accounts) > 0 {
+		account = p.accounts[0]
+	}
+
+	properties := map[string]interface{}{
+		resource.PropStatus:           safeString(instance.DBInstanceStatus),
+		resource.PropInstanceClass:    safeString(instance.DBInstanceClass),
+		resource.PropEngine:           safeString(instance.Engine),
+		"engine_version":              safeString(instance.EngineVersion),
+		resource.PropMultiAZ:          safeBool(instance.MultiAZ),
+		resource.PropEncrypted:        safeBool(instance.StorageEncrypted),
+		"publicly_accessible":         safeBool(instance.PubliclyAccessible),
+		"deletion_protection":         safeBool(instance.DeletionProtection),
+		"backup_retention_period":     safeInt32(instance.BackupRetentionPeriod),
+		resource.PropAllocatedStorage: safeInt32(instance.AllocatedStorage),
+	}
+
+	if instance.StorageType != nil {
+		properties[resource.PropStorageType] = *instance.StorageType
+	}
+	if instance.Iops != nil {
+		properties["iops"] = *instance.Iops
+	}
+	if instance.KmsKeyId != nil {
+		properties[resource.PropKMSKeyID] = *instance.KmsKeyId
+	}
+	if instance.AvailabilityZone != nil {
+		properties["availability_zone"] = *instance.AvailabilityZone
+	}
+	if instance.DBClusterIdentifier != nil {
+		properties[resource.PropClusterID] = *instance.DBClusterIdentifier
+	}
+	if instance.Endpoint != nil {
+		properties["endpoint"] = map[string]interface{}{
+			"address": safeString(instance.Endpoint.Address),
+			"port":    safeInt32(instance.Endpoint.Port),
+		}
+	}
+
+	var vpcID string
+	var subnetIDs []string
+	if group := instance.DBSubnetGroup; group != nil {
+		properties["subnet_group_name"] = safeString(group.DBSubnetGroupName)
+		vpcID = safeString(group.VpcId)
+		subnetIDs = dbSubnetIDs(group)
+	}
+	if vpcID != "" {
+		properties["vpc_id"] = vpcID
+	}
+	if len(subnetIDs) > 0 {
+		properties["subnet_ids"] = subnetIDs
+	}
+
+	securityGroupIDs := rdsSecurityGroups(instance.VpcSecurityGroups)
+	if len(securityGroupIDs) > 0 {
+		properties[resource.PropSecurityGroups] = securityGroupIDs
+	}
+
+	res := &resource.Resource{
+		ID:         safeString(instance.DBInstanceIdentifier),
+		Type:       resource.TypeAWSRDSInstance,
+		Name:       safeString(instance.DBInstanceIdentifier),
+		Provider:   "aws",
+		Account:    account,
+		Region:     region,
+		ARN:        safeString(instance.DBInstanceArn),
+		Tags:       rdsTags(instance.TagList),
+		Properties: properties,
+		RawData:    instance,
+	}
+
+	if instance.InstanceCreateTime != nil {
+		res.CreatedAt = instance.InstanceCreateTime
+	}
+
+	// Add relationships
+	res.Relationships = append(res.Relationships, networkRelationships(vpcID, subnetIDs)...)
+	res.Relationships = append(res.Relationships, securityGroupRelationships(securityGroupIDs)...)
+
+	if instance.DBClusterIdentifier != nil {
+		res.Relationships = append(res.Relationships, resource.Relationship{
+			Type:       resource.RelationBelongsTo,
+			TargetID:   *instance.DBClusterIdentifier,
+			TargetType: resource.TypeAWSRDSCluster,
+		})
+	}
+
 	return res
 }
 
+// convertRDSClusterToResource converts an RDS cluster to a Resource. The subnet group is nil
+// when it could not be described.
+func (p *Provider) convertRDSClusterToResource(cluster *rdsTypes.DBCluster, subnetGroup *rdsTypes.DBSubnetGroup, region string) *resource.Resource {
+	var account string
+	if len(p.accounts) > 0 {
+		account = p.accounts[0]
+	}
+
+	properties := map[string]interface{}{
+		resource.PropStatus:    safeString(cluster.Status),
+		resource.PropEngine:    safeString(cluster.Engine),
+		"engine_version":       safeString(cluster.EngineVersion),
+		resource.PropMultiAZ:   safeBool(cluster.MultiAZ),
+		resource.PropEncrypted: safeBool(cluster.StorageEncrypted),
+		"deletion_protection":  safeBool(cluster.DeletionProtection),
+	}
+
+	if cluster.EngineMode != nil {
+		properties["engine_mode"] = *cluster.EngineMode
+	}
+	if cluster.DBClusterInstanceClass != nil {
+		properties[resource.PropInstanceClass] = *cluster.DBClusterInstanceClass
+	}
+	if cluster.StorageType != nil {
+		properties[resource.PropStorageType] = *cluster.StorageType
+	}
+	// Aurora storage grows with the data and is reported as 1
+	if cluster.AllocatedStorage != nil && *cluster.AllocatedStorage > 1 {
+		properties[resource.PropAllocatedStorage] = *cluster.AllocatedStorage
+	}
+	if cluster.KmsKeyId != nil {
+		properties[resource.PropKMSKeyID] = *cluster.KmsKeyId
+	}
+	if cluster.Endpoint != nil {
+		properties["endpoint"] = *cluster.Endpoint
+	}
+	if cluster.ReaderEndpoint != nil {
+		properties["reader_endpoint"] = *cluster.ReaderEndpoint
+	}
+	if cluster.ServerlessV2ScalingConfiguration != nil {
+		properties["serverless_v2_scaling"] = map[string]interface{}{
+			"min_capacity": cluster.ServerlessV2ScalingConfiguration.MinCapacity,
+			"max_capacity": cluster.ServerlessV2ScalingConfiguration.MaxCapacity,
+		}
+	}
+
+	members := make([]map[string]interface{}, 0, len(cluster.DBClusterMembers))
+	for _, member := range cluster.DBClusterMembers {
+		members = append(members, map[string]interface{}{
+			"instance_id": safeString(member.DBInstanceIdentifier),
+			"writer":      safeBool(member.IsClusterWriter),
+		})
+	}
+	if len(members) > 0 {
+		properties["members"] = members
+	}
+
+	var vpcID string
+	var subnetIDs []string
+	if cluster.DBSubnetGroup != nil {
+		properties["subnet_group_name"] = *cluster.DBSubnetGroup
+	}
+	if subnetGroup != nil {
+		vpcID = safeString(subnetGroup.VpcId)
+		subnetIDs = dbSubnetIDs(subnetGroup)
+	}
+	if vpcID != "" {
+		properties["vpc_id"] = vpcID
+	}
+	if len(subnetIDs) > 0 {
+		properties["subnet_ids"] = subnetIDs
+	}
+
+	securityGroupIDs := rdsSecurityGroups(cluster.VpcSecurityGroups)
+	if len(securityGroupIDs) > 0 {
+		properties[resource.PropSecurityGroups] = securityGroupIDs
+	}
+
+	res := &resource.Resource{
+		ID:         safeString(cluster.DBClusterIdentifier),
+		Type:       resource.TypeAWSRDSCluster,
+		Name:       safeString(cluster.DBClusterIdentifier),
+		Provider:   "aws",
+		Account:    account,
+		Region:     region,
+		ARN:        safeString(cluster.DBClusterArn),
+		Tags:       rdsTags(cluster.TagList),
+		Properties: properties,
+		RawData:    cluster,
+	}
+
+	if cluster.ClusterCreateTime != nil {
+		res.CreatedAt = cluster.ClusterCreateTime
+	}
+
+	// Add relationships
+	res.Relationships = append(res.Relationships, networkRelationships(vpcID, subnetIDs)...)
+	res.Relationships = append(res.Relationships, securityGroupRelationships(securityGroupIDs)...)
+
+	for _, member := range cluster.DBClusterMembers {
+		if member.DBInstanceIdentifier == nil {
+			continue
+		}
+		res.Relationships = append(res.Relationships, resource.Relationship{
+			Type:       resource.RelationContains,
+			TargetID:   *member.DBInstanceIdentifier,
+			TargetType: resource.TypeAWSRDSInstance,
+		})
+	}
+
+	return res
+}
+
+// dbSubnetIDs returns the IDs of the subnets of a DB subnet group
+func dbSubnetIDs(group *rdsTypes.DBSubnetGroup) []string {
+	subnetIDs := make([]string, 0, len(group.Subnets))
+	for _, subnet := range group.Subnets {
+		if subnet.SubnetIdentifier != nil {
+			subnetIDs = append(subnetIDs, *subnet.SubnetIdentifier)
+		}
+	}
+	return subnetIDs
+}
+
+// rdsSecurityGroups returns the IDs of the VPC security groups of an RDS instance or cluster
+func rdsSecurityGroups(memberships []rdsTypes.VpcSecurityGroupMembership) []string {
+	groupIDs := make([]string, 0, len(memberships))
+	for _, membership := range memberships {
+		if membership.VpcSecurityGroupId != nil {
+			groupIDs = append(groupIDs, *membership.VpcSecurityGroupId)
+		}
+	}
+	return groupIDs
+}
+
+// rdsTags converts RDS tags to a map
+func rdsTags(tagList []rdsTypes.Tag) map[string]string {
+	if len(tagList) == 0 {
+		return nil
+	}
+	tags := make(map[string]string, len(tagList))
+	for _, tag := range tagList {
+		if tag.Key != nil && tag.Value != nil {
+			tags[*tag.Key] = *tag.Value
+		}
+	}
+	return tags
+}
+
 // safeInt32 safely dereferences an int32 pointer
 func safeInt32(i *int32) int32 {
 	if i == nil {
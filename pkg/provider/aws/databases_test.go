@@ -0,0 +1,113 @@
+package aws
+
+import (
+	"encoding/json"
+	"testing"
+
+	"github.com/aws/aws-sdk-go-v2/aws"
+	elasticacheTypes "github.com/aws/aws-sdk-go-v2/service/elasticache/types"
+	memorydbTypes "github.com/aws/aws-sdk-go-v2/service/memorydb/types"
+
+	"github.com/comfortablynumb/pmp-cloud-inspector/pkg/cost"
+	"github.com/comfortablynumb/pmp-cloud-inspector/pkg/resource"
+)
+
+// testCatalog prices the node types of the test clusters with round monthly prices
+func testCatalog() *cost.Catalog {
+	catalog := &cost.Catalog{Provider: "aws", Version: "test", Currency: "USD"}
+	catalog.Add(cost.Price{PriceKey: cost.PriceKey{Service: "elasticache", Size: "cache.r6g.large"}, Unit: cost.UnitMonth, Price: 100})
+	catalog.Add(cost.Price{PriceKey: cost.PriceKey{Service: "memorydb", Size: "db.r6g.large"}, Unit: cost.UnitMonth, Price: 200})
+	return catalog
+}
+
+// roundTrip returns a copy of a resource as read back from a JSON export, with numbers decoded as float64
+func roundTrip(t *testing.T, res *resource.Resource) *resource.Resource {
+	t.Helper()
+	content, err := json.Marshal(res)
+	if err != nil {
+		t.Fatalf("failed to marshal %s: %v", res.ID, err)
+	}
+	var decoded resource.Resource
+	if err := json.Unmarshal(content, &decoded); err != nil {
+		t.Fatalf("failed to unmarshal %s: %v", res.ID, err)
+	}
+	return &decoded
+}
+
+func TestDatabaseNodeCountsAreEstimated(t *testing.T) {
+	p := &Provider{}
+
+	tests := []struct {
+		name      string
+		res       *resource.Resource
+		property  string
+		wantCount int64
+		component string
+		wantCost  float64
+	}{
+		{
+			name: "elasticache cache nodes",
+			res: p.convertElastiCacheClusterToResource(&elasticacheTypes.CacheCluster{
+				CacheClusterId: aws.String("sessions"),
+				CacheNodeType:  aws.String("cache.r6g.large"),
+				NumCacheNodes:  aws.Int32(3),
+			}, nil, "us-east-1"),
+			property:  resource.PropNumCacheNodes,
+			wantCount: 3,
+			component: "cache_nodes",
+			wantCost:  300,
+		},
+		{
+			name: "memorydb nodes across shards",
+			res: p.convertMemoryDBClusterToResource(&memorydbTypes.Cluster{
+				Name:           aws.String("orders"),
+				NodeType:       aws.String("db.r6g.large"),
+				NumberOfShards: aws.Int32(2),
+				Shards: []memorydbTypes.Shard{
+					{NumberOfNodes: aws.Int32(2)},
+					{NumberOfNodes: aws.Int32(2)},
+				},
+			}, nil, "us-east-1"),
+			property:  resource.PropNumNodes,
+			wantCount: 4,
+			component: "memory_nodes",
+			wantCost:  800,
+		},
+		{
+			name: "memorydb shards without shard details",
+			res: p.convertMemoryDBClusterToResource(&memorydbTypes.Cluster{
+				Name:           aws.String("carts"),
+				NodeType:       aws.String("db.r6g.large"),
+				NumberOfShards: aws.Int32(2),
+			}, nil, "us-east-1"),
+			property:  resource.PropNumShards,
+			wantCount: 2,
+			component: "memory_nodes",
+			wantCost:  400,
+		},
+	}
+
+	estimator := cost.NewAWSEstimatorWithCatalog(testCatalog())
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			// As collected (int32) and as loaded from a previous export (float64)
+			for _, res := range []*resource.Resource{tt.res, roundTrip(t, tt.res)} {
+				if count, ok := res.IntProperty(tt.property); !ok || count != tt.wantCount {
+					t.Errorf("%s stored as %T: got %d (%v), want %d", tt.property, res.Properties[tt.property], count, ok, tt.wantCount)
+				}
+
+				estimate, err := estimator.EstimateCost(res)
+				if err != nil {
+					t.Fatalf("EstimateCost failed: %v", err)
+				}
+				if estimate == nil {
+					t.Fatalf("EstimateCost returned no estimate")
+				}
+				if got := estimate.Breakdown[tt.component]; got != tt.wantCost {
+					t.Errorf("%s stored as %T: got %s cost %g, want %g", tt.property, res.Properties[tt.property], tt.component, got, tt.wantCost)
+				}
+			}
+		})
+	}
+}
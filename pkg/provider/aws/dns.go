@@ -0,0 +1,233 @@
+package aws
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"strings"
+
+	"github.com/aws/aws-sdk-go-v2/aws"
+	"github.com/aws/aws-sdk-go-v2/service/route53"
+	route53Types "github.com/aws/aws-sdk-go-v2/service/route53/types"
+
+	"github.com/comfortablynumb/pmp-cloud-inspector/pkg/resource"
+)
+
+// collectRoute53 collects the Route 53 hosted zones and their records (global service), keeping
+// the requested types. Records are listed for every zone, as they are only reachable through it.
+func (p *Provider) collectRoute53(ctx context.Context, collection *resource.Collection, cfg aws.Config, typeSet map[resource.ResourceType]bool) error {
+	fmt.Fprintf(os.Stderr, "  Collecting Route 53 hosted zones (global)...\n")
+	client := route53.NewFromConfig(cfg)
+
+	paginator := route53.NewListHostedZonesPaginator(client, &route53.ListHostedZonesInput{})
+
+	zoneCount, recordCount := 0, 0
+	for paginator.HasMorePages() {
+		output, err := paginator.NextPage(ctx)
+		if err != nil {
+			return fmt.Errorf("failed to list hosted zones: %w", err)
+		}
+
+		for _, zone := range output.HostedZones {
+			zoneID := strings.TrimPrefix(safeString(zone.Id), "/hostedzone/")
+
+			if typeSet[resource.TypeAWSRoute53Zone] {
+				res := p.convertHostedZoneToResource(&zone, zoneID)
+				p.describeHostedZone(ctx, client, zoneID, res)
+				collection.Add(res)
+				zoneCount++
+				fmt.Fprintf(os.Stderr, "    Found hosted zone: %s (%s)\n", res.Name, zoneID)
+			}
+
+			if typeSet[resource.TypeAWSRoute53Record] {
+				count, err := p.collectRoute53Records(ctx, client, collection, zoneID)
+				if err != nil {
+					fmt.Fprintf(os.Stderr, "    Warning: failed to list records of hosted zone %s: %v\n", zoneID, err)
+				}
+				recordCount += count
+			}
+		}
+	}
+
+	fmt.Fprintf(os.Stderr, "  Collected %d hosted zones and %d records\n", zoneCount, recordCount)
+	return nil
+}
+
+// describeHostedZone adds the VPCs of a private hosted zone and the tags of a zone to its
+// resource. They are optional: failures are reported as warnings.
+func (p *Provider) describeHostedZone(ctx context.Context, client *route53.Client, zoneID string, res *resource.Resource) {
+	if private, _ := res.BoolProperty("private_zone"); private {
+		output, err := client.GetHostedZone(ctx, &route53.GetHostedZoneInput{Id: aws.String(zoneID)})
+		if err != nil {
+			fmt.Fprintf(os.Stderr, "    Warning: failed to get hosted zone %s: %v\n", zoneID, err)
+		} else {
+			vpcs := make([]map[string]interface{}, 0, len(output.VPCs))
+			for _, vpc := range output.VPCs {
+				vpcs = append(vpcs, map[string]interface{}{
+					"vpc_id": safeString(vpc.VPCId),
+					"region": string(vpc.VPCRegion),
+				})
+				res.Relationships = append(res.Relationships, resource.Relationship{
+					Type:       resource.RelationAttachedTo,
+					TargetID:   safeString(vpc.VPCId),
+					TargetType: resource.TypeAWSVPC,
+				})
+			}
+			res.Properties["vpcs"] = vpcs
+		}
+	}
+
+	tags, err := client.ListTagsForResource(ctx, &route53.ListTagsForResourceInput{
+		ResourceId:   aws.String(zoneID),
+		ResourceType: route53Types.TagResourceTypeHostedzone,
+	})
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "    Warning: failed to list tags of hosted zone %s: %v\n", zoneID, err)
+	} else if tags.ResourceTagSet != nil && len(tags.ResourceTagSet.Tags) > 0 {
+		res.Tags = make(map[string]string, len(tags.ResourceTagSet.Tags))
+		for _, tag := range tags.ResourceTagSet.Tags {
+			if tag.Key != nil {
+				res.Tags[*tag.Key] = safeString(tag.Value)
+			}
+		}
+	}
+}
+
+// convertHostedZoneToResource converts a Route 53 hosted zone to a Resource
+func (p *Provider) convertHostedZoneToResource(zone *route53Types.HostedZone, zoneID string) *resource.Resource {
+	var account string
+	if len(p.accounts) > 0 {
+		account = p.accounts[0]
+	}
+
+	properties := map[string]interface{}{
+		"private_zone": false,
+	}
+
+	if zone.Config != nil {
+		properties["private_zone"] = zone.Config.PrivateZone
+		if zone.Config.Comment != nil && *zone.Config.Comment != "" {
+			properties["comment"] = *zone.Config.Comment
+		}
+	}
+	if zone.ResourceRecordSetCount != nil {
+		properties["record_count"] = *zone.ResourceRecordSetCount
+	}
+	if zone.LinkedService != nil {
+		properties["linked_service"] = safeString(zone.LinkedService.ServicePrincipal)
+	}
+
+	return &resource.Resource{
+		ID:         zoneID,
+		Type:       resource.TypeAWSRoute53Zone,
+		Name:       dnsName(safeString(zone.Name)),
+		Provider:   "aws",
+		Account:    account,
+		Region:     "global", // Route 53 is a global service
+		ARN:        fmt.Sprintf("arn:aws:route53:::hostedzone/%s", zoneID),
+		Properties: properties,
+		RawData:    zone,
+	}
+}
+
+// collectRoute53Records collects the records of a hosted zone, and returns the number of records
+// collected
+func (p *Provider) collectRoute53Records(ctx context.Context, client *route53.Client, collection *resource.Collection, zoneID string) (int, error) {
+	paginator := route53.NewListResourceRecordSetsPaginator(client, &route53.ListResourceRecordSetsInput{
+		HostedZoneId: aws.String(zoneID),
+	})
+
+	count := 0
+	for paginator.HasMorePages() {
+		output, err := paginator.NextPage(ctx)
+		if err != nil {
+			return count, err
+		}
+
+		for _, record := range output.ResourceRecordSets {
+			collection.Add(p.convertRoute53RecordToResource(&record, zoneID))
+			count++
+		}
+	}
+
+	return count, nil
+}
+
+// convertRoute53RecordToResource converts a Route 53 record to a Resource. Records are identified
+// by their zone, name, type and set identifier, as routing policies allow several records with
+// the same name and type.
+func (p *Provider) convertRoute53RecordToResource(record *route53Types.ResourceRecordSet, zoneID string) *resource.Resource {
+	var account string
+	if len(p.accounts) > 0 {
+		account = p.accounts[0]
+	}
+
+	name := dnsName(safeString(record.Name))
+	id := fmt.Sprintf("%s/%s/%s", zoneID, name, record.Type)
+	if record.SetIdentifier != nil {
+		id += "/" + *record.SetIdentifier
+	}
+
+	properties := map[string]interface{}{
+		"zone_id":     zoneID,
+		"record_type": string(record.Type),
+	}
+
+	if record.TTL != nil {
+		properties["ttl"] = *record.TTL
+	}
+	if len(record.ResourceRecords) > 0 {
+		values := make([]string, 0, len(record.ResourceRecords))
+		for _, value := range record.ResourceRecords {
+			values = append(values, safeString(value.Value))
+		}
+		properties["values"] = values
+	}
+	if alias := record.AliasTarget; alias != nil {
+		properties["alias_target"] = map[string]interface{}{
+			"dns_name":               dnsName(safeString(alias.DNSName)),
+			"hosted_zone_id":         safeString(alias.HostedZoneId),
+			"evaluate_target_health": alias.EvaluateTargetHealth,
+		}
+	}
+	if record.SetIdentifier != nil {
+		properties["set_identifier"] = *record.SetIdentifier
+	}
+	if record.Weight != nil {
+		properties["weight"] = *record.Weight
+	}
+	if record.Region != "" {
+		properties["latency_region"] = string(record.Region)
+	}
+	if record.Failover != "" {
+		properties["failover"] = string(record.Failover)
+	}
+	if record.HealthCheckId != nil {
+		properties["health_check_id"] = *record.HealthCheckId
+	}
+
+	return &resource.Resource{
+		ID:         id,
+		Type:       resource.TypeAWSRoute53Record,
+		Name:       name,
+		Provider:   "aws",
+		Account:    account,
+		Region:     "global", // Route 53 is a global service
+		Properties: properties,
+		RawData:    record,
+		Relationships: []resource.Relationship{
+			{
+				Type:       resource.RelationBelongsTo,
+				TargetID:   zoneID,
+				TargetType: resource.TypeAWSRoute53Zone,
+			},
+		},
+	}
+}
+
+// dnsName normalizes a DNS name: lower case, without the trailing dot, and with the wildcard
+// escaped by Route 53 unescaped
+func dnsName(name string) string {
+	name = strings.ReplaceAll(name, "\\052", "*")
+	return strings.TrimSuffix(strings.ToLower(name), ".")
+}
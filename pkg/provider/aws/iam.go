@@ -9,6 +9,7 @@ import (
 	"github.com/aws/aws-sdk-go-v2/service/iam"
 	iamTypes "github.com/aws/aws-sdk-go-v2/service/iam/types"
 
+	"github.com/comfortablynumb/pmp-cloud-inspector/pkg/iampolicy"
 	"github.com/comfortablynumb/pmp-cloud-inspector/pkg/resource"
 )
 
@@ -26,6 +27,21 @@ func (p *Provider) collectIAMUsers(ctx context.Context, collection *resource.Col
 
 		for _, user := range output.Users {
 			res := p.convertIAMUserToResource(&user)
+			grants, inline, err := p.iamUserPolicyGrants(ctx, safeString(user.UserName))
+			if err != nil {
+				fmt.Fprintf(os.Stderr, "    Warning: failed to list policies of IAM user %s: %v\n", safeString(user.UserName), err)
+			}
+			res.Relationships = append(res.Relationships, grants...)
+			if len(inline) > 0 {
+				res.Properties[resource.PropInlinePolicies] = inline
+			}
+			keys, err := p.iamAccessKeys(ctx, safeString(user.UserName))
+			if err != nil {
+				fmt.Fprintf(os.Stderr, "    Warning: failed to list access keys of IAM user %s: %v\n", safeString(user.UserName), err)
+			}
+			if len(keys) > 0 {
+				res.Properties[resource.PropAccessKeys] = keys
+			}
 			collection.Add(res)
 			count++
 			fmt.Fprintf(os.Stderr, "    Found IAM user: %s\n", safeString(user.UserName))
@@ -39,6 +55,13 @@ func (p *Provider) collectIAMUsers(ctx context.Context, collection *resource.Col
 // collectIAMRoles collects all IAM roles
 func (p *Provider) collectIAMRoles(ctx context.Context, collection *resource.Collection) error {
 	fmt.Fprintf(os.Stderr, "  Collecting IAM roles...\n")
+
+	// Instance profiles relate the roles to the EC2 instances using them; they are optional
+	instanceProfiles, err := p.listInstanceProfiles(ctx)
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "    Warning: failed to list IAM instance profiles: %v\n", err)
+	}
+
 	paginator := iam.NewListRolesPaginator(p.iamClient, &iam.ListRolesInput{})
 
 	count := 0
@@ -49,7 +72,28 @@ func (p *Provider) collectIAMRoles(ctx context.Context, collection *resource.Col
 		}
 
 		for _, role := range output.Roles {
+			// ListRoles does not return tags, they are needed for cost allocation
+			if len(role.Tags) == 0 {
+				tagsOutput, err := p.iamClient.ListRoleTags(ctx, &iam.ListRoleTagsInput{RoleName: role.RoleName})
+				if err != nil {
+					fmt.Fprintf(os.Stderr, "    Warning: failed to list tags of IAM role %s: %v\n", safeString(role.RoleName), err)
+				} else {
+					role.Tags = tagsOutput.Tags
+				}
+			}
+
 			res := p.convertIAMRoleToResource(&role)
+			if profiles := instanceProfiles[res.ID]; len(profiles) > 0 {
+				res.Properties["instance_profiles"] = profiles
+			}
+			grants, inline, err := p.iamRolePolicyGrants(ctx, safeString(role.RoleName))
+			if err != nil {
+				fmt.Fprintf(os.Stderr, "    Warning: failed to list policies of IAM role %s: %v\n", safeString(role.RoleName), err)
+			}
+			res.Relationships = append(res.Relationships, grants...)
+			if len(inline) > 0 {
+				res.Properties[resource.PropInlinePolicies] = inline
+			}
 			collection.Add(res)
 			count++
 			fmt.Fprintf(os.Stderr, "    Found IAM role: %s\n", safeString(role.RoleName))
@@ -60,6 +104,192 @@ func (p *Provider) collectIAMRoles(ctx context.Context, collection *resource.Col
 	return nil
 }
 
+// iamAccessKeys returns the access keys of an IAM user, with when they were last used
+func (p *Provider) iamAccessKeys(ctx context.Context, userName string) ([]map[string]interface{}, error) {
+	var keys []map[string]interface{}
+
+	paginator := iam.NewListAccessKeysPaginator(p.iamClient, &iam.ListAccessKeysInput{UserName: &userName})
+	for paginator.HasMorePages() {
+		output, err := paginator.NextPage(ctx)
+		if err != nil {
+			return keys, err
+		}
+
+		for _, key := range output.AccessKeyMetadata {
+			entry := map[string]interface{}{
+				"access_key_id": safeString(key.AccessKeyId),
+				"status":        string(key.Status),
+			}
+			if key.CreateDate != nil {
+				entry["create_date"] = key.CreateDate.Format(time.RFC3339)
+			}
+
+			lastUsed, err := p.iamClient.GetAccessKeyLastUsed(ctx, &iam.GetAccessKeyLastUsedInput{AccessKeyId: key.AccessKeyId})
+			if err != nil {
+				return keys, err
+			}
+			if used := lastUsed.AccessKeyLastUsed; used != nil && used.LastUsedDate != nil {
+				entry["last_used_date"] = used.LastUsedDate.Format(time.RFC3339)
+				entry["last_used_service"] = safeString(used.ServiceName)
+			}
+
+			keys = append(keys, entry)
+		}
+	}
+
+	return keys, nil
+}
+
+// iamUserPolicyGrants returns has_access relationships to the managed policies attached to an
+// IAM user and to its inline policies, along with the documents of the inline policies
+func (p *Provider) iamUserPolicyGrants(ctx context.Context, userName string) ([]resource.Relationship, []map[string]interface{}, error) {
+	var grants []resource.Relationship
+	var inline []map[string]interface{}
+
+	attached := iam.NewListAttachedUserPoliciesPaginator(p.iamClient, &iam.ListAttachedUserPoliciesInput{UserName: &userName})
+	for attached.HasMorePages() {
+		output, err := attached.NextPage(ctx)
+		if err != nil {
+			return grants, inline, err
+		}
+		for _, policy := range output.AttachedPolicies {
+			grants = append(grants, managedPolicyGrant(policy))
+		}
+	}
+
+	inlineNames := iam.NewListUserPoliciesPaginator(p.iamClient, &iam.ListUserPoliciesInput{UserName: &userName})
+	for inlineNames.HasMorePages() {
+		output, err := inlineNames.NextPage(ctx)
+		if err != nil {
+			return grants, inline, err
+		}
+		for _, name := range output.PolicyNames {
+			grants = append(grants, inlinePolicyGrant(name))
+
+			policy, err := p.iamClient.GetUserPolicy(ctx, &iam.GetUserPolicyInput{UserName: &userName, PolicyName: &name})
+			if err != nil {
+				return grants, inline, err
+			}
+			inline = append(inline, inlinePolicy(name, policy.PolicyDocument))
+		}
+	}
+
+	return grants, inline, nil
+}
+
+// iamRolePolicyGrants returns has_access relationships to the managed policies attached to an
+// IAM role and to its inline policies, along with the documents of the inline policies
+func (p *Provider) iamRolePolicyGrants(ctx context.Context, roleName string) ([]resource.Relationship, []map[string]interface{}, error) {
+	var grants []resource.Relationship
+	var inline []map[string]interface{}
+
+	attached := iam.NewListAttachedRolePoliciesPaginator(p.iamClient, &iam.ListAttachedRolePoliciesInput{RoleName: &roleName})
+	for attached.HasMorePages() {
+		output, err := attached.NextPage(ctx)
+		if err != nil {
+			return grants, inline, err
+		}
+		for _, policy := range output.AttachedPolicies {
+			grants = append(grants, managedPolicyGrant(policy))
+		}
+	}
+
+	inlineNames := iam.NewListRolePoliciesPaginator(p.iamClient, &iam.ListRolePoliciesInput{RoleName: &roleName})
+	for inlineNames.HasMorePages() {
+		output, err := inlineNames.NextPage(ctx)
+		if err != nil {
+			return grants, inline, err
+		}
+		for _, name := range output.PolicyNames {
+			grants = append(grants, inlinePolicyGrant(name))
+
+			policy, err := p.iamClient.GetRolePolicy(ctx, &iam.GetRolePolicyInput{RoleName: &roleName, PolicyName: &name})
+			if err != nil {
+				return grants, inline, err
+			}
+			inline = append(inline, inlinePolicy(name, policy.PolicyDocument))
+		}
+	}
+
+	return grants, inline, nil
+}
+
+// iamGroupPolicyGrants returns has_access relationships to the managed policies attached to an
+// IAM group and to its inline policies, along with the documents of the inline policies
+func (p *Provider) iamGroupPolicyGrants(ctx context.Context, groupName string) ([]resource.Relationship, []map[string]interface{}, error) {
+	var grants []resource.Relationship
+	var inline []map[string]interface{}
+
+	attached := iam.NewListAttachedGroupPoliciesPaginator(p.iamClient, &iam.ListAttachedGroupPoliciesInput{GroupName: &groupName})
+	for attached.HasMorePages() {
+		output, err := attached.NextPage(ctx)
+		if err != nil {
+			return grants, inline, err
+		}
+		for _, policy := range output.AttachedPolicies {
+			grants = append(grants, managedPolicyGrant(policy))
+		}
+	}
+
+	inlineNames := iam.NewListGroupPoliciesPaginator(p.iamClient, &iam.ListGroupPoliciesInput{GroupName: &groupName})
+	for inlineNames.HasMorePages() {
+		output, err := inlineNames.NextPage(ctx)
+		if err != nil {
+			return grants, inline, err
+		}
+		for _, name := range output.PolicyNames {
+			grants = append(grants, inlinePolicyGrant(name))
+
+			policy, err := p.iamClient.GetGroupPolicy(ctx, &iam.GetGroupPolicyInput{GroupName: &groupName, PolicyName: &name})
+			if err != nil {
+				return grants, inline, err
+			}
+			inline = append(inline, inlinePolicy(name, policy.PolicyDocument))
+		}
+	}
+
+	return grants, inline, nil
+}
+
+// inlinePolicy returns the name and parsed document of an inline policy. Documents that cannot
+// be parsed are kept as returned by the API.
+func inlinePolicy(name string, document *string) map[string]interface{} {
+	entry := map[string]interface{}{"name": name}
+	if doc, err := iampolicy.ToProperty(safeString(document)); err == nil {
+		entry["document"] = doc
+	} else {
+		entry["document"] = safeString(document)
+	}
+	return entry
+}
+
+// managedPolicyGrant returns the has_access relationship to an attached managed policy
+func managedPolicyGrant(policy iamTypes.AttachedPolicy) resource.Relationship {
+	return resource.Relationship{
+		Type:       resource.RelationHasAccess,
+		TargetID:   safeString(policy.PolicyArn),
+		TargetType: resource.TypeAWSIAMPolicy,
+		Properties: map[string]interface{}{
+			resource.PropPermission: safeString(policy.PolicyName),
+			"policy_type":           "managed",
+		},
+	}
+}
+
+// inlinePolicyGrant returns the has_access relationship to an inline policy. Inline policies
+// have no ARN, the target is the policy name.
+func inlinePolicyGrant(name string) resource.Relationship {
+	return resource.Relationship{
+		Type:       resource.RelationHasAccess,
+		TargetID:   name,
+		TargetType: resource.TypeAWSIAMPolicy,
+		Properties: map[string]interface{}{
+			resource.PropPermission: name,
+			"policy_type":           "inline",
+		},
+	}
+}
+
 // collectAccounts collects account information
 func (p *Provider) collectAccounts(collection *resource.Collection) {
 	fmt.Fprintf(os.Stderr, "  Collecting AWS accounts...\n")
@@ -155,6 +385,17 @@ func (p *Provider) convertIAMRoleToResource(role *iamTypes.Role) *resource.Resou
 	if role.Description != nil {
 		properties["description"] = *role.Description
 	}
+	if role.AssumeRolePolicyDocument != nil {
+		trust, err := iampolicy.Parse(*role.AssumeRolePolicyDocument)
+		if err != nil {
+			fmt.Fprintf(os.Stderr, "    Warning: failed to parse the trust policy of IAM role %s: %v\n", safeString(role.RoleName), err)
+		} else {
+			if document, err := iampolicy.ToProperty(*role.AssumeRolePolicyDocument); err == nil {
+				properties[resource.PropTrustPolicy] = document
+			}
+			properties[resource.PropTrustedPrincipals] = principalProperties(iampolicy.TrustedPrincipals(trust, iampolicy.PrincipalAccount(safeString(role.Arn))))
+		}
+	}
 
 	var tags map[string]string
 	if len(role.Tags) > 0 {
@@ -187,6 +428,247 @@ func (p *Provider) convertIAMRoleToResource(role *iamTypes.Role) *resource.Resou
 	return res
 }
 
+// listInstanceProfiles returns the ARNs of the instance profiles of the account, by role ARN
+func (p *Provider) listInstanceProfiles(ctx context.Context) (map[string][]string, error) {
+	profiles := make(map[string][]string)
+
+	paginator := iam.NewListInstanceProfilesPaginator(p.iamClient, &iam.ListInstanceProfilesInput{})
+	for paginator.HasMorePages() {
+		output, err := paginator.NextPage(ctx)
+		if err != nil {
+			return profiles, err
+		}
+
+		for _, profile := range output.InstanceProfiles {
+			for _, role := range profile.Roles {
+				roleARN := safeString(role.Arn)
+				profiles[roleARN] = append(profiles[roleARN], safeString(profile.Arn))
+			}
+		}
+	}
+
+	return profiles, nil
+}
+
+// principalProperties returns the principals allowed by a trust policy or a key policy as
+// property values
+func principalProperties(trusted []iampolicy.TrustedPrincipal) []map[string]interface{} {
+	principals := []map[string]interface{}{}
+	for _, t := range trusted {
+		entry := map[string]interface{}{
+			"type":          t.Type,
+			"principal":     t.Principal,
+			"cross_account": t.CrossAccount,
+			"conditional":   t.Conditional,
+		}
+		if t.Account != "" {
+			entry["account"] = t.Account
+		}
+		principals = append(principals, entry)
+	}
+	return principals
+}
+
+// collectIAMGroups collects all IAM groups, with their members and policies
+func (p *Provider) collectIAMGroups(ctx context.Context, collection *resource.Collection) error {
+	fmt.Fprintf(os.Stderr, "  Collecting IAM groups...\n")
+	paginator := iam.NewListGroupsPaginator(p.iamClient, &iam.ListGroupsInput{})
+
+	count := 0
+	for paginator.HasMorePages() {
+		output, err := paginator.NextPage(ctx)
+		if err != nil {
+			return fmt.Errorf("failed to list IAM groups: %w", err)
+		}
+
+		for _, group := range output.Groups {
+			res := p.convertIAMGroupToResource(&group)
+
+			members, err := p.iamGroupMembers(ctx, safeString(group.GroupName))
+			if err != nil {
+				fmt.Fprintf(os.Stderr, "    Warning: failed to list members of IAM group %s: %v\n", safeString(group.GroupName), err)
+			}
+			res.Relationships = append(res.Relationships, members...)
+			res.Properties["member_count"] = len(members)
+
+			grants, inline, err := p.iamGroupPolicyGrants(ctx, safeString(group.GroupName))
+			if err != nil {
+				fmt.Fprintf(os.Stderr, "    Warning: failed to list policies of IAM group %s: %v\n", safeString(group.GroupName), err)
+			}
+			res.Relationships = append(res.Relationships, grants...)
+			if len(inline) > 0 {
+				res.Properties[resource.PropInlinePolicies] = inline
+			}
+
+			collection.Add(res)
+			count++
+			fmt.Fprintf(os.Stderr, "    Found IAM group: %s\n", safeString(group.GroupName))
+		}
+	}
+
+	fmt.Fprintf(os.Stderr, "  Collected %d IAM groups\n", count)
+	return nil
+}
+
+// iamGroupMembers returns the contains relationships of an IAM group to its users
+func (p *Provider) iamGroupMembers(ctx context.Context, groupName string) ([]resource.Relationship, error) {
+	var members []resource.Relationship
+
+	paginator := iam.NewGetGroupPaginator(p.iamClient, &iam.GetGroupInput{GroupName: &groupName})
+	for paginator.HasMorePages() {
+		output, err := paginator.NextPage(ctx)
+		if err != nil {
+			return members, err
+		}
+		for _, user := range output.Users {
+			members = append(members, resource.Relationship{
+				Type:       resource.RelationContains,
+				TargetID:   safeString(user.Arn),
+				TargetType: resource.TypeAWSIAMUser,
+			})
+		}
+	}
+
+	return members, nil
+}
+
+// collectIAMPolicies collects the customer managed policies and the AWS managed policies
+// attached to a user, group or role, with the document of their default version
+func (p *Provider) collectIAMPolicies(ctx context.Context, collection *resource.Collection) error {
+	fmt.Fprintf(os.Stderr, "  Collecting IAM policies...\n")
+
+	// There are over a thousand AWS managed policies, only those in use are collected
+	inputs := []*iam.ListPoliciesInput{
+		{Scope: iamTypes.PolicyScopeTypeLocal},
+		{Scope: iamTypes.PolicyScopeTypeAws, OnlyAttached: true},
+	}
+
+	count := 0
+	for _, input := range inputs {
+		awsManaged := input.Scope == iamTypes.PolicyScopeTypeAws
+		paginator := iam.NewListPoliciesPaginator(p.iamClient, input)
+		for paginator.HasMorePages() {
+			output, err := paginator.NextPage(ctx)
+			if err != nil {
+				return fmt.Errorf("failed to list IAM policies: %w", err)
+			}
+
+			for _, policy := range output.Policies {
+				res := p.convertIAMPolicyToResource(&policy, awsManaged)
+
+				version, err := p.iamClient.GetPolicyVersion(ctx, &iam.GetPolicyVersionInput{
+					PolicyArn: policy.Arn,
+					VersionId: policy.DefaultVersionId,
+				})
+				if err != nil {
+					fmt.Fprintf(os.Stderr, "    Warning: failed to get the document of IAM policy %s: %v\n", safeString(policy.PolicyName), err)
+				} else if version.PolicyVersion != nil && version.PolicyVersion.Document != nil {
+					if document, err := iampolicy.ToProperty(*version.PolicyVersion.Document); err == nil {
+						res.Properties[resource.PropPolicyDocument] = document
+					} else {
+						fmt.Fprintf(os.Stderr, "    Warning: failed to parse the document of IAM policy %s: %v\n", safeString(policy.PolicyName), err)
+					}
+				}
+
+				collection.Add(res)
+				count++
+				fmt.Fprintf(os.Stderr, "    Found IAM policy: %s\n", safeString(policy.PolicyName))
+			}
+		}
+	}
+
+	fmt.Fprintf(os.Stderr, "  Collected %d IAM policies\n", count)
+	return nil
+}
+
+// convertIAMGroupToResource converts an IAM group to a Resource
+func (p *Provider) convertIAMGroupToResource(group *iamTypes.Group) *resource.Resource {
+	var account string
+	if len(p.accounts) > 0 {
+		account = p.accounts[0]
+	}
+
+	properties := map[string]interface{}{}
+	if group.GroupId != nil {
+		properties["group_id"] = *group.GroupId
+	}
+	if group.Path != nil {
+		properties["path"] = *group.Path
+	}
+	if group.CreateDate != nil {
+		properties["create_date"] = group.CreateDate.Format(time.RFC3339)
+	}
+
+	return &resource.Resource{
+		ID:         safeString(group.Arn),
+		Type:       resource.TypeAWSIAMGroup,
+		Name:       safeString(group.GroupName),
+		Provider:   "aws",
+		Account:    account,
+		ARN:        safeString(group.Arn),
+		Properties: properties,
+		RawData:    group,
+		CreatedAt:  group.CreateDate,
+	}
+}
+
+// convertIAMPolicyToResource converts a managed policy to a Resource
+func (p *Provider) convertIAMPolicyToResource(policy *iamTypes.Policy, awsManaged bool) *resource.Resource {
+	var account string
+	if len(p.accounts) > 0 {
+		account = p.accounts[0]
+	}
+
+	properties := map[string]interface{}{
+		"aws_managed": awsManaged,
+	}
+	if policy.PolicyId != nil {
+		properties["policy_id"] = *policy.PolicyId
+	}
+	if policy.Path != nil {
+		properties["path"] = *policy.Path
+	}
+	if policy.Description != nil {
+		properties["description"] = *policy.Description
+	}
+	if policy.DefaultVersionId != nil {
+		properties["default_version_id"] = *policy.DefaultVersionId
+	}
+	if policy.AttachmentCount != nil {
+		properties["attachment_count"] = *policy.AttachmentCount
+	}
+	if policy.PermissionsBoundaryUsageCount != nil {
+		properties["permissions_boundary_usage_count"] = *policy.PermissionsBoundaryUsageCount
+	}
+	properties["is_attachable"] = policy.IsAttachable
+	if policy.UpdateDate != nil {
+		properties["update_date"] = policy.UpdateDate.Format(time.RFC3339)
+	}
+
+	var tags map[string]string
+	if len(policy.Tags) > 0 {
+		tags = make(map[string]string)
+		for _, tag := range policy.Tags {
+			if tag.Key != nil && tag.Value != nil {
+				tags[*tag.Key] = *tag.Value
+			}
+		}
+	}
+
+	return &resource.Resource{
+		ID:         safeString(policy.Arn),
+		Type:       resource.TypeAWSIAMPolicy,
+		Name:       safeString(policy.PolicyName),
+		Provider:   "aws",
+		Account:    account,
+		ARN:        safeString(policy.Arn),
+		Tags:       tags,
+		Properties: properties,
+		RawData:    policy,
+		CreatedAt:  policy.CreateDate,
+	}
+}
+
 // safeString safely dereferences a string pointer
 func safeString(s *string) string {
 	if s == nil {
@@ -4,6 +4,8 @@ import (
 	"context"
 	"fmt"
 	"os"
+	"slices"
+	"strings"
 
 	"github.com/aws/aws-sdk-go-v2/aws"
 	"github.com/aws/aws-sdk-go-v2/service/elasticloadbalancing"
@@ -29,7 +31,20 @@ func (p *Provider) collectClassicLoadBalancers(ctx context.Context, collection *
 		}
 
 		for _, lb := range output.LoadBalancerDescriptions {
-			res := p.convertClassicLoadBalancerToResource(&lb, region)
+			// The health of the registered instances is optional
+			var health []elbTypes.InstanceState
+			if len(lb.Instances) > 0 {
+				healthOutput, err := client.DescribeInstanceHealth(ctx, &elasticloadbalancing.DescribeInstanceHealthInput{
+					LoadBalancerName: lb.LoadBalancerName,
+				})
+				if err != nil {
+					fmt.Fprintf(os.Stderr, "    Warning: failed to describe instance health of %s: %v\n", safeString(lb.LoadBalancerName), err)
+				} else {
+					health = healthOutput.InstanceStates
+				}
+			}
+
+			res := p.convertClassicLoadBalancerToResource(&lb, health, region)
 			collection.Add(res)
 			count++
 			fmt.Fprintf(os.Stderr, "    Found Classic ELB: %s\n", safeString(lb.LoadBalancerName))
@@ -40,49 +55,347 @@ func (p *Provider) collectClassicLoadBalancers(ctx context.Context, collection *
 	return nil
 }
 
-// collectLoadBalancersV2 collects all ALBs and NLBs in a region
-func (p *Provider) collectLoadBalancersV2(ctx context.Context, collection *resource.Collection, region string, cfg aws.Config) error {
+// collectLoadBalancersV2 collects the ALBs and NLBs of a region, with their listeners and rules,
+// and their target groups, keeping the requested types
+func (p *Provider) collectLoadBalancersV2(ctx context.Context, collection *resource.Collection, region string, cfg aws.Config, typeSet map[resource.ResourceType]bool) error {
 	fmt.Fprintf(os.Stderr, "  Collecting ALBs/NLBs in %s...\n", region)
 	client := elasticloadbalancingv2.NewFromConfig(cfg)
 
-	paginator := elasticloadbalancingv2.NewDescribeLoadBalancersPaginator(client, &elasticloadbalancingv2.DescribeLoadBalancersInput{})
+	// Target groups give the registered targets of the load balancers, used to find idle load
+	// balancers; they are optional when only load balancers are collected
+	targetGroups, err := describeTargetGroups(ctx, client)
+	targetsKnown := err == nil
+	if err != nil {
+		if typeSet[resource.TypeAWSTargetGroup] {
+			return fmt.Errorf("failed to describe target groups: %w", err)
+		}
+		fmt.Fprintf(os.Stderr, "    Warning: failed to describe target groups in %s: %v\n", region, err)
+	}
 
 	albCount := 0
 	nlbCount := 0
+	if typeSet[resource.TypeAWSALB] || typeSet[resource.TypeAWSNLB] {
+		paginator := elasticloadbalancingv2.NewDescribeLoadBalancersPaginator(client, &elasticloadbalancingv2.DescribeLoadBalancersInput{})
+
+		for paginator.HasMorePages() {
+			output, err := paginator.NextPage(ctx)
+			if err != nil {
+				return fmt.Errorf("failed to describe load balancers v2: %w", err)
+			}
+
+			for _, lb := range output.LoadBalancers {
+				res := p.convertLoadBalancerV2ToResource(&lb, region)
+				if targetsKnown {
+					addLoadBalancerTargets(res, targetGroups)
+				}
+
+				// Listeners and their rules are optional
+				listeners, relationships, err := describeListeners(ctx, client, safeString(lb.LoadBalancerArn))
+				if err != nil {
+					fmt.Fprintf(os.Stderr, "    Warning: failed to describe listeners of %s: %v\n", safeString(lb.LoadBalancerName), err)
+				} else {
+					res.Properties["listeners"] = listeners
+					res.Relationships = append(res.Relationships, relationships...)
+				}
+
+				collection.Add(res)
+
+				if lb.Type == elbv2Types.LoadBalancerTypeEnumApplication {
+					albCount++
+					fmt.Fprintf(os.Stderr, "    Found ALB: %s\n", safeString(lb.LoadBalancerName))
+				} else if lb.Type == elbv2Types.LoadBalancerTypeEnumNetwork {
+					nlbCount++
+					fmt.Fprintf(os.Stderr, "    Found NLB: %s\n", safeString(lb.LoadBalancerName))
+				}
+			}
+		}
+	}
+
+	if typeSet[resource.TypeAWSTargetGroup] {
+		for _, group := range targetGroups {
+			collection.Add(p.convertTargetGroupToResource(group, region))
+			fmt.Fprintf(os.Stderr, "    Found target group: %s (%d targets)\n", safeString(group.group.TargetGroupName), len(group.targets))
+		}
+	}
+
+	fmt.Fprintf(os.Stderr, "  Collected %d ALBs, %d NLBs and %d target groups in %s\n", albCount, nlbCount, len(targetGroups), region)
+	return nil
+}
+
+// targetGroupTargets is a target group with the health of its registered targets
+type targetGroupTargets struct {
+	group   elbv2Types.TargetGroup
+	targets []elbv2Types.TargetHealthDescription
+	known   bool // the health of the targets could be described
+}
+
+// describeTargetGroups returns the target groups of a region with their registered targets.
+// Failures to describe the targets of a group are reported as warnings.
+func describeTargetGroups(ctx context.Context, client *elasticloadbalancingv2.Client) ([]*targetGroupTargets, error) {
+	paginator := elasticloadbalancingv2.NewDescribeTargetGroupsPaginator(client, &elasticloadbalancingv2.DescribeTargetGroupsInput{})
+
+	var groups []*targetGroupTargets
 	for paginator.HasMorePages() {
 		output, err := paginator.NextPage(ctx)
 		if err != nil {
-			return fmt.Errorf("failed to describe load balancers v2: %w", err)
+			return nil, err
 		}
 
-		for _, lb := range output.LoadBalancers {
-			res := p.convertLoadBalancerV2ToResource(&lb, region)
-			collection.Add(res)
+		for _, group := range output.TargetGroups {
+			entry := &targetGroupTargets{group: group}
+			health, err := client.DescribeTargetHealth(ctx, &elasticloadbalancingv2.DescribeTargetHealthInput{
+				TargetGroupArn: group.TargetGroupArn,
+			})
+			if err != nil {
+				fmt.Fprintf(os.Stderr, "    Warning: failed to describe targets of target group %s: %v\n", safeString(group.TargetGroupName), err)
+			} else {
+				entry.targets = health.TargetHealthDescriptions
+				entry.known = true
+			}
+			groups = append(groups, entry)
+		}
+	}
+
+	return groups, nil
+}
+
+// addLoadBalancerTargets sets the number of target groups of an ALB or NLB, and the number of
+// targets registered in them. The counts are left unset when the targets of one of its groups
+// are unknown.
+func addLoadBalancerTargets(res *resource.Resource, targetGroups []*targetGroupTargets) {
+	groups, targets, healthy := 0, 0, 0
+	for _, group := range targetGroups {
+		if !slices.Contains(group.group.LoadBalancerArns, res.ARN) {
+			continue
+		}
+		if !group.known {
+			return
+		}
+		groups++
+		targets += len(group.targets)
+		healthy += countHealthyTargets(group.targets)
+	}
+
+	res.Properties["target_groups"] = groups
+	res.Properties[resource.PropTargetCount] = targets
+	res.Properties[resource.PropHealthyTargets] = healthy
+}
+
+// countHealthyTargets returns the number of targets passing their health checks
+func countHealthyTargets(targets []elbv2Types.TargetHealthDescription) int {
+	healthy := 0
+	for _, target := range targets {
+		if target.TargetHealth != nil && target.TargetHealth.State == elbv2Types.TargetHealthStateEnumHealthy {
+			healthy++
+		}
+	}
+	return healthy
+}
+
+// describeListeners returns the listeners of an ALB or NLB with their rules, as property values,
+// and the routes_to relationships of the load balancer to the target groups they forward to,
+// with the listeners forwarding to each group as properties
+func describeListeners(ctx context.Context, client *elasticloadbalancingv2.Client, lbArn string) ([]map[string]interface{}, []resource.Relationship, error) {
+	paginator := elasticloadbalancingv2.NewDescribeListenersPaginator(client, &elasticloadbalancingv2.DescribeListenersInput{
+		LoadBalancerArn: aws.String(lbArn),
+	})
 
-			if lb.Type == elbv2Types.LoadBalancerTypeEnumApplication {
-				albCount++
-				fmt.Fprintf(os.Stderr, "    Found ALB: %s\n", safeString(lb.LoadBalancerName))
-			} else if lb.Type == elbv2Types.LoadBalancerTypeEnumNetwork {
-				nlbCount++
-				fmt.Fprintf(os.Stderr, "    Found NLB: %s\n", safeString(lb.LoadBalancerName))
+	var listeners []map[string]interface{}
+	var groupARNs []string
+	groupListeners := make(map[string][]string) // target group ARN -> listeners forwarding to it
+	forward := func(listener string, actions []elbv2Types.Action) {
+		for _, arn := range forwardedTargetGroups(actions) {
+			if _, ok := groupListeners[arn]; !ok {
+				groupARNs = append(groupARNs, arn)
+			}
+			if !slices.Contains(groupListeners[arn], listener) {
+				groupListeners[arn] = append(groupListeners[arn], listener)
 			}
 		}
 	}
 
-	fmt.Fprintf(os.Stderr, "  Collected %d ALBs and %d NLBs in %s\n", albCount, nlbCount, region)
-	return nil
+	for paginator.HasMorePages() {
+		output, err := paginator.NextPage(ctx)
+		if err != nil {
+			return nil, nil, err
+		}
+
+		for _, listener := range output.Listeners {
+			name := fmt.Sprintf("%s:%d", listener.Protocol, safeInt32(listener.Port))
+			entry := map[string]interface{}{
+				"listener_arn":    safeString(listener.ListenerArn),
+				"port":            safeInt32(listener.Port),
+				"protocol":        string(listener.Protocol),
+				"default_actions": actionProperties(listener.DefaultActions),
+			}
+			if listener.SslPolicy != nil {
+				entry["ssl_policy"] = *listener.SslPolicy
+			}
+			if len(listener.Certificates) > 0 {
+				certificates := make([]string, 0, len(listener.Certificates))
+				for _, certificate := range listener.Certificates {
+					certificates = append(certificates, safeString(certificate.CertificateArn))
+				}
+				entry["certificates"] = certificates
+			}
+			forward(name, listener.DefaultActions)
+
+			// Only the listeners of ALBs have rules besides the default one
+			if listener.Protocol == elbv2Types.ProtocolEnumHttp || listener.Protocol == elbv2Types.ProtocolEnumHttps {
+				rules, err := describeListenerRules(ctx, client, listener.ListenerArn)
+				if err != nil {
+					fmt.Fprintf(os.Stderr, "    Warning: failed to describe rules of listener %s: %v\n", name, err)
+				}
+				var ruleProperties []map[string]interface{}
+				for _, rule := range rules {
+					ruleProperties = append(ruleProperties, map[string]interface{}{
+						"priority":   safeString(rule.Priority),
+						"conditions": conditionProperties(rule.Conditions),
+						"actions":    actionProperties(rule.Actions),
+					})
+					forward(name, rule.Actions)
+				}
+				if len(ruleProperties) > 0 {
+					entry["rules"] = ruleProperties
+				}
+			}
+
+			listeners = append(listeners, entry)
+		}
+	}
+
+	relationships := make([]resource.Relationship, 0, len(groupARNs))
+	for _, arn := range groupARNs {
+		relationships = append(relationships, resource.Relationship{
+			Type:       resource.RelationRoutesTo,
+			TargetID:   arn,
+			TargetType: resource.TypeAWSTargetGroup,
+			Properties: map[string]interface{}{
+				"listeners": groupListeners[arn],
+			},
+		})
+	}
+
+	return listeners, relationships, nil
+}
+
+// describeListenerRules returns the rules of a listener, without its default rule
+func describeListenerRules(ctx context.Context, client *elasticloadbalancingv2.Client, listenerArn *string) ([]elbv2Types.Rule, error) {
+	var rules []elbv2Types.Rule
+
+	input := &elasticloadbalancingv2.DescribeRulesInput{ListenerArn: listenerArn}
+	for {
+		output, err := client.DescribeRules(ctx, input)
+		if err != nil {
+			return rules, err
+		}
+		for _, rule := range output.Rules {
+			if !safeBool(rule.IsDefault) {
+				rules = append(rules, rule)
+			}
+		}
+
+		if output.NextMarker == nil || *output.NextMarker == "" {
+			break
+		}
+		input.Marker = output.NextMarker
+	}
+
+	return rules, nil
+}
+
+// forwardedTargetGroups returns the ARNs of the target groups the forward actions of a listener
+// or rule send traffic to
+func forwardedTargetGroups(actions []elbv2Types.Action) []string {
+	var arns []string
+	for _, action := range actions {
+		if action.Type != elbv2Types.ActionTypeEnumForward {
+			continue
+		}
+		if action.TargetGroupArn != nil {
+			arns = append(arns, *action.TargetGroupArn)
+		}
+		if action.ForwardConfig != nil {
+			for _, group := range action.ForwardConfig.TargetGroups {
+				if group.TargetGroupArn != nil && !slices.Contains(arns, *group.TargetGroupArn) {
+					arns = append(arns, *group.TargetGroupArn)
+				}
+			}
+		}
+	}
+	return arns
+}
+
+// actionProperties returns the actions of a listener or rule as property values
+func actionProperties(actions []elbv2Types.Action) []map[string]interface{} {
+	properties := make([]map[string]interface{}, 0, len(actions))
+	for _, action := range actions {
+		entry := map[string]interface{}{
+			"type": string(action.Type),
+		}
+		if groups := forwardedTargetGroups([]elbv2Types.Action{action}); len(groups) > 0 {
+			entry["target_groups"] = groups
+		}
+		if redirect := action.RedirectConfig; redirect != nil {
+			entry["redirect"] = fmt.Sprintf("%s://%s:%s%s?%s", safeString(redirect.Protocol), safeString(redirect.Host),
+				safeString(redirect.Port), safeString(redirect.Path), safeString(redirect.Query))
+			entry["status_code"] = string(redirect.StatusCode)
+		}
+		if response := action.FixedResponseConfig; response != nil {
+			entry["status_code"] = safeString(response.StatusCode)
+		}
+		properties = append(properties, entry)
+	}
+	return properties
+}
+
+// conditionProperties returns the conditions of a rule as property values: the field matched and
+// the values it is matched against
+func conditionProperties(conditions []elbv2Types.RuleCondition) []map[string]interface{} {
+	properties := make([]map[string]interface{}, 0, len(conditions))
+	for _, condition := range conditions {
+		values := condition.Values
+		switch {
+		case condition.HostHeaderConfig != nil:
+			values = condition.HostHeaderConfig.Values
+		case condition.PathPatternConfig != nil:
+			values = condition.PathPatternConfig.Values
+		case condition.HttpRequestMethodConfig != nil:
+			values = condition.HttpRequestMethodConfig.Values
+		case condition.SourceIpConfig != nil:
+			values = condition.SourceIpConfig.Values
+		case condition.HttpHeaderConfig != nil:
+			values = make([]string, 0, len(condition.HttpHeaderConfig.Values))
+			for _, value := range condition.HttpHeaderConfig.Values {
+				values = append(values, safeString(condition.HttpHeaderConfig.HttpHeaderName)+": "+value)
+			}
+		case condition.QueryStringConfig != nil:
+			values = make([]string, 0, len(condition.QueryStringConfig.Values))
+			for _, pair := range condition.QueryStringConfig.Values {
+				values = append(values, safeString(pair.Key)+"="+safeString(pair.Value))
+			}
+		}
+
+		properties = append(properties, map[string]interface{}{
+			"field":  safeString(condition.Field),
+			"values": values,
+		})
+	}
+	return properties
 }
 
-// convertClassicLoadBalancerToResource converts a classic ELB to a Resource
-func (p *Provider) convertClassicLoadBalancerToResource(lb *elbTypes.LoadBalancerDescription, region string) *resource.Resource {
+// convertClassicLoadBalancerToResource converts a classic ELB to a Resource. The load balancer
+// routes to its registered instances, with their health as properties when known.
+func (p *Provider) convertClassicLoadBalancerToResource(lb *elbTypes.LoadBalancerDescription, health []elbTypes.InstanceState, region string) *resource.Resource {
 	var account string
 	if len(p.accounts) > 0 {
 		account = p.accounts[0]
 	}
 
 	properties := map[string]interface{}{
-		"dns_name": safeString(lb.DNSName),
-		"scheme":   safeString(lb.Scheme),
+		"dns_name":               safeString(lb.DNSName),
+		"scheme":                 safeString(lb.Scheme),
+		resource.PropTargetCount: len(lb.Instances),
 	}
 
 	if lb.VPCId != nil {
@@ -101,6 +414,41 @@ func (p *Provider) convertClassicLoadBalancerToResource(lb *elbTypes.LoadBalance
 		}
 		properties["instances"] = instanceIDs
 	}
+	if len(lb.SecurityGroups) > 0 {
+		properties[resource.PropSecurityGroups] = lb.SecurityGroups
+	}
+	if len(lb.ListenerDescriptions) > 0 {
+		listeners := make([]map[string]interface{}, 0, len(lb.ListenerDescriptions))
+		for _, description := range lb.ListenerDescriptions {
+			listener := description.Listener
+			if listener == nil {
+				continue
+			}
+			entry := map[string]interface{}{
+				"port":              listener.LoadBalancerPort,
+				"protocol":          safeString(listener.Protocol),
+				"instance_port":     safeInt32(listener.InstancePort),
+				"instance_protocol": safeString(listener.InstanceProtocol),
+			}
+			if listener.SSLCertificateId != nil {
+				entry["certificates"] = []string{*listener.SSLCertificateId}
+			}
+			listeners = append(listeners, entry)
+		}
+		properties["listeners"] = listeners
+	}
+
+	states := make(map[string]elbTypes.InstanceState, len(health))
+	healthy := 0
+	for _, state := range health {
+		states[safeString(state.InstanceId)] = state
+		if strings.EqualFold(safeString(state.State), "InService") {
+			healthy++
+		}
+	}
+	if health != nil {
+		properties[resource.PropHealthyTargets] = healthy
+	}
 
 	arn := fmt.Sprintf("arn:aws:elasticloadbalancing:%s:%s:loadbalancer/%s", region, account, safeString(lb.LoadBalancerName))
 
@@ -116,13 +464,24 @@ func (p *Provider) convertClassicLoadBalancerToResource(lb *elbTypes.LoadBalance
 		RawData:    lb,
 	}
 
-	// Add VPC relationship
-	if lb.VPCId != nil {
-		res.Relationships = append(res.Relationships, resource.Relationship{
-			Type:       resource.RelationBelongsTo,
-			TargetID:   *lb.VPCId,
-			TargetType: resource.TypeAWSVPC,
-		})
+	res.Relationships = append(res.Relationships, networkRelationships(safeString(lb.VPCId), lb.Subnets)...)
+	res.Relationships = append(res.Relationships, securityGroupRelationships(lb.SecurityGroups)...)
+
+	for _, instance := range lb.Instances {
+		rel := resource.Relationship{
+			Type:       resource.RelationRoutesTo,
+			TargetID:   safeString(instance.InstanceId),
+			TargetType: resource.TypeAWSEC2Instance,
+		}
+		if state, ok := states[rel.TargetID]; ok {
+			rel.Properties = map[string]interface{}{
+				resource.PropState: safeString(state.State),
+			}
+			if state.ReasonCode != nil && *state.ReasonCode != "N/A" {
+				rel.Properties["reason"] = *state.ReasonCode
+			}
+		}
+		res.Relationships = append(res.Relationships, rel)
 	}
 
 	return res
@@ -154,16 +513,26 @@ func (p *Provider) convertLoadBalancerV2ToResource(lb *elbv2Types.LoadBalancer,
 	if lb.VpcId != nil {
 		properties["vpc_id"] = *lb.VpcId
 	}
+	var subnetIDs []string
 	if len(lb.AvailabilityZones) > 0 {
 		azs := make([]string, len(lb.AvailabilityZones))
 		for i, az := range lb.AvailabilityZones {
 			azs[i] = safeString(az.ZoneName)
+			if az.SubnetId != nil {
+				subnetIDs = append(subnetIDs, *az.SubnetId)
+			}
 		}
 		properties["availability_zones"] = azs
 	}
+	if len(subnetIDs) > 0 {
+		properties["subnets"] = subnetIDs
+	}
 	if lb.IpAddressType != "" {
 		properties["ip_address_type"] = string(lb.IpAddressType)
 	}
+	if len(lb.SecurityGroups) > 0 {
+		properties[resource.PropSecurityGroups] = lb.SecurityGroups
+	}
 
 	res := &resource.Resource{
 		ID:         safeString(lb.LoadBalancerArn),
@@ -177,14 +546,8 @@ func (p *Provider) convertLoadBalancerV2ToResource(lb *elbv2Types.LoadBalancer,
 		RawData:    lb,
 	}
 
-	// Add VPC relationship
-	if lb.VpcId != nil {
-		res.Relationships = append(res.Relationships, resource.Relationship{
-			Type:       resource.RelationBelongsTo,
-			TargetID:   *lb.VpcId,
-			TargetType: resource.TypeAWSVPC,
-		})
-	}
+	res.Relationships = append(res.Relationships, networkRelationships(safeString(lb.VpcId), subnetIDs)...)
+	res.Relationships = append(res.Relationships, securityGroupRelationships(lb.SecurityGroups)...)
 
 	if lb.CreatedTime != nil {
 		res.CreatedAt = lb.CreatedTime
@@ -192,3 +555,107 @@ func (p *Provider) convertLoadBalancerV2ToResource(lb *elbv2Types.LoadBalancer,
 
 	return res
 }
+
+// convertTargetGroupToResource converts a target group to a Resource. The group routes to the
+// instances, Lambda functions and ALBs registered in it, with their health as properties; IP
+// targets are resolved to the network interfaces holding them by discoverIPTargets.
+func (p *Provider) convertTargetGroupToResource(entry *targetGroupTargets, region string) *resource.Resource {
+	var account string
+	if len(p.accounts) > 0 {
+		account = p.accounts[0]
+	}
+
+	group := entry.group
+	properties := map[string]interface{}{
+		"protocol":           string(group.Protocol),
+		"port":               safeInt32(group.Port),
+		"target_type":        string(group.TargetType),
+		"load_balancer_arns": group.LoadBalancerArns,
+	}
+
+	if group.VpcId != nil {
+		properties["vpc_id"] = *group.VpcId
+	}
+	if group.ProtocolVersion != nil {
+		properties["protocol_version"] = *group.ProtocolVersion
+	}
+	if safeBool(group.HealthCheckEnabled) {
+		healthCheck := map[string]interface{}{
+			"protocol": string(group.HealthCheckProtocol),
+			"port":     safeString(group.HealthCheckPort),
+		}
+		if group.HealthCheckPath != nil {
+			healthCheck["path"] = *group.HealthCheckPath
+		}
+		if group.Matcher != nil && group.Matcher.HttpCode != nil {
+			healthCheck["matcher"] = *group.Matcher.HttpCode
+		}
+		properties["health_check"] = healthCheck
+	}
+
+	res := &resource.Resource{
+		ID:         safeString(group.TargetGroupArn),
+		Type:       resource.TypeAWSTargetGroup,
+		Name:       safeString(group.TargetGroupName),
+		Provider:   "aws",
+		Account:    account,
+		Region:     region,
+		ARN:        safeString(group.TargetGroupArn),
+		Properties: properties,
+		RawData:    group,
+	}
+
+	res.Relationships = append(res.Relationships, networkRelationships(safeString(group.VpcId), nil)...)
+
+	if !entry.known {
+		return res
+	}
+
+	targets := make([]map[string]interface{}, 0, len(entry.targets))
+	for _, description := range entry.targets {
+		if description.Target == nil {
+			continue
+		}
+		target := map[string]interface{}{
+			"id":   safeString(description.Target.Id),
+			"port": safeInt32(description.Target.Port),
+		}
+		if description.Target.AvailabilityZone != nil {
+			target["availability_zone"] = *description.Target.AvailabilityZone
+		}
+		if health := description.TargetHealth; health != nil {
+			target[resource.PropState] = string(health.State)
+			if health.Reason != "" {
+				target["reason"] = string(health.Reason)
+			}
+		}
+		targets = append(targets, target)
+
+		var targetType resource.ResourceType
+		switch group.TargetType {
+		case elbv2Types.TargetTypeEnumInstance:
+			targetType = resource.TypeAWSEC2Instance
+		case elbv2Types.TargetTypeEnumLambda:
+			targetType = resource.TypeAWSLambda
+		case elbv2Types.TargetTypeEnumAlb:
+			targetType = resource.TypeAWSALB
+		default:
+			continue
+		}
+		res.Relationships = append(res.Relationships, resource.Relationship{
+			Type:       resource.RelationRoutesTo,
+			TargetID:   safeString(description.Target.Id),
+			TargetType: targetType,
+			Properties: map[string]interface{}{
+				"port":             target["port"],
+				resource.PropState: target[resource.PropState],
+			},
+		})
+	}
+
+	properties["targets"] = targets
+	properties[resource.PropTargetCount] = len(entry.targets)
+	properties[resource.PropHealthyTargets] = countHealthyTargets(entry.targets)
+
+	return res
+}
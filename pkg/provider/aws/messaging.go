@@ -7,6 +7,7 @@ import (
 
 	"github.com/aws/aws-sdk-go-v2/aws"
 	"github.com/aws/aws-sdk-go-v2/service/dynamodb"
+	dynamodbTypes "github.com/aws/aws-sdk-go-v2/service/dynamodb/types"
 	"github.com/aws/aws-sdk-go-v2/service/sns"
 	"github.com/aws/aws-sdk-go-v2/service/sqs"
 	sqstypes "github.com/aws/aws-sdk-go-v2/service/sqs/types"
@@ -48,6 +49,9 @@ func (p *Provider) collectSNSTopics(ctx context.Context, collection *resource.Co
 			for key, value := range attrs.Attributes {
 				properties[key] = value
 			}
+			if keyID := attrs.Attributes["KmsMasterKeyId"]; keyID != "" {
+				properties[resource.PropKMSKeyID] = keyID
+			}
 
 			res := &resource.Resource{
 				ID:         *topic.TopicArn,
@@ -60,6 +64,15 @@ func (p *Provider) collectSNSTopics(ctx context.Context, collection *resource.Co
 				RawData:    topic,
 			}
 
+			// Subscriptions relate the topic to the functions and queues it triggers; they are optional
+			subscriptions, err := listTopicSubscriptions(ctx, client, *topic.TopicArn)
+			if err != nil {
+				fmt.Fprintf(os.Stderr, "    Warning: failed to list subscriptions for topic %s: %v\n", *topic.TopicArn, err)
+			} else if len(subscriptions) > 0 {
+				res.Properties["subscriptions"] = subscriptions
+				res.Relationships = subscriptionRelationships(subscriptions)
+			}
+
 			collection.Add(res)
 			topicCount++
 		}
@@ -69,6 +82,54 @@ func (p *Provider) collectSNSTopics(ctx context.Context, collection *resource.Co
 	return nil
 }
 
+// listTopicSubscriptions returns the protocol and endpoint of the subscriptions of an SNS topic
+func listTopicSubscriptions(ctx context.Context, client *sns.Client, topicARN string) ([]map[string]interface{}, error) {
+	var subscriptions []map[string]interface{}
+
+	paginator := sns.NewListSubscriptionsByTopicPaginator(client, &sns.ListSubscriptionsByTopicInput{
+		TopicArn: aws.String(topicARN),
+	})
+	for paginator.HasMorePages() {
+		output, err := paginator.NextPage(ctx)
+		if err != nil {
+			return subscriptions, err
+		}
+
+		for _, subscription := range output.Subscriptions {
+			subscriptions = append(subscriptions, map[string]interface{}{
+				"subscription_arn": aws.ToString(subscription.SubscriptionArn),
+				"protocol":         aws.ToString(subscription.Protocol),
+				"endpoint":         aws.ToString(subscription.Endpoint),
+			})
+		}
+	}
+
+	return subscriptions, nil
+}
+
+// subscriptionRelationships returns the triggers relationships of an SNS topic to the Lambda
+// functions, queues and other collected resources subscribed to it. Email, HTTP and SMS
+// subscriptions are only kept in the topic properties.
+func subscriptionRelationships(subscriptions []map[string]interface{}) []resource.Relationship {
+	var relationships []resource.Relationship
+	for _, subscription := range subscriptions {
+		endpoint, _ := subscription["endpoint"].(string)
+		targetType := arnResourceType(endpoint)
+		if targetType == "" {
+			continue
+		}
+		relationships = append(relationships, resource.Relationship{
+			Type:       resource.RelationTriggers,
+			TargetID:   unqualifiedARN(endpoint),
+			TargetType: targetType,
+			Properties: map[string]interface{}{
+				"protocol": subscription["protocol"],
+			},
+		})
+	}
+	return relationships
+}
+
 // collectSQSQueues collects SQS queues from a region
 func (p *Provider) collectSQSQueues(ctx context.Context, collection *resource.Collection, region string, cfg aws.Config) error {
 	fmt.Fprintf(os.Stderr, "  Collecting SQS queues in %s...\n", region)
@@ -97,6 +158,9 @@ func (p *Provider) collectSQSQueues(ctx context.Context, collection *resource.Co
 		for key, value := range attrs.Attributes {
 			properties[key] = value
 		}
+		if keyID := attrs.Attributes["KmsMasterKeyId"]; keyID != "" {
+			properties[resource.PropKMSKeyID] = keyID
+		}
 
 		// Extract queue ARN
 		queueARN := attrs.Attributes["QueueArn"]
@@ -163,6 +227,23 @@ func (p *Provider) collectDynamoDBTables(ctx context.Context, collection *resour
 				"LocalSecondaryIndexes":  table.LocalSecondaryIndexes,
 			}
 
+			// Normalized billing properties used for cost estimates
+			properties[resource.PropBillingMode] = string(dynamodbTypes.BillingModeProvisioned)
+			if table.BillingModeSummary != nil && table.BillingModeSummary.BillingMode != "" {
+				properties[resource.PropBillingMode] = string(table.BillingModeSummary.BillingMode)
+			}
+			if table.ProvisionedThroughput != nil {
+				properties[resource.PropReadCapacityUnits] = aws.ToInt64(table.ProvisionedThroughput.ReadCapacityUnits)
+				properties[resource.PropWriteCapacityUnits] = aws.ToInt64(table.ProvisionedThroughput.WriteCapacityUnits)
+			}
+			if table.TableSizeBytes != nil {
+				properties[resource.PropTableSizeBytes] = *table.TableSizeBytes
+			}
+
+			if table.SSEDescription != nil && table.SSEDescription.KMSMasterKeyArn != nil {
+				properties[resource.PropKMSKeyID] = *table.SSEDescription.KMSMasterKeyArn
+			}
+
 			if table.StreamSpecification != nil {
 				properties["StreamEnabled"] = table.StreamSpecification.StreamEnabled
 				properties["StreamViewType"] = table.StreamSpecification.StreamViewType
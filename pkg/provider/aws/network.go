@@ -0,0 +1,1015 @@
+package aws
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"slices"
+	"strings"
+
+	"github.com/aws/aws-sdk-go-v2/aws"
+	"github.com/aws/aws-sdk-go-v2/service/ec2"
+	ec2Types "github.com/aws/aws-sdk-go-v2/service/ec2/types"
+
+	"github.com/comfortablynumb/pmp-cloud-inspector/pkg/iampolicy"
+	"github.com/comfortablynumb/pmp-cloud-inspector/pkg/resource"
+)
+
+// collectRouteTables collects route tables with their routes and associations
+func (p *Provider) collectRouteTables(ctx context.Context, collection *resource.Collection, region string, cfg aws.Config) error {
+	fmt.Fprintf(os.Stderr, "  Collecting route tables in %s...\n", region)
+	client := ec2.NewFromConfig(cfg)
+
+	paginator := ec2.NewDescribeRouteTablesPaginator(client, &ec2.DescribeRouteTablesInput{})
+
+	count := 0
+	for paginator.HasMorePages() {
+		output, err := paginator.NextPage(ctx)
+		if err != nil {
+			return fmt.Errorf("failed to describe route tables: %w", err)
+		}
+
+		for _, table := range output.RouteTables {
+			res := p.convertRouteTableToResource(&table, region)
+			collection.Add(res)
+			count++
+			fmt.Fprintf(os.Stderr, "    Found route table: %s (%d routes)\n", safeString(table.RouteTableId), len(table.Routes))
+		}
+	}
+
+	fmt.Fprintf(os.Stderr, "  Collected %d route tables in %s\n", count, region)
+	return nil
+}
+
+// collectInternetGateways collects internet gateways
+func (p *Provider) collectInternetGateways(ctx context.Context, collection *resource.Collection, region string, cfg aws.Config) error {
+	fmt.Fprintf(os.Stderr, "  Collecting internet gateways in %s...\n", region)
+	client := ec2.NewFromConfig(cfg)
+
+	paginator := ec2.NewDescribeInternetGatewaysPaginator(client, &ec2.DescribeInternetGatewaysInput{})
+
+	count := 0
+	for paginator.HasMorePages() {
+		output, err := paginator.NextPage(ctx)
+		if err != nil {
+			return fmt.Errorf("failed to describe internet gateways: %w", err)
+		}
+
+		for _, gateway := range output.InternetGateways {
+			res := p.convertInternetGatewayToResource(&gateway, region)
+			collection.Add(res)
+			count++
+			fmt.Fprintf(os.Stderr, "    Found internet gateway: %s\n", safeString(gateway.InternetGatewayId))
+		}
+	}
+
+	fmt.Fprintf(os.Stderr, "  Collected %d internet gateways in %s\n", count, region)
+	return nil
+}
+
+// collectNATGateways collects NAT gateways. Deleted gateways, which remain visible for about an
+// hour, are skipped.
+func (p *Provider) collectNATGateways(ctx context.Context, collection *resource.Collection, region string, cfg aws.Config) error {
+	fmt.Fprintf(os.Stderr, "  Collecting NAT gateways in %s...\n", region)
+	client := ec2.NewFromConfig(cfg)
+
+	paginator := ec2.NewDescribeNatGatewaysPaginator(client, &ec2.DescribeNatGatewaysInput{})
+
+	count := 0
+	for paginator.HasMorePages() {
+		output, err := paginator.NextPage(ctx)
+		if err != nil {
+			return fmt.Errorf("failed to describe NAT gateways: %w", err)
+		}
+
+		for _, gateway := range output.NatGateways {
+			if gateway.State == ec2Types.NatGatewayStateDeleted {
+				continue
+			}
+			res := p.convertNATGatewayToResource(&gateway, region)
+			collection.Add(res)
+			count++
+			fmt.Fprintf(os.Stderr, "    Found NAT gateway: %s (%s)\n", safeString(gateway.NatGatewayId), gateway.State)
+		}
+	}
+
+	fmt.Fprintf(os.Stderr, "  Collected %d NAT gateways in %s\n", count, region)
+	return nil
+}
+
+// collectTransitGateways collects transit gateways, owned or shared with the account
+func (p *Provider) collectTransitGateways(ctx context.Context, collection *resource.Collection, region string, cfg aws.Config) error {
+	fmt.Fprintf(os.Stderr, "  Collecting transit gateways in %s...\n", region)
+	client := ec2.NewFromConfig(cfg)
+
+	paginator := ec2.NewDescribeTransitGatewaysPaginator(client, &ec2.DescribeTransitGatewaysInput{})
+
+	count := 0
+	for paginator.HasMorePages() {
+		output, err := paginator.NextPage(ctx)
+		if err != nil {
+			return fmt.Errorf("failed to describe transit gateways: %w", err)
+		}
+
+		for _, gateway := range output.TransitGateways {
+			res := p.convertTransitGatewayToResource(&gateway, region)
+			collection.Add(res)
+			count++
+			fmt.Fprintf(os.Stderr, "    Found transit gateway: %s (%s)\n", safeString(gateway.TransitGatewayId), gateway.State)
+		}
+	}
+
+	fmt.Fprintf(os.Stderr, "  Collected %d transit gateways in %s\n", count, region)
+	return nil
+}
+
+// collectTransitGatewayAttachments collects the attachments of VPCs, VPNs and peerings to
+// transit gateways
+func (p *Provider) collectTransitGatewayAttachments(ctx context.Context, collection *resource.Collection, region string, cfg aws.Config) error {
+	fmt.Fprintf(os.Stderr, "  Collecting transit gateway attachments in %s...\n", region)
+	client := ec2.NewFromConfig(cfg)
+
+	paginator := ec2.NewDescribeTransitGatewayAttachmentsPaginator(client, &ec2.DescribeTransitGatewayAttachmentsInput{})
+
+	count := 0
+	for paginator.HasMorePages() {
+		output, err := paginator.NextPage(ctx)
+		if err != nil {
+			return fmt.Errorf("failed to describe transit gateway attachments: %w", err)
+		}
+
+		for _, attachment := range output.TransitGatewayAttachments {
+			res := p.convertTransitGatewayAttachmentToResource(&attachment, region)
+			collection.Add(res)
+			count++
+			fmt.Fprintf(os.Stderr, "    Found transit gateway attachment: %s (%s %s)\n",
+				safeString(attachment.TransitGatewayAttachmentId), attachment.ResourceType, safeString(attachment.ResourceId))
+		}
+	}
+
+	fmt.Fprintf(os.Stderr, "  Collected %d transit gateway attachments in %s\n", count, region)
+	return nil
+}
+
+// collectVPCPeeringConnections collects VPC peering connections requested or accepted by the account
+func (p *Provider) collectVPCPeeringConnections(ctx context.Context, collection *resource.Collection, region string, cfg aws.Config) error {
+	fmt.Fprintf(os.Stderr, "  Collecting VPC peering connections in %s...\n", region)
+	client := ec2.NewFromConfig(cfg)
+
+	paginator := ec2.NewDescribeVpcPeeringConnectionsPaginator(client, &ec2.DescribeVpcPeeringConnectionsInput{})
+
+	count := 0
+	for paginator.HasMorePages() {
+		output, err := paginator.NextPage(ctx)
+		if err != nil {
+			return fmt.Errorf("failed to describe VPC peering connections: %w", err)
+		}
+
+		for _, connection := range output.VpcPeeringConnections {
+			res := p.convertVPCPeeringConnectionToResource(&connection, region)
+			collection.Add(res)
+			count++
+			fmt.Fprintf(os.Stderr, "    Found VPC peering connection: %s\n", safeString(connection.VpcPeeringConnectionId))
+		}
+	}
+
+	fmt.Fprintf(os.Stderr, "  Collected %d VPC peering connections in %s\n", count, region)
+	return nil
+}
+
+// collectVPCEndpoints collects gateway, interface and Gateway Load Balancer VPC endpoints
+func (p *Provider) collectVPCEndpoints(ctx context.Context, collection *resource.Collection, region string, cfg aws.Config) error {
+	fmt.Fprintf(os.Stderr, "  Collecting VPC endpoints in %s...\n", region)
+	client := ec2.NewFromConfig(cfg)
+
+	paginator := ec2.NewDescribeVpcEndpointsPaginator(client, &ec2.DescribeVpcEndpointsInput{})
+
+	count := 0
+	for paginator.HasMorePages() {
+		output, err := paginator.NextPage(ctx)
+		if err != nil {
+			return fmt.Errorf("failed to describe VPC endpoints: %w", err)
+		}
+
+		for _, endpoint := range output.VpcEndpoints {
+			res := p.convertVPCEndpointToResource(&endpoint, region)
+			collection.Add(res)
+			count++
+			fmt.Fprintf(os.Stderr, "    Found VPC endpoint: %s (%s)\n", safeString(endpoint.VpcEndpointId), safeString(endpoint.ServiceName))
+		}
+	}
+
+	fmt.Fprintf(os.Stderr, "  Collected %d VPC endpoints in %s\n", count, region)
+	return nil
+}
+
+// collectElasticIPs collects Elastic IP addresses
+func (p *Provider) collectElasticIPs(ctx context.Context, collection *resource.Collection, region string, cfg aws.Config) error {
+	fmt.Fprintf(os.Stderr, "  Collecting Elastic IPs in %s...\n", region)
+	client := ec2.NewFromConfig(cfg)
+
+	// DescribeAddresses is not paginated
+	output, err := client.DescribeAddresses(ctx, &ec2.DescribeAddressesInput{})
+	if err != nil {
+		return fmt.Errorf("failed to describe addresses: %w", err)
+	}
+
+	count := 0
+	for _, address := range output.Addresses {
+		res := p.convertElasticIPToResource(&address, region)
+		collection.Add(res)
+		count++
+		fmt.Fprintf(os.Stderr, "    Found Elastic IP: %s\n", safeString(address.PublicIp))
+	}
+
+	fmt.Fprintf(os.Stderr, "  Collected %d Elastic IPs in %s\n", count, region)
+	return nil
+}
+
+// collectNetworkInterfaces collects network interfaces, including the ones managed by AWS
+// services (load balancers, NAT gateways, Lambda functions, VPC endpoints, ...)
+func (p *Provider) collectNetworkInterfaces(ctx context.Context, collection *resource.Collection, region string, cfg aws.Config) error {
+	fmt.Fprintf(os.Stderr, "  Collecting network interfaces in %s...\n", region)
+	client := ec2.NewFromConfig(cfg)
+
+	paginator := ec2.NewDescribeNetworkInterfacesPaginator(client, &ec2.DescribeNetworkInterfacesInput{})
+
+	count := 0
+	for paginator.HasMorePages() {
+		output, err := paginator.NextPage(ctx)
+		if err != nil {
+			return fmt.Errorf("failed to describe network interfaces: %w", err)
+		}
+
+		for _, iface := range output.NetworkInterfaces {
+			res := p.convertNetworkInterfaceToResource(&iface, region)
+			collection.Add(res)
+			count++
+			fmt.Fprintf(os.Stderr, "    Found network interface: %s (%s)\n", safeString(iface.NetworkInterfaceId), safeString(iface.PrivateIpAddress))
+		}
+	}
+
+	fmt.Fprintf(os.Stderr, "  Collected %d network interfaces in %s\n", count, region)
+	return nil
+}
+
+// routeTarget returns the ID and kind (e.g., internet_gateway) of the target of a route, and its
+// resource type when it is a collected resource. The resource type is empty for targets that are
+// not collected, such as VPN gateways or the local route of the VPC.
+func routeTarget(route ec2Types.Route) (string, string, resource.ResourceType) {
+	switch {
+	case route.NatGatewayId != nil:
+		return *route.NatGatewayId, "nat_gateway", resource.TypeAWSNATGateway
+	case route.TransitGatewayId != nil:
+		return *route.TransitGatewayId, "transit_gateway", resource.TypeAWSTransitGateway
+	case route.VpcPeeringConnectionId != nil:
+		return *route.VpcPeeringConnectionId, "vpc_peering_connection", resource.TypeAWSVPCPeeringConnection
+	case route.EgressOnlyInternetGatewayId != nil:
+		return *route.EgressOnlyInternetGatewayId, "egress_only_internet_gateway", ""
+	case route.InstanceId != nil:
+		// Routes to an instance also report its network interface
+		return *route.InstanceId, "instance", resource.TypeAWSEC2Instance
+	case route.NetworkInterfaceId != nil:
+		return *route.NetworkInterfaceId, "network_interface", resource.TypeAWSNetworkInterface
+	case route.CarrierGatewayId != nil:
+		return *route.CarrierGatewayId, "carrier_gateway", ""
+	case route.LocalGatewayId != nil:
+		return *route.LocalGatewayId, "local_gateway", ""
+	case route.CoreNetworkArn != nil:
+		return *route.CoreNetworkArn, "core_network", ""
+	case route.GatewayId != nil:
+		id := *route.GatewayId
+		switch {
+		case id == "local":
+			return id, "local", ""
+		case strings.HasPrefix(id, "igw-"):
+			return id, "internet_gateway", resource.TypeAWSInternetGateway
+		case strings.HasPrefix(id, "vpce-"):
+			return id, "vpc_endpoint", resource.TypeAWSVPCEndpoint
+		case strings.HasPrefix(id, "vgw-"):
+			return id, "vpn_gateway", ""
+		}
+		return id, "gateway", ""
+	}
+	return "", "", ""
+}
+
+// routeDestination returns the IPv4 CIDR, IPv6 CIDR or prefix list a route applies to
+func routeDestination(route ec2Types.Route) string {
+	switch {
+	case route.DestinationCidrBlock != nil:
+		return *route.DestinationCidrBlock
+	case route.DestinationIpv6CidrBlock != nil:
+		return *route.DestinationIpv6CidrBlock
+	}
+	return safeString(route.DestinationPrefixListId)
+}
+
+// convertRouteTableToResource converts a route table to a Resource. A route table with an active
+// route to an internet gateway is marked as public; the subnets using it are marked when
+// relationships are discovered (see discoverSubnetRouting).
+func (p *Provider) convertRouteTableToResource(table *ec2Types.RouteTable, region string) *resource.Resource {
+	var account string
+	if len(p.accounts) > 0 {
+		account = p.accounts[0]
+	}
+
+	vpcID := safeString(table.VpcId)
+	properties := map[string]interface{}{
+		"vpc_id":                    vpcID,
+		"owner_id":                  safeString(table.OwnerId),
+		resource.PropMainRouteTable: false,
+		resource.PropPublic:         false,
+	}
+
+	// Routes, with one routes_to relationship per collected target listing its destinations
+	routes := make([]map[string]interface{}, 0, len(table.Routes))
+	var targets []resource.Relationship
+	targetIndex := make(map[string]int)
+	for _, route := range table.Routes {
+		targetID, kind, targetType := routeTarget(route)
+		destination := routeDestination(route)
+		routes = append(routes, map[string]interface{}{
+			"destination":      destination,
+			"target_id":        targetID,
+			"target_type":      kind,
+			resource.PropState: string(route.State),
+			"origin":           string(route.Origin),
+		})
+
+		if kind == "internet_gateway" && route.State == ec2Types.RouteStateActive {
+			properties[resource.PropPublic] = true
+		}
+
+		if targetType == "" {
+			continue
+		}
+		if i, ok := targetIndex[targetID]; ok {
+			rel := targets[i]
+			rel.Properties["destinations"] = append(rel.Properties["destinations"].([]string), destination)
+			if route.State != ec2Types.RouteStateActive {
+				rel.Properties[resource.PropState] = string(route.State)
+			}
+			continue
+		}
+		targetIndex[targetID] = len(targets)
+		targets = append(targets, resource.Relationship{
+			Type:       resource.RelationRoutesTo,
+			TargetID:   targetID,
+			TargetType: targetType,
+			Properties: map[string]interface{}{
+				"destinations":     []string{destination},
+				resource.PropState: string(route.State),
+			},
+		})
+	}
+	properties["routes"] = routes
+
+	var subnetIDs, gatewayIDs []string
+	for _, association := range table.Associations {
+		if association.AssociationState != nil && association.AssociationState.State != ec2Types.RouteTableAssociationStateCodeAssociated {
+			continue
+		}
+		if safeBool(association.Main) {
+			properties[resource.PropMainRouteTable] = true
+		}
+		if association.SubnetId != nil {
+			subnetIDs = append(subnetIDs, *association.SubnetId)
+		}
+		if association.GatewayId != nil {
+			gatewayIDs = append(gatewayIDs, *association.GatewayId)
+		}
+	}
+	if len(subnetIDs) > 0 {
+		properties["subnet_ids"] = subnetIDs
+	}
+	if len(gatewayIDs) > 0 {
+		// Edge associations route the traffic entering the VPC through the gateway
+		properties["gateway_ids"] = gatewayIDs
+	}
+
+	tags, name := ec2Tags(table.Tags)
+	if name == "" {
+		name = safeString(table.RouteTableId)
+	}
+
+	arn := fmt.Sprintf("arn:aws:ec2:%s:%s:route-table/%s", region, account, safeString(table.RouteTableId))
+
+	res := &resource.Resource{
+		ID:         safeString(table.RouteTableId),
+		Type:       resource.TypeAWSRouteTable,
+		Name:       name,
+		Provider:   "aws",
+		Account:    account,
+		Region:     region,
+		ARN:        arn,
+		Tags:       tags,
+		Properties: properties,
+		RawData:    table,
+	}
+
+	res.Relationships = networkRelationships(vpcID, nil)
+	for _, subnetID := range subnetIDs {
+		res.Relationships = append(res.Relationships, resource.Relationship{
+			Type:       resource.RelationAttachedTo,
+			TargetID:   subnetID,
+			TargetType: resource.TypeAWSSubnet,
+		})
+	}
+	res.Relationships = append(res.Relationships, targets...)
+
+	return res
+}
+
+// convertInternetGatewayToResource converts an internet gateway to a Resource
+func (p *Provider) convertInternetGatewayToResource(gateway *ec2Types.InternetGateway, region string) *resource.Resource {
+	var account string
+	if len(p.accounts) > 0 {
+		account = p.accounts[0]
+	}
+
+	properties := map[string]interface{}{
+		"owner_id": safeString(gateway.OwnerId),
+	}
+
+	attachments := make([]map[string]interface{}, 0, len(gateway.Attachments))
+	for _, attachment := range gateway.Attachments {
+		attachments = append(attachments, map[string]interface{}{
+			"vpc_id":           safeString(attachment.VpcId),
+			resource.PropState: string(attachment.State),
+		})
+	}
+	properties["attachments"] = attachments
+
+	tags, name := ec2Tags(gateway.Tags)
+	if name == "" {
+		name = safeString(gateway.InternetGatewayId)
+	}
+
+	arn := fmt.Sprintf("arn:aws:ec2:%s:%s:internet-gateway/%s", region, account, safeString(gateway.InternetGatewayId))
+
+	res := &resource.Resource{
+		ID:         safeString(gateway.InternetGatewayId),
+		Type:       resource.TypeAWSInternetGateway,
+		Name:       name,
+		Provider:   "aws",
+		Account:    account,
+		Region:     region,
+		ARN:        arn,
+		Tags:       tags,
+		Properties: properties,
+		RawData:    gateway,
+	}
+
+	// Add relationships to the VPCs the gateway is attached to
+	for _, attachment := range gateway.Attachments {
+		if attachment.VpcId == nil {
+			continue
+		}
+		res.Relationships = append(res.Relationships, resource.Relationship{
+			Type:       resource.RelationAttachedTo,
+			TargetID:   *attachment.VpcId,
+			TargetType: resource.TypeAWSVPC,
+		})
+	}
+
+	return res
+}
+
+// convertNATGatewayToResource converts a NAT gateway to a Resource
+func (p *Provider) convertNATGatewayToResource(gateway *ec2Types.NatGateway, region string) *resource.Resource {
+	var account string
+	if len(p.accounts) > 0 {
+		account = p.accounts[0]
+	}
+
+	vpcID := safeString(gateway.VpcId)
+	subnetID := safeString(gateway.SubnetId)
+	properties := map[string]interface{}{
+		resource.PropState:  string(gateway.State),
+		"connectivity_type": string(gateway.ConnectivityType),
+		"vpc_id":            vpcID,
+		"subnet_id":         subnetID,
+	}
+
+	addresses := make([]map[string]interface{}, 0, len(gateway.NatGatewayAddresses))
+	var interfaceIDs []string
+	for _, address := range gateway.NatGatewayAddresses {
+		addresses = append(addresses, map[string]interface{}{
+			"allocation_id":        safeString(address.AllocationId),
+			"public_ip":            safeString(address.PublicIp),
+			"private_ip":           safeString(address.PrivateIp),
+			"network_interface_id": safeString(address.NetworkInterfaceId),
+			"primary":              safeBool(address.IsPrimary),
+			resource.PropStatus:    string(address.Status),
+		})
+		if address.NetworkInterfaceId != nil && !slices.Contains(interfaceIDs, *address.NetworkInterfaceId) {
+			interfaceIDs = append(interfaceIDs, *address.NetworkInterfaceId)
+		}
+		if safeBool(address.IsPrimary) {
+			if address.PublicIp != nil {
+				properties["public_ip"] = *address.PublicIp
+			}
+			if address.PrivateIp != nil {
+				properties["private_ip"] = *address.PrivateIp
+			}
+		}
+	}
+	if len(addresses) > 0 {
+		properties["addresses"] = addresses
+	}
+	if len(interfaceIDs) > 0 {
+		properties["network_interface_ids"] = interfaceIDs
+	}
+
+	tags, name := ec2Tags(gateway.Tags)
+	if name == "" {
+		name = safeString(gateway.NatGatewayId)
+	}
+
+	arn := fmt.Sprintf("arn:aws:ec2:%s:%s:natgateway/%s", region, account, safeString(gateway.NatGatewayId))
+
+	res := &resource.Resource{
+		ID:         safeString(gateway.NatGatewayId),
+		Type:       resource.TypeAWSNATGateway,
+		Name:       name,
+		Provider:   "aws",
+		Account:    account,
+		Region:     region,
+		ARN:        arn,
+		Tags:       tags,
+		Properties: properties,
+		RawData:    gateway,
+		CreatedAt:  gateway.CreateTime,
+	}
+
+	res.Relationships = networkRelationships(vpcID, []string{subnetID})
+	res.Relationships = append(res.Relationships, interfaceRelationships(interfaceIDs)...)
+
+	return res
+}
+
+// convertTransitGatewayToResource converts a transit gateway to a Resource
+func (p *Provider) convertTransitGatewayToResource(gateway *ec2Types.TransitGateway, region string) *resource.Resource {
+	var account string
+	if len(p.accounts) > 0 {
+		account = p.accounts[0]
+	}
+
+	properties := map[string]interface{}{
+		resource.PropState: string(gateway.State),
+		"owner_id":         safeString(gateway.OwnerId),
+		"description":      safeString(gateway.Description),
+	}
+
+	if options := gateway.Options; options != nil {
+		if options.AmazonSideAsn != nil {
+			properties["amazon_side_asn"] = *options.AmazonSideAsn
+		}
+		properties["auto_accept_shared_attachments"] = string(options.AutoAcceptSharedAttachments)
+		properties["default_route_table_association"] = string(options.DefaultRouteTableAssociation)
+		properties["default_route_table_propagation"] = string(options.DefaultRouteTablePropagation)
+		properties["dns_support"] = string(options.DnsSupport)
+		if options.AssociationDefaultRouteTableId != nil {
+			properties["association_default_route_table_id"] = *options.AssociationDefaultRouteTableId
+		}
+		if len(options.TransitGatewayCidrBlocks) > 0 {
+			properties["cidr_blocks"] = options.TransitGatewayCidrBlocks
+		}
+	}
+
+	tags, name := ec2Tags(gateway.Tags)
+	if name == "" {
+		name = safeString(gateway.TransitGatewayId)
+	}
+
+	return &resource.Resource{
+		ID:         safeString(gateway.TransitGatewayId),
+		Type:       resource.TypeAWSTransitGateway,
+		Name:       name,
+		Provider:   "aws",
+		Account:    account,
+		Region:     region,
+		ARN:        safeString(gateway.TransitGatewayArn),
+		Tags:       tags,
+		Properties: properties,
+		RawData:    gateway,
+		CreatedAt:  gateway.CreationTime,
+	}
+}
+
+// convertTransitGatewayAttachmentToResource converts a transit gateway attachment to a Resource
+func (p *Provider) convertTransitGatewayAttachmentToResource(attachment *ec2Types.TransitGatewayAttachment, region string) *resource.Resource {
+	var account string
+	if len(p.accounts) > 0 {
+		account = p.accounts[0]
+	}
+
+	gatewayID := safeString(attachment.TransitGatewayId)
+	resourceID := safeString(attachment.ResourceId)
+	properties := map[string]interface{}{
+		resource.PropState:         string(attachment.State),
+		"transit_gateway_id":       gatewayID,
+		"transit_gateway_owner_id": safeString(attachment.TransitGatewayOwnerId),
+		"resource_type":            string(attachment.ResourceType),
+		"resource_id":              resourceID,
+		"resource_owner_id":        safeString(attachment.ResourceOwnerId),
+	}
+
+	if association := attachment.Association; association != nil {
+		properties["route_table_id"] = safeString(association.TransitGatewayRouteTableId)
+		properties["association_state"] = string(association.State)
+	}
+
+	tags, name := ec2Tags(attachment.Tags)
+	if name == "" {
+		name = safeString(attachment.TransitGatewayAttachmentId)
+	}
+
+	arn := fmt.Sprintf("arn:aws:ec2:%s:%s:transit-gateway-attachment/%s", region, account, safeString(attachment.TransitGatewayAttachmentId))
+
+	res := &resource.Resource{
+		ID:         safeString(attachment.TransitGatewayAttachmentId),
+		Type:       resource.TypeAWSTransitGatewayAttachment,
+		Name:       name,
+		Provider:   "aws",
+		Account:    account,
+		Region:     region,
+		ARN:        arn,
+		Tags:       tags,
+		Properties: properties,
+		RawData:    attachment,
+		CreatedAt:  attachment.CreationTime,
+	}
+
+	if gatewayID != "" {
+		res.Relationships = append(res.Relationships, resource.Relationship{
+			Type:       resource.RelationBelongsTo,
+			TargetID:   gatewayID,
+			TargetType: resource.TypeAWSTransitGateway,
+		})
+	}
+
+	// Only attached VPCs are collected; VPN, Direct Connect and peering attachments keep the
+	// attached resource in their properties
+	if attachment.ResourceType == ec2Types.TransitGatewayAttachmentResourceTypeVpc && resourceID != "" {
+		res.Relationships = append(res.Relationships, resource.Relationship{
+			Type:       resource.RelationAttachedTo,
+			TargetID:   resourceID,
+			TargetType: resource.TypeAWSVPC,
+		})
+	}
+
+	return res
+}
+
+// convertVPCPeeringConnectionToResource converts a VPC peering connection to a Resource
+func (p *Provider) convertVPCPeeringConnectionToResource(connection *ec2Types.VpcPeeringConnection, region string) *resource.Resource {
+	var account string
+	if len(p.accounts) > 0 {
+		account = p.accounts[0]
+	}
+
+	properties := map[string]interface{}{}
+	if connection.Status != nil {
+		properties[resource.PropStatus] = string(connection.Status.Code)
+		if connection.Status.Message != nil {
+			properties["status_message"] = *connection.Status.Message
+		}
+	}
+
+	sides := []struct {
+		name string
+		info *ec2Types.VpcPeeringConnectionVpcInfo
+	}{
+		{"requester", connection.RequesterVpcInfo},
+		{"accepter", connection.AccepterVpcInfo},
+	}
+
+	var relationships []resource.Relationship
+	for _, side := range sides {
+		if side.info == nil {
+			continue
+		}
+		properties[side.name+"_vpc_id"] = safeString(side.info.VpcId)
+		properties[side.name+"_owner_id"] = safeString(side.info.OwnerId)
+		properties[side.name+"_region"] = safeString(side.info.Region)
+		properties[side.name+"_cidr_block"] = safeString(side.info.CidrBlock)
+
+		if side.info.VpcId == nil {
+			continue
+		}
+		relationships = append(relationships, resource.Relationship{
+			Type:       resource.RelationAttachedTo,
+			TargetID:   *side.info.VpcId,
+			TargetType: resource.TypeAWSVPC,
+			Properties: map[string]interface{}{
+				"side":     side.name,
+				"owner_id": safeString(side.info.OwnerId),
+				"region":   safeString(side.info.Region),
+			},
+		})
+	}
+
+	tags, name := ec2Tags(connection.Tags)
+	if name == "" {
+		name = safeString(connection.VpcPeeringConnectionId)
+	}
+
+	arn := fmt.Sprintf("arn:aws:ec2:%s:%s:vpc-peering-connection/%s", region, account, safeString(connection.VpcPeeringConnectionId))
+
+	return &resource.Resource{
+		ID:            safeString(connection.VpcPeeringConnectionId),
+		Type:          resource.TypeAWSVPCPeeringConnection,
+		Name:          name,
+		Provider:      "aws",
+		Account:       account,
+		Region:        region,
+		ARN:           arn,
+		Tags:          tags,
+		Properties:    properties,
+		RawData:       connection,
+		Relationships: relationships,
+	}
+}
+
+// convertVPCEndpointToResource converts a VPC endpoint to a Resource
+func (p *Provider) convertVPCEndpointToResource(endpoint *ec2Types.VpcEndpoint, region string) *resource.Resource {
+	var account string
+	if len(p.accounts) > 0 {
+		account = p.accounts[0]
+	}
+
+	vpcID := safeString(endpoint.VpcId)
+	properties := map[string]interface{}{
+		resource.PropState:    string(endpoint.State),
+		"vpc_endpoint_type":   string(endpoint.VpcEndpointType),
+		"service_name":        safeString(endpoint.ServiceName),
+		"private_dns_enabled": safeBool(endpoint.PrivateDnsEnabled),
+		"requester_managed":   safeBool(endpoint.RequesterManaged),
+		"vpc_id":              vpcID,
+	}
+
+	if len(endpoint.SubnetIds) > 0 {
+		properties["subnet_ids"] = endpoint.SubnetIds
+	}
+	if len(endpoint.RouteTableIds) > 0 {
+		properties["route_table_ids"] = endpoint.RouteTableIds
+	}
+	if len(endpoint.NetworkInterfaceIds) > 0 {
+		properties["network_interface_ids"] = endpoint.NetworkInterfaceIds
+	}
+
+	groupIDs := make([]string, 0, len(endpoint.Groups))
+	for _, group := range endpoint.Groups {
+		groupIDs = append(groupIDs, safeString(group.GroupId))
+	}
+	if len(groupIDs) > 0 {
+		properties[resource.PropSecurityGroups] = groupIDs
+	}
+
+	if endpoint.PolicyDocument != nil {
+		if document, err := iampolicy.ToProperty(*endpoint.PolicyDocument); err == nil {
+			properties[resource.PropPolicyDocument] = document
+		} else {
+			fmt.Fprintf(os.Stderr, "    Warning: failed to parse the policy of VPC endpoint %s: %v\n", safeString(endpoint.VpcEndpointId), err)
+		}
+	}
+
+	tags, name := ec2Tags(endpoint.Tags)
+	if name == "" {
+		name = safeString(endpoint.VpcEndpointId)
+	}
+
+	arn := fmt.Sprintf("arn:aws:ec2:%s:%s:vpc-endpoint/%s", region, account, safeString(endpoint.VpcEndpointId))
+
+	res := &resource.Resource{
+		ID:         safeString(endpoint.VpcEndpointId),
+		Type:       resource.TypeAWSVPCEndpoint,
+		Name:       name,
+		Provider:   "aws",
+		Account:    account,
+		Region:     region,
+		ARN:        arn,
+		Tags:       tags,
+		Properties: properties,
+		RawData:    endpoint,
+		CreatedAt:  endpoint.CreationTimestamp,
+	}
+
+	// Gateway endpoints are reached through the routes_to relationships of their route tables
+	res.Relationships = networkRelationships(vpcID, endpoint.SubnetIds)
+	res.Relationships = append(res.Relationships, securityGroupRelationships(groupIDs)...)
+	res.Relationships = append(res.Relationships, interfaceRelationships(endpoint.NetworkInterfaceIds)...)
+
+	return res
+}
+
+// convertElasticIPToResource converts an Elastic IP address to a Resource
+func (p *Provider) convertElasticIPToResource(address *ec2Types.Address, region string) *resource.Resource {
+	var account string
+	if len(p.accounts) > 0 {
+		account = p.accounts[0]
+	}
+
+	publicIP := safeString(address.PublicIp)
+	properties := map[string]interface{}{
+		"public_ip":            publicIP,
+		"domain":               string(address.Domain),
+		"associated":           address.AssociationId != nil,
+		"network_border_group": safeString(address.NetworkBorderGroup),
+		"public_ipv4_pool":     safeString(address.PublicIpv4Pool),
+	}
+
+	if address.AssociationId != nil {
+		properties["association_id"] = *address.AssociationId
+	}
+	if address.PrivateIpAddress != nil {
+		properties["private_ip"] = *address.PrivateIpAddress
+	}
+	if address.NetworkInterfaceId != nil {
+		properties["network_interface_id"] = *address.NetworkInterfaceId
+	}
+	if address.InstanceId != nil && *address.InstanceId != "" {
+		properties["instance_id"] = *address.InstanceId
+	}
+	if address.ServiceManaged != "" {
+		properties["service_managed"] = string(address.ServiceManaged)
+	}
+
+	tags, name := ec2Tags(address.Tags)
+	if name == "" {
+		name = publicIP
+	}
+
+	// Addresses allocated for EC2-Classic have no allocation ID
+	id := safeString(address.AllocationId)
+	if id == "" {
+		id = publicIP
+	}
+
+	arn := fmt.Sprintf("arn:aws:ec2:%s:%s:elastic-ip/%s", region, account, id)
+
+	res := &resource.Resource{
+		ID:         id,
+		Type:       resource.TypeAWSElasticIP,
+		Name:       name,
+		Provider:   "aws",
+		Account:    account,
+		Region:     region,
+		ARN:        arn,
+		Tags:       tags,
+		Properties: properties,
+		RawData:    address,
+	}
+
+	// Add relationships to the network interface and instance holding the address
+	if address.NetworkInterfaceId != nil {
+		res.Relationships = append(res.Relationships, resource.Relationship{
+			Type:       resource.RelationAttachedTo,
+			TargetID:   *address.NetworkInterfaceId,
+			TargetType: resource.TypeAWSNetworkInterface,
+			Properties: map[string]interface{}{
+				"private_ip": safeString(address.PrivateIpAddress),
+			},
+		})
+	}
+	if address.InstanceId != nil && *address.InstanceId != "" {
+		res.Relationships = append(res.Relationships, resource.Relationship{
+			Type:       resource.RelationAttachedTo,
+			TargetID:   *address.InstanceId,
+			TargetType: resource.TypeAWSEC2Instance,
+		})
+	}
+
+	return res
+}
+
+// convertNetworkInterfaceToResource converts a network interface to a Resource, with all its
+// private and public IP addresses
+func (p *Provider) convertNetworkInterfaceToResource(iface *ec2Types.NetworkInterface, region string) *resource.Resource {
+	var account string
+	if len(p.accounts) > 0 {
+		account = p.accounts[0]
+	}
+
+	vpcID := safeString(iface.VpcId)
+	subnetID := safeString(iface.SubnetId)
+	properties := map[string]interface{}{
+		resource.PropStatus:  string(iface.Status),
+		"interface_type":     string(iface.InterfaceType),
+		"description":        safeString(iface.Description),
+		"private_ip":         safeString(iface.PrivateIpAddress),
+		"mac_address":        safeString(iface.MacAddress),
+		"availability_zone":  safeString(iface.AvailabilityZone),
+		"requester_managed":  safeBool(iface.RequesterManaged),
+		"source_dest_check":  safeBool(iface.SourceDestCheck),
+		"vpc_id":             vpcID,
+		"subnet_id":          subnetID,
+		"owner_id":           safeString(iface.OwnerId),
+		"private_dns_name":   safeString(iface.PrivateDnsName),
+		"ipv6_address_count": len(iface.Ipv6Addresses),
+	}
+
+	if iface.RequesterId != nil {
+		properties["requester_id"] = *iface.RequesterId
+	}
+
+	privateIPs := make([]string, 0, len(iface.PrivateIpAddresses))
+	var publicIPs []string
+	for _, address := range iface.PrivateIpAddresses {
+		privateIPs = append(privateIPs, safeString(address.PrivateIpAddress))
+		if address.Association != nil && address.Association.PublicIp != nil {
+			publicIPs = append(publicIPs, *address.Association.PublicIp)
+		}
+	}
+	if len(privateIPs) > 0 {
+		properties["private_ips"] = privateIPs
+	}
+	if len(publicIPs) > 0 {
+		properties["public_ips"] = publicIPs
+	}
+	if iface.Association != nil && iface.Association.PublicIp != nil {
+		properties["public_ip"] = *iface.Association.PublicIp
+		if iface.Association.AllocationId != nil {
+			properties["allocation_id"] = *iface.Association.AllocationId
+		}
+	}
+	if len(iface.Ipv6Addresses) > 0 {
+		ipv6Addresses := make([]string, 0, len(iface.Ipv6Addresses))
+		for _, address := range iface.Ipv6Addresses {
+			ipv6Addresses = append(ipv6Addresses, safeString(address.Ipv6Address))
+		}
+		properties["ipv6_addresses"] = ipv6Addresses
+	}
+
+	groupIDs := make([]string, 0, len(iface.Groups))
+	for _, group := range iface.Groups {
+		groupIDs = append(groupIDs, safeString(group.GroupId))
+	}
+	if len(groupIDs) > 0 {
+		properties[resource.PropSecurityGroups] = groupIDs
+	}
+
+	if attachment := iface.Attachment; attachment != nil {
+		if attachment.InstanceId != nil {
+			properties["instance_id"] = *attachment.InstanceId
+		}
+		if attachment.DeviceIndex != nil {
+			properties["device_index"] = *attachment.DeviceIndex
+		}
+		properties["attachment_status"] = string(attachment.Status)
+	}
+
+	tags, name := ec2Tags(iface.TagSet)
+	if name == "" {
+		name = safeString(iface.NetworkInterfaceId)
+	}
+
+	arn := fmt.Sprintf("arn:aws:ec2:%s:%s:network-interface/%s", region, account, safeString(iface.NetworkInterfaceId))
+
+	res := &resource.Resource{
+		ID:         safeString(iface.NetworkInterfaceId),
+		Type:       resource.TypeAWSNetworkInterface,
+		Name:       name,
+		Provider:   "aws",
+		Account:    account,
+		Region:     region,
+		ARN:        arn,
+		Tags:       tags,
+		Properties: properties,
+		RawData:    iface,
+	}
+
+	res.Relationships = networkRelationships(vpcID, []string{subnetID})
+	res.Relationships = append(res.Relationships, securityGroupRelationships(groupIDs)...)
+	if iface.Attachment != nil && iface.Attachment.InstanceId != nil {
+		res.Relationships = append(res.Relationships, resource.Relationship{
+			Type:       resource.RelationAttachedTo,
+			TargetID:   *iface.Attachment.InstanceId,
+			TargetType: resource.TypeAWSEC2Instance,
+			Properties: map[string]interface{}{
+				"device_index": safeInt32(iface.Attachment.DeviceIndex),
+			},
+		})
+	}
+
+	return res
+}
+
+// interfaceRelationships returns the contains relationships of a resource to the network
+// interfaces it manages (e.g., NAT gateways, interface VPC endpoints)
+func interfaceRelationships(interfaceIDs []string) []resource.Relationship {
+	relationships := make([]resource.Relationship, 0, len(interfaceIDs))
+	for _, interfaceID := range interfaceIDs {
+		if interfaceID == "" {
+			continue
+		}
+		relationships = append(relationships, resource.Relationship{
+			Type:       resource.RelationContains,
+			TargetID:   interfaceID,
+			TargetType: resource.TypeAWSNetworkInterface,
+		})
+	}
+	return relationships
+}
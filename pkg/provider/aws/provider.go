@@ -3,6 +3,7 @@ package aws
 import (
 	"context"
 	"fmt"
+	"os"
 	"sync"
 
 	"github.com/aws/aws-sdk-go-v2/aws"
@@ -11,8 +12,10 @@ import (
 	"github.com/aws/aws-sdk-go-v2/service/ecr"
 	"github.com/aws/aws-sdk-go-v2/service/iam"
 	"github.com/aws/aws-sdk-go-v2/service/sts"
+	wafv2Types "github.com/aws/aws-sdk-go-v2/service/wafv2/types"
 
 	"github.com/comfortablynumb/pmp-cloud-inspector/pkg/config"
+	"github.com/comfortablynumb/pmp-cloud-inspector/pkg/iampolicy"
 	"github.com/comfortablynumb/pmp-cloud-inspector/pkg/provider"
 	"github.com/comfortablynumb/pmp-cloud-inspector/pkg/ratelimit"
 	"github.com/comfortablynumb/pmp-cloud-inspector/pkg/resource"
@@ -100,17 +103,37 @@ func (p *Provider) GetSupportedResourceTypes() []resource.ResourceType {
 	return []resource.ResourceType{
 		resource.TypeAWSIAMUser,
 		resource.TypeAWSIAMRole,
+		resource.TypeAWSIAMGroup,
+		resource.TypeAWSIAMPolicy,
 		resource.TypeAWSAccount,
 		resource.TypeAWSVPC,
 		resource.TypeAWSSubnet,
 		resource.TypeAWSSecurityGroup,
+		resource.TypeAWSRouteTable,
+		resource.TypeAWSInternetGateway,
+		resource.TypeAWSNATGateway,
+		resource.TypeAWSTransitGateway,
+		resource.TypeAWSTransitGatewayAttachment,
+		resource.TypeAWSVPCPeeringConnection,
+		resource.TypeAWSVPCEndpoint,
+		resource.TypeAWSElasticIP,
+		resource.TypeAWSNetworkInterface,
 		resource.TypeAWSEC2Instance,
 		resource.TypeAWSECR,
 		resource.TypeAWSEKSCluster,
+		resource.TypeAWSEKSNodeGroup,
+		resource.TypeAWSEKSFargateProfile,
+		resource.TypeAWSECSCluster,
+		resource.TypeAWSECSService,
+		resource.TypeAWSECSTaskDefinition,
 		resource.TypeAWSELB,
 		resource.TypeAWSALB,
 		resource.TypeAWSNLB,
+		resource.TypeAWSTargetGroup,
 		resource.TypeAWSLambda,
+		resource.TypeAWSStateMachine,
+		resource.TypeAWSEventBus,
+		resource.TypeAWSEventRule,
 		resource.TypeAWSAPIGateway,
 		resource.TypeAWSCloudFront,
 		resource.TypeAWSMemoryDB,
@@ -119,6 +142,18 @@ func (p *Provider) GetSupportedResourceTypes() []resource.ResourceType {
 		resource.TypeAWSSNSTopic,
 		resource.TypeAWSSQSQueue,
 		resource.TypeAWSDynamoDBTable,
+		resource.TypeAWSS3Bucket,
+		resource.TypeAWSRDSInstance,
+		resource.TypeAWSRDSCluster,
+		resource.TypeAWSEBSVolume,
+		resource.TypeAWSEBSSnapshot,
+		resource.TypeAWSEFS,
+		resource.TypeAWSKMSKey,
+		resource.TypeAWSACMCertificate,
+		resource.TypeAWSRoute53Zone,
+		resource.TypeAWSRoute53Record,
+		resource.TypeAWSCloudTrail,
+		resource.TypeAWSWAFWebACL,
 	}
 }
 
@@ -164,6 +199,31 @@ func (p *Provider) CollectResources(ctx context.Context, types []resource.Resour
 		}
 	}
 
+	if typeSet[resource.TypeAWSIAMGroup] {
+		if err := p.collectIAMGroups(ctx, collection); err != nil {
+			return nil, fmt.Errorf("failed to collect IAM groups: %w", err)
+		}
+		if err := p.rateLimiter.Wait(ctx); err != nil {
+			return nil, err
+		}
+	}
+
+	if typeSet[resource.TypeAWSIAMPolicy] {
+		if err := p.collectIAMPolicies(ctx, collection); err != nil {
+			return nil, fmt.Errorf("failed to collect IAM policies: %w", err)
+		}
+		if err := p.rateLimiter.Wait(ctx); err != nil {
+			return nil, err
+		}
+	}
+
+	// Flag the users and roles allowed every action, now that their policies are collected
+	if typeSet[resource.TypeAWSIAMUser] || typeSet[resource.TypeAWSIAMRole] {
+		if admins := iampolicy.MarkAdmins(collection); admins > 0 {
+			fmt.Fprintf(os.Stderr, "  Found %d IAM users and roles with administrator access\n", admins)
+		}
+	}
+
 	if typeSet[resource.TypeAWSAccount] {
 		p.collectAccounts(collection)
 		if err := p.rateLimiter.Wait(ctx); err != nil {
@@ -171,7 +231,7 @@ func (p *Provider) CollectResources(ctx context.Context, types []resource.Resour
 		}
 	}
 
-	// Collect global resources (CloudFront)
+	// Collect global resources (CloudFront, S3, Route 53, CloudFront web ACLs)
 	if typeSet[resource.TypeAWSCloudFront] {
 		if err := p.collectCloudFrontDistributions(ctx, collection, p.awsConfig); err != nil {
 			return nil, fmt.Errorf("failed to collect CloudFront distributions: %w", err)
@@ -181,6 +241,33 @@ func (p *Provider) CollectResources(ctx context.Context, types []resource.Resour
 		}
 	}
 
+	if typeSet[resource.TypeAWSS3Bucket] {
+		if err := p.collectS3Buckets(ctx, collection, p.awsConfig); err != nil {
+			return nil, fmt.Errorf("failed to collect S3 buckets: %w", err)
+		}
+		if err := p.rateLimiter.Wait(ctx); err != nil {
+			return nil, err
+		}
+	}
+
+	if typeSet[resource.TypeAWSRoute53Zone] || typeSet[resource.TypeAWSRoute53Record] {
+		if err := p.collectRoute53(ctx, collection, p.awsConfig, typeSet); err != nil {
+			return nil, fmt.Errorf("failed to collect Route 53 resources: %w", err)
+		}
+		if err := p.rateLimiter.Wait(ctx); err != nil {
+			return nil, err
+		}
+	}
+
+	if typeSet[resource.TypeAWSWAFWebACL] {
+		if err := p.collectWebACLs(ctx, collection, "global", p.awsConfig, wafv2Types.ScopeCloudfront); err != nil {
+			return nil, fmt.Errorf("failed to collect CloudFront WAF web ACLs: %w", err)
+		}
+		if err := p.rateLimiter.Wait(ctx); err != nil {
+			return nil, err
+		}
+	}
+
 	// Collect regional resources with concurrency
 	if concurrency == 1 {
 		// Sequential execution for backward compatibility
@@ -231,6 +318,87 @@ func (p *Provider) collectRegionalResources(ctx context.Context, collection *res
 		}
 	}
 
+	if typeSet[resource.TypeAWSRouteTable] {
+		if err := p.collectRouteTables(ctx, collection, region, regionalConfig); err != nil {
+			return fmt.Errorf("failed to collect route tables in %s: %w", region, err)
+		}
+		if err := p.rateLimiter.Wait(ctx); err != nil {
+			return err
+		}
+	}
+
+	if typeSet[resource.TypeAWSInternetGateway] {
+		if err := p.collectInternetGateways(ctx, collection, region, regionalConfig); err != nil {
+			return fmt.Errorf("failed to collect internet gateways in %s: %w", region, err)
+		}
+		if err := p.rateLimiter.Wait(ctx); err != nil {
+			return err
+		}
+	}
+
+	if typeSet[resource.TypeAWSNATGateway] {
+		if err := p.collectNATGateways(ctx, collection, region, regionalConfig); err != nil {
+			return fmt.Errorf("failed to collect NAT gateways in %s: %w", region, err)
+		}
+		if err := p.rateLimiter.Wait(ctx); err != nil {
+			return err
+		}
+	}
+
+	if typeSet[resource.TypeAWSTransitGateway] {
+		if err := p.collectTransitGateways(ctx, collection, region, regionalConfig); err != nil {
+			return fmt.Errorf("failed to collect transit gateways in %s: %w", region, err)
+		}
+		if err := p.rateLimiter.Wait(ctx); err != nil {
+			return err
+		}
+	}
+
+	if typeSet[resource.TypeAWSTransitGatewayAttachment] {
+		if err := p.collectTransitGatewayAttachments(ctx, collection, region, regionalConfig); err != nil {
+			return fmt.Errorf("failed to collect transit gateway attachments in %s: %w", region, err)
+		}
+		if err := p.rateLimiter.Wait(ctx); err != nil {
+			return err
+		}
+	}
+
+	if typeSet[resource.TypeAWSVPCPeeringConnection] {
+		if err := p.collectVPCPeeringConnections(ctx, collection, region, regionalConfig); err != nil {
+			return fmt.Errorf("failed to collect VPC peering connections in %s: %w", region, err)
+		}
+		if err := p.rateLimiter.Wait(ctx); err != nil {
+			return err
+		}
+	}
+
+	if typeSet[resource.TypeAWSVPCEndpoint] {
+		if err := p.collectVPCEndpoints(ctx, collection, region, regionalConfig); err != nil {
+			return fmt.Errorf("failed to collect VPC endpoints in %s: %w", region, err)
+		}
+		if err := p.rateLimiter.Wait(ctx); err != nil {
+			return err
+		}
+	}
+
+	if typeSet[resource.TypeAWSElasticIP] {
+		if err := p.collectElasticIPs(ctx, collection, region, regionalConfig); err != nil {
+			return fmt.Errorf("failed to collect Elastic IPs in %s: %w", region, err)
+		}
+		if err := p.rateLimiter.Wait(ctx); err != nil {
+			return err
+		}
+	}
+
+	if typeSet[resource.TypeAWSNetworkInterface] {
+		if err := p.collectNetworkInterfaces(ctx, collection, region, regionalConfig); err != nil {
+			return fmt.Errorf("failed to collect network interfaces in %s: %w", region, err)
+		}
+		if err := p.rateLimiter.Wait(ctx); err != nil {
+			return err
+		}
+	}
+
 	if typeSet[resource.TypeAWSEC2Instance] {
 		if err := p.collectEC2Instances(ctx, collection, region, regionalConfig); err != nil {
 			return fmt.Errorf("failed to collect EC2 instances in %s: %w", region, err)
@@ -258,6 +426,24 @@ func (p *Provider) collectRegionalResources(ctx context.Context, collection *res
 		}
 	}
 
+	if typeSet[resource.TypeAWSEKSNodeGroup] || typeSet[resource.TypeAWSEKSFargateProfile] {
+		if err := p.collectEKSNodeGroups(ctx, collection, region, regionalConfig, typeSet); err != nil {
+			return fmt.Errorf("failed to collect EKS node groups in %s: %w", region, err)
+		}
+		if err := p.rateLimiter.Wait(ctx); err != nil {
+			return err
+		}
+	}
+
+	if typeSet[resource.TypeAWSECSCluster] || typeSet[resource.TypeAWSECSService] || typeSet[resource.TypeAWSECSTaskDefinition] {
+		if err := p.collectECSResources(ctx, collection, region, regionalConfig, typeSet); err != nil {
+			return fmt.Errorf("failed to collect ECS resources in %s: %w", region, err)
+		}
+		if err := p.rateLimiter.Wait(ctx); err != nil {
+			return err
+		}
+	}
+
 	if typeSet[resource.TypeAWSELB] {
 		if err := p.collectClassicLoadBalancers(ctx, collection, region, regionalConfig); err != nil {
 			return fmt.Errorf("failed to collect ELBs in %s: %w", region, err)
@@ -267,8 +453,8 @@ func (p *Provider) collectRegionalResources(ctx context.Context, collection *res
 		}
 	}
 
-	if typeSet[resource.TypeAWSALB] || typeSet[resource.TypeAWSNLB] {
-		if err := p.collectLoadBalancersV2(ctx, collection, region, regionalConfig); err != nil {
+	if typeSet[resource.TypeAWSALB] || typeSet[resource.TypeAWSNLB] || typeSet[resource.TypeAWSTargetGroup] {
+		if err := p.collectLoadBalancersV2(ctx, collection, region, regionalConfig, typeSet); err != nil {
 			return fmt.Errorf("failed to collect ALBs/NLBs in %s: %w", region, err)
 		}
 		if err := p.rateLimiter.Wait(ctx); err != nil {
@@ -285,6 +471,24 @@ func (p *Provider) collectRegionalResources(ctx context.Context, collection *res
 		}
 	}
 
+	if typeSet[resource.TypeAWSStateMachine] {
+		if err := p.collectStateMachines(ctx, collection, region, regionalConfig); err != nil {
+			return fmt.Errorf("failed to collect state machines in %s: %w", region, err)
+		}
+		if err := p.rateLimiter.Wait(ctx); err != nil {
+			return err
+		}
+	}
+
+	if typeSet[resource.TypeAWSEventBus] || typeSet[resource.TypeAWSEventRule] {
+		if err := p.collectEventBridge(ctx, collection, region, regionalConfig, typeSet); err != nil {
+			return fmt.Errorf("failed to collect EventBridge resources in %s: %w", region, err)
+		}
+		if err := p.rateLimiter.Wait(ctx); err != nil {
+			return err
+		}
+	}
+
 	if typeSet[resource.TypeAWSAPIGateway] {
 		if err := p.collectAPIGatewayAPIs(ctx, collection, region, regionalConfig); err != nil {
 			return fmt.Errorf("failed to collect API Gateways in %s: %w", region, err)
@@ -348,6 +552,87 @@ func (p *Provider) collectRegionalResources(ctx context.Context, collection *res
 		}
 	}
 
+	if typeSet[resource.TypeAWSRDSInstance] {
+		if err := p.collectRDSInstances(ctx, collection, region, regionalConfig); err != nil {
+			return fmt.Errorf("failed to collect RDS instances in %s: %w", region, err)
+		}
+		if err := p.rateLimiter.Wait(ctx); err != nil {
+			return err
+		}
+	}
+
+	if typeSet[resource.TypeAWSRDSCluster] {
+		if err := p.collectRDSClusters(ctx, collection, region, regionalConfig); err != nil {
+			return fmt.Errorf("failed to collect RDS clusters in %s: %w", region, err)
+		}
+		if err := p.rateLimiter.Wait(ctx); err != nil {
+			return err
+		}
+	}
+
+	if typeSet[resource.TypeAWSEBSVolume] {
+		if err := p.collectEBSVolumes(ctx, collection, region, regionalConfig); err != nil {
+			return fmt.Errorf("failed to collect EBS volumes in %s: %w", region, err)
+		}
+		if err := p.rateLimiter.Wait(ctx); err != nil {
+			return err
+		}
+	}
+
+	if typeSet[resource.TypeAWSEBSSnapshot] {
+		if err := p.collectEBSSnapshots(ctx, collection, region, regionalConfig); err != nil {
+			return fmt.Errorf("failed to collect EBS snapshots in %s: %w", region, err)
+		}
+		if err := p.rateLimiter.Wait(ctx); err != nil {
+			return err
+		}
+	}
+
+	if typeSet[resource.TypeAWSEFS] {
+		if err := p.collectEFSFileSystems(ctx, collection, region, regionalConfig); err != nil {
+			return fmt.Errorf("failed to collect EFS file systems in %s: %w", region, err)
+		}
+		if err := p.rateLimiter.Wait(ctx); err != nil {
+			return err
+		}
+	}
+
+	if typeSet[resource.TypeAWSKMSKey] {
+		if err := p.collectKMSKeys(ctx, collection, region, regionalConfig); err != nil {
+			return fmt.Errorf("failed to collect KMS keys in %s: %w", region, err)
+		}
+		if err := p.rateLimiter.Wait(ctx); err != nil {
+			return err
+		}
+	}
+
+	if typeSet[resource.TypeAWSACMCertificate] {
+		if err := p.collectACMCertificates(ctx, collection, region, regionalConfig); err != nil {
+			return fmt.Errorf("failed to collect ACM certificates in %s: %w", region, err)
+		}
+		if err := p.rateLimiter.Wait(ctx); err != nil {
+			return err
+		}
+	}
+
+	if typeSet[resource.TypeAWSCloudTrail] {
+		if err := p.collectCloudTrails(ctx, collection, region, regionalConfig); err != nil {
+			return fmt.Errorf("failed to collect CloudTrail trails in %s: %w", region, err)
+		}
+		if err := p.rateLimiter.Wait(ctx); err != nil {
+			return err
+		}
+	}
+
+	if typeSet[resource.TypeAWSWAFWebACL] {
+		if err := p.collectWebACLs(ctx, collection, region, regionalConfig, wafv2Types.ScopeRegional); err != nil {
+			return fmt.Errorf("failed to collect WAF web ACLs in %s: %w", region, err)
+		}
+		if err := p.rateLimiter.Wait(ctx); err != nil {
+			return err
+		}
+	}
+
 	return nil
 }
 
@@ -408,19 +693,22 @@ func (p *Provider) collectRegionalResourcesConcurrent(ctx context.Context, colle
 	return nil
 }
 
-// DiscoverRelationships establishes relationships between AWS resources
+// DiscoverRelationships establishes relationships between AWS resources. The resources are
+// indexed once, and every discovery looks up the resources it links in the index.
 func (p *Provider) DiscoverRelationships(ctx context.Context, collection *resource.Collection) error {
-	// Build relationships based on AWS resource structure
-	for _, res := range collection.Resources {
-		switch res.Type {
-		case resource.TypeAWSVPC:
-			p.discoverVPCRelationships(res, collection)
-		case resource.TypeAWSSubnet:
-			p.discoverSubnetRelationships(res, collection)
-		case resource.TypeAWSSecurityGroup:
-			p.discoverSecurityGroupRelationships(res, collection)
-		}
-	}
+	idx := newResourceIndex(collection)
+	targets := dnsTargets(idx)
+
+	p.discoverVPCRelationships(idx)
+	p.discoverSubnetRouting(idx)
+	p.discoverEventSources(idx)
+	p.discoverKMSKeyUsage(idx)
+	p.discoverDNSTargets(idx, targets)
+	p.discoverCloudFrontOrigins(idx, targets)
+	p.discoverWebACLAssociations(idx)
+	p.discoverIPTargets(idx)
+	p.discoverInstanceProfileRoles(idx)
+	p.resolveARNTargets(idx)
 
 	return nil
 }
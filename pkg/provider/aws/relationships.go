@@ -1,53 +1,568 @@
 package aws
 
 import (
+	"fmt"
+	"slices"
+	"strings"
+
+	ec2Types "github.com/aws/aws-sdk-go-v2/service/ec2/types"
+
 	"github.com/comfortablynumb/pmp-cloud-inspector/pkg/resource"
 )
 
-// discoverSubnetRelationships discovers relationships for subnets
-func (p *Provider) discoverSubnetRelationships(subnet *resource.Resource, collection *resource.Collection) {
-	// Subnet relationships are already added during collection
-	// (belongs_to VPC relationship)
+// resourceIndex indexes the collected AWS resources by type, ID and ARN, so that relationship
+// discovery looks up the resources it links instead of scanning the collection for each of them
+type resourceIndex struct {
+	resources []*resource.Resource
+	byType    map[resource.ResourceType][]*resource.Resource
+	byID      map[resource.ResourceType]map[string]*resource.Resource
+	byARN     map[string]*resource.Resource
+}
+
+// newResourceIndex indexes the AWS resources of a collection
+func newResourceIndex(collection *resource.Collection) *resourceIndex {
+	idx := &resourceIndex{
+		byType: make(map[resource.ResourceType][]*resource.Resource),
+		byID:   make(map[resource.ResourceType]map[string]*resource.Resource),
+		byARN:  make(map[string]*resource.Resource),
+	}
+
+	for _, res := range collection.Resources {
+		if res.Provider != "aws" {
+			continue
+		}
+		idx.resources = append(idx.resources, res)
+		idx.byType[res.Type] = append(idx.byType[res.Type], res)
+		if idx.byID[res.Type] == nil {
+			idx.byID[res.Type] = make(map[string]*resource.Resource)
+		}
+		idx.byID[res.Type][res.ID] = res
+		if res.ARN != "" {
+			idx.byARN[res.ARN] = res
+		}
+	}
+
+	return idx
 }
 
-// discoverSecurityGroupRelationships discovers relationships for security groups
-func (p *Provider) discoverSecurityGroupRelationships(sg *resource.Resource, collection *resource.Collection) {
-	// Security group relationships are already added during collection
-	// (belongs_to VPC relationship)
+// get returns the resource of a type with an ID, or nil when it was not collected
+func (idx *resourceIndex) get(resourceType resource.ResourceType, id string) *resource.Resource {
+	return idx.byID[resourceType][id]
+}
 
-	// Could add more complex relationships here, such as:
-	// - References to other security groups in rules
-	// - Associations with specific resources (EC2 instances, etc.)
+// ofType returns the resources of the given types, in collection order within each type
+func (idx *resourceIndex) ofType(resourceTypes ...resource.ResourceType) []*resource.Resource {
+	if len(resourceTypes) == 1 {
+		return idx.byType[resourceTypes[0]]
+	}
+	var resources []*resource.Resource
+	for _, resourceType := range resourceTypes {
+		resources = append(resources, idx.byType[resourceType]...)
+	}
+	return resources
 }
 
-// discoverVPCRelationships discovers relationships for VPCs
-func (p *Provider) discoverVPCRelationships(vpc *resource.Resource, collection *resource.Collection) {
-	// Find all subnets in this VPC
-	for _, res := range collection.Resources {
-		if res.Type == resource.TypeAWSSubnet {
-			for _, rel := range res.Relationships {
-				if rel.TargetID == vpc.ID && rel.Type == resource.RelationBelongsTo {
-					// Add inverse relationship
-					vpc.Relationships = append(vpc.Relationships, resource.Relationship{
-						Type:       resource.RelationContains,
-						TargetID:   res.ID,
-						TargetType: resource.TypeAWSSubnet,
-					})
+// discoverSubnetRouting resolves the route table used by every subnet: the one explicitly
+// associated with it, or the main route table of its VPC. The subnet gets the ID of the route
+// table and is marked as public when the table routes to an internet gateway. Subnets using the
+// main route table are added to it as attached_to relationships, like explicit associations.
+// Subnets are left untouched when no route tables were collected.
+func (p *Provider) discoverSubnetRouting(idx *resourceIndex) {
+	associated := make(map[string]*resource.Resource) // subnet ID -> explicitly associated route table
+	mainTables := make(map[string]*resource.Resource) // VPC ID -> main route table
+	for _, res := range idx.ofType(resource.TypeAWSRouteTable) {
+		if isMain, _ := res.BoolProperty(resource.PropMainRouteTable); isMain {
+			vpcID, _ := res.StringProperty("vpc_id")
+			mainTables[vpcID] = res
+		}
+		for _, subnetID := range res.StringsProperty("subnet_ids") {
+			associated[subnetID] = res
+		}
+	}
+	if len(associated) == 0 && len(mainTables) == 0 {
+		return
+	}
+
+	for _, subnet := range idx.ofType(resource.TypeAWSSubnet) {
+		table, ok := associated[subnet.ID]
+		if !ok {
+			table, ok = mainTables[subnetVPC(subnet)]
+			if !ok {
+				continue
+			}
+			table.Relationships = append(table.Relationships, resource.Relationship{
+				Type:       resource.RelationAttachedTo,
+				TargetID:   subnet.ID,
+				TargetType: resource.TypeAWSSubnet,
+				Properties: map[string]interface{}{
+					resource.PropMainRouteTable: true,
+				},
+			})
+		}
+
+		public, _ := table.BoolProperty(resource.PropPublic)
+		subnet.Properties[resource.PropRouteTableID] = table.ID
+		subnet.Properties[resource.PropPublic] = public
+	}
+}
+
+// subnetVPC returns the ID of the VPC a subnet belongs to
+func subnetVPC(subnet *resource.Resource) string {
+	for _, rel := range subnet.Relationships {
+		if rel.Type == resource.RelationBelongsTo && rel.TargetType == resource.TypeAWSVPC {
+			return rel.TargetID
+		}
+	}
+	return ""
+}
+
+// resolveARNTargets rewrites the targets of relationships that reference a resource by its ARN
+// (e.g., the function triggered by an event rule) to the ID of the resource, for the collected
+// resources whose ID is not their ARN, such as Lambda functions, SQS queues or DynamoDB tables.
+// Targets that were not collected keep their ARN.
+func (p *Provider) resolveARNTargets(idx *resourceIndex) {
+	for _, res := range idx.resources {
+		for i, rel := range res.Relationships {
+			if !strings.HasPrefix(rel.TargetID, "arn:") {
+				continue
+			}
+			if target, ok := idx.byARN[unqualifiedARN(rel.TargetID)]; ok {
+				res.Relationships[i].TargetID = target.ID
+			}
+		}
+	}
+}
+
+// discoverEventSources adds a triggers relationship from the queues, tables and other collected
+// sources of the event source mappings of every Lambda function to the function
+func (p *Provider) discoverEventSources(idx *resourceIndex) {
+	for _, function := range idx.ofType(resource.TypeAWSLambda) {
+		for _, mapping := range function.MapsProperty(resource.PropEventSources) {
+			sourceARN, _ := mapping["event_source_arn"].(string)
+			source, ok := idx.byARN[unqualifiedARN(sourceARN)]
+			if !ok {
+				continue
+			}
+			source.Relationships = append(source.Relationships, resource.Relationship{
+				Type:       resource.RelationTriggers,
+				TargetID:   function.ID,
+				TargetType: resource.TypeAWSLambda,
+				Properties: map[string]interface{}{
+					"uuid":             mapping["uuid"],
+					resource.PropState: mapping[resource.PropState],
+				},
+			})
+		}
+	}
+}
+
+// discoverKMSKeyUsage adds a references relationship from the resources encrypted with a KMS key
+// (secrets, queues, topics, tables, volumes, buckets, ...) to the key. Resources set their key
+// by key ID, key ARN, alias name or alias ARN; key IDs and alias names are resolved in the region
+// of the resource. Keys that were not collected are referenced by their key ARN.
+func (p *Provider) discoverKMSKeyUsage(idx *resourceIndex) {
+	keys := make(map[string]string) // key or alias ARN, or region and key ID or alias name -> key resource ID
+	for _, key := range idx.ofType(resource.TypeAWSKMSKey) {
+		keyID, _ := key.StringProperty("key_id")
+		keys[key.ARN] = key.ID
+		keys[key.Region+"/"+keyID] = key.ID
+
+		// arn:aws:kms:region:account:key/id -> arn:aws:kms:region:account:alias/name
+		arnPrefix := strings.TrimSuffix(key.ARN, "key/"+keyID)
+		for _, alias := range key.StringsProperty("aliases") {
+			keys[key.Region+"/"+alias] = key.ID
+			keys[arnPrefix+alias] = key.ID
+		}
+	}
+
+	for _, res := range idx.resources {
+		if res.Type == resource.TypeAWSKMSKey {
+			continue
+		}
+		keyRef, _ := res.StringProperty(resource.PropKMSKeyID)
+		if keyRef == "" {
+			continue
+		}
+
+		targetID, ok := keys[keyRef]
+		if !ok {
+			targetID, ok = keys[res.Region+"/"+keyRef]
+		}
+		if !ok {
+			if !strings.HasPrefix(keyRef, "arn:") || strings.Contains(keyRef, ":alias/") {
+				continue
+			}
+			targetID = keyRef
+		}
+
+		res.Relationships = append(res.Relationships, resource.Relationship{
+			Type:       resource.RelationReferences,
+			TargetID:   targetID,
+			TargetType: resource.TypeAWSKMSKey,
+		})
+	}
+}
+
+// dnsTargets returns the load balancers, CloudFront distributions, API Gateway APIs and S3
+// buckets reachable through a DNS name, by name. APIs are reached through their default endpoint
+// or their custom domain names, and buckets through their REST and website endpoints.
+func dnsTargets(idx *resourceIndex) map[string][]*resource.Resource {
+	targets := make(map[string][]*resource.Resource) // DNS name -> resources
+	for _, res := range idx.ofType(resource.TypeAWSELB, resource.TypeAWSALB, resource.TypeAWSNLB, resource.TypeAWSCloudFront, resource.TypeAWSAPIGateway, resource.TypeAWSS3Bucket) {
+		switch res.Type {
+		case resource.TypeAWSELB, resource.TypeAWSALB, resource.TypeAWSNLB:
+			name, _ := res.StringProperty("dns_name")
+			targets[dnsName(name)] = append(targets[dnsName(name)], res)
+		case resource.TypeAWSCloudFront:
+			name, _ := res.StringProperty("domain_name")
+			targets[dnsName(name)] = append(targets[dnsName(name)], res)
+		case resource.TypeAWSAPIGateway:
+			endpoint := fmt.Sprintf("%s.execute-api.%s.amazonaws.com", strings.ToLower(res.ID), res.Region)
+			targets[endpoint] = append(targets[endpoint], res)
+			for _, domain := range res.MapsProperty("custom_domains") {
+				name, _ := domain["api_gateway_domain_name"].(string)
+				if name != "" && !slices.Contains(targets[dnsName(name)], res) {
+					targets[dnsName(name)] = append(targets[dnsName(name)], res)
 				}
 			}
+		case resource.TypeAWSS3Bucket:
+			bucket := strings.ToLower(res.ID)
+			for _, endpoint := range []string{
+				bucket + ".s3.amazonaws.com",
+				fmt.Sprintf("%s.s3.%s.amazonaws.com", bucket, res.Region),
+				fmt.Sprintf("%s.s3-website-%s.amazonaws.com", bucket, res.Region),
+				fmt.Sprintf("%s.s3-website.%s.amazonaws.com", bucket, res.Region),
+			} {
+				targets[endpoint] = append(targets[endpoint], res)
+			}
+		}
+	}
+	delete(targets, "")
+	return targets
+}
+
+// discoverDNSTargets adds a references relationship from the Route 53 records to the load
+// balancers, CloudFront distributions, API Gateway APIs and S3 buckets they point to, by alias
+// target or CNAME value
+func (p *Provider) discoverDNSTargets(idx *resourceIndex, targets map[string][]*resource.Resource) {
+	if len(targets) == 0 {
+		return
+	}
+
+	for _, record := range idx.ofType(resource.TypeAWSRoute53Record) {
+		var names []string
+		if alias, ok := record.Properties["alias_target"].(map[string]interface{}); ok {
+			if name, ok := alias["dns_name"].(string); ok {
+				names = append(names, name)
+			}
+		}
+		if recordType, _ := record.StringProperty("record_type"); recordType == "CNAME" {
+			names = append(names, record.StringsProperty("values")...)
+		}
+
+		for _, name := range names {
+			for _, target := range targets[strings.TrimPrefix(dnsName(name), "dualstack.")] {
+				record.Relationships = append(record.Relationships, resource.Relationship{
+					Type:       resource.RelationReferences,
+					TargetID:   target.ID,
+					TargetType: target.Type,
+				})
+			}
+		}
+	}
+}
+
+// discoverIPTargets adds a routes_to relationship from the target groups with IP targets to the
+// network interfaces holding the registered IP addresses, such as those of ECS tasks. IP targets
+// outside the collected VPCs are only listed in the targets property of the group.
+func (p *Provider) discoverIPTargets(idx *resourceIndex) {
+	interfaces := make(map[string]string) // region and private IP -> network interface ID
+	for _, res := range idx.ofType(resource.TypeAWSNetworkInterface) {
+		for _, ip := range res.StringsProperty("private_ips") {
+			interfaces[res.Region+"/"+ip] = res.ID
+		}
+	}
+	if len(interfaces) == 0 {
+		return
+	}
+
+	for _, group := range idx.ofType(resource.TypeAWSTargetGroup) {
+		if targetType, _ := group.StringProperty("target_type"); targetType != "ip" {
+			continue
+		}
+
+		for _, target := range group.MapsProperty("targets") {
+			ip, _ := target["id"].(string)
+			interfaceID, ok := interfaces[group.Region+"/"+ip]
+			if !ok {
+				continue
+			}
+			group.Relationships = append(group.Relationships, resource.Relationship{
+				Type:       resource.RelationRoutesTo,
+				TargetID:   interfaceID,
+				TargetType: resource.TypeAWSNetworkInterface,
+				Properties: map[string]interface{}{
+					"ip":               ip,
+					"port":             target["port"],
+					resource.PropState: target[resource.PropState],
+				},
+			})
+		}
+	}
+}
+
+// discoverWebACLAssociations adds an attached_to relationship from the WAF web ACLs to the
+// CloudFront distributions they protect, which reference their web ACL by ARN
+func (p *Provider) discoverWebACLAssociations(idx *resourceIndex) {
+	for _, distribution := range idx.ofType(resource.TypeAWSCloudFront) {
+		webACL, _ := distribution.StringProperty("web_acl_id")
+		if acl, ok := idx.byARN[webACL]; ok && acl.Type == resource.TypeAWSWAFWebACL {
+			acl.Relationships = append(acl.Relationships, resource.Relationship{
+				Type:       resource.RelationAttachedTo,
+				TargetID:   distribution.ID,
+				TargetType: resource.TypeAWSCloudFront,
+			})
 		}
+	}
+}
+
+// discoverCloudFrontOrigins adds a routes_to relationship from the CloudFront distributions to
+// the load balancers, API Gateway APIs and S3 buckets serving their origins, by domain name
+func (p *Provider) discoverCloudFrontOrigins(idx *resourceIndex, targets map[string][]*resource.Resource) {
+	if len(targets) == 0 {
+		return
+	}
 
-		// Find all security groups in this VPC
-		if res.Type == resource.TypeAWSSecurityGroup {
-			for _, rel := range res.Relationships {
-				if rel.TargetID == vpc.ID && rel.Type == resource.RelationBelongsTo {
-					// Add inverse relationship
-					vpc.Relationships = append(vpc.Relationships, resource.Relationship{
-						Type:       resource.RelationContains,
-						TargetID:   res.ID,
-						TargetType: resource.TypeAWSSecurityGroup,
-					})
+	for _, distribution := range idx.ofType(resource.TypeAWSCloudFront) {
+		for _, origin := range distribution.MapsProperty("origins") {
+			domain, _ := origin["domain_name"].(string)
+			for _, target := range targets[strings.TrimPrefix(dnsName(domain), "dualstack.")] {
+				if target == distribution {
+					continue
 				}
+				distribution.Relationships = append(distribution.Relationships, resource.Relationship{
+					Type:       resource.RelationRoutesTo,
+					TargetID:   target.ID,
+					TargetType: target.Type,
+					Properties: map[string]interface{}{
+						"origin_id": origin["id"],
+					},
+				})
+			}
+		}
+	}
+}
+
+// discoverInstanceProfileRoles adds an assumes relationship from the EC2 instances to the IAM
+// role of their instance profile. Instances only reference the profile, which is resolved to its
+// role through the instance profiles of the collected roles.
+func (p *Provider) discoverInstanceProfileRoles(idx *resourceIndex) {
+	roles := make(map[string]*resource.Resource) // instance profile ARN -> role
+	for _, role := range idx.ofType(resource.TypeAWSIAMRole) {
+		for _, profile := range role.StringsProperty("instance_profiles") {
+			roles[profile] = role
+		}
+	}
+	if len(roles) == 0 {
+		return
+	}
+
+	for _, instance := range idx.ofType(resource.TypeAWSEC2Instance) {
+		profile, _ := instance.StringProperty("iam_instance_profile_arn")
+		role, ok := roles[profile]
+		if !ok {
+			continue
+		}
+		instance.Relationships = append(instance.Relationships, resource.Relationship{
+			Type:       resource.RelationAssumes,
+			TargetID:   role.ID,
+			TargetType: resource.TypeAWSIAMRole,
+			Properties: map[string]interface{}{
+				"instance_profile_arn": profile,
+			},
+		})
+	}
+}
+
+// unqualifiedARN returns the ARN of the resource an ARN refers to: the function of a Lambda
+// version or alias, the state machine of a version or alias, the table of a DynamoDB stream and
+// the API of an API Gateway stage. Other ARNs are returned unchanged.
+func unqualifiedARN(arn string) string {
+	parts := strings.Split(arn, ":")
+	if len(parts) < 6 {
+		return arn
+	}
+
+	switch parts[2] {
+	case "lambda", "states":
+		// arn:aws:lambda:region:account:function:name[:qualifier]
+		if len(parts) > 7 {
+			return strings.Join(parts[:7], ":")
+		}
+	case "dynamodb":
+		// arn:aws:dynamodb:region:account:table/name/stream/label
+		if table, _, found := strings.Cut(arn, "/stream/"); found {
+			return table
+		}
+	case "apigateway":
+		// arn:aws:apigateway:region::/restapis/id/stages/name
+		if api, _, found := strings.Cut(arn, "/stages/"); found {
+			return api
+		}
+	}
+	return arn
+}
+
+// arnResourceType returns the type of the resource an ARN refers to, or an empty string for
+// resources that are not collected
+func arnResourceType(arn string) resource.ResourceType {
+	parts := strings.SplitN(arn, ":", 6)
+	if len(parts) < 6 || parts[0] != "arn" {
+		return ""
+	}
+
+	service, name := parts[2], parts[5]
+	switch {
+	case service == "lambda" && strings.HasPrefix(name, "function:"):
+		return resource.TypeAWSLambda
+	case service == "sqs":
+		return resource.TypeAWSSQSQueue
+	case service == "sns" && !strings.Contains(name, ":"):
+		return resource.TypeAWSSNSTopic
+	case service == "states" && strings.HasPrefix(name, "stateMachine:"):
+		return resource.TypeAWSStateMachine
+	case service == "events" && strings.HasPrefix(name, "event-bus/"):
+		return resource.TypeAWSEventBus
+	case service == "ecs" && strings.HasPrefix(name, "cluster/"):
+		return resource.TypeAWSECSCluster
+	case service == "ecs" && strings.HasPrefix(name, "service/"):
+		return resource.TypeAWSECSService
+	case service == "ecs" && strings.HasPrefix(name, "task-definition/"):
+		return resource.TypeAWSECSTaskDefinition
+	case service == "dynamodb" && strings.HasPrefix(name, "table/"):
+		return resource.TypeAWSDynamoDBTable
+	case service == "iam" && strings.HasPrefix(name, "role/"):
+		return resource.TypeAWSIAMRole
+	case service == "elasticloadbalancing" && strings.HasPrefix(name, "loadbalancer/app/"):
+		return resource.TypeAWSALB
+	case service == "elasticloadbalancing" && strings.HasPrefix(name, "loadbalancer/net/"):
+		return resource.TypeAWSNLB
+	case service == "elasticloadbalancing" && strings.HasPrefix(name, "loadbalancer/"):
+		return resource.TypeAWSELB
+	case service == "cloudfront" && strings.HasPrefix(name, "distribution/"):
+		return resource.TypeAWSCloudFront
+	case service == "apigateway" && (strings.HasPrefix(name, "/restapis/") || strings.HasPrefix(name, "/apis/")):
+		return resource.TypeAWSAPIGateway
+	case service == "ecr" && strings.HasPrefix(name, "repository/"):
+		return resource.TypeAWSECR
+	case service == "secretsmanager":
+		return resource.TypeAWSSecret
+	}
+	return ""
+}
+
+// securityGroupReferences returns the references relationships of a security group to the
+// other security groups allowed by its ingress or egress rules. Rules referencing the group
+// itself are skipped.
+func securityGroupReferences(groupID, direction string, permissions []ec2Types.IpPermission) []resource.Relationship {
+	var relationships []resource.Relationship
+	for _, perm := range permissions {
+		for _, pair := range perm.UserIdGroupPairs {
+			targetID := safeString(pair.GroupId)
+			if targetID == "" || targetID == groupID {
+				continue
+			}
+
+			properties := map[string]interface{}{
+				"direction":   direction,
+				"ip_protocol": safeString(perm.IpProtocol),
+			}
+			if perm.FromPort != nil {
+				properties["from_port"] = *perm.FromPort
+			}
+			if perm.ToPort != nil {
+				properties["to_port"] = *perm.ToPort
+			}
+			if pair.UserId != nil {
+				properties["account"] = *pair.UserId
+			}
+			if pair.VpcPeeringConnectionId != nil {
+				properties["vpc_peering_connection_id"] = *pair.VpcPeeringConnectionId
+			}
+
+			relationships = append(relationships, resource.Relationship{
+				Type:       resource.RelationReferences,
+				TargetID:   targetID,
+				TargetType: resource.TypeAWSSecurityGroup,
+				Properties: properties,
+			})
+		}
+	}
+	return relationships
+}
+
+// securityGroupRelationships returns the attached_to relationships of a resource to the
+// security groups it uses
+func securityGroupRelationships(groupIDs []string) []resource.Relationship {
+	relationships := make([]resource.Relationship, 0, len(groupIDs))
+	for _, groupID := range groupIDs {
+		if groupID == "" {
+			continue
+		}
+		relationships = append(relationships, resource.Relationship{
+			Type:       resource.RelationAttachedTo,
+			TargetID:   groupID,
+			TargetType: resource.TypeAWSSecurityGroup,
+		})
+	}
+	return relationships
+}
+
+// networkRelationships returns the belongs_to relationships of a resource to its VPC and
+// subnets. Empty IDs are skipped.
+func networkRelationships(vpcID string, subnetIDs []string) []resource.Relationship {
+	relationships := make([]resource.Relationship, 0, len(subnetIDs)+1)
+	if vpcID != "" {
+		relationships = append(relationships, resource.Relationship{
+			Type:       resource.RelationBelongsTo,
+			TargetID:   vpcID,
+			TargetType: resource.TypeAWSVPC,
+		})
+	}
+	for _, subnetID := range subnetIDs {
+		if subnetID == "" {
+			continue
+		}
+		relationships = append(relationships, resource.Relationship{
+			Type:       resource.RelationBelongsTo,
+			TargetID:   subnetID,
+			TargetType: resource.TypeAWSSubnet,
+		})
+	}
+	return relationships
+}
+
+// discoverVPCRelationships adds a contains relationship from the VPCs to their subnets and
+// security groups, which belong to their VPC since collection
+func (p *Provider) discoverVPCRelationships(idx *resourceIndex) {
+	if len(idx.ofType(resource.TypeAWSVPC)) == 0 {
+		return
+	}
+
+	for _, res := range idx.resources {
+		if res.Type != resource.TypeAWSSubnet && res.Type != resource.TypeAWSSecurityGroup {
+			continue
+		}
+		for _, rel := range res.Relationships {
+			if rel.Type != resource.RelationBelongsTo || rel.TargetType != resource.TypeAWSVPC {
+				continue
+			}
+			if vpc := idx.get(resource.TypeAWSVPC, rel.TargetID); vpc != nil {
+				vpc.Relationships = append(vpc.Relationships, resource.Relationship{
+					Type:       resource.RelationContains,
+					TargetID:   res.ID,
+					TargetType: res.Type,
+				})
 			}
 		}
 	}
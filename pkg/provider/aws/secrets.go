@@ -57,7 +57,7 @@ func (p *Provider) convertSecretToResource(secret *secretsTypes.SecretListEntry,
 		properties["rotation_lambda_arn"] = *secret.RotationLambdaARN
 	}
 	if secret.KmsKeyId != nil {
-		properties["kms_key_id"] = *secret.KmsKeyId
+		properties[resource.PropKMSKeyID] = *secret.KmsKeyId
 	}
 	if secret.LastRotatedDate != nil {
 		properties["last_rotated_date"] = secret.LastRotatedDate.String()
@@ -66,7 +66,7 @@ func (p *Provider) convertSecretToResource(secret *secretsTypes.SecretListEntry,
 		properties["last_changed_date"] = secret.LastChangedDate.String()
 	}
 	if secret.LastAccessedDate != nil {
-		properties["last_accessed_date"] = secret.LastAccessedDate.String()
+		properties[resource.PropLastAccessedDate] = secret.LastAccessedDate.String()
 	}
 
 	res := &resource.Resource{
@@ -89,14 +89,17 @@ func (p *Provider) convertSecretToResource(secret *secretsTypes.SecretListEntry,
 		res.UpdatedAt = secret.LastChangedDate
 	}
 
-	// Add KMS key relationship if present
-	if secret.KmsKeyId != nil {
+	// Add rotation function relationship
+	if secret.RotationLambdaARN != nil {
 		res.Relationships = append(res.Relationships, resource.Relationship{
-			Type:       resource.RelationDependsOn,
-			TargetID:   *secret.KmsKeyId,
-			TargetType: "aws:kms:key", // Future resource type
+			Type:       resource.RelationTriggers,
+			TargetID:   *secret.RotationLambdaARN,
+			TargetType: resource.TypeAWSLambda,
 		})
 	}
 
+	// The references relationship to the KMS key is resolved by discoverKMSKeyUsage, as the key
+	// may be set by alias
+
 	return res
 }
@@ -0,0 +1,582 @@
+package aws
+
+import (
+	"context"
+	"fmt"
+	"math"
+	"os"
+	"strings"
+	"time"
+
+	"github.com/aws/aws-sdk-go-v2/aws"
+	"github.com/aws/aws-sdk-go-v2/service/acm"
+	acmTypes "github.com/aws/aws-sdk-go-v2/service/acm/types"
+	"github.com/aws/aws-sdk-go-v2/service/cloudtrail"
+	cloudtrailTypes "github.com/aws/aws-sdk-go-v2/service/cloudtrail/types"
+	"github.com/aws/aws-sdk-go-v2/service/kms"
+	kmsTypes "github.com/aws/aws-sdk-go-v2/service/kms/types"
+	"github.com/aws/aws-sdk-go-v2/service/wafv2"
+	wafv2Types "github.com/aws/aws-sdk-go-v2/service/wafv2/types"
+
+	"github.com/comfortablynumb/pmp-cloud-inspector/pkg/iampolicy"
+	"github.com/comfortablynumb/pmp-cloud-inspector/pkg/resource"
+)
+
+// collectKMSKeys collects the KMS keys of a region, with their aliases, rotation status and key
+// policy
+func (p *Provider) collectKMSKeys(ctx context.Context, collection *resource.Collection, region string, cfg aws.Config) error {
+	fmt.Fprintf(os.Stderr, "  Collecting KMS keys in %s...\n", region)
+	client := kms.NewFromConfig(cfg)
+
+	// Aliases by target key ID
+	aliases := make(map[string][]string)
+	aliasPaginator := kms.NewListAliasesPaginator(client, &kms.ListAliasesInput{})
+	for aliasPaginator.HasMorePages() {
+		output, err := aliasPaginator.NextPage(ctx)
+		if err != nil {
+			return fmt.Errorf("failed to list KMS aliases: %w", err)
+		}
+		for _, alias := range output.Aliases {
+			if alias.TargetKeyId != nil {
+				aliases[*alias.TargetKeyId] = append(aliases[*alias.TargetKeyId], safeString(alias.AliasName))
+			}
+		}
+	}
+
+	paginator := kms.NewListKeysPaginator(client, &kms.ListKeysInput{})
+
+	count := 0
+	for paginator.HasMorePages() {
+		output, err := paginator.NextPage(ctx)
+		if err != nil {
+			return fmt.Errorf("failed to list KMS keys: %w", err)
+		}
+
+		for _, key := range output.Keys {
+			described, err := client.DescribeKey(ctx, &kms.DescribeKeyInput{KeyId: key.KeyId})
+			if err != nil || described.KeyMetadata == nil {
+				fmt.Fprintf(os.Stderr, "    Warning: failed to describe KMS key %s: %v\n", safeString(key.KeyId), err)
+				continue
+			}
+			metadata := described.KeyMetadata
+
+			res := p.convertKMSKeyToResource(metadata, aliases[safeString(metadata.KeyId)], region)
+			p.describeKMSKey(ctx, client, metadata, res)
+
+			collection.Add(res)
+			count++
+			fmt.Fprintf(os.Stderr, "    Found KMS key: %s (%s)\n", res.Name, metadata.KeyManager)
+		}
+	}
+
+	fmt.Fprintf(os.Stderr, "  Collected %d KMS keys in %s\n", count, region)
+	return nil
+}
+
+// describeKMSKey adds the key policy, rotation status and tags of a KMS key to its resource.
+// They are optional: failures are reported as warnings.
+func (p *Provider) describeKMSKey(ctx context.Context, client *kms.Client, metadata *kmsTypes.KeyMetadata, res *resource.Resource) {
+	keyID := metadata.KeyId
+	warn := func(what string, err error) {
+		fmt.Fprintf(os.Stderr, "    Warning: failed to get %s of KMS key %s: %v\n", what, safeString(keyID), err)
+	}
+
+	policy, err := client.GetKeyPolicy(ctx, &kms.GetKeyPolicyInput{KeyId: keyID, PolicyName: aws.String("default")})
+	if err != nil {
+		warn("policy", err)
+	} else if policy.Policy != nil {
+		if document, err := iampolicy.ToProperty(*policy.Policy); err == nil {
+			res.Properties[resource.PropPolicyDocument] = document
+		}
+		if doc, err := iampolicy.Parse(*policy.Policy); err == nil {
+			res.Properties[resource.PropKeyPolicyPrincipals] = principalProperties(iampolicy.AllowedPrincipals(doc, safeString(metadata.AWSAccountId)))
+		}
+	}
+
+	// AWS managed keys are rotated every year and cannot be tagged. Rotation is only supported
+	// by the symmetric keys with key material generated by KMS.
+	if metadata.KeyManager == kmsTypes.KeyManagerTypeAws {
+		res.Properties["rotation_enabled"] = true
+		return
+	}
+
+	if metadata.KeySpec == kmsTypes.KeySpecSymmetricDefault && metadata.Origin == kmsTypes.OriginTypeAwsKms &&
+		metadata.KeyState != kmsTypes.KeyStatePendingDeletion {
+		rotation, err := client.GetKeyRotationStatus(ctx, &kms.GetKeyRotationStatusInput{KeyId: keyID})
+		if err != nil {
+			warn("rotation status", err)
+		} else {
+			res.Properties["rotation_enabled"] = rotation.KeyRotationEnabled
+			if rotation.RotationPeriodInDays != nil {
+				res.Properties["rotation_period_days"] = *rotation.RotationPeriodInDays
+			}
+			if rotation.NextRotationDate != nil {
+				res.Properties["next_rotation_date"] = rotation.NextRotationDate.Format(time.RFC3339)
+			}
+		}
+	} else {
+		res.Properties["rotation_enabled"] = false
+	}
+
+	tags, err := client.ListResourceTags(ctx, &kms.ListResourceTagsInput{KeyId: keyID})
+	if err != nil {
+		warn("tags", err)
+	} else if len(tags.Tags) > 0 {
+		res.Tags = make(map[string]string, len(tags.Tags))
+		for _, tag := range tags.Tags {
+			if tag.TagKey != nil && tag.TagValue != nil {
+				res.Tags[*tag.TagKey] = *tag.TagValue
+			}
+		}
+	}
+}
+
+// convertKMSKeyToResource converts a KMS key to a Resource, named after its first alias
+func (p *Provider) convertKMSKeyToResource(metadata *kmsTypes.KeyMetadata, aliases []string, region string) *resource.Resource {
+	var account string
+	if len(p.accounts) > 0 {
+		account = p.accounts[0]
+	}
+
+	properties := map[string]interface{}{
+		"key_id":           safeString(metadata.KeyId),
+		"key_manager":      string(metadata.KeyManager),
+		resource.PropState: string(metadata.KeyState),
+		"enabled":          metadata.Enabled,
+		"key_usage":        string(metadata.KeyUsage),
+		"key_spec":         string(metadata.KeySpec),
+		"origin":           string(metadata.Origin),
+		"multi_region":     safeBool(metadata.MultiRegion),
+	}
+
+	if metadata.Description != nil && *metadata.Description != "" {
+		properties["description"] = *metadata.Description
+	}
+	if len(aliases) > 0 {
+		properties["aliases"] = aliases
+	}
+	if metadata.DeletionDate != nil {
+		properties["deletion_date"] = metadata.DeletionDate.Format(time.RFC3339)
+	}
+
+	name := safeString(metadata.KeyId)
+	if len(aliases) > 0 {
+		name = strings.TrimPrefix(aliases[0], "alias/")
+	}
+
+	res := &resource.Resource{
+		ID:         safeString(metadata.Arn),
+		Type:       resource.TypeAWSKMSKey,
+		Name:       name,
+		Provider:   "aws",
+		Account:    account,
+		Region:     region,
+		ARN:        safeString(metadata.Arn),
+		Properties: properties,
+		RawData:    metadata,
+	}
+
+	if metadata.CreationDate != nil {
+		res.CreatedAt = metadata.CreationDate
+	}
+
+	return res
+}
+
+// collectACMCertificates collects the ACM certificates of a region, with their expiry and the
+// resources using them
+func (p *Provider) collectACMCertificates(ctx context.Context, collection *resource.Collection, region string, cfg aws.Config) error {
+	fmt.Fprintf(os.Stderr, "  Collecting ACM certificates in %s...\n", region)
+	client := acm.NewFromConfig(cfg)
+
+	// Only RSA 2048 certificates are listed unless other key types are requested
+	paginator := acm.NewListCertificatesPaginator(client, &acm.ListCertificatesInput{
+		Includes: &acmTypes.Filters{KeyTypes: acmTypes.KeyAlgorithm("").Values()},
+	})
+
+	count := 0
+	for paginator.HasMorePages() {
+		output, err := paginator.NextPage(ctx)
+		if err != nil {
+			return fmt.Errorf("failed to list ACM certificates: %w", err)
+		}
+
+		for _, summary := range output.CertificateSummaryList {
+			described, err := client.DescribeCertificate(ctx, &acm.DescribeCertificateInput{CertificateArn: summary.CertificateArn})
+			if err != nil || described.Certificate == nil {
+				fmt.Fprintf(os.Stderr, "    Warning: failed to describe certificate %s: %v\n", safeString(summary.CertificateArn), err)
+				continue
+			}
+
+			res := p.convertACMCertificateToResource(described.Certificate, region)
+
+			// Tags are optional
+			tags, err := client.ListTagsForCertificate(ctx, &acm.ListTagsForCertificateInput{CertificateArn: summary.CertificateArn})
+			if err != nil {
+				fmt.Fprintf(os.Stderr, "    Warning: failed to list tags of certificate %s: %v\n", safeString(summary.DomainName), err)
+			} else if len(tags.Tags) > 0 {
+				res.Tags = make(map[string]string, len(tags.Tags))
+				for _, tag := range tags.Tags {
+					if tag.Key != nil {
+						res.Tags[*tag.Key] = safeString(tag.Value)
+					}
+				}
+			}
+
+			collection.Add(res)
+			count++
+			fmt.Fprintf(os.Stderr, "    Found certificate: %s (%s)\n", safeString(summary.DomainName), described.Certificate.Status)
+		}
+	}
+
+	fmt.Fprintf(os.Stderr, "  Collected %d ACM certificates in %s\n", count, region)
+	return nil
+}
+
+// convertACMCertificateToResource converts an ACM certificate to a Resource. The certificate is
+// attached to the load balancers, CloudFront distributions and other resources using it.
+func (p *Provider) convertACMCertificateToResource(cert *acmTypes.CertificateDetail, region string) *resource.Resource {
+	var account string
+	if len(p.accounts) > 0 {
+		account = p.accounts[0]
+	}
+
+	properties := map[string]interface{}{
+		"domain_name":         safeString(cert.DomainName),
+		resource.PropStatus:   string(cert.Status),
+		"certificate_type":    string(cert.Type),
+		"key_algorithm":       string(cert.KeyAlgorithm),
+		"in_use":              len(cert.InUseBy) > 0,
+		"renewal_eligibility": string(cert.RenewalEligibility),
+	}
+
+	if len(cert.SubjectAlternativeNames) > 0 {
+		properties["subject_alternative_names"] = cert.SubjectAlternativeNames
+	}
+	if cert.Issuer != nil {
+		properties["issuer"] = *cert.Issuer
+	}
+	if cert.NotBefore != nil {
+		properties["not_before"] = cert.NotBefore.Format(time.RFC3339)
+	}
+	if cert.NotAfter != nil {
+		properties["not_after"] = cert.NotAfter.Format(time.RFC3339)
+		properties["days_until_expiry"] = int(math.Floor(time.Until(*cert.NotAfter).Hours() / 24))
+	}
+	if len(cert.InUseBy) > 0 {
+		properties["in_use_by"] = cert.InUseBy
+	}
+
+	res := &resource.Resource{
+		ID:         safeString(cert.CertificateArn),
+		Type:       resource.TypeAWSACMCertificate,
+		Name:       safeString(cert.DomainName),
+		Provider:   "aws",
+		Account:    account,
+		Region:     region,
+		ARN:        safeString(cert.CertificateArn),
+		Properties: properties,
+		RawData:    cert,
+	}
+
+	if cert.CreatedAt != nil {
+		res.CreatedAt = cert.CreatedAt
+	} else if cert.ImportedAt != nil {
+		res.CreatedAt = cert.ImportedAt
+	}
+
+	// ARN targets are resolved to the ID of the collected load balancers and distributions by
+	// resolveARNTargets
+	for _, arn := range cert.InUseBy {
+		targetType := arnResourceType(arn)
+		if targetType == "" {
+			continue
+		}
+		res.Relationships = append(res.Relationships, resource.Relationship{
+			Type:       resource.RelationAttachedTo,
+			TargetID:   arn,
+			TargetType: targetType,
+		})
+	}
+
+	return res
+}
+
+// collectCloudTrails collects the CloudTrail trails whose home region is a region, with their
+// logging status
+func (p *Provider) collectCloudTrails(ctx context.Context, collection *resource.Collection, region string, cfg aws.Config) error {
+	fmt.Fprintf(os.Stderr, "  Collecting CloudTrail trails in %s...\n", region)
+	client := cloudtrail.NewFromConfig(cfg)
+
+	// Multi-region trails are only described in their home region
+	output, err := client.DescribeTrails(ctx, &cloudtrail.DescribeTrailsInput{
+		IncludeShadowTrails: aws.Bool(false),
+	})
+	if err != nil {
+		return fmt.Errorf("failed to describe CloudTrail trails: %w", err)
+	}
+
+	count := 0
+	for _, trail := range output.TrailList {
+		res := p.convertCloudTrailToResource(&trail, region)
+
+		// Status and tags are optional
+		status, err := client.GetTrailStatus(ctx, &cloudtrail.GetTrailStatusInput{Name: trail.TrailARN})
+		if err != nil {
+			fmt.Fprintf(os.Stderr, "    Warning: failed to get status of trail %s: %v\n", safeString(trail.Name), err)
+		} else {
+			res.Properties["is_logging"] = safeBool(status.IsLogging)
+			if status.LatestDeliveryTime != nil {
+				res.Properties["latest_delivery_time"] = status.LatestDeliveryTime.Format(time.RFC3339)
+			}
+			if status.LatestDeliveryError != nil && *status.LatestDeliveryError != "" {
+				res.Properties["latest_delivery_error"] = *status.LatestDeliveryError
+			}
+		}
+
+		tags, err := client.ListTags(ctx, &cloudtrail.ListTagsInput{ResourceIdList: []string{safeString(trail.TrailARN)}})
+		if err != nil {
+			fmt.Fprintf(os.Stderr, "    Warning: failed to list tags of trail %s: %v\n", safeString(trail.Name), err)
+		} else {
+			for _, tagList := range tags.ResourceTagList {
+				for _, tag := range tagList.TagsList {
+					if res.Tags == nil {
+						res.Tags = make(map[string]string)
+					}
+					res.Tags[safeString(tag.Key)] = safeString(tag.Value)
+				}
+			}
+		}
+
+		collection.Add(res)
+		count++
+		fmt.Fprintf(os.Stderr, "    Found trail: %s\n", safeString(trail.Name))
+	}
+
+	fmt.Fprintf(os.Stderr, "  Collected %d CloudTrail trails in %s\n", count, region)
+	return nil
+}
+
+// convertCloudTrailToResource converts a CloudTrail trail to a Resource. The trail references
+// the S3 bucket and SNS topic it delivers to, and assumes the role writing to CloudWatch Logs.
+func (p *Provider) convertCloudTrailToResource(trail *cloudtrailTypes.Trail, region string) *resource.Resource {
+	var account string
+	if len(p.accounts) > 0 {
+		account = p.accounts[0]
+	}
+
+	properties := map[string]interface{}{
+		"home_region":                   safeString(trail.HomeRegion),
+		"multi_region":                  safeBool(trail.IsMultiRegionTrail),
+		"organization_trail":            safeBool(trail.IsOrganizationTrail),
+		"include_global_service_events": safeBool(trail.IncludeGlobalServiceEvents),
+		"log_file_validation_enabled":   safeBool(trail.LogFileValidationEnabled),
+		"has_custom_event_selectors":    safeBool(trail.HasCustomEventSelectors),
+		resource.PropEncrypted:          trail.KmsKeyId != nil,
+	}
+
+	if trail.S3BucketName != nil {
+		properties["s3_bucket_name"] = *trail.S3BucketName
+	}
+	if trail.S3KeyPrefix != nil {
+		properties["s3_key_prefix"] = *trail.S3KeyPrefix
+	}
+	if trail.SnsTopicARN != nil {
+		properties["sns_topic_arn"] = *trail.SnsTopicARN
+	}
+	if trail.CloudWatchLogsLogGroupArn != nil {
+		properties["cloudwatch_logs_log_group_arn"] = *trail.CloudWatchLogsLogGroupArn
+	}
+	if trail.CloudWatchLogsRoleArn != nil {
+		properties["cloudwatch_logs_role_arn"] = *trail.CloudWatchLogsRoleArn
+	}
+	if trail.KmsKeyId != nil {
+		properties[resource.PropKMSKeyID] = *trail.KmsKeyId
+	}
+
+	res := &resource.Resource{
+		ID:         safeString(trail.TrailARN),
+		Type:       resource.TypeAWSCloudTrail,
+		Name:       safeString(trail.Name),
+		Provider:   "aws",
+		Account:    account,
+		Region:     region,
+		ARN:        safeString(trail.TrailARN),
+		Properties: properties,
+		RawData:    trail,
+	}
+
+	if trail.S3BucketName != nil {
+		res.Relationships = append(res.Relationships, resource.Relationship{
+			Type:       resource.RelationReferences,
+			TargetID:   *trail.S3BucketName,
+			TargetType: resource.TypeAWSS3Bucket,
+		})
+	}
+	if trail.SnsTopicARN != nil {
+		res.Relationships = append(res.Relationships, resource.Relationship{
+			Type:       resource.RelationReferences,
+			TargetID:   *trail.SnsTopicARN,
+			TargetType: resource.TypeAWSSNSTopic,
+		})
+	}
+	if trail.CloudWatchLogsRoleArn != nil {
+		res.Relationships = append(res.Relationships, resource.Relationship{
+			Type:       resource.RelationAssumes,
+			TargetID:   *trail.CloudWatchLogsRoleArn,
+			TargetType: resource.TypeAWSIAMRole,
+		})
+	}
+
+	return res
+}
+
+// collectWebACLs collects the WAF web ACLs of a scope: the regional web ACLs of a region, or the
+// CloudFront web ACLs, which are global and managed in us-east-1. Regional web ACLs are attached
+// to the load balancers and API Gateway stages they protect; CloudFront distributions reference
+// their web ACL, and are linked to it by discoverWebACLAssociations.
+func (p *Provider) collectWebACLs(ctx context.Context, collection *resource.Collection, region string, cfg aws.Config, scope wafv2Types.Scope) error {
+	location := region
+	if scope == wafv2Types.ScopeCloudfront {
+		location = "global"
+		cfg = cfg.Copy()
+		cfg.Region = "us-east-1"
+	}
+
+	fmt.Fprintf(os.Stderr, "  Collecting WAF web ACLs (%s)...\n", location)
+	client := wafv2.NewFromConfig(cfg)
+
+	var summaries []wafv2Types.WebACLSummary
+	input := &wafv2.ListWebACLsInput{Scope: scope}
+	for {
+		output, err := client.ListWebACLs(ctx, input)
+		if err != nil {
+			return fmt.Errorf("failed to list WAF web ACLs: %w", err)
+		}
+		summaries = append(summaries, output.WebACLs...)
+
+		if output.NextMarker == nil || *output.NextMarker == "" || len(output.WebACLs) == 0 {
+			break
+		}
+		input.NextMarker = output.NextMarker
+	}
+
+	count := 0
+	for _, summary := range summaries {
+		output, err := client.GetWebACL(ctx, &wafv2.GetWebACLInput{Id: summary.Id, Name: summary.Name, Scope: scope})
+		if err != nil || output.WebACL == nil {
+			fmt.Fprintf(os.Stderr, "    Warning: failed to get web ACL %s: %v\n", safeString(summary.Name), err)
+			continue
+		}
+
+		res := p.convertWebACLToResource(output.WebACL, location)
+
+		// Associations and tags are optional
+		if scope == wafv2Types.ScopeRegional {
+			for _, resourceType := range []wafv2Types.ResourceType{wafv2Types.ResourceTypeApplicationLoadBalancer, wafv2Types.ResourceTypeApiGateway} {
+				associated, err := client.ListResourcesForWebACL(ctx, &wafv2.ListResourcesForWebACLInput{
+					WebACLArn:    summary.ARN,
+					ResourceType: resourceType,
+				})
+				if err != nil {
+					fmt.Fprintf(os.Stderr, "    Warning: failed to list resources of web ACL %s: %v\n", safeString(summary.Name), err)
+					continue
+				}
+				res.Relationships = append(res.Relationships, webACLRelationships(associated.ResourceArns)...)
+			}
+		}
+
+		tags, err := client.ListTagsForResource(ctx, &wafv2.ListTagsForResourceInput{ResourceARN: summary.ARN})
+		if err != nil {
+			fmt.Fprintf(os.Stderr, "    Warning: failed to list tags of web ACL %s: %v\n", safeString(summary.Name), err)
+		} else if tags.TagInfoForResource != nil && len(tags.TagInfoForResource.TagList) > 0 {
+			res.Tags = make(map[string]string, len(tags.TagInfoForResource.TagList))
+			for _, tag := range tags.TagInfoForResource.TagList {
+				if tag.Key != nil {
+					res.Tags[*tag.Key] = safeString(tag.Value)
+				}
+			}
+		}
+
+		collection.Add(res)
+		count++
+		fmt.Fprintf(os.Stderr, "    Found web ACL: %s\n", safeString(summary.Name))
+	}
+
+	fmt.Fprintf(os.Stderr, "  Collected %d WAF web ACLs (%s)\n", count, location)
+	return nil
+}
+
+// convertWebACLToResource converts a WAF web ACL to a Resource
+func (p *Provider) convertWebACLToResource(acl *wafv2Types.WebACL, region string) *resource.Resource {
+	var account string
+	if len(p.accounts) > 0 {
+		account = p.accounts[0]
+	}
+
+	properties := map[string]interface{}{
+		"web_acl_id":                  safeString(acl.Id),
+		"capacity":                    acl.Capacity,
+		"managed_by_firewall_manager": acl.ManagedByFirewallManager,
+	}
+
+	if acl.Description != nil && *acl.Description != "" {
+		properties["description"] = *acl.Description
+	}
+	if acl.DefaultAction != nil {
+		if acl.DefaultAction.Block != nil {
+			properties["default_action"] = "BLOCK"
+		} else {
+			properties["default_action"] = "ALLOW"
+		}
+	}
+
+	if len(acl.Rules) > 0 {
+		rules := make([]map[string]interface{}, 0, len(acl.Rules))
+		for _, rule := range acl.Rules {
+			entry := map[string]interface{}{
+				"name":     safeString(rule.Name),
+				"priority": rule.Priority,
+			}
+			if rule.Statement != nil && rule.Statement.ManagedRuleGroupStatement != nil {
+				group := rule.Statement.ManagedRuleGroupStatement
+				entry["managed_rule_group"] = safeString(group.VendorName) + "/" + safeString(group.Name)
+			}
+			rules = append(rules, entry)
+		}
+		properties["rules"] = rules
+	}
+
+	return &resource.Resource{
+		ID:         safeString(acl.ARN),
+		Type:       resource.TypeAWSWAFWebACL,
+		Name:       safeString(acl.Name),
+		Provider:   "aws",
+		Account:    account,
+		Region:     region,
+		ARN:        safeString(acl.ARN),
+		Properties: properties,
+		RawData:    acl,
+	}
+}
+
+// webACLRelationships returns the attached_to relationships of a web ACL to the load balancers
+// and APIs it protects. API Gateway stages are linked to their API.
+func webACLRelationships(arns []string) []resource.Relationship {
+	relationships := make([]resource.Relationship, 0, len(arns))
+	for _, arn := range arns {
+		targetType := arnResourceType(arn)
+		if targetType == "" {
+			continue
+		}
+		relationships = append(relationships, resource.Relationship{
+			Type:       resource.RelationAttachedTo,
+			TargetID:   unqualifiedARN(arn),
+			TargetType: targetType,
+			Properties: map[string]interface{}{
+				"resource_arn": arn,
+			},
+		})
+	}
+	return relationships
+}
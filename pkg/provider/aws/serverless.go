@@ -0,0 +1,487 @@
+package aws
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"os"
+	"slices"
+	"strings"
+
+	"github.com/aws/aws-sdk-go-v2/aws"
+	"github.com/aws/aws-sdk-go-v2/service/eventbridge"
+	eventbridgeTypes "github.com/aws/aws-sdk-go-v2/service/eventbridge/types"
+	"github.com/aws/aws-sdk-go-v2/service/lambda"
+	"github.com/aws/aws-sdk-go-v2/service/sfn"
+	sfnTypes "github.com/aws/aws-sdk-go-v2/service/sfn/types"
+
+	"github.com/comfortablynumb/pmp-cloud-inspector/pkg/iampolicy"
+	"github.com/comfortablynumb/pmp-cloud-inspector/pkg/resource"
+)
+
+// collectStateMachines collects Step Functions state machines
+func (p *Provider) collectStateMachines(ctx context.Context, collection *resource.Collection, region string, cfg aws.Config) error {
+	fmt.Fprintf(os.Stderr, "  Collecting Step Functions state machines in %s...\n", region)
+	client := sfn.NewFromConfig(cfg)
+
+	paginator := sfn.NewListStateMachinesPaginator(client, &sfn.ListStateMachinesInput{})
+
+	count := 0
+	for paginator.HasMorePages() {
+		output, err := paginator.NextPage(ctx)
+		if err != nil {
+			return fmt.Errorf("failed to list state machines: %w", err)
+		}
+
+		for _, item := range output.StateMachines {
+			stateMachine, err := client.DescribeStateMachine(ctx, &sfn.DescribeStateMachineInput{
+				StateMachineArn: item.StateMachineArn,
+			})
+			if err != nil {
+				fmt.Fprintf(os.Stderr, "    Warning: failed to describe state machine %s: %v\n", safeString(item.Name), err)
+				continue
+			}
+
+			// Tags are optional
+			var tags map[string]string
+			tagsOutput, err := client.ListTagsForResource(ctx, &sfn.ListTagsForResourceInput{
+				ResourceArn: item.StateMachineArn,
+			})
+			if err != nil {
+				fmt.Fprintf(os.Stderr, "    Warning: failed to list tags of state machine %s: %v\n", safeString(item.Name), err)
+			} else {
+				tags = sfnTags(tagsOutput.Tags)
+			}
+
+			res := p.convertStateMachineToResource(stateMachine, tags, region)
+			collection.Add(res)
+			count++
+			fmt.Fprintf(os.Stderr, "    Found state machine: %s (%s)\n", safeString(item.Name), item.Type)
+		}
+	}
+
+	fmt.Fprintf(os.Stderr, "  Collected %d state machines in %s\n", count, region)
+	return nil
+}
+
+// collectEventBridge collects EventBridge event buses and their rules, keeping the requested
+// types
+func (p *Provider) collectEventBridge(ctx context.Context, collection *resource.Collection, region string, cfg aws.Config, typeSet map[resource.ResourceType]bool) error {
+	fmt.Fprintf(os.Stderr, "  Collecting EventBridge buses and rules in %s...\n", region)
+	client := eventbridge.NewFromConfig(cfg)
+
+	var buses []eventbridgeTypes.EventBus
+	input := &eventbridge.ListEventBusesInput{}
+	for {
+		output, err := client.ListEventBuses(ctx, input)
+		if err != nil {
+			return fmt.Errorf("failed to list event buses: %w", err)
+		}
+		buses = append(buses, output.EventBuses...)
+		if output.NextToken == nil {
+			break
+		}
+		input.NextToken = output.NextToken
+	}
+
+	busCount, ruleCount := 0, 0
+	for _, bus := range buses {
+		if typeSet[resource.TypeAWSEventBus] {
+			res := p.convertEventBusToResource(&bus, eventBridgeTags(ctx, client, bus.Arn), region)
+			collection.Add(res)
+			busCount++
+			fmt.Fprintf(os.Stderr, "    Found event bus: %s\n", safeString(bus.Name))
+		}
+
+		if !typeSet[resource.TypeAWSEventRule] {
+			continue
+		}
+
+		rules, err := listEventRules(ctx, client, safeString(bus.Name))
+		if err != nil {
+			fmt.Fprintf(os.Stderr, "    Warning: failed to list rules of event bus %s: %v\n", safeString(bus.Name), err)
+			continue
+		}
+
+		for _, rule := range rules {
+			targets, err := listEventRuleTargets(ctx, client, &rule)
+			if err != nil {
+				fmt.Fprintf(os.Stderr, "    Warning: failed to list targets of event rule %s: %v\n", safeString(rule.Name), err)
+			}
+
+			res := p.convertEventRuleToResource(&rule, targets, eventBridgeTags(ctx, client, rule.Arn), region)
+			collection.Add(res)
+			ruleCount++
+			fmt.Fprintf(os.Stderr, "    Found event rule: %s (%d targets)\n", safeString(rule.Name), len(targets))
+		}
+	}
+
+	fmt.Fprintf(os.Stderr, "  Collected %d event buses and %d rules in %s\n", busCount, ruleCount, region)
+	return nil
+}
+
+// listEventRules returns the rules of an event bus
+func listEventRules(ctx context.Context, client *eventbridge.Client, busName string) ([]eventbridgeTypes.Rule, error) {
+	var rules []eventbridgeTypes.Rule
+	input := &eventbridge.ListRulesInput{EventBusName: aws.String(busName)}
+	for {
+		output, err := client.ListRules(ctx, input)
+		if err != nil {
+			return rules, err
+		}
+		rules = append(rules, output.Rules...)
+		if output.NextToken == nil {
+			return rules, nil
+		}
+		input.NextToken = output.NextToken
+	}
+}
+
+// listEventRuleTargets returns the targets of an event rule
+func listEventRuleTargets(ctx context.Context, client *eventbridge.Client, rule *eventbridgeTypes.Rule) ([]eventbridgeTypes.Target, error) {
+	var targets []eventbridgeTypes.Target
+	input := &eventbridge.ListTargetsByRuleInput{Rule: rule.Name, EventBusName: rule.EventBusName}
+	for {
+		output, err := client.ListTargetsByRule(ctx, input)
+		if err != nil {
+			return targets, err
+		}
+		targets = append(targets, output.Targets...)
+		if output.NextToken == nil {
+			return targets, nil
+		}
+		input.NextToken = output.NextToken
+	}
+}
+
+// eventBridgeTags returns the tags of an event bus or rule. Tags are optional: failures are
+// reported as warnings.
+func eventBridgeTags(ctx context.Context, client *eventbridge.Client, arn *string) map[string]string {
+	output, err := client.ListTagsForResource(ctx, &eventbridge.ListTagsForResourceInput{ResourceARN: arn})
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "    Warning: failed to list tags of %s: %v\n", safeString(arn), err)
+		return nil
+	}
+	if len(output.Tags) == 0 {
+		return nil
+	}
+
+	tags := make(map[string]string, len(output.Tags))
+	for _, tag := range output.Tags {
+		if tag.Key != nil && tag.Value != nil {
+			tags[*tag.Key] = *tag.Value
+		}
+	}
+	return tags
+}
+
+// listEventSourceMappings returns the event source mappings of the Lambda functions of a region,
+// by unqualified function ARN
+func listEventSourceMappings(ctx context.Context, client *lambda.Client) (map[string][]map[string]interface{}, error) {
+	mappings := make(map[string][]map[string]interface{})
+
+	paginator := lambda.NewListEventSourceMappingsPaginator(client, &lambda.ListEventSourceMappingsInput{})
+	for paginator.HasMorePages() {
+		output, err := paginator.NextPage(ctx)
+		if err != nil {
+			return mappings, err
+		}
+
+		for _, mapping := range output.EventSourceMappings {
+			source := map[string]interface{}{
+				"uuid":             safeString(mapping.UUID),
+				"event_source_arn": safeString(mapping.EventSourceArn),
+				resource.PropState: safeString(mapping.State),
+				"batch_size":       safeInt32(mapping.BatchSize),
+			}
+			if mapping.StartingPosition != "" {
+				source["starting_position"] = string(mapping.StartingPosition)
+			}
+			if mapping.FunctionArn != nil && *mapping.FunctionArn != unqualifiedARN(*mapping.FunctionArn) {
+				// The mapping invokes a version or alias of the function
+				source["function_arn"] = *mapping.FunctionArn
+			}
+
+			functionARN := unqualifiedARN(safeString(mapping.FunctionArn))
+			mappings[functionARN] = append(mappings[functionARN], source)
+		}
+	}
+
+	return mappings, nil
+}
+
+// convertStateMachineToResource converts a Step Functions state machine to a Resource. The
+// Lambda functions, state machines, queues and topics invoked by its tasks are related with
+// triggers relationships.
+func (p *Provider) convertStateMachineToResource(stateMachine *sfn.DescribeStateMachineOutput, tags map[string]string, region string) *resource.Resource {
+	var account string
+	if len(p.accounts) > 0 {
+		account = p.accounts[0]
+	}
+
+	properties := map[string]interface{}{
+		resource.PropStatus: string(stateMachine.Status),
+		"type":              string(stateMachine.Type),
+		"role_arn":          safeString(stateMachine.RoleArn),
+	}
+
+	if stateMachine.Description != nil {
+		properties["description"] = *stateMachine.Description
+	}
+	if logging := stateMachine.LoggingConfiguration; logging != nil {
+		properties["logging_level"] = string(logging.Level)
+	}
+	if stateMachine.TracingConfiguration != nil {
+		properties["tracing_enabled"] = stateMachine.TracingConfiguration.Enabled
+	}
+	if encryption := stateMachine.EncryptionConfiguration; encryption != nil {
+		properties["encryption_type"] = string(encryption.Type)
+		if encryption.KmsKeyId != nil {
+			properties[resource.PropKMSKeyID] = *encryption.KmsKeyId
+		}
+	}
+
+	invoked := stateMachineTargets(safeString(stateMachine.Definition))
+	if len(invoked) > 0 {
+		properties["invoked_resources"] = invoked
+	}
+
+	res := &resource.Resource{
+		ID:         safeString(stateMachine.StateMachineArn),
+		Type:       resource.TypeAWSStateMachine,
+		Name:       safeString(stateMachine.Name),
+		Provider:   "aws",
+		Account:    account,
+		Region:     region,
+		ARN:        safeString(stateMachine.StateMachineArn),
+		Tags:       tags,
+		Properties: properties,
+		RawData:    stateMachine,
+		CreatedAt:  stateMachine.CreationDate,
+	}
+
+	if stateMachine.RoleArn != nil {
+		res.Relationships = append(res.Relationships, resource.Relationship{
+			Type:       resource.RelationAssumes,
+			TargetID:   *stateMachine.RoleArn,
+			TargetType: resource.TypeAWSIAMRole,
+		})
+	}
+	for _, target := range invoked {
+		if targetType := arnResourceType(target); targetType != "" {
+			res.Relationships = append(res.Relationships, resource.Relationship{
+				Type:       resource.RelationTriggers,
+				TargetID:   target,
+				TargetType: targetType,
+			})
+		}
+	}
+
+	return res
+}
+
+// stateMachineTargets returns the ARNs of the resources invoked by the tasks of a state machine
+// definition: the resource of tasks invoking a Lambda function directly, and the function, state
+// machine, queue or topic passed as parameters to service integrations. Values set from the
+// state input (e.g., FunctionName.$) are not known.
+func stateMachineTargets(definition string) []string {
+	var document interface{}
+	if err := json.Unmarshal([]byte(definition), &document); err != nil {
+		return nil
+	}
+
+	var targets []string
+	add := func(value interface{}) {
+		arn, ok := value.(string)
+		if !ok || !strings.HasPrefix(arn, "arn:") {
+			return
+		}
+		// Service integrations (e.g., arn:aws:states:::lambda:invoke) have no account
+		if parts := strings.SplitN(arn, ":", 6); len(parts) == 6 && parts[4] == "" {
+			return
+		}
+		arn = unqualifiedARN(arn)
+		if !slices.Contains(targets, arn) {
+			targets = append(targets, arn)
+		}
+	}
+
+	var walk func(node interface{})
+	walk = func(node interface{}) {
+		switch value := node.(type) {
+		case map[string]interface{}:
+			for key, child := range value {
+				switch key {
+				case "Resource", "FunctionName", "StateMachineArn", "TopicArn":
+					add(child)
+				}
+				walk(child)
+			}
+		case []interface{}:
+			for _, child := range value {
+				walk(child)
+			}
+		}
+	}
+	walk(document)
+
+	slices.Sort(targets)
+	return targets
+}
+
+// convertEventBusToResource converts an EventBridge event bus to a Resource
+func (p *Provider) convertEventBusToResource(bus *eventbridgeTypes.EventBus, tags map[string]string, region string) *resource.Resource {
+	var account string
+	if len(p.accounts) > 0 {
+		account = p.accounts[0]
+	}
+
+	properties := map[string]interface{}{}
+	if bus.Description != nil {
+		properties["description"] = *bus.Description
+	}
+	if bus.Policy != nil {
+		if document, err := iampolicy.ToProperty(*bus.Policy); err == nil {
+			properties[resource.PropPolicyDocument] = document
+		} else {
+			fmt.Fprintf(os.Stderr, "    Warning: failed to parse the policy of event bus %s: %v\n", safeString(bus.Name), err)
+		}
+	}
+
+	return &resource.Resource{
+		ID:         safeString(bus.Arn),
+		Type:       resource.TypeAWSEventBus,
+		Name:       safeString(bus.Name),
+		Provider:   "aws",
+		Account:    account,
+		Region:     region,
+		ARN:        safeString(bus.Arn),
+		Tags:       tags,
+		Properties: properties,
+		RawData:    bus,
+		CreatedAt:  bus.CreationTime,
+	}
+}
+
+// convertEventRuleToResource converts an EventBridge rule to a Resource, with triggers
+// relationships to its targets
+func (p *Provider) convertEventRuleToResource(rule *eventbridgeTypes.Rule, targets []eventbridgeTypes.Target, tags map[string]string, region string) *resource.Resource {
+	var account string
+	if len(p.accounts) > 0 {
+		account = p.accounts[0]
+	}
+
+	busName := safeString(rule.EventBusName)
+	properties := map[string]interface{}{
+		resource.PropState: string(rule.State),
+		"event_bus_name":   busName,
+	}
+
+	if rule.Description != nil {
+		properties["description"] = *rule.Description
+	}
+	if rule.EventPattern != nil {
+		properties["event_pattern"] = *rule.EventPattern
+	}
+	if rule.ScheduleExpression != nil {
+		properties["schedule_expression"] = *rule.ScheduleExpression
+	}
+	if rule.ManagedBy != nil {
+		properties["managed_by"] = *rule.ManagedBy
+	}
+
+	var relationships []resource.Relationship
+	roleARNs := make([]string, 0, len(targets)+1)
+	if rule.RoleArn != nil {
+		roleARNs = append(roleARNs, *rule.RoleArn)
+	}
+
+	targetList := make([]map[string]interface{}, 0, len(targets))
+	for _, target := range targets {
+		targetARN := safeString(target.Arn)
+		item := map[string]interface{}{
+			"id":  safeString(target.Id),
+			"arn": targetARN,
+		}
+		if target.RoleArn != nil {
+			item["role_arn"] = *target.RoleArn
+			if !slices.Contains(roleARNs, *target.RoleArn) {
+				roleARNs = append(roleARNs, *target.RoleArn)
+			}
+		}
+		if target.DeadLetterConfig != nil && target.DeadLetterConfig.Arn != nil {
+			item["dead_letter_arn"] = *target.DeadLetterConfig.Arn
+		}
+		targetList = append(targetList, item)
+
+		if targetType := arnResourceType(targetARN); targetType != "" {
+			relationships = append(relationships, resource.Relationship{
+				Type:       resource.RelationTriggers,
+				TargetID:   targetARN,
+				TargetType: targetType,
+				Properties: map[string]interface{}{
+					"target_id": safeString(target.Id),
+				},
+			})
+		}
+
+		// ECS targets run a task definition in the target cluster
+		if target.EcsParameters != nil && target.EcsParameters.TaskDefinitionArn != nil {
+			item["task_definition_arn"] = *target.EcsParameters.TaskDefinitionArn
+			relationships = append(relationships, resource.Relationship{
+				Type:       resource.RelationTriggers,
+				TargetID:   *target.EcsParameters.TaskDefinitionArn,
+				TargetType: resource.TypeAWSECSTaskDefinition,
+				Properties: map[string]interface{}{
+					"target_id": safeString(target.Id),
+				},
+			})
+		}
+	}
+	properties["targets"] = targetList
+
+	// Rules of the default bus have no bus in their ARN, which is built from the bus name
+	busARN := fmt.Sprintf("arn:aws:events:%s:%s:event-bus/%s", region, account, busName)
+	if busName != "" {
+		relationships = append([]resource.Relationship{{
+			Type:       resource.RelationBelongsTo,
+			TargetID:   busARN,
+			TargetType: resource.TypeAWSEventBus,
+		}}, relationships...)
+	}
+	for _, roleARN := range roleARNs {
+		relationships = append(relationships, resource.Relationship{
+			Type:       resource.RelationAssumes,
+			TargetID:   roleARN,
+			TargetType: resource.TypeAWSIAMRole,
+		})
+	}
+
+	return &resource.Resource{
+		ID:            safeString(rule.Arn),
+		Type:          resource.TypeAWSEventRule,
+		Name:          safeString(rule.Name),
+		Provider:      "aws",
+		Account:       account,
+		Region:        region,
+		ARN:           safeString(rule.Arn),
+		Tags:          tags,
+		Properties:    properties,
+		RawData:       rule,
+		Relationships: relationships,
+	}
+}
+
+// sfnTags converts Step Functions tags to a map
+func sfnTags(sfnTags []sfnTypes.Tag) map[string]string {
+	if len(sfnTags) == 0 {
+		return nil
+	}
+	tags := make(map[string]string, len(sfnTags))
+	for _, tag := range sfnTags {
+		if tag.Key != nil && tag.Value != nil {
+			tags[*tag.Key] = *tag.Value
+		}
+	}
+	return tags
+}
@@ -0,0 +1,639 @@
+package aws
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"os"
+	"slices"
+
+	"github.com/aws/aws-sdk-go-v2/aws"
+	"github.com/aws/aws-sdk-go-v2/service/ec2"
+	ec2Types "github.com/aws/aws-sdk-go-v2/service/ec2/types"
+	"github.com/aws/aws-sdk-go-v2/service/efs"
+	efsTypes "github.com/aws/aws-sdk-go-v2/service/efs/types"
+	"github.com/aws/aws-sdk-go-v2/service/s3"
+	s3Types "github.com/aws/aws-sdk-go-v2/service/s3/types"
+	"github.com/aws/smithy-go"
+
+	"github.com/comfortablynumb/pmp-cloud-inspector/pkg/resource"
+)
+
+// collectS3Buckets collects all S3 buckets of the account, whatever their region. The
+// configuration of each bucket is read from its own region.
+func (p *Provider) collectS3Buckets(ctx context.Context, collection *resource.Collection, cfg aws.Config) error {
+	fmt.Fprintf(os.Stderr, "  Collecting S3 buckets...\n")
+
+	listConfig := cfg.Copy()
+	if listConfig.Region == "" {
+		listConfig.Region = "us-east-1"
+	}
+	client := s3.NewFromConfig(listConfig)
+
+	// Clients by bucket region
+	clients := map[string]*s3.Client{listConfig.Region: client}
+	clientFor := func(region string) *s3.Client {
+		if c, ok := clients[region]; ok {
+			return c
+		}
+		regionalConfig := cfg.Copy()
+		regionalConfig.Region = region
+		clients[region] = s3.NewFromConfig(regionalConfig)
+		return clients[region]
+	}
+
+	paginator := s3.NewListBucketsPaginator(client, &s3.ListBucketsInput{})
+
+	count := 0
+	for paginator.HasMorePages() {
+		output, err := paginator.NextPage(ctx)
+		if err != nil {
+			return fmt.Errorf("failed to list S3 buckets: %w", err)
+		}
+
+		for _, bucket := range output.Buckets {
+			name := safeString(bucket.Name)
+			region := safeString(bucket.BucketRegion)
+			if region == "" {
+				region, err = s3BucketRegion(ctx, client, name)
+				if err != nil {
+					fmt.Fprintf(os.Stderr, "    Warning: failed to get the region of bucket %s: %v\n", name, err)
+					continue
+				}
+			}
+
+			res := p.convertS3BucketToResource(&bucket, region)
+			p.describeS3Bucket(ctx, clientFor(region), res)
+			collection.Add(res)
+			count++
+			fmt.Fprintf(os.Stderr, "    Found S3 bucket: %s (%s)\n", name, region)
+		}
+	}
+
+	fmt.Fprintf(os.Stderr, "  Collected %d S3 buckets\n", count)
+	return nil
+}
+
+// s3BucketRegion returns the region of a bucket; buckets without location constraint are in
+// us-east-1
+func s3BucketRegion(ctx context.Context, client *s3.Client, name string) (string, error) {
+	output, err := client.GetBucketLocation(ctx, &s3.GetBucketLocationInput{Bucket: &name})
+	if err != nil {
+		return "", err
+	}
+	if output.LocationConstraint == "" {
+		return "us-east-1", nil
+	}
+	return string(output.LocationConstraint), nil
+}
+
+// describeS3Bucket adds the versioning, encryption, public access and lifecycle configuration
+// of a bucket, and its tags, to its resource. Configurations that can't be read are left out
+// with a warning.
+func (p *Provider) describeS3Bucket(ctx context.Context, client *s3.Client, res *resource.Resource) {
+	name := res.ID
+	warn := func(what string, err error) {
+		fmt.Fprintf(os.Stderr, "    Warning: failed to get %s of bucket %s: %v\n", what, name, err)
+	}
+
+	if versioning, err := client.GetBucketVersioning(ctx, &s3.GetBucketVersioningInput{Bucket: &name}); err != nil {
+		warn("versioning", err)
+	} else {
+		// Versioning was never enabled when there is no status
+		status := string(versioning.Status)
+		if status == "" {
+			status = "Disabled"
+		}
+		res.Properties["versioning"] = status
+		res.Properties["mfa_delete"] = versioning.MFADelete == s3Types.MFADeleteStatusEnabled
+	}
+
+	encryption, err := client.GetBucketEncryption(ctx, &s3.GetBucketEncryptionInput{Bucket: &name})
+	switch {
+	case isAPIError(err, "ServerSideEncryptionConfigurationNotFoundError"):
+		res.Properties[resource.PropEncrypted] = false
+	case err != nil:
+		warn("encryption", err)
+	default:
+		res.Properties[resource.PropEncrypted] = false
+		if encryption.ServerSideEncryptionConfiguration != nil {
+			for _, rule := range encryption.ServerSideEncryptionConfiguration.Rules {
+				if rule.ApplyServerSideEncryptionByDefault == nil {
+					continue
+				}
+				res.Properties[resource.PropEncrypted] = true
+				res.Properties["encryption"] = string(rule.ApplyServerSideEncryptionByDefault.SSEAlgorithm)
+				if rule.ApplyServerSideEncryptionByDefault.KMSMasterKeyID != nil {
+					res.Properties[resource.PropKMSKeyID] = *rule.ApplyServerSideEncryptionByDefault.KMSMasterKeyID
+				}
+				res.Properties["bucket_key_enabled"] = safeBool(rule.BucketKeyEnabled)
+				break
+			}
+		}
+	}
+
+	block, err := client.GetPublicAccessBlock(ctx, &s3.GetPublicAccessBlockInput{Bucket: &name})
+	switch {
+	case isAPIError(err, "NoSuchPublicAccessBlockConfiguration"):
+		res.Properties["public_access_blocked"] = false
+	case err != nil:
+		warn("public access block", err)
+	case block.PublicAccessBlockConfiguration != nil:
+		config := block.PublicAccessBlockConfiguration
+		res.Properties["public_access_block"] = map[string]interface{}{
+			"block_public_acls":       safeBool(config.BlockPublicAcls),
+			"ignore_public_acls":      safeBool(config.IgnorePublicAcls),
+			"block_public_policy":     safeBool(config.BlockPublicPolicy),
+			"restrict_public_buckets": safeBool(config.RestrictPublicBuckets),
+		}
+		res.Properties["public_access_blocked"] = safeBool(config.BlockPublicAcls) && safeBool(config.IgnorePublicAcls) &&
+			safeBool(config.BlockPublicPolicy) && safeBool(config.RestrictPublicBuckets)
+	}
+
+	status, err := client.GetBucketPolicyStatus(ctx, &s3.GetBucketPolicyStatusInput{Bucket: &name})
+	switch {
+	case isAPIError(err, "NoSuchBucketPolicy"):
+		res.Properties["has_policy"] = false
+		res.Properties["policy_public"] = false
+	case err != nil:
+		warn("policy status", err)
+	default:
+		res.Properties["has_policy"] = true
+		res.Properties["policy_public"] = status.PolicyStatus != nil && safeBool(status.PolicyStatus.IsPublic)
+	}
+
+	lifecycle, err := client.GetBucketLifecycleConfiguration(ctx, &s3.GetBucketLifecycleConfigurationInput{Bucket: &name})
+	switch {
+	case isAPIError(err, "NoSuchLifecycleConfiguration"):
+		res.Properties["lifecycle_rules"] = []map[string]interface{}{}
+	case err != nil:
+		warn("lifecycle configuration", err)
+	default:
+		res.Properties["lifecycle_rules"] = convertLifecycleRules(lifecycle.Rules)
+	}
+
+	tagging, err := client.GetBucketTagging(ctx, &s3.GetBucketTaggingInput{Bucket: &name})
+	switch {
+	case isAPIError(err, "NoSuchTagSet"):
+	case err != nil:
+		warn("tags", err)
+	case len(tagging.TagSet) > 0:
+		res.Tags = make(map[string]string, len(tagging.TagSet))
+		for _, tag := range tagging.TagSet {
+			if tag.Key != nil && tag.Value != nil {
+				res.Tags[*tag.Key] = *tag.Value
+			}
+		}
+	}
+}
+
+// convertLifecycleRules converts the lifecycle rules of a bucket, with the storage classes
+// objects transition to and the days after which they expire
+func convertLifecycleRules(rules []s3Types.LifecycleRule) []map[string]interface{} {
+	converted := make([]map[string]interface{}, 0, len(rules))
+	for _, rule := range rules {
+		entry := map[string]interface{}{
+			"id":     safeString(rule.ID),
+			"status": string(rule.Status),
+		}
+
+		transitions := make([]string, 0, len(rule.Transitions))
+		for _, transition := range rule.Transitions {
+			transitions = append(transitions, string(transition.StorageClass))
+		}
+		if len(transitions) > 0 {
+			entry["transitions"] = transitions
+		}
+		if rule.Expiration != nil && rule.Expiration.Days != nil {
+			entry["expiration_days"] = *rule.Expiration.Days
+		}
+		if rule.NoncurrentVersionExpiration != nil && rule.NoncurrentVersionExpiration.NoncurrentDays != nil {
+			entry["noncurrent_expiration_days"] = *rule.NoncurrentVersionExpiration.NoncurrentDays
+		}
+
+		converted = append(converted, entry)
+	}
+	return converted
+}
+
+// isAPIError reports whether an error is an AWS API error with one of the codes
+func isAPIError(err error, codes ...string) bool {
+	var apiErr smithy.APIError
+	return errors.As(err, &apiErr) && slices.Contains(codes, apiErr.ErrorCode())
+}
+
+// collectEBSVolumes collects all EBS volumes in a region
+func (p *Provider) collectEBSVolumes(ctx context.Context, collection *resource.Collection, region string, cfg aws.Config) error {
+	fmt.Fprintf(os.Stderr, "  Collecting EBS volumes in %s...\n", region)
+	client := ec2.NewFromConfig(cfg)
+
+	paginator := ec2.NewDescribeVolumesPaginator(client, &ec2.DescribeVolumesInput{})
+
+	count := 0
+	for paginator.HasMorePages() {
+		output, err := paginator.NextPage(ctx)
+		if err != nil {
+			return fmt.Errorf("failed to describe volumes: %w", err)
+		}
+
+		for _, volume := range output.Volumes {
+			res := p.convertEBSVolumeToResource(&volume, region)
+			collection.Add(res)
+			count++
+			fmt.Fprintf(os.Stderr, "    Found EBS volume: %s (%s)\n", safeString(volume.VolumeId), volume.State)
+		}
+	}
+
+	fmt.Fprintf(os.Stderr, "  Collected %d EBS volumes in %s\n", count, region)
+	return nil
+}
+
+// collectEBSSnapshots collects the EBS snapshots owned by the account in a region
+func (p *Provider) collectEBSSnapshots(ctx context.Context, collection *resource.Collection, region string, cfg aws.Config) error {
+	fmt.Fprintf(os.Stderr, "  Collecting EBS snapshots in %s...\n", region)
+	client := ec2.NewFromConfig(cfg)
+
+	paginator := ec2.NewDescribeSnapshotsPaginator(client, &ec2.DescribeSnapshotsInput{
+		OwnerIds: []string{"self"},
+	})
+
+	count := 0
+	for paginator.HasMorePages() {
+		output, err := paginator.NextPage(ctx)
+		if err != nil {
+			return fmt.Errorf("failed to describe snapshots: %w", err)
+		}
+
+		for _, snapshot := range output.Snapshots {
+			res := p.convertEBSSnapshotToResource(&snapshot, region)
+			collection.Add(res)
+			count++
+		}
+	}
+
+	fmt.Fprintf(os.Stderr, "  Collected %d EBS snapshots in %s\n", count, region)
+	return nil
+}
+
+// collectEFSFileSystems collects all EFS file systems in a region, with their mount targets
+func (p *Provider) collectEFSFileSystems(ctx context.Context, collection *resource.Collection, region string, cfg aws.Config) error {
+	fmt.Fprintf(os.Stderr, "  Collecting EFS file systems in %s...\n", region)
+	client := efs.NewFromConfig(cfg)
+
+	paginator := efs.NewDescribeFileSystemsPaginator(client, &efs.DescribeFileSystemsInput{})
+
+	count := 0
+	for paginator.HasMorePages() {
+		output, err := paginator.NextPage(ctx)
+		if err != nil {
+			return fmt.Errorf("failed to describe EFS file systems: %w", err)
+		}
+
+		for _, fileSystem := range output.FileSystems {
+			mountTargets, err := describeMountTargets(ctx, client, safeString(fileSystem.FileSystemId))
+			if err != nil {
+				fmt.Fprintf(os.Stderr, "    Warning: failed to describe mount targets of %s: %v\n", safeString(fileSystem.FileSystemId), err)
+			}
+
+			res := p.convertEFSFileSystemToResource(&fileSystem, mountTargets, region)
+			collection.Add(res)
+			count++
+			fmt.Fprintf(os.Stderr, "    Found EFS file system: %s (%s)\n", res.Name, fileSystem.LifeCycleState)
+		}
+	}
+
+	fmt.Fprintf(os.Stderr, "  Collected %d EFS file systems in %s\n", count, region)
+	return nil
+}
+
+// describeMountTargets returns the mount targets of a file system, with their security groups
+func describeMountTargets(ctx context.Context, client *efs.Client, fileSystemID string) ([]map[string]interface{}, error) {
+	var mountTargets []map[string]interface{}
+
+	paginator := efs.NewDescribeMountTargetsPaginator(client, &efs.DescribeMountTargetsInput{FileSystemId: &fileSystemID})
+	for paginator.HasMorePages() {
+		output, err := paginator.NextPage(ctx)
+		if err != nil {
+			return mountTargets, err
+		}
+
+		for _, target := range output.MountTargets {
+			mountTarget := convertMountTarget(target)
+
+			groups, err := client.DescribeMountTargetSecurityGroups(ctx, &efs.DescribeMountTargetSecurityGroupsInput{
+				MountTargetId: target.MountTargetId,
+			})
+			if err != nil {
+				return mountTargets, err
+			}
+			mountTarget[resource.PropSecurityGroups] = groups.SecurityGroups
+
+			mountTargets = append(mountTargets, mountTarget)
+		}
+	}
+
+	return mountTargets, nil
+}
+
+// convertMountTarget converts an EFS mount target to the properties stored on its file system
+func convertMountTarget(target efsTypes.MountTargetDescription) map[string]interface{} {
+	mountTarget := map[string]interface{}{
+		"mount_target_id":   safeString(target.MountTargetId),
+		"subnet_id":         safeString(target.SubnetId),
+		"vpc_id":            safeString(target.VpcId),
+		"availability_zone": safeString(target.AvailabilityZoneName),
+		resource.PropState:  string(target.LifeCycleState),
+	}
+	if target.IpAddress != nil {
+		mountTarget["ip_address"] = *target.IpAddress
+	}
+	if target.NetworkInterfaceId != nil {
+		mountTarget["network_interface_id"] = *target.NetworkInterfaceId
+	}
+	return mountTarget
+}
+
+// convertS3BucketToResource converts an S3 bucket to a Resource, without its configuration
+func (p *Provider) convertS3BucketToResource(bucket *s3Types.Bucket, region string) *resource.Resource {
+	var account string
+	if len(p.accounts) > 0 {
+		account = p.accounts[0]
+	}
+
+	name := safeString(bucket.Name)
+
+	arn := safeString(bucket.BucketArn)
+	if arn == "" {
+		arn = "arn:aws:s3:::" + name
+	}
+
+	return &resource.Resource{
+		ID:         name,
+		Type:       resource.TypeAWSS3Bucket,
+		Name:       name,
+		Provider:   "aws",
+		Account:    account,
+		Region:     region,
+		ARN:        arn,
+		Properties: map[string]interface{}{},
+		RawData:    bucket,
+		CreatedAt:  bucket.CreationDate,
+	}
+}
+
+// convertEBSVolumeToResource converts an EBS volume to a Resource
+func (p *Provider) convertEBSVolumeToResource(volume *ec2Types.Volume, region string) *resource.Resource {
+	var account string
+	if len(p.accounts) > 0 {
+		account = p.accounts[0]
+	}
+
+	properties := map[string]interface{}{
+		resource.PropState:        string(volume.State),
+		resource.PropVolumeType:   string(volume.VolumeType),
+		resource.PropVolumeSizeGB: safeInt32(volume.Size),
+		resource.PropEncrypted:    safeBool(volume.Encrypted),
+		"availability_zone":       safeString(volume.AvailabilityZone),
+		"multi_attach_enabled":    safeBool(volume.MultiAttachEnabled),
+	}
+
+	if volume.Iops != nil {
+		properties["iops"] = *volume.Iops
+	}
+	if volume.Throughput != nil {
+		properties["throughput"] = *volume.Throughput
+	}
+	if volume.KmsKeyId != nil {
+		properties[resource.PropKMSKeyID] = *volume.KmsKeyId
+	}
+	if volume.SnapshotId != nil && *volume.SnapshotId != "" {
+		properties["snapshot_id"] = *volume.SnapshotId
+	}
+
+	attachments := make([]map[string]interface{}, 0, len(volume.Attachments))
+	for _, attachment := range volume.Attachments {
+		attachments = append(attachments, map[string]interface{}{
+			"instance_id":           safeString(attachment.InstanceId),
+			"device":                safeString(attachment.Device),
+			resource.PropState:      string(attachment.State),
+			"delete_on_termination": safeBool(attachment.DeleteOnTermination),
+		})
+	}
+	if len(attachments) > 0 {
+		properties["attachments"] = attachments
+	}
+
+	tags, name := ec2Tags(volume.Tags)
+	if name == "" {
+		name = safeString(volume.VolumeId)
+	}
+
+	arn := fmt.Sprintf("arn:aws:ec2:%s:%s:volume/%s", region, account, safeString(volume.VolumeId))
+
+	res := &resource.Resource{
+		ID:         safeString(volume.VolumeId),
+		Type:       resource.TypeAWSEBSVolume,
+		Name:       name,
+		Provider:   "aws",
+		Account:    account,
+		Region:     region,
+		ARN:        arn,
+		Tags:       tags,
+		Properties: properties,
+		RawData:    volume,
+		CreatedAt:  volume.CreateTime,
+	}
+
+	// Add relationships to the instances the volume is attached to
+	for _, attachment := range volume.Attachments {
+		if attachment.InstanceId == nil {
+			continue
+		}
+		res.Relationships = append(res.Relationships, resource.Relationship{
+			Type:       resource.RelationAttachedTo,
+			TargetID:   *attachment.InstanceId,
+			TargetType: resource.TypeAWSEC2Instance,
+			Properties: map[string]interface{}{
+				"device": safeString(attachment.Device),
+			},
+		})
+	}
+
+	return res
+}
+
+// convertEBSSnapshotToResource converts an EBS snapshot to a Resource
+func (p *Provider) convertEBSSnapshotToResource(snapshot *ec2Types.Snapshot, region string) *resource.Resource {
+	var account string
+	if len(p.accounts) > 0 {
+		account = p.accounts[0]
+	}
+
+	properties := map[string]interface{}{
+		resource.PropState:        string(snapshot.State),
+		resource.PropVolumeSizeGB: safeInt32(snapshot.VolumeSize),
+		resource.PropEncrypted:    safeBool(snapshot.Encrypted),
+		resource.PropStorageClass: string(snapshot.StorageTier),
+	}
+
+	if snapshot.VolumeId != nil {
+		properties[resource.PropVolumeID] = *snapshot.VolumeId
+	}
+	if snapshot.FullSnapshotSizeInBytes != nil {
+		properties[resource.PropSizeBytes] = *snapshot.FullSnapshotSizeInBytes
+	}
+	if snapshot.Description != nil && *snapshot.Description != "" {
+		properties["description"] = *snapshot.Description
+	}
+	if snapshot.KmsKeyId != nil {
+		properties[resource.PropKMSKeyID] = *snapshot.KmsKeyId
+	}
+
+	tags, name := ec2Tags(snapshot.Tags)
+	if name == "" {
+		name = safeString(snapshot.SnapshotId)
+	}
+
+	arn := fmt.Sprintf("arn:aws:ec2:%s::snapshot/%s", region, safeString(snapshot.SnapshotId))
+
+	res := &resource.Resource{
+		ID:         safeString(snapshot.SnapshotId),
+		Type:       resource.TypeAWSEBSSnapshot,
+		Name:       name,
+		Provider:   "aws",
+		Account:    account,
+		Region:     region,
+		ARN:        arn,
+		Tags:       tags,
+		Properties: properties,
+		RawData:    snapshot,
+		CreatedAt:  snapshot.StartTime,
+	}
+
+	// Snapshots of volumes copied from other snapshots reference a placeholder volume
+	if snapshot.VolumeId != nil && *snapshot.VolumeId != "vol-ffffffff" {
+		res.Relationships = append(res.Relationships, resource.Relationship{
+			Type:       resource.RelationReferences,
+			TargetID:   *snapshot.VolumeId,
+			TargetType: resource.TypeAWSEBSVolume,
+		})
+	}
+
+	return res
+}
+
+// convertEFSFileSystemToResource converts an EFS file system and its mount targets to a Resource
+func (p *Provider) convertEFSFileSystemToResource(fileSystem *efsTypes.FileSystemDescription, mountTargets []map[string]interface{}, region string) *resource.Resource {
+	var account string
+	if len(p.accounts) > 0 {
+		account = p.accounts[0]
+	}
+
+	properties := map[string]interface{}{
+		resource.PropState:     string(fileSystem.LifeCycleState),
+		resource.PropEncrypted: safeBool(fileSystem.Encrypted),
+		"performance_mode":     string(fileSystem.PerformanceMode),
+		"throughput_mode":      string(fileSystem.ThroughputMode),
+		"mount_target_count":   fileSystem.NumberOfMountTargets,
+	}
+
+	if size := fileSystem.SizeInBytes; size != nil {
+		properties[resource.PropSizeBytes] = size.Value
+		if size.ValueInIA != nil {
+			properties[resource.PropSizeIABytes] = *size.ValueInIA
+		}
+		if size.ValueInArchive != nil {
+			properties[resource.PropSizeArchiveBytes] = *size.ValueInArchive
+		}
+	}
+	if fileSystem.KmsKeyId != nil {
+		properties[resource.PropKMSKeyID] = *fileSystem.KmsKeyId
+	}
+	if fileSystem.ProvisionedThroughputInMibps != nil {
+		properties["provisioned_throughput_mibps"] = *fileSystem.ProvisionedThroughputInMibps
+	}
+	if fileSystem.AvailabilityZoneName != nil {
+		// One Zone file system
+		properties["availability_zone"] = *fileSystem.AvailabilityZoneName
+	}
+
+	var vpcID string
+	var subnetIDs, securityGroupIDs []string
+	for _, target := range mountTargets {
+		if id, _ := target["vpc_id"].(string); id != "" {
+			vpcID = id
+		}
+		if id, _ := target["subnet_id"].(string); id != "" {
+			subnetIDs = append(subnetIDs, id)
+		}
+		groups, _ := target[resource.PropSecurityGroups].([]string)
+		for _, groupID := range groups {
+			if !slices.Contains(securityGroupIDs, groupID) {
+				securityGroupIDs = append(securityGroupIDs, groupID)
+			}
+		}
+	}
+	if len(mountTargets) > 0 {
+		properties["mount_targets"] = mountTargets
+	}
+	if vpcID != "" {
+		properties["vpc_id"] = vpcID
+	}
+	if len(securityGroupIDs) > 0 {
+		properties[resource.PropSecurityGroups] = securityGroupIDs
+	}
+
+	tags := make(map[string]string, len(fileSystem.Tags))
+	for _, tag := range fileSystem.Tags {
+		if tag.Key != nil && tag.Value != nil {
+			tags[*tag.Key] = *tag.Value
+		}
+	}
+	if len(tags) == 0 {
+		tags = nil
+	}
+
+	name := safeString(fileSystem.Name)
+	if name == "" {
+		name = safeString(fileSystem.FileSystemId)
+	}
+
+	res := &resource.Resource{
+		ID:         safeString(fileSystem.FileSystemId),
+		Type:       resource.TypeAWSEFS,
+		Name:       name,
+		Provider:   "aws",
+		Account:    account,
+		Region:     region,
+		ARN:        safeString(fileSystem.FileSystemArn),
+		Tags:       tags,
+		Properties: properties,
+		RawData:    fileSystem,
+		CreatedAt:  fileSystem.CreationTime,
+	}
+
+	// Mount targets place the file system in the subnets of a VPC
+	res.Relationships = append(res.Relationships, networkRelationships(vpcID, subnetIDs)...)
+	res.Relationships = append(res.Relationships, securityGroupRelationships(securityGroupIDs)...)
+
+	return res
+}
+
+// ec2Tags converts EC2 tags to a map, and returns the value of the Name tag
+func ec2Tags(ec2Tags []ec2Types.Tag) (map[string]string, string) {
+	if len(ec2Tags) == 0 {
+		return nil, ""
+	}
+
+	tags := make(map[string]string, len(ec2Tags))
+	var name string
+	for _, tag := range ec2Tags {
+		if tag.Key != nil && tag.Value != nil {
+			tags[*tag.Key] = *tag.Value
+			if *tag.Key == "Name" {
+				name = *tag.Value
+			}
+		}
+	}
+	return tags, name
+}
@@ -213,57 +213,15 @@ func (p *Provider) convertSecurityGroupToResource(sg *ec2Types.SecurityGroup, re
 	}
 
 	properties := map[string]interface{}{
-		"description": safeString(sg.Description),
+		"description":          safeString(sg.Description),
+		resource.PropGroupName: safeString(sg.GroupName),
 	}
 
-	// Add ingress rules
 	if len(sg.IpPermissions) > 0 {
-		ingressRules := make([]map[string]interface{}, 0, len(sg.IpPermissions))
-		for _, perm := range sg.IpPermissions {
-			rule := map[string]interface{}{
-				"ip_protocol": safeString(perm.IpProtocol),
-			}
-			if perm.FromPort != nil {
-				rule["from_port"] = *perm.FromPort
-			}
-			if perm.ToPort != nil {
-				rule["to_port"] = *perm.ToPort
-			}
-			if len(perm.IpRanges) > 0 {
-				cidrs := make([]string, 0, len(perm.IpRanges))
-				for _, ipRange := range perm.IpRanges {
-					cidrs = append(cidrs, safeString(ipRange.CidrIp))
-				}
-				rule["cidr_blocks"] = cidrs
-			}
-			ingressRules = append(ingressRules, rule)
-		}
-		properties["ingress_rules"] = ingressRules
+		properties["ingress_rules"] = convertSecurityGroupRules(sg.IpPermissions)
 	}
-
-	// Add egress rules
 	if len(sg.IpPermissionsEgress) > 0 {
-		egressRules := make([]map[string]interface{}, 0, len(sg.IpPermissionsEgress))
-		for _, perm := range sg.IpPermissionsEgress {
-			rule := map[string]interface{}{
-				"ip_protocol": safeString(perm.IpProtocol),
-			}
-			if perm.FromPort != nil {
-				rule["from_port"] = *perm.FromPort
-			}
-			if perm.ToPort != nil {
-				rule["to_port"] = *perm.ToPort
-			}
-			if len(perm.IpRanges) > 0 {
-				cidrs := make([]string, 0, len(perm.IpRanges))
-				for _, ipRange := range perm.IpRanges {
-					cidrs = append(cidrs, safeString(ipRange.CidrIp))
-				}
-				rule["cidr_blocks"] = cidrs
-			}
-			egressRules = append(egressRules, rule)
-		}
-		properties["egress_rules"] = egressRules
+		properties["egress_rules"] = convertSecurityGroupRules(sg.IpPermissionsEgress)
 	}
 
 	var tags map[string]string
@@ -313,5 +271,56 @@ func (p *Provider) convertSecurityGroupToResource(sg *ec2Types.SecurityGroup, re
 		}
 	}
 
+	// Add references to the security groups allowed by its rules
+	res.Relationships = append(res.Relationships, securityGroupReferences(res.ID, "ingress", sg.IpPermissions)...)
+	res.Relationships = append(res.Relationships, securityGroupReferences(res.ID, "egress", sg.IpPermissionsEgress)...)
+
 	return res
 }
+
+// convertSecurityGroupRules converts the ingress or egress permissions of a security group to
+// rule properties, with the IPv4 and IPv6 ranges, prefix lists and security groups they allow
+func convertSecurityGroupRules(permissions []ec2Types.IpPermission) []map[string]interface{} {
+	rules := make([]map[string]interface{}, 0, len(permissions))
+	for _, perm := range permissions {
+		rule := map[string]interface{}{
+			"ip_protocol": safeString(perm.IpProtocol),
+		}
+		if perm.FromPort != nil {
+			rule["from_port"] = *perm.FromPort
+		}
+		if perm.ToPort != nil {
+			rule["to_port"] = *perm.ToPort
+		}
+		if len(perm.IpRanges) > 0 {
+			cidrs := make([]string, 0, len(perm.IpRanges))
+			for _, ipRange := range perm.IpRanges {
+				cidrs = append(cidrs, safeString(ipRange.CidrIp))
+			}
+			rule["cidr_blocks"] = cidrs
+		}
+		if len(perm.Ipv6Ranges) > 0 {
+			cidrs := make([]string, 0, len(perm.Ipv6Ranges))
+			for _, ipRange := range perm.Ipv6Ranges {
+				cidrs = append(cidrs, safeString(ipRange.CidrIpv6))
+			}
+			rule["ipv6_cidr_blocks"] = cidrs
+		}
+		if len(perm.PrefixListIds) > 0 {
+			prefixLists := make([]string, 0, len(perm.PrefixListIds))
+			for _, prefixList := range perm.PrefixListIds {
+				prefixLists = append(prefixLists, safeString(prefixList.PrefixListId))
+			}
+			rule["prefix_list_ids"] = prefixLists
+		}
+		if len(perm.UserIdGroupPairs) > 0 {
+			groups := make([]string, 0, len(perm.UserIdGroupPairs))
+			for _, pair := range perm.UserIdGroupPairs {
+				groups = append(groups, safeString(pair.GroupId))
+			}
+			rule["source_security_groups"] = groups
+		}
+		rules = append(rules, rule)
+	}
+	return rules
+}
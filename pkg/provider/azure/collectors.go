@@ -107,7 +107,12 @@ func (p *Provider) collectVirtualMachines(ctx context.Context, collection *resou
 			}
 
 			if vm.Properties.HardwareProfile != nil && vm.Properties.HardwareProfile.VMSize != nil {
-				properties["vm_size"] = string(*vm.Properties.HardwareProfile.VMSize)
+				properties[resource.PropVMSize] = string(*vm.Properties.HardwareProfile.VMSize)
+			}
+
+			if vm.Properties.StorageProfile != nil && vm.Properties.StorageProfile.OSDisk != nil &&
+				vm.Properties.StorageProfile.OSDisk.OSType != nil {
+				properties[resource.PropOSType] = string(*vm.Properties.StorageProfile.OSDisk.OSType)
 			}
 
 			if vm.Properties.OSProfile != nil {
@@ -231,7 +236,7 @@ func (p *Provider) collectStorageAccounts(ctx context.Context, collection *resou
 				"location":       getString(sa.Location),
 				"resource_group": extractResourceGroup(*sa.ID),
 				"kind":           string(*sa.Kind),
-				"sku":            string(*sa.SKU.Name),
+				resource.PropSKU: string(*sa.SKU.Name),
 			}
 
 			if sa.Properties != nil {
@@ -205,9 +205,9 @@ func (p *Provider) collectCloudRunServices(ctx context.Context, collection *reso
 
 func (p *Provider) convertComputeInstanceToResource(inst *computepb.Instance, zone string) *resource.Resource {
 	properties := map[string]interface{}{
-		"machine_type": safeString(inst.MachineType),
-		"status":       safeString(inst.Status),
-		"zone":         zone,
+		resource.PropMachineType: safeString(inst.MachineType),
+		"status":                 safeString(inst.Status),
+		"zone":                   zone,
 	}
 
 	if inst.CpuPlatform != nil {
@@ -291,8 +291,8 @@ func (p *Provider) convertSubnetworkToResource(subnet *computepb.Subnetwork, reg
 
 func (p *Provider) convertStorageBucketToResource(attrs *storage.BucketAttrs) *resource.Resource {
 	properties := map[string]interface{}{
-		"location":      attrs.Location,
-		"storage_class": attrs.StorageClass,
+		resource.PropLocation:     attrs.Location,
+		resource.PropStorageClass: attrs.StorageClass,
 	}
 
 	if attrs.VersioningEnabled {
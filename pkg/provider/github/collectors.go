@@ -76,6 +76,11 @@ func (p *Provider) collectTeams(ctx context.Context, collection *resource.Collec
 
 		for _, team := range teams {
 			res := p.convertTeamToResource(team, org)
+			grants, err := p.teamGrants(ctx, org, safeString(team.Slug))
+			if err != nil {
+				fmt.Fprintf(os.Stderr, "    Warning: failed to list members and repositories of team %s: %v\n", safeString(team.Name), err)
+			}
+			res.Relationships = append(res.Relationships, grants...)
 			collection.Add(res)
 			count++
 			fmt.Fprintf(os.Stderr, "    Found team: %s\n", safeString(team.Name))
@@ -91,9 +96,83 @@ func (p *Provider) collectTeams(ctx context.Context, collection *resource.Collec
 	return nil
 }
 
+// teamGrants returns the contains relationships of a team to its members and the has_access
+// relationships to its repositories, with the permission of the team on each of them
+func (p *Provider) teamGrants(ctx context.Context, org, slug string) ([]resource.Relationship, error) {
+	var relationships []resource.Relationship
+
+	memberOpts := &github.TeamListTeamMembersOptions{
+		ListOptions: github.ListOptions{PerPage: 100},
+	}
+	for {
+		users, resp, err := p.client.Teams.ListTeamMembersBySlug(ctx, org, slug, memberOpts)
+		if err != nil {
+			return relationships, fmt.Errorf("failed to list members: %w", err)
+		}
+
+		for _, user := range users {
+			relationships = append(relationships, resource.Relationship{
+				Type:       resource.RelationContains,
+				TargetID:   fmt.Sprintf("%d", safeInt64(user.ID)),
+				TargetType: resource.TypeGitHubUser,
+			})
+		}
+
+		if resp.NextPage == 0 {
+			break
+		}
+		memberOpts.Page = resp.NextPage
+	}
+
+	repoOpts := &github.ListOptions{PerPage: 100}
+	for {
+		repos, resp, err := p.client.Teams.ListTeamReposBySlug(ctx, org, slug, repoOpts)
+		if err != nil {
+			return relationships, fmt.Errorf("failed to list repositories: %w", err)
+		}
+
+		for _, repo := range repos {
+			relationships = append(relationships, resource.Relationship{
+				Type:       resource.RelationHasAccess,
+				TargetID:   fmt.Sprintf("%d", safeInt64(repo.ID)),
+				TargetType: resource.TypeGitHubRepository,
+				Properties: map[string]interface{}{
+					resource.PropPermission: repositoryPermission(repo.Permissions),
+				},
+			})
+		}
+
+		if resp.NextPage == 0 {
+			break
+		}
+		repoOpts.Page = resp.NextPage
+	}
+
+	return relationships, nil
+}
+
+// repositoryPermissions lists the repository permissions from the highest to the lowest
+var repositoryPermissions = []string{"admin", "maintain", "push", "triage", "pull"}
+
+// repositoryPermission returns the highest permission granted on a repository
+func repositoryPermission(permissions map[string]bool) string {
+	for _, permission := range repositoryPermissions {
+		if permissions[permission] {
+			return permission
+		}
+	}
+	return "pull"
+}
+
 // collectUsers collects all members for an organization
 func (p *Provider) collectUsers(ctx context.Context, collection *resource.Collection, org string) error {
 	fmt.Fprintf(os.Stderr, "  Collecting users for %s...\n", org)
+
+	admins, err := p.listOrganizationAdmins(ctx, org)
+	if err != nil {
+		return err
+	}
+
 	opts := &github.ListMembersOptions{
 		ListOptions: github.ListOptions{PerPage: 100},
 	}
@@ -106,7 +185,16 @@ func (p *Provider) collectUsers(ctx context.Context, collection *resource.Collec
 		}
 
 		for _, user := range users {
-			res := p.convertUserToResource(user, org)
+			role := "member"
+			if admins[safeString(user.Login)] {
+				role = "admin"
+			}
+			res := p.convertUserToResource(user, org, role)
+			if p.memberActivity {
+				if err := p.addMemberActivity(ctx, res); err != nil {
+					fmt.Fprintf(os.Stderr, "    Warning: failed to get the activity of user %s: %v\n", safeString(user.Login), err)
+				}
+			}
 			collection.Add(res)
 			count++
 			fmt.Fprintf(os.Stderr, "    Found user: %s\n", safeString(user.Login))
@@ -122,6 +210,47 @@ func (p *Provider) collectUsers(ctx context.Context, collection *resource.Collec
 	return nil
 }
 
+// addMemberActivity sets the time of the latest event performed by a user. Only public events
+// of the last 90 days are available for users other than the authenticated one.
+func (p *Provider) addMemberActivity(ctx context.Context, user *resource.Resource) error {
+	events, _, err := p.client.Activity.ListEventsPerformedByUser(ctx, user.Name, false, &github.ListOptions{PerPage: 1})
+	if err != nil {
+		return err
+	}
+
+	if len(events) > 0 && events[0].CreatedAt != nil {
+		user.Properties["last_event_at"] = events[0].CreatedAt.Format(time.RFC3339)
+	}
+	return nil
+}
+
+// listOrganizationAdmins returns the logins of the owners of an organization
+func (p *Provider) listOrganizationAdmins(ctx context.Context, org string) (map[string]bool, error) {
+	opts := &github.ListMembersOptions{
+		Role:        "admin",
+		ListOptions: github.ListOptions{PerPage: 100},
+	}
+
+	admins := make(map[string]bool)
+	for {
+		users, resp, err := p.client.Organizations.ListMembers(ctx, org, opts)
+		if err != nil {
+			return nil, fmt.Errorf("failed to list admins: %w", err)
+		}
+
+		for _, user := range users {
+			admins[safeString(user.Login)] = true
+		}
+
+		if resp.NextPage == 0 {
+			break
+		}
+		opts.Page = resp.NextPage
+	}
+
+	return admins, nil
+}
+
 // convertOrganizationToResource converts a GitHub organization to a Resource
 func (p *Provider) convertOrganizationToResource(org *github.Organization) *resource.Resource {
 	properties := make(map[string]interface{})
@@ -287,8 +416,10 @@ func (p *Provider) convertTeamToResource(team *github.Team, org string) *resourc
 }
 
 // convertUserToResource converts a GitHub user to a Resource
-func (p *Provider) convertUserToResource(user *github.User, org string) *resource.Resource {
-	properties := make(map[string]interface{})
+func (p *Provider) convertUserToResource(user *github.User, org, role string) *resource.Resource {
+	properties := map[string]interface{}{
+		"role": role, // admin (organization owner) or member
+	}
 
 	if user.Name != nil {
 		properties["name"] = *user.Name
@@ -19,8 +19,9 @@ type Provider struct {
 	client *github.Client
 
 	// Configuration
-	organizations []string
-	token         string
+	organizations  []string
+	token          string
+	memberActivity bool // fetch the latest event of each member (options.member_activity)
 }
 
 // init registers the GitHub provider
@@ -57,6 +58,11 @@ func (p *Provider) Initialize(ctx context.Context, cfg config.ProviderConfig) er
 		p.organizations = cfg.Accounts
 	}
 
+	// The latest event of members costs a request per member, it is only fetched on demand
+	if enabled, ok := cfg.Options["member_activity"].(bool); ok {
+		p.memberActivity = enabled
+	}
+
 	return nil
 }
 
@@ -55,9 +55,5 @@ func (p *Provider) discoverRepositoryRelationships(repo *resource.Resource, coll
 // discoverTeamRelationships discovers relationships for teams
 func (p *Provider) discoverTeamRelationships(team *resource.Resource, collection *resource.Collection) {
 	// Team relationships are already added during collection
-	// (belongs_to Organization relationship)
-
-	// Could add more complex relationships here, such as:
-	// - Team members (User resources)
-	// - Team repositories
+	// (belongs_to Organization, contains User members and has_access to Repositories)
 }
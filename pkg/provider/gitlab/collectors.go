@@ -7,6 +7,7 @@ import (
 	"context"
 	"fmt"
 	"os"
+	"time"
 
 	"github.com/xanzy/go-gitlab"
 
@@ -57,6 +58,7 @@ func (p *Provider) collectProjects(ctx context.Context, collection *resource.Col
 			PerPage: 100,
 			Page:    1,
 		},
+		Statistics: gitlab.Ptr(true),
 	}
 
 	// If groups are specified, collect projects for those groups
@@ -77,6 +79,17 @@ func (p *Provider) collectProjects(ctx context.Context, collection *resource.Col
 				}
 
 				for _, project := range projects {
+					// Group project listings have no statistics; fetch them for archived
+					// projects, whose storage is reported by recommendations
+					if project.Archived && project.Statistics == nil {
+						detailed, _, err := p.client.Projects.GetProject(project.ID, &gitlab.GetProjectOptions{Statistics: gitlab.Ptr(true)})
+						if err != nil {
+							fmt.Fprintf(os.Stderr, "    Warning: failed to get statistics of project %s: %v\n", project.PathWithNamespace, err)
+						} else {
+							project = detailed
+						}
+					}
+
 					res := p.convertProjectToResource(project)
 					collection.Add(res)
 					count++
@@ -188,7 +201,7 @@ func (p *Provider) convertProjectToResource(project *gitlab.Project) *resource.R
 		"visibility":          project.Visibility,
 		"description":         project.Description,
 		"default_branch":      project.DefaultBranch,
-		"archived":            project.Archived,
+		resource.PropArchived: project.Archived,
 	}
 
 	if project.WebURL != "" {
@@ -201,6 +214,16 @@ func (p *Provider) convertProjectToResource(project *gitlab.Project) *resource.R
 		properties["http_url"] = project.HTTPURLToRepo
 	}
 
+	// Storage statistics are only returned to members with at least the Reporter role
+	if project.Statistics != nil {
+		properties[resource.PropSizeBytes] = project.Statistics.StorageSize
+		properties["repository_size_bytes"] = project.Statistics.RepositorySize
+		properties["lfs_objects_size_bytes"] = project.Statistics.LFSObjectsSize
+		properties["job_artifacts_size_bytes"] = project.Statistics.JobArtifactsSize
+		properties["packages_size_bytes"] = project.Statistics.PackagesSize
+		properties["container_registry_size_bytes"] = project.Statistics.ContainerRegistrySize
+	}
+
 	res := &resource.Resource{
 		ID:         fmt.Sprintf("%d", project.ID),
 		Type:       resource.TypeGitLabProject,
@@ -235,6 +258,7 @@ func (p *Provider) convertUserToResource(user *gitlab.User) *resource.Resource {
 		"username": user.Username,
 		"email":    user.Email,
 		"state":    user.State,
+		"is_admin": user.IsAdmin,
 	}
 
 	if user.WebURL != "" {
@@ -244,6 +268,17 @@ func (p *Provider) convertUserToResource(user *gitlab.User) *resource.Resource {
 		properties["avatar_url"] = user.AvatarURL
 	}
 
+	// Sign-in and activity dates are only returned to administrators
+	if user.LastActivityOn != nil {
+		properties["last_activity_on"] = time.Time(*user.LastActivityOn).Format(time.RFC3339)
+	}
+	if user.LastSignInAt != nil {
+		properties["last_sign_in_at"] = user.LastSignInAt.Format(time.RFC3339)
+	}
+	if user.CurrentSignInAt != nil {
+		properties["current_sign_in_at"] = user.CurrentSignInAt.Format(time.RFC3339)
+	}
+
 	res := &resource.Resource{
 		ID:         fmt.Sprintf("%d", user.ID),
 		Type:       resource.TypeGitLabUser,
@@ -264,3 +299,65 @@ func (p *Provider) convertUserToResource(user *gitlab.User) *resource.Resource {
 func (p *Provider) discoverProjectRelationships(res *resource.Resource, collection *resource.Collection) {
 	// Relationships are already added during conversion
 }
+
+// discoverGroupMemberships adds has_access relationships from the direct members of a group to
+// the group, with their access level. Members that were not collected are skipped.
+func (p *Provider) discoverGroupMemberships(group *resource.Resource, users map[string]*resource.Resource) error {
+	opt := &gitlab.ListGroupMembersOptions{
+		ListOptions: gitlab.ListOptions{
+			PerPage: 100,
+			Page:    1,
+		},
+	}
+
+	for {
+		members, resp, err := p.client.Groups.ListGroupMembers(group.ID, opt)
+		if err != nil {
+			return err
+		}
+
+		for _, member := range members {
+			user, ok := users[fmt.Sprintf("%d", member.ID)]
+			if !ok {
+				continue
+			}
+			user.Relationships = append(user.Relationships, resource.Relationship{
+				Type:       resource.RelationHasAccess,
+				TargetID:   group.ID,
+				TargetType: resource.TypeGitLabGroup,
+				Properties: map[string]interface{}{
+					resource.PropPermission: accessLevelName(member.AccessLevel),
+					"access_level":          int(member.AccessLevel),
+				},
+			})
+		}
+
+		if resp.NextPage == 0 {
+			break
+		}
+		opt.Page = resp.NextPage
+	}
+
+	return nil
+}
+
+// accessLevelName returns the role name of a GitLab access level
+func accessLevelName(level gitlab.AccessLevelValue) string {
+	switch {
+	case level >= gitlab.AdminPermissions:
+		return "admin"
+	case level >= gitlab.OwnerPermissions:
+		return "owner"
+	case level >= gitlab.MaintainerPermissions:
+		return "maintainer"
+	case level >= gitlab.DeveloperPermissions:
+		return "developer"
+	case level >= gitlab.ReporterPermissions:
+		return "reporter"
+	case level >= gitlab.GuestPermissions:
+		return "guest"
+	case level >= gitlab.MinimalAccessPermissions:
+		return "minimal_access"
+	}
+	return "none"
+}
@@ -115,11 +115,23 @@ func (p *Provider) CollectResources(ctx context.Context, types []resource.Resour
 
 // DiscoverRelationships establishes relationships between GitLab resources
 func (p *Provider) DiscoverRelationships(ctx context.Context, collection *resource.Collection) error {
+	// Users are indexed by type, as group, project and user IDs may collide
+	users := make(map[string]*resource.Resource)
+	for _, res := range collection.Resources {
+		if res.Type == resource.TypeGitLabUser {
+			users[res.ID] = res
+		}
+	}
+
 	// Build relationships based on GitLab resource structure
 	for _, res := range collection.Resources {
 		switch res.Type {
 		case resource.TypeGitLabProject:
 			p.discoverProjectRelationships(res, collection)
+		case resource.TypeGitLabGroup:
+			if err := p.discoverGroupMemberships(res, users); err != nil {
+				fmt.Fprintf(os.Stderr, "    Warning: failed to discover group memberships for %s: %v\n", res.Name, err)
+			}
 		}
 	}
 
@@ -6,7 +6,10 @@ package jfrog
 import (
 	"context"
 	"fmt"
+	"maps"
+	"net/url"
 	"os"
+	"slices"
 
 	"github.com/comfortablynumb/pmp-cloud-inspector/pkg/resource"
 )
@@ -28,6 +31,8 @@ type User struct {
 	ProfileUpdatable         bool     `json:"profileUpdatable"`
 	InternalPasswordDisabled bool     `json:"internalPasswordDisabled"`
 	Groups                   []string `json:"groups"`
+	LastLoggedIn             string   `json:"lastLoggedIn"`
+	Realm                    string   `json:"realm"`
 }
 
 // Group represents a JFrog group
@@ -40,9 +45,29 @@ type Group struct {
 
 // Permission represents a JFrog permission target
 type Permission struct {
-	Name         string                 `json:"name"`
-	Repositories []string               `json:"repositories"`
-	Principals   map[string]interface{} `json:"principals"`
+	Name         string               `json:"name"`
+	Repositories []string             `json:"repositories"`
+	Principals   PermissionPrincipals `json:"principals"`
+}
+
+// PermissionPrincipals maps the users and groups of a permission target to their actions
+type PermissionPrincipals struct {
+	Users  map[string][]string `json:"users"`
+	Groups map[string][]string `json:"groups"`
+}
+
+// permissionActions maps the actions of permission targets to their names, from the highest
+// to the lowest
+var permissionActions = []struct {
+	Code string
+	Name string
+}{
+	{"m", "manage"},
+	{"d", "delete"},
+	{"w", "deploy"},
+	{"n", "annotate"},
+	{"r", "read"},
+	{"mxm", "manage_xray_metadata"},
 }
 
 // collectRepositories collects all repositories
@@ -80,14 +105,21 @@ func (p *Provider) collectUsers(ctx context.Context, collection *resource.Collec
 		return fmt.Errorf("failed to list users: %w", err)
 	}
 
+	// The list only has the names and realms of the users
 	var users []User
 	if err := parseResponse(resp, &users); err != nil {
 		return fmt.Errorf("failed to parse users: %w", err)
 	}
 
 	count := 0
-	for _, user := range users {
-		res := p.convertUserToResource(&user)
+	for _, listed := range users {
+		user, err := p.getUser(listed.Name)
+		if err != nil {
+			fmt.Fprintf(os.Stderr, "    Warning: failed to get user %s: %v\n", listed.Name, err)
+			user = &listed
+		}
+
+		res := p.convertUserToResource(user)
 		collection.Add(res)
 		count++
 		fmt.Fprintf(os.Stderr, "    Found user: %s\n", user.Name)
@@ -132,14 +164,21 @@ func (p *Provider) collectPermissions(ctx context.Context, collection *resource.
 		return fmt.Errorf("failed to list permissions: %w", err)
 	}
 
+	// The list only has the names of the permission targets
 	var permissions []Permission
 	if err := parseResponse(resp, &permissions); err != nil {
 		return fmt.Errorf("failed to parse permissions: %w", err)
 	}
 
 	count := 0
-	for _, perm := range permissions {
-		res := p.convertPermissionToResource(&perm)
+	for _, listed := range permissions {
+		perm, err := p.getPermission(listed.Name)
+		if err != nil {
+			fmt.Fprintf(os.Stderr, "    Warning: failed to get permission %s: %v\n", listed.Name, err)
+			perm = &listed
+		}
+
+		res := p.convertPermissionToResource(perm)
 		collection.Add(res)
 		count++
 		fmt.Fprintf(os.Stderr, "    Found permission: %s\n", perm.Name)
@@ -149,6 +188,34 @@ func (p *Provider) collectPermissions(ctx context.Context, collection *resource.
 	return nil
 }
 
+// getUser gets a user with its email, groups and last login
+func (p *Provider) getUser(name string) (*User, error) {
+	resp, err := p.doRequest("GET", "security/users/"+url.PathEscape(name))
+	if err != nil {
+		return nil, err
+	}
+
+	var user User
+	if err := parseResponse(resp, &user); err != nil {
+		return nil, err
+	}
+	return &user, nil
+}
+
+// getPermission gets a permission target with its repositories and principals
+func (p *Provider) getPermission(name string) (*Permission, error) {
+	resp, err := p.doRequest("GET", "security/permissions/"+url.PathEscape(name))
+	if err != nil {
+		return nil, err
+	}
+
+	var perm Permission
+	if err := parseResponse(resp, &perm); err != nil {
+		return nil, err
+	}
+	return &perm, nil
+}
+
 // convertRepositoryToResource converts a JFrog repository to a Resource
 func (p *Provider) convertRepositoryToResource(repo *Repository) *resource.Resource {
 	properties := map[string]interface{}{
@@ -177,6 +244,12 @@ func (p *Provider) convertUserToResource(user *User) *resource.Resource {
 		"internal_password_disabled": user.InternalPasswordDisabled,
 		"groups":                     user.Groups,
 	}
+	if user.LastLoggedIn != "" {
+		properties["last_logged_in"] = user.LastLoggedIn
+	}
+	if user.Realm != "" {
+		properties["realm"] = user.Realm
+	}
 
 	res := &resource.Resource{
 		ID:         user.Name,
@@ -244,3 +317,50 @@ func (p *Provider) convertPermissionToResource(perm *Permission) *resource.Resou
 
 	return res
 }
+
+// discoverPermissionGrants adds has_access relationships from the users and groups of a
+// permission target to its repositories, with their actions. Principals that were not collected
+// are skipped.
+func discoverPermissionGrants(perm *Permission, users, groups map[string]*resource.Resource) {
+	grant := func(principal *resource.Resource, codes []string) {
+		actions := actionNames(codes)
+		permission := ""
+		if len(actions) > 0 {
+			permission = actions[0]
+		}
+		for _, repoKey := range perm.Repositories {
+			principal.Relationships = append(principal.Relationships, resource.Relationship{
+				Type:       resource.RelationHasAccess,
+				TargetID:   repoKey,
+				TargetType: resource.TypeJFrogRepository,
+				Properties: map[string]interface{}{
+					resource.PropPermission: permission,
+					resource.PropActions:    actions,
+					"permission_target":     perm.Name,
+				},
+			})
+		}
+	}
+
+	for _, name := range slices.Sorted(maps.Keys(perm.Principals.Users)) {
+		if user, ok := users[name]; ok {
+			grant(user, perm.Principals.Users[name])
+		}
+	}
+	for _, name := range slices.Sorted(maps.Keys(perm.Principals.Groups)) {
+		if group, ok := groups[name]; ok {
+			grant(group, perm.Principals.Groups[name])
+		}
+	}
+}
+
+// actionNames returns the names of permission target actions, from the highest to the lowest
+func actionNames(codes []string) []string {
+	names := make([]string, 0, len(codes))
+	for _, action := range permissionActions {
+		if slices.Contains(codes, action.Code) {
+			names = append(names, action.Name)
+		}
+	}
+	return names
+}
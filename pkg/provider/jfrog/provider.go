@@ -125,7 +125,24 @@ func (p *Provider) CollectResources(ctx context.Context, types []resource.Resour
 
 // DiscoverRelationships establishes relationships between JFrog resources
 func (p *Provider) DiscoverRelationships(ctx context.Context, collection *resource.Collection) error {
-	// Relationships can be discovered here if needed
+	// Users and groups are indexed by type, as a user and a group may have the same name
+	users := make(map[string]*resource.Resource)
+	groups := make(map[string]*resource.Resource)
+	for _, res := range collection.Resources {
+		switch res.Type {
+		case resource.TypeJFrogUser:
+			users[res.ID] = res
+		case resource.TypeJFrogGroup:
+			groups[res.ID] = res
+		}
+	}
+
+	for _, res := range collection.Resources {
+		if perm, ok := res.RawData.(*Permission); ok && res.Type == resource.TypeJFrogPermission {
+			discoverPermissionGrants(perm, users, groups)
+		}
+	}
+
 	return nil
 }
 
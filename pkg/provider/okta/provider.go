@@ -124,12 +124,15 @@ func (p *Provider) CollectResources(ctx context.Context, types []resource.Resour
 
 // DiscoverRelationships establishes relationships between Okta resources
 func (p *Provider) DiscoverRelationships(ctx context.Context, collection *resource.Collection) error {
-	// Discover relationships between groups and users
+	// Discover relationships between groups and users, and the applications assigned to groups
 	for _, res := range collection.Resources {
 		if res.Type == resource.TypeOktaGroup {
 			if err := p.discoverGroupMemberships(ctx, res, collection); err != nil {
 				fmt.Fprintf(os.Stderr, "    Warning: failed to discover group memberships for %s: %v\n", res.ID, err)
 			}
+			if err := p.discoverGroupApplications(ctx, res); err != nil {
+				fmt.Fprintf(os.Stderr, "    Warning: failed to discover application assignments for %s: %v\n", res.ID, err)
+			}
 		}
 	}
 
@@ -168,3 +171,28 @@ func (p *Provider) discoverGroupMemberships(ctx context.Context, group *resource
 
 	return nil
 }
+
+// discoverGroupApplications discovers the applications assigned to a group
+func (p *Provider) discoverGroupApplications(ctx context.Context, group *resource.Resource) error {
+	apps, _, err := p.client.Group.ListAssignedApplicationsForGroup(ctx, group.ID, nil)
+	if err != nil {
+		return err
+	}
+
+	for _, app := range apps {
+		appDetails, ok := app.(*okta.Application)
+		if !ok {
+			continue
+		}
+		group.Relationships = append(group.Relationships, resource.Relationship{
+			Type:       resource.RelationHasAccess,
+			TargetID:   appDetails.Id,
+			TargetType: resource.TypeOktaApplication,
+			Properties: map[string]interface{}{
+				resource.PropPermission: "assigned",
+			},
+		})
+	}
+
+	return nil
+}
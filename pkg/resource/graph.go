@@ -3,14 +3,34 @@ package resource
 // Graph represents a graph of resources and their relationships
 type Graph struct {
 	Collection *Collection
-	edges      map[string][]string // adjacency list: resourceID -> []relatedResourceIDs
+	nodes      map[string]*Resource      // resourceID -> resource
+	edges      map[string][]string       // adjacency list: resourceID -> []relatedResourceIDs
+	incoming   map[string][]IncomingEdge // reverse adjacency list: resourceID -> edges pointing to it
 }
 
+// IncomingEdge is a relationship pointing to a resource from another resource
+type IncomingEdge struct {
+	SourceID   string
+	SourceType ResourceType
+	Type       RelationType
+}
+
+// Direction defines which edges are followed when traversing the graph
+type Direction string
+
+const (
+	DirectionOutgoing Direction = "out" // Follow relationships from a resource to its targets
+	DirectionIncoming Direction = "in"  // Follow relationships pointing to a resource
+	DirectionBoth     Direction = "any" // Follow relationships in both directions
+)
+
 // NewGraph creates a new resource graph
 func NewGraph(collection *Collection) *Graph {
 	g := &Graph{
 		Collection: collection,
+		nodes:      make(map[string]*Resource),
 		edges:      make(map[string][]string),
+		incoming:   make(map[string][]IncomingEdge),
 	}
 	g.buildGraph()
 	return g
@@ -19,21 +39,32 @@ func NewGraph(collection *Collection) *Graph {
 // buildGraph constructs the graph from resource relationships
 func (g *Graph) buildGraph() {
 	for _, resource := range g.Collection.Resources {
+		g.nodes[resource.ID] = resource
 		g.edges[resource.ID] = make([]string, 0)
 
 		for _, rel := range resource.Relationships {
 			g.edges[resource.ID] = append(g.edges[resource.ID], rel.TargetID)
+			g.incoming[rel.TargetID] = append(g.incoming[rel.TargetID], IncomingEdge{
+				SourceID:   resource.ID,
+				SourceType: resource.Type,
+				Type:       rel.Type,
+			})
 		}
 	}
 }
 
+// Get returns the resource with the given ID, or nil if it is not in the graph
+func (g *Graph) Get(id string) *Resource {
+	return g.nodes[id]
+}
+
 // GetRelated returns all resources related to the given resource ID
 func (g *Graph) GetRelated(id string) []*Resource {
 	relatedIDs := g.edges[id]
 	related := make([]*Resource, 0, len(relatedIDs))
 
 	for _, relatedID := range relatedIDs {
-		if resource := g.Collection.Get(relatedID); resource != nil {
+		if resource := g.nodes[relatedID]; resource != nil {
 			related = append(related, resource)
 		}
 	}
@@ -43,16 +74,21 @@ func (g *Graph) GetRelated(id string) []*Resource {
 
 // GetRelationships returns all relationships for a given resource
 func (g *Graph) GetRelationships(id string) []Relationship {
-	resource := g.Collection.Get(id)
+	resource := g.nodes[id]
 	if resource == nil {
 		return nil
 	}
 	return resource.Relationships
 }
 
+// GetIncoming returns all relationships pointing to the given resource ID
+func (g *Graph) GetIncoming(id string) []IncomingEdge {
+	return g.incoming[id]
+}
+
 // AddRelationship adds a relationship between two resources
 func (g *Graph) AddRelationship(fromID string, rel Relationship) {
-	resource := g.Collection.Get(fromID)
+	resource := g.nodes[fromID]
 	if resource == nil {
 		return
 	}
@@ -62,6 +98,66 @@ func (g *Graph) AddRelationship(fromID string, rel Relationship) {
 
 	// Update edges
 	g.edges[fromID] = append(g.edges[fromID], rel.TargetID)
+	g.incoming[rel.TargetID] = append(g.incoming[rel.TargetID], IncomingEdge{
+		SourceID:   fromID,
+		SourceType: resource.Type,
+		Type:       rel.Type,
+	})
+}
+
+// Neighborhood returns the IDs of all resources reachable from the seeds within depth hops,
+// mapped to their distance from the nearest seed. Seeds are included at distance 0.
+// If relation types are given, only edges of those types are followed.
+func (g *Graph) Neighborhood(seeds []string, depth int, direction Direction, types ...RelationType) map[string]int {
+	typeSet := make(map[RelationType]bool)
+	for _, t := range types {
+		typeSet[t] = true
+	}
+	follow := func(t RelationType) bool {
+		return len(typeSet) == 0 || typeSet[t]
+	}
+
+	distances := make(map[string]int)
+	frontier := make([]string, 0, len(seeds))
+	for _, seed := range seeds {
+		if _, seen := distances[seed]; !seen {
+			distances[seed] = 0
+			frontier = append(frontier, seed)
+		}
+	}
+
+	for hop := 1; hop <= depth && len(frontier) > 0; hop++ {
+		var next []string
+		visit := func(id string) {
+			if _, seen := distances[id]; !seen {
+				distances[id] = hop
+				next = append(next, id)
+			}
+		}
+
+		for _, id := range frontier {
+			if direction != DirectionIncoming {
+				if resource := g.nodes[id]; resource != nil {
+					for _, rel := range resource.Relationships {
+						if follow(rel.Type) {
+							visit(rel.TargetID)
+						}
+					}
+				}
+			}
+			if direction != DirectionOutgoing {
+				for _, edge := range g.incoming[id] {
+					if follow(edge.Type) {
+						visit(edge.SourceID)
+					}
+				}
+			}
+		}
+
+		frontier = next
+	}
+
+	return distances
 }
 
 // GetSubgraph returns a subgraph containing only resources of specified types
@@ -0,0 +1,227 @@
+package resource
+
+import (
+	"encoding/json"
+	"time"
+)
+
+// Property names shared by collectors and consumers of resource properties (e.g., cost
+// estimators). Collectors may store numbers as any integer or float type; consumers must read
+// them with the typed accessors below, which also handle values decoded from JSON or YAML.
+const (
+	// Compute
+	PropState        = "state"
+	PropStatus       = "status"
+	PropInstanceType = "instance_type" // EC2 instance type
+	PropPlatform     = "platform"      // EC2 platform details, e.g., Linux/UNIX, Windows
+	PropVolumes      = "volumes"       // attached block volumes, see PropVolume*
+	PropVMSize       = "vm_size"       // Azure VM size
+	PropOSType       = "os_type"       // Azure VM OS type
+	PropMachineType  = "machine_type"  // GCP machine type
+
+	// Networking
+	PropSecurityGroups = "security_groups" // IDs of the security groups a resource uses
+	PropGroupName      = "group_name"      // security group name
+	PropTargetCount    = "target_count"    // registered load balancer targets
+	PropHealthyTargets = "healthy_targets" // registered load balancer targets passing their health checks
+	PropPublic         = "public"          // a subnet or route table routes to an internet gateway
+	PropRouteTableID   = "route_table_id"  // route table used by a subnet, explicitly associated or the main one of its VPC
+	PropMainRouteTable = "main"            // the route table is the main route table of its VPC
+
+	// Attached volume fields
+	PropVolumeID     = "volume_id"
+	PropVolumeType   = "volume_type"
+	PropVolumeSizeGB = "size_gb"
+
+	// Caches
+	PropNodeType      = "node_type"
+	PropNumShards     = "num_shards"
+	PropNumNodes      = "num_nodes"
+	PropNumCacheNodes = "num_cache_nodes"
+
+	// Databases
+	PropEngine           = "engine"               // database engine, e.g., mysql, aurora-postgresql
+	PropInstanceClass    = "instance_class"       // RDS instance class
+	PropMultiAZ          = "multi_az"             // a standby replica is kept in another availability zone
+	PropAllocatedStorage = "allocated_storage_gb" // provisioned storage in GB
+	PropStorageType      = "storage_type"         // RDS storage type, e.g., gp3, io1
+	PropClusterID        = "cluster_id"           // cluster an instance is a member of
+
+	// Functions
+	PropMemorySize   = "memory_size"   // MB
+	PropTimeout      = "timeout"       // seconds
+	PropEventSources = "event_sources" // event source mappings of a Lambda function, with the ARN of their source
+
+	// Secrets
+	PropLastAccessedDate = "last_accessed_date"
+
+	// Repositories
+	PropArchived = "archived"
+
+	// Tables
+	PropBillingMode        = "billing_mode" // PROVISIONED or PAY_PER_REQUEST
+	PropReadCapacityUnits  = "read_capacity_units"
+	PropWriteCapacityUnits = "write_capacity_units"
+	PropTableSizeBytes     = "table_size_bytes"
+
+	// Storage
+	PropStorageClass     = "storage_class"
+	PropSizeBytes        = "size_bytes"
+	PropSizeIABytes      = "size_ia_bytes"      // bytes stored in the infrequent access class, included in size_bytes
+	PropSizeArchiveBytes = "size_archive_bytes" // bytes stored in the archive class, included in size_bytes
+	PropEncrypted        = "encrypted"          // data is encrypted at rest
+	PropSKU              = "sku"
+	PropLocation         = "location"
+
+	// Identities
+	PropLastActivity = "last_activity" // latest sign-in or use of a user account, normalized from the provider signals
+	PropAccessKeys   = "access_keys"   // IAM user access keys, with their status and last use
+	PropAdmin        = "admin"         // the account has administrator privileges
+
+	// IAM policies
+	PropPolicyDocument    = "document"           // document of the default version of a managed policy
+	PropInlinePolicies    = "inline_policies"    // inline policies of a principal, with their name and document
+	PropTrustPolicy       = "trust_policy"       // document of the trust policy of a role
+	PropTrustedPrincipals = "trusted_principals" // principals allowed to assume a role by its trust policy
+
+	// Encryption
+	PropKMSKeyID            = "kms_key_id"            // KMS key encrypting a resource: key ID, key ARN, alias name or alias ARN
+	PropKeyPolicyPrincipals = "key_policy_principals" // principals allowed by the policy of a KMS key
+
+	// Access grants, set on the properties of has_access relationships
+	PropPermission = "permission" // permission level granted, e.g., admin, push, developer
+	PropActions    = "actions"    // actions granted, e.g., read, deploy
+)
+
+// StringProperty returns a string property
+func (r *Resource) StringProperty(name string) (string, bool) {
+	value, ok := r.Properties[name].(string)
+	return value, ok
+}
+
+// FloatProperty returns a numeric property as a float64, whatever numeric type it was stored as
+func (r *Resource) FloatProperty(name string) (float64, bool) {
+	return ToFloat(r.Properties[name])
+}
+
+// IntProperty returns a numeric property as an int64, whatever numeric type it was stored as
+func (r *Resource) IntProperty(name string) (int64, bool) {
+	value, ok := ToFloat(r.Properties[name])
+	return int64(value), ok
+}
+
+// BoolProperty returns a boolean property
+func (r *Resource) BoolProperty(name string) (bool, bool) {
+	value, ok := r.Properties[name].(bool)
+	return value, ok
+}
+
+// timeLayouts are the layouts of time properties: RFC 3339, and time.Time.String() as stored
+// by some collectors
+var timeLayouts = []string{
+	time.RFC3339Nano,
+	"2006-01-02 15:04:05.999999999 -0700 MST",
+}
+
+// TimeProperty returns a time property, stored as a time.Time or as a formatted string
+func (r *Resource) TimeProperty(name string) (time.Time, bool) {
+	switch value := r.Properties[name].(type) {
+	case time.Time:
+		return value, true
+	case *time.Time:
+		if value != nil {
+			return *value, true
+		}
+	case string:
+		for _, layout := range timeLayouts {
+			if t, err := time.Parse(layout, value); err == nil {
+				return t, true
+			}
+		}
+	}
+	return time.Time{}, false
+}
+
+// StringsProperty returns a property holding a list of strings, either as stored by collectors
+// ([]string) or as decoded from JSON or YAML ([]interface{})
+func (r *Resource) StringsProperty(name string) []string {
+	return ToStrings(r.Properties[name])
+}
+
+// ToStrings converts a list of strings, either as stored by collectors ([]string) or as decoded
+// from JSON or YAML ([]interface{}), to a []string
+func ToStrings(value interface{}) []string {
+	switch values := value.(type) {
+	case []string:
+		return values
+	case []interface{}:
+		result := make([]string, 0, len(values))
+		for _, value := range values {
+			if s, ok := value.(string); ok {
+				result = append(result, s)
+			}
+		}
+		return result
+	}
+	return nil
+}
+
+// MapsProperty returns a property holding a list of objects, either as stored by collectors
+// ([]map[string]interface{}) or as decoded from JSON or YAML ([]interface{})
+func (r *Resource) MapsProperty(name string) []map[string]interface{} {
+	switch values := r.Properties[name].(type) {
+	case []map[string]interface{}:
+		return values
+	case []interface{}:
+		maps := make([]map[string]interface{}, 0, len(values))
+		for _, value := range values {
+			if m, ok := value.(map[string]interface{}); ok {
+				maps = append(maps, m)
+			}
+		}
+		return maps
+	}
+	return nil
+}
+
+// ToFloat converts a numeric value of any integer or float type to a float64
+func ToFloat(value interface{}) (float64, bool) {
+	switch v := value.(type) {
+	case float64:
+		return v, true
+	case float32:
+		return float64(v), true
+	case int:
+		return float64(v), true
+	case int8:
+		return float64(v), true
+	case int16:
+		return float64(v), true
+	case int32:
+		return float64(v), true
+	case int64:
+		return float64(v), true
+	case uint:
+		return float64(v), true
+	case uint8:
+		return float64(v), true
+	case uint16:
+		return float64(v), true
+	case uint32:
+		return float64(v), true
+	case uint64:
+		return float64(v), true
+	case *int32:
+		if v != nil {
+			return float64(*v), true
+		}
+	case *int64:
+		if v != nil {
+			return float64(*v), true
+		}
+	case json.Number:
+		f, err := v.Float64()
+		return f, err == nil
+	}
+	return 0, false
+}
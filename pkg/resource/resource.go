@@ -2,6 +2,7 @@ package resource
 
 import (
 	"encoding/json"
+	"slices"
 	"time"
 )
 
@@ -10,27 +11,59 @@ type ResourceType string
 
 const (
 	// AWS Resource Types
-	TypeAWSIAMUser       ResourceType = "aws:iam:user"
-	TypeAWSIAMRole       ResourceType = "aws:iam:role"
-	TypeAWSAccount       ResourceType = "aws:account"
-	TypeAWSVPC           ResourceType = "aws:ec2:vpc"
-	TypeAWSSubnet        ResourceType = "aws:ec2:subnet"
-	TypeAWSSecurityGroup ResourceType = "aws:ec2:security-group"
-	TypeAWSEC2Instance   ResourceType = "aws:ec2:instance"
-	TypeAWSECR           ResourceType = "aws:ecr:repository"
-	TypeAWSEKSCluster    ResourceType = "aws:eks:cluster"
-	TypeAWSELB           ResourceType = "aws:elb:classic"
-	TypeAWSALB           ResourceType = "aws:elb:application"
-	TypeAWSNLB           ResourceType = "aws:elb:network"
-	TypeAWSLambda        ResourceType = "aws:lambda:function"
-	TypeAWSAPIGateway    ResourceType = "aws:apigateway:api"
-	TypeAWSCloudFront    ResourceType = "aws:cloudfront:distribution"
-	TypeAWSMemoryDB      ResourceType = "aws:memorydb:cluster"
-	TypeAWSElastiCache   ResourceType = "aws:elasticache:cluster"
-	TypeAWSSecret        ResourceType = "aws:secretsmanager:secret"
-	TypeAWSSNSTopic      ResourceType = "aws:sns:topic"
-	TypeAWSSQSQueue      ResourceType = "aws:sqs:queue"
-	TypeAWSDynamoDBTable ResourceType = "aws:dynamodb:table"
+	TypeAWSIAMUser                  ResourceType = "aws:iam:user"
+	TypeAWSIAMRole                  ResourceType = "aws:iam:role"
+	TypeAWSIAMPolicy                ResourceType = "aws:iam:policy"
+	TypeAWSIAMGroup                 ResourceType = "aws:iam:group"
+	TypeAWSAccount                  ResourceType = "aws:account"
+	TypeAWSVPC                      ResourceType = "aws:ec2:vpc"
+	TypeAWSSubnet                   ResourceType = "aws:ec2:subnet"
+	TypeAWSSecurityGroup            ResourceType = "aws:ec2:security-group"
+	TypeAWSEC2Instance              ResourceType = "aws:ec2:instance"
+	TypeAWSECR                      ResourceType = "aws:ecr:repository"
+	TypeAWSEKSCluster               ResourceType = "aws:eks:cluster"
+	TypeAWSEKSNodeGroup             ResourceType = "aws:eks:nodegroup"
+	TypeAWSEKSFargateProfile        ResourceType = "aws:eks:fargate-profile"
+	TypeAWSECSCluster               ResourceType = "aws:ecs:cluster"
+	TypeAWSECSService               ResourceType = "aws:ecs:service"
+	TypeAWSECSTaskDefinition        ResourceType = "aws:ecs:task-definition"
+	TypeAWSELB                      ResourceType = "aws:elb:classic"
+	TypeAWSALB                      ResourceType = "aws:elb:application"
+	TypeAWSNLB                      ResourceType = "aws:elb:network"
+	TypeAWSTargetGroup              ResourceType = "aws:elb:target-group"
+	TypeAWSLambda                   ResourceType = "aws:lambda:function"
+	TypeAWSStateMachine             ResourceType = "aws:states:state-machine"
+	TypeAWSEventBus                 ResourceType = "aws:events:event-bus"
+	TypeAWSEventRule                ResourceType = "aws:events:rule"
+	TypeAWSAPIGateway               ResourceType = "aws:apigateway:api"
+	TypeAWSCloudFront               ResourceType = "aws:cloudfront:distribution"
+	TypeAWSMemoryDB                 ResourceType = "aws:memorydb:cluster"
+	TypeAWSElastiCache              ResourceType = "aws:elasticache:cluster"
+	TypeAWSSecret                   ResourceType = "aws:secretsmanager:secret"
+	TypeAWSSNSTopic                 ResourceType = "aws:sns:topic"
+	TypeAWSSQSQueue                 ResourceType = "aws:sqs:queue"
+	TypeAWSDynamoDBTable            ResourceType = "aws:dynamodb:table"
+	TypeAWSNATGateway               ResourceType = "aws:ec2:nat-gateway"
+	TypeAWSRouteTable               ResourceType = "aws:ec2:route-table"
+	TypeAWSInternetGateway          ResourceType = "aws:ec2:internet-gateway"
+	TypeAWSTransitGateway           ResourceType = "aws:ec2:transit-gateway"
+	TypeAWSTransitGatewayAttachment ResourceType = "aws:ec2:transit-gateway-attachment"
+	TypeAWSVPCPeeringConnection     ResourceType = "aws:ec2:vpc-peering-connection"
+	TypeAWSVPCEndpoint              ResourceType = "aws:ec2:vpc-endpoint"
+	TypeAWSElasticIP                ResourceType = "aws:ec2:elastic-ip"
+	TypeAWSNetworkInterface         ResourceType = "aws:ec2:network-interface"
+	TypeAWSS3Bucket                 ResourceType = "aws:s3:bucket"
+	TypeAWSRDSInstance              ResourceType = "aws:rds:instance"
+	TypeAWSRDSCluster               ResourceType = "aws:rds:cluster"
+	TypeAWSEBSVolume                ResourceType = "aws:ec2:volume"
+	TypeAWSEBSSnapshot              ResourceType = "aws:ec2:snapshot"
+	TypeAWSEFS                      ResourceType = "aws:efs:file-system"
+	TypeAWSKMSKey                   ResourceType = "aws:kms:key"
+	TypeAWSACMCertificate           ResourceType = "aws:acm:certificate"
+	TypeAWSRoute53Zone              ResourceType = "aws:route53:hosted-zone"
+	TypeAWSRoute53Record            ResourceType = "aws:route53:record"
+	TypeAWSCloudTrail               ResourceType = "aws:cloudtrail:trail"
+	TypeAWSWAFWebACL                ResourceType = "aws:wafv2:web-acl"
 
 	// GitHub Resource Types
 	TypeGitHubOrganization ResourceType = "github:organization"
@@ -80,6 +113,9 @@ const (
 	TypeAzureAppService     ResourceType = "azure:web:appservice"
 	TypeAzureSQLDatabase    ResourceType = "azure:sql:database"
 	TypeAzureKeyVault       ResourceType = "azure:keyvault:vault"
+
+	// Identity Resource Types (synthetic, built by identity correlation)
+	TypeIdentityPerson ResourceType = "identity:person"
 )
 
 // Resource represents a cloud resource
@@ -102,10 +138,17 @@ type Resource struct {
 
 // ResourceCost represents cost information for a resource
 type ResourceCost struct {
-	MonthlyEstimate float64            `json:"monthly_estimate"`    // Estimated monthly cost
-	Currency        string             `json:"currency"`            // Currency code (USD, EUR, etc.)
-	Breakdown       map[string]float64 `json:"breakdown,omitempty"` // Cost breakdown by component
-	LastUpdated     time.Time          `json:"last_updated"`        // When cost was last calculated
+	MonthlyEstimate float64            `json:"monthly_estimate"`          // Estimated monthly cost
+	Currency        string             `json:"currency"`                  // Currency code (USD, EUR, etc.)
+	Breakdown       map[string]float64 `json:"breakdown,omitempty"`       // Cost breakdown by component
+	LastUpdated     time.Time          `json:"last_updated"`              // When cost was last calculated
+	CatalogVersion  string             `json:"catalog_version,omitempty"` // Pricing catalog version used, if any
+
+	// Set when the estimate was converted from the currency it was priced in
+	OriginalCurrency    string  `json:"original_currency,omitempty"`     // Currency the estimate was priced in
+	OriginalEstimate    float64 `json:"original_estimate,omitempty"`     // Monthly estimate in the original currency
+	ExchangeRate        float64 `json:"exchange_rate,omitempty"`         // Units of Currency per unit of OriginalCurrency
+	ExchangeRateVersion string  `json:"exchange_rate_version,omitempty"` // Exchange rates version used
 }
 
 // Relationship represents a connection between resources
@@ -127,6 +170,8 @@ const (
 	RelationHasAccess  RelationType = "has_access"  // e.g., User has access to Resource
 	RelationReferences RelationType = "references"  // Generic reference
 	RelationDependsOn  RelationType = "depends_on"  // Dependency relationship
+	RelationRoutesTo   RelationType = "routes_to"   // e.g., RouteTable routes to InternetGateway, LoadBalancer routes to TargetGroup
+	RelationTriggers   RelationType = "triggers"    // e.g., Queue triggers Function, Rule triggers its targets
 )
 
 // Collection holds all discovered resources
@@ -146,6 +191,22 @@ type CollectionMetadata struct {
 	ByRegion        map[string]int                  `json:"by_region,omitempty"`
 	ByTypeAndRegion map[string]map[ResourceType]int `json:"by_type_and_region,omitempty"`
 	TotalCost       *CostSummary                    `json:"total_cost,omitempty"`
+	Alerts          []CostAlert                     `json:"alerts,omitempty"` // budget thresholds crossed by the estimated costs
+}
+
+// CostAlert reports a budget threshold crossed by the estimated monthly cost of the resources
+// in the budget's scope
+type CostAlert struct {
+	Budget    string  `json:"budget"`
+	Scope     string  `json:"scope"`         // total, provider, account or tag
+	Key       string  `json:"key,omitempty"` // provider, account or key=value tag pair
+	Severity  string  `json:"severity"`      // warning or critical
+	Amount    float64 `json:"amount"`        // monthly budget
+	Spend     float64 `json:"spend"`         // estimated monthly cost
+	Percent   float64 `json:"percent"`       // spend as a percentage of the budget
+	Threshold float64 `json:"threshold"`     // percentage of the budget that was crossed
+	Currency  string  `json:"currency"`
+	Message   string  `json:"message"`
 }
 
 // CostSummary provides cost aggregations for the collection
@@ -156,6 +217,11 @@ type CostSummary struct {
 	ByRegion   map[string]float64 `json:"by_region,omitempty"`   // Cost breakdown by region
 	ByType     map[string]float64 `json:"by_type,omitempty"`     // Cost breakdown by resource type
 	ByTag      map[string]float64 `json:"by_tag,omitempty"`      // Cost breakdown by tag values
+
+	CatalogVersions []string `json:"catalog_versions,omitempty"` // Pricing catalog versions used
+
+	ExchangeRates        map[string]float64 `json:"exchange_rates,omitempty"`         // Rates used to convert from each original currency
+	ExchangeRateVersions []string           `json:"exchange_rate_versions,omitempty"` // Exchange rates versions used
 }
 
 // NewCollection creates a new resource collection
@@ -238,6 +304,49 @@ func (c *Collection) updateCostMetadata(resource *Resource) {
 		tagKey := key + "=" + value
 		c.Metadata.TotalCost.ByTag[tagKey] += cost
 	}
+
+	// Track the pricing catalog versions used
+	if version := resource.Cost.CatalogVersion; version != "" && !slices.Contains(c.Metadata.TotalCost.CatalogVersions, version) {
+		c.Metadata.TotalCost.CatalogVersions = append(c.Metadata.TotalCost.CatalogVersions, version)
+	}
+
+	// Track the exchange rates used to convert estimates
+	if resource.Cost.OriginalCurrency != "" {
+		if c.Metadata.TotalCost.ExchangeRates == nil {
+			c.Metadata.TotalCost.ExchangeRates = make(map[string]float64)
+		}
+		c.Metadata.TotalCost.ExchangeRates[resource.Cost.OriginalCurrency] = resource.Cost.ExchangeRate
+
+		if version := resource.Cost.ExchangeRateVersion; version != "" && !slices.Contains(c.Metadata.TotalCost.ExchangeRateVersions, version) {
+			c.Metadata.TotalCost.ExchangeRateVersions = append(c.Metadata.TotalCost.ExchangeRateVersions, version)
+		}
+	}
+}
+
+// RecomputeMetadata rebuilds the index and the metadata aggregations (counts and costs)
+// from the current resources. It must be called after resources are modified in place,
+// e.g. after cost estimation, and on collections decoded from JSON or YAML.
+func (c *Collection) RecomputeMetadata() {
+	resources := c.Resources
+	timestamp := c.Metadata.Timestamp
+
+	fresh := NewCollection()
+	fresh.Metadata.Timestamp = timestamp
+	fresh.Metadata.Alerts = c.Metadata.Alerts
+	for _, res := range resources {
+		fresh.Add(res)
+	}
+
+	c.index = fresh.index
+	c.Metadata = fresh.Metadata
+}
+
+// InheritMetadata copies the metadata describing the whole inventory rather than the resources
+// of the collection (timestamp and alerts) from the collection it was derived from, e.g. by
+// filtering
+func (c *Collection) InheritMetadata(parent *Collection) {
+	c.Metadata.Timestamp = parent.Metadata.Timestamp
+	c.Metadata.Alerts = parent.Metadata.Alerts
 }
 
 // Get retrieves a resource by ID
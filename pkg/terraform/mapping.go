@@ -0,0 +1,118 @@
+package terraform
+
+import (
+	"strings"
+
+	"github.com/comfortablynumb/pmp-cloud-inspector/pkg/resource"
+)
+
+// typeMapping describes how a Terraform resource type maps to inventory resources
+type typeMapping struct {
+	// Types are the inventory resource types the Terraform type can correspond to
+	Types []resource.ResourceType
+	// Attributes are the state attributes that hold the inventory ID or ARN, in priority order
+	Attributes []string
+}
+
+// typeMappings maps Terraform resource types to inventory resource types
+var typeMappings = map[string]typeMapping{
+	// AWS
+	"aws_iam_user":                           {Types: []resource.ResourceType{resource.TypeAWSIAMUser}, Attributes: []string{"arn"}},
+	"aws_iam_role":                           {Types: []resource.ResourceType{resource.TypeAWSIAMRole}, Attributes: []string{"arn"}},
+	"aws_iam_group":                          {Types: []resource.ResourceType{resource.TypeAWSIAMGroup}, Attributes: []string{"arn"}},
+	"aws_iam_policy":                         {Types: []resource.ResourceType{resource.TypeAWSIAMPolicy}, Attributes: []string{"arn"}},
+	"aws_vpc":                                {Types: []resource.ResourceType{resource.TypeAWSVPC}, Attributes: []string{"id", "arn"}},
+	"aws_subnet":                             {Types: []resource.ResourceType{resource.TypeAWSSubnet}, Attributes: []string{"id", "arn"}},
+	"aws_security_group":                     {Types: []resource.ResourceType{resource.TypeAWSSecurityGroup}, Attributes: []string{"id", "arn"}},
+	"aws_route_table":                        {Types: []resource.ResourceType{resource.TypeAWSRouteTable}, Attributes: []string{"id", "arn"}},
+	"aws_default_route_table":                {Types: []resource.ResourceType{resource.TypeAWSRouteTable}, Attributes: []string{"id", "arn"}},
+	"aws_internet_gateway":                   {Types: []resource.ResourceType{resource.TypeAWSInternetGateway}, Attributes: []string{"id", "arn"}},
+	"aws_nat_gateway":                        {Types: []resource.ResourceType{resource.TypeAWSNATGateway}, Attributes: []string{"id"}},
+	"aws_ec2_transit_gateway":                {Types: []resource.ResourceType{resource.TypeAWSTransitGateway}, Attributes: []string{"id", "arn"}},
+	"aws_ec2_transit_gateway_vpc_attachment": {Types: []resource.ResourceType{resource.TypeAWSTransitGatewayAttachment}, Attributes: []string{"id"}},
+	"aws_vpc_peering_connection":             {Types: []resource.ResourceType{resource.TypeAWSVPCPeeringConnection}, Attributes: []string{"id"}},
+	"aws_vpc_endpoint":                       {Types: []resource.ResourceType{resource.TypeAWSVPCEndpoint}, Attributes: []string{"id", "arn"}},
+	"aws_eip":                                {Types: []resource.ResourceType{resource.TypeAWSElasticIP}, Attributes: []string{"allocation_id", "id"}},
+	"aws_network_interface":                  {Types: []resource.ResourceType{resource.TypeAWSNetworkInterface}, Attributes: []string{"id", "arn"}},
+	"aws_instance":                           {Types: []resource.ResourceType{resource.TypeAWSEC2Instance}, Attributes: []string{"id", "arn"}},
+	"aws_ecr_repository":                     {Types: []resource.ResourceType{resource.TypeAWSECR}, Attributes: []string{"arn"}},
+	"aws_eks_cluster":                        {Types: []resource.ResourceType{resource.TypeAWSEKSCluster}, Attributes: []string{"arn", "name"}},
+	"aws_eks_node_group":                     {Types: []resource.ResourceType{resource.TypeAWSEKSNodeGroup}, Attributes: []string{"arn"}},
+	"aws_eks_fargate_profile":                {Types: []resource.ResourceType{resource.TypeAWSEKSFargateProfile}, Attributes: []string{"arn"}},
+	"aws_ecs_cluster":                        {Types: []resource.ResourceType{resource.TypeAWSECSCluster}, Attributes: []string{"arn", "id"}},
+	"aws_ecs_service":                        {Types: []resource.ResourceType{resource.TypeAWSECSService}, Attributes: []string{"id"}},
+	"aws_ecs_task_definition":                {Types: []resource.ResourceType{resource.TypeAWSECSTaskDefinition}, Attributes: []string{"arn"}},
+	"aws_elb":                                {Types: []resource.ResourceType{resource.TypeAWSELB}, Attributes: []string{"name", "arn"}},
+	"aws_lb":                                 {Types: []resource.ResourceType{resource.TypeAWSALB, resource.TypeAWSNLB}, Attributes: []string{"arn"}},
+	"aws_alb":                                {Types: []resource.ResourceType{resource.TypeAWSALB, resource.TypeAWSNLB}, Attributes: []string{"arn"}},
+	"aws_lb_target_group":                    {Types: []resource.ResourceType{resource.TypeAWSTargetGroup}, Attributes: []string{"arn"}},
+	"aws_alb_target_group":                   {Types: []resource.ResourceType{resource.TypeAWSTargetGroup}, Attributes: []string{"arn"}},
+	"aws_lambda_function":                    {Types: []resource.ResourceType{resource.TypeAWSLambda}, Attributes: []string{"arn", "function_name"}},
+	"aws_sfn_state_machine":                  {Types: []resource.ResourceType{resource.TypeAWSStateMachine}, Attributes: []string{"arn", "id"}},
+	"aws_cloudwatch_event_bus":               {Types: []resource.ResourceType{resource.TypeAWSEventBus}, Attributes: []string{"arn"}},
+	"aws_cloudwatch_event_rule":              {Types: []resource.ResourceType{resource.TypeAWSEventRule}, Attributes: []string{"arn"}},
+	"aws_api_gateway_rest_api":               {Types: []resource.ResourceType{resource.TypeAWSAPIGateway}, Attributes: []string{"id"}},
+	"aws_apigatewayv2_api":                   {Types: []resource.ResourceType{resource.TypeAWSAPIGateway}, Attributes: []string{"id"}},
+	"aws_cloudfront_distribution":            {Types: []resource.ResourceType{resource.TypeAWSCloudFront}, Attributes: []string{"id", "arn"}},
+	"aws_memorydb_cluster":                   {Types: []resource.ResourceType{resource.TypeAWSMemoryDB}, Attributes: []string{"arn", "name"}},
+	"aws_elasticache_cluster":                {Types: []resource.ResourceType{resource.TypeAWSElastiCache}, Attributes: []string{"arn", "cluster_id"}},
+	"aws_secretsmanager_secret":              {Types: []resource.ResourceType{resource.TypeAWSSecret}, Attributes: []string{"arn"}},
+	"aws_sns_topic":                          {Types: []resource.ResourceType{resource.TypeAWSSNSTopic}, Attributes: []string{"arn"}},
+	"aws_sqs_queue":                          {Types: []resource.ResourceType{resource.TypeAWSSQSQueue}, Attributes: []string{"url", "id", "arn"}},
+	"aws_dynamodb_table":                     {Types: []resource.ResourceType{resource.TypeAWSDynamoDBTable}, Attributes: []string{"arn", "name"}},
+	"aws_s3_bucket":                          {Types: []resource.ResourceType{resource.TypeAWSS3Bucket}, Attributes: []string{"bucket", "arn"}},
+	"aws_db_instance":                        {Types: []resource.ResourceType{resource.TypeAWSRDSInstance}, Attributes: []string{"arn", "identifier"}},
+	"aws_rds_cluster":                        {Types: []resource.ResourceType{resource.TypeAWSRDSCluster}, Attributes: []string{"arn", "cluster_identifier"}},
+	"aws_rds_cluster_instance":               {Types: []resource.ResourceType{resource.TypeAWSRDSInstance}, Attributes: []string{"arn", "identifier"}},
+	"aws_ebs_volume":                         {Types: []resource.ResourceType{resource.TypeAWSEBSVolume}, Attributes: []string{"id", "arn"}},
+	"aws_ebs_snapshot":                       {Types: []resource.ResourceType{resource.TypeAWSEBSSnapshot}, Attributes: []string{"id", "arn"}},
+	"aws_efs_file_system":                    {Types: []resource.ResourceType{resource.TypeAWSEFS}, Attributes: []string{"id", "arn"}},
+	"aws_kms_key":                            {Types: []resource.ResourceType{resource.TypeAWSKMSKey}, Attributes: []string{"arn"}},
+	"aws_acm_certificate":                    {Types: []resource.ResourceType{resource.TypeAWSACMCertificate}, Attributes: []string{"arn"}},
+	"aws_route53_zone":                       {Types: []resource.ResourceType{resource.TypeAWSRoute53Zone}, Attributes: []string{"zone_id"}},
+	"aws_cloudtrail":                         {Types: []resource.ResourceType{resource.TypeAWSCloudTrail}, Attributes: []string{"arn"}},
+	"aws_wafv2_web_acl":                      {Types: []resource.ResourceType{resource.TypeAWSWAFWebACL}, Attributes: []string{"arn"}},
+
+	// GitHub
+	"github_repository": {Types: []resource.ResourceType{resource.TypeGitHubRepository}, Attributes: []string{"repo_id"}},
+	"github_team":       {Types: []resource.ResourceType{resource.TypeGitHubTeam}, Attributes: []string{"id"}},
+
+	// GitLab
+	"gitlab_project": {Types: []resource.ResourceType{resource.TypeGitLabProject}, Attributes: []string{"id"}},
+	"gitlab_group":   {Types: []resource.ResourceType{resource.TypeGitLabGroup}, Attributes: []string{"id"}},
+
+	// GCP
+	"google_compute_instance":        {Types: []resource.ResourceType{resource.TypeGCPComputeInstance}, Attributes: []string{"instance_id"}},
+	"google_compute_network":         {Types: []resource.ResourceType{resource.TypeGCPVPC}, Attributes: []string{"numeric_id"}},
+	"google_storage_bucket":          {Types: []resource.ResourceType{resource.TypeGCPStorageBucket}, Attributes: []string{"name"}},
+	"google_cloudfunctions_function": {Types: []resource.ResourceType{resource.TypeGCPCloudFunction}, Attributes: []string{"id"}},
+	"google_cloud_run_v2_service":    {Types: []resource.ResourceType{resource.TypeGCPCloudRun}, Attributes: []string{"id"}},
+
+	// Azure
+	"azurerm_resource_group":          {Types: []resource.ResourceType{resource.TypeAzureResourceGroup}, Attributes: []string{"id"}},
+	"azurerm_linux_virtual_machine":   {Types: []resource.ResourceType{resource.TypeAzureVM}, Attributes: []string{"id"}},
+	"azurerm_windows_virtual_machine": {Types: []resource.ResourceType{resource.TypeAzureVM}, Attributes: []string{"id"}},
+	"azurerm_virtual_network":         {Types: []resource.ResourceType{resource.TypeAzureVNet}, Attributes: []string{"id"}},
+	"azurerm_storage_account":         {Types: []resource.ResourceType{resource.TypeAzureStorageAccount}, Attributes: []string{"id"}},
+	"azurerm_linux_web_app":           {Types: []resource.ResourceType{resource.TypeAzureAppService}, Attributes: []string{"id"}},
+	"azurerm_windows_web_app":         {Types: []resource.ResourceType{resource.TypeAzureAppService}, Attributes: []string{"id"}},
+	"azurerm_mssql_database":          {Types: []resource.ResourceType{resource.TypeAzureSQLDatabase}, Attributes: []string{"id"}},
+	"azurerm_key_vault":               {Types: []resource.ResourceType{resource.TypeAzureKeyVault}, Attributes: []string{"id"}},
+}
+
+// managedTypes returns the set of inventory resource types that can be managed by Terraform
+func managedTypes() map[resource.ResourceType]bool {
+	types := make(map[resource.ResourceType]bool)
+	for _, mapping := range typeMappings {
+		for _, t := range mapping.Types {
+			types[t] = true
+		}
+	}
+	return types
+}
+
+// providerOf returns the inventory provider name for a resource type (e.g., "aws" for "aws:ec2:instance")
+func providerOf(t resource.ResourceType) string {
+	provider, _, _ := strings.Cut(string(t), ":")
+	return provider
+}
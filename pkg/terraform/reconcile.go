@@ -0,0 +1,246 @@
+package terraform
+
+import (
+	"slices"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/comfortablynumb/pmp-cloud-inspector/pkg/resource"
+)
+
+// Property keys set on resources found in a Terraform state
+const (
+	PropertyManagedBy        = "managed_by"
+	PropertyTerraformState   = "terraform_state"
+	PropertyTerraformAddress = "terraform_address"
+
+	// ManagedByTerraform is the value of the managed_by property for Terraform-managed resources
+	ManagedByTerraform = "terraform"
+)
+
+// Report is the result of reconciling an inventory against Terraform state files
+type Report struct {
+	GeneratedAt time.Time           `json:"generated_at"`
+	States      []string            `json:"states"`
+	Summary     ReportSummary       `json:"summary"`
+	Unmanaged   []UnmanagedResource `json:"unmanaged"`
+	Ghosts      []GhostResource     `json:"ghosts"`
+}
+
+// ReportSummary provides summary statistics for a reconciliation
+type ReportSummary struct {
+	TotalResources   int `json:"total_resources"`   // Inventory resources of types Terraform can manage
+	Managed          int `json:"managed"`           // Inventory resources found in a state
+	Unmanaged        int `json:"unmanaged"`         // Inventory resources not found in any state
+	Ghosts           int `json:"ghosts"`            // State entries with no matching inventory resource
+	UnsupportedTypes int `json:"unsupported_types"` // State entries whose Terraform type is not mapped
+}
+
+// UnmanagedResource is an inventory resource not present in any Terraform state
+type UnmanagedResource struct {
+	ID       string                `json:"id"`
+	Type     resource.ResourceType `json:"type"`
+	Name     string                `json:"name"`
+	Provider string                `json:"provider"`
+	Account  string                `json:"account,omitempty"`
+	Region   string                `json:"region,omitempty"`
+}
+
+// GhostResource is a Terraform state entry that no longer exists in the cloud
+type GhostResource struct {
+	Address       string `json:"address"`
+	StateFile     string `json:"state_file"`
+	TerraformType string `json:"terraform_type"`
+	ID            string `json:"id"`
+}
+
+// Scope is what an inventory looked for. State entries outside of it can't be matched, so they
+// are not reported as ghosts.
+type Scope struct {
+	Types   []resource.ResourceType // resource types collected (empty = every type)
+	Regions []string                // regions collected (empty = every region)
+}
+
+// CollectionScope returns the resource types and regions present in a collection, for
+// inventories whose configuration is not known, such as exports
+func CollectionScope(collection *resource.Collection) Scope {
+	var scope Scope
+	types := make(map[resource.ResourceType]bool)
+	regions := make(map[string]bool)
+	for _, res := range collection.Resources {
+		if !types[res.Type] {
+			types[res.Type] = true
+			scope.Types = append(scope.Types, res.Type)
+		}
+		if res.Region != "" && res.Region != "global" && !regions[res.Region] {
+			regions[res.Region] = true
+			scope.Regions = append(scope.Regions, res.Region)
+		}
+	}
+	return scope
+}
+
+// includes reports whether the inventory looked for a state instance of a type mapping: one of
+// the mapped types was collected, in the region of the instance if it is known
+func (s Scope) includes(mapping typeMapping, instance *StateInstance) bool {
+	if len(s.Types) > 0 && !slices.ContainsFunc(mapping.Types, func(t resource.ResourceType) bool {
+		return slices.Contains(s.Types, t)
+	}) {
+		return false
+	}
+
+	if region := instanceRegion(instance); region != "" && len(s.Regions) > 0 {
+		return slices.Contains(s.Regions, region)
+	}
+
+	return true
+}
+
+// instanceRegion returns the region of a state instance: its region attribute, or the region of
+// its ARN. Global resources, such as IAM roles, have no region.
+func instanceRegion(instance *StateInstance) string {
+	if region := instance.Attribute("region"); region != "" {
+		return region
+	}
+	parts := strings.SplitN(instance.Attribute("arn"), ":", 6)
+	if len(parts) == 6 && parts[0] == "arn" {
+		return parts[3]
+	}
+	return ""
+}
+
+// Reconcile matches the resources in the collection against the given Terraform states.
+// Matched resources are annotated with the managed_by, terraform_state and terraform_address
+// properties. The returned report lists unmanaged inventory resources and ghost state entries
+// of the providers inspected, within the scope of resource types and regions collected.
+func Reconcile(collection *resource.Collection, states []*State, scope Scope) *Report {
+	report := &Report{
+		GeneratedAt: time.Now(),
+		States:      make([]string, 0, len(states)),
+		Unmanaged:   make([]UnmanagedResource, 0),
+		Ghosts:      make([]GhostResource, 0),
+	}
+
+	// Index inventory by type and by both ID and ARN
+	index := make(map[resource.ResourceType]map[string]*resource.Resource)
+	providers := make(map[string]bool)
+	for _, res := range collection.Resources {
+		if index[res.Type] == nil {
+			index[res.Type] = make(map[string]*resource.Resource)
+		}
+		index[res.Type][res.ID] = res
+		if res.ARN != "" {
+			index[res.Type][res.ARN] = res
+		}
+		providers[res.Provider] = true
+	}
+
+	managed := make(map[*resource.Resource]bool)
+
+	for _, state := range states {
+		report.States = append(report.States, state.Path)
+
+		for i := range state.Resources {
+			stateRes := &state.Resources[i]
+			if stateRes.Mode != "managed" {
+				continue
+			}
+
+			mapping, ok := typeMappings[stateRes.Type]
+			if !ok {
+				report.Summary.UnsupportedTypes += len(stateRes.Instances)
+				continue
+			}
+
+			for j := range stateRes.Instances {
+				instance := &stateRes.Instances[j]
+				address := stateRes.Address(instance)
+
+				res := matchInstance(index, mapping, instance)
+				if res != nil {
+					if res.Properties == nil {
+						res.Properties = make(map[string]interface{})
+					}
+					res.Properties[PropertyManagedBy] = ManagedByTerraform
+					res.Properties[PropertyTerraformState] = state.Path
+					res.Properties[PropertyTerraformAddress] = address
+					managed[res] = true
+					continue
+				}
+
+				// Only report ghosts for the providers, types and regions actually inspected
+				if !providers[providerOf(mapping.Types[0])] || !scope.includes(mapping, instance) {
+					continue
+				}
+
+				id := ""
+				for _, attr := range mapping.Attributes {
+					if id = instance.Attribute(attr); id != "" {
+						break
+					}
+				}
+
+				report.Ghosts = append(report.Ghosts, GhostResource{
+					Address:       address,
+					StateFile:     state.Path,
+					TerraformType: stateRes.Type,
+					ID:            id,
+				})
+			}
+		}
+	}
+
+	manageable := managedTypes()
+	for _, res := range collection.Resources {
+		if !manageable[res.Type] {
+			continue
+		}
+
+		report.Summary.TotalResources++
+
+		if managed[res] {
+			report.Summary.Managed++
+			continue
+		}
+
+		report.Unmanaged = append(report.Unmanaged, UnmanagedResource{
+			ID:       res.ID,
+			Type:     res.Type,
+			Name:     res.Name,
+			Provider: res.Provider,
+			Account:  res.Account,
+			Region:   res.Region,
+		})
+	}
+
+	sort.Slice(report.Unmanaged, func(i, j int) bool {
+		if report.Unmanaged[i].Type != report.Unmanaged[j].Type {
+			return report.Unmanaged[i].Type < report.Unmanaged[j].Type
+		}
+		return report.Unmanaged[i].ID < report.Unmanaged[j].ID
+	})
+
+	report.Summary.Unmanaged = len(report.Unmanaged)
+	report.Summary.Ghosts = len(report.Ghosts)
+
+	return report
+}
+
+// matchInstance finds the inventory resource corresponding to a state instance
+func matchInstance(index map[resource.ResourceType]map[string]*resource.Resource, mapping typeMapping, instance *StateInstance) *resource.Resource {
+	for _, attr := range mapping.Attributes {
+		value := instance.Attribute(attr)
+		if value == "" {
+			continue
+		}
+
+		for _, t := range mapping.Types {
+			if res, ok := index[t][value]; ok {
+				return res
+			}
+		}
+	}
+
+	return nil
+}
@@ -0,0 +1,268 @@
+package terraform
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/comfortablynumb/pmp-cloud-inspector/pkg/resource"
+)
+
+func TestLoadState(t *testing.T) {
+	state, err := LoadState(filepath.Join("testdata", "aws.tfstate"))
+	if err != nil {
+		t.Fatalf("LoadState failed: %v", err)
+	}
+
+	if state.Version != 4 || state.Serial != 12 || state.TerraformVersion != "1.7.5" {
+		t.Errorf("unexpected state header: version %d, serial %d, terraform %s", state.Version, state.Serial, state.TerraformVersion)
+	}
+	if len(state.Resources) != 6 {
+		t.Fatalf("expected 6 resource blocks, got %d", len(state.Resources))
+	}
+
+	var addresses []string
+	for i := range state.Resources {
+		for j := range state.Resources[i].Instances {
+			addresses = append(addresses, state.Resources[i].Address(&state.Resources[i].Instances[j]))
+		}
+	}
+	want := []string{
+		"module.network.aws_vpc.main",
+		"aws_instance.web[0]",
+		"aws_instance.web[1]",
+		`module.apps["api"].aws_eks_cluster.this["primary"]`,
+		"data.aws_instance.bastion",
+		"random_id.suffix",
+		"github_repository.infra",
+	}
+	if len(addresses) != len(want) {
+		t.Fatalf("got addresses %v, want %v", addresses, want)
+	}
+	for i := range want {
+		if addresses[i] != want[i] {
+			t.Errorf("address %d: got %s, want %s", i, addresses[i], want[i])
+		}
+	}
+
+	// Numeric attributes are rendered without decimals
+	if id := state.Resources[5].Instances[0].Attribute("repo_id"); id != "123456" {
+		t.Errorf("expected repo_id 123456, got %s", id)
+	}
+}
+
+func TestLoadStateErrors(t *testing.T) {
+	dir := t.TempDir()
+
+	tests := []struct {
+		name    string
+		content string
+	}{
+		{"unsupported version", `{"version": 3, "resources": []}`},
+		{"invalid json", `{"version": 4,`},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			path := filepath.Join(dir, tt.name+".tfstate")
+			if err := os.WriteFile(path, []byte(tt.content), 0o600); err != nil {
+				t.Fatal(err)
+			}
+			if _, err := LoadState(path); err == nil {
+				t.Errorf("LoadState succeeded, want an error")
+			}
+		})
+	}
+}
+
+func TestLoadStatesWalksDirectories(t *testing.T) {
+	dir := t.TempDir()
+	fixture, err := os.ReadFile(filepath.Join("testdata", "aws.tfstate"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	for _, name := range []string{"b/prod.tfstate", "a/dev.tfstate", "a/notes.txt"} {
+		path := filepath.Join(dir, name)
+		if err := os.MkdirAll(filepath.Dir(path), 0o750); err != nil {
+			t.Fatal(err)
+		}
+		if err := os.WriteFile(path, fixture, 0o600); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	states, err := LoadStates([]string{dir})
+	if err != nil {
+		t.Fatalf("LoadStates failed: %v", err)
+	}
+	if len(states) != 2 {
+		t.Fatalf("expected 2 states, got %d", len(states))
+	}
+	if states[0].Path != filepath.Join(dir, "a/dev.tfstate") || states[1].Path != filepath.Join(dir, "b/prod.tfstate") {
+		t.Errorf("expected states sorted by path, got %s and %s", states[0].Path, states[1].Path)
+	}
+}
+
+func TestReconcile(t *testing.T) {
+	state, err := LoadState(filepath.Join("testdata", "aws.tfstate"))
+	if err != nil {
+		t.Fatalf("LoadState failed: %v", err)
+	}
+
+	vpc := &resource.Resource{ID: "vpc-0a1b2c3d", Type: resource.TypeAWSVPC, Provider: "aws"}
+	managedInstance := &resource.Resource{ID: "i-0000000000000001", Type: resource.TypeAWSEC2Instance, Provider: "aws"}
+	unmanagedInstance := &resource.Resource{ID: "i-0000000000000003", Type: resource.TypeAWSEC2Instance, Name: "manual", Provider: "aws", Region: "us-east-1"}
+	// Matched by ARN: the name in the state no longer matches the cluster ID
+	cluster := &resource.Resource{ID: "api", Type: resource.TypeAWSEKSCluster, Provider: "aws", ARN: "arn:aws:eks:us-east-1:123456789012:cluster/api"}
+	// Not a type Terraform state entries are mapped to, so neither managed nor unmanaged
+	unmapped := &resource.Resource{ID: "unmapped-1", Type: resource.ResourceType("aws:test:unmapped"), Provider: "aws"}
+
+	collection := resource.NewCollection()
+	for _, res := range []*resource.Resource{vpc, managedInstance, unmanagedInstance, cluster, unmapped} {
+		collection.Add(res)
+	}
+
+	report := Reconcile(collection, []*State{state}, Scope{})
+
+	want := ReportSummary{TotalResources: 4, Managed: 3, Unmanaged: 1, Ghosts: 1, UnsupportedTypes: 1}
+	if report.Summary != want {
+		t.Errorf("got summary %+v, want %+v", report.Summary, want)
+	}
+
+	// The ghost is the second counted instance; the GitHub repository is not reported as a
+	// ghost, as GitHub was not inspected
+	if len(report.Ghosts) != 1 || report.Ghosts[0].Address != "aws_instance.web[1]" || report.Ghosts[0].ID != "i-0000000000000002" {
+		t.Errorf("unexpected ghosts: %+v", report.Ghosts)
+	}
+	if len(report.Unmanaged) != 1 || report.Unmanaged[0].ID != unmanagedInstance.ID {
+		t.Errorf("unexpected unmanaged resources: %+v", report.Unmanaged)
+	}
+
+	annotated := map[*resource.Resource]string{
+		vpc:             "module.network.aws_vpc.main",
+		managedInstance: "aws_instance.web[0]",
+		cluster:         `module.apps["api"].aws_eks_cluster.this["primary"]`,
+	}
+	for res, address := range annotated {
+		if got, _ := res.StringProperty(PropertyTerraformAddress); got != address {
+			t.Errorf("%s: got terraform_address %q, want %q", res.ID, got, address)
+		}
+		if got, _ := res.StringProperty(PropertyManagedBy); got != ManagedByTerraform {
+			t.Errorf("%s: got managed_by %q, want %q", res.ID, got, ManagedByTerraform)
+		}
+		if got, _ := res.StringProperty(PropertyTerraformState); got != state.Path {
+			t.Errorf("%s: got terraform_state %q, want %q", res.ID, got, state.Path)
+		}
+	}
+	if _, ok := unmanagedInstance.Properties[PropertyManagedBy]; ok {
+		t.Errorf("unmanaged instance was annotated as managed")
+	}
+}
+
+func TestReconcileGhostsOfInspectedProvidersOnly(t *testing.T) {
+	state, err := LoadState(filepath.Join("testdata", "aws.tfstate"))
+	if err != nil {
+		t.Fatalf("LoadState failed: %v", err)
+	}
+
+	// Only GitHub was inspected: the AWS state entries are not ghosts, the repository is
+	collection := resource.NewCollection()
+	collection.Add(&resource.Resource{ID: "999", Type: resource.TypeGitHubRepository, Provider: "github"})
+
+	report := Reconcile(collection, []*State{state}, Scope{})
+
+	want := ReportSummary{TotalResources: 1, Managed: 0, Unmanaged: 1, Ghosts: 1, UnsupportedTypes: 1}
+	if report.Summary != want {
+		t.Errorf("got summary %+v, want %+v", report.Summary, want)
+	}
+	if len(report.Ghosts) != 1 || report.Ghosts[0].Address != "github_repository.infra" || report.Ghosts[0].ID != "123456" {
+		t.Errorf("unexpected ghosts: %+v", report.Ghosts)
+	}
+}
+
+func TestReconcileGhostsWithinScope(t *testing.T) {
+	state, err := LoadState(filepath.Join("testdata", "aws.tfstate"))
+	if err != nil {
+		t.Fatalf("LoadState failed: %v", err)
+	}
+
+	vpc := func() *resource.Resource {
+		return &resource.Resource{ID: "vpc-0a1b2c3d", Type: resource.TypeAWSVPC, Provider: "aws", Region: "us-east-1"}
+	}
+	instance := func() *resource.Resource {
+		return &resource.Resource{ID: "i-0000000000000001", Type: resource.TypeAWSEC2Instance, Provider: "aws", Region: "us-east-1"}
+	}
+
+	tests := []struct {
+		name       string
+		resources  []*resource.Resource
+		scope      func(*resource.Collection) Scope
+		wantGhosts []string
+	}{
+		{
+			name:       "every type and region",
+			resources:  []*resource.Resource{vpc(), instance()},
+			scope:      func(*resource.Collection) Scope { return Scope{} },
+			wantGhosts: []string{"aws_instance.web[1]", `module.apps["api"].aws_eks_cluster.this["primary"]`},
+		},
+		{
+			name:       "partial type list",
+			resources:  []*resource.Resource{vpc()},
+			scope:      func(*resource.Collection) Scope { return Scope{Types: []resource.ResourceType{resource.TypeAWSVPC}} },
+			wantGhosts: nil,
+		},
+		{
+			name:      "type list with instances",
+			resources: []*resource.Resource{vpc(), instance()},
+			scope: func(*resource.Collection) Scope {
+				return Scope{Types: []resource.ResourceType{resource.TypeAWSVPC, resource.TypeAWSEC2Instance}}
+			},
+			wantGhosts: []string{"aws_instance.web[1]"},
+		},
+		{
+			name:       "other region",
+			resources:  []*resource.Resource{vpc(), instance()},
+			scope:      func(*resource.Collection) Scope { return Scope{Regions: []string{"eu-west-1"}} },
+			wantGhosts: nil,
+		},
+		{
+			name:       "collection scope without instances",
+			resources:  []*resource.Resource{vpc()},
+			scope:      CollectionScope,
+			wantGhosts: nil,
+		},
+		{
+			name:       "collection scope with instances",
+			resources:  []*resource.Resource{vpc(), instance()},
+			scope:      CollectionScope,
+			wantGhosts: []string{"aws_instance.web[1]"},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			collection := resource.NewCollection()
+			for _, res := range tt.resources {
+				collection.Add(res)
+			}
+
+			report := Reconcile(collection, []*State{state}, tt.scope(collection))
+
+			var ghosts []string
+			for _, ghost := range report.Ghosts {
+				ghosts = append(ghosts, ghost.Address)
+			}
+			if len(ghosts) != len(tt.wantGhosts) {
+				t.Fatalf("got ghosts %v, want %v", ghosts, tt.wantGhosts)
+			}
+			for i := range ghosts {
+				if ghosts[i] != tt.wantGhosts[i] {
+					t.Errorf("ghost %d: got %s, want %s", i, ghosts[i], tt.wantGhosts[i])
+				}
+			}
+			if report.Summary.Ghosts != len(tt.wantGhosts) {
+				t.Errorf("got %d ghosts in the summary, want %d", report.Summary.Ghosts, len(tt.wantGhosts))
+			}
+		})
+	}
+}
@@ -0,0 +1,145 @@
+package terraform
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+)
+
+// State represents a Terraform state file (format version 4)
+type State struct {
+	Version          int             `json:"version"`
+	TerraformVersion string          `json:"terraform_version"`
+	Serial           int64           `json:"serial"`
+	Lineage          string          `json:"lineage"`
+	Resources        []StateResource `json:"resources"`
+
+	// Path is the file the state was loaded from
+	Path string `json:"-"`
+}
+
+// StateResource represents a resource block in a Terraform state file
+type StateResource struct {
+	Module    string          `json:"module,omitempty"`
+	Mode      string          `json:"mode"` // managed or data
+	Type      string          `json:"type"`
+	Name      string          `json:"name"`
+	Provider  string          `json:"provider"`
+	Instances []StateInstance `json:"instances"`
+}
+
+// StateInstance represents a single instance of a resource block (count/for_each)
+type StateInstance struct {
+	IndexKey      interface{}            `json:"index_key,omitempty"`
+	SchemaVersion int                    `json:"schema_version"`
+	Attributes    map[string]interface{} `json:"attributes"`
+}
+
+// Address returns the Terraform address of the instance (e.g., module.net.aws_vpc.main[0])
+func (r *StateResource) Address(instance *StateInstance) string {
+	address := fmt.Sprintf("%s.%s", r.Type, r.Name)
+	if r.Mode == "data" {
+		address = "data." + address
+	}
+	if r.Module != "" {
+		address = r.Module + "." + address
+	}
+
+	switch key := instance.IndexKey.(type) {
+	case nil:
+	case float64:
+		address += fmt.Sprintf("[%d]", int64(key))
+	case string:
+		address += fmt.Sprintf("[%q]", key)
+	default:
+		address += fmt.Sprintf("[%v]", key)
+	}
+
+	return address
+}
+
+// Attribute returns an attribute of the instance as a string
+func (i *StateInstance) Attribute(name string) string {
+	value, ok := i.Attributes[name]
+	if !ok || value == nil {
+		return ""
+	}
+
+	switch v := value.(type) {
+	case string:
+		return v
+	case float64:
+		return fmt.Sprintf("%.0f", v)
+	default:
+		return fmt.Sprintf("%v", v)
+	}
+}
+
+// LoadState loads a single Terraform state file
+func LoadState(path string) (*State, error) {
+	// #nosec G304 - path is provided by user as CLI argument, this is expected behavior
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read state file: %w", err)
+	}
+
+	var state State
+	if err := json.Unmarshal(data, &state); err != nil {
+		return nil, fmt.Errorf("failed to parse state file %s: %w", path, err)
+	}
+
+	if state.Version != 4 {
+		return nil, fmt.Errorf("unsupported state version %d in %s (only version 4 is supported)", state.Version, path)
+	}
+
+	state.Path = path
+
+	return &state, nil
+}
+
+// LoadStates loads Terraform state files from a list of files and/or directories.
+// Directories are walked recursively and every *.tfstate file found is loaded.
+func LoadStates(paths []string) ([]*State, error) {
+	var files []string
+
+	for _, path := range paths {
+		info, err := os.Stat(path)
+		if err != nil {
+			return nil, fmt.Errorf("failed to stat %s: %w", path, err)
+		}
+
+		if !info.IsDir() {
+			files = append(files, path)
+			continue
+		}
+
+		walkErr := filepath.WalkDir(path, func(p string, d os.DirEntry, err error) error {
+			if err != nil {
+				return err
+			}
+			if !d.IsDir() && strings.HasSuffix(d.Name(), ".tfstate") {
+				files = append(files, p)
+			}
+			return nil
+		})
+		if walkErr != nil {
+			return nil, fmt.Errorf("failed to walk directory %s: %w", path, walkErr)
+		}
+	}
+
+	sort.Strings(files)
+
+	states := make([]*State, 0, len(files))
+	for _, file := range files {
+		state, err := LoadState(file)
+		if err != nil {
+			return nil, err
+		}
+		states = append(states, state)
+	}
+
+	return states, nil
+}
@@ -7,11 +7,17 @@ import (
 	"html/template"
 	"io"
 	"net/http"
+	"strconv"
 	"strings"
+	"sync"
 	"time"
 
 	"gopkg.in/yaml.v3"
 
+	"github.com/comfortablynumb/pmp-cloud-inspector/pkg/config"
+	"github.com/comfortablynumb/pmp-cloud-inspector/pkg/cost"
+	"github.com/comfortablynumb/pmp-cloud-inspector/pkg/filter"
+	"github.com/comfortablynumb/pmp-cloud-inspector/pkg/policy"
 	"github.com/comfortablynumb/pmp-cloud-inspector/pkg/resource"
 )
 
@@ -25,23 +31,45 @@ var staticFS embed.FS
 type Server struct {
 	port      int
 	templates *template.Template
+	config    *config.Config // optional, provides filter set presets
+
+	currency string              // default currency costs are converted to (empty = as exported)
+	rates    *cost.ExchangeRates // exchange rates used to convert costs, may be nil
+
+	recommenderOnce sync.Once
+	recommender     *cost.Recommender // created on first use, since it loads the pricing catalogs
+	recommenderErr  error
+
+	policyOnce   sync.Once
+	policyEngine *policy.Engine // created on first use, since it loads the rule files
+	policyErr    error
 }
 
-// NewServer creates a new UI server
-func NewServer(port int) *Server {
+// NewServer creates a new UI server. The config is optional and may be nil.
+func NewServer(port int, cfg *config.Config) *Server {
 	tmpl := template.Must(template.ParseFS(templatesFS, "templates/*.html"))
 	return &Server{
 		port:      port,
 		templates: tmpl,
+		config:    cfg,
 	}
 }
 
+// SetCurrency sets the default currency costs are converted to and the exchange rates used
+// to convert them. Both are optional.
+func (s *Server) SetCurrency(currency string, rates *cost.ExchangeRates) {
+	s.currency = strings.ToUpper(currency)
+	s.rates = rates
+}
+
 // Start starts the web server
 func (s *Server) Start() error {
 	http.HandleFunc("/", s.handleIndex)
 	http.HandleFunc("/upload", s.handleUpload)
 	http.HandleFunc("/compare", s.handleCompare)
 	http.HandleFunc("/api/stats", s.handleStats)
+	http.HandleFunc("/api/filter-sets", s.handleFilterSets)
+	http.HandleFunc("/api/currencies", s.handleCurrencies)
 
 	// Serve static files
 	staticFileServer := http.FileServer(http.FS(staticFS))
@@ -73,6 +101,10 @@ type uploadResponse struct {
 	Error     string                      `json:"error,omitempty"`
 	Resources []*resource.Resource        `json:"resources,omitempty"`
 	Metadata  resource.CollectionMetadata `json:"metadata,omitempty"`
+
+	Allocation      *cost.AllocationReport     `json:"allocation,omitempty"` // cost allocation, if allocation tags are set
+	Recommendations *cost.RecommendationReport `json:"recommendations,omitempty"`
+	Audit           *policy.Report             `json:"audit,omitempty"` // findings of the configured compliance rules
 }
 
 // handleUpload handles file uploads
@@ -124,17 +156,175 @@ func (s *Server) handleUpload(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
+	// Apply filter set and expression if provided
+	filtered, err := s.applyFilters(&collection, r.FormValue("filter_set"), r.FormValue("where"))
+	if err != nil {
+		s.sendError(w, err.Error())
+		return
+	}
+	collection = *filtered
+
+	// Convert costs to the requested or default currency
+	if err := s.convertCosts(&collection, r.FormValue("currency")); err != nil {
+		s.sendError(w, err.Error())
+		return
+	}
+
+	// Allocate costs by the requested or configured allocation tags
+	var allocation *cost.AllocationReport
+	if tagKeys := s.allocationTags(r.FormValue("allocation_tags")); len(tagKeys) > 0 && collection.Metadata.TotalCost != nil {
+		allocation = cost.Allocate(&collection, tagKeys)
+	}
+
+	// Find savings with the configured recommendation rules
+	recommendations, err := s.recommend(&collection)
+	if err != nil {
+		s.sendError(w, err.Error())
+		return
+	}
+
+	// Evaluate the built-in and configured compliance rules
+	audit, err := s.audit(&collection)
+	if err != nil {
+		s.sendError(w, err.Error())
+		return
+	}
+
 	// Send response
 	w.Header().Set("Content-Type", "application/json")
 	if err := json.NewEncoder(w).Encode(uploadResponse{
-		Success:   true,
-		Resources: collection.Resources,
-		Metadata:  collection.Metadata,
+		Success:         true,
+		Resources:       collection.Resources,
+		Metadata:        collection.Metadata,
+		Allocation:      allocation,
+		Recommendations: recommendations,
+		Audit:           audit,
 	}); err != nil {
 		http.Error(w, err.Error(), http.StatusInternalServerError)
 	}
 }
 
+// allocationTags returns the allocation tag keys given as a comma-separated list, or the
+// configured ones if the list is empty
+func (s *Server) allocationTags(list string) []string {
+	var tagKeys []string
+	for _, key := range strings.Split(list, ",") {
+		if key = strings.TrimSpace(key); key != "" {
+			tagKeys = append(tagKeys, key)
+		}
+	}
+
+	if len(tagKeys) == 0 && s.config != nil {
+		tagKeys = s.config.Cost.AllocationTags
+	}
+
+	return tagKeys
+}
+
+// recommend returns the savings recommendations for a collection, creating the recommender
+// with the configured catalogs, usage assumptions and rules on first use
+func (s *Server) recommend(collection *resource.Collection) (*cost.RecommendationReport, error) {
+	s.recommenderOnce.Do(func() {
+		var costConfig config.CostConfig
+		if s.config != nil {
+			costConfig = s.config.Cost
+		}
+
+		catalogs, err := cost.LoadCatalogs(costConfig.Catalogs)
+		if err != nil {
+			s.recommenderErr = fmt.Errorf("failed to load pricing catalogs: %w", err)
+			return
+		}
+		s.recommender = cost.NewRecommender(catalogs, costConfig.Assumptions, costConfig.Recommendations)
+		s.recommender.SetExchangeRates(s.rates)
+	})
+	if s.recommenderErr != nil {
+		return nil, s.recommenderErr
+	}
+
+	return s.recommender.Recommend(collection)
+}
+
+// audit evaluates the compliance rules of the policy settings over a collection, creating the
+// policy engine on first use
+func (s *Server) audit(collection *resource.Collection) (*policy.Report, error) {
+	s.policyOnce.Do(func() {
+		var settings policy.Settings
+		if s.config != nil {
+			settings = s.config.Policy
+		}
+
+		s.policyEngine, s.policyErr = policy.NewEngine(settings)
+		if s.policyErr != nil {
+			s.policyErr = fmt.Errorf("failed to load policy rules: %w", s.policyErr)
+		}
+	})
+	if s.policyErr != nil {
+		return nil, s.policyErr
+	}
+
+	return s.policyEngine.Evaluate(collection), nil
+}
+
+// convertCosts converts the cost estimates of a collection to the requested currency, or to the
+// default currency if none is requested
+func (s *Server) convertCosts(collection *resource.Collection, currency string) error {
+	if currency = strings.ToUpper(strings.TrimSpace(currency)); currency == "" {
+		currency = s.currency
+	}
+	if currency == "" {
+		return nil
+	}
+	if s.rates == nil {
+		if currency == cost.DefaultCurrency {
+			// Estimates are priced in the default currency, there is nothing to convert
+			return nil
+		}
+		return fmt.Errorf("no exchange rates to convert costs to %s (start the UI with --exchange-rates or a config with cost.rates)", currency)
+	}
+
+	return cost.ConvertCollection(collection, currency, s.rates)
+}
+
+// currenciesResponse describes the currencies costs can be converted to
+type currenciesResponse struct {
+	Default    string   `json:"default,omitempty"`
+	Currencies []string `json:"currencies"`
+	Version    string   `json:"version,omitempty"`
+}
+
+// handleCurrencies returns the currencies costs can be converted to
+func (s *Server) handleCurrencies(w http.ResponseWriter, r *http.Request) {
+	response := currenciesResponse{Default: s.currency, Currencies: make([]string, 0)}
+	if s.rates != nil {
+		response.Currencies = s.rates.Currencies()
+		response.Version = s.rates.Version
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	if err := json.NewEncoder(w).Encode(response); err != nil {
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+	}
+}
+
+// costThreshold returns the cost change threshold given as a percentage, or the configured one
+// if it is empty
+func (s *Server) costThreshold(value string) (float64, error) {
+	if value = strings.TrimSpace(value); value != "" {
+		threshold, err := strconv.ParseFloat(value, 64)
+		if err != nil {
+			return 0, fmt.Errorf("invalid cost threshold '%s'", value)
+		}
+		return threshold, nil
+	}
+
+	if s.config != nil {
+		return s.config.Cost.DeltaThreshold, nil
+	}
+
+	return 0, nil
+}
+
 // handleStats returns statistics for the loaded data
 func (s *Server) handleStats(w http.ResponseWriter, r *http.Request) {
 	// This is a placeholder - stats are calculated on the client side
@@ -166,6 +356,7 @@ type compareResponse struct {
 	Modified         []resourceDiff       `json:"modified,omitempty"`
 	Unchanged        []*resource.Resource `json:"unchanged,omitempty"`
 	Summary          driftSummary         `json:"summary,omitempty"`
+	CostDelta        *cost.DeltaReport    `json:"cost_delta,omitempty"` // cost change, if either export has cost estimates
 }
 
 // resourceDiff represents changes to a resource
@@ -240,9 +431,41 @@ func (s *Server) handleCompare(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
+	// Apply filter set and expression to both exports if provided
+	filterSet, where := r.FormValue("filter_set"), r.FormValue("where")
+	if baseCollection, err = s.applyFilters(baseCollection, filterSet, where); err != nil {
+		s.sendCompareError(w, err.Error())
+		return
+	}
+	if compareCollection, err = s.applyFilters(compareCollection, filterSet, where); err != nil {
+		s.sendCompareError(w, err.Error())
+		return
+	}
+
+	// Convert both exports to the same currency
+	for _, collection := range []*resource.Collection{baseCollection, compareCollection} {
+		if err := s.convertCosts(collection, r.FormValue("currency")); err != nil {
+			s.sendCompareError(w, err.Error())
+			return
+		}
+	}
+
 	// Generate drift report
 	report := s.generateDriftReport(baseCollection, compareCollection)
 
+	// Add the cost change if either export has cost estimates
+	if baseCollection.Metadata.TotalCost != nil || compareCollection.Metadata.TotalCost != nil {
+		threshold, err := s.costThreshold(r.FormValue("cost_threshold"))
+		if err != nil {
+			s.sendCompareError(w, err.Error())
+			return
+		}
+		if report.CostDelta, err = cost.Delta(baseCollection, compareCollection, cost.DeltaOptions{ThresholdPercent: threshold}); err != nil {
+			s.sendCompareError(w, err.Error()+" (select a currency)")
+			return
+		}
+	}
+
 	// Send response
 	w.Header().Set("Content-Type", "application/json")
 	if err := json.NewEncoder(w).Encode(report); err != nil {
@@ -275,6 +498,58 @@ func (s *Server) parseFile(file io.Reader, filename string) (*resource.Collectio
 	return &collection, nil
 }
 
+// applyFilters filters a collection with a configured filter set and a filter expression.
+// Both are optional; the collection is returned unchanged if neither is set.
+func (s *Server) applyFilters(collection *resource.Collection, filterSet, where string) (*resource.Collection, error) {
+	var filters []filter.Filter
+
+	if filterSet != "" {
+		if s.config == nil {
+			return nil, fmt.Errorf("filter set '%s' requested but no config was loaded (start the UI with --config)", filterSet)
+		}
+		setFilters, err := s.config.BuildFilterSet(filterSet)
+		if err != nil {
+			return nil, err
+		}
+		filters = append(filters, setFilters...)
+	}
+
+	if strings.TrimSpace(where) != "" {
+		f, err := filter.ParseExpression(where)
+		if err != nil {
+			return nil, fmt.Errorf("invalid filter expression: %w", err)
+		}
+		filters = append(filters, f)
+	}
+
+	return filter.ApplyFilters(collection, filters...), nil
+}
+
+// filterSetInfo describes a configured filter set for the UI presets
+type filterSetInfo struct {
+	Name        string `json:"name"`
+	Description string `json:"description,omitempty"`
+}
+
+// handleFilterSets returns the filter sets available as presets
+func (s *Server) handleFilterSets(w http.ResponseWriter, r *http.Request) {
+	sets := make([]filterSetInfo, 0)
+	if s.config != nil {
+		for _, name := range s.config.FilterSetNames() {
+			info := filterSetInfo{Name: name}
+			if set := s.config.Filters[name]; set != nil {
+				info.Description = set.Description
+			}
+			sets = append(sets, info)
+		}
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	if err := json.NewEncoder(w).Encode(sets); err != nil {
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+	}
+}
+
 // generateDriftReport generates a drift report between two collections
 func (s *Server) generateDriftReport(base, compare *resource.Collection) compareResponse {
 	report := compareResponse{